@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+
+	"lxc-dev-manager/internal/operations"
+
+	"github.com/spf13/cobra"
+)
+
+var attachCmd = &cobra.Command{
+	Use:   "attach <name> [session]",
+	Short: "Attach to a zellij or tmux session in a container",
+	Long: `Attach to a named terminal multiplexer session in a container, creating
+it if needed, so long-running work survives the terminal disconnecting.
+Detach with the multiplexer's key (zellij: Ctrl+o d, tmux: Ctrl+b d) and
+run attach again to pick up where you left off.
+
+Uses zellij, or tmux if only tmux is installed. When neither is, zellij is
+installed from its GitHub releases; --multiplexer tmux installs tmux from
+the distribution's packages instead.
+
+The session defaults to 'main' and runs as the configured user.
+
+Examples:
+  lxc-dev-manager attach dev1                      # Session 'main'
+  lxc-dev-manager attach dev1 api                  # Session 'api'
+  lxc-dev-manager attach dev1 --multiplexer tmux
+  lxc-dev-manager attach dev1 -u root`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runAttach,
+}
+
+var (
+	attachMultiplexer string
+	attachUser        string
+)
+
+func init() {
+	rootCmd.AddCommand(attachCmd)
+	attachCmd.Flags().StringVarP(&attachMultiplexer, "multiplexer", "m", "", "zellij or tmux (default: the one installed, else zellij)")
+	attachCmd.Flags().StringVarP(&attachUser, "user", "u", "", "Run the session as user (default: configured user)")
+}
+
+func runAttach(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	session := operations.DefaultSession
+	if len(args) > 1 {
+		session = args[1]
+	}
+
+	cfg, _, err := requireRunningContainer(&name)
+	if err != nil {
+		return err
+	}
+
+	return operations.Attach(cfg, name, operations.AttachOpts{
+		Session:     session,
+		Multiplexer: attachMultiplexer,
+		User:        attachUser,
+	}, func(step string) {
+		fmt.Printf("%s...\n", step)
+	})
+}
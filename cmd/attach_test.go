@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/lxc"
+	"lxc-dev-manager/internal/operations"
+)
+
+func TestAttach_NotRunning(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+	env.setContainerExists("dev1", false)
+
+	err := runAttach(nil, []string{"dev1"})
+	if err == nil || !strings.Contains(err.Error(), "not running") {
+		t.Errorf("expected not running error, got %v", err)
+	}
+}
+
+func TestAttachArgs(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+	env.setContainerExists("dev1", true)
+	t.Cleanup(lxc.InvalidateCache)
+	env.mock.SetOutput("exec dev1 -- sh -c command -v zellij", "tmux\n")
+	env.mock.SetOutput("exec dev1 -- sh -c if su", "su\n")
+	env.mock.SetOutput("exec dev1 -- sh -c set -e", "")
+
+	cfg, err := config.Load(env.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The installed multiplexer is used, as the configured user
+	args, err := operations.AttachArgs(cfg, "dev1", operations.AttachOpts{}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{"exec", "dev1", "--", "su", "-l", "dev", "-c", "tmux new-session -A -s main"}
+	if !slices.Equal(args, expected) {
+		t.Errorf("expected %v, got %v", expected, args)
+	}
+
+	args, err = operations.AttachArgs(cfg, "dev1", operations.AttachOpts{Session: "api", Multiplexer: "zellij"}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if args[len(args)-1] != "zellij attach --create api" {
+		t.Errorf("expected a zellij session, got %v", args)
+	}
+	if !env.mock.HasCallPrefix("exec", "dev1", "--", "sh", "-c", "set -e\ncommand -v zellij") {
+		t.Error("expected zellij to be installed when missing")
+	}
+}
+
+func TestAttachArgs_Invalid(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+	env.setContainerExists("dev1", true)
+
+	cfg, err := config.Load(env.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := operations.AttachArgs(cfg, "dev1", operations.AttachOpts{Session: "a b"}, nil); err == nil {
+		t.Error("expected an invalid session name error")
+	}
+	if _, err := operations.AttachArgs(cfg, "dev1", operations.AttachOpts{Multiplexer: "screen"}, nil); err == nil {
+		t.Error("expected an unknown multiplexer error")
+	}
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"lxc-dev-manager/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+// auditedCommands change containers, images, files or the config and are
+// recorded in the project's audit log. Read-only commands are not.
+var auditedCommands = map[*cobra.Command]bool{
+	createCmd:                  true,
+	projectCreateCmd:           true,
+	projectDeleteCmd:           true,
+	projectRenameCmd:           true,
+	containerCreateCmd:         true,
+	containerResetCmd:          true,
+	containerRebaseCmd:         true,
+	containerCloneCmd:          true,
+	containerResizeCmd:         true,
+	containerMoveCmd:           true,
+	containerSetDescriptionCmd: true,
+	containerSetNestingCmd:     true,
+	containerSetCmd:            true,
+	containerDockerSetupCmd:    true,
+	containerSnapshotCreateCmd: true,
+	containerSnapshotDeleteCmd: true,
+	removeCmd:                  true,
+	upCmd:                      true,
+	downCmd:                    true,
+	mountCmd:                   true,
+	unmountCmd:                 true,
+	syncCmd:                    true,
+	syncAddCmd:                 true,
+	syncRmCmd:                  true,
+	mvCmd:                      true,
+	fileRmCmd:                  true,
+	fileEditCmd:                true,
+	deviceAddCmd:               true,
+	deviceRemoveCmd:            true,
+	dotfilesApplyCmd:           true,
+	imageCreateCmd:             true,
+	imageBuildCmd:              true,
+	imageExportCmd:             true,
+	imageImportCmd:             true,
+	imagePushCmd:               true,
+	imagePruneCmd:              true,
+	imageDeleteCmd:             true,
+	imageRenameCmd:             true,
+	volumeCreateCmd:            true,
+	volumeAttachCmd:            true,
+	volumeDetachCmd:            true,
+	volumeDeleteCmd:            true,
+	dnsSyncCmd:                 true,
+	dnsClearCmd:                true,
+	configSetCmd:               true,
+	configEditCmd:              true,
+	configUndoCmd:              true,
+	uiCmd:                      true,
+	devcontainerExportCmd:      true,
+	devcontainerImportCmd:      true,
+	importComposeCmd:           true,
+	codeCmd:                    true,
+}
+
+// recordAudit appends an audit entry for cmd if it's a mutating command run
+// inside a project. args is the full command line after the program name;
+// runErr is the command's result. Failing to write the log only warns.
+func recordAudit(cmd *cobra.Command, args []string, runErr error) {
+	if cmd == nil || !auditedCommands[cmd] {
+		return
+	}
+
+	dir := projectDir
+	if dir == "" {
+		dir = "."
+	}
+	// Outside a project there's nowhere to keep the log. A deleted project
+	// still has its audit directory.
+	if _, err := os.Stat(filepath.Join(dir, config.ConfigFile)); err != nil {
+		if _, err := os.Stat(filepath.Join(dir, filepath.Dir(config.AuditFile))); err != nil {
+			return
+		}
+	}
+
+	entry := config.AuditEntry{
+		Command: strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" "),
+		Args:    redactArgs(args),
+	}
+	if runErr != nil {
+		entry.Error = runErr.Error()
+	}
+	if err := config.AppendAudit(dir, entry); err != nil {
+		slog.Warn("failed to write audit log", "error", err)
+	}
+}
+
+// redactArgs hides passwords given with --password or to config set, so
+// they don't end up in the audit log
+func redactArgs(args []string) []string {
+	out := make([]string, len(args))
+	copy(out, args)
+	for i, arg := range out {
+		switch {
+		case strings.HasPrefix(arg, "--password="):
+			out[i] = "--password=<redacted>"
+		case arg == "--password" && i+1 < len(out):
+			out[i+1] = "<redacted>"
+		case arg == "set" && i+2 < len(out) && strings.HasSuffix(strings.ToLower(out[i+1]), "password"):
+			out[i+2] = "<redacted>"
+		}
+	}
+	return out
+}
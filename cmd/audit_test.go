@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"lxc-dev-manager/internal/config"
+)
+
+func TestRecordAudit(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev3", "ubuntu:24.04")
+
+	oldDir := projectDir
+	projectDir = env.dir
+	defer func() { projectDir = oldDir }()
+
+	recordAudit(removeCmd, []string{"remove", "dev3", "--force"}, nil)
+	recordAudit(listCmd, []string{"list"}, nil)
+	recordAudit(containerResetCmd, []string{"c", "reset", "dev3", "missing"}, errors.New("snapshot 'missing' does not exist"))
+
+	entries, err := config.ReadAudit(env.dir)
+	if err != nil {
+		t.Fatalf("ReadAudit failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries (list is not audited), got %d: %+v", len(entries), entries)
+	}
+	if entries[0].Command != "remove" || entries[0].Error != "" {
+		t.Errorf("unexpected first entry: %+v", entries[0])
+	}
+	if entries[1].Command != "container reset" || entries[1].Error == "" {
+		t.Errorf("expected failed container reset to be recorded by its full name: %+v", entries[1])
+	}
+}
+
+func TestRecordAudit_OutsideProject(t *testing.T) {
+	dir := t.TempDir()
+	oldDir := projectDir
+	projectDir = dir
+	defer func() { projectDir = oldDir }()
+
+	recordAudit(removeCmd, []string{"remove", "dev1"}, nil)
+
+	if entries, _ := config.ReadAudit(dir); len(entries) != 0 {
+		t.Errorf("expected no audit log outside a project, got %+v", entries)
+	}
+}
+
+func TestRedactArgs(t *testing.T) {
+	tests := []struct {
+		in   []string
+		want []string
+	}{
+		{
+			[]string{"container", "create", "dev1", "ubuntu:24.04", "--password", "hunter2"},
+			[]string{"container", "create", "dev1", "ubuntu:24.04", "--password", "<redacted>"},
+		},
+		{
+			[]string{"container", "create", "dev1", "ubuntu:24.04", "--password=hunter2"},
+			[]string{"container", "create", "dev1", "ubuntu:24.04", "--password=<redacted>"},
+		},
+		{
+			[]string{"config", "set", "defaults.user.password", "hunter2"},
+			[]string{"config", "set", "defaults.user.password", "<redacted>"},
+		},
+		{
+			[]string{"config", "set", "defaults.user.name", "alice"},
+			[]string{"config", "set", "defaults.user.name", "alice"},
+		},
+	}
+	for _, tt := range tests {
+		if got := redactArgs(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("redactArgs(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+
+	"lxc-dev-manager/internal/dryrun"
+	"lxc-dev-manager/internal/lxc"
+
+	"github.com/spf13/cobra"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the local package cache",
+}
+
+var cacheServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run an apt-cacher-ng proxy for containers",
+	Long: `Run apt-cacher-ng in the foreground, listening on the LXC bridge, so
+containers share downloaded packages instead of fetching them again each.
+apt-cacher-ng must be installed on the host (apt install apt-cacher-ng);
+its system service doesn't need to run.
+
+Point new containers at it with apt_proxy in containers.yaml:
+
+  defaults:
+    apt_proxy: http://10.10.10.1:3142
+
+Press Ctrl+C to stop the proxy.
+
+Examples:
+  lxc-dev-manager cache serve
+  lxc-dev-manager cache serve --port 3143 --dir /srv/apt-cache`,
+	Args: cobra.NoArgs,
+	RunE: runCacheServe,
+}
+
+var cacheNetwork string
+var cachePort int
+var cacheDir string
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheServeCmd)
+	cacheServeCmd.Flags().StringVar(&cacheNetwork, "network", "lxdbr0", "LXC bridge to listen on")
+	cacheServeCmd.Flags().IntVar(&cachePort, "port", 3142, "Port to listen on")
+	cacheServeCmd.Flags().StringVar(&cacheDir, "dir", "", "Directory for cached packages and logs (default: ~/.cache/lxc-dev-manager/apt-cacher-ng)")
+}
+
+// runAptCacher runs apt-cacher-ng in the foreground; it's replaced in tests
+var runAptCacher = func(args ...string) error {
+	path, err := exec.LookPath("apt-cacher-ng")
+	if err != nil {
+		return fmt.Errorf("apt-cacher-ng not found in PATH; install it with: sudo apt install apt-cacher-ng")
+	}
+	cmd := exec.Command(path, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+func runCacheServe(cmd *cobra.Command, args []string) error {
+	if dryrun.Enabled() {
+		return fmt.Errorf("cache serve does not support --dry-run")
+	}
+
+	subnet, err := lxc.GetNetworkSubnet(cacheNetwork)
+	if err != nil {
+		return err
+	}
+	ip, _, err := net.ParseCIDR(subnet)
+	if err != nil {
+		return fmt.Errorf("network '%s' has no usable IPv4 address (%s)", cacheNetwork, subnet)
+	}
+
+	dir := cacheDir
+	if dir == "" {
+		userCache, err := os.UserCacheDir()
+		if err != nil {
+			return fmt.Errorf("failed to find cache directory: %w", err)
+		}
+		dir = filepath.Join(userCache, "lxc-dev-manager", "apt-cacher-ng")
+	}
+	for _, sub := range []string{"cache", "log"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return err
+		}
+	}
+
+	proxy := "http://" + net.JoinHostPort(ip.String(), strconv.Itoa(cachePort))
+	fmt.Printf("APT cache at %s (packages in %s)\n", proxy, dir)
+	fmt.Printf("\nUse it for new containers in containers.yaml:\n  defaults:\n    apt_proxy: %s\n", proxy)
+	fmt.Println("\nPress Ctrl+C to stop")
+
+	return runAptCacher(
+		"ForeGround=1",
+		"CacheDir="+filepath.Join(dir, "cache"),
+		"LogDir="+filepath.Join(dir, "log"),
+		"SocketPath="+filepath.Join(dir, "socket"),
+		"PidFile="+filepath.Join(dir, "pid"),
+		"Port="+strconv.Itoa(cachePort),
+		"BindAddress="+ip.String(),
+	)
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCacheServe(t *testing.T) {
+	env := setupTestEnv(t)
+	env.mock.SetOutput("network get lxdbr0 ipv4.address", "10.10.10.1/24")
+
+	var gotArgs []string
+	old := runAptCacher
+	runAptCacher = func(args ...string) error {
+		gotArgs = args
+		return nil
+	}
+	defer func() { runAptCacher = old }()
+
+	dir := t.TempDir()
+	cacheDir = dir
+	defer func() { cacheDir = "" }()
+
+	out := captureStdout(t, func() {
+		if err := runCacheServe(nil, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	if !strings.Contains(out, "apt_proxy: http://10.10.10.1:3142") {
+		t.Errorf("expected apt_proxy hint, got:\n%s", out)
+	}
+	for _, want := range []string{"ForeGround=1", "BindAddress=10.10.10.1", "Port=3142", "CacheDir=" + filepath.Join(dir, "cache")} {
+		if !slices.Contains(gotArgs, want) {
+			t.Errorf("expected %s in apt-cacher-ng args %v", want, gotArgs)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"lxc-dev-manager/internal/dryrun"
+	"lxc-dev-manager/internal/operations"
+
+	"github.com/spf13/cobra"
+)
+
+var codeSSHDir string
+
+var codeCmd = &cobra.Command{
+	Use:   "code <container> [path]",
+	Short: "Open a container in VS Code over Remote-SSH",
+	Long: `Open a container in VS Code through the Remote-SSH extension.
+
+Before launching, the container is prepared for key auth:
+  - sshd is installed and started
+  - your public key (~/.ssh/id_ed25519.pub, id_ecdsa.pub or id_rsa.pub)
+    is added to the container user's authorized_keys
+  - a Host entry named after the container is written to ~/.ssh/config,
+    or refreshed if it's already there
+
+VS Code opens the project mount (--mount-project), else the user's home
+directory. Pass a path to open another directory.
+
+Examples:
+  lxc-dev-manager code dev1
+  lxc-dev-manager code dev1 /srv/app`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runCode,
+}
+
+func init() {
+	rootCmd.AddCommand(codeCmd)
+	codeCmd.Flags().StringVar(&codeSSHDir, "ssh-dir", "", "SSH directory with your key and config (default: ~/.ssh)")
+}
+
+// launchEditor starts an editor; it's replaced in tests
+var launchEditor = func(name string, args ...string) error {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return fmt.Errorf("%s not found in PATH; install VS Code and its 'code' command", name)
+	}
+	cmd := exec.Command(path, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+func runCode(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	cfg, _, err := requireRunningContainer(&name)
+	if err != nil {
+		return err
+	}
+
+	sshDir := codeSSHDir
+	if sshDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("failed to find home directory: %w", err)
+		}
+		sshDir = filepath.Join(home, ".ssh")
+	}
+
+	fmt.Printf("Setting up SSH access to '%s'...\n", name)
+	remote, err := operations.PrepareRemoteSSH(cfg, name, sshDir)
+	if err != nil {
+		return err
+	}
+
+	path := remote.Path
+	if len(args) > 1 {
+		path = args[1]
+	}
+	codeArgs := []string{"--remote", "ssh-remote+" + remote.Host, path}
+	if dryrun.Enabled() {
+		dryrun.Printf("code %s %s %s", codeArgs[0], codeArgs[1], codeArgs[2])
+		return nil
+	}
+
+	fmt.Printf("Opening %s:%s in VS Code (Host %s in %s)\n", remote.Host, path, remote.Host, remote.ConfigPath)
+	return launchEditor("code", codeArgs...)
+}
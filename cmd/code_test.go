@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCode(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+    workdir: /workspace
+`)
+	env.setContainerExists("test-dev1", true)
+
+	sshDir := filepath.Join(env.dir, "ssh")
+	os.MkdirAll(sshDir, 0700)
+	os.WriteFile(filepath.Join(sshDir, "id_ed25519.pub"), []byte("ssh-ed25519 AAAA me@host\n"), 0644)
+	os.WriteFile(filepath.Join(sshDir, "config"), []byte("Host github.com\n    User git\n"), 0600)
+	codeSSHDir = sshDir
+	defer func() { codeSSHDir = "" }()
+
+	var launched []string
+	origLaunch := launchEditor
+	launchEditor = func(name string, args ...string) error {
+		launched = append([]string{name}, args...)
+		return nil
+	}
+	defer func() { launchEditor = origLaunch }()
+
+	if err := runCode(nil, []string{"dev1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(launched, []string{"code", "--remote", "ssh-remote+test-dev1", "/workspace"}) {
+		t.Errorf("unexpected launch: %v", launched)
+	}
+	if !env.mock.HasCallPrefix("exec", "test-dev1", "--", "bash", "-c") {
+		t.Error("expected sshd setup and key authorization")
+	}
+
+	sshConfig, _ := os.ReadFile(filepath.Join(sshDir, "config"))
+	for _, want := range []string{"Host github.com\n", "Host test-dev1\n    HostName 10.10.10.100\n    User dev\n", "IdentityFile " + filepath.Join(sshDir, "id_ed25519")} {
+		if !strings.Contains(string(sshConfig), want) {
+			t.Errorf("expected %q in ssh config:\n%s", want, sshConfig)
+		}
+	}
+
+	// Running again refreshes the entry instead of adding another
+	if err := runCode(nil, []string{"dev1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sshConfig, _ = os.ReadFile(filepath.Join(sshDir, "config"))
+	if strings.Count(string(sshConfig), "Host test-dev1\n") != 1 {
+		t.Errorf("expected a single entry:\n%s", sshConfig)
+	}
+}
+
+func TestCode_NoKey(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+	env.setContainerExists("dev1", true)
+	codeSSHDir = t.TempDir()
+	defer func() { codeSSHDir = "" }()
+
+	err := runCode(nil, []string{"dev1"})
+	if err == nil || !strings.Contains(err.Error(), "ssh-keygen") {
+		t.Errorf("expected missing key error, got %v", err)
+	}
+}
+
+func TestCode_NotRunning(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+	env.setContainerExists("dev1", false)
+
+	err := runCode(nil, []string{"dev1"})
+	if err == nil || !strings.Contains(err.Error(), "not running") {
+		t.Errorf("expected not running error, got %v", err)
+	}
+}
@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"sort"
+	"strings"
+
+	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/lxc"
+	"lxc-dev-manager/internal/operations"
+
+	"github.com/spf13/cobra"
+)
+
+// argCompleter returns candidates for one positional argument, given the
+// arguments before it. A nil argCompleter falls back to file completion.
+type argCompleter func(cfg *config.Config, args []string) []string
+
+// completeArgs builds a ValidArgsFunction that completes the nth positional
+// argument with the nth completer. Arguments past the last completer get no
+// suggestions.
+func completeArgs(completers ...argCompleter) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) >= len(completers) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		complete := completers[len(args)]
+		if complete == nil {
+			return nil, cobra.ShellCompDirectiveDefault
+		}
+
+		cfg, err := config.Load(projectDir)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		var matches []string
+		for _, c := range complete(cfg, args) {
+			if strings.HasPrefix(c, toComplete) {
+				matches = append(matches, c)
+			}
+		}
+		sort.Strings(matches)
+		return matches, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeContainers lists the containers in containers.yaml
+func completeContainers(cfg *config.Config, args []string) []string {
+	names := make([]string, 0, len(cfg.Containers))
+	for name := range cfg.Containers {
+		names = append(names, name)
+	}
+	return names
+}
+
+// completeSnapshots lists the snapshots of the container given as the
+// first argument, from LXC when it exists and the config otherwise
+func completeSnapshots(cfg *config.Config, args []string) []string {
+	seen := make(map[string]bool)
+	for name := range cfg.GetSnapshots(args[0]) {
+		seen[name] = true
+	}
+	if snapshots, err := lxc.ListSnapshots(cfg.GetLXCName(args[0])); err == nil {
+		for _, name := range snapshots {
+			seen[name] = true
+		}
+	}
+	return keys(seen)
+}
+
+// completeMounts lists the disk devices of the container given as the
+// first argument
+func completeMounts(cfg *config.Config, args []string) []string {
+	var names []string
+	for name, device := range cfg.GetDevices(args[0]) {
+		if device.Type == "disk" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// completeDevices lists every device of the container given as the first
+// argument
+func completeDevices(cfg *config.Config, args []string) []string {
+	var names []string
+	for name := range cfg.GetDevices(args[0]) {
+		names = append(names, name)
+	}
+	return names
+}
+
+// completeSyncSources lists the sync entry sources of the container given
+// as the first argument
+func completeSyncSources(cfg *config.Config, args []string) []string {
+	var sources []string
+	for _, entry := range cfg.Containers[args[0]].Sync {
+		sources = append(sources, entry.Source)
+	}
+	return sources
+}
+
+// completeVolumes lists the volumes in containers.yaml
+func completeVolumes(cfg *config.Config, args []string) []string {
+	names := make([]string, 0, len(cfg.Volumes))
+	for name := range cfg.Volumes {
+		names = append(names, name)
+	}
+	return names
+}
+
+// completeImages lists local image aliases from LXC
+func completeImages(cfg *config.Config, args []string) []string {
+	images, err := lxc.ListImageDetails()
+	if err != nil {
+		return nil
+	}
+	var aliases []string
+	for _, img := range images {
+		aliases = append(aliases, img.Aliases...)
+	}
+	return aliases
+}
+
+// completeDeviceTypes lists the device types device add accepts
+func completeDeviceTypes(cfg *config.Config, args []string) []string {
+	return []string{"usb", "unix-char"}
+}
+
+func keys(m map[string]bool) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
+
+func init() {
+	for _, c := range []*cobra.Command{
+		upCmd, downCmd, sshCmd, execCmd, attachCmd, removeCmd, infoCmd, proxyCmd, mountsCmd,
+		syncCmd, syncListCmd, fileLsCmd, fileCatCmd, fileRmCmd, fileEditCmd,
+		deviceListCmd, portCheckCmd, dotfilesApplyCmd,
+		containerResizeCmd, containerSetDescriptionCmd, containerRebaseCmd,
+		containerSnapshotCreateCmd, containerSnapshotListCmd, imageCreateCmd,
+		devcontainerExportCmd, codeCmd,
+	} {
+		c.ValidArgsFunction = completeArgs(completeContainers)
+	}
+
+	// create <name>... <image>: any argument after the first may be the image
+	containerCreateCmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completeArgs(completeImages)(cmd, nil, toComplete)
+	}
+	containerCloneCmd.ValidArgsFunction = completeArgs(completeContainers)
+	// set <container> <key=value>...: property names after the container
+	containerSetCmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return completeArgs(completeContainers)(cmd, args, toComplete)
+		}
+		var matches []string
+		for _, key := range operations.ContainerProperties {
+			if strings.HasPrefix(key+"=", toComplete) {
+				matches = append(matches, key+"=")
+			}
+		}
+		return matches, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+	}
+	containerMoveCmd.ValidArgsFunction = completeArgs(completeContainers, nil)
+	containerResetCmd.ValidArgsFunction = completeArgs(completeContainers, completeSnapshots)
+	containerSnapshotDeleteCmd.ValidArgsFunction = completeArgs(completeContainers, completeSnapshots)
+	mountCmd.ValidArgsFunction = completeArgs(completeContainers, nil)
+	unmountCmd.ValidArgsFunction = completeArgs(completeContainers, completeMounts)
+	deviceAddCmd.ValidArgsFunction = completeArgs(completeContainers, completeDeviceTypes)
+	deviceRemoveCmd.ValidArgsFunction = completeArgs(completeContainers, completeDevices)
+	syncAddCmd.ValidArgsFunction = completeArgs(completeContainers, nil)
+	syncRmCmd.ValidArgsFunction = completeArgs(completeContainers, completeSyncSources)
+	imageDeleteCmd.ValidArgsFunction = completeArgs(completeImages)
+	imageRenameCmd.ValidArgsFunction = completeArgs(completeImages)
+	imageExportCmd.ValidArgsFunction = completeArgs(completeImages, nil)
+	imagePushCmd.ValidArgsFunction = completeArgs(completeImages)
+	imageInfoCmd.ValidArgsFunction = completeArgs(completeImages)
+	volumeAttachCmd.ValidArgsFunction = completeArgs(completeVolumes, completeContainers)
+	volumeDetachCmd.ValidArgsFunction = completeArgs(completeVolumes, completeContainers)
+	volumeDeleteCmd.ValidArgsFunction = completeArgs(completeVolumes)
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestCompletion_Containers(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`containers:
+  dev1:
+    image: ubuntu:24.04
+  dev2:
+    image: ubuntu:24.04
+  api:
+    image: ubuntu:24.04
+`)
+
+	got, directive := sshCmd.ValidArgsFunction(sshCmd, nil, "dev")
+	if !reflect.DeepEqual(got, []string{"dev1", "dev2"}) {
+		t.Errorf("expected dev1 and dev2, got %v", got)
+	}
+	if directive != cobra.ShellCompDirectiveNoFileComp {
+		t.Errorf("expected no file completion, got %v", directive)
+	}
+
+	if got, _ := sshCmd.ValidArgsFunction(sshCmd, []string{"dev1"}, ""); len(got) != 0 {
+		t.Errorf("expected nothing after the container, got %v", got)
+	}
+}
+
+func TestCompletion_SnapshotsAndMounts(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`containers:
+  dev1:
+    image: ubuntu:24.04
+    snapshots:
+      initial-state:
+        description: Initial state
+    devices:
+      workspace:
+        type: disk
+        config:
+          source: /tmp
+          path: /workspace
+      keyboard:
+        type: usb
+        config:
+          vendorid: "046d"
+`)
+	env.mock.SetOutput("query /1.0/instances/dev1/snapshots", `["/1.0/instances/dev1/snapshots/initial-state","/1.0/instances/dev1/snapshots/before-upgrade"]`)
+
+	got, _ := containerResetCmd.ValidArgsFunction(containerResetCmd, []string{"dev1"}, "")
+	if !reflect.DeepEqual(got, []string{"before-upgrade", "initial-state"}) {
+		t.Errorf("unexpected snapshot completions: %v", got)
+	}
+
+	got, _ = unmountCmd.ValidArgsFunction(unmountCmd, []string{"dev1"}, "")
+	if !reflect.DeepEqual(got, []string{"workspace"}) {
+		t.Errorf("expected only disk devices, got %v", got)
+	}
+
+	if _, directive := mountCmd.ValidArgsFunction(mountCmd, []string{"dev1"}, ""); directive != cobra.ShellCompDirectiveDefault {
+		t.Errorf("expected file completion for the mount source, got %v", directive)
+	}
+}
+
+func TestCompletion_NoProject(t *testing.T) {
+	setupTestEnv(t)
+
+	got, directive := sshCmd.ValidArgsFunction(sshCmd, nil, "")
+	if len(got) != 0 || directive != cobra.ShellCompDirectiveNoFileComp {
+		t.Errorf("expected no completions outside a project, got %v, %v", got, directive)
+	}
+}
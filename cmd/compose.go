@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"lxc-dev-manager/internal/compose"
+	"lxc-dev-manager/internal/operations"
+
+	"github.com/spf13/cobra"
+)
+
+var composeImage string
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Create containers from other tools' definitions",
+}
+
+var importComposeCmd = &cobra.Command{
+	Use:   "compose <docker-compose.yml>",
+	Short: "Create a container per docker-compose service",
+	Long: `Create one container per service of a docker-compose file and record
+them in containers.yaml. Dependencies are created first.
+
+  image       - the matching LXC image for distro images (ubuntu, debian,
+                alpine, fedora, ...); other images and builds use --image
+                and their software has to be installed separately
+  ports       - ports (the container side)
+  volumes     - bind mounts (named volumes and tmpfs are skipped)
+  environment - env, set for exec and login shells
+  depends_on  - depends_on; 'up' starts dependencies first
+
+Service names with '_' become container names with '-'.
+
+Examples:
+  lxc-dev-manager import compose docker-compose.yml
+  lxc-dev-manager import compose ../app/compose.yaml --image debian/12`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImportCompose,
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+	importCmd.AddCommand(importComposeCmd)
+
+	importComposeCmd.Flags().StringVar(&composeImage, "image", operations.DefaultComposeImage, "Image for services without a distro image")
+}
+
+func runImportCompose(cmd *cobra.Command, args []string) error {
+	cfg, lock, err := requireProjectWithLock()
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	file, err := compose.Load(args[0])
+	if err != nil {
+		return err
+	}
+	plans, err := operations.PlanComposeImport(cfg, file, composeImage)
+	if err != nil {
+		return err
+	}
+
+	for _, plan := range plans {
+		if !plan.ExactImage {
+			fmt.Fprintf(os.Stderr, "Warning: service '%s' (%s) uses %s; install its software separately\n", plan.Service, plan.FromImage, plan.Image)
+		}
+		for _, skipped := range plan.Skipped {
+			fmt.Fprintf(os.Stderr, "Warning: service '%s': skipping %s\n", plan.Service, skipped)
+		}
+	}
+
+	names := make([]string, len(plans))
+	for i, plan := range plans {
+		names[i] = plan.Name
+	}
+	fmt.Printf("Creating containers %s from %s...\n", strings.Join(names, ", "), args[0])
+	if err := operations.ImportCompose(cfg, plans); err != nil {
+		return err
+	}
+
+	fmt.Printf("\nImported %d services from %s\n", len(plans), args[0])
+	fmt.Printf("Start them with: %s up <container>\n", os.Args[0])
+	return nil
+}
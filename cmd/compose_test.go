@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestImportCompose(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers: {}
+`)
+	os.WriteFile("docker-compose.yml", []byte(`services:
+  api_server:
+    image: node:20
+    ports: ["3000:3000"]
+    environment:
+      NODE_ENV: development
+    depends_on: [db]
+  db:
+    image: debian:bookworm
+`), 0644)
+	env.setContainerNotExists("test-db")
+	env.setContainerNotExists("test-api-server")
+	env.setLaunchSuccess()
+	env.mock.SetCallback("launch", func(args []string) {
+		env.setContainerExists(args[1], true)
+	})
+
+	if err := runImportCompose(nil, []string{"docker-compose.yml"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !env.mock.HasCallPrefix("launch", "images:debian/bookworm", "test-db") {
+		t.Errorf("expected db launched from the debian image, got calls %v", env.mock.Calls)
+	}
+	if !env.mock.HasCallPrefix("launch", "ubuntu:24.04", "test-api-server") {
+		t.Error("expected api-server launched from the fallback image")
+	}
+
+	cfg := env.readConfig()
+	for _, want := range []string{"api-server:", "NODE_ENV: development", "depends_on:", "- db", "- 3000"} {
+		if !strings.Contains(cfg, want) {
+			t.Errorf("expected %q in config:\n%s", want, cfg)
+		}
+	}
+}
+
+func TestImportCompose_UnknownDependency(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers: {}
+`)
+	os.WriteFile("compose.yml", []byte(`services:
+  web:
+    image: ubuntu:24.04
+    depends_on: [cache]
+`), 0644)
+
+	err := runImportCompose(nil, []string{"compose.yml"})
+	if err == nil || !strings.Contains(err.Error(), "cache") {
+		t.Errorf("expected unknown dependency error, got %v", err)
+	}
+	if env.mock.HasCallPrefix("launch") {
+		t.Error("expected no launch")
+	}
+}
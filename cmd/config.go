@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"lxc-dev-manager/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect and edit containers.yaml",
+	Long: `Commands for working with the project's containers.yaml.
+
+Every change to containers.yaml first saves the previous version under
+.lxc-dev-manager/history/ (the last 20 are kept), so a bad edit or bulk
+operation can be rolled back with 'config undo'.`,
+}
+
+var configUndoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Restore the previous containers.yaml",
+	Long: `Restore containers.yaml from the most recent backup in
+.lxc-dev-manager/history/. Run it again to step further back.
+
+Only the config file is restored: containers, snapshots and mounts in LXC
+are left as they are.
+
+Examples:
+  lxc-dev-manager config undo`,
+	Args: cobra.NoArgs,
+	RunE: runConfigUndo,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <path>",
+	Short: "Print a value from containers.yaml",
+	Long: `Print the value at a dotted path in containers.yaml. Scalars are printed
+as-is, lists and mappings as YAML. List items are addressed by index.
+
+Examples:
+  lxc-dev-manager config get defaults.user.name
+  lxc-dev-manager config get containers.dev1.ports
+  lxc-dev-manager config get containers.dev1.sync.0.dest`,
+	Args: cobra.ExactArgs(1),
+	RunE: runConfigGet,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <path> <value>",
+	Short: "Set a value in containers.yaml",
+	Long: `Set the value at a dotted path in containers.yaml. The value is parsed as
+YAML, so lists and mappings can be given inline. Missing keys along the path
+are created.
+
+The change is rejected if it introduces an unknown key or makes the
+configuration invalid. Comments and key order elsewhere in the file are
+kept, and the previous version can be restored with 'config undo'.
+
+Examples:
+  lxc-dev-manager config set containers.dev1.ports '[3000, 8080]'
+  lxc-dev-manager config set defaults.user.name alice
+  lxc-dev-manager config set containers.dev1.description "API server"`,
+	Args: cobra.ExactArgs(2),
+	RunE: runConfigSet,
+}
+
+var configEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Edit containers.yaml in your editor with validation",
+	Long: `Open containers.yaml in $VISUAL or $EDITOR (default: vi) while holding the
+project lock. When the editor exits, the file is checked for YAML errors,
+unknown keys and invalid values before it's saved. If it doesn't pass, you
+can re-open the editor with your changes or give up without saving.
+
+Examples:
+  lxc-dev-manager config edit
+  EDITOR="code --wait" lxc-dev-manager config edit`,
+	Args: cobra.NoArgs,
+	RunE: runConfigEdit,
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configEditCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUndoCmd)
+}
+
+func runConfigGet(cmd *cobra.Command, args []string) error {
+	if _, err := requireProject(); err != nil {
+		return err
+	}
+
+	value, err := config.GetValue(projectDir, args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Println(value)
+	return nil
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	_, lock, err := requireProjectWithLock()
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	if err := config.SetValue(projectDir, args[0], args[1]); err != nil {
+		return err
+	}
+	fmt.Printf("Set %s\n", args[0])
+	return nil
+}
+
+func runConfigEdit(cmd *cobra.Command, args []string) error {
+	_, lock, err := requireProjectWithLock()
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	changed, err := config.Edit(projectDir, runEditor, func(err error) bool {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return confirmOrDecline("Re-open the editor?")
+	})
+	if err != nil {
+		return err
+	}
+	if !changed {
+		fmt.Println("No changes")
+		return nil
+	}
+	fmt.Printf("Saved %s\n", config.ConfigFile)
+
+	if cfg, err := requireProject(); err == nil {
+		refreshDNS(cfg)
+	}
+	return nil
+}
+
+func runConfigUndo(cmd *cobra.Command, args []string) error {
+	_, lock, err := requireProjectWithLock()
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	backup, err := config.Undo(projectDir)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Restored %s from backup of %s\n", config.ConfigFile, backup.Time.Format("2006-01-02 15:04:05"))
+
+	if cfg, err := requireProject(); err == nil {
+		refreshDNS(cfg)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"lxc-dev-manager/internal/config"
+)
+
+func TestConfigUndo(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+
+	cfg, err := config.Load(env.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.RemoveContainer("dev1")
+	if err := cfg.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runConfigUndo(nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(env.readConfig(), "dev1:") {
+		t.Error("expected dev1 to be restored")
+	}
+}
+
+func TestConfigUndo_NoBackups(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+
+	err := runConfigUndo(nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "no backups") {
+		t.Fatalf("expected no backups error, got %v", err)
+	}
+}
+
+func TestConfigSetGet(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+
+	if err := runConfigSet(nil, []string{"containers.dev1.ports", "[3000, 8080]"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := runConfigGet(nil, []string{"containers.dev1.ports"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfg, err := config.Load(env.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := cfg.GetPorts("dev1"); len(p) != 2 || p[1] != 8080 {
+		t.Errorf("expected ports [3000 8080], got %v", p)
+	}
+
+	if err := runConfigSet(nil, []string{"containers.dev1.ports", "[0]"}); err == nil {
+		t.Error("expected invalid port to be rejected")
+	}
+}
@@ -3,7 +3,14 @@ package cmd
 import (
 	"fmt"
 	"os"
-
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/errcode"
 	"lxc-dev-manager/internal/lxc"
 	"lxc-dev-manager/internal/operations"
 
@@ -22,7 +29,7 @@ the actual LXC container will be named "webapp-dev1".`,
 }
 
 var containerCreateCmd = &cobra.Command{
-	Use:   "create <name> <image>",
+	Use:   "create <name>... [image]",
 	Short: "Create a new container in the current project",
 	Long: `Create a new container from an image and configure it for development.
 
@@ -33,10 +40,43 @@ The container will be set up with:
 
 The container name will be prefixed with the project name in LXC.
 
+With a single name and no image, defaults.image from containers.yaml is
+used. New containers also get defaults.sync entries (synced right away)
+and run defaults.setup commands before the initial snapshot.
+
+Use --ip to pin a static IPv4 address on the LXC bridge instead of DHCP.
+The address must be inside the bridge subnet.
+
+Use --mount-project to mount the project directory (where containers.yaml
+lives) read-write at the given path and export it as $WORKDIR. Set
+defaults.workdir in containers.yaml to do this for every container.
+
+Use --mount source:path[:ro] (repeatable) to mount host directories right
+after creation, before the initial snapshot. Mounts are read-write unless
+":ro" is given. --ports and --user are saved to the container's entry in
+containers.yaml. --no-start leaves the container stopped once setup is done.
+
+Use --arch to pull a remote image (remote:alias) for another architecture,
+e.g. arm64 or amd64; the host must be able to run it. A warning is
+printed when the image isn't built for the host's architecture.
+
+Give several names to create a fleet from the same image. Up to --parallel
+containers are set up at once, with progress lines prefixed by container
+name. --ip can't be combined with several names.
+
 Examples:
   lxc-dev-manager container create dev1 ubuntu:24.04
-  lxc-dev-manager c create myapp my-custom-base`,
-	Args: cobra.ExactArgs(2),
+  lxc-dev-manager container create db ubuntu:24.04 --ip 10.10.10.50
+  lxc-dev-manager container create dev1 ubuntu:24.04 --mount-project /workspace
+  lxc-dev-manager container create dev1 ubuntu:24.04 --disk 20GiB
+  lxc-dev-manager container create dev1 ubuntu:24.04 --ports 3000,5432 --user alice
+  lxc-dev-manager container create dev1 ubuntu:24.04 --mount ~/src:/src --mount ~/data:/data:ro
+  lxc-dev-manager container create builder ubuntu:24.04 --no-start
+  lxc-dev-manager container create legacy images:debian/12 --arch i386
+  lxc-dev-manager container create dev{1..3} ubuntu:24.04 --parallel 3
+  lxc-dev-manager c create myapp my-custom-base
+  lxc-dev-manager container create dev3   # uses defaults.image`,
+	Args: cobra.MinimumNArgs(1),
 	RunE: runContainerCreate,
 }
 
@@ -48,15 +88,43 @@ var containerResetCmd = &cobra.Command{
 If no snapshot is specified, resets to 'initial-state'.
 Uses ZFS snapshots - the operation is instant.
 
+With --snapshot-first (or reset.auto_snapshot: true in containers.yaml)
+the current state is kept as a snapshot named pre-reset-<timestamp>
+first, so nothing is lost if the reset was a mistake.
+
+Devices are brought back in line with containers.yaml afterwards, since
+the snapshot has the mounts it was taken with. --sync copies the sync
+entries into the container again.
+
 Examples:
   lxc-dev-manager container reset dev1                    # reset to initial-state
-  lxc-dev-manager container reset dev1 before-refactor    # reset to named snapshot`,
+  lxc-dev-manager container reset dev1 before-refactor    # reset to named snapshot
+  lxc-dev-manager container reset dev1 --snapshot-first   # keep the current state first
+  lxc-dev-manager container reset dev1 --sync             # copy .env and co. again`,
 	Args: cobra.RangeArgs(1, 2),
 	RunE: runContainerReset,
 }
 
+var containerRebaseCmd = &cobra.Command{
+	Use:   "rebase <container>",
+	Short: "Make the current state the one reset goes back to",
+	Long: `Replace a container's initial-state snapshot with its current state, so
+that reset without a snapshot name goes back to it from then on. Use it
+once toolchains and project setup are installed to make them the
+container's baseline.
+
+The original initial-state is deleted, so asks for confirmation unless
+--force is given.
+
+Examples:
+  lxc-dev-manager container rebase dev1
+  lxc-dev-manager container rebase dev1 --force`,
+	Args: cobra.ExactArgs(1),
+	RunE: runContainerRebase,
+}
+
 var containerCloneCmd = &cobra.Command{
-	Use:   "clone <source> <new-name>",
+	Use:   "clone <source> <new-name>...",
 	Short: "Clone a container",
 	Long: `Clone an existing container to create a new one.
 
@@ -68,28 +136,207 @@ The cloned container will:
   - Get a new 'initial-state' snapshot
   - Be registered in the project config
 
+Give several new names to make multiple clones; up to --parallel copies
+run at once.
+
 Examples:
   lxc-dev-manager container clone dev dev2                     # clone current state
-  lxc-dev-manager container clone dev dev2 --snapshot checkpoint  # clone from snapshot`,
-	Args: cobra.ExactArgs(2),
+  lxc-dev-manager container clone dev dev2 --snapshot checkpoint  # clone from snapshot
+  lxc-dev-manager container clone dev dev{2..4}                # three clones in parallel`,
+	Args: cobra.MinimumNArgs(2),
 	RunE: runContainerClone,
 }
 
+var containerResizeCmd = &cobra.Command{
+	Use:   "resize <container> <size>",
+	Short: "Change the root disk size limit of a container",
+	Long: `Set the size quota of a container's root disk so it can't fill the
+shared storage pool. The size must fit in the pool.
+
+Examples:
+  lxc-dev-manager container resize dev1 30GiB
+  lxc-dev-manager container resize dev1 500MB`,
+	Args: cobra.ExactArgs(2),
+	RunE: runContainerResize,
+}
+
+var containerMoveCmd = &cobra.Command{
+	Use:   "move-to-project <container> <project-dir>",
+	Short: "Move a container to another project",
+	Long: `Move a container to the project whose containers.yaml is in project-dir.
+
+The LXC container is renamed to the destination project's prefix and its
+entry (devices, snapshots, sync entries) moves from this containers.yaml
+to the other one. A running container is stopped for the rename and
+started again. Relative sync sources are rewritten to keep pointing at
+the same host paths.
+
+Examples:
+  lxc-dev-manager container move-to-project dev-weird-tls ../other-project`,
+	Args: cobra.ExactArgs(2),
+	RunE: runContainerMove,
+}
+
+var containerSetDescriptionCmd = &cobra.Command{
+	Use:   "set-description <container> [description...]",
+	Short: "Set the notes shown for a container",
+	Long: `Set a free-form description for a container, shown by list and info.
+Run without a description to clear it.
+
+Examples:
+  lxc-dev-manager container set-description dev-weird-tls "repro for the TLS 1.0 client bug"
+  lxc-dev-manager container set-description dev-weird-tls`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runContainerSetDescription,
+}
+
+var containerSetNestingCmd = &cobra.Command{
+	Use:   "set-nesting <container> <true|false>",
+	Short: "Turn Docker-in-LXC support on or off",
+	Long: `Set security.nesting, and the syscall interception Docker needs, for a
+container and record it in containers.yaml. A running container picks the
+change up when it is restarted.
+
+Examples:
+  lxc-dev-manager container set-nesting dev false`,
+	Args: cobra.ExactArgs(2),
+	RunE: runContainerSetNesting,
+}
+
+var containerSetCmd = &cobra.Command{
+	Use:   "set <container> <key=value>...",
+	Short: "Change several container properties at once",
+	Long: `Change properties of a container and record them in containers.yaml.
+All values are checked before anything changes. An empty value clears a
+property.
+
+Properties:
+  ports           Ports to proxy, comma-separated (empty: defaults.ports)
+  image           Image recorded for the container
+  user.name       User for ssh, exec and code (must exist in the running container)
+  limits.cpu      CPU count (2) or CPU set (0-3), applied immediately
+  limits.memory   Memory size (4GiB) or share of the host's (50%)
+  autostart       Start the container when the host boots (true/false)
+
+Running proxies keep forwarding the old ports until they are restarted.
+
+Examples:
+  lxc-dev-manager container set dev ports=3000,5173 limits.cpu=2
+  lxc-dev-manager container set dev limits.memory=4GiB autostart=true
+  lxc-dev-manager container set dev limits.memory=`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: runContainerSet,
+}
+
+var containerDockerSetupCmd = &cobra.Command{
+	Use:   "docker-setup <container>",
+	Short: "Install Docker in a container and check it works",
+	Long: `Install the distribution's Docker package in a running container, add the
+user to the docker group and check the daemon runs. Nesting is enabled first
+if it is off, which restarts the container.
+
+The storage driver and cgroup version Docker ends up with are reported,
+with a warning for setups that work but are slow or limited (the vfs
+driver, cgroup v1).
+
+Examples:
+  lxc-dev-manager container docker-setup dev`,
+	Args: cobra.ExactArgs(1),
+	RunE: runContainerDockerSetup,
+}
+
+var containerRepairCmd = &cobra.Command{
+	Use:   "repair <container>",
+	Short: "Finish the setup of a partially created container",
+	Long: `Check the provisioning steps of a running container (nesting, user, SSH)
+and re-run the ones that are missing, for containers whose create failed
+with --no-rollback or was interrupted. The initial-state snapshot is taken
+when containers.yaml records it as pending.
+
+Examples:
+  lxc-dev-manager container repair dev`,
+	Args: cobra.ExactArgs(1),
+	RunE: runContainerRepair,
+}
+
+var resetSnapshotFirst bool
+var resetSync bool
+var resetPruneDevices bool
+var rebaseForce bool
 var cloneSnapshot string
+var cloneParallel int
+var cloneTiming bool
+var createIP string
+var createMountProject string
+var createDisk string
+var createPorts string
+var createUser string
+var createPassword string
+var createNoStart bool
+var createNoUser bool
+var createNoSSH bool
+var createNoSnapshot bool
+var createNoRollback bool
+var createArch string
+var createPrivileged bool
+var createNesting bool
+var createMounts []string
+var createParallel int
+var createTiming bool
 
 func init() {
 	rootCmd.AddCommand(containerCmd)
 	containerCmd.AddCommand(containerCreateCmd)
 	containerCmd.AddCommand(containerResetCmd)
+	containerCmd.AddCommand(containerRebaseCmd)
 	containerCmd.AddCommand(containerCloneCmd)
+	containerCmd.AddCommand(containerResizeCmd)
+	containerCmd.AddCommand(containerMoveCmd)
+	containerCmd.AddCommand(containerSetDescriptionCmd)
+	containerCmd.AddCommand(containerSetNestingCmd)
+	containerCmd.AddCommand(containerSetCmd)
+	containerCmd.AddCommand(containerDockerSetupCmd)
+	containerCmd.AddCommand(containerRepairCmd)
+
+	// Create flags
+	containerCreateCmd.Flags().StringVar(&createIP, "ip", "", "Static IPv4 address on the LXC bridge (default: DHCP)")
+	containerCreateCmd.Flags().StringVar(&createDisk, "disk", "", "Root disk size limit, e.g. 20GiB (default: unlimited)")
+	containerCreateCmd.Flags().StringVar(&createMountProject, "mount-project", "", "Mount the project directory read-write at this path and export it as $WORKDIR")
+	containerCreateCmd.Flags().StringVarP(&createPorts, "ports", "p", "", "Ports to proxy for this container (comma-separated, default: defaults.ports)")
+	containerCreateCmd.Flags().StringVarP(&createUser, "user", "u", "", "User to create (default: from containers.yaml, or dev)")
+	containerCreateCmd.Flags().StringVar(&createPassword, "password", "", "Password for the user (default: from containers.yaml, or dev)")
+	containerCreateCmd.Flags().BoolVar(&createNoStart, "no-start", false, "Stop the container once setup is done")
+	containerCreateCmd.Flags().BoolVar(&createNoUser, "no-user", false, "Don't create a user (exec runs as root)")
+	containerCreateCmd.Flags().BoolVar(&createNoSSH, "no-ssh", false, "Don't install and enable the SSH server")
+	containerCreateCmd.Flags().BoolVar(&createNoSnapshot, "no-snapshot", false, "Don't take the initial-state snapshot")
+	containerCreateCmd.Flags().BoolVar(&createNoRollback, "no-rollback", false, "Keep a container whose setup failed instead of deleting it")
+	containerCreateCmd.Flags().StringVar(&createArch, "arch", "", "Architecture of remote images, e.g. amd64 or arm64 (default: the host's)")
+	containerCreateCmd.Flags().BoolVar(&createPrivileged, "privileged", false, "Create a privileged container (root inside is root on the host; asks for confirmation)")
+	containerCreateCmd.Flags().BoolVar(&createNesting, "nesting", true, "Enable Docker-in-LXC support (default: defaults.nesting, else true)")
+	containerCreateCmd.Flags().StringArrayVarP(&createMounts, "mount", "m", nil, "Mount a host directory, as source:path[:ro] (repeatable)")
+	containerCreateCmd.Flags().IntVarP(&createParallel, "parallel", "j", 4, "How many containers to set up at once when creating several")
+	containerCreateCmd.Flags().BoolVar(&createTiming, "timing", false, "Print how long each setup step took")
+
+	// Reset flags
+	containerResetCmd.Flags().BoolVar(&resetSnapshotFirst, "snapshot-first", false, "Keep the current state as a pre-reset snapshot before restoring")
+	containerResetCmd.Flags().BoolVar(&resetSync, "sync", false, "Copy the sync entries into the container again after the reset")
+	containerResetCmd.Flags().BoolVar(&resetPruneDevices, "prune-devices", false, "Detach devices the config doesn't list after the reset")
+
+	// Rebase flags
+	containerRebaseCmd.Flags().BoolVarP(&rebaseForce, "force", "f", false, "Skip confirmation")
 
 	// Clone flags
 	containerCloneCmd.Flags().StringVarP(&cloneSnapshot, "snapshot", "s", "", "Clone from a specific snapshot instead of current state")
+	containerCloneCmd.Flags().IntVarP(&cloneParallel, "parallel", "j", 4, "How many clones to copy at once when cloning several")
+	containerCloneCmd.Flags().BoolVar(&cloneTiming, "timing", false, "Print how long each step took")
 }
 
 func runContainerCreate(cmd *cobra.Command, args []string) error {
-	name := args[0]
-	image := args[1]
+	// A lone name uses defaults.image
+	names, image := args, ""
+	if len(args) > 1 {
+		names, image = args[:len(args)-1], args[len(args)-1]
+	}
 
 	// Load config with lock to prevent race conditions
 	cfg, lock, err := requireProjectWithLock()
@@ -98,12 +345,75 @@ func runContainerCreate(cmd *cobra.Command, args []string) error {
 	}
 	defer lock.Release()
 
+	if image, err = operations.DefaultImage(cfg, image); err != nil {
+		return err
+	}
+
+	ports, err := parsePortList(createPorts)
+	if err != nil {
+		return err
+	}
+
+	var mounts []config.Mount
+	for _, spec := range createMounts {
+		m, err := operations.ParseMountSpec(spec)
+		if err != nil {
+			return err
+		}
+		mounts = append(mounts, m)
+	}
+
+	privileged := createPrivileged || cfg.Defaults.Privileged
+	if privileged {
+		fmt.Println("Warning: privileged containers run as real root on the host. A process escaping the")
+		fmt.Println("container gets full access to this machine. Read-write and /home mounts are refused.")
+		ok, err := confirmPrompt("Create privileged container?")
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("Cancelled")
+			return nil
+		}
+	}
+
+	opts := operations.CreateContainerOpts{
+		Ports:      ports,
+		User:       createUser,
+		Password:   createPassword,
+		IP:         createIP,
+		Workdir:    createMountProject,
+		Disk:       createDisk,
+		Mounts:     mounts,
+		NoStart:    createNoStart,
+		NoUser:     createNoUser,
+		NoSSH:      createNoSSH,
+		NoSnapshot: createNoSnapshot,
+		NoRollback: createNoRollback,
+		Arch:       createArch,
+		Privileged: privileged,
+	}
+	if cmd != nil && cmd.Flags().Changed("nesting") {
+		opts.Nesting = &createNesting
+	}
+	var timer *phaseTimer
+	if createTiming {
+		timer = newPhaseTimer()
+	}
+	if len(names) > 1 {
+		return createContainers(cfg, names, image, opts, timer)
+	}
+
+	name := names[0]
 	lxcName := cfg.GetLXCName(name)
 
 	fmt.Printf("Creating container '%s' (LXC: %s) from image '%s'...\n", name, lxcName, image)
 
+	if timer != nil {
+		opts.Progress = timer.progress(nil)
+	}
 	// Use operations package for core logic
-	if err := operations.CreateContainer(cfg, name, image, operations.CreateContainerOpts{}); err != nil {
+	if err := operations.CreateContainer(cfg, name, image, opts); err != nil {
 		return err
 	}
 
@@ -112,6 +422,9 @@ func runContainerCreate(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		ip = "(pending)"
 	}
+	if createNoStart {
+		ip = "(stopped)"
+	}
 
 	// Get user config for display
 	user := cfg.GetUser(name)
@@ -119,12 +432,94 @@ func runContainerCreate(cmd *cobra.Command, args []string) error {
 	fmt.Printf("\nContainer '%s' created successfully!\n", name)
 	fmt.Printf("  LXC name: %s\n", lxcName)
 	fmt.Printf("  IP: %s\n", ip)
-	fmt.Printf("  User: %s / Password: %s\n", user.Name, user.Password)
+	if createNoUser {
+		fmt.Printf("  User: root (no user created)\n")
+	} else {
+		fmt.Printf("  User: %s / Password: %s\n", user.Name, user.Password)
+	}
+	if workdir := cfg.Containers[name].Workdir; workdir != "" {
+		fmt.Printf("  Workdir: %s -> %s ($WORKDIR)\n", cfg.ProjectDir(), workdir)
+	}
+	if timer != nil {
+		timer.print(os.Stdout)
+	}
+	if createNoStart {
+		fmt.Printf("\nStart with: %s up %s\n", os.Args[0], name)
+		return nil
+	}
+	if createNoSSH {
+		fmt.Printf("\nRun commands with: %s exec %s -- <command>\n", os.Args[0], name)
+		return nil
+	}
 	fmt.Printf("\nConnect with: %s ssh %s\n", os.Args[0], name)
 
 	return nil
 }
 
+// createContainers creates several containers in parallel, printing each
+// one's progress as it goes and a summary at the end, followed by the step
+// timings when timer is set
+func createContainers(cfg *config.Config, names []string, image string, opts operations.CreateContainerOpts, timer *phaseTimer) error {
+	opts.Progress = batchProgress(names)
+	if timer != nil {
+		opts.Progress = timer.progress(opts.Progress)
+	}
+
+	fmt.Printf("Creating %d containers from image '%s' (%d at a time)...\n", len(names), image, max(createParallel, 1))
+	failed, err := operations.CreateContainers(cfg, names, image, opts, createParallel)
+	if err != nil {
+		return err
+	}
+	err = printBatchResult(cfg, names, failed, "created", opts.NoStart)
+	if timer != nil {
+		timer.print(os.Stdout)
+	}
+	return err
+}
+
+// batchProgress returns a progress callback that prints one line per step,
+// prefixed with the container name so parallel output stays readable
+func batchProgress(names []string) func(name, step string) {
+	width := 0
+	for _, name := range names {
+		width = max(width, len(name))
+	}
+
+	var mu sync.Mutex
+	return func(name, step string) {
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Printf("[%-*s] %s\n", width, name, step)
+	}
+}
+
+// printBatchResult prints a summary table for a parallel create or clone
+// and returns an error if any container failed
+func printBatchResult(cfg *config.Config, names []string, failed map[string]error, verb string, stopped bool) error {
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tLXC NAME\tIP\tRESULT")
+	for _, name := range names {
+		ip, result := "-", verb
+		if err, ok := failed[name]; ok {
+			result = "FAILED: " + err.Error()
+		} else if stopped {
+			ip = "(stopped)"
+		} else if addr, err := lxc.GetIP(cfg.GetLXCName(name)); err == nil {
+			ip = addr
+		} else {
+			ip = "(pending)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", name, cfg.GetLXCName(name), ip, result)
+	}
+	w.Flush()
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d containers failed", len(failed), len(names))
+	}
+	return nil
+}
+
 func runContainerReset(cmd *cobra.Command, args []string) error {
 	name := args[0]
 	snapshotName := "initial-state"
@@ -132,10 +527,12 @@ func runContainerReset(cmd *cobra.Command, args []string) error {
 		snapshotName = args[1]
 	}
 
-	cfg, lxcName, err := requireContainer(name)
+	// Locked since a pre-reset snapshot is recorded in the config
+	cfg, lxcName, lock, err := requireContainerWithLock(&name)
 	if err != nil {
 		return err
 	}
+	defer lock.Release()
 
 	// Check status before reset for display purposes
 	status, _ := lxc.GetStatus(lxcName)
@@ -144,7 +541,15 @@ func runContainerReset(cmd *cobra.Command, args []string) error {
 	fmt.Printf("Restoring container '%s' to snapshot '%s'...\n", name, snapshotName)
 
 	// Use operations package for core logic
-	if err := operations.Reset(cfg, name, snapshotName); err != nil {
+	preReset, err := operations.Reset(cfg, name, snapshotName, operations.ResetOpts{
+		SnapshotFirst: resetSnapshotFirst,
+		SyncFiles:     resetSync,
+		PruneDevices:  resetPruneDevices,
+	})
+	if preReset != "" {
+		fmt.Printf("Kept the previous state as snapshot '%s'\n", preReset)
+	}
+	if err != nil {
 		return err
 	}
 
@@ -163,27 +568,82 @@ func runContainerReset(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func runContainerRebase(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	cfg, _, lock, err := requireContainerWithLock(&name)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	if !rebaseForce {
+		ok, err := confirmPrompt(fmt.Sprintf("Make the current state of '%s' its new initial-state, deleting the original one?", name))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("Cancelled")
+			return nil
+		}
+	}
+
+	if err := operations.Rebase(cfg, name); err != nil {
+		return err
+	}
+
+	fmt.Printf("Container '%s' rebased: reset now goes back to its current state\n", name)
+	return nil
+}
+
 func runContainerClone(cmd *cobra.Command, args []string) error {
 	sourceName := args[0]
-	newName := args[1]
 
 	// Load config with lock to prevent race conditions
-	cfg, _, lock, err := requireContainerWithLock(sourceName)
+	cfg, _, lock, err := requireContainerWithProjectLock(&sourceName)
 	if err != nil {
 		return err
 	}
 	defer lock.Release()
 
+	var timer *phaseTimer
+	if cloneTiming {
+		timer = newPhaseTimer()
+	}
+
+	if newNames := args[1:]; len(newNames) > 1 {
+		progress := batchProgress(newNames)
+		if timer != nil {
+			progress = timer.progress(progress)
+		}
+		fmt.Printf("Cloning container '%s' to %d containers (%d at a time)...\n", sourceName, len(newNames), max(cloneParallel, 1))
+		failed, err := operations.CloneContainers(cfg, sourceName, newNames, operations.CloneOpts{
+			FromSnapshot: cloneSnapshot,
+			Progress:     progress,
+		}, cloneParallel)
+		if err != nil {
+			return err
+		}
+		err = printBatchResult(cfg, newNames, failed, "cloned", false)
+		if timer != nil {
+			timer.print(os.Stdout)
+		}
+		return err
+	}
+	newName := args[1]
+
 	if cloneSnapshot != "" {
 		fmt.Printf("Cloning container '%s' (snapshot: %s) to '%s'...\n", sourceName, cloneSnapshot, newName)
 	} else {
 		fmt.Printf("Cloning container '%s' to '%s'...\n", sourceName, newName)
 	}
 
+	opts := operations.CloneOpts{FromSnapshot: cloneSnapshot}
+	if timer != nil {
+		opts.Progress = timer.progress(nil)
+	}
 	// Use operations package for core logic
-	if err := operations.Clone(cfg, sourceName, newName, operations.CloneOpts{
-		FromSnapshot: cloneSnapshot,
-	}); err != nil {
+	if err := operations.Clone(cfg, sourceName, newName, opts); err != nil {
 		return err
 	}
 
@@ -208,6 +668,205 @@ func runContainerClone(cmd *cobra.Command, args []string) error {
 	fmt.Printf("  IP: %s\n", ip)
 	fmt.Printf("  User: %s\n", user.Name)
 	fmt.Printf("  SSH: ssh %s@%s\n", user.Name, ip)
+	if timer != nil {
+		timer.print(os.Stdout)
+	}
+
+	return nil
+}
+
+func runContainerResize(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	size := args[1]
+
+	cfg, _, lock, err := requireContainerWithLock(&name)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	if err := operations.ResizeDisk(cfg, name, size); err != nil {
+		return err
+	}
+
+	fmt.Printf("Container '%s' root disk limited to %s\n", name, size)
+	return nil
+}
+
+func runContainerMove(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	destDir := args[1]
+
+	cfg, oldLXC, lock, err := requireContainerWithLock(&name)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
 
+	dest, destLock, err := config.LoadWithLock(destDir)
+	if err != nil {
+		return fmt.Errorf("failed to load destination project: %w", err)
+	}
+	defer destLock.Release()
+
+	fmt.Printf("Moving container '%s' from project '%s' to '%s'...\n", name, cfg.Project, dest.Project)
+
+	if err := operations.MoveToProject(cfg, dest, name); err != nil {
+		return err
+	}
+
+	refreshDNS(cfg)
+	refreshDNS(dest)
+
+	fmt.Printf("Container '%s' moved to project '%s'\n", name, dest.Project)
+	fmt.Printf("  LXC name: %s -> %s\n", oldLXC, dest.GetLXCName(name))
+	return nil
+}
+
+func runContainerSetDescription(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	description := strings.Join(args[1:], " ")
+
+	cfg, lock, err := requireProjectWithLock()
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	if err := operations.SetDescription(cfg, name, description); err != nil {
+		return err
+	}
+
+	if description == "" {
+		fmt.Printf("Cleared description of '%s'\n", name)
+	} else {
+		fmt.Printf("Set description of '%s'\n", name)
+	}
+	return nil
+}
+
+func runContainerSetNesting(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	enabled, err := strconv.ParseBool(args[1])
+	if err != nil {
+		return errcode.Errorf(errcode.Usage, name, "invalid value '%s' (expected true or false)", args[1])
+	}
+
+	cfg, _, lock, err := requireContainerWithLock(&name)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	restartNeeded, err := operations.SetNesting(cfg, name, enabled)
+	if err != nil {
+		return err
+	}
+
+	state := "Disabled"
+	if enabled {
+		state = "Enabled"
+	}
+	fmt.Printf("%s nesting for '%s'\n", state, name)
+	if restartNeeded {
+		fmt.Printf("Restart the container to apply: %s down %s && %s up %s\n", os.Args[0], name, os.Args[0], name)
+	}
+	return nil
+}
+
+func runContainerSet(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	properties := make(map[string]string, len(args)-1)
+	for _, arg := range args[1:] {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			return errcode.Errorf(errcode.Usage, name, "invalid property %q: use key=value", arg)
+		}
+		properties[key] = value
+	}
+
+	cfg, _, lock, err := requireContainerWithLock(&name)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	if err := operations.SetProperties(cfg, name, properties); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if value := properties[key]; value == "" {
+			fmt.Printf("Cleared %s of '%s'\n", key, name)
+		} else {
+			fmt.Printf("Set %s of '%s' to %s\n", key, name, value)
+		}
+	}
+	return nil
+}
+
+func runContainerRepair(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	cfg, _, lock, err := requireContainerWithLock(&name)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	fmt.Printf("Repairing '%s'...\n", name)
+	report, err := operations.Repair(cfg, name, nil)
+	if report != nil {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, step := range report.Steps {
+			result := "ok"
+			if step.Repaired {
+				result = "repaired"
+			}
+			fmt.Fprintf(w, "  %s\t%s\n", step.Step, result)
+		}
+		w.Flush()
+	}
+	if err != nil {
+		return err
+	}
+
+	if report.RestartNeeded {
+		fmt.Printf("Restart the container to apply nesting: %s down %s && %s up %s\n", os.Args[0], name, os.Args[0], name)
+	}
+	fmt.Printf("\n'%s' is fully set up\n", name)
+	return nil
+}
+
+func runContainerDockerSetup(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	cfg, _, lock, err := requireContainerWithLock(&name)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	fmt.Printf("Setting up Docker in '%s'...\n", name)
+	report, err := operations.SetupDocker(cfg, name)
+	if err != nil {
+		return err
+	}
+
+	if report.Restarted {
+		fmt.Println("  Enabled nesting (container restarted)")
+	}
+	fmt.Printf("  Storage driver: %s\n", report.StorageDriver)
+	fmt.Printf("  Cgroups: %s\n", report.CgroupFS)
+	for _, warning := range report.Warnings {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+	fmt.Printf("\nDocker is ready in '%s'\n", name)
 	return nil
 }
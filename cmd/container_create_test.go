@@ -0,0 +1,877 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"lxc-dev-manager/internal/lxc"
+)
+
+func TestContainerCreate_StaticIP(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers: {}
+`)
+	env.setContainerNotExists("test-dev1")
+	env.setLaunchSuccess()
+	env.mock.SetOutput("query /1.0/instances/test-dev1", `{"expanded_devices":{"eth0":{"type":"nic","network":"lxdbr0"}}}`)
+	env.mock.SetOutput("network get lxdbr0 ipv4.address", "10.10.10.1/24")
+
+	createIP = "10.10.10.50"
+	defer func() { createIP = "" }()
+
+	if err := runContainerCreate(nil, []string{"dev1", "ubuntu:24.04"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if env.mock.HasCallPrefix("launch") {
+		t.Error("static IP containers should be created with init, not launch")
+	}
+	if !env.mock.HasCall("init", "ubuntu:24.04", "test-dev1") {
+		t.Error("expected init call")
+	}
+	if !env.mock.HasCall("config", "device", "override", "test-dev1", "eth0", "ipv4.address=10.10.10.50") {
+		t.Error("expected eth0 override with static IP")
+	}
+	if !env.mock.HasCall("start", "test-dev1") {
+		t.Error("expected container to be started")
+	}
+
+	if !strings.Contains(env.readConfig(), "ip: 10.10.10.50") {
+		t.Error("expected static IP to be saved in config")
+	}
+}
+
+func TestContainerCreate_StaticIPOutsideSubnet(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers: {}
+`)
+	env.setContainerNotExists("test-dev1")
+	env.setLaunchSuccess()
+	env.mock.SetOutput("query /1.0/instances/test-dev1", `{"expanded_devices":{"eth0":{"type":"nic","network":"lxdbr0"}}}`)
+	env.mock.SetOutput("network get lxdbr0 ipv4.address", "10.10.10.1/24")
+
+	createIP = "192.168.1.50"
+	defer func() { createIP = "" }()
+
+	err := runContainerCreate(nil, []string{"dev1", "ubuntu:24.04"})
+	if err == nil {
+		t.Fatal("expected error for IP outside subnet")
+	}
+	if !strings.Contains(err.Error(), "not in subnet") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !env.mock.HasCall("delete", "test-dev1", "--force") {
+		t.Error("expected half-created container to be deleted")
+	}
+	if strings.Contains(env.readConfig(), "dev1") {
+		t.Error("container should not be added to config")
+	}
+}
+
+func TestContainerCreate_StaticIPTaken(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers:
+  db:
+    image: ubuntu:24.04
+    ip: 10.10.10.50
+`)
+	env.setContainerNotExists("test-dev1")
+	env.setLaunchSuccess()
+
+	createIP = "10.10.10.50"
+	defer func() { createIP = "" }()
+
+	err := runContainerCreate(nil, []string{"dev1", "ubuntu:24.04"})
+	if err == nil {
+		t.Fatal("expected error for duplicate IP")
+	}
+	if !strings.Contains(err.Error(), "already assigned") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if env.mock.HasCallPrefix("init") {
+		t.Error("should not create container when IP is taken")
+	}
+}
+
+func TestContainerCreate_AppliesDefaultMounts(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+defaults:
+  mounts:
+    - source: ${PROJECT_DIR}
+      path: /workspace
+      mode: rw
+containers: {}
+`)
+	env.setContainerNotExists("test-dev1")
+	env.setLaunchSuccess()
+	env.mock.SetCallback("launch", func(args []string) {
+		env.setContainerExists("test-dev1", true)
+	})
+
+	if err := runContainerCreate(nil, []string{"dev1", "ubuntu:24.04"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !env.mock.HasCallPrefix("config", "device", "add", "test-dev1") {
+		t.Fatal("expected default mount to be added")
+	}
+
+	cfg := env.readConfig()
+	if !strings.Contains(cfg, "source: "+env.dir) {
+		t.Errorf("expected ${PROJECT_DIR} to expand to %s, got:\n%s", env.dir, cfg)
+	}
+	if !strings.Contains(cfg, "path: /workspace") {
+		t.Error("expected mount path in config")
+	}
+}
+
+func TestContainerCreate_MountProject(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers: {}
+`)
+	env.setContainerNotExists("test-dev1")
+	env.setLaunchSuccess()
+	env.mock.SetCallback("launch", func(args []string) {
+		env.setContainerExists("test-dev1", true)
+	})
+
+	createMountProject = "/workspace"
+	defer func() { createMountProject = "" }()
+
+	if err := runContainerCreate(nil, []string{"dev1", "ubuntu:24.04"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !env.mock.HasCallPrefix("config", "device", "add", "test-dev1", "project", "disk") {
+		t.Error("expected project directory mount")
+	}
+	if !env.mock.HasCall("config", "set", "test-dev1", "environment.WORKDIR", "/workspace") {
+		t.Error("expected WORKDIR to be exported")
+	}
+
+	cfg := env.readConfig()
+	if !strings.Contains(cfg, "workdir: /workspace") {
+		t.Error("expected workdir to be saved in config")
+	}
+	if !strings.Contains(cfg, "source: "+env.dir) {
+		t.Error("expected project directory as mount source")
+	}
+	if !strings.Contains(cfg, `shift: "true"`) {
+		t.Error("expected project mount to use shift")
+	}
+}
+
+func TestContainerCreate_AppliesDotfiles(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+defaults:
+  dotfiles:
+    repo: https://example.com/me/dotfiles.git
+containers: {}
+`)
+	env.setContainerNotExists("test-dev1")
+	env.setLaunchSuccess()
+	env.mock.SetCallback("launch", func(args []string) {
+		env.setContainerExists("test-dev1", true)
+	})
+
+	if err := runContainerCreate(nil, []string{"dev1", "ubuntu:24.04"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	dotfilesCall, snapshotCall := -1, -1
+	for i, call := range env.mock.Calls {
+		joined := strings.Join(call.Args, " ")
+		if strings.HasPrefix(joined, "exec test-dev1 -- su -l dev -c") && strings.Contains(joined, "'https://example.com/me/dotfiles.git'") {
+			dotfilesCall = i
+		}
+		if strings.HasPrefix(joined, "snapshot test-dev1 initial-state") {
+			snapshotCall = i
+		}
+	}
+	if dotfilesCall < 0 {
+		t.Fatalf("expected dotfiles to be applied as the user, got calls: %v", env.mock.Calls)
+	}
+	if snapshotCall >= 0 && snapshotCall < dotfilesCall {
+		t.Error("expected dotfiles to be applied before the initial snapshot")
+	}
+}
+
+func TestContainerCreate_UserEnvironment(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+defaults:
+  user:
+    shell: zsh
+    timezone: Europe/Paris
+    locale: fr_FR.UTF-8
+containers: {}
+`)
+	env.setContainerNotExists("test-dev1")
+	env.setLaunchSuccess()
+	env.mock.SetCallback("launch", func(args []string) {
+		env.setContainerExists("test-dev1", true)
+	})
+
+	if err := runContainerCreate(nil, []string{"dev1", "ubuntu:24.04"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, want := range []string{"chsh -s", "/usr/share/zoneinfo/$tz", "update-locale"} {
+		found := false
+		for _, call := range env.mock.Calls {
+			joined := strings.Join(call.Args, " ")
+			if strings.HasPrefix(joined, "exec test-dev1 -- bash -c") && strings.Contains(joined, want) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("expected a setup script containing %q", want)
+		}
+	}
+}
+
+func TestContainerCreate_Flags(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers: {}
+`)
+	env.setContainerNotExists("test-dev1")
+	env.setLaunchSuccess()
+	env.mock.SetCallback("launch", func(args []string) {
+		env.setContainerExists("test-dev1", true)
+	})
+
+	src := t.TempDir()
+	createPorts = "3000,5432"
+	createUser = "alice"
+	createMounts = []string{src + ":/src:ro"}
+	createNoStart = true
+	defer func() {
+		createPorts, createUser, createMounts, createNoStart = "", "", nil, false
+	}()
+
+	if err := runContainerCreate(nil, []string{"dev1", "ubuntu:24.04"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfg := env.readConfig()
+	for _, want := range []string{"- 3000", "- 5432", "name: alice"} {
+		if !strings.Contains(cfg, want) {
+			t.Errorf("expected %q in config:\n%s", want, cfg)
+		}
+	}
+	if !strings.Contains(cfg, "path: /src") || !strings.Contains(cfg, "readonly: \"true\"") {
+		t.Errorf("expected read-only /src mount in config:\n%s", cfg)
+	}
+	if !env.mock.HasCallPrefix("exec", "test-dev1", "--", "bash", "-c", "\n\t\t# Create user if not exists\n\t\tid alice") {
+		t.Error("expected user alice to be set up")
+	}
+	if !env.mock.HasCallPrefix("stop", "test-dev1") {
+		t.Error("expected container to be stopped with --no-start")
+	}
+}
+
+func TestContainerCreate_SkipSteps(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+defaults:
+  dotfiles:
+    repo: https://example.com/me/dotfiles.git
+containers: {}
+`)
+	env.setContainerNotExists("test-dev1")
+	env.setLaunchSuccess()
+	env.mock.SetCallback("launch", func(args []string) {
+		env.setContainerExists("test-dev1", true)
+	})
+
+	createNoUser, createNoSSH, createNoSnapshot = true, true, true
+	defer func() {
+		createNoUser, createNoSSH, createNoSnapshot = false, false, false
+	}()
+
+	if err := runContainerCreate(nil, []string{"dev1", "ubuntu:24.04"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, call := range env.mock.Calls {
+		joined := strings.Join(call.Args, " ")
+		switch {
+		case strings.Contains(joined, "# Create user if not exists"):
+			t.Error("expected no user setup with --no-user")
+		case strings.Contains(joined, "openssh-server"):
+			t.Error("expected no SSH setup with --no-ssh")
+		case strings.Contains(joined, "dotfiles.git"):
+			t.Error("expected dotfiles to be skipped with --no-user")
+		case strings.HasPrefix(joined, "snapshot "):
+			t.Error("expected no initial snapshot with --no-snapshot")
+		}
+	}
+	cfg := env.readConfig()
+	if !strings.Contains(cfg, "name: root") {
+		t.Errorf("expected the container to be saved with user root:\n%s", cfg)
+	}
+	if strings.Contains(cfg, "initial-state") {
+		t.Errorf("expected no initial-state snapshot in config:\n%s", cfg)
+	}
+}
+
+func TestContainerCreate_NoUserWithUser(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers: {}
+`)
+	env.setContainerNotExists("test-dev1")
+
+	createNoUser, createUser = true, "alice"
+	defer func() { createNoUser, createUser = false, "" }()
+
+	err := runContainerCreate(nil, []string{"dev1", "ubuntu:24.04"})
+	if err == nil || !strings.Contains(err.Error(), "skipping user setup") {
+		t.Fatalf("expected an error combining --no-user with --user, got %v", err)
+	}
+	if env.mock.HasCallPrefix("launch") {
+		t.Error("expected nothing to be launched")
+	}
+}
+
+func TestContainerCreate_RollsBackFailedSetup(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers: {}
+`)
+	env.setContainerNotExists("test-dev1")
+	env.mock.DefaultResponse = lxc.MockResponse{}
+	env.mock.SetOutput("exec test-dev1 -- cloud-init status", "status: done")
+	env.mock.SetError("exec test-dev1 -- bash -c \n\t\t# Install openssh-server", "apt-get failed")
+
+	err := runContainerCreate(nil, []string{"dev1", "ubuntu:24.04"})
+	if err == nil || !strings.Contains(err.Error(), "failed to enable SSH") {
+		t.Fatalf("expected the SSH setup error, got %v", err)
+	}
+	if !env.mock.HasCall("delete", "test-dev1", "--force") {
+		t.Error("expected the half-created container to be deleted")
+	}
+	if strings.Contains(env.readConfig(), "dev1") {
+		t.Error("container should not be added to config")
+	}
+}
+
+func TestContainerCreate_MountFailureRollsBack(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers: {}
+`)
+	env.setContainerNotExists("test-dev1")
+	env.setLaunchSuccess()
+	env.mock.SetCallback("launch", func(args []string) {
+		env.setContainerExists("test-dev1", true)
+	})
+	env.mock.SetError("config device add test-dev1", "device busy")
+
+	createMounts = []string{t.TempDir() + ":/src:ro"}
+	defer func() { createMounts = nil }()
+
+	err := runContainerCreate(nil, []string{"dev1", "ubuntu:24.04"})
+	if err == nil || !strings.Contains(err.Error(), "-> '/src' failed") {
+		t.Fatalf("expected the mount error, got %v", err)
+	}
+	if strings.Contains(err.Error(), "container created") {
+		t.Errorf("a rolled back container shouldn't be reported as created: %v", err)
+	}
+	if !env.mock.HasCall("delete", "test-dev1", "--force") {
+		t.Error("expected the container to be rolled back")
+	}
+	if strings.Contains(env.readConfig(), "dev1") {
+		t.Error("container should be removed from config")
+	}
+}
+
+func TestContainerCreate_NoRollback(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers: {}
+`)
+	env.setContainerNotExists("test-dev1")
+	env.mock.DefaultResponse = lxc.MockResponse{}
+	env.mock.SetOutput("exec test-dev1 -- cloud-init status", "status: done")
+	env.mock.SetError("exec test-dev1 -- bash -c \n\t\t# Install openssh-server", "apt-get failed")
+	env.mock.SetCallback("launch", func(args []string) {
+		env.setContainerExists("test-dev1", true)
+	})
+
+	createNoRollback = true
+	defer func() { createNoRollback = false }()
+
+	if err := runContainerCreate(nil, []string{"dev1", "ubuntu:24.04"}); err == nil {
+		t.Fatal("expected the SSH setup error")
+	}
+	if env.mock.HasCallPrefix("delete", "test-dev1") {
+		t.Error("expected the failed container to be kept with --no-rollback")
+	}
+	cfg := env.readConfig()
+	if !strings.Contains(cfg, "pending_setup:\n            - ssh\n            - snapshot") {
+		t.Errorf("expected the kept container to be recorded with its pending steps:\n%s", cfg)
+	}
+}
+
+func TestContainerCreate_InvalidMountSpec(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers: {}
+`)
+	createMounts = []string{"/src"}
+	defer func() { createMounts = nil }()
+
+	err := runContainerCreate(nil, []string{"dev1", "ubuntu:24.04"})
+	if err == nil || !strings.Contains(err.Error(), "source:path") {
+		t.Fatalf("expected mount spec error, got %v", err)
+	}
+	if env.mock.HasCallPrefix("launch") {
+		t.Error("should not launch with an invalid mount spec")
+	}
+}
+
+func TestContainerCreate_Several(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers: {}
+`)
+	names := []string{"dev1", "dev2", "dev3"}
+	for _, name := range names {
+		env.setContainerNotExists("test-" + name)
+	}
+	env.setLaunchSuccess()
+
+	createParallel = 2
+	defer func() { createParallel = 4 }()
+
+	if err := runContainerCreate(nil, append(names, "ubuntu:24.04")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfg := env.readConfig()
+	for _, name := range names {
+		if !env.mock.HasCall("launch", "ubuntu:24.04", "test-"+name) {
+			t.Errorf("expected launch of test-%s", name)
+		}
+		if !strings.Contains(cfg, "  "+name+":") {
+			t.Errorf("expected %s to be saved in config", name)
+		}
+	}
+}
+
+func TestContainerCreate_SeveralValidatedFirst(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers:
+  dev2:
+    image: ubuntu:24.04
+`)
+	env.setContainerNotExists("test-dev1")
+	env.setLaunchSuccess()
+
+	err := runContainerCreate(nil, []string{"dev1", "dev2", "ubuntu:24.04"})
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("expected already exists error, got %v", err)
+	}
+	if env.mock.HasCallPrefix("launch") {
+		t.Error("nothing should be launched when any name is invalid")
+	}
+}
+
+func TestContainerCreate_SeveralRejectsStaticIP(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers: {}
+`)
+	env.setContainerNotExists("test-dev1")
+	env.setContainerNotExists("test-dev2")
+
+	createIP = "10.10.10.50"
+	defer func() { createIP = "" }()
+
+	if err := runContainerCreate(nil, []string{"dev1", "dev2", "ubuntu:24.04"}); err == nil {
+		t.Fatal("expected error for --ip with several names")
+	}
+}
+
+func TestContainerCreate_PullsFromImageRemote(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+image_remote: team
+containers: {}
+`)
+	env.setContainerNotExists("test-dev1")
+	env.setLaunchSuccess()
+
+	if err := runContainerCreate(nil, []string{"dev1", "webapp-base"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !env.mock.HasCall("image", "copy", "team:webapp-base", "local:", "--alias", "webapp-base") {
+		t.Errorf("expected the image to be pulled, got calls %v", env.mock.Calls)
+	}
+	if !env.mock.HasCallPrefix("launch", "webapp-base", "test-dev1") {
+		t.Error("expected launch from the pulled alias")
+	}
+}
+
+func TestContainerCreate_ImageRemoteSkipsLocalAndRemoteImages(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+image_remote: team
+containers: {}
+`)
+	env.setContainerNotExists("test-dev1")
+	env.setContainerNotExists("test-dev2")
+	env.setLaunchSuccess()
+	env.mock.SetOutput("image list webapp-base --format=csv -c f", "abc123")
+
+	if err := runContainerCreate(nil, []string{"dev1", "webapp-base"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := runContainerCreate(nil, []string{"dev2", "ubuntu:24.04"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if env.mock.HasCallPrefix("image", "copy") {
+		t.Error("expected no pull for a local image or one with a remote")
+	}
+}
+
+func TestContainerCreate_ArchSelectsRemoteVariant(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers: {}
+`)
+	env.setContainerNotExists("test-dev1")
+	env.setLaunchSuccess()
+	env.mock.SetOutput("query /1.0", `{"environment":{"architectures":["x86_64","i686"]}}`)
+
+	createArch = "i386"
+	defer func() { createArch = "" }()
+
+	if err := runContainerCreate(nil, []string{"dev1", "images:debian/12"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !env.mock.HasCall("launch", "images:debian/12/i386", "test-dev1") {
+		t.Errorf("expected the i386 variant to be launched, got calls %v", env.mock.Calls)
+	}
+}
+
+func TestContainerCreate_ArchMismatchLocalImage(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers: {}
+`)
+	env.setContainerNotExists("test-dev1")
+	env.setLaunchSuccess()
+	env.mock.SetOutput("query /1.0/images?recursion=1", `[
+ {"fingerprint":"abc123","aliases":[{"name":"webapp-base"}],"architecture":"x86_64"}
+]`)
+
+	createArch = "arm64"
+	defer func() { createArch = "" }()
+
+	err := runContainerCreate(nil, []string{"dev1", "webapp-base"})
+	if err == nil || !strings.Contains(err.Error(), "is x86_64, not aarch64") {
+		t.Fatalf("expected architecture mismatch error, got %v", err)
+	}
+	if env.mock.HasCallPrefix("launch") {
+		t.Error("expected no launch for a mismatched image")
+	}
+}
+
+func TestContainerCreate_InvalidArch(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers: {}
+`)
+	env.setContainerNotExists("test-dev1")
+
+	createArch = "arm"
+	defer func() { createArch = "" }()
+
+	if err := runContainerCreate(nil, []string{"dev1", "ubuntu:24.04"}); err == nil {
+		t.Fatal("expected error for unknown architecture")
+	}
+}
+
+func TestContainerCreate_DefaultImage(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+defaults:
+  image: ubuntu:22.04
+containers: {}
+`)
+	env.setContainerNotExists("test-dev3")
+	env.setLaunchSuccess()
+
+	if err := runContainerCreate(nil, []string{"dev3"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !env.mock.HasCall("launch", "ubuntu:22.04", "test-dev3") {
+		t.Errorf("expected launch from defaults.image, got calls %v", env.mock.Calls)
+	}
+	if !strings.Contains(env.readConfig(), "image: ubuntu:22.04") {
+		t.Error("expected the default image to be saved for the container")
+	}
+}
+
+func TestContainerCreate_NoImage(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers: {}
+`)
+	env.setContainerNotExists("test-dev3")
+
+	err := runContainerCreate(nil, []string{"dev3"})
+	if err == nil || !strings.Contains(err.Error(), "defaults.image") {
+		t.Fatalf("expected defaults.image error, got %v", err)
+	}
+	if env.mock.HasCallPrefix("launch") {
+		t.Error("expected no launch")
+	}
+}
+
+func TestContainerCreate_DefaultSyncAndSetup(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+defaults:
+  sync:
+    - source: .env
+      dest: /home/dev/app/.env
+  setup:
+    - make deps
+containers: {}
+`)
+	if err := os.WriteFile(filepath.Join(env.dir, ".env"), []byte("KEY=1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	env.setContainerNotExists("test-dev1")
+	env.setLaunchSuccess()
+	env.mock.SetCallback("launch", func(args []string) {
+		env.setContainerExists("test-dev1", true)
+	})
+
+	if err := runContainerCreate(nil, []string{"dev1", "ubuntu:24.04"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !env.mock.HasCallPrefix("file", "push") {
+		t.Errorf("expected the default sync entry to be pushed, got calls %v", env.mock.Calls)
+	}
+	setupCall, snapshotCall := -1, -1
+	for i, call := range env.mock.Calls {
+		joined := strings.Join(call.Args, " ")
+		if strings.HasPrefix(joined, "exec test-dev1 -- su -l dev -c") && strings.Contains(joined, "make deps") {
+			setupCall = i
+		}
+		if strings.HasPrefix(joined, "snapshot test-dev1 initial-state") {
+			snapshotCall = i
+		}
+	}
+	if setupCall < 0 {
+		t.Fatalf("expected defaults.setup to run as the user, got calls %v", env.mock.Calls)
+	}
+	if snapshotCall >= 0 && snapshotCall < setupCall {
+		t.Error("expected setup to run before the initial snapshot")
+	}
+	if !strings.Contains(env.readConfig(), "dest: /home/dev/app/.env") {
+		t.Error("expected the default sync entry to be added to the container")
+	}
+}
+
+func TestContainerCreate_Privileged(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers: {}
+`)
+	env.setContainerNotExists("test-dev1")
+	env.setLaunchSuccess()
+
+	createPrivileged = true
+	assumeYes = true
+	defer func() { createPrivileged, assumeYes = false, false }()
+
+	if err := runContainerCreate(nil, []string{"dev1", "ubuntu:24.04"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !env.mock.HasCall("init", "ubuntu:24.04", "test-dev1") {
+		t.Error("privileged containers should be created with init")
+	}
+	if !env.mock.HasCall("config", "set", "test-dev1", "security.privileged", "true") {
+		t.Error("expected security.privileged to be set before the first start")
+	}
+	if !env.mock.HasCall("start", "test-dev1") {
+		t.Error("expected container to be started")
+	}
+	if !strings.Contains(env.readConfig(), "privileged: true") {
+		t.Error("expected privileged to be saved in config")
+	}
+}
+
+func TestContainerCreate_PrivilegedNeedsConfirmation(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+defaults:
+  privileged: true
+containers: {}
+`)
+	env.setContainerNotExists("test-dev1")
+	env.setLaunchSuccess()
+	withStdinTerminal(t, false)
+
+	err := runContainerCreate(nil, []string{"dev1", "ubuntu:24.04"})
+	if err == nil || !strings.Contains(err.Error(), "--yes") {
+		t.Fatalf("expected confirmation error, got %v", err)
+	}
+	if env.mock.HasCallPrefix("init") || env.mock.HasCallPrefix("launch") {
+		t.Error("should not create a privileged container without confirmation")
+	}
+}
+
+func TestContainerCreate_PrivilegedRejectsReadWriteMount(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers: {}
+`)
+	env.setContainerNotExists("test-dev1")
+	env.setLaunchSuccess()
+
+	createPrivileged = true
+	assumeYes = true
+	createMounts = []string{t.TempDir() + ":/src"}
+	defer func() { createPrivileged, assumeYes, createMounts = false, false, nil }()
+
+	err := runContainerCreate(nil, []string{"dev1", "ubuntu:24.04"})
+	if err == nil || !strings.Contains(err.Error(), "read-write mounts are disabled for privileged containers") {
+		t.Fatalf("expected read-write mount error, got %v", err)
+	}
+	if env.mock.HasCallPrefix("init") || env.mock.HasCallPrefix("launch") {
+		t.Error("should not launch when a mount would be refused")
+	}
+}
+
+func TestContainerCreate_NestingDisabled(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+defaults:
+  nesting: false
+containers: {}
+`)
+	env.setContainerNotExists("test-dev1")
+	env.setLaunchSuccess()
+
+	if err := runContainerCreate(nil, []string{"dev1", "ubuntu:24.04"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if env.mock.HasCallPrefix("config", "set", "test-dev1", "security.nesting") {
+		t.Error("nesting should not be enabled with defaults.nesting: false")
+	}
+	if !strings.Contains(env.readConfig(), "nesting: false") {
+		t.Error("expected nesting: false to be recorded for the container")
+	}
+}
+
+func TestContainerCreate_NestingFailureIsReported(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+defaults:
+  nesting: true
+containers: {}
+`)
+	env.setContainerNotExists("test-dev1")
+	env.setLaunchSuccess()
+	env.mock.SetError("config set test-dev1 security.nesting", "not allowed")
+
+	err := runContainerCreate(nil, []string{"dev1", "ubuntu:24.04"})
+	if err == nil || !strings.Contains(err.Error(), "failed to enable nesting") {
+		t.Fatalf("expected nesting error, got %v", err)
+	}
+	if !env.mock.HasCall("delete", "test-dev1", "--force") {
+		t.Error("expected the half-created container to be deleted")
+	}
+}
+
+func TestContainerCreate_AptProxy(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+defaults:
+  apt_proxy: http://10.10.10.1:3142
+containers: {}
+`)
+	env.setContainerNotExists("test-dev1")
+	env.setLaunchSuccess()
+
+	if err := runContainerCreate(nil, []string{"dev1", "ubuntu:24.04"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	found := false
+	for _, call := range env.mock.Calls {
+		script := strings.Join(call.Args, " ")
+		if strings.HasPrefix(script, "exec test-dev1") && strings.Contains(script, "'http://10.10.10.1:3142' > /etc/apt/apt.conf.d/") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected the apt proxy to be written, got calls %v", env.mock.Calls)
+	}
+}
+
+func TestContainerCreate_MotdAndShellHistory(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+defaults:
+  ports: [5173, 8000]
+  motd: true
+  shell_history: .history
+containers: {}
+`)
+	env.setContainerNotExists("test-dev1")
+	env.setLaunchSuccess()
+	env.mock.SetCallback("launch", func(args []string) {
+		env.setContainerExists("test-dev1", true)
+	})
+
+	if err := runContainerCreate(nil, []string{"dev1", "ubuntu:24.04"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var motd, history bool
+	for _, call := range env.mock.Calls {
+		script := strings.Join(call.Args, " ")
+		if !strings.HasPrefix(script, "exec test-dev1") {
+			continue
+		}
+		if strings.Contains(script, "Image:   ubuntu:24.04") && strings.Contains(script, "Ports:   5173, 8000") {
+			motd = true
+		}
+		if strings.Contains(script, "HISTFILE=") && strings.Contains(script, lxc.HistoryDir+"/dev1") {
+			history = true
+		}
+	}
+	if !motd {
+		t.Errorf("expected the motd to be written, got calls %v", env.mock.Calls)
+	}
+	if !history {
+		t.Errorf("expected HISTFILE to be set, got calls %v", env.mock.Calls)
+	}
+	if info, err := os.Stat(".history"); err != nil || !info.IsDir() {
+		t.Errorf("expected the history directory to be created: %v", err)
+	}
+	if !strings.Contains(env.readConfig(), "path: "+lxc.HistoryDir) {
+		t.Errorf("expected the history mount in config, got:\n%s", env.readConfig())
+	}
+}
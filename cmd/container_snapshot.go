@@ -59,7 +59,7 @@ func runSnapshotCreate(cmd *cobra.Command, args []string) error {
 	snapshotName := args[1]
 
 	// Load config with lock to prevent race conditions
-	cfg, _, lock, err := requireContainerWithLock(containerName)
+	cfg, _, lock, err := requireContainerWithLock(&containerName)
 	if err != nil {
 		return err
 	}
@@ -79,7 +79,7 @@ func runSnapshotCreate(cmd *cobra.Command, args []string) error {
 func runSnapshotList(cmd *cobra.Command, args []string) error {
 	containerName := args[0]
 
-	cfg, _, err := requireContainer(containerName)
+	cfg, _, err := requireContainer(&containerName)
 	if err != nil {
 		return err
 	}
@@ -120,7 +120,7 @@ func runSnapshotDelete(cmd *cobra.Command, args []string) error {
 	snapshotName := args[1]
 
 	// Load config with lock to prevent race conditions
-	cfg, _, lock, err := requireContainerWithLock(containerName)
+	cfg, _, lock, err := requireContainerWithLock(&containerName)
 	if err != nil {
 		return err
 	}
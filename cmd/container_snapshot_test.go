@@ -227,3 +227,120 @@ containers: {}
 		t.Errorf("unexpected error: %v", err)
 	}
 }
+
+func TestContainerRebase(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+    snapshots:
+      initial-state:
+        description: Initial state after setup
+        created_at: "2024-05-02T10:14:03Z"
+`)
+	env.setContainerExists("test-dev1", true)
+	env.mock.SetOutput("info test-dev1/initial-state", "Name: initial-state")
+
+	rebaseForce = true
+	defer func() { rebaseForce = false }()
+
+	captureStdout(t, func() {
+		if err := runContainerRebase(nil, []string{"dev1"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	if !env.mock.HasCallPrefix("snapshot", "test-dev1") {
+		t.Fatal("expected a snapshot of the current state")
+	}
+	if !env.mock.HasCall("delete", "test-dev1/initial-state") {
+		t.Error("expected the old initial-state to be deleted")
+	}
+	var snapshotAt, deleteAt int
+	for i, call := range env.mock.Calls {
+		switch strings.Join(call.Args, " ") {
+		case "delete test-dev1/initial-state":
+			deleteAt = i
+		default:
+			if len(call.Args) == 3 && call.Args[0] == "snapshot" {
+				snapshotAt = i
+				if temp := "test-dev1/" + call.Args[2]; !env.mock.HasCall("move", temp, "test-dev1/initial-state") {
+					t.Errorf("expected %s to be renamed to initial-state", temp)
+				}
+			}
+		}
+	}
+	if snapshotAt > deleteAt {
+		t.Error("deleted the old initial-state before taking the new one")
+	}
+	cfg := env.readConfig()
+	if strings.Contains(cfg, "2024-05-02") || !strings.Contains(cfg, "rebased") {
+		t.Errorf("expected the snapshot to be recorded again:\n%s", cfg)
+	}
+}
+
+func TestContainerRebase_SnapshotFails(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+`)
+	env.setContainerExists("test-dev1", true)
+	env.mock.SetOutput("info test-dev1/initial-state", "Name: initial-state")
+	env.mock.SetError("snapshot test-dev1", "no space left")
+
+	rebaseForce = true
+	defer func() { rebaseForce = false }()
+
+	err := runContainerRebase(nil, []string{"dev1"})
+	if err == nil || !strings.Contains(err.Error(), "failed to create snapshot") {
+		t.Fatalf("expected the snapshot error, got %v", err)
+	}
+	if env.mock.HasCallPrefix("delete") {
+		t.Error("deleted the old initial-state although the new one failed")
+	}
+}
+
+func TestContainerRebase_RenameFails(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+`)
+	env.setContainerExists("test-dev1", true)
+	env.mock.SetOutput("info test-dev1/initial-state", "Name: initial-state")
+	env.mock.SetError("move test-dev1/", "storage error")
+
+	rebaseForce = true
+	defer func() { rebaseForce = false }()
+
+	err := runContainerRebase(nil, []string{"dev1"})
+	if err == nil || !strings.Contains(err.Error(), "container repair") {
+		t.Fatalf("expected an error pointing to repair, got %v", err)
+	}
+	cfg := env.readConfig()
+	if !strings.Contains(cfg, "rebase-") || !strings.Contains(cfg, "- snapshot") {
+		t.Errorf("expected the kept snapshot and the pending initial-state to be recorded:\n%s", cfg)
+	}
+}
+
+func TestContainerRebase_NeedsConfirmation(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+	env.setContainerExists("dev1", true)
+
+	output := captureStdout(t, func() {
+		if err := runContainerRebase(nil, []string{"dev1"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if !strings.Contains(output, "Cancelled") {
+		t.Errorf("expected the rebase to be cancelled, got: %s", output)
+	}
+	if env.mock.HasCallPrefix("delete") {
+		t.Error("deleted the snapshot without confirmation")
+	}
+}
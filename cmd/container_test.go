@@ -1,8 +1,13 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"lxc-dev-manager/internal/config"
 )
 
 func TestContainerReset_DefaultSnapshot(t *testing.T) {
@@ -28,6 +33,29 @@ containers:
 	}
 }
 
+func TestContainerReset_StopTimeout(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+    stop_timeout: 30s
+`)
+	env.setContainerExists("test-dev1", true)
+	env.mock.SetOutput("info test-dev1/initial-state", "Name: initial-state")
+	env.mock.SetError("stop test-dev1 --timeout=30", "timed out")
+
+	if err := runContainerReset(nil, []string{"dev1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !env.mock.HasCall("stop", "test-dev1", "--timeout=30") {
+		t.Errorf("expected the configured stop_timeout, got %v", env.mock.Calls)
+	}
+	if !env.mock.HasCall("stop", "test-dev1", "--force") {
+		t.Error("expected a container that doesn't stop in time to be forced")
+	}
+}
+
 func TestContainerReset_NamedSnapshot(t *testing.T) {
 	env := setupTestEnv(t)
 	env.writeConfig(`project: test
@@ -51,6 +79,251 @@ containers:
 	}
 }
 
+func TestContainerReset_SnapshotFirst(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        string
+		snapshotFirst bool
+	}{
+		{"flag", "", true},
+		{"auto_snapshot", "reset:\n  auto_snapshot: true\n", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := setupTestEnv(t)
+			env.writeConfig(`project: test
+` + tt.config + `containers:
+  dev1:
+    image: ubuntu:24.04
+`)
+			env.setContainerExists("test-dev1", true)
+			env.mock.SetOutput("info test-dev1/initial-state", "Name: initial-state")
+			env.mock.SetError("info test-dev1/pre-reset-", "not found")
+
+			resetSnapshotFirst = tt.snapshotFirst
+			defer func() { resetSnapshotFirst = false }()
+
+			out := captureStdout(t, func() {
+				if err := runContainerReset(nil, []string{"dev1"}); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			})
+			if !strings.Contains(out, "Kept the previous state as snapshot 'pre-reset-") {
+				t.Errorf("unexpected output:\n%s", out)
+			}
+
+			snapshotted := false
+			for _, call := range env.mock.Calls {
+				args := strings.Join(call.Args, " ")
+				if strings.HasPrefix(args, "snapshot test-dev1 pre-reset-") {
+					snapshotted = true
+				}
+				if strings.HasPrefix(args, "restore ") && !snapshotted {
+					t.Fatal("restored before taking the pre-reset snapshot")
+				}
+			}
+			if !snapshotted {
+				t.Error("expected a pre-reset snapshot")
+			}
+			if !strings.Contains(env.readConfig(), "pre-reset-") {
+				t.Errorf("expected the snapshot in config:\n%s", env.readConfig())
+			}
+		})
+	}
+}
+
+func TestContainerReset_SnapshotFirstFailureKeepsState(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+`)
+	env.setContainerExists("test-dev1", true)
+	env.mock.SetOutput("info test-dev1/initial-state", "Name: initial-state")
+	env.mock.SetError("info test-dev1/pre-reset-", "not found")
+	env.mock.SetError("snapshot test-dev1", "no space left")
+
+	resetSnapshotFirst = true
+	defer func() { resetSnapshotFirst = false }()
+
+	err := runContainerReset(nil, []string{"dev1"})
+	if err == nil || !strings.Contains(err.Error(), "pre-reset snapshot") {
+		t.Fatalf("expected pre-reset snapshot error, got %v", err)
+	}
+	if env.mock.HasCallPrefix("restore") {
+		t.Error("restored although the pre-reset snapshot failed")
+	}
+}
+
+// writeResetDevicesConfig writes a project whose dev1 has two mounts and a
+// serial device, and makes the mock report the devices as restored from an
+// older snapshot: repo points elsewhere, data and serial are missing, and
+// old was unmounted after the snapshot
+func writeResetDevicesConfig(t *testing.T, env *testEnv) (repo, data string) {
+	t.Helper()
+	repo = filepath.Join(env.dir, "repo")
+	data = filepath.Join(env.dir, "data")
+	for _, dir := range []string{repo, data} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	env.writeConfig(fmt.Sprintf(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+    devices:
+      repo:
+        type: disk
+        config:
+          source: %s
+          path: /repo
+      data:
+        type: disk
+        config:
+          source: %s
+          path: /data
+      serial:
+        type: unix-char
+        config:
+          source: /dev/ttyUSB0
+`, repo, data))
+	env.setContainerExists("test-dev1", true)
+	env.mock.SetOutput("info test-dev1/initial-state", "Name: initial-state")
+	env.mock.SetOutput("config device show test-dev1", fmt.Sprintf(`repo:
+  type: disk
+  source: %s
+  path: /repo
+old:
+  type: disk
+  source: %s
+  path: /old
+root:
+  type: disk
+  path: /
+  pool: default
+  size: 20GiB
+`, filepath.Join(env.dir, "old-repo"), filepath.Join(env.dir, "old")))
+	return repo, data
+}
+
+func TestContainerReset_ReconcilesDevices(t *testing.T) {
+	env := setupTestEnv(t)
+	repo, data := writeResetDevicesConfig(t, env)
+
+	captureStdout(t, func() {
+		if err := runContainerReset(nil, []string{"dev1"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	for _, call := range [][]string{
+		{"config", "device", "remove", "test-dev1", "repo"},
+		{"config", "device", "add", "test-dev1", "repo", "disk", "path=/repo", "source=" + repo},
+		{"config", "device", "add", "test-dev1", "data", "disk", "path=/data", "source=" + data},
+		{"config", "device", "add", "test-dev1", "serial", "unix-char", "source=/dev/ttyUSB0"},
+	} {
+		if !env.mock.HasCall(call...) {
+			t.Errorf("expected call %v", call)
+		}
+	}
+	if env.mock.HasCall("config", "device", "remove", "test-dev1", "old") {
+		t.Error("a device not in the config was removed without --prune-devices")
+	}
+	if env.mock.HasCall("config", "device", "remove", "test-dev1", "root") {
+		t.Error("the root disk override was removed")
+	}
+}
+
+func TestContainerReset_PruneDevices(t *testing.T) {
+	env := setupTestEnv(t)
+	writeResetDevicesConfig(t, env)
+
+	resetPruneDevices = true
+	defer func() { resetPruneDevices = false }()
+
+	captureStdout(t, func() {
+		if err := runContainerReset(nil, []string{"dev1"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if !env.mock.HasCall("config", "device", "remove", "test-dev1", "old") {
+		t.Error("expected the device not in the config to be removed with --prune-devices")
+	}
+	if env.mock.HasCall("config", "device", "remove", "test-dev1", "root") {
+		t.Error("the root disk override was removed")
+	}
+}
+
+func TestContainerReset_BlockedDeviceNotReadded(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+    devices:
+      etc:
+        type: disk
+        config:
+          source: /etc
+          path: /host-etc
+      serial:
+        type: unix-char
+        config:
+          source: /dev/ttyUSB0
+`)
+	env.setContainerExists("test-dev1", true)
+	env.mock.SetOutput("info test-dev1/initial-state", "Name: initial-state")
+
+	var err error
+	captureStdout(t, func() {
+		err = runContainerReset(nil, []string{"dev1"})
+	})
+	if err == nil || !strings.Contains(err.Error(), "device 'etc'") {
+		t.Fatalf("expected the blocked mount to be reported, got %v", err)
+	}
+	if env.mock.HasCallPrefix("config", "device", "add", "test-dev1", "etc") {
+		t.Error("re-added a mount of a blocked host path")
+	}
+	if !env.mock.HasCallPrefix("config", "device", "add", "test-dev1", "serial") {
+		t.Error("expected the other devices to still be re-added")
+	}
+	if !env.mock.HasCall("start", "test-dev1") {
+		t.Error("expected the container to be restarted")
+	}
+}
+
+func TestContainerReset_Sync(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+    sync:
+      - source: .env
+        dest: /app/.env
+`)
+	if err := os.WriteFile(filepath.Join(env.dir, ".env"), []byte("KEY=1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	env.setContainerExists("test-dev1", true)
+	env.mock.SetOutput("info test-dev1/initial-state", "Name: initial-state")
+
+	resetSync = true
+	defer func() { resetSync = false }()
+
+	captureStdout(t, func() {
+		if err := runContainerReset(nil, []string{"dev1"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if !env.mock.HasCallPrefix("file", "push") {
+		t.Errorf("expected the sync entry to be pushed, got %v", env.mock.Calls)
+	}
+}
+
 func TestContainerReset_StoppedContainer(t *testing.T) {
 	env := setupTestEnv(t)
 	env.writeConfig(`project: test
@@ -186,6 +459,119 @@ containers:
 	}
 }
 
+func TestContainerClone_Several(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+`)
+	env.setContainerExists("test-dev1", false)
+	for _, name := range []string{"test-dev2", "test-dev3", "test-dev4"} {
+		env.setContainerNotExists(name)
+	}
+
+	cloneSnapshot = ""
+	if err := runContainerClone(nil, []string{"dev1", "dev2", "dev3", "dev4"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfg, err := config.Load(env.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"dev2", "dev3", "dev4"} {
+		if !env.mock.HasCall("copy", "test-dev1", "test-"+name) {
+			t.Errorf("expected copy to test-%s", name)
+		}
+		if _, ok := cfg.Containers[name].Snapshots["initial-state"]; !ok {
+			t.Errorf("expected %s in config with an initial-state snapshot", name)
+		}
+	}
+}
+
+func TestContainerClone_CopiesDefinition(t *testing.T) {
+	env := setupTestEnv(t)
+	src := t.TempDir()
+	env.writeConfig(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+    ip: 10.10.10.50
+    ports: [3000, 5432]
+    user:
+      name: alice
+      password: secret
+    sync:
+      - source: ./app
+        dest: /app
+    devices:
+      src:
+        type: disk
+        config:
+          source: ` + src + `
+          path: /src
+    snapshots:
+      checkpoint:
+        created_at: "2024-01-01T00:00:00Z"
+`)
+	env.setContainerExists("test-dev1", false)
+	env.setContainerNotExists("test-dev2")
+	env.mock.SetOutput("copy test-dev1 test-dev2", "")
+
+	cloneSnapshot = ""
+	if err := runContainerClone(nil, []string{"dev1", "dev2"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfg, err := config.Load(env.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clone := cfg.Containers["dev2"]
+	if len(clone.Ports) != 2 || clone.User.Name != "alice" || len(clone.Sync) != 1 {
+		t.Errorf("expected ports, user and sync to be copied, got %+v", clone)
+	}
+	if clone.Devices["src"].Config["source"] != src {
+		t.Errorf("expected devices to be copied, got %v", clone.Devices)
+	}
+	if clone.IP != "" {
+		t.Error("static IP should not be copied")
+	}
+	if _, ok := clone.Snapshots["checkpoint"]; ok {
+		t.Error("source snapshots should not be copied")
+	}
+	if !env.mock.HasCall("config", "device", "unset", "test-dev2", "eth0", "ipv4.address") {
+		t.Error("expected the static IP to be cleared on the clone")
+	}
+}
+
+func TestContainerClone_InvalidDeviceSource(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+    devices:
+      gone:
+        type: disk
+        config:
+          source: /nonexistent/path/for/clone
+          path: /data
+`)
+	env.setContainerExists("test-dev1", false)
+	env.setContainerNotExists("test-dev2")
+
+	cloneSnapshot = ""
+	err := runContainerClone(nil, []string{"dev1", "dev2"})
+	if err == nil || !strings.Contains(err.Error(), "gone") {
+		t.Fatalf("expected device source error, got %v", err)
+	}
+	if env.mock.HasCallPrefix("copy") {
+		t.Error("should not copy when a device source is invalid")
+	}
+}
+
 func TestContainerClone_FromSnapshot(t *testing.T) {
 	env := setupTestEnv(t)
 	env.writeConfig(`project: test
@@ -285,3 +671,417 @@ func TestContainerClone_NoProject(t *testing.T) {
 		t.Errorf("unexpected error: %v", err)
 	}
 }
+
+func TestContainerResize_Success(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+`)
+	env.setContainerExists("test-dev1", true)
+	env.mock.SetOutput("query /1.0/instances/test-dev1", `{"expanded_devices":{"root":{"type":"disk","path":"/","pool":"default"}}}`)
+	env.mock.SetOutput("query /1.0/storage-pools/default/resources", `{"space":{"used":10737418240,"total":107374182400}}`)
+	env.mock.SetOutput("config device override test-dev1 root", "")
+
+	if err := runContainerResize(nil, []string{"dev1", "30GiB"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !env.mock.HasCall("config", "device", "override", "test-dev1", "root", "size=30GiB") {
+		t.Error("expected root device override with size")
+	}
+	if !strings.Contains(env.readConfig(), "disk: 30GiB") {
+		t.Error("expected disk size to be saved in config")
+	}
+}
+
+func TestContainerResize_ExceedsPool(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+`)
+	env.setContainerExists("test-dev1", true)
+	env.mock.SetOutput("query /1.0/instances/test-dev1", `{"expanded_devices":{"root":{"type":"disk","path":"/","pool":"default"}}}`)
+	env.mock.SetOutput("query /1.0/storage-pools/default/resources", `{"space":{"used":0,"total":107374182400}}`)
+
+	err := runContainerResize(nil, []string{"dev1", "200GiB"})
+	if err == nil || !strings.Contains(err.Error(), "exceeds the capacity") {
+		t.Fatalf("expected capacity error, got: %v", err)
+	}
+	if env.mock.HasCallPrefix("config", "device", "override") {
+		t.Error("should not resize beyond pool capacity")
+	}
+}
+
+func TestContainerResize_InvalidSize(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+	env.setContainerExists("dev1", true)
+
+	if err := runContainerResize(nil, []string{"dev1", "lots"}); err == nil {
+		t.Fatal("expected error for invalid size")
+	}
+}
+
+func TestContainerMove_ToProject(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: webapp
+containers:
+  dev1:
+    image: ubuntu:24.04
+    sync:
+      - source: ./app
+        dest: /app
+    snapshots:
+      checkpoint:
+        created_at: "2024-01-01T00:00:00Z"
+  dev2:
+    image: ubuntu:24.04
+`)
+	destDir := filepath.Join(env.dir, "other")
+	if err := os.Mkdir(destDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(destDir, "containers.yaml"), []byte("project: api\ncontainers: {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	env.setContainerExists("webapp-dev1", true)
+	env.setContainerNotExists("api-dev1")
+
+	if err := runContainerMove(nil, []string{"dev1", destDir}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !env.mock.HasCall("move", "webapp-dev1", "api-dev1") {
+		t.Error("expected LXC container to be renamed")
+	}
+	if !env.mock.HasCall("start", "api-dev1") {
+		t.Error("expected running container to be restarted")
+	}
+
+	src, _ := config.Load(env.dir)
+	if src.HasContainer("dev1") || !src.HasContainer("dev2") {
+		t.Errorf("expected only dev1 to leave the source config, got %v", src.Containers)
+	}
+	dest, _ := config.Load(destDir)
+	moved, ok := dest.Containers["dev1"]
+	if !ok {
+		t.Fatal("expected dev1 in destination config")
+	}
+	if _, ok := moved.Snapshots["checkpoint"]; !ok {
+		t.Error("expected snapshot metadata to move")
+	}
+	if len(moved.Sync) != 1 || moved.Sync[0].Source != filepath.Join("..", "app") {
+		t.Errorf("expected sync source rebased to ../app, got %v", moved.Sync)
+	}
+}
+
+func TestContainerMove_NameTaken(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: webapp
+containers:
+  dev1:
+    image: ubuntu:24.04
+`)
+	destDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(destDir, "containers.yaml"), []byte("project: api\ncontainers:\n  dev1:\n    image: debian/12\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	env.setContainerExists("webapp-dev1", false)
+
+	err := runContainerMove(nil, []string{"dev1", destDir})
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("expected name conflict error, got %v", err)
+	}
+	if env.mock.HasCallPrefix("move") {
+		t.Error("should not rename on conflict")
+	}
+}
+
+func TestContainerSetDescription(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+
+	if err := runContainerSetDescription(nil, []string{"dev1", "repro", "for", "the", "TLS", "bug"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(env.readConfig(), "description: repro for the TLS bug") {
+		t.Errorf("expected description in config:\n%s", env.readConfig())
+	}
+
+	if err := runContainerSetDescription(nil, []string{"dev1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(env.readConfig(), "description:") {
+		t.Error("expected description to be cleared")
+	}
+}
+
+func TestContainerSetDescription_NotFound(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+
+	err := runContainerSetDescription(nil, []string{"nope", "notes"})
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
+
+func TestContainerSetNesting(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+	env.setContainerExists("dev1", true)
+
+	if err := runContainerSetNesting(nil, []string{"dev1", "false"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !env.mock.HasCall("config", "set", "dev1", "security.nesting", "false") {
+		t.Error("expected security.nesting to be turned off")
+	}
+	if !strings.Contains(env.readConfig(), "nesting: false") {
+		t.Errorf("expected nesting to be recorded in config:\n%s", env.readConfig())
+	}
+
+	err := runContainerSetNesting(nil, []string{"dev1", "maybe"})
+	if err == nil || !strings.Contains(err.Error(), "expected true or false") {
+		t.Fatalf("expected invalid value error, got %v", err)
+	}
+}
+
+func TestContainerSet(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+	env.setContainerExists("dev1", true)
+	env.mock.SetOutput("exec dev1 -- id -u alice", "1001")
+
+	out := captureStdout(t, func() {
+		err := runContainerSet(nil, []string{"dev1", "ports=3000,5173", "limits.cpu=2", "autostart=true", "user.name=alice"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if !strings.Contains(out, "Set limits.cpu of 'dev1' to 2") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+	if !env.mock.HasCall("config", "set", "dev1", "limits.cpu", "2") {
+		t.Error("expected limits.cpu to be set")
+	}
+	if !env.mock.HasCall("config", "set", "dev1", "boot.autostart", "true") {
+		t.Error("expected boot.autostart to be set")
+	}
+
+	cfg, err := config.Load(env.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	container := cfg.Containers["dev1"]
+	if len(container.Ports) != 2 || container.Ports[0] != 3000 || container.Ports[1] != 5173 {
+		t.Errorf("ports = %v", container.Ports)
+	}
+	if container.Limits.CPU != "2" || !container.Autostart || container.User.Name != "alice" {
+		t.Errorf("unexpected container: %+v", container)
+	}
+
+	captureStdout(t, func() {
+		if err := runContainerSet(nil, []string{"dev1", "limits.cpu="}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if !env.mock.HasCall("config", "unset", "dev1", "limits.cpu") {
+		t.Error("expected limits.cpu to be unset")
+	}
+}
+
+func TestContainerSet_InvalidChangesNothing(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+	env.setContainerExists("dev1", true)
+	env.mock.SetError("exec dev1 -- id -u bob", "id: 'bob': no such user")
+	before := env.readConfig()
+
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{[]string{"dev1", "limits.cpu=2", "ports=99999"}, "invalid port"},
+		{[]string{"dev1", "limits.memory=lots"}, "invalid memory limit"},
+		{[]string{"dev1", "autostart=maybe"}, "expected true or false"},
+		{[]string{"dev1", "colour=blue"}, "unknown property"},
+		{[]string{"dev1", "limits.cpu=2", "user.name=bob"}, "user 'bob' doesn't exist"},
+		{[]string{"dev1", "ports"}, "use key=value"},
+	}
+	for _, tt := range tests {
+		err := runContainerSet(nil, tt.args)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("runContainerSet(%v) error = %v, want containing %q", tt.args, err, tt.wantErr)
+		}
+	}
+	if env.mock.HasCall("config", "set", "dev1", "limits.cpu", "2") {
+		t.Error("LXC changed although a value was invalid")
+	}
+	if env.readConfig() != before {
+		t.Errorf("config changed:\n%s", env.readConfig())
+	}
+}
+
+func TestContainerSet_UserNeedsRunningContainer(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+	env.setContainerExists("dev1", false)
+
+	err := runContainerSet(nil, []string{"dev1", "user.name=alice"})
+	if err == nil || !strings.Contains(err.Error(), "must be running") {
+		t.Fatalf("expected a not running error, got %v", err)
+	}
+	if env.mock.HasCallPrefix("exec", "dev1") {
+		t.Error("should not look the user up in a stopped container")
+	}
+}
+
+func TestContainerRepair(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: ""
+containers:
+  dev1:
+    image: ubuntu:24.04
+    pending_setup: [ssh, snapshot]
+`)
+	env.setContainerExists("dev1", true)
+	env.mock.SetOutput("config get dev1 security.nesting", "true")
+	env.mock.SetOutput("exec dev1 -- id -u dev", "1000")
+	env.mock.SetError("exec dev1 -- test -e /usr/sbin/sshd", "exit status 1")
+	env.mock.SetError("info dev1/initial-state", "not found")
+
+	out := captureStdout(t, func() {
+		if err := runContainerRepair(nil, []string{"dev1"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	if env.mock.HasCallPrefix("config", "set", "dev1", "security.nesting") {
+		t.Error("expected nesting to be left alone")
+	}
+	if env.mock.HasCallPrefix("exec", "dev1", "--", "bash", "-c", "\n\t\t# Create user if not exists") {
+		t.Error("expected the existing user to be left alone")
+	}
+	if !env.mock.HasCallPrefix("exec", "dev1", "--", "bash", "-c", "\n\t\t# Install openssh-server") {
+		t.Error("expected SSH to be set up")
+	}
+	if !env.mock.HasCall("snapshot", "dev1", "initial-state") {
+		t.Error("expected the initial-state snapshot to be taken")
+	}
+	if !strings.Contains(out, "ssh       repaired") || !strings.Contains(out, "user      ok") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+	cfg := env.readConfig()
+	if strings.Contains(cfg, "pending_setup") || !strings.Contains(cfg, "initial-state") {
+		t.Errorf("expected pending_setup to be cleared and the snapshot recorded:\n%s", cfg)
+	}
+}
+
+func TestContainerRepair_NotRunning(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+	env.setContainerExists("dev1", false)
+
+	err := runContainerRepair(nil, []string{"dev1"})
+	if err == nil || !strings.Contains(err.Error(), "must be running") {
+		t.Fatalf("expected a not running error, got %v", err)
+	}
+}
+
+func TestContainerDockerSetup(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+	env.setContainerExists("dev1", true)
+	env.mock.SetOutput("config get dev1 security.nesting", "false")
+	env.mock.SetOutput("exec dev1 -- cloud-init status", "status: done")
+	env.mock.SetOutput("exec dev1 -- sh -c", "cgroup2fs\nvfs\n")
+
+	out := captureStdout(t, func() {
+		if err := runContainerDockerSetup(nil, []string{"dev1"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	if !env.mock.HasCall("config", "set", "dev1", "security.nesting", "true") {
+		t.Error("expected nesting to be enabled")
+	}
+	if !env.mock.HasCallPrefix("stop", "dev1") || !env.mock.HasCall("start", "dev1") {
+		t.Error("expected the container to be restarted after enabling nesting")
+	}
+	if !env.mock.HasCallPrefix("exec", "dev1", "--", "bash", "-c", "set -e\ncommand -v docker") {
+		t.Error("expected docker to be installed")
+	}
+	if !strings.Contains(out, "Storage driver: vfs") || !strings.Contains(out, "Warning: Docker uses the vfs storage driver") {
+		t.Errorf("expected vfs storage driver warning, got:\n%s", out)
+	}
+	if !strings.Contains(env.readConfig(), "nesting: true") {
+		t.Error("expected nesting to be recorded in config")
+	}
+}
+
+func TestContainerDockerSetup_NotRunning(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+	env.setContainerExists("dev1", false)
+
+	err := runContainerDockerSetup(nil, []string{"dev1"})
+	if err == nil || !strings.Contains(err.Error(), "not running") {
+		t.Fatalf("expected not running error, got %v", err)
+	}
+}
+
+func TestRequireContainer_ResolvesAbbreviation(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`containers:
+  dev1:
+    image: ubuntu:24.04
+  api:
+    image: ubuntu:24.04
+`)
+	env.setContainerExists("dev1", true)
+
+	name := "d1"
+	_, lxcName, err := requireContainer(&name)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if name != "dev1" || lxcName != "dev1" {
+		t.Errorf("expected d1 to resolve to dev1, got %q (%s)", name, lxcName)
+	}
+}
+
+func TestRequireContainer_SuggestsOnTypo(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+
+	name := "dve1"
+	_, _, err := requireContainer(&name)
+	if err == nil || !strings.Contains(err.Error(), "did you mean 'dev1'?") {
+		t.Errorf("expected a suggestion, got %v", err)
+	}
+}
+
+func TestRequireContainerWithLock_ResolvesBeforeLocking(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("worker", "ubuntu:24.04")
+	env.setContainerExists("worker", true)
+
+	name := "wo"
+	_, _, lock, err := requireContainerWithLock(&name)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer lock.Release()
+
+	if name != "worker" {
+		t.Errorf("expected wo to resolve to worker, got %q", name)
+	}
+	if _, err := os.Stat(filepath.Join(env.dir, ".containers.yaml.locks", "worker.lock")); err != nil {
+		t.Errorf("expected the resolved container's lock file: %v", err)
+	}
+}
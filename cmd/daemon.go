@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"lxc-dev-manager/internal/dryrun"
+	"lxc-dev-manager/internal/lxc"
+	"lxc-dev-manager/pkg/lxcmgr"
+
+	"github.com/spf13/cobra"
+)
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Serve the project's API on a unix socket",
+	Long: `Serve the project's container, snapshot and mount operations as a JSON
+API on a unix socket, so IDE plugins and CI agents can drive the project
+without running the CLI for every step.
+
+The socket defaults to .lxc-dev-manager/daemon.sock in the project
+directory and is only accessible to the current user. Go programs can
+connect with lxcmgr.Dial; anything else can speak HTTP over the socket:
+
+  curl --unix-socket .lxc-dev-manager/daemon.sock http://daemon/v1/containers
+
+Requests that change something are recorded in the audit log. Press
+Ctrl+C to stop the daemon.
+
+Examples:
+  lxc-dev-manager daemon
+  lxc-dev-manager daemon --socket /run/user/1000/lxc-dev.sock`,
+	Args: cobra.NoArgs,
+	RunE: runDaemon,
+}
+
+var daemonSocket string
+
+func init() {
+	rootCmd.AddCommand(daemonCmd)
+	daemonCmd.Flags().StringVar(&daemonSocket, "socket", "", "Socket path (default .lxc-dev-manager/daemon.sock in the project)")
+}
+
+func runDaemon(cmd *cobra.Command, args []string) error {
+	if dryrun.Enabled() {
+		return fmt.Errorf("daemon does not support --dry-run")
+	}
+	if _, err := requireProject(); err != nil {
+		return err
+	}
+
+	// The daemon is long-lived and state changes outside it, so results
+	// must not be cached
+	lxc.EnableCache(false)
+
+	client, err := lxcmgr.New(projectDir)
+	if err != nil {
+		return err
+	}
+
+	socket := daemonSocket
+	if socket == "" {
+		socket = lxcmgr.DaemonSocket(client.Dir())
+	}
+	listener, err := listenUnix(socket)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(socket)
+
+	server := &http.Server{
+		Handler:           lxcmgr.DaemonHandler(client),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	errChan := make(chan error, 1)
+	go func() { errChan <- server.Serve(listener) }()
+
+	fmt.Printf("Listening on %s\n", socket)
+	fmt.Println("\nPress Ctrl+C to stop")
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case err := <-errChan:
+		return err
+	case <-sigChan:
+	}
+
+	fmt.Println("\nStopping daemon...")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// listenUnix listens on socket, readable only by the current user. A
+// socket left behind by a daemon that died is replaced; one that still
+// answers belongs to a running daemon.
+func listenUnix(socket string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(socket), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %w", err)
+	}
+	if _, err := os.Stat(socket); err == nil {
+		if conn, err := net.Dial("unix", socket); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("a daemon is already listening on %s", socket)
+		}
+		if err := os.Remove(socket); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", socket, err)
+	}
+	if err := os.Chmod(socket, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to restrict socket permissions: %w", err)
+	}
+	return listener, nil
+}
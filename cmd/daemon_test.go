@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// shortSocketPath returns a socket path short enough for the ~108 byte limit
+func shortSocketPath(t *testing.T) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "ldm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return filepath.Join(dir, "d.sock")
+}
+
+func TestListenUnix_Permissions(t *testing.T) {
+	socket := shortSocketPath(t)
+
+	listener, err := listenUnix(socket)
+	if err != nil {
+		t.Fatalf("listenUnix failed: %v", err)
+	}
+	defer listener.Close()
+
+	info, err := os.Stat(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("expected mode 0600, got %o", perm)
+	}
+}
+
+func TestListenUnix_ReplacesStaleSocket(t *testing.T) {
+	socket := shortSocketPath(t)
+
+	// A socket file nobody listens on, as left by a killed daemon
+	stale, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	listener, err := listenUnix(socket)
+	if err != nil {
+		t.Fatalf("expected stale socket to be replaced, got %v", err)
+	}
+	listener.Close()
+}
+
+func TestListenUnix_AlreadyRunning(t *testing.T) {
+	socket := shortSocketPath(t)
+
+	running, err := listenUnix(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer running.Close()
+
+	_, err = listenUnix(socket)
+	if err == nil || !strings.Contains(err.Error(), "already listening") {
+		t.Errorf("expected already listening error, got %v", err)
+	}
+}
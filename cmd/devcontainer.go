@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"lxc-dev-manager/internal/devcontainer"
+	"lxc-dev-manager/internal/dryrun"
+	"lxc-dev-manager/internal/lxc"
+	"lxc-dev-manager/internal/operations"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	devcontainerOutput string
+	devcontainerForce  bool
+	devcontainerImage  string
+)
+
+var devcontainerCmd = &cobra.Command{
+	Use:   "devcontainer",
+	Short: "Export and import devcontainer.json specs",
+	Long: `Convert between containers and devcontainer.json specs, so editors that
+understand the Development Containers format can attach to project
+containers, and existing specs can be used to create them.`,
+}
+
+var devcontainerExportCmd = &cobra.Command{
+	Use:   "export <container>",
+	Short: "Write a devcontainer.json for a container",
+	Long: `Write .devcontainer/devcontainer.json describing a container, with an
+ssh_config next to it that editors attach through (VS Code's Remote-SSH
+picks it up from the spec's settings).
+
+The SSH host is the container's static IP, its DNS name when dns is
+configured, or else its current IP, so the container must be running
+unless it has one of the first two.
+
+Examples:
+  lxc-dev-manager devcontainer export dev1
+  lxc-dev-manager devcontainer export dev1 --output ../app/.devcontainer`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDevcontainerExport,
+}
+
+var devcontainerImportCmd = &cobra.Command{
+	Use:   "import <name> [devcontainer.json]",
+	Short: "Create a container from a devcontainer.json",
+	Long: `Create a container matching a devcontainer.json (default:
+.devcontainer/devcontainer.json in the project).
+
+  image             - image to launch (override with --image; Docker
+                      images and Dockerfile builds can't be used by LXC)
+  forwardPorts      - ports
+  remoteUser        - user
+  workspaceFolder   - where the project directory is mounted ($WORKDIR)
+  mounts            - bind mounts (volume and tmpfs mounts are skipped)
+  postCreateCommand - run once during setup, before the initial snapshot
+  postStartCommand  - on_start commands
+
+Examples:
+  lxc-dev-manager devcontainer import dev1
+  lxc-dev-manager devcontainer import dev1 ../app/.devcontainer/devcontainer.json --image ubuntu:24.04`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runDevcontainerImport,
+}
+
+func init() {
+	rootCmd.AddCommand(devcontainerCmd)
+	devcontainerCmd.AddCommand(devcontainerExportCmd)
+	devcontainerCmd.AddCommand(devcontainerImportCmd)
+
+	devcontainerExportCmd.Flags().StringVarP(&devcontainerOutput, "output", "o", "", "Directory to write to (default: .devcontainer in the project)")
+	devcontainerExportCmd.Flags().BoolVarP(&devcontainerForce, "force", "f", false, "Overwrite an existing devcontainer.json")
+	devcontainerImportCmd.Flags().StringVar(&devcontainerImage, "image", "", "Image to launch instead of the spec's")
+}
+
+func runDevcontainerExport(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	cfg, err := requireProject()
+	if err != nil {
+		return err
+	}
+	if err := resolveContainerName(cfg, &name); err != nil {
+		return err
+	}
+
+	spec, sshConfig, err := operations.ExportDevcontainer(cfg, name)
+	if err != nil {
+		return err
+	}
+	data, err := spec.Marshal()
+	if err != nil {
+		return err
+	}
+
+	dir := devcontainerOutput
+	if dir == "" {
+		dir = filepath.Join(cfg.ProjectDir(), filepath.Dir(devcontainer.DefaultPath))
+	}
+	specPath := filepath.Join(dir, filepath.Base(devcontainer.DefaultPath))
+	sshPath := filepath.Join(dir, operations.DevcontainerSSHConfig)
+	if _, err := os.Stat(specPath); err == nil && !devcontainerForce {
+		return fmt.Errorf("%s already exists (use --force to overwrite)", specPath)
+	}
+
+	if dryrun.Enabled() {
+		dryrun.Printf("write %s and %s", specPath, sshPath)
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	if err := os.WriteFile(specPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", specPath, err)
+	}
+	if err := os.WriteFile(sshPath, []byte(sshConfig), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", sshPath, err)
+	}
+
+	fmt.Printf("Wrote %s\n", specPath)
+	fmt.Printf("Wrote %s\n", sshPath)
+	fmt.Printf("\nConnect with: ssh -F %s %s\n", sshPath, cfg.GetLXCName(name))
+	return nil
+}
+
+func runDevcontainerImport(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	cfg, lock, err := requireProjectWithLock()
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	path := filepath.Join(cfg.ProjectDir(), devcontainer.DefaultPath)
+	if len(args) > 1 {
+		path = args[1]
+	}
+	spec, err := devcontainer.Load(path)
+	if err != nil {
+		return err
+	}
+
+	plan, err := operations.PlanDevcontainerImport(cfg, spec, devcontainerImage)
+	if err != nil {
+		return err
+	}
+	for _, skipped := range plan.Skipped {
+		fmt.Fprintf(os.Stderr, "Warning: skipping %s\n", skipped)
+	}
+
+	lxcName := cfg.GetLXCName(name)
+	fmt.Printf("Creating container '%s' (LXC: %s) from %s...\n", name, lxcName, path)
+
+	if err := operations.ImportDevcontainer(cfg, name, plan); err != nil {
+		return err
+	}
+
+	ip, err := lxc.GetIP(lxcName)
+	if err != nil {
+		ip = "(pending)"
+	}
+	user := cfg.GetUser(name)
+
+	fmt.Printf("\nContainer '%s' created successfully!\n", name)
+	fmt.Printf("  Image: %s\n", plan.Image)
+	fmt.Printf("  IP: %s\n", ip)
+	fmt.Printf("  User: %s / Password: %s\n", user.Name, user.Password)
+	if workdir := cfg.Containers[name].Workdir; workdir != "" {
+		fmt.Printf("  Workdir: %s -> %s ($WORKDIR)\n", cfg.ProjectDir(), workdir)
+	}
+	fmt.Printf("\nConnect with: %s ssh %s\n", os.Args[0], name)
+	return nil
+}
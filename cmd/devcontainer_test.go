@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDevcontainerExport(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+    ip: 10.0.0.5
+    ports: [3000]
+`)
+
+	if err := runDevcontainerExport(nil, []string{"dev1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	spec, err := os.ReadFile(filepath.Join(env.dir, ".devcontainer", "devcontainer.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(spec), `"image": "ubuntu:24.04"`) || !strings.Contains(string(spec), `"remote.SSH.configFile"`) {
+		t.Errorf("unexpected devcontainer.json:\n%s", spec)
+	}
+
+	sshConfig, err := os.ReadFile(filepath.Join(env.dir, ".devcontainer", "ssh_config"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(sshConfig), "Host test-dev1\n    HostName 10.0.0.5\n") {
+		t.Errorf("unexpected ssh_config:\n%s", sshConfig)
+	}
+
+	// A second export must not clobber the first without --force
+	if err := runDevcontainerExport(nil, []string{"dev1"}); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("expected overwrite error, got %v", err)
+	}
+}
+
+func TestDevcontainerImport(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers: {}
+`)
+	os.MkdirAll(".devcontainer", 0755)
+	os.WriteFile(".devcontainer/devcontainer.json", []byte(`{
+	// VS Code allows comments
+	"image": "ubuntu:24.04",
+	"forwardPorts": [3000],
+	"postCreateCommand": "make deps",
+	"postStartCommand": "make serve",
+}`), 0644)
+	env.setContainerNotExists("test-dev1")
+	env.setLaunchSuccess()
+	env.mock.SetCallback("launch", func(args []string) {
+		env.setContainerExists("test-dev1", true)
+	})
+
+	if err := runDevcontainerImport(nil, []string{"dev1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !env.mock.HasCallPrefix("launch", "ubuntu:24.04", "test-dev1") {
+		t.Errorf("expected launch, got calls %v", env.mock.Calls)
+	}
+	if !env.mock.HasCall("exec", "test-dev1", "--", "su", "-l", "dev", "-c", `cd "${WORKDIR:-$HOME}" && make deps`) {
+		t.Error("expected postCreateCommand to run during setup")
+	}
+
+	cfg := env.readConfig()
+	if !strings.Contains(cfg, "- 3000") || !strings.Contains(cfg, "- make serve") {
+		t.Errorf("expected ports and on_start in config:\n%s", cfg)
+	}
+}
+
+func TestDevcontainerImport_NoImage(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers: {}
+`)
+	os.WriteFile("spec.json", []byte(`{"build": {"dockerfile": "Dockerfile"}}`), 0644)
+
+	err := runDevcontainerImport(nil, []string{"dev1", "spec.json"})
+	if err == nil || !strings.Contains(err.Error(), "--image") {
+		t.Errorf("expected --image error, got %v", err)
+	}
+	if env.mock.HasCallPrefix("launch") {
+		t.Error("expected no launch")
+	}
+}
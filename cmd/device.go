@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"lxc-dev-manager/internal/operations"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	deviceName      string
+	deviceVendorID  string
+	deviceProductID string
+	deviceSource    string
+	devicePath      string
+	deviceMode      string
+	deviceUID       string
+	deviceGID       string
+)
+
+var deviceCmd = &cobra.Command{
+	Use:   "device",
+	Short: "Pass host USB and character devices through to containers",
+	Long: `Manage USB and character device passthrough for hardware development.
+
+Devices are stored in containers.yaml alongside mounts.`,
+}
+
+var deviceAddCmd = &cobra.Command{
+	Use:   "add <container> <usb|unix-char>",
+	Short: "Add a USB or character device to a container",
+	Long: `Add a host device to a container.
+
+usb:       hotplugs a USB device matched by vendor/product ID (see lsusb)
+unix-char: exposes a host character device node (e.g. a serial port)
+
+Examples:
+  lxc-dev-manager device add dev1 usb --vendorid 0403 --productid 6001
+  lxc-dev-manager device add dev1 unix-char --source /dev/ttyUSB0
+  lxc-dev-manager device add dev1 unix-char --source /dev/ttyACM0 --path /dev/ttyS9 --mode 0660 --gid 20`,
+	Args: cobra.ExactArgs(2),
+	RunE: runDeviceAdd,
+}
+
+var deviceListCmd = &cobra.Command{
+	Use:   "list <container>",
+	Short: "List passthrough devices for a container",
+	Long: `List USB and character devices for a container, showing their status.
+
+Status values:
+  ok        - Device exists in both config and LXC
+  untracked - Device exists in LXC but not in config (manually added)
+  missing   - Device exists in config but not in LXC (needs re-add)`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDeviceList,
+}
+
+var deviceRemoveCmd = &cobra.Command{
+	Use:   "remove <container> <name>",
+	Short: "Remove a passthrough device from a container",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runDeviceRemove,
+}
+
+func init() {
+	rootCmd.AddCommand(deviceCmd)
+	deviceCmd.AddCommand(deviceAddCmd)
+	deviceCmd.AddCommand(deviceListCmd)
+	deviceCmd.AddCommand(deviceRemoveCmd)
+
+	deviceAddCmd.Flags().StringVarP(&deviceName, "name", "n", "", "Device name (default: auto-generated)")
+	deviceAddCmd.Flags().StringVar(&deviceVendorID, "vendorid", "", "USB vendor ID (usb)")
+	deviceAddCmd.Flags().StringVar(&deviceProductID, "productid", "", "USB product ID (usb)")
+	deviceAddCmd.Flags().StringVar(&deviceSource, "source", "", "Host device node, e.g. /dev/ttyUSB0 (unix-char)")
+	deviceAddCmd.Flags().StringVar(&devicePath, "path", "", "Path inside the container (unix-char, default: same as source)")
+	deviceAddCmd.Flags().StringVar(&deviceMode, "mode", "", "File mode inside the container, e.g. 0660")
+	deviceAddCmd.Flags().StringVar(&deviceUID, "uid", "", "Owner UID inside the container")
+	deviceAddCmd.Flags().StringVar(&deviceGID, "gid", "", "Owner GID inside the container")
+}
+
+func runDeviceAdd(cmd *cobra.Command, args []string) error {
+	containerName := args[0]
+	deviceType := args[1]
+
+	cfg, _, lock, err := requireContainerWithLock(&containerName)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	name, err := operations.AddDevice(cfg, containerName, deviceType, operations.DeviceOpts{
+		Name:      deviceName,
+		VendorID:  deviceVendorID,
+		ProductID: deviceProductID,
+		Source:    deviceSource,
+		Path:      devicePath,
+		Mode:      deviceMode,
+		UID:       deviceUID,
+		GID:       deviceGID,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Added %s device '%s' to '%s'\n", deviceType, name, containerName)
+	return nil
+}
+
+func runDeviceList(cmd *cobra.Command, args []string) error {
+	containerName := args[0]
+
+	cfg, _, err := requireContainer(&containerName)
+	if err != nil {
+		return err
+	}
+
+	devices, err := operations.ListDevices(cfg, containerName)
+	if err != nil {
+		return err
+	}
+
+	if len(devices) == 0 {
+		fmt.Println("No devices found.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTYPE\tCONFIG\tSTATUS")
+	for _, d := range devices {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", d.Name, d.Type, formatDeviceConfig(d.Config), d.Status)
+	}
+	w.Flush()
+
+	return nil
+}
+
+func runDeviceRemove(cmd *cobra.Command, args []string) error {
+	containerName := args[0]
+	name := args[1]
+
+	cfg, _, lock, err := requireContainerWithLock(&containerName)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	if err := operations.RemoveDevice(cfg, containerName, name); err != nil {
+		return err
+	}
+
+	fmt.Printf("Removed device '%s' from '%s'\n", name, containerName)
+	return nil
+}
+
+// formatDeviceConfig renders device config as sorted key=value pairs
+func formatDeviceConfig(cfg map[string]string) string {
+	keys := make([]string, 0, len(cfg))
+	for k := range cfg {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+cfg[k])
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func resetDeviceFlags() {
+	deviceName = ""
+	deviceVendorID = ""
+	deviceProductID = ""
+	deviceSource = ""
+	devicePath = ""
+	deviceMode = ""
+	deviceUID = ""
+	deviceGID = ""
+}
+
+func TestDeviceAdd_USB(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+`)
+	env.setContainerExists("test-dev1", true)
+	env.mock.SetOutput("config device add test-dev1", "")
+
+	resetDeviceFlags()
+	deviceVendorID = "0403"
+	deviceProductID = "6001"
+	defer resetDeviceFlags()
+
+	if err := runDeviceAdd(nil, []string{"dev1", "usb"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !env.mock.HasCallPrefix("config", "device", "add", "test-dev1", "usb-0403-6001", "usb") {
+		t.Error("expected usb device add command")
+	}
+
+	cfg := env.readConfig()
+	if !strings.Contains(cfg, "type: usb") {
+		t.Error("expected usb device in config")
+	}
+	if !strings.Contains(cfg, `vendorid: "0403"`) {
+		t.Errorf("expected vendorid in config, got:\n%s", cfg)
+	}
+}
+
+func TestDeviceAdd_UnixChar(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+`)
+	env.setContainerExists("test-dev1", true)
+	env.mock.SetOutput("config device add test-dev1", "")
+
+	resetDeviceFlags()
+	deviceSource = "/dev/ttyUSB0"
+	deviceMode = "0660"
+	defer resetDeviceFlags()
+
+	if err := runDeviceAdd(nil, []string{"dev1", "unix-char"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !env.mock.HasCallPrefix("config", "device", "add", "test-dev1", "char-ttyUSB0", "unix-char") {
+		t.Errorf("expected unix-char device add command, calls: %v", env.mock.Calls)
+	}
+
+	cfg := env.readConfig()
+	if !strings.Contains(cfg, "source: /dev/ttyUSB0") {
+		t.Error("expected source in config")
+	}
+}
+
+func TestDeviceAdd_InvalidVendorID(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+`)
+	env.setContainerExists("test-dev1", true)
+
+	resetDeviceFlags()
+	deviceVendorID = "xyz"
+	defer resetDeviceFlags()
+
+	err := runDeviceAdd(nil, []string{"dev1", "usb"})
+	if err == nil {
+		t.Fatal("expected error for invalid vendor ID")
+	}
+	if env.mock.HasCallPrefix("config", "device", "add") {
+		t.Error("should not add device with invalid vendor ID")
+	}
+}
+
+func TestDeviceAdd_SourceOutsideDev(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+`)
+	env.setContainerExists("test-dev1", true)
+
+	resetDeviceFlags()
+	deviceSource = "/etc/passwd"
+	defer resetDeviceFlags()
+
+	if err := runDeviceAdd(nil, []string{"dev1", "unix-char"}); err == nil {
+		t.Fatal("expected error for source outside /dev")
+	}
+}
+
+func TestDeviceAdd_UnsupportedType(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+`)
+	env.setContainerExists("test-dev1", true)
+
+	resetDeviceFlags()
+	defer resetDeviceFlags()
+
+	err := runDeviceAdd(nil, []string{"dev1", "gpu"})
+	if err == nil || !strings.Contains(err.Error(), "unsupported device type") {
+		t.Errorf("expected unsupported type error, got: %v", err)
+	}
+}
+
+func TestDeviceRemove_RejectsMount(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+    devices:
+      repo:
+        type: disk
+        config:
+          source: /host/path
+          path: /container/path
+`)
+	env.setContainerExists("test-dev1", true)
+
+	err := runDeviceRemove(nil, []string{"dev1", "repo"})
+	if err == nil || !strings.Contains(err.Error(), "unmount") {
+		t.Errorf("expected error pointing at unmount, got: %v", err)
+	}
+}
+
+func TestDeviceRemove_Success(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+    devices:
+      serial:
+        type: unix-char
+        config:
+          source: /dev/ttyUSB0
+`)
+	env.setContainerExists("test-dev1", true)
+	env.mock.SetOutput("config device remove test-dev1 serial", "")
+
+	if err := runDeviceRemove(nil, []string{"dev1", "serial"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(env.readConfig(), "serial") {
+		t.Error("expected device to be removed from config")
+	}
+}
+
+func TestDeviceList(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+    devices:
+      serial:
+        type: unix-char
+        config:
+          source: /dev/ttyUSB0
+`)
+	env.setContainerExists("test-dev1", true)
+	env.mock.SetOutput("config device show test-dev1", `serial:
+  type: unix-char
+  source: /dev/ttyUSB0
+`)
+
+	if err := runDeviceList(nil, []string{"dev1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
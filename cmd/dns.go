@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+
+	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/dns"
+	"lxc-dev-manager/internal/operations"
+
+	"github.com/spf13/cobra"
+)
+
+var dnsCmd = &cobra.Command{
+	Use:   "dns",
+	Short: "Manage host name resolution for containers",
+	Long: `Register containers on the host as <container>.<project>.lxd.
+
+Enable it in containers.yaml:
+
+  dns:
+    mode: hosts      # or: dnsmasq
+    domain: lxd      # optional
+    sudo: true       # write with sudo when the file isn't writable
+
+Entries are refreshed automatically on 'up' and 'down'.`,
+}
+
+var dnsSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Refresh DNS entries from current container IPs",
+	Long: `Rewrite the project's /etc/hosts block (or dnsmasq drop-in) so every
+running container resolves to its current IP.
+
+Example:
+  lxc-dev-manager dns sync`,
+	Args: cobra.NoArgs,
+	RunE: runDNSSync,
+}
+
+var dnsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Show hostnames for running containers",
+	Args:  cobra.NoArgs,
+	RunE:  runDNSList,
+}
+
+var dnsClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove all DNS entries for the project",
+	Args:  cobra.NoArgs,
+	RunE:  runDNSClear,
+}
+
+func init() {
+	rootCmd.AddCommand(dnsCmd)
+	dnsCmd.AddCommand(dnsSyncCmd)
+	dnsCmd.AddCommand(dnsListCmd)
+	dnsCmd.AddCommand(dnsClearCmd)
+}
+
+func runDNSSync(cmd *cobra.Command, args []string) error {
+	cfg, err := requireDNSProject()
+	if err != nil {
+		return err
+	}
+
+	entries, err := operations.RefreshDNS(cfg)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Registered %d container(s) (%s)\n", len(entries), cfg.DNS.Mode)
+	for _, e := range entries {
+		fmt.Printf("  %s -> %s\n", e.Hostname, e.IP)
+	}
+	return nil
+}
+
+func runDNSList(cmd *cobra.Command, args []string) error {
+	cfg, err := requireProject()
+	if err != nil {
+		return err
+	}
+
+	entries, err := operations.DNSEntries(cfg)
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No running containers with an IP")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "HOSTNAME\tIP")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s\t%s\n", e.Hostname, e.IP)
+	}
+	return w.Flush()
+}
+
+func runDNSClear(cmd *cobra.Command, args []string) error {
+	cfg, err := requireDNSProject()
+	if err != nil {
+		return err
+	}
+
+	if err := operations.ClearDNS(cfg); err != nil {
+		return err
+	}
+
+	fmt.Printf("Removed DNS entries for project '%s'\n", cfg.Project)
+	return nil
+}
+
+// requireDNSProject loads the project and ensures DNS registration is configured
+func requireDNSProject() (*config.Config, error) {
+	cfg, err := requireProject()
+	if err != nil {
+		return nil, err
+	}
+	if !operations.DNSEnabled(cfg) {
+		return nil, fmt.Errorf("dns is not configured; set dns.mode to '%s' or '%s' in %s", dns.ModeHosts, dns.ModeDnsmasq, config.ConfigFile)
+	}
+	return cfg, nil
+}
+
+// refreshDNS updates host DNS entries after a lifecycle change.
+// Failures are reported as warnings since the container operation itself succeeded.
+func refreshDNS(cfg *config.Config) {
+	if _, err := operations.RefreshDNS(cfg); err != nil {
+		slog.Warn("failed to update DNS entries", "error", err)
+	}
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+
+	"lxc-dev-manager/internal/operations"
+
+	"github.com/spf13/cobra"
+)
+
+var dotfilesCmd = &cobra.Command{
+	Use:   "dotfiles",
+	Short: "Manage the user's dotfiles in containers",
+	Long: `Provision the container user's shell and editor setup from a git repository.
+
+Configure the repository in containers.yaml (defaults.dotfiles, or per
+container). New containers get it at create time.
+
+  defaults:
+    dotfiles:
+      repo: https://github.com/me/dotfiles.git
+      install: install.sh   # optional
+
+The repository is cloned to ~/.dotfiles. The install script runs from there;
+without one, install.sh, bootstrap.sh or setup.sh is used if present,
+otherwise the repository's top-level dotfiles are symlinked into ~.`,
+}
+
+var dotfilesApplyCmd = &cobra.Command{
+	Use:   "apply <container>",
+	Short: "Clone or update dotfiles in a container and run the install script",
+	Long: `Clone the configured dotfiles repository (or pull it if already cloned)
+for the container user and run its install script.
+
+Examples:
+  lxc-dev-manager dotfiles apply dev1`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDotfilesApply,
+}
+
+func init() {
+	rootCmd.AddCommand(dotfilesCmd)
+	dotfilesCmd.AddCommand(dotfilesApplyCmd)
+}
+
+func runDotfilesApply(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	cfg, _, err := requireRunningContainer(&name)
+	if err != nil {
+		return err
+	}
+
+	dotfiles := cfg.GetDotfiles(name)
+	if dotfiles == nil {
+		return fmt.Errorf("no dotfiles configured for container '%s' (set defaults.dotfiles.repo in containers.yaml)", name)
+	}
+
+	fmt.Printf("Applying dotfiles from %s to %s...\n", dotfiles.Repo, name)
+	if err := operations.ApplyDotfiles(cfg, name); err != nil {
+		return err
+	}
+
+	fmt.Println("Dotfiles applied.")
+	return nil
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDotfilesApply(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+defaults:
+  dotfiles:
+    repo: https://example.com/me/dotfiles.git
+containers:
+  dev1:
+    image: ubuntu:24.04
+    user:
+      name: alice
+    dotfiles:
+      repo: git@example.com:alice/dotfiles.git
+      install: scripts/install.sh
+`)
+	env.setContainerExists("test-dev1", true)
+
+	if err := runDotfilesApply(nil, []string{"dev1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var script string
+	for _, call := range env.mock.Calls {
+		if strings.HasPrefix(strings.Join(call.Args, " "), "exec test-dev1 -- su -l alice -c") {
+			script = call.Args[len(call.Args)-1]
+		}
+	}
+	if script == "" {
+		t.Fatalf("expected dotfiles script to run as alice, got calls: %v", env.mock.Calls)
+	}
+	if !strings.Contains(script, "'git@example.com:alice/dotfiles.git'") {
+		t.Error("expected per-container repo to override defaults")
+	}
+	if !strings.Contains(script, "for s in 'scripts/install.sh'; do") {
+		t.Error("expected configured install script")
+	}
+}
+
+func TestDotfilesApply_NotConfigured(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+`)
+	env.setContainerExists("test-dev1", true)
+
+	err := runDotfilesApply(nil, []string{"dev1"})
+	if err == nil || !strings.Contains(err.Error(), "no dotfiles configured") {
+		t.Errorf("expected not configured error, got: %v", err)
+	}
+}
+
+func TestDotfilesApply_NotRunning(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+defaults:
+  dotfiles:
+    repo: https://example.com/me/dotfiles.git
+containers:
+  dev1:
+    image: ubuntu:24.04
+`)
+	env.setContainerExists("test-dev1", false)
+
+	err := runDotfilesApply(nil, []string{"dev1"})
+	if err == nil || !strings.Contains(err.Error(), "not running") {
+		t.Errorf("expected not running error, got: %v", err)
+	}
+}
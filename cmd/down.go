@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"time"
 
 	"lxc-dev-manager/internal/lxc"
 	"lxc-dev-manager/internal/operations"
@@ -14,20 +15,34 @@ var downCmd = &cobra.Command{
 	Short: "Stop a container",
 	Long: `Stop a running container.
 
-Example:
-  lxc-dev-manager down dev1`,
-	Args: cobra.ExactArgs(1),
-	RunE: runDown,
+The container gets --timeout (default: its stop_timeout in containers.yaml,
+else 5s) to shut down cleanly, so services such as databases can flush.
+With --force, it is killed if it is still running after that; --timeout 0
+--force kills it straight away.
+
+Examples:
+  lxc-dev-manager down dev1
+  lxc-dev-manager down db --timeout 30s --force`,
+	Args:    cobra.ExactArgs(1),
+	Aliases: []string{"stop"},
+	RunE:    runDown,
 }
 
+var (
+	downTimeout time.Duration
+	downForce   bool
+)
+
 func init() {
 	rootCmd.AddCommand(downCmd)
+	downCmd.Flags().DurationVar(&downTimeout, "timeout", 0, "How long to wait for a clean shutdown (default: stop_timeout, else 5s)")
+	downCmd.Flags().BoolVar(&downForce, "force", false, "Kill the container if it hasn't shut down by then")
 }
 
 func runDown(cmd *cobra.Command, args []string) error {
 	name := args[0]
 
-	cfg, lxcName, err := requireContainer(name)
+	cfg, lxcName, err := requireContainer(&name)
 	if err != nil {
 		return err
 	}
@@ -45,11 +60,18 @@ func runDown(cmd *cobra.Command, args []string) error {
 
 	fmt.Printf("Stopping container '%s'...\n", name)
 
+	opts := operations.StopOpts{Timeout: downTimeout, Force: downForce}
+	if cmd != nil && cmd.Flags().Changed("timeout") && downTimeout == 0 {
+		opts.Timeout = -1 // An explicit 0: no grace period
+	}
+
 	// Use operations package for core logic
-	if err := operations.Stop(cfg, name); err != nil {
+	if err := operations.Stop(cfg, name, opts); err != nil {
 		return err
 	}
 
 	fmt.Printf("Container '%s' stopped\n", name)
+
+	refreshDNS(cfg)
 	return nil
 }
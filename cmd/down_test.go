@@ -77,3 +77,72 @@ func TestDown_GetStatusFails(t *testing.T) {
 		t.Fatal("expected error")
 	}
 }
+
+func TestDown_ConfiguredTimeout(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: ""
+containers:
+  dev1:
+    image: ubuntu:24.04
+    stop_timeout: 30s
+`)
+	env.setContainerExists("dev1", true)
+	env.mock.SetOutput("stop dev1 --timeout=30", "")
+
+	if err := runDown(nil, []string{"dev1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !env.mock.HasCall("stop", "dev1", "--timeout=30") {
+		t.Errorf("expected the configured stop_timeout, got %v", env.mock.Calls)
+	}
+}
+
+func TestDown_ForceAfterTimeout(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+	env.setContainerExists("dev1", true)
+	env.mock.SetError("stop dev1 --timeout=5", "timed out")
+	env.mock.SetOutput("stop dev1 --force", "")
+
+	downForce = true
+	defer func() { downForce = false }()
+
+	if err := runDown(nil, []string{"dev1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !env.mock.HasCall("stop", "dev1", "--timeout=5") || !env.mock.HasCall("stop", "dev1", "--force") {
+		t.Errorf("expected a clean stop, then a forced one, got %v", env.mock.Calls)
+	}
+}
+
+func TestDown_AutoProxy(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: ""
+containers:
+  dev1:
+    image: ubuntu:24.04
+    ports: [3000]
+    proxy: auto
+`)
+	env.setContainerExists("dev1", true)
+	env.mock.SetOutput("stop dev1 --timeout=5", "")
+	env.mock.SetOutput("config device show dev1", `port-3000:
+  type: proxy
+  listen: tcp:0.0.0.0:3000
+  connect: tcp:127.0.0.1:3000
+data:
+  type: disk
+  source: /srv/data
+  path: /data
+`)
+
+	if err := runDown(nil, []string{"dev1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !env.mock.HasCall("config", "device", "remove", "dev1", "port-3000") {
+		t.Errorf("expected the proxy device to be removed, got %v", env.mock.Calls)
+	}
+	if env.mock.HasCall("config", "device", "remove", "dev1", "data") {
+		t.Error("only the port forwards should be removed")
+	}
+}
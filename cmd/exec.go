@@ -6,6 +6,8 @@ import (
 	"os/exec"
 	"syscall"
 
+	"lxc-dev-manager/internal/lxc"
+
 	"github.com/spf13/cobra"
 )
 
@@ -35,19 +37,13 @@ func init() {
 }
 
 // buildExecArgs constructs the lxc exec arguments for running a command
-func buildExecArgs(lxcName, user string, cmdArgs []string) []string {
-	args := []string{"exec", lxcName, "--"}
-
+func buildExecArgs(lxcName, user string, cmdArgs []string, sw lxc.UserSwitch) []string {
 	if user != "" {
-		// Run command as specified user via su -l
-		args = append(args, "su", "-l", user)
-		args = append(args, cmdArgs...)
-	} else {
-		// Run command directly as root
-		args = append(args, cmdArgs...)
+		// Run command as specified user, via su -l unless the image lacks it
+		return sw.ExecArgs(lxcName, user, cmdArgs)
 	}
-
-	return args
+	// Run command directly as root
+	return append([]string{"exec", lxcName, "--"}, cmdArgs...)
 }
 
 func runExec(cmd *cobra.Command, args []string) error {
@@ -58,7 +54,7 @@ func runExec(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("command required after --\nFor interactive shell, use: %s ssh %s", os.Args[0], name)
 	}
 
-	cfg, lxcName, err := requireRunningContainer(name)
+	cfg, lxcName, err := requireRunningContainer(&name)
 	if err != nil {
 		return err
 	}
@@ -71,7 +67,11 @@ func runExec(cmd *cobra.Command, args []string) error {
 	}
 
 	// Build lxc exec command
-	lxcArgs := buildExecArgs(lxcName, user, cmdArgs)
+	var sw lxc.UserSwitch
+	if user != "" {
+		sw = lxc.DetectUserSwitch(lxcName, user)
+	}
+	lxcArgs := buildExecArgs(lxcName, user, cmdArgs, sw)
 
 	// Replace current process with lxc exec (for proper TTY handling)
 	lxcPath, err := exec.LookPath("lxc")
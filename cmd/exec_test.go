@@ -1,8 +1,11 @@
 package cmd
 
 import (
+	"slices"
 	"strings"
 	"testing"
+
+	"lxc-dev-manager/internal/lxc"
 )
 
 func TestExec_RequiresCommand(t *testing.T) {
@@ -160,7 +163,7 @@ func TestBuildExecArgs(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			args := buildExecArgs(tt.container, tt.user, tt.cmdArgs)
+			args := buildExecArgs(tt.container, tt.user, tt.cmdArgs, lxc.UserSwitch{})
 			if len(args) != len(tt.expected) {
 				t.Fatalf("expected %d args, got %d: %v", len(tt.expected), len(args), args)
 			}
@@ -190,7 +193,7 @@ func TestBuildExecArgs_DifferentUsers(t *testing.T) {
 			name = "no-user"
 		}
 		t.Run(name, func(t *testing.T) {
-			args := buildExecArgs("test-container", tt.user, []string{"htop"})
+			args := buildExecArgs("test-container", tt.user, []string{"htop"}, lxc.UserSwitch{})
 			if len(args) != len(tt.expected) {
 				t.Fatalf("expected %d args, got %d: %v", len(tt.expected), len(args), args)
 			}
@@ -202,3 +205,40 @@ func TestBuildExecArgs_DifferentUsers(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildExecArgs_UserSwitchFallbacks(t *testing.T) {
+	ids := lxc.UserSwitch{UID: 1000, GID: 1000, Home: "/home/dev", Shell: "/bin/ash"}
+	env := []string{"--cwd", "/home/dev", "--env", "HOME=/home/dev", "--env", "USER=dev", "--env", "LOGNAME=dev", "--env", "SHELL=/bin/ash"}
+
+	runuser := ids
+	runuser.Method = lxc.UserSwitchRunuser
+	setpriv := ids
+	setpriv.Method = lxc.UserSwitchSetpriv
+	uid := ids
+	uid.Method = lxc.UserSwitchUID
+
+	tests := []struct {
+		name     string
+		sw       lxc.UserSwitch
+		expected []string
+	}{
+		{"runuser", runuser, []string{"exec", "dev", "--", "runuser", "-l", "dev", "whoami"}},
+		{"setpriv", setpriv, slices.Concat([]string{"exec", "dev"}, env, []string{"--", "setpriv", "--reuid=1000", "--regid=1000", "--init-groups", "whoami"})},
+		{"uid", uid, slices.Concat([]string{"exec", "dev"}, env, []string{"--user", "1000", "--group", "1000", "--", "whoami"})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := buildExecArgs("dev", "dev", []string{"whoami"}, tt.sw)
+			if !slices.Equal(args, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, args)
+			}
+		})
+	}
+
+	// Without a command, the user's shell is started as a login shell
+	args := uid.ExecArgs("dev", "dev", nil)
+	if !slices.Equal(args[len(args)-2:], []string{"/bin/ash", "-l"}) {
+		t.Errorf("expected a login shell, got %v", args)
+	}
+}
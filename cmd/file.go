@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"lxc-dev-manager/internal/operations"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	fileRmRecursive bool
+	fileRmForce     bool
+)
+
+var fileCmd = &cobra.Command{
+	Use:   "file",
+	Short: "Inspect and edit files inside a container",
+	Long: `Quick file operations inside a container, without opening a shell.
+
+Paths starting with ~ refer to the container user's home directory.
+To copy files in or out, use 'mv'.`,
+}
+
+var fileLsCmd = &cobra.Command{
+	Use:   "ls <container> [path]",
+	Short: "List a directory in a container",
+	Long: `List a directory (or file) in a container, like 'ls -la'.
+
+Examples:
+  lxc-dev-manager file ls dev1
+  lxc-dev-manager file ls dev1 /etc/nginx`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runFileLs,
+}
+
+var fileCatCmd = &cobra.Command{
+	Use:   "cat <container> <path>",
+	Short: "Print a file from a container",
+	Long: `Print the contents of a file in a container.
+
+Examples:
+  lxc-dev-manager file cat dev1 /etc/hosts
+  lxc-dev-manager file cat dev1 ~/.bashrc`,
+	Args: cobra.ExactArgs(2),
+	RunE: runFileCat,
+}
+
+var fileRmCmd = &cobra.Command{
+	Use:   "rm <container> <path>",
+	Short: "Delete a file in a container",
+	Long: `Delete a file in a container. Use -r to delete a directory tree.
+
+Examples:
+  lxc-dev-manager file rm dev1 /tmp/debug.log
+  lxc-dev-manager file rm dev1 ~/build -r -f`,
+	Args: cobra.ExactArgs(2),
+	RunE: runFileRm,
+}
+
+var fileEditCmd = &cobra.Command{
+	Use:   "edit <container> <path>",
+	Short: "Edit a file in a container with your local editor",
+	Long: `Copy a file out of a container, open it in $VISUAL or $EDITOR (default: vi)
+and copy it back if it changed. Ownership and permissions are preserved.
+A file that doesn't exist yet is created, owned by the container user.
+
+Examples:
+  lxc-dev-manager file edit dev1 /etc/nginx/sites-available/default
+  EDITOR="code --wait" lxc-dev-manager file edit dev1 ~/.env`,
+	Args: cobra.ExactArgs(2),
+	RunE: runFileEdit,
+}
+
+func init() {
+	rootCmd.AddCommand(fileCmd)
+	fileCmd.AddCommand(fileLsCmd)
+	fileCmd.AddCommand(fileCatCmd)
+	fileCmd.AddCommand(fileRmCmd)
+	fileCmd.AddCommand(fileEditCmd)
+
+	fileRmCmd.Flags().BoolVarP(&fileRmRecursive, "recursive", "r", false, "Delete directories and their contents")
+	fileRmCmd.Flags().BoolVarP(&fileRmForce, "force", "f", false, "Skip confirmation")
+}
+
+func runFileLs(cmd *cobra.Command, args []string) error {
+	cfg, _, err := requireContainer(&args[0])
+	if err != nil {
+		return err
+	}
+
+	target := "~"
+	if len(args) == 2 {
+		target = args[1]
+	}
+
+	listing, err := operations.ListFiles(cfg, args[0], target)
+	if err != nil {
+		return err
+	}
+	fmt.Print(listing)
+	return nil
+}
+
+func runFileCat(cmd *cobra.Command, args []string) error {
+	cfg, _, err := requireContainer(&args[0])
+	if err != nil {
+		return err
+	}
+
+	data, err := operations.ReadFile(cfg, args[0], args[1])
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
+
+func runFileRm(cmd *cobra.Command, args []string) error {
+	name, target := args[0], args[1]
+
+	cfg, _, err := requireContainer(&name)
+	if err != nil {
+		return err
+	}
+
+	if !fileRmForce {
+		ok, err := confirmPrompt(fmt.Sprintf("Delete '%s' in %s?", target, name))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("Cancelled")
+			return nil
+		}
+	}
+
+	if err := operations.RemoveFile(cfg, name, target, fileRmRecursive); err != nil {
+		return err
+	}
+	fmt.Printf("Deleted '%s' in %s\n", target, name)
+	return nil
+}
+
+func runFileEdit(cmd *cobra.Command, args []string) error {
+	name, target := args[0], args[1]
+
+	cfg, _, err := requireContainer(&name)
+	if err != nil {
+		return err
+	}
+
+	changed, err := operations.EditFile(cfg, name, target, runEditor)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		fmt.Println("No changes")
+		return nil
+	}
+	fmt.Printf("Saved '%s' in %s\n", target, name)
+	return nil
+}
+
+// runEditor opens path in the user's editor and waits for it to exit.
+// The editor command may include arguments (e.g. "code --wait").
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	fields := strings.Fields(editor)
+	c := exec.Command(fields[0], append(fields[1:], path)...)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("editor '%s' failed: %w", editor, err)
+	}
+	return nil
+}
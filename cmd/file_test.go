@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileCat(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+	env.setContainerExists("dev1", true)
+	env.mock.SetError("exec dev1 -- test -d /etc/hosts", "not a directory")
+	env.mock.SetOutput("file pull dev1//etc/hosts -", "127.0.0.1 localhost\n")
+
+	if err := runFileCat(nil, []string{"dev1", "/etc/hosts"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !env.mock.HasCall("file", "pull", "dev1//etc/hosts", "-") {
+		t.Errorf("expected file pull to stdout, got calls: %v", env.mock.Calls)
+	}
+}
+
+func TestFileCat_Directory(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+	env.setContainerExists("dev1", true)
+	env.mock.SetOutput("exec dev1 -- test -d /etc", "")
+
+	err := runFileCat(nil, []string{"dev1", "/etc"})
+	if err == nil || !strings.Contains(err.Error(), "is a directory") {
+		t.Errorf("expected directory error, got: %v", err)
+	}
+}
+
+func TestFileLs_DefaultsToHome(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+	env.setContainerExists("dev1", true)
+	env.mock.SetOutput("exec dev1 -- ls -la -- /home/dev", "total 0\n")
+
+	if err := runFileLs(nil, []string{"dev1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !env.mock.HasCall("exec", "dev1", "--", "ls", "-la", "--", "/home/dev") {
+		t.Errorf("expected listing of home directory, got calls: %v", env.mock.Calls)
+	}
+}
+
+func TestFileRm(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+	env.setContainerExists("dev1", true)
+	env.mock.SetOutput("exec dev1 -- test -e /tmp/debug.log", "")
+	env.mock.SetError("exec dev1 -- test -d /tmp/debug.log", "not a directory")
+
+	fileRmForce = true
+	defer func() { fileRmForce = false }()
+
+	if err := runFileRm(nil, []string{"dev1", "/tmp/debug.log"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !env.mock.HasCall("exec", "dev1", "--", "rm", "-f", "--", "/tmp/debug.log") {
+		t.Errorf("expected rm call, got calls: %v", env.mock.Calls)
+	}
+}
+
+func TestFileRm_DirectoryNeedsRecursive(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+	env.setContainerExists("dev1", true)
+	env.mock.SetOutput("exec dev1 -- test", "")
+
+	fileRmForce = true
+	defer func() { fileRmForce = false }()
+
+	err := runFileRm(nil, []string{"dev1", "/home/dev/build"})
+	if err == nil || !strings.Contains(err.Error(), "is a directory") {
+		t.Fatalf("expected directory error, got: %v", err)
+	}
+
+	fileRmRecursive = true
+	defer func() { fileRmRecursive = false }()
+
+	if err := runFileRm(nil, []string{"dev1", "/home/dev/build"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !env.mock.HasCall("exec", "dev1", "--", "rm", "-rf", "--", "/home/dev/build") {
+		t.Errorf("expected recursive rm call, got calls: %v", env.mock.Calls)
+	}
+}
+
+func TestFileRm_RefusesRoot(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+	env.setContainerExists("dev1", true)
+
+	fileRmForce = true
+	fileRmRecursive = true
+	defer func() {
+		fileRmForce = false
+		fileRmRecursive = false
+	}()
+
+	err := runFileRm(nil, []string{"dev1", "/"})
+	if err == nil || !strings.Contains(err.Error(), "refusing") {
+		t.Errorf("expected refusal, got: %v", err)
+	}
+	if env.mock.HasCallPrefix("exec", "dev1", "--", "rm") {
+		t.Error("rm should not be called for /")
+	}
+}
+
+// setEditor points $VISUAL at a script run on the edited file
+func setEditor(t *testing.T, script string) {
+	t.Helper()
+	editor := filepath.Join(t.TempDir(), "editor.sh")
+	if err := os.WriteFile(editor, []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("VISUAL", editor)
+}
+
+func TestFileEdit_PreservesOwnership(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+	env.setContainerExists("dev1", true)
+	env.mock.SetError("exec dev1 -- test -d /etc/app.conf", "not a directory")
+	env.mock.SetOutput("exec dev1 -- test -e /etc/app.conf", "")
+	env.mock.SetOutput("exec dev1 -- stat -c %u %g %a -- /etc/app.conf", "0 33 640\n")
+	env.mock.SetCallback("file pull dev1//etc/app.conf", func(args []string) {
+		os.WriteFile(args[len(args)-1], []byte("debug=false\n"), 0644)
+	})
+
+	var pushed string
+	env.mock.SetCallback("file push", func(args []string) {
+		data, _ := os.ReadFile(args[len(args)-2])
+		pushed = string(data)
+	})
+
+	setEditor(t, `echo "debug=true" > "$1"`)
+
+	if err := runFileEdit(nil, []string{"dev1", "/etc/app.conf"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if pushed != "debug=true\n" {
+		t.Errorf("expected edited content to be pushed, got %q", pushed)
+	}
+	if !env.mock.HasCallPrefix("file", "push", "--uid", "0", "--gid", "33", "--mode", "640") {
+		t.Errorf("expected push preserving ownership, got calls: %v", env.mock.Calls)
+	}
+}
+
+func TestFileEdit_NoChanges(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+	env.setContainerExists("dev1", true)
+	env.mock.SetError("exec dev1 -- test -d /etc/app.conf", "not a directory")
+	env.mock.SetOutput("exec dev1 -- test -e /etc/app.conf", "")
+	env.mock.SetOutput("exec dev1 -- stat", "0 0 644\n")
+	env.mock.SetCallback("file pull", func(args []string) {
+		os.WriteFile(args[len(args)-1], []byte("unchanged\n"), 0644)
+	})
+
+	setEditor(t, "true")
+
+	if err := runFileEdit(nil, []string{"dev1", "/etc/app.conf"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if env.mock.HasCallPrefix("file", "push") {
+		t.Error("unchanged file should not be pushed")
+	}
+}
@@ -3,8 +3,11 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 
 	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/errcode"
 	"lxc-dev-manager/internal/lxc"
 )
 
@@ -19,28 +22,45 @@ func requireProject() (*config.Config, error) {
 }
 
 // requireContainer ensures a container exists in both config and LXC.
+// An abbreviated name is replaced by the container it matches (see
+// resolveContainerName).
 // Returns the config, LXC name, and any error.
-func requireContainer(name string) (*config.Config, string, error) {
+func requireContainer(name *string) (*config.Config, string, error) {
 	cfg, err := requireProject()
 	if err != nil {
 		return nil, "", err
 	}
 
-	if !cfg.HasContainer(name) {
-		return nil, "", fmt.Errorf("container '%s' not found in project config", name)
+	if err := resolveContainerName(cfg, name); err != nil {
+		return nil, "", err
 	}
 
-	lxcName := cfg.GetLXCName(name)
+	lxcName := cfg.GetLXCName(*name)
 	if !lxc.Exists(lxcName) {
-		return nil, "", fmt.Errorf("container '%s' does not exist in LXC (expected: %s)", name, lxcName)
+		return nil, "", errcode.Errorf(errcode.NotFound, *name, "container '%s' does not exist in LXC (expected: %s)", *name, lxcName)
 	}
 
 	return cfg, lxcName, nil
 }
 
+// resolveContainerName replaces *name with the container it names or
+// unambiguously abbreviates, telling the user on stderr when it isn't an
+// exact match. Unknown names get an error with close suggestions.
+func resolveContainerName(cfg *config.Config, name *string) error {
+	resolved, err := cfg.ResolveContainer(*name)
+	if err != nil {
+		return err
+	}
+	if resolved != *name {
+		fmt.Fprintf(os.Stderr, "Using container '%s'\n", resolved)
+		*name = resolved
+	}
+	return nil
+}
+
 // requireRunningContainer ensures a container exists and is running.
 // Returns the config, LXC name, and any error.
-func requireRunningContainer(name string) (*config.Config, string, error) {
+func requireRunningContainer(name *string) (*config.Config, string, error) {
 	cfg, lxcName, err := requireContainer(name)
 	if err != nil {
 		return nil, "", err
@@ -51,7 +71,7 @@ func requireRunningContainer(name string) (*config.Config, string, error) {
 		return nil, "", fmt.Errorf("failed to get container status: %w", err)
 	}
 	if status != "RUNNING" {
-		return nil, "", fmt.Errorf("container '%s' is not running (status: %s). Start it with: %s up %s", name, status, os.Args[0], name)
+		return nil, "", errcode.Errorf(errcode.NotRunning, *name, "container '%s' is not running (status: %s). Start it with: %s up %s", *name, status, os.Args[0], *name)
 	}
 
 	return cfg, lxcName, nil
@@ -67,24 +87,72 @@ func requireProjectWithLock() (*config.Config, *config.ConfigLock, error) {
 	return cfg, lock, nil
 }
 
-// requireContainerWithLock ensures a container exists in both config and LXC, with lock held.
+// requireContainerWithLock ensures a container exists in both config and LXC,
+// holding only that container's lock so commands on other containers can run
+// alongside. Saves write back just this container's entry; commands that
+// change anything else must use requireContainerWithProjectLock.
 // The caller must call lock.Release() when done.
-func requireContainerWithLock(name string) (*config.Config, string, *config.ConfigLock, error) {
+func requireContainerWithLock(name *string) (*config.Config, string, *config.ConfigLock, error) {
+	// The lock is per container, so the name has to be resolved first
+	cfg, err := requireProject()
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if err := resolveContainerName(cfg, name); err != nil {
+		return nil, "", nil, err
+	}
+
+	cfg, lock, err := config.LoadWithContainerLock(projectDir, *name)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	return checkLockedContainer(cfg, name, lock)
+}
+
+// requireContainerWithProjectLock is like requireContainerWithLock but holds
+// the exclusive project lock.
+// The caller must call lock.Release() when done.
+func requireContainerWithProjectLock(name *string) (*config.Config, string, *config.ConfigLock, error) {
 	cfg, lock, err := requireProjectWithLock()
 	if err != nil {
 		return nil, "", nil, err
 	}
+	return checkLockedContainer(cfg, name, lock)
+}
 
-	if !cfg.HasContainer(name) {
+// checkLockedContainer checks a container exists in config and LXC,
+// releasing lock if it doesn't
+func checkLockedContainer(cfg *config.Config, name *string, lock *config.ConfigLock) (*config.Config, string, *config.ConfigLock, error) {
+	if err := resolveContainerName(cfg, name); err != nil {
 		lock.Release()
-		return nil, "", nil, fmt.Errorf("container '%s' not found in project config", name)
+		return nil, "", nil, err
 	}
 
-	lxcName := cfg.GetLXCName(name)
+	lxcName := cfg.GetLXCName(*name)
 	if !lxc.Exists(lxcName) {
 		lock.Release()
-		return nil, "", nil, fmt.Errorf("container '%s' does not exist in LXC (expected: %s)", name, lxcName)
+		return nil, "", nil, errcode.Errorf(errcode.NotFound, *name, "container '%s' does not exist in LXC (expected: %s)", *name, lxcName)
 	}
 
 	return cfg, lxcName, lock, nil
 }
+
+// parsePortList parses a comma-separated port list such as "5173,8000"
+func parsePortList(s string) ([]int, error) {
+	var ports []int
+	for _, ps := range strings.Split(s, ",") {
+		ps = strings.TrimSpace(ps)
+		if ps == "" {
+			continue
+		}
+		port, err := strconv.Atoi(ps)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port %q: %w", ps, err)
+		}
+		if port < 1 || port > 65535 {
+			return nil, fmt.Errorf("invalid port %d: must be between 1 and 65535", port)
+		}
+		ports = append(ports, port)
+	}
+	return ports, nil
+}
@@ -1,7 +1,9 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
 	"strings"
 
 	"lxc-dev-manager/internal/operations"
@@ -13,7 +15,7 @@ import (
 var imageCmd = &cobra.Command{
 	Use:   "image",
 	Short: "Manage images",
-	Long:  `Manage container images (list, delete, rename).`,
+	Long:  `Manage container images (build, list, info, delete, rename, export, import, push, prune).`,
 }
 
 // Alias: 'images' -> 'image list'
@@ -31,9 +33,16 @@ var imageListCmd = &cobra.Command{
 	Short: "List local images",
 	Long: `List all local images.
 
+Sort by name (default), size (largest first) or created (newest first).
+--filter keeps images whose alias, fingerprint, description or
+architecture contains the given text. Use --json for machine-readable
+output.
+
 Example:
   lxc-dev-manager image list
-  lxc-dev-manager image list --all`,
+  lxc-dev-manager image list --all
+  lxc-dev-manager image list --sort size
+  lxc-dev-manager image list --filter ubuntu --json`,
 	Args: cobra.NoArgs,
 	RunE: runImageList,
 }
@@ -65,6 +74,9 @@ Example:
 }
 
 var imageListAll bool
+var imageListSort string
+var imageListFilter string
+var imageListJSON bool
 var imageDeleteForce bool
 
 func init() {
@@ -73,7 +85,13 @@ func init() {
 
 	// Add subcommands to image
 	imageCmd.AddCommand(imageCreateCmd)
+	imageCmd.AddCommand(imageBuildCmd)
+	imageCmd.AddCommand(imageExportCmd)
+	imageCmd.AddCommand(imageImportCmd)
+	imageCmd.AddCommand(imagePushCmd)
+	imageCmd.AddCommand(imagePruneCmd)
 	imageCmd.AddCommand(imageListCmd)
+	imageCmd.AddCommand(imageInfoCmd)
 	imageCmd.AddCommand(imageDeleteCmd)
 	imageCmd.AddCommand(imageRenameCmd)
 
@@ -83,7 +101,26 @@ func init() {
 	// Flags
 	imageListCmd.Flags().BoolVarP(&imageListAll, "all", "a", false, "Show all images including cached")
 	imagesCmd.Flags().BoolVarP(&imageListAll, "all", "a", false, "Show all images including cached")
+	for _, c := range []*cobra.Command{imageListCmd, imagesCmd} {
+		c.Flags().StringVar(&imageListSort, "sort", "name", "Sort by name, size or created")
+		c.Flags().StringVar(&imageListFilter, "filter", "", "Only show images matching this text")
+		c.Flags().BoolVar(&imageListJSON, "json", false, "Print images as JSON")
+	}
+	imageInfoCmd.Flags().BoolVar(&imageInfoJSON, "json", false, "Print image details as JSON")
+	imageCreateCmd.Flags().BoolVar(&imageCreateStop, "stop", false, "Stop a running container for the snapshot, then restart it")
+	imageCreateCmd.Flags().StringVarP(&imageCreateDescription, "description", "d", "", "Image description shown by image list")
+	imageCreateCmd.Flags().BoolVar(&imageCreatePublic, "public", false, "Let other hosts pull the image without trusting this one")
+	imageCreateCmd.Flags().StringVar(&imageCreateExpiry, "expiry", "", "Delete the image after this long (e.g. 30d, 2w, 12h)")
+	imageCreateCmd.Flags().StringArrayVar(&imageCreateProperties, "property", nil, "Set an image property, as key=value (repeatable)")
+	imageCreateCmd.Flags().BoolVar(&imageCreateTiming, "timing", false, "Print how long each step took")
 	imageDeleteCmd.Flags().BoolVarP(&imageDeleteForce, "force", "f", false, "Skip confirmation prompt")
+	imageBuildCmd.Flags().StringVar(&imageBuildAlias, "alias", "", "Image alias (overrides the spec's alias)")
+	imageBuildCmd.Flags().BoolVarP(&imageBuildForce, "force", "f", false, "Replace an existing image with the same alias")
+	imageExportCmd.Flags().BoolVarP(&imageExportForce, "force", "f", false, "Overwrite existing files")
+	imageImportCmd.Flags().StringVar(&imageImportAlias, "alias", "", "Alias for the imported image (required)")
+	imageImportCmd.Flags().BoolVarP(&imageImportForce, "force", "f", false, "Replace an existing image with the same alias")
+	imagePruneCmd.Flags().StringVar(&imagePruneOlderThan, "older-than", "30d", "Only prune images unused for this long (e.g. 30d, 2w, 12h)")
+	imagePruneCmd.Flags().BoolVarP(&imagePruneForce, "force", "f", false, "Skip confirmation prompt")
 }
 
 func runImageList(cmd *cobra.Command, args []string) error {
@@ -92,11 +129,27 @@ func runImageList(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	if err := operations.SortImages(images, imageListSort); err != nil {
+		return err
+	}
+	images = operations.FilterImages(images, imageListFilter)
+
+	if imageListJSON {
+		if images == nil {
+			images = []operations.ImageInfo{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(images)
+	}
 
 	if len(images) == 0 {
-		if imageListAll {
+		switch {
+		case imageListFilter != "":
+			fmt.Printf("No images matching '%s'\n", imageListFilter)
+		case imageListAll:
 			fmt.Println("No images found")
-		} else {
+		default:
 			fmt.Println("No custom images found")
 			fmt.Println("Use --all to show cached images")
 		}
@@ -104,8 +157,8 @@ func runImageList(cmd *cobra.Command, args []string) error {
 	}
 
 	// Print header
-	fmt.Printf("%-25s %-14s %-10s %s\n", "ALIAS", "FINGERPRINT", "SIZE", "DESCRIPTION")
-	fmt.Println(strings.Repeat("-", 75))
+	fmt.Printf("%-25s %-14s %-10s %-8s %-11s %s\n", "ALIAS", "FINGERPRINT", "SIZE", "ARCH", "CREATED", "DESCRIPTION")
+	fmt.Println(strings.Repeat("-", 95))
 
 	for _, img := range images {
 		alias := img.Alias
@@ -118,12 +171,17 @@ func runImageList(cmd *cobra.Command, args []string) error {
 			fp = fp[:12]
 		}
 
+		created := "-"
+		if !img.CreatedAt.IsZero() {
+			created = img.CreatedAt.Local().Format("2006-01-02")
+		}
+
 		desc := img.Description
 		if len(desc) > 25 {
 			desc = desc[:22] + "..."
 		}
 
-		fmt.Printf("%-25s %-14s %-10s %s\n", alias, fp, img.Size, desc)
+		fmt.Printf("%-25s %-14s %-10s %-8s %-11s %s\n", alias, fp, img.Size, img.Architecture, created, desc)
 	}
 
 	return nil
@@ -153,7 +211,11 @@ func runImageDelete(cmd *cobra.Command, args []string) error {
 
 	// Ask for confirmation unless --force
 	if !imageDeleteForce {
-		if !confirmPrompt(fmt.Sprintf("Are you sure you want to delete image '%s'?", name)) {
+		ok, err := confirmPrompt(fmt.Sprintf("Are you sure you want to delete image '%s'?", name))
+		if err != nil {
+			return err
+		}
+		if !ok {
 			fmt.Println("Cancelled")
 			return nil
 		}
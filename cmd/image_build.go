@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/imagespec"
+	"lxc-dev-manager/internal/operations"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	imageBuildAlias string
+	imageBuildForce bool
+)
+
+var imageBuildCmd = &cobra.Command{
+	Use:   "build <spec.yaml>",
+	Short: "Build an image from a spec file",
+	Long: `Build a reusable image from a declarative spec: a temporary container is
+launched from the base image, provisioned, published under the alias and
+deleted.
+
+Spec format:
+  base: ubuntu:24.04            # Image to start from (required)
+  alias: webapp-base            # Resulting image (or pass --alias)
+  packages: [git, postgresql-client]
+  files:                        # Copied from the host (relative to the spec)
+    - source: ./bashrc
+      dest: /etc/skel/.bashrc
+      mode: "0644"
+  run:                          # Shell commands, run as root in order
+    - curl -fsSL https://deb.nodesource.com/setup_20.x | bash -
+    - apt-get install -y nodejs
+
+Example:
+  lxc-dev-manager image build image.yaml
+  lxc-dev-manager container create dev1 webapp-base`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImageBuild,
+}
+
+// imageBuildCmd is registered in image.go init()
+
+func runImageBuild(cmd *cobra.Command, args []string) error {
+	spec, err := imagespec.Load(args[0])
+	if err != nil {
+		return err
+	}
+
+	opts := operations.BuildImageOpts{
+		Alias: imageBuildAlias,
+		Force: imageBuildForce,
+		Progress: func(step string) {
+			stepInfo(step)
+		},
+	}
+	// Builds don't need a project, but use its defaults.stop_timeout if run in one
+	if cfg, err := config.Load(projectDir); err == nil {
+		opts.StopTimeout = cfg.GetStopTimeout("", 0)
+	}
+
+	fmt.Printf("Building image from %s (base %s)...\n", args[0], spec.Base)
+	alias, err := operations.BuildImage(spec, opts)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\n%sImage '%s' built successfully!%s\n", colorGreen, alias, colorReset)
+	fmt.Printf("\nCreate new containers from it with:\n")
+	fmt.Printf("  %s container create <name> %s\n", os.Args[0], alias)
+	return nil
+}
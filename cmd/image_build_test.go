@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeImageSpec(t *testing.T, env *testEnv, content string) string {
+	t.Helper()
+	path := filepath.Join(env.dir, "image.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestImageBuild(t *testing.T) {
+	env := setupTestEnv(t)
+	os.WriteFile(filepath.Join(env.dir, "bashrc"), []byte("alias ll='ls -l'\n"), 0644)
+	spec := writeImageSpec(t, env, `base: ubuntu:24.04
+alias: webapp-base
+packages: [git, make]
+files:
+  - source: bashrc
+    dest: /etc/skel/.bashrc
+    mode: "0644"
+run:
+  - echo built > /etc/built
+`)
+	env.setLaunchSuccess()
+
+	if err := runImageBuild(nil, []string{spec}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var buildName string
+	for _, call := range env.mock.Calls {
+		if len(call.Args) > 2 && call.Args[0] == "launch" {
+			buildName = call.Args[2]
+		}
+	}
+	if !strings.HasPrefix(buildName, "lxc-dev-manager-build-") {
+		t.Fatalf("expected a build container launch, got calls %v", env.mock.Calls)
+	}
+	if !env.mock.HasCallPrefix("file", "push", filepath.Join(env.dir, "bashrc"), buildName+"//etc/skel/.bashrc") {
+		t.Error("expected the file to be pushed relative to the spec")
+	}
+	if !env.mock.HasCall("exec", buildName, "--", "chmod", "0644", "/etc/skel/.bashrc") {
+		t.Error("expected the file mode to be set")
+	}
+	if !env.mock.HasCall("exec", buildName, "--", "bash", "-c", "set -e\necho built > /etc/built") {
+		t.Error("expected the run command")
+	}
+	if !env.mock.HasCall("publish", buildName, "--alias", "webapp-base") {
+		t.Error("expected the build container to be published")
+	}
+	if !env.mock.HasCall("delete", buildName, "--force") {
+		t.Error("expected the build container to be deleted")
+	}
+}
+
+func TestImageBuild_RunFailsDeletesContainer(t *testing.T) {
+	env := setupTestEnv(t)
+	spec := writeImageSpec(t, env, `base: ubuntu:24.04
+alias: webapp-base
+run: [exit 1]
+`)
+	env.setLaunchSuccess()
+	env.mock.SetCallback("launch", func(args []string) {
+		env.mock.SetError("exec "+args[2]+" -- bash -c set -e\nexit 1", "exit status 1")
+	})
+
+	err := runImageBuild(nil, []string{spec})
+	if err == nil || !strings.Contains(err.Error(), "exit 1") {
+		t.Fatalf("expected run command error, got %v", err)
+	}
+	if env.mock.HasCallPrefix("publish") {
+		t.Error("expected no publish")
+	}
+	if !env.mock.HasCallPrefix("delete", "lxc-dev-manager-build-") {
+		t.Error("expected the build container to be deleted")
+	}
+}
+
+func TestImageBuild_AliasExists(t *testing.T) {
+	env := setupTestEnv(t)
+	spec := writeImageSpec(t, env, "base: ubuntu:24.04\nalias: webapp-base\n")
+	env.mock.SetOutput("image list webapp-base --format=csv -c f", "abc123")
+
+	err := runImageBuild(nil, []string{spec})
+	if err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("expected already exists error, got %v", err)
+	}
+	if env.mock.HasCallPrefix("launch") {
+		t.Error("expected no launch")
+	}
+}
+
+func TestImageBuild_InvalidSpec(t *testing.T) {
+	env := setupTestEnv(t)
+	spec := writeImageSpec(t, env, "base: ubuntu:24.04\npackage: [git]\n")
+
+	err := runImageBuild(nil, []string{spec})
+	if err == nil || !strings.Contains(err.Error(), "package") {
+		t.Errorf("expected unknown field error, got %v", err)
+	}
+}
@@ -3,8 +3,11 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
 
+	"lxc-dev-manager/internal/errcode"
 	"lxc-dev-manager/internal/operations"
+	"lxc-dev-manager/internal/validation"
 
 	"github.com/spf13/cobra"
 )
@@ -14,10 +17,21 @@ var imageCreateCmd = &cobra.Command{
 	Short: "Create an image from a container",
 	Long: `Create a reusable image from an existing container.
 
-The container will be stopped before creating the image, then restarted.
+The image is published from a snapshot, so a running container keeps
+running and your sessions stay open. A snapshot of a running container is
+like pulling the plug: files being written at that moment may be caught
+half-written. Use --stop to stop the container for the snapshot (it is
+restarted as soon as the snapshot is taken) when that matters, e.g. for
+databases.
+
+Describe the image so 'image list' still makes sense weeks later, and
+give throwaway images an expiry so LXD deletes them on its own.
 
 Example:
   lxc-dev-manager image create dev1 my-base-image
+  lxc-dev-manager image create dev1 my-base-image --stop
+  lxc-dev-manager image create dev1 pr-1234 --description "PR 1234 repro" --expiry 2w
+  lxc-dev-manager image create dev1 node-base --property node=20 --public
 
 Then create new containers from it:
   lxc-dev-manager container create dev2 my-base-image`,
@@ -27,6 +41,15 @@ Then create new containers from it:
 
 // imageCreateCmd is registered in image.go init()
 
+var (
+	imageCreateStop        bool
+	imageCreateDescription string
+	imageCreatePublic      bool
+	imageCreateExpiry      string
+	imageCreateProperties  []string
+	imageCreateTiming      bool
+)
+
 const (
 	colorReset  = "\033[0m"
 	colorGreen  = "\033[32m"
@@ -50,11 +73,39 @@ func runImageCreate(cmd *cobra.Command, args []string) error {
 	name := args[0]
 	imageName := args[1]
 
-	cfg, _, err := requireContainer(name)
+	cfg, _, err := requireContainer(&name)
 	if err != nil {
 		return err
 	}
 
+	opts := operations.CreateImageOpts{
+		Stop:        imageCreateStop,
+		Description: imageCreateDescription,
+		Public:      imageCreatePublic,
+	}
+	if imageCreateExpiry != "" {
+		if opts.Expiry, err = validation.ParseAge(imageCreateExpiry); err != nil {
+			return err
+		}
+	}
+	if len(imageCreateProperties) > 0 {
+		opts.Properties = make(map[string]string, len(imageCreateProperties))
+		for _, prop := range imageCreateProperties {
+			key, value, ok := strings.Cut(prop, "=")
+			if !ok {
+				return errcode.Errorf(errcode.Usage, "", "invalid property %q: use key=value", prop)
+			}
+			opts.Properties[key] = value
+		}
+	}
+
+	var timer *phaseTimer
+	if imageCreateTiming {
+		timer = newPhaseTimer()
+		progress := timer.progress(nil)
+		opts.Progress = func(step string) { progress(name, step) }
+	}
+
 	fmt.Printf("Creating image '%s' from container '%s'...\n", imageName, name)
 
 	// Create a prefixed writer to indent LXC output
@@ -62,11 +113,14 @@ func runImageCreate(cmd *cobra.Command, args []string) error {
 	stderr := &prefixWriter{prefix: "      ", w: os.Stderr}
 
 	// Use operations package for core logic
-	if err := operations.CreateImage(cfg, name, imageName, stdout, stderr); err != nil {
+	if err := operations.CreateImage(cfg, name, imageName, opts, stdout, stderr); err != nil {
 		return err
 	}
 
 	fmt.Printf("\n%sImage '%s' created successfully!%s\n", colorGreen, imageName, colorReset)
+	if timer != nil {
+		timer.print(os.Stdout)
+	}
 	fmt.Printf("\nCreate new containers from it with:\n")
 	fmt.Printf("  %s container create <name> %s\n", os.Args[0], imageName)
 
@@ -23,8 +23,15 @@ func TestImageCreate_NotExists(t *testing.T) {
 	}
 }
 
+func withImageCreateStop(t *testing.T) {
+	t.Helper()
+	imageCreateStop = true
+	t.Cleanup(func() { imageCreateStop = false })
+}
+
 func TestImageCreate_StopFails(t *testing.T) {
 	env := setupTestEnv(t)
+	withImageCreateStop(t)
 	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
 	env.setContainerExists("dev1", true)
 	env.mock.SetError("stop dev1 --timeout=5", "failed to stop")
@@ -55,6 +62,7 @@ func TestImageCreate_SnapshotFails(t *testing.T) {
 
 func TestImageCreate_StopsRunningContainer(t *testing.T) {
 	env := setupTestEnv(t)
+	withImageCreateStop(t)
 	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
 	env.setContainerExists("dev1", true) // Running
 	env.mock.SetOutput("stop dev1 --timeout=5", "")
@@ -66,6 +74,26 @@ func TestImageCreate_StopsRunningContainer(t *testing.T) {
 	if !env.mock.HasCall("stop", "dev1", "--timeout=5") {
 		t.Error("expected stop command for running container")
 	}
+	if !env.mock.HasCall("start", "dev1") {
+		t.Error("expected container to be restarted")
+	}
+}
+
+func TestImageCreate_KeepsRunningContainerRunning(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+	env.setContainerExists("dev1", true) // Running
+	// Let snapshot fail so we don't hit the exec.Command publish
+	env.mock.SetError("snapshot dev1", "test stop")
+
+	runImageCreate(nil, []string{"dev1", "my-image"})
+
+	if !env.mock.HasCallPrefix("snapshot", "dev1") {
+		t.Error("expected a snapshot of the running container")
+	}
+	if env.mock.HasCallPrefix("stop") || env.mock.HasCallPrefix("start") {
+		t.Errorf("running container should not be stopped, got calls %v", env.mock.Calls)
+	}
 }
 
 func TestImageCreate_SkipsStopForStoppedContainer(t *testing.T) {
@@ -165,6 +193,7 @@ func TestImageCreate_HandlesContainerWithSpecialChars(t *testing.T) {
 
 func TestImageCreate_CallsStopBeforeSnapshot(t *testing.T) {
 	env := setupTestEnv(t)
+	withImageCreateStop(t)
 	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
 	env.setContainerExists("dev1", true) // Running
 	env.mock.SetOutput("stop dev1 --timeout=5", "")
@@ -194,3 +223,36 @@ func TestImageCreate_CallsStopBeforeSnapshot(t *testing.T) {
 		t.Error("expected stop to be called before snapshot")
 	}
 }
+
+func TestImageCreate_InvalidProperty(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+	env.setContainerExists("dev1", false)
+
+	imageCreateProperties = []string{"node"}
+	defer func() { imageCreateProperties = nil }()
+
+	err := runImageCreate(nil, []string{"dev1", "my-image"})
+	if err == nil || !strings.Contains(err.Error(), "key=value") {
+		t.Fatalf("expected key=value error, got %v", err)
+	}
+	if env.mock.HasCallPrefix("snapshot") {
+		t.Error("expected no snapshot for invalid options")
+	}
+}
+
+func TestImageCreate_InvalidExpiry(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+	env.setContainerExists("dev1", false)
+
+	imageCreateExpiry = "soon"
+	defer func() { imageCreateExpiry = "" }()
+
+	if err := runImageCreate(nil, []string{"dev1", "my-image"}); err == nil {
+		t.Fatal("expected error for invalid expiry")
+	}
+	if env.mock.HasCallPrefix("snapshot") {
+		t.Error("expected no snapshot for invalid options")
+	}
+}
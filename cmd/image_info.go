@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"lxc-dev-manager/internal/lxc"
+	"lxc-dev-manager/internal/operations"
+
+	"github.com/spf13/cobra"
+)
+
+var imageInfoJSON bool
+
+var imageInfoCmd = &cobra.Command{
+	Use:   "info <image>",
+	Short: "Show image details",
+	Long: `Show an image's fingerprint, size, architecture, creation and expiry
+dates, aliases, properties and, for images made with 'image create', the
+container it came from.
+
+The image can be given by alias or by a fingerprint prefix.
+
+Example:
+  lxc-dev-manager image info my-base
+  lxc-dev-manager image info 3f2a9c --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImageInfo,
+}
+
+// imageInfoCmd is registered in image.go init()
+
+func runImageInfo(cmd *cobra.Command, args []string) error {
+	info, err := operations.GetImageInfo(args[0])
+	if err != nil {
+		return err
+	}
+
+	if imageInfoJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(info)
+	}
+
+	aliases := "-"
+	if len(info.Aliases) > 0 {
+		aliases = strings.Join(info.Aliases, ", ")
+	}
+	source := info.SourceContainer
+	if source == "" {
+		source = "-"
+	}
+	lastUsed := "never"
+	if !info.LastUsedAt.IsZero() {
+		lastUsed = formatImageTime(info.LastUsedAt)
+	}
+	expires := "never"
+	if !info.ExpiresAt.IsZero() {
+		expires = formatImageTime(info.ExpiresAt)
+	}
+	public := "no"
+	if info.Public {
+		public = "yes"
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Fingerprint:\t%s\n", info.Fingerprint)
+	fmt.Fprintf(w, "Aliases:\t%s\n", aliases)
+	fmt.Fprintf(w, "Size:\t%s\n", info.Size)
+	fmt.Fprintf(w, "Architecture:\t%s\n", info.Architecture)
+	if info.Description != "" {
+		fmt.Fprintf(w, "Description:\t%s\n", info.Description)
+	}
+	fmt.Fprintf(w, "Created:\t%s\n", formatImageTime(info.CreatedAt))
+	fmt.Fprintf(w, "Last used:\t%s\n", lastUsed)
+	fmt.Fprintf(w, "Expires:\t%s\n", expires)
+	fmt.Fprintf(w, "Public:\t%s\n", public)
+	fmt.Fprintf(w, "Source container:\t%s\n", source)
+	if info.Cached {
+		fmt.Fprintf(w, "Cached:\tyes\n")
+	}
+
+	// description and source_container are shown above
+	var keys []string
+	for key := range info.Properties {
+		if key != "description" && key != lxc.SourceContainerProperty {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) > 0 {
+		sort.Strings(keys)
+		fmt.Fprintf(w, "Properties:\t\n")
+		for _, key := range keys {
+			fmt.Fprintf(w, "  %s:\t%s\n", key, info.Properties[key])
+		}
+	}
+	return w.Flush()
+}
+
+// formatImageTime renders an image timestamp in local time, "-" if unknown
+func formatImageTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/operations"
+	"lxc-dev-manager/internal/validation"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	imagePruneOlderThan string
+	imagePruneForce     bool
+)
+
+var imagePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete unused images",
+	Long: `Delete local images that no container was created from and that haven't
+been used for a while (default: 30 days).
+
+Containers of every project count as users, since they're all LXC
+containers on this host. Run inside a project, images named in its
+containers.yaml are kept too.
+
+Preview with --dry-run; the images that would be deleted are listed with
+their size and age.
+
+Example:
+  lxc-dev-manager image prune --dry-run
+  lxc-dev-manager image prune --older-than 2w --force`,
+	Args: cobra.NoArgs,
+	RunE: runImagePrune,
+}
+
+// imagePruneCmd is registered in image.go init()
+
+func runImagePrune(cmd *cobra.Command, args []string) error {
+	olderThan, err := validation.ParseAge(imagePruneOlderThan)
+	if err != nil {
+		return err
+	}
+
+	// Outside a project only instances protect images
+	cfg, err := config.Load(projectDir)
+	if errors.Is(err, config.ErrNoProject) {
+		cfg = nil
+	} else if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	images, err := operations.FindPrunableImages(cfg, olderThan, time.Now())
+	if err != nil {
+		return err
+	}
+	if len(images) == 0 {
+		fmt.Println("No unused images to prune")
+		return nil
+	}
+
+	var total int64
+	fmt.Printf("%-25s %-14s %-10s %s\n", "IMAGE", "FINGERPRINT", "SIZE", "UNUSED FOR")
+	for _, img := range images {
+		fp := img.Fingerprint
+		if len(fp) > 12 {
+			fp = fp[:12]
+		}
+		fmt.Printf("%-25s %-14s %-10s %s\n", img.Name(), fp, validation.FormatSize(img.Size), formatAge(img.Age))
+		total += img.Size
+	}
+	fmt.Println()
+
+	if !imagePruneForce {
+		ok, err := confirmPrompt(fmt.Sprintf("Delete %d images (%s)?", len(images), validation.FormatSize(total)))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("Cancelled")
+			return nil
+		}
+	}
+
+	freed, failed := operations.PruneImages(images)
+	fmt.Printf("Deleted %d images, freed %s\n", len(images)-len(failed), validation.FormatSize(freed))
+	if len(failed) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(failed))
+	for name := range failed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "Failed to delete '%s': %v\n", name, failed[name])
+	}
+	return fmt.Errorf("%d of %d images could not be deleted", len(failed), len(images))
+}
+
+// formatAge renders a duration in days, or hours below a day
+func formatAge(d time.Duration) string {
+	if d < 24*time.Hour {
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(d.Hours()/24))
+}
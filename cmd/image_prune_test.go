@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func setImagePruneState(env *testEnv) {
+	old := time.Now().Add(-60 * 24 * time.Hour).UTC().Format(time.RFC3339)
+	recent := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	env.mock.SetOutput("query /1.0/images?recursion=1", `[
+ {"fingerprint":"aaa111","aliases":[{"name":"in-use"}],"size":1073741824,"created_at":"`+old+`","last_used_at":"0001-01-01T00:00:00Z"},
+ {"fingerprint":"bbb222","aliases":[{"name":"orphan"}],"size":2147483648,"created_at":"`+old+`","last_used_at":"0001-01-01T00:00:00Z"},
+ {"fingerprint":"ccc333","aliases":[],"size":1024,"created_at":"`+old+`","last_used_at":"`+recent+`"},
+ {"fingerprint":"ddd444","aliases":[{"name":"configured"}],"size":1024,"created_at":"`+old+`","last_used_at":"0001-01-01T00:00:00Z"},
+ {"fingerprint":"eee555","aliases":[],"size":1024,"created_at":"`+old+`","last_used_at":"0001-01-01T00:00:00Z"}
+]`)
+	env.mock.SetOutput("query /1.0/instances?recursion=1", `[
+ {"name":"other-project-dev1","config":{"volatile.base_image":"aaa111"}}
+]`)
+}
+
+func TestImagePrune(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers:
+  dev1:
+    image: configured
+`)
+	setImagePruneState(env)
+	imagePruneForce = true
+	defer func() { imagePruneForce = false }()
+
+	if err := runImagePrune(nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !env.mock.HasCall("image", "delete", "orphan") || !env.mock.HasCall("image", "delete", "eee555") {
+		t.Errorf("expected orphaned images deleted, got calls %v", env.mock.Calls)
+	}
+	for _, kept := range []string{"in-use", "aaa111", "ccc333", "configured", "ddd444"} {
+		if env.mock.HasCall("image", "delete", kept) {
+			t.Errorf("expected %s to be kept", kept)
+		}
+	}
+}
+
+func TestImagePrune_OutsideProject(t *testing.T) {
+	env := setupTestEnv(t)
+	setImagePruneState(env)
+	imagePruneForce = true
+	defer func() { imagePruneForce = false }()
+
+	if err := runImagePrune(nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !env.mock.HasCall("image", "delete", "configured") {
+		t.Error("expected images only named by a project to be pruned outside it")
+	}
+}
+
+func TestImagePrune_OlderThan(t *testing.T) {
+	env := setupTestEnv(t)
+	setImagePruneState(env)
+	imagePruneForce = true
+	imagePruneOlderThan = "90d"
+	defer func() {
+		imagePruneForce = false
+		imagePruneOlderThan = "30d"
+	}()
+
+	if err := runImagePrune(nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if env.mock.HasCallPrefix("image", "delete") {
+		t.Error("expected nothing old enough to prune")
+	}
+
+	imagePruneOlderThan = "30 days"
+	if err := runImagePrune(nil, nil); err == nil || !strings.Contains(err.Error(), "invalid age") {
+		t.Errorf("expected invalid age error, got %v", err)
+	}
+}
@@ -1,56 +1,166 @@
 package cmd
 
 import (
+	"encoding/json"
 	"strings"
 	"testing"
+
+	"lxc-dev-manager/internal/operations"
 )
 
+const testImageQuery = `[
+ {"fingerprint":"abc123def456","aliases":[{"name":"my-base"}],"size":524288000,"architecture":"x86_64",
+  "created_at":"2026-01-02T03:04:05Z","expires_at":"2026-02-02T03:04:05Z","public":true,
+  "properties":{"description":"Ubuntu 24.04","source_container":"test-dev1","node":"20"}},
+ {"fingerprint":"def789ghi012","aliases":[{"name":"dev-image"}],"size":1288490188,"architecture":"x86_64",
+  "created_at":"2026-02-02T03:04:05Z","properties":{"description":"Custom dev image"}},
+ {"fingerprint":"fed456","aliases":[],"size":314572800,"architecture":"aarch64","cached":true,
+  "created_at":"2026-03-02T03:04:05Z","properties":{"description":"cached image"}}
+]`
+
 func TestImageList_Empty(t *testing.T) {
 	env := setupTestEnv(t)
-	env.mock.SetOutput("image list --format=csv -c lfsd", "")
+	env.mock.SetOutput("query /1.0/images?recursion=1", "[]")
 
-	err := runImageList(nil, []string{})
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
+	out := captureStdout(t, func() {
+		if err := runImageList(nil, []string{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if !strings.Contains(out, "No custom images found") {
+		t.Errorf("unexpected output: %s", out)
 	}
 }
 
 func TestImageList_WithImages(t *testing.T) {
 	env := setupTestEnv(t)
-	env.mock.SetOutput("image list --format=csv -c lfsd", `my-base,abc123def456,500MiB,Ubuntu 24.04
-dev-image,def789ghi012,1.2GiB,Custom dev image`)
+	env.mock.SetOutput("query /1.0/images?recursion=1", testImageQuery)
 
-	err := runImageList(nil, []string{})
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
+	out := captureStdout(t, func() {
+		if err := runImageList(nil, []string{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	for _, want := range []string{"my-base", "dev-image", "500.0MiB", "x86_64", "Ubuntu 24.04"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "dev-image") > strings.Index(out, "my-base") {
+		t.Errorf("expected images sorted by name:\n%s", out)
 	}
 }
 
 func TestImageList_FiltersCached(t *testing.T) {
 	env := setupTestEnv(t)
-	// One aliased, one cached (no alias)
-	env.mock.SetOutput("image list --format=csv -c lfsd", `my-base,abc123,500MiB,Ubuntu
-,def456,300MiB,cached image`)
+	env.mock.SetOutput("query /1.0/images?recursion=1", testImageQuery)
 
 	imageListAll = false
-	err := runImageList(nil, []string{})
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
+	out := captureStdout(t, func() {
+		if err := runImageList(nil, []string{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if strings.Contains(out, "fed456") {
+		t.Errorf("expected cached image to be hidden:\n%s", out)
 	}
-	// Should only show aliased images (verified by output inspection)
 }
 
 func TestImageList_ShowsAllWithFlag(t *testing.T) {
 	env := setupTestEnv(t)
-	env.mock.SetOutput("image list --format=csv -c lfsd", `my-base,abc123,500MiB,Ubuntu
-,def456,300MiB,cached image`)
+	env.mock.SetOutput("query /1.0/images?recursion=1", testImageQuery)
 
 	imageListAll = true
 	defer func() { imageListAll = false }()
 
-	err := runImageList(nil, []string{})
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
+	out := captureStdout(t, func() {
+		if err := runImageList(nil, []string{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if !strings.Contains(out, "fed456") {
+		t.Errorf("expected cached image with --all:\n%s", out)
+	}
+}
+
+func TestImageList_SortFilterJSON(t *testing.T) {
+	env := setupTestEnv(t)
+	env.mock.SetOutput("query /1.0/images?recursion=1", testImageQuery)
+
+	imageListSort, imageListFilter, imageListJSON = "size", "image", true
+	defer func() { imageListSort, imageListFilter, imageListJSON = "name", "", false }()
+
+	out := captureStdout(t, func() {
+		if err := runImageList(nil, []string{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	var images []operations.ImageInfo
+	if err := json.Unmarshal([]byte(out), &images); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if len(images) != 1 || images[0].Alias != "dev-image" || images[0].SizeBytes != 1288490188 {
+		t.Errorf("unexpected images: %+v", images)
+	}
+}
+
+func TestImageList_InvalidSort(t *testing.T) {
+	env := setupTestEnv(t)
+	env.mock.SetOutput("query /1.0/images?recursion=1", testImageQuery)
+
+	imageListSort = "age"
+	defer func() { imageListSort = "name" }()
+
+	if err := runImageList(nil, []string{}); err == nil {
+		t.Fatal("expected error for invalid sort key")
+	}
+}
+
+func TestImageInfo(t *testing.T) {
+	env := setupTestEnv(t)
+	env.mock.SetOutput("query /1.0/images?recursion=1", testImageQuery)
+
+	out := captureStdout(t, func() {
+		if err := runImageInfo(nil, []string{"my-base"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	for _, want := range []string{"abc123def456", "500.0MiB", "x86_64", "test-dev1", "Ubuntu 24.04", "never", "2026-02-02", "node:"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+}
+
+func TestImageInfo_FingerprintPrefixJSON(t *testing.T) {
+	env := setupTestEnv(t)
+	env.mock.SetOutput("query /1.0/images?recursion=1", testImageQuery)
+
+	imageInfoJSON = true
+	defer func() { imageInfoJSON = false }()
+
+	out := captureStdout(t, func() {
+		if err := runImageInfo(nil, []string{"fed"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	var info operations.ImageInfo
+	if err := json.Unmarshal([]byte(out), &info); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if info.Fingerprint != "fed456" || !info.Cached || info.Architecture != "aarch64" {
+		t.Errorf("unexpected info: %+v", info)
+	}
+}
+
+func TestImageInfo_NotFound(t *testing.T) {
+	env := setupTestEnv(t)
+	env.mock.SetOutput("query /1.0/images?recursion=1", testImageQuery)
+
+	err := runImageInfo(nil, []string{"missing"})
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected not found error, got %v", err)
 	}
 }
 
@@ -66,7 +176,7 @@ func TestImageDelete_Success(t *testing.T) {
 	withImageDeleteForce(t)
 
 	env.mock.SetOutput("image list my-base --format=csv -c f", "abc123def456")
-	env.mock.SetOutput("image list --format=csv -c lfsd", "my-base,abc123,500MiB,Test image")
+	env.mock.SetOutput("query /1.0/images?recursion=1", testImageQuery)
 	env.mock.SetOutput("image delete my-base", "")
 
 	err := runImageDelete(nil, []string{"my-base"})
@@ -99,7 +209,7 @@ func TestImageDelete_Error(t *testing.T) {
 	withImageDeleteForce(t)
 
 	env.mock.SetOutput("image list my-base --format=csv -c f", "abc123")
-	env.mock.SetOutput("image list --format=csv -c lfsd", "my-base,abc123,500MiB,Test")
+	env.mock.SetOutput("query /1.0/images?recursion=1", testImageQuery)
 	env.mock.SetError("image delete my-base", "image in use")
 
 	err := runImageDelete(nil, []string{"my-base"})
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"lxc-dev-manager/internal/errcode"
+	"lxc-dev-manager/internal/operations"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	imageExportForce bool
+	imageImportAlias string
+	imageImportForce bool
+)
+
+var imageExportCmd = &cobra.Command{
+	Use:   "export <image> <file.tar.gz>",
+	Short: "Export an image to a file",
+	Long: `Export an image to a file that can be copied to artifact storage and
+imported on another machine with 'image import'.
+
+Split images (separate metadata and rootfs) also write <file>.rootfs.
+
+Example:
+  lxc-dev-manager image export webapp-base webapp-base.tar.gz`,
+	Args: cobra.ExactArgs(2),
+	RunE: runImageExport,
+}
+
+var imageImportCmd = &cobra.Command{
+	Use:   "import <file.tar.gz> [rootfs]",
+	Short: "Import an image from a file",
+	Long: `Import an image written by 'image export' (or 'lxc image export').
+
+For split images, <file>.rootfs next to the file is picked up
+automatically; pass the rootfs explicitly if it's elsewhere.
+
+Example:
+  lxc-dev-manager image import webapp-base.tar.gz --alias webapp-base`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runImageImport,
+}
+
+var imagePushCmd = &cobra.Command{
+	Use:   "push <image> [remote]",
+	Short: "Push an image to a remote",
+	Long: `Copy a local image to an LXC remote (see 'lxc remote add') under the
+same alias. The remote defaults to the project's image_remote.
+
+Projects that set image_remote pull images that aren't local yet when
+creating containers, so pushing a base image once shares it with the team.
+
+Example:
+  lxc-dev-manager image push webapp-base team`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runImagePush,
+}
+
+// imageExportCmd, imageImportCmd and imagePushCmd are registered in image.go init()
+
+func runImageExport(cmd *cobra.Command, args []string) error {
+	alias, path := args[0], args[1]
+
+	fmt.Printf("Exporting image '%s'...\n", alias)
+	files, err := operations.ExportImage(alias, path, imageExportForce)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		fmt.Printf("Wrote %s\n", f)
+	}
+	fmt.Printf("\nImport it elsewhere with:\n")
+	fmt.Printf("  %s image import %s --alias %s\n", os.Args[0], path, alias)
+	return nil
+}
+
+func runImageImport(cmd *cobra.Command, args []string) error {
+	path := args[0]
+	var rootfs string
+	if len(args) > 1 {
+		rootfs = args[1]
+	}
+
+	fmt.Printf("Importing image '%s' from %s...\n", imageImportAlias, path)
+	if err := operations.ImportImage(path, rootfs, imageImportAlias, imageImportForce); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n%sImage '%s' imported successfully!%s\n", colorGreen, imageImportAlias, colorReset)
+	fmt.Printf("\nCreate new containers from it with:\n")
+	fmt.Printf("  %s container create <name> %s\n", os.Args[0], imageImportAlias)
+	return nil
+}
+
+func runImagePush(cmd *cobra.Command, args []string) error {
+	alias := args[0]
+
+	var remote string
+	if len(args) > 1 {
+		remote = args[1]
+	} else {
+		cfg, err := requireProject()
+		if err != nil {
+			return err
+		}
+		if cfg.ImageRemote == "" {
+			return errcode.Errorf(errcode.Usage, "", "no remote given and the project has no image_remote")
+		}
+		remote = cfg.ImageRemote
+	}
+
+	fmt.Printf("Pushing image '%s' to %s...\n", alias, remote)
+	if err := operations.PushImage(alias, remote); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n%sImage '%s' pushed to %s!%s\n", colorGreen, alias, remote, colorReset)
+	fmt.Printf("\nProjects with image_remote: %s pull it when creating containers:\n", remote)
+	fmt.Printf("  %s container create <name> %s\n", os.Args[0], alias)
+	return nil
+}
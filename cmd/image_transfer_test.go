@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImageExport(t *testing.T) {
+	env := setupTestEnv(t)
+	env.mock.SetOutput("image list webapp-base --format=csv -c f", "abc123")
+	env.mock.SetCallback("image export", func(args []string) {
+		os.WriteFile(filepath.Join(args[3], "abc123.tar.gz"), []byte("image"), 0644)
+	})
+	path := filepath.Join(env.dir, "webapp-base.tar.gz")
+
+	if err := runImageExport(nil, []string{"webapp-base", path}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "image" {
+		t.Errorf("expected the export at %s, got %q (%v)", path, data, err)
+	}
+	entries, _ := os.ReadDir(env.dir)
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".lxc-dev-manager-export-") {
+			t.Error("expected the temporary directory to be removed")
+		}
+	}
+
+	// A second export must not clobber the first without --force
+	if err := runImageExport(nil, []string{"webapp-base", path}); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("expected overwrite error, got %v", err)
+	}
+}
+
+func TestImageExport_Split(t *testing.T) {
+	env := setupTestEnv(t)
+	env.mock.SetOutput("image list webapp-base --format=csv -c f", "abc123")
+	env.mock.SetCallback("image export", func(args []string) {
+		os.WriteFile(filepath.Join(args[3], "meta-abc123.tar.xz"), []byte("meta"), 0644)
+		os.WriteFile(filepath.Join(args[3], "abc123.squashfs"), []byte("rootfs"), 0644)
+	})
+	path := filepath.Join(env.dir, "webapp-base.tar.xz")
+
+	if err := runImageExport(nil, []string{"webapp-base", path}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if data, _ := os.ReadFile(path); string(data) != "meta" {
+		t.Errorf("expected metadata at %s, got %q", path, data)
+	}
+	if data, _ := os.ReadFile(path + ".rootfs"); string(data) != "rootfs" {
+		t.Errorf("expected rootfs at %s.rootfs, got %q", path, data)
+	}
+}
+
+func TestImageExport_NotFound(t *testing.T) {
+	setupTestEnv(t)
+
+	err := runImageExport(nil, []string{"missing", "missing.tar.gz"})
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+func TestImageImport(t *testing.T) {
+	env := setupTestEnv(t)
+	os.WriteFile("image.tar.xz", []byte("meta"), 0644)
+	os.WriteFile("image.tar.xz.rootfs", []byte("rootfs"), 0644)
+	imageImportAlias = "webapp-base"
+	defer func() { imageImportAlias = "" }()
+
+	if err := runImageImport(nil, []string{"image.tar.xz"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !env.mock.HasCall("image", "import", "image.tar.xz", "image.tar.xz.rootfs", "--alias", "webapp-base") {
+		t.Errorf("expected import with the rootfs, got calls %v", env.mock.Calls)
+	}
+}
+
+func TestImageImport_AliasExists(t *testing.T) {
+	env := setupTestEnv(t)
+	os.WriteFile("image.tar.gz", []byte("image"), 0644)
+	env.mock.SetOutput("image list webapp-base --format=csv -c f", "abc123")
+	imageImportAlias = "webapp-base"
+	defer func() { imageImportAlias = "" }()
+
+	err := runImageImport(nil, []string{"image.tar.gz"})
+	if err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("expected already exists error, got %v", err)
+	}
+
+	imageImportForce = true
+	defer func() { imageImportForce = false }()
+	if err := runImageImport(nil, []string{"image.tar.gz"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !env.mock.HasCall("image", "delete", "webapp-base") || !env.mock.HasCall("image", "import", "image.tar.gz", "--alias", "webapp-base") {
+		t.Errorf("expected the old image replaced, got calls %v", env.mock.Calls)
+	}
+}
+
+func TestImageImport_NoAlias(t *testing.T) {
+	setupTestEnv(t)
+	os.WriteFile("image.tar.gz", []byte("image"), 0644)
+
+	err := runImageImport(nil, []string{"image.tar.gz"})
+	if err == nil || !strings.Contains(err.Error(), "--alias") {
+		t.Errorf("expected alias error, got %v", err)
+	}
+}
+
+func TestImagePush(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+image_remote: team
+containers: {}
+`)
+	env.mock.SetOutput("image list webapp-base --format=csv -c f", "abc123")
+
+	if err := runImagePush(nil, []string{"webapp-base"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !env.mock.HasCall("image", "copy", "webapp-base", "team:", "--alias", "webapp-base") {
+		t.Errorf("expected copy to the project's remote, got calls %v", env.mock.Calls)
+	}
+
+	if err := runImagePush(nil, []string{"webapp-base", "other"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !env.mock.HasCall("image", "copy", "webapp-base", "other:", "--alias", "webapp-base") {
+		t.Error("expected copy to the given remote")
+	}
+}
+
+func TestImagePush_NoRemote(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers: {}
+`)
+
+	err := runImagePush(nil, []string{"webapp-base"})
+	if err == nil || !strings.Contains(err.Error(), "image_remote") {
+		t.Errorf("expected missing remote error, got %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"lxc-dev-manager/internal/operations"
+	"lxc-dev-manager/internal/validation"
+
+	"github.com/spf13/cobra"
+)
+
+var infoCmd = &cobra.Command{
+	Use:   "info <container>",
+	Short: "Show everything about a container",
+	Long: `Show a container's configuration and live state in one view: image,
+status, IPs, uptime, configured vs listening ports, user, mounts, sync
+entries, snapshots and resource limits.
+
+Example:
+  lxc-dev-manager info dev1`,
+	Args: cobra.ExactArgs(1),
+	RunE: runInfo,
+}
+
+func init() {
+	rootCmd.AddCommand(infoCmd)
+}
+
+func runInfo(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	cfg, _, err := requireContainer(&name)
+	if err != nil {
+		return err
+	}
+
+	d, err := operations.Info(cfg, name)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Name:\t%s\n", d.Name)
+	fmt.Fprintf(w, "LXC name:\t%s\n", d.LXCName)
+	if d.Description != "" {
+		fmt.Fprintf(w, "Description:\t%s\n", d.Description)
+	}
+	fmt.Fprintf(w, "Image:\t%s\n", d.Image)
+	fmt.Fprintf(w, "Status:\t%s\n", d.Status)
+	if !d.StartedAt.IsZero() {
+		fmt.Fprintf(w, "Uptime:\t%s (since %s)\n", time.Since(d.StartedAt).Truncate(time.Second), d.StartedAt.Local().Format("2006-01-02 15:04"))
+	}
+	fmt.Fprintf(w, "IP:\t%s\n", orValue(d.IP, "-"))
+	if d.IPv6 != "" {
+		fmt.Fprintf(w, "IPv6:\t%s\n", d.IPv6)
+	}
+	fmt.Fprintf(w, "User:\t%s\n", d.User)
+	fmt.Fprintf(w, "Ports:\t%s\n", formatPorts(d.Ports))
+	if d.Status == "RUNNING" {
+		fmt.Fprintf(w, "Listening:\t%s\n", formatPorts(d.Listening))
+	}
+	w.Flush()
+
+	if missing := missingPorts(d.Ports, d.Listening); d.Status == "RUNNING" && len(missing) > 0 {
+		fmt.Printf("  (configured but not listening: %s)\n", formatPorts(missing))
+	}
+
+	fmt.Println("\nResources:")
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "  Disk:\t%s used, limit %s\n", validation.FormatSize(d.DiskUsage), orValue(d.Disk, "none"))
+	keys := make([]string, 0, len(d.Limits))
+	for k := range d.Limits {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(w, "  %s:\t%s\n", strings.TrimPrefix(k, "limits."), d.Limits[k])
+	}
+	w.Flush()
+
+	fmt.Println("\nMounts:")
+	if len(d.Mounts) == 0 {
+		fmt.Println("  (none)")
+	} else {
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, m := range d.Mounts {
+			fmt.Fprintf(w, "  %s\t%s -> %s\t%s\t%s\n", m.Name, m.Source, m.Path, m.Mode, m.Status)
+		}
+		w.Flush()
+	}
+
+	fmt.Println("\nSync:")
+	if len(d.Sync) == 0 {
+		fmt.Println("  (none)")
+	}
+	for _, s := range d.Sync {
+		fmt.Printf("  %s -> %s\n", s.Source, s.Dest)
+	}
+
+	fmt.Println("\nSnapshots:")
+	if len(d.Snapshots) == 0 {
+		fmt.Println("  (none)")
+	} else {
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, s := range d.Snapshots {
+			created := "-"
+			if !s.CreatedAt.IsZero() {
+				created = s.CreatedAt.Local().Format("2006-01-02 15:04")
+			}
+			fmt.Fprintf(w, "  %s\t%s\t%s\n", s.Name, created, s.Description)
+		}
+		w.Flush()
+	}
+
+	return nil
+}
+
+// missingPorts returns the configured ports nothing is listening on
+func missingPorts(configured, listening []int) []int {
+	open := make(map[int]bool, len(listening))
+	for _, p := range listening {
+		open[p] = true
+	}
+	var missing []int
+	for _, p := range configured {
+		if !open[p] {
+			missing = append(missing, p)
+		}
+	}
+	return missing
+}
+
+func orValue(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
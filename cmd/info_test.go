@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/operations"
+)
+
+func TestInfo_Running(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: ""
+containers:
+  dev1:
+    image: ubuntu:24.04
+    description: repro for the TLS bug
+    ports: [3000, 5432]
+    disk: 20GiB
+    sync:
+      - source: ./app
+        dest: /app
+`)
+	env.setContainerExists("dev1", true)
+	env.mock.SetOutput("query /1.0/instances/dev1", `{"last_used_at":"2024-05-01T10:00:00Z","expanded_config":{"limits.cpu":"2","limits.memory":"4GiB","image.os":"ubuntu"},"expanded_devices":{}}`)
+	env.mock.SetOutput("exec dev1 -- sh -c ss -Hltn 2>/dev/null || netstat -ltn", "LISTEN 0 128 0.0.0.0:3000 0.0.0.0:*\nLISTEN 0 128 [::]:22 [::]:*\n")
+	env.mock.SetOutput("config device show dev1", "")
+	env.mock.SetOutput("snapshot list dev1 -f csv", "")
+
+	if err := runInfo(nil, []string{"dev1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfg, err := config.Load(env.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := operations.Info(cfg, "dev1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.Description != "repro for the TLS bug" || d.Disk != "20GiB" || d.IP != "10.10.10.100" {
+		t.Errorf("unexpected details: %+v", d)
+	}
+	if d.StartedAt.IsZero() {
+		t.Error("expected start time for a running container")
+	}
+	if len(d.Limits) != 2 || d.Limits["limits.memory"] != "4GiB" {
+		t.Errorf("expected only limits.* keys, got %v", d.Limits)
+	}
+	if got := formatPorts(missingPorts(d.Ports, d.Listening)); got != "5432" {
+		t.Errorf("expected 5432 to be reported as not listening, got %s (listening %v)", got, d.Listening)
+	}
+}
+
+func TestInfo_NotFound(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+
+	err := runInfo(nil, []string{"nope"})
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
@@ -1,11 +1,15 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"text/tabwriter"
 
 	"lxc-dev-manager/internal/operations"
+	"lxc-dev-manager/internal/validation"
 
 	"github.com/spf13/cobra"
 )
@@ -15,14 +19,21 @@ var listCmd = &cobra.Command{
 	Short: "List all containers",
 	Long: `List all containers defined in the config with their status.
 
-Example:
-  lxc-dev-manager list`,
+Besides status and addresses, each row shows the number of snapshots and
+mounts and the root disk usage. Use --json for machine-readable output.
+
+Examples:
+  lxc-dev-manager list
+  lxc-dev-manager list --json`,
 	Args: cobra.NoArgs,
 	RunE: runList,
 }
 
+var listJSON bool
+
 func init() {
 	rootCmd.AddCommand(listCmd)
+	listCmd.Flags().BoolVar(&listJSON, "json", false, "Print containers as JSON")
 }
 
 func runList(cmd *cobra.Command, args []string) error {
@@ -31,6 +42,16 @@ func runList(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// Use operations package to get container list
+	containers, err := operations.List(cfg)
+	if err != nil {
+		return err
+	}
+
+	if listJSON {
+		return printListJSON(containers)
+	}
+
 	// Show project header
 	fmt.Printf("Project: %s\n\n", cfg.Project)
 
@@ -40,29 +61,84 @@ func runList(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	// Use operations package to get container list
-	containers, err := operations.List(cfg)
-	if err != nil {
-		return err
+	// Only show the IPv6 and description columns when some container has one
+	showIPv6 := false
+	showDescription := false
+	for _, c := range containers {
+		if c.IPv6 != "" {
+			showIPv6 = true
+		}
+		if c.Description != "" {
+			showDescription = true
+		}
 	}
 
-	// Print header
-	fmt.Printf("%-15s %-20s %-10s %-15s %s\n", "NAME", "IMAGE", "STATUS", "IP", "PORTS")
-	fmt.Println(strings.Repeat("-", 75))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	header := []string{"NAME", "IMAGE", "STATUS", "IP"}
+	if showIPv6 {
+		header = append(header, "IPV6")
+	}
+	header = append(header, "SNAPS", "MOUNTS", "DISK", "PORTS")
+	if showDescription {
+		header = append(header, "DESCRIPTION")
+	}
+	fmt.Fprintln(w, strings.Join(header, "\t"))
 
-	// Print each container
 	for _, c := range containers {
-		ip := c.IP
-		if ip == "" {
-			ip = "-"
+		row := []string{c.Name, c.Image, c.Status, orValue(c.IP, "-")}
+		if showIPv6 {
+			row = append(row, orValue(c.IPv6, "-"))
+		}
+		disk := "-"
+		if c.DiskUsage > 0 {
+			disk = validation.FormatSize(c.DiskUsage)
+		}
+		row = append(row, strconv.Itoa(c.Snapshots), strconv.Itoa(c.Mounts), disk, formatPorts(c.Ports))
+		if showDescription {
+			row = append(row, c.Description)
 		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
 
-		portStr := formatPorts(c.Ports)
+// listEntry is the JSON form of a list row
+type listEntry struct {
+	Name        string `json:"name"`
+	Image       string `json:"image"`
+	Description string `json:"description,omitempty"`
+	Status      string `json:"status"`
+	IP          string `json:"ip,omitempty"`
+	IPv6        string `json:"ipv6,omitempty"`
+	Ports       []int  `json:"ports"`
+	Snapshots   int    `json:"snapshots"`
+	Mounts      int    `json:"mounts"`
+	DiskUsage   int64  `json:"disk_usage"`
+}
 
-		fmt.Printf("%-15s %-20s %-10s %-15s %s\n", c.Name, c.Image, c.Status, ip, portStr)
+func printListJSON(containers []operations.ContainerInfo) error {
+	entries := make([]listEntry, 0, len(containers))
+	for _, c := range containers {
+		ports := c.Ports
+		if ports == nil {
+			ports = []int{}
+		}
+		entries = append(entries, listEntry{
+			Name:        c.Name,
+			Image:       c.Image,
+			Description: c.Description,
+			Status:      c.Status,
+			IP:          c.IP,
+			IPv6:        c.IPv6,
+			Ports:       ports,
+			Snapshots:   c.Snapshots,
+			Mounts:      c.Mounts,
+			DiskUsage:   c.DiskUsage,
+		})
 	}
-
-	return nil
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(entries)
 }
 
 func formatPorts(ports []int) string {
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/operations"
 )
 
 func TestList_Empty(t *testing.T) {
@@ -113,10 +114,53 @@ func TestList_LXCError(t *testing.T) {
   dev1:
     image: ubuntu
 `)
-	env.mock.SetError("list -c ns4 -f csv", "permission denied")
+	env.mock.SetError("list --format json", "permission denied")
 
 	err := runList(nil, []string{})
 	if err == nil {
 		t.Fatal("expected error")
 	}
 }
+
+func TestList_Counts(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`containers:
+  dev1:
+    image: ubuntu:24.04
+    devices:
+      src:
+        type: disk
+        config:
+          source: /srv/src
+          path: /src
+      serial:
+        type: unix-char
+        config:
+          source: /dev/ttyUSB0
+  dev2:
+    image: ubuntu:24.04
+`)
+	env.setListAllContainers(`dev1,RUNNING,10.10.10.45 (eth0),,2,1073741824
+dev2,STOPPED,,,0,`)
+
+	cfg, err := config.Load(env.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	containers, err := operations.List(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(containers) != 2 || containers[0].Name != "dev1" {
+		t.Fatalf("expected containers sorted by name, got %+v", containers)
+	}
+	if c := containers[0]; c.Snapshots != 2 || c.Mounts != 1 || c.DiskUsage != 1<<30 {
+		t.Errorf("unexpected counts for dev1: %+v", c)
+	}
+
+	listJSON = true
+	defer func() { listJSON = false }()
+	if err := runList(nil, []string{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+
+	"lxc-dev-manager/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+var forceUnlock bool
+
+var lockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Inspect config locks",
+	Long: `Commands that change containers.yaml lock it first: the whole project,
+or a single container. If a command was killed while holding a lock on a
+filesystem without flock support, later commands time out waiting for it.
+
+Use 'lock status' to see who holds each lock, and the global --force-unlock
+flag to remove stale locks before running a command. Locks a running process
+still holds are never removed.`,
+}
+
+var lockStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show who holds the project and container locks",
+	Long: `List the project lock and each container lock with its last recorded
+owner (pid, host, start time and command) and whether it looks stale.
+
+A lock is stale when it names an owner that no longer holds it, or an owner
+process on this host that has exited. Remove stale locks with:
+  lxc-dev-manager --force-unlock lock status
+
+Examples:
+  lxc-dev-manager lock status`,
+	Args: cobra.NoArgs,
+	RunE: runLockStatus,
+}
+
+func init() {
+	rootCmd.AddCommand(lockCmd)
+	lockCmd.AddCommand(lockStatusCmd)
+}
+
+func runLockStatus(cmd *cobra.Command, args []string) error {
+	if _, err := requireProject(); err != nil {
+		return err
+	}
+
+	locks, err := config.LockStatus(projectDir)
+	if err != nil {
+		return err
+	}
+	if len(locks) == 0 {
+		fmt.Println("No lock files.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "LOCK\tSTATE\tOWNER")
+	for _, l := range locks {
+		name := "project"
+		if l.Container != "" {
+			name = "container " + l.Container
+		}
+
+		state := "free"
+		switch {
+		case l.Stale():
+			state = "STALE"
+		case l.Held:
+			state = "held"
+		}
+
+		owner := "-"
+		if l.Owner != nil {
+			owner = l.Owner.String()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", name, state, owner)
+	}
+	w.Flush()
+
+	for _, l := range locks {
+		if l.Stale() {
+			fmt.Printf("\nRemove stale locks with: %s --force-unlock lock status\n", os.Args[0])
+			break
+		}
+	}
+	return nil
+}
+
+// runForceUnlock removes the project's stale lock files before a command runs
+func runForceUnlock() error {
+	removed, held, err := config.ForceUnlock(projectDir)
+	for _, path := range removed {
+		slog.Warn("removed stale lock", "path", path)
+	}
+	for _, path := range held {
+		slog.Warn("lock still held, not removed; delete it by hand once its owner is gone", "path", path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to remove locks: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestForceUnlock_RemovesLocks(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+	os.MkdirAll(filepath.Join(env.dir, ".containers.yaml.locks"), 0755)
+	os.WriteFile(filepath.Join(env.dir, ".containers.yaml.locks", "dev1.lock"), []byte("pid: 1\nhost: elsewhere\n"), 0644)
+
+	if err := runLockStatus(nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := runForceUnlock(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(env.dir, ".containers.yaml.locks", "dev1.lock")); !os.IsNotExist(err) {
+		t.Error("expected the stale container lock to be removed")
+	}
+}
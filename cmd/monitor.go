@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"lxc-dev-manager/internal/dryrun"
+	"lxc-dev-manager/internal/lxc"
+	"lxc-dev-manager/internal/operations"
+
+	"github.com/spf13/cobra"
+)
+
+var monitorCmd = &cobra.Command{
+	Use:   "monitor [name...]",
+	Short: "Act when a container stops unexpectedly",
+	Long: `Watch the project's containers (or only the ones named) with lxc monitor
+and react when a running one stops without being asked to: its processes
+died, or it was shut down from inside. Stops made with down or lxc stop are
+not reported.
+
+On each unexpected stop, monitor prints a line, runs the on_crash hook from
+containers.yaml, then the --exec command and a desktop notification with
+--notify. The --exec command runs with sh -c and gets the same environment
+as hooks (LXC_DEV_EVENT=on_crash, LXC_DEV_CONTAINER, LXC_DEV_LXC_NAME) plus
+LXC_DEV_ACTION, the lifecycle event that was seen.
+
+Press Ctrl+C to stop monitoring.
+
+Examples:
+  lxc-dev-manager monitor --notify
+  lxc-dev-manager monitor db --exec 'logger "db crashed"'`,
+	RunE: runMonitor,
+}
+
+var monitorExec string
+var monitorNotify bool
+
+func init() {
+	rootCmd.AddCommand(monitorCmd)
+	monitorCmd.Flags().StringVar(&monitorExec, "exec", "", "Command to run (with sh -c) when a container stops unexpectedly")
+	monitorCmd.Flags().BoolVar(&monitorNotify, "notify", false, "Show a desktop notification (notify-send) when a container stops unexpectedly")
+}
+
+// notifyDesktop shows a desktop notification; it's replaced in tests
+var notifyDesktop = func(title, body string) error {
+	path, err := exec.LookPath("notify-send")
+	if err != nil {
+		return fmt.Errorf("notify-send not found in PATH; install libnotify")
+	}
+	return exec.Command(path, "--urgency=critical", title, body).Run()
+}
+
+func runMonitor(cmd *cobra.Command, args []string) error {
+	if dryrun.Enabled() {
+		return fmt.Errorf("monitor does not support --dry-run")
+	}
+	cfg, err := requireProject()
+	if err != nil {
+		return err
+	}
+	names := args
+	for i := range names {
+		if err := resolveContainerName(cfg, &names[i]); err != nil {
+			return err
+		}
+	}
+
+	// Status changes outside this process, so results must not be cached
+	lxc.EnableCache(false)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	count := len(names)
+	if count == 0 {
+		count = len(cfg.Containers)
+	}
+	fmt.Printf("Watching %d container(s) for unexpected stops\n", count)
+	fmt.Println("\nPress Ctrl+C to stop")
+
+	return operations.Monitor(ctx, cfg, names, func(crash operations.Crash) {
+		fmt.Printf("%s  '%s' stopped unexpectedly (%s)\n", crash.Time.Local().Format("15:04:05"), crash.Name, crash.Action)
+
+		if monitorExec != "" {
+			c := exec.Command("sh", "-c", monitorExec)
+			c.Dir = cfg.ProjectDir()
+			c.Stdout = os.Stdout
+			c.Stderr = os.Stderr
+			c.Env = append(os.Environ(),
+				"LXC_DEV_EVENT=on_crash",
+				"LXC_DEV_ACTION="+crash.Action,
+				"LXC_DEV_CONTAINER="+crash.Name,
+				"LXC_DEV_LXC_NAME="+cfg.GetLXCName(crash.Name),
+			)
+			if err := c.Run(); err != nil {
+				slog.Warn("monitor --exec command failed", "container", crash.Name, "error", err)
+			}
+		}
+		if monitorNotify {
+			body := fmt.Sprintf("Container '%s' (%s) stopped unexpectedly", crash.Name, cfg.Project)
+			if err := notifyDesktop("lxc-dev-manager", body); err != nil {
+				slog.Warn("desktop notification failed", "container", crash.Name, "error", err)
+			}
+		}
+	})
+}
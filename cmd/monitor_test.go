@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMonitor_ReportsUnexpectedStop(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: ""
+hooks:
+  on_crash: echo "hook $LXC_DEV_EVENT {{.Name}}" >> crash.log
+containers:
+  dev1:
+    image: ubuntu:24.04
+  db:
+    image: ubuntu:24.04
+`)
+	env.setContainerExists("dev1", true)
+	env.setContainerExists("db", true)
+	env.mock.SetOutput("monitor --type=lifecycle --format=json", `{"type":"lifecycle","timestamp":"2024-05-01T10:00:00Z","metadata":{"action":"instance-stopped","source":"/1.0/instances/db","requestor":{"username":"dev"}}}
+{"type":"lifecycle","timestamp":"2024-05-01T10:00:02Z","metadata":{"action":"instance-shutdown","source":"/1.0/instances/dev1","requestor":null}}
+{"type":"lifecycle","timestamp":"2024-05-01T10:00:02Z","metadata":{"action":"instance-stopped","source":"/1.0/instances/dev1","requestor":null}}
+`)
+
+	var notified []string
+	oldNotify := notifyDesktop
+	notifyDesktop = func(title, body string) error {
+		notified = append(notified, body)
+		return nil
+	}
+	monitorExec = `echo "exec $LXC_DEV_CONTAINER $LXC_DEV_ACTION" >> crash.log`
+	monitorNotify = true
+	t.Cleanup(func() {
+		notifyDesktop = oldNotify
+		monitorExec = ""
+		monitorNotify = false
+	})
+
+	out := captureStdout(t, func() {
+		if err := runMonitor(nil, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	if !strings.Contains(out, "'dev1' stopped unexpectedly (instance-shutdown)") {
+		t.Errorf("expected the crash to be reported, got:\n%s", out)
+	}
+	if strings.Contains(out, "'db'") {
+		t.Errorf("a requested stop should not be reported, got:\n%s", out)
+	}
+
+	data, err := os.ReadFile(filepath.Join(env.dir, "crash.log"))
+	if err != nil {
+		t.Fatalf("expected the hook and --exec to run: %v", err)
+	}
+	want := "hook on_crash dev1\nexec dev1 instance-shutdown\n"
+	if string(data) != want {
+		t.Errorf("crash.log = %q, want %q", data, want)
+	}
+	if len(notified) != 1 || !strings.Contains(notified[0], "dev1") {
+		t.Errorf("expected one notification for dev1, got %v", notified)
+	}
+}
+
+func TestMonitor_UnknownContainer(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+
+	err := runMonitor(nil, []string{"nope"})
+	if err == nil || !strings.Contains(err.Error(), "nope") {
+		t.Errorf("expected a not-found error, got %v", err)
+	}
+}
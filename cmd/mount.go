@@ -4,7 +4,6 @@ import (
 	"fmt"
 
 	"lxc-dev-manager/internal/operations"
-	"lxc-dev-manager/internal/validation"
 
 	"github.com/spf13/cobra"
 )
@@ -13,8 +12,12 @@ var (
 	mountName      string
 	mountReadWrite bool
 	mountShift     bool
+	mountIDMap     string
+	mountNoAutoShift bool
+	mountNoSuffix  bool
+	mountRecursive bool
+	mountPropagation string
 	mountAllowRisky bool
-	mountYes       bool
 )
 
 var mountCmd = &cobra.Command{
@@ -28,6 +31,9 @@ Examples:
   lxc-dev-manager mount dev1 ~/project /workspace
   lxc-dev-manager mount dev1 ~/.isollm/repo.git /repo.git --rw
   lxc-dev-manager mount dev1 /data /mnt/data --name data-mount
+  lxc-dev-manager mount dev1 ~/project /workspace --rw --idmap auto
+  lxc-dev-manager mount dev1 ~/project /workspace --rw --idmap "both 1000 1000"
+  lxc-dev-manager mount dev1 /srv/tree /srv/tree --recursive --propagation rslave
   lxc-dev-manager mount dev1 /home /mnt/home --allow-risky`,
 	Args: cobra.ExactArgs(3),
 	RunE: runMount,
@@ -38,8 +44,12 @@ func init() {
 	mountCmd.Flags().StringVarP(&mountName, "name", "n", "", "Device name (default: auto-generated from path)")
 	mountCmd.Flags().BoolVar(&mountReadWrite, "rw", false, "Mount read-write (default: read-only)")
 	mountCmd.Flags().BoolVar(&mountShift, "shift", false, "Enable UID/GID shifting")
+	mountCmd.Flags().StringVar(&mountIDMap, "idmap", "", `Set raw.idmap entry (e.g. "both 1000 1000"), or "auto" to map the source owner`)
+	mountCmd.Flags().BoolVar(&mountRecursive, "recursive", false, "Also bind mounts nested under the source (e.g. /var/lib/docker)")
+	mountCmd.Flags().StringVar(&mountPropagation, "propagation", "", "Bind propagation: shared, slave, private (or r-prefixed variants)")
+	mountCmd.Flags().BoolVar(&mountNoSuffix, "no-auto-suffix", false, "Fail if the generated name is taken instead of adding -2, -3, ...")
+	mountCmd.Flags().BoolVar(&mountNoAutoShift, "no-auto-shift", false, "Don't enable shifting when source and container UIDs differ")
 	mountCmd.Flags().BoolVar(&mountAllowRisky, "allow-risky", false, "Allow mounting risky paths (e.g., /home)")
-	mountCmd.Flags().BoolVarP(&mountYes, "yes", "y", false, "Skip confirmation prompts")
 }
 
 func runMount(cmd *cobra.Command, args []string) error {
@@ -48,22 +58,35 @@ func runMount(cmd *cobra.Command, args []string) error {
 	containerPath := args[2]
 
 	// Load config with lock and verify container
-	cfg, _, lock, err := requireContainerWithLock(containerName)
+	cfg, _, lock, err := requireContainerWithLock(&containerName)
 	if err != nil {
 		return err
 	}
 	defer lock.Release()
 
 	// Handle risky path warning interactively (CLI-specific)
-	resolvedSource, warning, err := validation.ValidateSourcePath(sourcePath)
+	policy, err := cfg.PathPolicy()
+	if err != nil {
+		return err
+	}
+	resolvedSource, warning, err := policy.ValidateSourcePath(sourcePath)
 	if err != nil {
 		return fmt.Errorf("invalid source path: %w", err)
 	}
 
+	// Policy denials can't be confirmed away, so check before prompting
+	if err := operations.CheckMountPolicy(cfg, resolvedSource, mountReadWrite, warning != ""); err != nil {
+		return err
+	}
+
 	allowRiskyPath := mountAllowRisky
-	if warning != "" && !mountAllowRisky && !mountYes {
+	if warning != "" && !mountAllowRisky && !assumeYes {
 		fmt.Printf("Warning: %s\n", warning)
-		if confirmPrompt("Do you want to continue?") {
+		ok, err := confirmPrompt("Do you want to continue?")
+		if err != nil {
+			return err
+		}
+		if ok {
 			allowRiskyPath = true
 		} else {
 			fmt.Println("Cancelled")
@@ -71,11 +94,26 @@ func runMount(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Resolve "auto" here so the chosen mapping can be shown to the user
+	idmap := mountIDMap
+	if idmap == operations.IDMapAuto {
+		idmap, err = operations.SuggestIDMap(cfg, containerName, resolvedSource)
+		if err != nil {
+			return err
+		}
+	}
+	idmapIsNew := idmap != "" && !operations.HasIDMap(cfg, containerName, idmap)
+
 	// Use operations package for core logic
 	deviceName, err := operations.Mount(cfg, containerName, sourcePath, containerPath, operations.MountOpts{
 		Name:           mountName,
 		ReadWrite:      mountReadWrite,
 		Shift:          mountShift,
+		IDMap:          idmap,
+		Recursive:      mountRecursive,
+		Propagation:    mountPropagation,
+		NoAutoShift:    mountNoAutoShift,
+		NoAutoSuffix:   mountNoSuffix,
 		AllowRiskyPath: allowRiskyPath,
 	})
 	if err != nil {
@@ -88,5 +126,26 @@ func runMount(cmd *cobra.Command, args []string) error {
 		mode = "rw"
 	}
 	fmt.Printf("Mounted '%s' -> '%s' (%s) as device '%s'\n", resolvedSource, containerPath, mode, deviceName)
+
+	autoShifted := !mountShift && cfg.GetDevices(containerName)[deviceName].Config["shift"] == "true"
+
+	switch {
+	case autoShifted:
+		_, hostUID, mappedUID, _ := operations.OwnershipMismatch(cfg, containerName, resolvedSource)
+		fmt.Printf("Enabled shift: source is owned by host UID %d but the container user runs as host UID %d.\n", hostUID, mappedUID)
+		fmt.Println("If files still appear as nobody:nogroup, remount with --idmap auto (or --no-auto-shift to disable).")
+	case idmapIsNew:
+		fmt.Printf("Set raw.idmap '%s'. Restart the container to apply it:\n", idmap)
+		fmt.Printf("  lxc-dev-manager down %s && lxc-dev-manager up %s\n", containerName, containerName)
+	case mountReadWrite && !mountShift && idmap == "" && mountNoAutoShift:
+		// Without shifting, mismatched host-owned files show up as nobody:nogroup
+		mismatch, _, _, err := operations.OwnershipMismatch(cfg, containerName, resolvedSource)
+		if err != nil || !mismatch {
+			break
+		}
+		if suggestion, err := operations.SuggestIDMap(cfg, containerName, resolvedSource); err == nil {
+			fmt.Printf("Tip: if files appear as nobody:nogroup, remount with --idmap \"%s\" (or --shift)\n", suggestion)
+		}
+	}
 	return nil
 }
@@ -1,7 +1,9 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -25,13 +27,13 @@ containers:
 	mountReadWrite = false
 	mountShift = false
 	mountAllowRisky = false
-	mountYes = false
+	assumeYes = false
 	defer func() {
 		mountName = ""
 		mountReadWrite = false
 		mountShift = false
 		mountAllowRisky = false
-		mountYes = false
+		assumeYes = false
 	}()
 
 	err := runMount(nil, []string{"dev1", sourceDir, "/workspace"})
@@ -72,13 +74,13 @@ containers:
 	mountReadWrite = false
 	mountShift = false
 	mountAllowRisky = false
-	mountYes = false
+	assumeYes = false
 	defer func() {
 		mountName = ""
 		mountReadWrite = false
 		mountShift = false
 		mountAllowRisky = false
-		mountYes = false
+		assumeYes = false
 	}()
 
 	err := runMount(nil, []string{"dev1", sourceDir, "/workspace"})
@@ -111,13 +113,13 @@ containers:
 	mountReadWrite = true
 	mountShift = false
 	mountAllowRisky = false
-	mountYes = false
+	assumeYes = false
 	defer func() {
 		mountName = ""
 		mountReadWrite = false
 		mountShift = false
 		mountAllowRisky = false
-		mountYes = false
+		assumeYes = false
 	}()
 
 	err := runMount(nil, []string{"dev1", sourceDir, "/workspace"})
@@ -185,13 +187,13 @@ containers:
 	mountReadWrite = false
 	mountShift = false
 	mountAllowRisky = false
-	mountYes = false
+	assumeYes = false
 	defer func() {
 		mountName = ""
 		mountReadWrite = false
 		mountShift = false
 		mountAllowRisky = false
-		mountYes = false
+		assumeYes = false
 	}()
 
 	err := runMount(nil, []string{"dev1", sourceDir, "/workspace"})
@@ -226,13 +228,13 @@ containers:
 	mountReadWrite = false
 	mountShift = false
 	mountAllowRisky = false
-	mountYes = false
+	assumeYes = false
 	defer func() {
 		mountName = ""
 		mountReadWrite = false
 		mountShift = false
 		mountAllowRisky = false
-		mountYes = false
+		assumeYes = false
 	}()
 
 	err := runMount(nil, []string{"dev1", sourceDir, "/workspace"})
@@ -266,13 +268,13 @@ containers:
 	mountReadWrite = false
 	mountShift = false
 	mountAllowRisky = false
-	mountYes = false
+	assumeYes = false
 	defer func() {
 		mountName = ""
 		mountReadWrite = false
 		mountShift = false
 		mountAllowRisky = false
-		mountYes = false
+		assumeYes = false
 	}()
 
 	err := runMount(nil, []string{"dev1", sourceDir, "/workspace"})
@@ -287,6 +289,293 @@ containers:
 	}
 }
 
+func TestMount_IDMap(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+`)
+	env.setContainerExists("test-dev1", true)
+	env.mock.SetOutput("config get test-dev1 security.privileged", "")
+	env.mock.SetOutput("config device add test-dev1", "")
+	env.mock.SetOutput("config set test-dev1 raw.idmap", "")
+
+	sourceDir := t.TempDir()
+
+	mountName = "myrepo"
+	mountReadWrite = true
+	mountIDMap = "both 1000 1000"
+	defer func() {
+		mountName = ""
+		mountReadWrite = false
+		mountIDMap = ""
+	}()
+
+	if err := runMount(nil, []string{"dev1", sourceDir, "/workspace"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !env.mock.HasCall("config", "set", "test-dev1", "raw.idmap", "both 1000 1000") {
+		t.Error("expected raw.idmap to be set")
+	}
+
+	cfg := env.readConfig()
+	if !strings.Contains(cfg, "both 1000 1000") {
+		t.Error("expected idmap entry in config")
+	}
+}
+
+func TestMount_IDMapAuto(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+`)
+	env.setContainerExists("test-dev1", true)
+	env.mock.SetOutput("config get test-dev1 security.privileged", "")
+	env.mock.SetOutput("config device add test-dev1", "")
+	env.mock.SetOutput("config set test-dev1 raw.idmap", "")
+	env.mock.SetOutput("exec test-dev1 -- id -u", "1001\n")
+
+	sourceDir := t.TempDir()
+
+	mountName = "myrepo"
+	mountReadWrite = true
+	mountIDMap = "auto"
+	defer func() {
+		mountName = ""
+		mountReadWrite = false
+		mountIDMap = ""
+	}()
+
+	if err := runMount(nil, []string{"dev1", sourceDir, "/workspace"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := fmt.Sprintf("both %d 1001", os.Getuid())
+	if !env.mock.HasCall("config", "set", "test-dev1", "raw.idmap", want) {
+		t.Errorf("expected raw.idmap %q, calls: %v", want, env.mock.Calls)
+	}
+}
+
+func TestMount_IDMapWithShift(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+`)
+	env.setContainerExists("test-dev1", true)
+
+	sourceDir := t.TempDir()
+
+	mountShift = true
+	mountIDMap = "both 1000 1000"
+	defer func() {
+		mountShift = false
+		mountIDMap = ""
+	}()
+
+	if err := runMount(nil, []string{"dev1", sourceDir, "/workspace"}); err == nil {
+		t.Fatal("expected error when combining --shift and --idmap")
+	}
+	if env.mock.HasCallPrefix("config", "device", "add") {
+		t.Error("should not add device")
+	}
+}
+
+func TestMount_AutoShiftOnUIDMismatch(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+`)
+	env.setContainerExists("test-dev1", true)
+	env.mock.SetOutput("config get test-dev1 security.privileged", "")
+	env.mock.SetOutput("config get test-dev1 volatile.last_state.idmap",
+		`[{"Isuid":true,"Isgid":false,"Hostid":1000000,"Nsid":0,"Maprange":1000000000}]`)
+	env.mock.SetOutput("exec test-dev1 -- id -u", "1000\n")
+	env.mock.SetOutput("config device add test-dev1", "")
+
+	sourceDir := t.TempDir()
+
+	mountName = "myrepo"
+	mountReadWrite = true
+	defer func() {
+		mountName = ""
+		mountReadWrite = false
+	}()
+
+	if err := runMount(nil, []string{"dev1", sourceDir, "/workspace"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(env.readConfig(), `shift: "true"`) {
+		t.Error("expected shift to be enabled for mismatched UIDs")
+	}
+}
+
+func TestMount_NoAutoShift(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+`)
+	env.setContainerExists("test-dev1", true)
+	env.mock.SetOutput("config get test-dev1 security.privileged", "")
+	env.mock.SetOutput("config get test-dev1 volatile.last_state.idmap",
+		`[{"Isuid":true,"Isgid":false,"Hostid":1000000,"Nsid":0,"Maprange":1000000000}]`)
+	env.mock.SetOutput("config device add test-dev1", "")
+
+	sourceDir := t.TempDir()
+
+	mountName = "myrepo"
+	mountReadWrite = true
+	mountNoAutoShift = true
+	defer func() {
+		mountName = ""
+		mountReadWrite = false
+		mountNoAutoShift = false
+	}()
+
+	if err := runMount(nil, []string{"dev1", sourceDir, "/workspace"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if strings.Contains(env.readConfig(), "shift") {
+		t.Error("expected shift to stay disabled with --no-auto-shift")
+	}
+}
+
+func TestMount_AutoSuffixOnNameConflict(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+    devices:
+      myproject:
+        type: disk
+        config:
+          source: /other/myproject
+          path: /other
+`)
+	env.setContainerExists("test-dev1", true)
+	env.mock.SetOutput("config get test-dev1 security.privileged", "")
+	env.mock.SetOutput("config device add test-dev1", "")
+
+	sourceDir := t.TempDir() + "/myproject"
+	if err := os.MkdirAll(sourceDir, 0755); err != nil {
+		t.Fatalf("failed to create source dir: %v", err)
+	}
+
+	if err := runMount(nil, []string{"dev1", sourceDir, "/workspace"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !env.mock.HasCallPrefix("config", "device", "add", "test-dev1", "myproject-2", "disk") {
+		t.Error("expected auto-suffixed device name myproject-2")
+	}
+}
+
+func TestMount_NoAutoSuffix(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+    devices:
+      myproject:
+        type: disk
+        config:
+          source: /other/myproject
+          path: /other
+`)
+	env.setContainerExists("test-dev1", true)
+	env.mock.SetOutput("config get test-dev1 security.privileged", "")
+
+	sourceDir := t.TempDir() + "/myproject"
+	if err := os.MkdirAll(sourceDir, 0755); err != nil {
+		t.Fatalf("failed to create source dir: %v", err)
+	}
+
+	mountNoSuffix = true
+	defer func() { mountNoSuffix = false }()
+
+	err := runMount(nil, []string{"dev1", sourceDir, "/workspace"})
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("expected name conflict error, got: %v", err)
+	}
+}
+
+func TestMount_RecursivePropagation(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+`)
+	env.setContainerExists("test-dev1", true)
+	env.mock.SetOutput("config get test-dev1 security.privileged", "")
+	env.mock.SetOutput("config device add test-dev1", "")
+
+	sourceDir := t.TempDir()
+
+	mountName = "tree"
+	mountRecursive = true
+	mountPropagation = "rslave"
+	defer func() {
+		mountName = ""
+		mountRecursive = false
+		mountPropagation = ""
+	}()
+
+	if err := runMount(nil, []string{"dev1", sourceDir, "/srv/tree"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	found := false
+	for _, call := range env.mock.Calls {
+		joined := strings.Join(call.Args, " ")
+		if strings.HasPrefix(joined, "config device add test-dev1 tree disk") {
+			found = strings.Contains(joined, "recursive=true") && strings.Contains(joined, "propagation=rslave")
+		}
+	}
+	if !found {
+		t.Error("expected device add with recursive=true and propagation=rslave")
+	}
+
+	cfg := env.readConfig()
+	if !strings.Contains(cfg, "recursive: \"true\"") || !strings.Contains(cfg, "propagation: rslave") {
+		t.Errorf("expected bind options in config, got:\n%s", cfg)
+	}
+}
+
+func TestMount_InvalidPropagation(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+`)
+	env.setContainerExists("test-dev1", true)
+
+	mountPropagation = "everywhere"
+	defer func() { mountPropagation = "" }()
+
+	err := runMount(nil, []string{"dev1", t.TempDir(), "/srv/tree"})
+	if err == nil || !strings.Contains(err.Error(), "invalid propagation") {
+		t.Errorf("expected invalid propagation error, got: %v", err)
+	}
+	if env.mock.HasCallPrefix("config device add") {
+		t.Error("device should not be added with invalid propagation")
+	}
+}
+
 // TestUnmount tests
 
 func TestUnmount_ByName(t *testing.T) {
@@ -459,3 +748,183 @@ containers: {}
 		t.Errorf("unexpected error: %v", err)
 	}
 }
+
+func TestMounts_All(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+    devices:
+      repo:
+        type: disk
+        config:
+          source: /host/repo
+          path: /repo
+  dev2:
+    image: ubuntu:24.04
+`)
+	env.setContainerExists("test-dev1", true)
+	env.setContainerExists("test-dev2", true)
+	env.setListAllContainers("test-dev1,RUNNING\ntest-dev2,RUNNING")
+	env.mock.SetOutput("config device show test-dev1", `repo:
+  type: disk
+  source: /host/repo
+  path: /repo
+`)
+	env.mock.SetOutput("config device show test-dev2", "")
+
+	mountsAll = true
+	defer func() { mountsAll = false }()
+
+	if err := runMounts(nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !env.mock.HasCall("config", "device", "show", "test-dev2") {
+		t.Error("expected mounts of every container to be listed")
+	}
+}
+
+func TestMounts_AllRejectsContainer(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+
+	mountsAll = true
+	defer func() { mountsAll = false }()
+
+	if err := runMounts(nil, []string{"dev1"}); err == nil {
+		t.Fatal("expected error when combining --all with a container")
+	}
+}
+
+func TestUnmount_AllBySource(t *testing.T) {
+	env := setupTestEnv(t)
+	sourceDir := t.TempDir()
+	env.writeConfig(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+    devices:
+      repo:
+        type: disk
+        config:
+          source: ` + sourceDir + `
+          path: /repo
+  dev2:
+    image: ubuntu:24.04
+    devices:
+      code:
+        type: disk
+        config:
+          source: ` + sourceDir + `
+          path: /code
+      other:
+        type: disk
+        config:
+          source: /host/other
+          path: /other
+  gone:
+    image: ubuntu:24.04
+    devices:
+      repo:
+        type: disk
+        config:
+          source: ` + sourceDir + `
+          path: /repo
+`)
+	env.setContainerExists("test-dev1", true)
+	env.setContainerExists("test-dev2", true)
+	env.setContainerNotExists("test-gone")
+	env.mock.SetOutput("config device remove", "")
+
+	unmountAll = true
+	unmountForce = true
+	defer func() {
+		unmountAll = false
+		unmountForce = false
+	}()
+
+	if err := runUnmount(nil, []string{sourceDir}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !env.mock.HasCall("config", "device", "remove", "test-dev1", "repo") {
+		t.Error("expected unmount from dev1")
+	}
+	if !env.mock.HasCall("config", "device", "remove", "test-dev2", "code") {
+		t.Error("expected unmount from dev2")
+	}
+	if env.mock.HasCall("config", "device", "remove", "test-dev2", "other") {
+		t.Error("should not unmount unrelated source")
+	}
+
+	cfg := env.readConfig()
+	if strings.Contains(cfg, sourceDir) {
+		t.Errorf("expected all mounts of source to be removed from config, got:\n%s", cfg)
+	}
+	if !strings.Contains(cfg, "/host/other") {
+		t.Error("expected unrelated mount to be kept")
+	}
+}
+
+func TestUnmount_AllNotMounted(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+
+	unmountAll = true
+	defer func() { unmountAll = false }()
+
+	err := runUnmount(nil, []string{"/nowhere"})
+	if err == nil || !strings.Contains(err.Error(), "not mounted") {
+		t.Errorf("expected not mounted error, got: %v", err)
+	}
+}
+
+func TestMount_ProjectBlockedPath(t *testing.T) {
+	env := setupTestEnv(t)
+	sourceDir := t.TempDir()
+	env.writeConfig(fmt.Sprintf(`project: test
+security:
+  blocked_host_paths: [%s]
+containers:
+  dev1:
+    image: ubuntu:24.04
+`, sourceDir))
+	env.setContainerExists("test-dev1", true)
+
+	err := runMount(nil, []string{"dev1", sourceDir, "/workspace"})
+	if err == nil || !strings.Contains(err.Error(), "not allowed") {
+		t.Fatalf("expected blocked path error, got %v", err)
+	}
+	if env.mock.HasCallPrefix("config", "device", "add") {
+		t.Error("expected no device to be added")
+	}
+}
+
+func TestMount_PolicyDeniesReadWriteOutsideProject(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+`)
+	env.setContainerExists("test-dev1", true)
+	userConfig := filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "lxc-dev-manager", "config.yaml")
+	if err := os.MkdirAll(filepath.Dir(userConfig), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(userConfig, []byte("policy:\n  deny_rw_outside_project: true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mountReadWrite, mountAllowRisky, assumeYes = true, true, true
+	defer func() { mountReadWrite, mountAllowRisky, assumeYes = false, false, false }()
+
+	err := runMount(nil, []string{"dev1", t.TempDir(), "/data"})
+	if err == nil || !strings.Contains(err.Error(), "deny_rw_outside_project") {
+		t.Fatalf("expected policy denial, got %v", err)
+	}
+	if env.mock.HasCallPrefix("config", "device", "add") {
+		t.Error("expected no device to be added")
+	}
+}
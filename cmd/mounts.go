@@ -12,6 +12,7 @@ import (
 )
 
 var mountsSync bool
+var mountsAll bool
 
 var mountsCmd = &cobra.Command{
 	Use:   "mounts [container]",
@@ -27,19 +28,32 @@ Use --sync to reconcile config with LXC state:
   - untracked mounts will be added to config
   - missing mounts will be re-added to LXC
 
+Use --all to list mounts across every container in the project.
+
 Examples:
   lxc-dev-manager mounts dev1
-  lxc-dev-manager mounts dev1 --sync`,
-	Args: cobra.ExactArgs(1),
+  lxc-dev-manager mounts dev1 --sync
+  lxc-dev-manager mounts --all`,
+	Args: cobra.RangeArgs(0, 1),
 	RunE: runMounts,
 }
 
 func init() {
 	rootCmd.AddCommand(mountsCmd)
 	mountsCmd.Flags().BoolVar(&mountsSync, "sync", false, "Reconcile config with LXC state")
+	mountsCmd.Flags().BoolVarP(&mountsAll, "all", "a", false, "List mounts of all containers")
 }
 
 func runMounts(cmd *cobra.Command, args []string) error {
+	if mountsAll {
+		if len(args) > 0 || mountsSync {
+			return fmt.Errorf("--all takes no container and can't be combined with --sync")
+		}
+		return runMountsAll()
+	}
+	if len(args) != 1 {
+		return fmt.Errorf("requires a container name (or --all)")
+	}
 	containerName := args[0]
 
 	var cfg *config.Config
@@ -48,13 +62,13 @@ func runMounts(cmd *cobra.Command, args []string) error {
 
 	// Use lock if we're syncing (will modify config)
 	if mountsSync {
-		cfg, _, lock, err = requireContainerWithLock(containerName)
+		cfg, _, lock, err = requireContainerWithLock(&containerName)
 		if err != nil {
 			return err
 		}
 		defer lock.Release()
 	} else {
-		cfg, _, err = requireContainer(containerName)
+		cfg, _, err = requireContainer(&containerName)
 		if err != nil {
 			return err
 		}
@@ -91,3 +105,29 @@ func runMounts(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+func runMountsAll() error {
+	cfg, err := requireProject()
+	if err != nil {
+		return err
+	}
+
+	mounts, err := operations.ListAllMounts(cfg)
+	if err != nil {
+		return err
+	}
+
+	if len(mounts) == 0 {
+		fmt.Println("No mounts found.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "CONTAINER\tNAME\tSOURCE\tPATH\tMODE\tSTATUS")
+	for _, m := range mounts {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", m.Container, m.Name, m.Source, m.Path, m.Mode, m.Status)
+	}
+	w.Flush()
+
+	return nil
+}
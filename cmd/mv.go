@@ -3,13 +3,15 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"path"
-	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/errcode"
 	"lxc-dev-manager/internal/lxc"
+	"lxc-dev-manager/internal/operations"
+	"lxc-dev-manager/internal/validation"
 
 	"github.com/spf13/cobra"
 )
@@ -78,103 +80,76 @@ func matchContainers(cfg *config.Config, pattern string) []string {
 // validateContainer checks that a container exists in config and LXC
 func validateContainer(cfg *config.Config, name string) error {
 	if !cfg.HasContainer(name) {
-		return fmt.Errorf("container '%s' not found in project config", name)
+		return cfg.ContainerNotFound(name)
 	}
 	lxcName := cfg.GetLXCName(name)
 	if !lxc.Exists(lxcName) {
-		return fmt.Errorf("container '%s' does not exist in LXC (expected: %s)", name, lxcName)
+		return errcode.Errorf(errcode.NotFound, name, "container '%s' does not exist in LXC (expected: %s)", name, lxcName)
 	}
 	return nil
 }
 
 // copyToContainer copies a file or directory from host to a single container
-func copyToContainer(cfg *config.Config, containerName, source, remotePath string, sourceInfo os.FileInfo, autoCreate bool) error {
-	lxcName := cfg.GetLXCName(containerName)
-
-	// Expand ~ to user's home directory
-	if strings.HasPrefix(remotePath, "~/") {
-		user := cfg.GetUser(containerName)
-		remotePath = "/home/" + user.Name + remotePath[1:]
-	} else if remotePath == "~" {
-		user := cfg.GetUser(containerName)
-		remotePath = "/home/" + user.Name
-	}
-
-	// Determine if recursive (directory)
-	recursive := sourceInfo.IsDir()
-
-	// Get the destination directory to check/create
-	destDir := path.Dir(remotePath)
-
-	// Check if destination directory exists
-	user := cfg.GetUser(containerName)
-	if !lxc.DirExists(lxcName, destDir) {
-		if !autoCreate && !confirmPrompt(fmt.Sprintf("Directory '%s' does not exist in %s. Create it?", destDir, containerName)) {
-			return fmt.Errorf("destination directory does not exist")
-		}
-		if err := lxc.Exec(lxcName, "mkdir", "-p", destDir); err != nil {
-			return fmt.Errorf("failed to create directory: %w", err)
-		}
-		lxc.Exec(lxcName, "chown", user.Name+":"+user.Name, destDir)
-	}
-
-	// Push the file
-	pushPath := remotePath
-	if recursive {
-		pushPath = path.Dir(remotePath)
-	}
-
-	if err := lxc.FilePush(lxcName, source, pushPath, recursive); err != nil {
-		return err
-	}
-
-	// Fix ownership
-	if recursive {
-		if err := lxc.Exec(lxcName, "chown", "-R", user.Name+":"+user.Name, remotePath); err != nil {
-			return fmt.Errorf("could not set ownership: %w", err)
-		}
-	} else {
-		if err := lxc.Exec(lxcName, "chown", user.Name+":"+user.Name, remotePath); err != nil {
-			return fmt.Errorf("could not set ownership: %w", err)
-		}
-	}
-
-	return nil
+func copyToContainer(cfg *config.Config, containerName, source, remotePath string, autoCreate bool) error {
+	return operations.CopyToContainer(cfg, containerName, source, remotePath, operations.CopyOpts{
+		AutoCreateDir: autoCreate,
+		Exclude:       mvExclude,
+		Verify:        mvVerify,
+		ConfirmCreateDir: func(dir string) bool {
+			return confirmOrDecline(fmt.Sprintf("Directory '%s' does not exist in %s. Create it?", dir, containerName))
+		},
+		Progress: copyProgress(),
+	})
 }
 
 // copyFromContainer copies a file or directory from container to host
 func copyFromContainer(cfg *config.Config, containerName, remotePath, localPath string) error {
-	lxcName := cfg.GetLXCName(containerName)
+	return operations.CopyFromContainer(cfg, containerName, remotePath, localPath, operations.CopyOpts{
+		Verify:   mvVerify,
+		Progress: copyProgress(),
+	})
+}
 
-	// Expand ~ to user's home directory
-	if strings.HasPrefix(remotePath, "~/") {
-		user := cfg.GetUser(containerName)
-		remotePath = "/home/" + user.Name + remotePath[1:]
-	} else if remotePath == "~" {
-		user := cfg.GetUser(containerName)
-		remotePath = "/home/" + user.Name
-	}
+// copyBetweenContainers streams a file or directory from one container to another
+func copyBetweenContainers(cfg *config.Config, srcContainer, srcPath, destContainer, destPath string, autoCreate bool) error {
+	return operations.CopyBetweenContainers(cfg, srcContainer, srcPath, destContainer, destPath, operations.CopyOpts{
+		AutoCreateDir: autoCreate,
+		Exclude:       mvExclude,
+		Verify:        mvVerify,
+		ConfirmCreateDir: func(dir string) bool {
+			return confirmOrDecline(fmt.Sprintf("Directory '%s' does not exist in %s. Create it?", dir, destContainer))
+		},
+		Progress: copyProgress(),
+	})
+}
 
-	// Check if source exists in container
-	if !lxc.FileExists(lxcName, remotePath) {
-		return fmt.Errorf("source '%s' does not exist in container %s", remotePath, containerName)
+// copyProgress returns a progress renderer, or nil with --quiet or when
+// stdout isn't a terminal
+func copyProgress() func(operations.CopyProgress) {
+	if mvQuiet {
+		return nil
 	}
-
-	// Determine if recursive (directory)
-	recursive := lxc.IsDir(lxcName, remotePath)
-
-	// Ensure local destination directory exists
-	localDir := filepath.Dir(localPath)
-	if err := os.MkdirAll(localDir, 0755); err != nil {
-		return fmt.Errorf("failed to create local directory: %w", err)
+	if info, err := os.Stdout.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return nil
 	}
+	return renderCopyProgress
+}
 
-	// Pull the file
-	if err := lxc.FilePull(lxcName, remotePath, localPath, recursive); err != nil {
-		return err
+// renderCopyProgress redraws a single progress line: bytes, percent and ETA
+func renderCopyProgress(p operations.CopyProgress) {
+	line := validation.FormatSize(p.Copied)
+	if p.Total > 0 {
+		line = fmt.Sprintf("%s / %s (%.0f%%)", line, validation.FormatSize(p.Total), p.Percent())
+	}
+	if p.Finished {
+		line += fmt.Sprintf(" in %s", p.Elapsed.Round(time.Second))
+	} else if eta := p.ETA(); eta > 0 {
+		line += fmt.Sprintf(", ETA %s", eta.Round(time.Second))
+	}
+	fmt.Printf("\r\033[K  %s", line)
+	if p.Finished {
+		fmt.Println()
 	}
-
-	return nil
 }
 
 var mvCmd = &cobra.Command{
@@ -189,22 +164,43 @@ Supports multiple directions:
 
 Use * to target all containers, or prefix* to match by name prefix.
 
+Container to container copies are streamed directly (tar piped between the
+two containers) without a temporary copy on the host.
+
 Examples:
   lxc-dev-manager mv ./app dev1:/home/dev/app       # host → container
   lxc-dev-manager mv ./config.json *:/etc/app/      # host → all containers
   lxc-dev-manager mv dev1:/etc/config ./backup/     # container → host
   lxc-dev-manager mv dev1:/app/config *:/app/       # container → all containers
   lxc-dev-manager mv dev1:/data dev2:/data          # container → container
-  lxc-dev-manager mv ./data dev1:/opt/data -y       # auto-create directory`,
+  lxc-dev-manager mv ./data dev1:/opt/data -y       # auto-create directory
+  lxc-dev-manager mv ./app dev1:/home/dev/app -x node_modules -x .git
+
+When copying a directory, --exclude skips matching paths. Patterns without
+a slash match names at any depth (node_modules, *.log); patterns with a
+slash match paths relative to the directory (/build, docs/*.pdf), and a
+trailing slash matches directories only. Patterns listed in a .lxcignore
+file at the root of the directory are applied too.
+
+Progress (bytes copied, ETA) is shown on a terminal; use --quiet to hide it.
+
+With --verify (or defaults.verify_copy in containers.yaml), sha256 checksums
+of the copied files are compared on both sides and a mismatch is an error.`,
 	Args: cobra.ExactArgs(2),
 	RunE: runMv,
 }
 
-var mvYes bool
+var (
+	mvQuiet   bool
+	mvExclude []string
+	mvVerify  bool
+)
 
 func init() {
 	rootCmd.AddCommand(mvCmd)
-	mvCmd.Flags().BoolVarP(&mvYes, "yes", "y", false, "Auto-create destination directory if it doesn't exist")
+	mvCmd.Flags().BoolVarP(&mvQuiet, "quiet", "q", false, "Don't show copy progress")
+	mvCmd.Flags().BoolVar(&mvVerify, "verify", false, "Compare sha256 checksums on both sides after copying")
+	mvCmd.Flags().StringArrayVarP(&mvExclude, "exclude", "x", nil, "Skip paths matching a pattern when copying a directory (repeatable)")
 }
 
 func runMv(cmd *cobra.Command, args []string) error {
@@ -278,7 +274,7 @@ func hostToContainer(src, dst pathSpec) error {
 
 			printCopyMessage(src.path, name, dst.path, info.IsDir())
 
-			if err := copyToContainer(cfg, name, src.path, dst.path, info, mvYes); err != nil {
+			if err := copyToContainer(cfg, name, src.path, dst.path, assumeYes); err != nil {
 				errors = append(errors, fmt.Sprintf("%s: %v", name, err))
 				fmt.Printf("✗ %s failed: %v\n", name, err)
 				continue
@@ -300,7 +296,7 @@ func hostToContainer(src, dst pathSpec) error {
 
 	printCopyMessage(src.path, dst.container, dst.path, info.IsDir())
 
-	if err := copyToContainer(cfg, dst.container, src.path, dst.path, info, mvYes); err != nil {
+	if err := copyToContainer(cfg, dst.container, src.path, dst.path, assumeYes); err != nil {
 		return err
 	}
 
@@ -361,27 +357,7 @@ func containerToContainer(src, dst pathSpec) error {
 		return err
 	}
 
-	// Create temp directory for intermediate storage
-	tempDir, err := os.MkdirTemp("", "lxc-mv-")
-	if err != nil {
-		return fmt.Errorf("failed to create temp directory: %w", err)
-	}
-	defer os.RemoveAll(tempDir)
-
-	// Pull from source container to temp
-	tempPath := filepath.Join(tempDir, filepath.Base(src.path))
-	fmt.Printf("Pulling from %s:%s...\n", src.container, src.path)
-	if err := copyFromContainer(cfg, src.container, src.path, tempPath); err != nil {
-		return fmt.Errorf("failed to pull from source: %w", err)
-	}
-
-	// Get info about the pulled file/directory
-	info, err := os.Stat(tempPath)
-	if err != nil {
-		return fmt.Errorf("failed to stat temp file: %w", err)
-	}
-
-	// Push to destination container(s)
+	// Copy to destination container(s)
 	if strings.Contains(dst.container, "*") {
 		matches := matchContainers(cfg, dst.container)
 		if len(matches) == 0 {
@@ -404,9 +380,9 @@ func containerToContainer(src, dst pathSpec) error {
 				continue
 			}
 
-			printCopyMessage(src.path, name, dst.path, info.IsDir())
+			fmt.Printf("Copying %s:%s to %s:%s...\n", src.container, src.path, name, dst.path)
 
-			if err := copyToContainer(cfg, name, tempPath, dst.path, info, mvYes); err != nil {
+			if err := copyBetweenContainers(cfg, src.container, src.path, name, dst.path, assumeYes); err != nil {
 				errors = append(errors, fmt.Sprintf("%s: %v", name, err))
 				fmt.Printf("✗ %s failed: %v\n", name, err)
 				continue
@@ -426,9 +402,9 @@ func containerToContainer(src, dst pathSpec) error {
 		return err
 	}
 
-	printCopyMessage(src.path, dst.container, dst.path, info.IsDir())
+	fmt.Printf("Copying %s:%s to %s:%s...\n", src.container, src.path, dst.container, dst.path)
 
-	if err := copyToContainer(cfg, dst.container, tempPath, dst.path, info, mvYes); err != nil {
+	if err := copyBetweenContainers(cfg, src.container, src.path, dst.container, dst.path, assumeYes); err != nil {
 		return err
 	}
 
@@ -253,20 +253,8 @@ containers:
 	env.mock.SetOutput("exec dev1 -- test -e /home/alice/config", "")
 	// Mock is directory check - return error to indicate it's a file
 	env.mock.SetError("exec dev1 -- test -d /home/alice/config", "not a directory")
-	// Mock file pull from dev1 - use callback to create the temp file
-	env.mock.SetOutput("file pull", "")
-	env.mock.SetCallback("file pull", func(args []string) {
-		// args: ["file", "pull", "dev1//home/alice/config", "/tmp/lxc-mv-.../config"]
-		// The last arg is the destination path
-		if len(args) >= 4 {
-			destPath := args[len(args)-1]
-			_ = os.WriteFile(destPath, []byte("mock config content"), 0644)
-		}
-	})
 	// Mock directory exists check for dest
 	env.mock.SetOutput("exec dev2 -- test -d /home/bob", "")
-	// Mock file push to dev2
-	env.mock.SetOutput("file push", "")
 	// Mock chown on dev2
 	env.mock.SetOutput("exec dev2 -- chown bob:bob /home/bob/config", "")
 
@@ -275,29 +263,228 @@ containers:
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	// Verify the file pull was called with alice's home
-	foundPull := false
-	for _, call := range env.mock.Calls {
-		callStr := strings.Join(call.Args, " ")
-		if strings.Contains(callStr, "file pull") && strings.Contains(callStr, "/home/alice/config") {
-			foundPull = true
-			break
-		}
+	// Verify the file is streamed from alice's home into bob's home
+	if !env.mock.HasCall("exec", "dev1", "--", "cat", "--", "/home/alice/config") {
+		t.Errorf("expected stream from alice's home path, got calls: %v", env.mock.Calls)
 	}
-	if !foundPull {
-		t.Errorf("expected file pull with alice's home path, got calls: %v", env.mock.Calls)
+	if !env.mock.HasCall("exec", "dev2", "--", "sh", "-c", `cat > "$1"`, "sh", "/home/bob/config") {
+		t.Errorf("expected stream into bob's home path, got calls: %v", env.mock.Calls)
 	}
+	if env.mock.HasCallPrefix("file") {
+		t.Errorf("expected no host round trip, got calls: %v", env.mock.Calls)
+	}
+}
 
-	// Verify the file push was called with bob's home
-	foundPush := false
+func TestMv_ContainerToContainerDirectory(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: ""
+containers:
+  dev1:
+    image: ubuntu:24.04
+  dev2:
+    image: ubuntu:24.04
+`)
+	env.setContainerExists("dev1", true)
+	env.setContainerExists("dev2", true)
+	env.mock.SetOutput("exec dev1 -- test -e /build/out", "")
+	env.mock.SetOutput("exec dev1 -- test -d /build/out", "")
+	env.mock.SetError("exec dev1 -- test -e /build/out/.lxcignore", "not found")
+	env.mock.SetOutput("exec dev2 -- test -d /opt", "")
+
+	if err := runMv(nil, []string{"dev1:/build/out", "dev2:/opt/out"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !env.mock.HasCall("exec", "dev1", "--", "tar", "-C", "/build/out", "-cf", "-", ".") {
+		t.Errorf("expected tar stream from source, got calls: %v", env.mock.Calls)
+	}
+	if !env.mock.HasCallPrefix("exec", "dev2", "--", "sh", "-c") {
+		t.Errorf("expected tar extract on destination, got calls: %v", env.mock.Calls)
+	}
+	if !env.mock.HasCall("exec", "dev2", "--", "chown", "-R", "dev:dev", "/opt/out") {
+		t.Errorf("expected recursive chown on destination, got calls: %v", env.mock.Calls)
+	}
+}
+
+func TestMv_ContainerToContainerExcludeUsesHost(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: ""
+containers:
+  dev1:
+    image: ubuntu:24.04
+  dev2:
+    image: ubuntu:24.04
+`)
+	env.setContainerExists("dev1", true)
+	env.setContainerExists("dev2", true)
+	env.mock.SetOutput("exec dev1 -- test", "")
+	env.mock.SetOutput("exec dev2 -- test -d /opt", "")
+	env.mock.SetCallback("file pull", func(args []string) {
+		// Recursive pulls create the source directory inside the target
+		os.MkdirAll(filepath.Join(args[len(args)-1], "app"), 0755)
+	})
+
+	mvExclude = []string{"node_modules"}
+	defer func() { mvExclude = nil }()
+
+	if err := runMv(nil, []string{"dev1:/src/app", "dev2:/opt/app"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !env.mock.HasCallPrefix("file", "pull", "-r") || !env.mock.HasCallPrefix("file", "push", "-r") {
+		t.Errorf("expected host round trip with excludes, got calls: %v", env.mock.Calls)
+	}
+	if env.mock.HasCallPrefix("exec", "dev1", "--", "tar") {
+		t.Errorf("expected no streaming with excludes, got calls: %v", env.mock.Calls)
+	}
+}
+
+func TestMv_ContainerToContainerViaHostRenamesDirectory(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: ""
+containers:
+  dev1:
+    image: ubuntu:24.04
+  dev2:
+    image: ubuntu:24.04
+`)
+	env.setContainerExists("dev1", true)
+	env.setContainerExists("dev2", true)
+	env.mock.SetOutput("exec dev1 -- test", "")
+	env.mock.SetOutput("exec dev2 -- test -d /opt", "")
+	env.mock.SetCallback("file pull", func(args []string) {
+		os.MkdirAll(filepath.Join(args[len(args)-1], "app"), 0755)
+	})
+	var pushed string
+	env.mock.SetCallback("file push -r", func(args []string) {
+		pushed = args[len(args)-2]
+	})
+
+	mvExclude = []string{"node_modules"}
+	defer func() { mvExclude = nil }()
+
+	if err := runMv(nil, []string{"dev1:/src/app", "dev2:/opt/renamed"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Pushed into /opt, so the directory must be named after the
+	// destination to end up at /opt/renamed like a streamed copy
+	if filepath.Base(pushed) != "renamed" {
+		t.Errorf("expected the pushed directory to be named renamed, got %q", pushed)
+	}
+}
+
+func TestMv_DirectoryCopyWithExclude(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+	env.setContainerExists("dev1", true)
+	env.mock.SetOutput("exec dev1 -- test -d", "")
+	env.mock.SetOutput("exec dev1 -- chown -R dev:dev", "")
+
+	testDir := filepath.Join(env.dir, "myproject")
+	os.MkdirAll(filepath.Join(testDir, "node_modules"), 0755)
+	os.MkdirAll(filepath.Join(testDir, "target"), 0755)
+	os.WriteFile(filepath.Join(testDir, "main.go"), []byte("package main"), 0644)
+	os.WriteFile(filepath.Join(testDir, "node_modules", "dep.js"), []byte("x"), 0644)
+	os.WriteFile(filepath.Join(testDir, "target", "app"), []byte("bin"), 0755)
+	os.WriteFile(filepath.Join(testDir, ".lxcignore"), []byte("target/\n"), 0644)
+
+	// Inspect the directory handed to lxc while it still exists
+	var pushed []string
+	env.mock.SetCallback("file push -r", func(args []string) {
+		src := args[len(args)-2]
+		filepath.WalkDir(src, func(p string, d os.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				rel, _ := filepath.Rel(src, p)
+				pushed = append(pushed, rel)
+			}
+			return nil
+		})
+	})
+
+	mvExclude = []string{"node_modules"}
+	defer func() { mvExclude = nil }()
+
+	if err := runMv(nil, []string{testDir, "dev1:/home/dev/myproject"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(pushed) != 1 || pushed[0] != "main.go" {
+		t.Errorf("expected only main.go to be pushed, got %v", pushed)
+	}
+}
+
+func TestMv_Verify(t *testing.T) {
+	tests := []struct {
+		name    string
+		remote  string
+		wantErr bool
+	}{
+		// sha256 of "test content"
+		{"match", "6ae8a75555209fd6c44157c0aed8016e763ff435a19cf186f76863140143ff72  /home/dev/testfile.txt\n", false},
+		{"truncated", "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855  /home/dev/testfile.txt\n", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := setupTestEnv(t)
+			env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+			env.setContainerExists("dev1", true)
+			env.mock.SetOutput("exec dev1 -- test -d /home/dev", "")
+			env.mock.SetOutput("file push", "")
+			env.mock.SetOutput("exec dev1 -- chown", "")
+			env.mock.SetOutput("exec dev1 -- sha256sum -- /home/dev/testfile.txt", tt.remote)
+
+			testFile := filepath.Join(env.dir, "testfile.txt")
+			os.WriteFile(testFile, []byte("test content"), 0644)
+
+			mvVerify = true
+			defer func() { mvVerify = false }()
+
+			err := runMv(nil, []string{testFile, "dev1:/home/dev/testfile.txt"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("runMv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "checksum mismatch") {
+				t.Errorf("expected checksum mismatch error, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestMv_VerifyFromConfig(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: ""
+containers:
+  dev1:
+    image: ubuntu:24.04
+defaults:
+  verify_copy: true
+`)
+	env.setContainerExists("dev1", true)
+	env.mock.SetOutput("exec dev1 -- test -d", "")
+	env.mock.SetOutput("file push -r", "")
+	env.mock.SetOutput("exec dev1 -- chown -R dev:dev", "")
+	// Only a.txt arrived
+	env.mock.SetOutput("exec dev1 -- sh -c", "ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb  ./a.txt\n")
+
+	testDir := filepath.Join(env.dir, "myproject")
+	os.MkdirAll(testDir, 0755)
+	os.WriteFile(filepath.Join(testDir, "a.txt"), []byte("a"), 0644)
+	os.WriteFile(filepath.Join(testDir, "b.txt"), []byte("b"), 0644)
+
+	err := runMv(nil, []string{testDir, "dev1:/home/dev/myproject"})
+	if err == nil || !strings.Contains(err.Error(), "b.txt") {
+		t.Fatalf("expected mismatch reporting b.txt, got: %v", err)
+	}
+
+	found := false
 	for _, call := range env.mock.Calls {
-		callStr := strings.Join(call.Args, " ")
-		if strings.Contains(callStr, "file push") && strings.Contains(callStr, "/home/bob/config") {
-			foundPush = true
-			break
+		if strings.Contains(strings.Join(call.Args, " "), "sha256sum") && call.Args[len(call.Args)-1] == "/home/dev/myproject" {
+			found = true
 		}
 	}
-	if !foundPush {
-		t.Errorf("expected file push with bob's home path, got calls: %v", env.mock.Calls)
+	if !found {
+		t.Errorf("expected checksums of /home/dev/myproject, got calls: %v", env.mock.Calls)
 	}
 }
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"lxc-dev-manager/internal/operations"
+
+	"github.com/spf13/cobra"
+)
+
+var portCheckPreferIPv6 bool
+
+var portCmd = &cobra.Command{
+	Use:   "port",
+	Short: "Inspect container ports",
+}
+
+var portCheckCmd = &cobra.Command{
+	Use:   "check <container>",
+	Short: "Check that configured ports are listening and reachable",
+	Long: `For each configured port, check whether a process is listening inside
+the container (via ss, or netstat) and whether the port is reachable from
+the host at the container's IP.
+
+This tells apart "the app isn't running", "the app only listens on
+localhost" and "the proxy is the problem".
+
+Examples:
+  lxc-dev-manager port check dev1
+  lxc-dev-manager port check dev1 --prefer-ipv6`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPortCheck,
+}
+
+func init() {
+	rootCmd.AddCommand(portCmd)
+	portCmd.AddCommand(portCheckCmd)
+	portCheckCmd.Flags().BoolVar(&portCheckPreferIPv6, "prefer-ipv6", false, "Probe the container's IPv6 address when available")
+}
+
+func runPortCheck(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	cfg, _, err := requireRunningContainer(&name)
+	if err != nil {
+		return err
+	}
+
+	checks, ip, err := operations.CheckPorts(cfg, name, operations.ProxyOpts{
+		PreferIPv6: portCheckPreferIPv6,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Ports of %s (%s):\n", name, ip)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "PORT\tLISTENING\tREACHABLE\tSTATUS")
+	for _, c := range checks {
+		listening := "no"
+		if c.Listening {
+			listening = strings.Join(c.Addresses, ",")
+		}
+		reachable := "no"
+		if c.Reachable {
+			reachable = "yes"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", c.Port, listening, reachable, portStatus(c))
+	}
+	return w.Flush()
+}
+
+// portStatus explains a port check result
+func portStatus(c operations.PortCheck) string {
+	switch {
+	case c.Reachable:
+		return "ok"
+	case !c.Listening:
+		return "nothing listening - is the app running?"
+	case c.LoopbackOnly:
+		return "bound to localhost only - listen on 0.0.0.0"
+	default:
+		return "listening but unreachable - check the container firewall"
+	}
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/operations"
+)
+
+func TestPortCheck(t *testing.T) {
+	env := setupTestEnv(t)
+
+	// One port served on the "container" IP, one whose listener is loopback-only
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer ln.Close()
+	served := ln.Addr().(*net.TCPAddr).Port
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	unserved := closed.Addr().(*net.TCPAddr).Port
+	closed.Close()
+
+	env.writeConfig(fmt.Sprintf(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+    ports: [%d, %d, 9]
+`, served, unserved))
+	env.setContainerExists("test-dev1", true)
+	env.mock.SetOutput("list test-dev1 -c4 -f csv", "127.0.0.1 (eth0)")
+	env.mock.SetOutput("exec test-dev1 -- sh -c", fmt.Sprintf(
+		"LISTEN 0 4096 0.0.0.0:%d 0.0.0.0:*\nLISTEN 0 511 [::1]:%d [::]:*\n", served, unserved))
+
+	if err := runPortCheck(nil, []string{"dev1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfg, err := config.Load(env.dir)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	checks, _, err := operations.CheckPorts(cfg, "dev1", operations.ProxyOpts{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(checks) != 3 {
+		t.Fatalf("expected 3 checks, got %d", len(checks))
+	}
+
+	if !checks[0].Listening || !checks[0].Reachable || portStatus(checks[0]) != "ok" {
+		t.Errorf("expected port %d ok, got %+v", served, checks[0])
+	}
+	if !checks[1].LoopbackOnly || checks[1].Reachable || !strings.Contains(portStatus(checks[1]), "localhost") {
+		t.Errorf("expected port %d loopback-only, got %+v", unserved, checks[1])
+	}
+	if checks[2].Listening || !strings.Contains(portStatus(checks[2]), "nothing listening") {
+		t.Errorf("expected port 9 not listening, got %+v", checks[2])
+	}
+}
+
+func TestPortCheck_NotRunning(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+    ports: [8000]
+`)
+	env.setContainerExists("test-dev1", false)
+
+	err := runPortCheck(nil, []string{"dev1"})
+	if err == nil || !strings.Contains(err.Error(), "not running") {
+		t.Errorf("expected not running error, got: %v", err)
+	}
+}
@@ -3,12 +3,9 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"path/filepath"
-	"strconv"
-	"strings"
+	"sort"
 
 	"lxc-dev-manager/internal/config"
-	"lxc-dev-manager/internal/lxc"
 	"lxc-dev-manager/internal/operations"
 
 	"github.com/spf13/cobra"
@@ -35,6 +32,11 @@ the project name in LXC.
 Default ports for proxying can be specified with --ports as a
 comma-separated list. If not specified, no default ports are set.
 
+Creation is refused when existing LXC containers already carry the
+project prefix, e.g. "web-app-api" for a new project "web". Pass --force
+to create it anyway, e.g. to recreate a project deleted with
+--keep-containers.
+
 Examples:
   lxc-dev-manager project create
   lxc-dev-manager project create --name my-app
@@ -57,6 +59,11 @@ the project name in LXC.
 Default ports for proxying can be specified with --ports as a
 comma-separated list. If not specified, no default ports are set.
 
+Creation is refused when existing LXC containers already carry the
+project prefix, e.g. "web-app-api" for a new project "web". Pass --force
+to create it anyway, e.g. to recreate a project deleted with
+--keep-containers.
+
 This is an alias for 'lxc-dev-manager project create'.
 
 Examples:
@@ -73,62 +80,88 @@ var projectDeleteCmd = &cobra.Command{
 	Long: `Deletes all containers belonging to this project and removes
 the containers.yaml file. This action is destructive and irreversible.
 
+The containers that will be deleted are listed before asking for
+confirmation. Pass --yes or --force to skip it, and --dry-run to only
+show what would be deleted.
+
+If a container fails to delete, the project is left in place so the
+command can be retried. --force keeps going: the other containers are
+deleted and containers.yaml removed anyway.
+
+--keep-containers only removes containers.yaml; the LXC containers are
+left as they are and can be managed with lxc directly.
+
 Examples:
   lxc-dev-manager project delete
-  lxc-dev-manager project delete --force`,
+  lxc-dev-manager project delete --force
+  lxc-dev-manager project delete --keep-containers`,
 	Args: cobra.NoArgs,
 	RunE: runProjectDelete,
 }
 
+var projectRenameCmd = &cobra.Command{
+	Use:   "rename <new-name>",
+	Short: "Rename the project and its containers",
+	Long: `Renames the project, moving every container to the new prefix in LXC
+and updating containers.yaml.
+
+Running containers are stopped for the rename and started again
+afterwards. Snapshots move with their containers. If a container can't be
+renamed, the ones already renamed are moved back.
+
+The project switches to escaped naming as part of the rename, so hyphens
+in the new name are doubled in LXC names ("my-app" gives "my--app-dev").
+
+Projects with volumes can't be renamed yet, as the LXD volumes carry the
+project prefix too.
+
+Examples:
+  lxc-dev-manager project rename my-app`,
+	Args: cobra.ExactArgs(1),
+	RunE: runProjectRename,
+}
+
 var (
 	projectNameFlag    string
 	projectPortsFlag   string
+	projectCreateForce bool
 	projectDeleteForce bool
+	projectDeleteKeep  bool
 )
 
 func init() {
 	rootCmd.AddCommand(projectCmd)
 	projectCmd.AddCommand(projectCreateCmd)
 	projectCmd.AddCommand(projectDeleteCmd)
+	projectCmd.AddCommand(projectRenameCmd)
 
 	// Add --name flag to project create
 	projectCreateCmd.Flags().StringVarP(&projectNameFlag, "name", "n", "", "Project name (defaults to folder name)")
 	projectCreateCmd.Flags().StringVarP(&projectPortsFlag, "ports", "p", "", "Default ports to proxy (comma-separated, e.g., 5173,8000,5432)")
+	projectCreateCmd.Flags().BoolVarP(&projectCreateForce, "force", "f", false, "Create even if LXC containers already use the project prefix")
 
-	// Add --force flag to project delete
-	projectDeleteCmd.Flags().BoolVarP(&projectDeleteForce, "force", "f", false, "Skip confirmation prompt")
+	projectDeleteCmd.Flags().BoolVarP(&projectDeleteForce, "force", "f", false, "Skip confirmation and keep going when a container fails to delete")
+	projectDeleteCmd.Flags().BoolVar(&projectDeleteKeep, "keep-containers", false, "Only remove containers.yaml, leaving the LXC containers")
 
 	// Add root-level create alias
 	rootCmd.AddCommand(createCmd)
 	createCmd.Flags().StringVarP(&projectNameFlag, "name", "n", "", "Project name (defaults to folder name)")
 	createCmd.Flags().StringVarP(&projectPortsFlag, "ports", "p", "", "Default ports to proxy (comma-separated, e.g., 5173,8000,5432)")
+	createCmd.Flags().BoolVarP(&projectCreateForce, "force", "f", false, "Create even if LXC containers already use the project prefix")
 }
 
 func runProjectCreate(cmd *cobra.Command, args []string) error {
 	// Parse ports flag
-	var ports []int
-	if projectPortsFlag != "" {
-		portStrs := strings.Split(projectPortsFlag, ",")
-		for _, ps := range portStrs {
-			ps = strings.TrimSpace(ps)
-			if ps == "" {
-				continue
-			}
-			port, err := strconv.Atoi(ps)
-			if err != nil {
-				return fmt.Errorf("invalid port %q: %w", ps, err)
-			}
-			if port < 1 || port > 65535 {
-				return fmt.Errorf("invalid port %d: must be between 1 and 65535", port)
-			}
-			ports = append(ports, port)
-		}
+	ports, err := parsePortList(projectPortsFlag)
+	if err != nil {
+		return err
 	}
 
 	// Use operations package for project creation
 	cfg, err := operations.CreateProject(projectDir, operations.CreateProjectOpts{
 		Name:  projectNameFlag,
 		Ports: ports,
+		Force: projectCreateForce,
 	})
 	if err != nil {
 		return err
@@ -153,13 +186,22 @@ func runProjectDelete(cmd *cobra.Command, args []string) error {
 	fmt.Printf("Config:  %s\n\n", config.ConfigFile)
 
 	if len(cfg.Containers) > 0 {
-		fmt.Println("Containers to be deleted:")
+		if projectDeleteKeep {
+			fmt.Println("Containers kept in LXC (only the config is removed):")
+		} else {
+			fmt.Println("Containers to be deleted:")
+		}
+		inv, _ := operations.Inventory(cfg)
+		names := make([]string, 0, len(cfg.Containers))
 		for name := range cfg.Containers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
 			lxcName := cfg.GetLXCName(name)
 			status := "NOT FOUND"
-			if lxc.Exists(lxcName) {
-				s, _ := lxc.GetStatus(lxcName)
-				status = s
+			if info, ok := inv[lxcName]; ok {
+				status = info.Status
 			}
 			fmt.Printf("  - %s (%s) [%s]\n", name, lxcName, status)
 		}
@@ -170,47 +212,50 @@ func runProjectDelete(cmd *cobra.Command, args []string) error {
 
 	// Confirm deletion
 	if !projectDeleteForce {
-		if !confirmPrompt("Are you sure you want to delete this project?") {
+		ok, err := confirmPrompt("Are you sure you want to delete this project?")
+		if err != nil {
+			return err
+		}
+		if !ok {
 			fmt.Println("Cancelled.")
 			return nil
 		}
 	}
 
-	// Delete all containers
-	var deleteErrors []string
-	for name := range cfg.Containers {
-		lxcName := cfg.GetLXCName(name)
-		fmt.Printf("Deleting container '%s'... ", name)
-
-		if lxc.Exists(lxcName) {
-			if err := lxc.Delete(lxcName); err != nil {
-				fmt.Printf("FAILED: %v\n", err)
-				deleteErrors = append(deleteErrors, fmt.Sprintf("%s: %v", name, err))
-				continue
-			}
-		}
-		fmt.Println("done")
+	err = operations.DeleteProject(projectDir, operations.DeleteProjectOpts{
+		KeepContainers: projectDeleteKeep,
+		Force:          projectDeleteForce,
+		Progress: func(name string) {
+			fmt.Printf("Deleting container '%s'...\n", name)
+		},
+	})
+	if err != nil {
+		return err
 	}
 
-	// Remove config file
-	cfgDir := projectDir
-	if cfgDir == "" {
-		cfgDir = "."
-	}
-	configPath := filepath.Join(cfgDir, config.ConfigFile)
-	fmt.Printf("Removing %s... ", configPath)
-	if err := os.Remove(configPath); err != nil {
-		return fmt.Errorf("failed to remove config: %w", err)
+	fmt.Printf("\nProject '%s' deleted\n", cfg.Project)
+	return nil
+}
+
+func runProjectRename(cmd *cobra.Command, args []string) error {
+	newName := args[0]
+
+	cfg, lock, err := requireProjectWithLock()
+	if err != nil {
+		return err
 	}
-	fmt.Println("done")
+	defer lock.Release()
 
-	if len(deleteErrors) > 0 {
-		fmt.Printf("\nWarning: Some containers failed to delete:\n")
-		for _, e := range deleteErrors {
-			fmt.Printf("  - %s\n", e)
-		}
+	oldName := cfg.Project
+	err = operations.RenameProject(cfg, newName, operations.RenameProjectOpts{
+		Progress: func(name string) {
+			fmt.Printf("Renaming container '%s' (%s)...\n", name, cfg.GetLXCName(name))
+		},
+	})
+	if err != nil {
+		return err
 	}
 
-	fmt.Printf("\nProject '%s' deleted\n", cfg.Project)
+	fmt.Printf("Project '%s' renamed to '%s'\n", oldName, newName)
 	return nil
 }
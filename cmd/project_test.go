@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"lxc-dev-manager/internal/config"
+)
+
+const twoContainerProject = `project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+  dev2:
+    image: ubuntu:24.04
+`
+
+func withProjectDeleteFlags(t *testing.T, force, keep bool) {
+	t.Helper()
+	projectDeleteForce, projectDeleteKeep, assumeYes = force, keep, true
+	t.Cleanup(func() { projectDeleteForce, projectDeleteKeep, assumeYes = false, false, false })
+}
+
+func configExists(env *testEnv) bool {
+	_, err := os.Stat(filepath.Join(env.dir, config.ConfigFile))
+	return err == nil
+}
+
+func TestProjectDelete(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(twoContainerProject)
+	env.setContainerExists("test-dev1", true)
+	env.setContainerNotExists("test-dev2")
+	withProjectDeleteFlags(t, false, false)
+
+	if err := runProjectDelete(nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !env.mock.HasCallPrefix("delete", "test-dev1") {
+		t.Error("expected test-dev1 to be deleted")
+	}
+	if env.mock.HasCallPrefix("delete", "test-dev2") {
+		t.Error("expected no delete for a container missing from LXC")
+	}
+	if configExists(env) {
+		t.Error("expected containers.yaml to be removed")
+	}
+}
+
+func TestProjectDelete_KeepContainers(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(twoContainerProject)
+	env.setContainerExists("test-dev1", true)
+	env.setContainerExists("test-dev2", false)
+	withProjectDeleteFlags(t, false, true)
+
+	if err := runProjectDelete(nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if env.mock.HasCallPrefix("delete") {
+		t.Errorf("expected containers to be kept, got calls %v", env.mock.Calls)
+	}
+	if configExists(env) {
+		t.Error("expected containers.yaml to be removed")
+	}
+}
+
+func TestProjectDelete_FailureKeepsConfig(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(twoContainerProject)
+	env.setContainerExists("test-dev1", false)
+	env.setContainerExists("test-dev2", false)
+	env.mock.SetError("delete test-dev1", "device busy")
+	withProjectDeleteFlags(t, false, false)
+
+	err := runProjectDelete(nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "dev1") {
+		t.Fatalf("expected error for dev1, got %v", err)
+	}
+	if env.mock.HasCallPrefix("delete", "test-dev2") {
+		t.Error("expected deletion to stop at the first failure")
+	}
+	if !configExists(env) {
+		t.Error("expected containers.yaml to be kept for a retry")
+	}
+}
+
+func TestProjectDelete_ForceContinuesPastFailures(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(twoContainerProject)
+	env.setContainerExists("test-dev1", false)
+	env.setContainerExists("test-dev2", false)
+	env.mock.SetError("delete test-dev1", "device busy")
+	withProjectDeleteFlags(t, true, false)
+
+	err := runProjectDelete(nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "dev1") {
+		t.Fatalf("expected the failure to be reported, got %v", err)
+	}
+	if !env.mock.HasCallPrefix("delete", "test-dev2") {
+		t.Error("expected test-dev2 to be deleted despite the failure")
+	}
+	if configExists(env) {
+		t.Error("expected containers.yaml to be removed with --force")
+	}
+}
+
+func TestProjectRename(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(twoContainerProject)
+	env.setContainerExists("test-dev1", true)
+	env.setContainerExists("test-dev2", false)
+	env.setContainerNotExists("other-dev1")
+	env.setContainerNotExists("other-dev2")
+
+	if err := runProjectRename(nil, []string{"other"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, name := range []string{"dev1", "dev2"} {
+		if !env.mock.HasCall("move", "test-"+name, "other-"+name) {
+			t.Errorf("expected test-%s to be renamed, got calls %v", name, env.mock.Calls)
+		}
+	}
+	if !env.mock.HasCallPrefix("stop", "test-dev1") || !env.mock.HasCallPrefix("start", "other-dev1") {
+		t.Error("expected running dev1 to be stopped and started again")
+	}
+	if env.mock.HasCallPrefix("start", "other-dev2") {
+		t.Error("expected stopped dev2 to stay stopped")
+	}
+	if !strings.Contains(env.readConfig(), "project: other") {
+		t.Errorf("expected project 'other', got config:\n%s", env.readConfig())
+	}
+}
+
+func TestProjectRename_NameTooLong(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(twoContainerProject)
+
+	err := runProjectRename(nil, []string{strings.Repeat("p", 60)})
+	if err == nil || !strings.Contains(err.Error(), "too long") {
+		t.Fatalf("expected name length error, got %v", err)
+	}
+	if env.mock.HasCallPrefix("move") {
+		t.Error("expected no containers to be renamed")
+	}
+	if !strings.Contains(env.readConfig(), "project: test") {
+		t.Errorf("expected project to stay 'test', got config:\n%s", env.readConfig())
+	}
+}
+
+func TestProjectRename_RollsBackOnFailure(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(twoContainerProject)
+	env.setContainerExists("test-dev1", false)
+	env.setContainerExists("test-dev2", false)
+	env.setContainerNotExists("other-dev1")
+	env.setContainerNotExists("other-dev2")
+	env.mock.SetError("move test-dev2", "busy")
+
+	if err := runProjectRename(nil, []string{"other"}); err == nil {
+		t.Fatal("expected error")
+	}
+	if !env.mock.HasCall("move", "other-dev1", "test-dev1") {
+		t.Errorf("expected dev1 to be renamed back, got calls %v", env.mock.Calls)
+	}
+	if !strings.Contains(env.readConfig(), "project: test") {
+		t.Errorf("expected project to stay 'test', got config:\n%s", env.readConfig())
+	}
+}
+
+func TestProjectRename_RefusesWithVolumes(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(twoContainerProject + `volumes:
+  cache:
+    size: 1GiB
+`)
+
+	err := runProjectRename(nil, []string{"other"})
+	if err == nil || !strings.Contains(err.Error(), "cache") {
+		t.Fatalf("expected volume error, got %v", err)
+	}
+}
+
+func withProjectCreateFlags(t *testing.T, name string, force bool) {
+	t.Helper()
+	projectNameFlag, projectCreateForce = name, force
+	t.Cleanup(func() { projectNameFlag, projectCreateForce = "", false })
+}
+
+func TestProjectCreate_EscapedNaming(t *testing.T) {
+	env := setupTestEnv(t)
+	os.Remove(filepath.Join(env.dir, config.ConfigFile))
+	withProjectCreateFlags(t, "web-app", false)
+
+	if err := runProjectCreate(nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(env.readConfig(), "naming: escaped") {
+		t.Errorf("expected new project to use escaped naming, got:\n%s", env.readConfig())
+	}
+}
+
+func TestProjectCreate_PrefixCollision(t *testing.T) {
+	env := setupTestEnv(t)
+	os.Remove(filepath.Join(env.dir, config.ConfigFile))
+	env.setListAllContainers("web-app-api,RUNNING\nweb--shop-db,STOPPED\nother-dev,RUNNING")
+	withProjectCreateFlags(t, "web", false)
+
+	err := runProjectCreate(nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "web-app-api") {
+		t.Fatalf("expected collision with web-app-api, got %v", err)
+	}
+	if strings.Contains(err.Error(), "web--shop-db") || strings.Contains(err.Error(), "other-dev") {
+		t.Errorf("expected only colliding containers to be listed, got %v", err)
+	}
+	if configExists(env) {
+		t.Error("expected no containers.yaml to be written")
+	}
+
+	withProjectCreateFlags(t, "web", true)
+	if err := runProjectCreate(nil, nil); err != nil {
+		t.Fatalf("expected --force to create the project, got %v", err)
+	}
+}
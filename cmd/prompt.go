@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"lxc-dev-manager/internal/dryrun"
+)
+
+// assumeYesEnv answers every confirmation prompt with yes when set to a
+// true value (1, true, yes), like --yes
+const assumeYesEnv = "LXC_DEV_MANAGER_ASSUME_YES"
+
+var assumeYes bool
+
+// stdinIsTerminal reports whether prompts can be answered interactively
+// (replaceable for testing)
+var stdinIsTerminal = func() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// envAssumesYes reports whether assumeYesEnv is set to a true value
+func envAssumesYes() bool {
+	v := strings.ToLower(strings.TrimSpace(os.Getenv(assumeYesEnv)))
+	if v == "y" || v == "yes" {
+		return true
+	}
+	yes, _ := strconv.ParseBool(v)
+	return yes
+}
+
+// confirmPrompt asks user for yes/no confirmation. With --yes (or
+// LXC_DEV_MANAGER_ASSUME_YES) it answers yes without asking; without a
+// terminal to ask on it fails instead of guessing.
+func confirmPrompt(question string) (bool, error) {
+	if dryrun.Enabled() {
+		// Nothing will be changed, so show what answering yes would do
+		dryrun.Printf("%s yes", question)
+		return true, nil
+	}
+	if assumeYes {
+		fmt.Printf("%s [y/N]: yes (--yes)\n", question)
+		return true, nil
+	}
+	if !stdinIsTerminal() {
+		return false, fmt.Errorf("%s: stdin is not a terminal; pass --yes (or set %s=1) to confirm", strings.TrimSuffix(question, "?"), assumeYesEnv)
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Printf("%s [y/N]: ", question)
+
+	response, err := reader.ReadString('\n')
+	if err != nil {
+		return false, nil
+	}
+
+	response = strings.ToLower(strings.TrimSpace(response))
+	return response == "y" || response == "yes", nil
+}
+
+// confirmOrDecline is confirmPrompt for callbacks that can only answer
+// yes or no: a prompt that can't be asked is reported and declined
+func confirmOrDecline(question string) bool {
+	ok, err := confirmPrompt(question)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	}
+	return ok
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func withStdinTerminal(t *testing.T, terminal bool) {
+	t.Helper()
+	old := stdinIsTerminal
+	stdinIsTerminal = func() bool { return terminal }
+	t.Cleanup(func() { stdinIsTerminal = old })
+}
+
+func TestConfirmPrompt_AssumeYes(t *testing.T) {
+	withStdinTerminal(t, false)
+	assumeYes = true
+	defer func() { assumeYes = false }()
+
+	ok, err := confirmPrompt("Delete it?")
+	if err != nil || !ok {
+		t.Errorf("expected yes without asking, got %v, %v", ok, err)
+	}
+}
+
+func TestConfirmPrompt_NoTerminal(t *testing.T) {
+	withStdinTerminal(t, false)
+
+	ok, err := confirmPrompt("Delete it?")
+	if ok || err == nil {
+		t.Fatalf("expected an error without a terminal, got %v, %v", ok, err)
+	}
+	if !strings.Contains(err.Error(), "--yes") {
+		t.Errorf("expected error to mention --yes, got %v", err)
+	}
+}
+
+func TestEnvAssumesYes(t *testing.T) {
+	for _, v := range []string{"1", "true", "yes", "Y"} {
+		t.Setenv(assumeYesEnv, v)
+		if !envAssumesYes() {
+			t.Errorf("expected %q to assume yes", v)
+		}
+	}
+	for _, v := range []string{"", "0", "false", "no"} {
+		t.Setenv(assumeYesEnv, v)
+		if envAssumesYes() {
+			t.Errorf("expected %q not to assume yes", v)
+		}
+	}
+}
+
+func TestRemove_NoTerminalFailsFast(t *testing.T) {
+	env := setupTestEnv(t)
+	withStdinTerminal(t, false)
+
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+	env.setContainerExists("dev1", true)
+
+	if err := runRemove(nil, []string{"dev1"}); err == nil {
+		t.Fatal("expected an error when confirmation can't be asked")
+	}
+	if env.mock.HasCallPrefix("delete") {
+		t.Error("container must not be deleted without confirmation")
+	}
+}
+
+func TestRemove_AssumeYes(t *testing.T) {
+	env := setupTestEnv(t)
+	withStdinTerminal(t, false)
+	assumeYes = true
+	defer func() { assumeYes = false }()
+
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+	env.setContainerExists("dev1", true)
+
+	if err := runRemove(nil, []string{"dev1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !env.mock.HasCallPrefix("delete") {
+		t.Error("expected container to be deleted with --yes")
+	}
+}
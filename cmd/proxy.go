@@ -1,12 +1,21 @@
 package cmd
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strconv"
 	"syscall"
+	"text/tabwriter"
+	"time"
 
 	"lxc-dev-manager/internal/operations"
+	"lxc-dev-manager/internal/proxy"
+	"lxc-dev-manager/internal/validation"
 
 	"github.com/spf13/cobra"
 )
@@ -24,6 +33,22 @@ Press Ctrl+C to stop the proxy.
 Example:
   lxc-dev-manager proxy dev1
 
+Use --prefer-ipv6 (or defaults.prefer_ipv6 in containers.yaml) to forward
+to the container's IPv6 address instead of IPv4.
+
+Ports are checked before any is forwarded: a port another project's proxy
+or another program already listens on is reported with its owner. With
+--auto-port, it is forwarded from the next free port instead.
+
+With --tls, every port serves HTTPS and forwards plain HTTP to the
+container, so secure cookies and OAuth redirects work locally. Certificates
+for localhost and *.localhost come from a local CA created on first use;
+add its certificate to the browser or system trust store once.
+
+Each connection is logged with --log-level debug (client, bytes, duration,
+or why the container couldn't be reached); 'proxy stats' shows the counters
+of running proxies.
+
 Then access services at:
   http://localhost:5173  ->  container:5173
   http://localhost:8000  ->  container:8000`,
@@ -31,27 +56,78 @@ Then access services at:
 	RunE: runProxy,
 }
 
+var proxyStatsCmd = &cobra.Command{
+	Use:   "stats [container]",
+	Short: "Show connection counters of running proxies",
+	Long: `Show, for each port the project's running proxies forward, the open
+connections, connections accepted since the proxy started, connections the
+container couldn't be reached for, and bytes in each direction.
+
+Counters are published by the proxy process every second. Ports forwarded
+by 'proxy: auto' go through LXD and have no counters.
+
+Examples:
+  lxc-dev-manager proxy stats
+  lxc-dev-manager proxy stats dev1 --json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runProxyStats,
+}
+
+var (
+	proxyPreferIPv6 bool
+	proxyAutoPort   bool
+	proxyTLS        bool
+	proxyStatsJSON  bool
+)
+
 func init() {
 	rootCmd.AddCommand(proxyCmd)
+	proxyCmd.AddCommand(proxyStatsCmd)
+	proxyCmd.Flags().BoolVar(&proxyPreferIPv6, "prefer-ipv6", false, "Forward to the container's IPv6 address when available")
+	proxyCmd.Flags().BoolVar(&proxyAutoPort, "auto-port", false, "Forward ports taken on the host from the next free port")
+	proxyCmd.Flags().BoolVar(&proxyTLS, "tls", false, "Terminate HTTPS with a local CA certificate, forwarding plain HTTP")
+	proxyStatsCmd.Flags().BoolVar(&proxyStatsJSON, "json", false, "Print stats as JSON")
 }
 
 func runProxy(cmd *cobra.Command, args []string) error {
 	name := args[0]
 
-	cfg, _, err := requireRunningContainer(name)
+	cfg, _, err := requireRunningContainer(&name)
 	if err != nil {
 		return err
 	}
 
 	// Use operations package to start proxy
-	manager, ip, ports, err := operations.StartProxy(cfg, name)
+	manager, ip, forwards, err := operations.StartProxy(cfg, name, operations.ProxyOpts{
+		PreferIPv6: proxyPreferIPv6,
+		AutoPort:   proxyAutoPort,
+		TLS:        proxyTLS,
+	})
+	var conflict *proxy.ConflictError
+	if errors.As(err, &conflict) {
+		return fmt.Errorf("%w\nUse --auto-port to forward it from the next free port", err)
+	}
 	if err != nil {
 		return err
 	}
 
 	fmt.Printf("Proxying %s (%s):\n", name, ip)
-	for _, port := range ports {
-		fmt.Printf("  localhost:%d -> %s:%d\n", port, ip, port)
+	for _, f := range forwards {
+		local := fmt.Sprintf("localhost:%d", f.Local)
+		if f.TLS {
+			local = "https://" + local
+		}
+		line := fmt.Sprintf("  %s -> %s", local, net.JoinHostPort(ip, strconv.Itoa(f.Container)))
+		if f.Local != f.Container {
+			line += fmt.Sprintf(" (port %d is taken)", f.Container)
+		}
+		fmt.Println(line)
+	}
+
+	if proxyTLS {
+		if dir, err := proxy.DefaultCADir(); err == nil {
+			fmt.Printf("\nTrust %s to accept the certificates\n", filepath.Join(dir, proxy.CACertFile))
+		}
 	}
 
 	fmt.Println("\nPress Ctrl+C to stop")
@@ -66,3 +142,61 @@ func runProxy(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+func runProxyStats(cmd *cobra.Command, args []string) error {
+	cfg, err := requireProject()
+	if err != nil {
+		return err
+	}
+	name := ""
+	if len(args) == 1 {
+		name = args[0]
+		if err := resolveContainerName(cfg, &name); err != nil {
+			return err
+		}
+	}
+
+	forwards, err := operations.ProxyStats(cfg, name)
+	if err != nil {
+		return err
+	}
+
+	if proxyStatsJSON {
+		entries := make([]proxyStatsEntry, 0, len(forwards))
+		for _, f := range forwards {
+			entries = append(entries, proxyStatsEntry{Container: f.Owner.Container, PID: f.Owner.PID, Stats: f.Stats})
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+
+	if len(forwards) == 0 {
+		fmt.Println("No proxies running")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PORT\tCONTAINER\tREMOTE\tACTIVE\tTOTAL\tFAILED\tIN\tOUT\tLAST")
+	for _, f := range forwards {
+		s := f.Stats
+		last := "-"
+		if !s.LastConnection.IsZero() {
+			last = time.Since(s.LastConnection).Round(time.Second).String() + " ago"
+		}
+		remote := s.Remote
+		if remote == "" {
+			remote = "-"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%d\t%d\t%s\t%s\t%s\n", s.Port, f.Owner.Container, remote,
+			s.Active, s.Total, s.Failed+s.Rejected, validation.FormatSize(s.BytesIn), validation.FormatSize(s.BytesOut), last)
+	}
+	return w.Flush()
+}
+
+// proxyStatsEntry is a port in proxy stats --json
+type proxyStatsEntry struct {
+	Container string `json:"container"`
+	PID       int    `json:"pid"`
+	proxy.Stats
+}
@@ -2,12 +2,16 @@ package cmd
 
 import (
 	"context"
+	"net"
+	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/proxy"
 )
 
 func TestProxy_ContainerNotExists(t *testing.T) {
@@ -212,3 +216,45 @@ containers:
 		t.Errorf("unexpected error: %v", proxyErr)
 	}
 }
+
+func TestProxyStats(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+	old := proxy.RegistryDir
+	proxy.RegistryDir = t.TempDir()
+	t.Cleanup(func() { proxy.RegistryDir = old })
+
+	out := captureStdout(t, func() {
+		if err := runProxyStats(nil, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if !strings.Contains(out, "No proxies running") {
+		t.Errorf("unexpected output: %s", out)
+	}
+
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+	manager := proxy.NewManagerFor(proxy.Owner{Dir: cfg.ProjectDir(), Container: "dev1", PID: os.Getpid()})
+	if err := manager.Add(port, "10.0.0.5", 5173); err != nil {
+		t.Fatal(err)
+	}
+	defer manager.StopAll()
+
+	out = captureStdout(t, func() {
+		if err := runProxyStats(nil, []string{"dev1"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if !strings.Contains(out, strconv.Itoa(port)) || !strings.Contains(out, "10.0.0.5:5173") {
+		t.Errorf("expected the forwarded port in output, got: %s", out)
+	}
+}
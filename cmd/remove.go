@@ -1,10 +1,7 @@
 package cmd
 
 import (
-	"bufio"
 	"fmt"
-	"os"
-	"strings"
 
 	"lxc-dev-manager/internal/lxc"
 	"lxc-dev-manager/internal/operations"
@@ -52,7 +49,7 @@ func runRemove(cmd *cobra.Command, args []string) error {
 	existsInConfig := cfg.HasContainer(name)
 
 	if !existsInLXC && !existsInConfig {
-		return fmt.Errorf("container '%s' not found", name)
+		return cfg.ContainerNotFound(name)
 	}
 
 	// Show what will be deleted
@@ -73,7 +70,11 @@ func runRemove(cmd *cobra.Command, args []string) error {
 
 	// Ask for confirmation unless --force
 	if !removeForce {
-		if !confirmPrompt(fmt.Sprintf("Are you sure you want to delete container '%s'?", name)) {
+		ok, err := confirmPrompt(fmt.Sprintf("Are you sure you want to delete container '%s'?", name))
+		if err != nil {
+			return err
+		}
+		if !ok {
 			fmt.Println("Cancelled")
 			return nil
 		}
@@ -89,18 +90,3 @@ func runRemove(cmd *cobra.Command, args []string) error {
 	fmt.Printf("Container '%s' removed\n", name)
 	return nil
 }
-
-// confirmPrompt asks user for yes/no confirmation
-func confirmPrompt(question string) bool {
-	reader := bufio.NewReader(os.Stdin)
-
-	fmt.Printf("%s [y/N]: ", question)
-
-	response, err := reader.ReadString('\n')
-	if err != nil {
-		return false
-	}
-
-	response = strings.ToLower(strings.TrimSpace(response))
-	return response == "y" || response == "yes"
-}
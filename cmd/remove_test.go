@@ -1,10 +1,13 @@
 package cmd
 
 import (
+	"bytes"
 	"strings"
 	"testing"
 
 	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/dryrun"
+	"lxc-dev-manager/internal/lxc"
 )
 
 // Helper to set force flag for tests
@@ -151,3 +154,36 @@ func TestRemove_BothConfigAndLXC(t *testing.T) {
 		t.Error("dev1 should be removed from config")
 	}
 }
+
+func TestRemove_DryRun(t *testing.T) {
+	env := setupTestEnv(t)
+	withForceFlag(t)
+
+	env.writeConfig(`containers:
+  dev1:
+    image: ubuntu:24.04
+`)
+	env.setContainerExists("dev1", true)
+
+	var out bytes.Buffer
+	dryrun.Enable(&out)
+	defer dryrun.Disable()
+	lxc.EnableDryRun()
+
+	if err := runRemove(nil, []string{"dev1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if env.mock.HasCallPrefix("delete") {
+		t.Error("delete must not run in dry-run mode")
+	}
+	cfg, _ := config.Load("")
+	if !cfg.HasContainer("dev1") {
+		t.Error("dev1 should still be in config")
+	}
+	for _, want := range []string{"[dry-run] lxc delete dev1 --force", "[dry-run] write containers.yaml:", "-   dev1:"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in dry-run output, got %q", want, out.String())
+		}
+	}
+}
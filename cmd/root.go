@@ -1,13 +1,32 @@
 package cmd
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 
+	"lxc-dev-manager/internal/dryrun"
+	"lxc-dev-manager/internal/errcode"
+	"lxc-dev-manager/internal/logging"
+	"lxc-dev-manager/internal/lxc"
+	"lxc-dev-manager/internal/tracing"
+
 	"github.com/spf13/cobra"
 )
 
-var projectDir string
+var (
+	projectDir string
+	logLevel   string
+	logFormat  string
+	dryRun     bool
+	jsonErrors bool
+)
+
+// commandSpan covers the whole command when tracing is enabled
+var commandSpan *tracing.Span
 
 var rootCmd = &cobra.Command{
 	Use:   "lxc-dev-manager",
@@ -16,16 +35,147 @@ var rootCmd = &cobra.Command{
 
 It provides easy container lifecycle management and port proxying to make
 containers feel like local services.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := logging.Setup(os.Stderr, logLevel, logFormat); err != nil {
+			return err
+		}
+		if err := tracing.Setup(); err != nil {
+			return err
+		}
+		if err := startProfiling(); err != nil {
+			return err
+		}
+		commandSpan = tracing.Start(cmd.CommandPath(), "dry_run", dryRun)
+		if envAssumesYes() {
+			assumeYes = true
+		}
+		if dryRun {
+			dryrun.Enable(os.Stdout)
+			lxc.EnableDryRun()
+		}
+		if forceUnlock {
+			if dryRun {
+				dryrun.Printf("remove the project's stale config locks")
+				return nil
+			}
+			return runForceUnlock()
+		}
+		return nil
+	},
 }
 
 func init() {
 	rootCmd.PersistentFlags().StringVarP(&projectDir, "project-dir", "C", "",
 		"path to project directory (default: current directory)")
+	rootCmd.PersistentFlags().BoolVar(&forceUnlock, "force-unlock", false,
+		"remove the project's stale config locks before running (see 'lock status')")
+	rootCmd.PersistentFlags().BoolVarP(&assumeYes, "yes", "y", false,
+		"answer yes to every confirmation prompt (or set "+assumeYesEnv+"=1)")
+	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false,
+		"print the lxc commands and file changes a command would make without making them")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", logging.DefaultLevel,
+		"log verbosity: debug, info, warn or error (debug shows every lxc command)")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", logging.FormatText,
+		"log output format: text or json")
+	rootCmd.PersistentFlags().BoolVar(&jsonErrors, "json-errors", false,
+		"report a failure as a JSON object {code, message, container} on stderr")
+
+	// Execute reports errors itself, in the format asked for
+	rootCmd.SilenceErrors = true
+	// Runs once flags are parsed, before argument checks can fail
+	cobra.OnInitialize(func() {
+		if jsonErrors {
+			rootCmd.SilenceUsage = true
+		}
+	})
 }
 
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+	// Each run is a single short-lived command, so repeated existence and
+	// status checks can share results
+	lxc.EnableCache(true)
+
+	c, err := rootCmd.ExecuteC()
+	if err := stopProfiling(); err != nil {
+		slog.Warn("failed to write profile", "error", err)
+	}
+	commandSpan.End(err)
+	if err := tracing.Flush(); err != nil {
+		slog.Warn("failed to send traces", "error", err)
+	}
+	if dryrun.Enabled() {
+		fmt.Println("\nDry run: nothing was changed")
+	} else {
+		recordAudit(c, os.Args[1:], err)
+	}
+	var status exitStatus
+	if errors.As(err, &status) {
+		os.Exit(int(status))
+	}
+	if err != nil {
+		if !c.Flags().Parsed() {
+			// Unknown commands fail before any flags are parsed, so read
+			// the output options directly
+			parseGlobalFlags(os.Args[1:])
+		}
+		code := errorCode(err)
+		switch {
+		case jsonErrors:
+			writeJSONError(os.Stderr, code, err)
+		case logFormat == logging.FormatJSON:
+			// Keep stderr machine-readable
+			slog.Error("command failed", "error", err.Error(), "code", string(code))
+		default:
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			if code == errcode.Usage {
+				fmt.Fprintf(os.Stderr, "Run '%s --help' for usage.\n", c.CommandPath())
+			}
+		}
+		os.Exit(code.ExitCode())
+	}
+}
+
+// exitStatus is returned by commands that report their result only
+// through the exit status, like state. Execute exits with it silently.
+type exitStatus int
+
+func (e exitStatus) Error() string {
+	return fmt.Sprintf("exit status %d", int(e))
+}
+
+// parseGlobalFlags sets the root's persistent flags from args, ignoring
+// anything else
+func parseGlobalFlags(args []string) {
+	flags := rootCmd.PersistentFlags()
+	flags.ParseErrorsAllowlist.UnknownFlags = true
+	flags.SetOutput(io.Discard)
+	flags.Parse(args)
+}
+
+// errorCode classifies a command's error, treating cobra's own argument
+// and flag errors as usage errors
+func errorCode(err error) errcode.Code {
+	if code := errcode.Of(err); code != errcode.General {
+		return code
 	}
+	if errcode.IsUsage(err) {
+		return errcode.Usage
+	}
+	return errcode.General
+}
+
+// jsonError is what --json-errors prints for a failed command
+type jsonError struct {
+	Code      errcode.Code `json:"code"`
+	Message   string       `json:"message"`
+	Container string       `json:"container"`
+}
+
+func writeJSONError(w io.Writer, code errcode.Code, err error) {
+	data, _ := json.Marshal(jsonError{
+		Code:      code,
+		Message:   err.Error(),
+		Container: errcode.ContainerOf(err),
+	})
+	fmt.Fprintln(w, string(data))
 }
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"lxc-dev-manager/internal/errcode"
+)
+
+func TestErrorCode(t *testing.T) {
+	tests := []struct {
+		name      string
+		setup     func(env *testEnv)
+		args      []string
+		want      errcode.Code
+		container string
+	}{
+		{
+			name:  "no project",
+			setup: func(env *testEnv) {},
+			args:  []string{"dev1", "whoami"},
+			want:  errcode.NotFound,
+		},
+		{
+			name: "not in config",
+			setup: func(env *testEnv) {
+				env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+			},
+			args:      []string{"web", "whoami"},
+			want:      errcode.NotFound,
+			container: "web",
+		},
+		{
+			name: "not in LXC",
+			setup: func(env *testEnv) {
+				env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+				env.setContainerNotExists("dev1")
+			},
+			args:      []string{"dev1", "whoami"},
+			want:      errcode.NotFound,
+			container: "dev1",
+		},
+		{
+			name: "not running",
+			setup: func(env *testEnv) {
+				env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+				env.setContainerExists("dev1", false)
+			},
+			args:      []string{"dev1", "whoami"},
+			want:      errcode.NotRunning,
+			container: "dev1",
+		},
+		{
+			name: "lxc failure",
+			setup: func(env *testEnv) {
+				env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+				env.mock.SetOutput("info dev1", "Name: dev1")
+				env.mock.SetError("list dev1 -cs -f csv", "permission denied")
+			},
+			args: []string{"dev1", "whoami"},
+			want: errcode.LXC,
+		},
+		{
+			name: "invalid config",
+			setup: func(env *testEnv) {
+				env.writeConfig("project: test\ncontainers:\n  dev1:\n    image: ubuntu:24.04\n    ports: [70000]\n")
+			},
+			args: []string{"dev1", "whoami"},
+			want: errcode.Validation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := setupTestEnv(t)
+			tt.setup(env)
+
+			err := runExec(nil, tt.args)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if got := errorCode(err); got != tt.want {
+				t.Errorf("errorCode() = %s, want %s (error: %v)", got, tt.want, err)
+			}
+			if got := errcode.ContainerOf(err); got != tt.container {
+				t.Errorf("container = %q, want %q", got, tt.container)
+			}
+		})
+	}
+}
+
+func TestErrorCode_Usage(t *testing.T) {
+	if got := errorCode(errors.New("accepts 1 arg(s), received 0")); got != errcode.Usage {
+		t.Errorf("errorCode() = %s, want %s", got, errcode.Usage)
+	}
+	if got := errorCode(errors.New("something broke")); got != errcode.General {
+		t.Errorf("errorCode() = %s, want %s", got, errcode.General)
+	}
+}
+
+func TestWriteJSONError(t *testing.T) {
+	err := errcode.Errorf(errcode.NotRunning, "dev1", "container '%s' is not running", "dev1")
+
+	var buf bytes.Buffer
+	writeJSONError(&buf, errorCode(err), err)
+
+	var got map[string]string
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON %q: %v", buf.String(), err)
+	}
+	want := map[string]string{
+		"code":      "not_running",
+		"message":   "container 'dev1' is not running",
+		"container": "dev1",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"lxc-dev-manager/internal/dryrun"
+	"lxc-dev-manager/internal/lxc"
+	"lxc-dev-manager/internal/web"
+
+	"github.com/spf13/cobra"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve a web dashboard and JSON API for the project",
+	Long: `Serve a web dashboard showing the project's containers, snapshots,
+mounts and logs, with buttons to start, stop and reset containers. The
+same data is available as a JSON API under /api.
+
+The server only listens on localhost by default. Anyone who can reach it
+can start, stop and reset containers, so only use --listen with another
+address on a trusted network (or behind an authenticating proxy).
+On loopback it only answers requests for localhost, 127.0.0.1, [::1] or the
+--listen address, so web pages can't reach it through DNS rebinding.
+
+Press Ctrl+C to stop the server.
+
+Examples:
+  lxc-dev-manager serve
+  lxc-dev-manager serve --listen 0.0.0.0:8420`,
+	Args: cobra.NoArgs,
+	RunE: runServe,
+}
+
+var serveListen string
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+	serveCmd.Flags().StringVar(&serveListen, "listen", "127.0.0.1:8420", "Address to listen on")
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	if dryrun.Enabled() {
+		return fmt.Errorf("serve does not support --dry-run")
+	}
+	if _, err := requireProject(); err != nil {
+		return err
+	}
+
+	// The server is long-lived and state changes outside it, so results
+	// must not be cached
+	lxc.EnableCache(false)
+
+	listener, err := net.Listen("tcp", serveListen)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", serveListen, err)
+	}
+	dashboard := web.NewServer(projectDir)
+	if isLoopback(listener.Addr()) {
+		// Only answer to local names, against DNS rebinding
+		host, _, _ := net.SplitHostPort(serveListen)
+		dashboard.AllowHosts("localhost", "127.0.0.1", "::1", host)
+	} else {
+		fmt.Fprintf(os.Stderr, "Warning: serving on %s; anyone who can reach it can start, stop and reset containers\n", listener.Addr())
+	}
+
+	server := &http.Server{
+		Handler:           dashboard.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	errChan := make(chan error, 1)
+	go func() { errChan <- server.Serve(listener) }()
+
+	fmt.Printf("Dashboard at http://%s\n", listener.Addr())
+	fmt.Println("\nPress Ctrl+C to stop")
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case err := <-errChan:
+		return err
+	case <-sigChan:
+	}
+
+	fmt.Println("\nStopping server...")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// isLoopback reports whether addr only accepts local connections
+func isLoopback(addr net.Addr) bool {
+	tcp, ok := addr.(*net.TCPAddr)
+	return ok && tcp.IP.IsLoopback()
+}
@@ -33,7 +33,7 @@ func init() {
 func runSSH(cmd *cobra.Command, args []string) error {
 	name := args[0]
 
-	cfg, _, err := requireRunningContainer(name)
+	cfg, _, err := requireRunningContainer(&name)
 	if err != nil {
 		return err
 	}
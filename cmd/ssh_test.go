@@ -4,6 +4,7 @@ import (
 	"strings"
 	"testing"
 
+	"lxc-dev-manager/internal/lxc"
 	"lxc-dev-manager/internal/operations"
 )
 
@@ -99,7 +100,7 @@ func TestSSH_ContainerWithDifferentStatuses(t *testing.T) {
 func TestBuildShellArgs_WithUser(t *testing.T) {
 	// When user is specified, should use "su -l <user>" to get proper login shell
 	// This ensures PAM is triggered and supplementary groups (like docker) are loaded
-	args := operations.BuildShellArgs("mycontainer", "dev")
+	args := operations.BuildShellArgs("mycontainer", "dev", lxc.UserSwitch{})
 
 	expected := []string{"exec", "mycontainer", "--", "su", "-l", "dev"}
 	if len(args) != len(expected) {
@@ -114,7 +115,7 @@ func TestBuildShellArgs_WithUser(t *testing.T) {
 
 func TestBuildShellArgs_WithoutUser(t *testing.T) {
 	// When no user specified, should use root bash shell
-	args := operations.BuildShellArgs("mycontainer", "")
+	args := operations.BuildShellArgs("mycontainer", "", lxc.UserSwitch{})
 
 	expected := []string{"exec", "mycontainer", "--", "bash", "-l"}
 	if len(args) != len(expected) {
@@ -144,7 +145,7 @@ func TestBuildShellArgs_DifferentUsers(t *testing.T) {
 			name = "no-user"
 		}
 		t.Run(name, func(t *testing.T) {
-			args := operations.BuildShellArgs("test-container", tt.user)
+			args := operations.BuildShellArgs("test-container", tt.user, lxc.UserSwitch{})
 			if len(args) != len(tt.expected) {
 				t.Fatalf("expected %d args, got %d: %v", len(tt.expected), len(args), args)
 			}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/errcode"
+	"lxc-dev-manager/internal/lxc"
+	"lxc-dev-manager/internal/operations"
+
+	"github.com/spf13/cobra"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status <name>",
+	Short: "Show a container's status",
+	Long: `Print a container's status (RUNNING, STOPPED, ...) and IP address.
+
+With --watch, keep checking until interrupted and print a timestamped line
+each time the status changes.
+
+Examples:
+  lxc-dev-manager status dev1
+  lxc-dev-manager status dev1 --watch`,
+	Args: cobra.ExactArgs(1),
+	RunE: runStatus,
+}
+
+var stateCmd = &cobra.Command{
+	Use:   "state <name>",
+	Short: "Exit 0 if a container is running, for scripts",
+	Long: `Print nothing and exit 0 when the container is in the expected state
+(RUNNING unless --is says otherwise), or exit 1 when it isn't. A container
+that doesn't exist fails with the usual not-found error and exit status.
+
+Examples:
+  until lxc-dev-manager state dev1; do sleep 1; done
+  lxc-dev-manager state dev1 --is STOPPED && echo "dev1 is down"`,
+	Args: cobra.ExactArgs(1),
+	RunE: runState,
+}
+
+var statusWatch bool
+var statusInterval time.Duration
+var stateIs string
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(stateCmd)
+	statusCmd.Flags().BoolVarP(&statusWatch, "watch", "w", false, "Keep checking and print each status change until interrupted")
+	statusCmd.Flags().DurationVar(&statusInterval, "interval", 2*time.Second, "How often to check with --watch")
+	stateCmd.Flags().StringVar(&stateIs, "is", "RUNNING", "State to test for, e.g. STOPPED or FROZEN")
+}
+
+func runStatus(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	cfg, _, err := requireContainer(&name)
+	if err != nil {
+		return err
+	}
+
+	if !statusWatch {
+		status, err := operations.Status(cfg, name)
+		if err != nil {
+			return err
+		}
+		fmt.Println(statusLine(cfg, name, status))
+		return nil
+	}
+
+	if statusInterval <= 0 {
+		return errcode.Errorf(errcode.Usage, name, "--interval must be positive")
+	}
+
+	// Status changes outside this process, so results must not be cached
+	lxc.EnableCache(false)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	return watchStatus(ctx, cfg, name, statusInterval, os.Stdout)
+}
+
+// watchStatus prints a container's status, then a line each time it
+// changes, until ctx is done. A container deleted meanwhile is reported
+// as NOT FOUND rather than ending the watch.
+func watchStatus(ctx context.Context, cfg *config.Config, name string, interval time.Duration, w io.Writer) error {
+	last := ""
+	for {
+		status, err := operations.Status(cfg, name)
+		if errcode.Of(err) == errcode.NotFound {
+			status, err = "NOT FOUND", nil
+		}
+		if err != nil {
+			return err
+		}
+		if status != last {
+			fmt.Fprintf(w, "%s  %s\n", time.Now().Format("15:04:05"), statusLine(cfg, name, status))
+			last = status
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// statusLine formats a container's status, with its IP when running
+func statusLine(cfg *config.Config, name, status string) string {
+	line := fmt.Sprintf("%s: %s", name, status)
+	if status == "RUNNING" {
+		if ip, err := lxc.GetIP(cfg.GetLXCName(name)); err == nil {
+			line += "  " + ip
+		}
+	}
+	return line
+}
+
+func runState(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	cfg, _, err := requireContainer(&name)
+	if err != nil {
+		return err
+	}
+
+	status, err := operations.Status(cfg, name)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(status, stateIs) {
+		return exitStatus(1)
+	}
+	return nil
+}
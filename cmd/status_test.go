@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatus(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+	env.setContainerExists("dev1", true)
+	env.mock.SetOutput("list dev1 -c4 -f csv", "10.10.10.100 (eth0)")
+
+	out := captureStdout(t, func() {
+		if err := runStatus(nil, []string{"dev1"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if strings.TrimSpace(out) != "dev1: RUNNING  10.10.10.100" {
+		t.Errorf("unexpected output: %q", out)
+	}
+}
+
+func TestWatchStatus_PrintsChanges(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+	env.setContainerExists("dev1", false)
+
+	cfg, err := requireProject()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checks := 0
+	env.mock.SetCallback("list dev1 -cs", func(args []string) {
+		checks++
+		if checks == 3 {
+			env.mock.SetOutput("list dev1 -cs -f csv", "RUNNING")
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var buf bytes.Buffer
+	if err := watchStatus(ctx, cfg, "dev1", time.Millisecond, &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[0], "dev1: STOPPED") || !strings.Contains(lines[1], "dev1: RUNNING") {
+		t.Errorf("expected one line per status change, got:\n%s", buf.String())
+	}
+}
+
+func TestState(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfigWithContainer("dev1", "ubuntu:24.04")
+	env.setContainerExists("dev1", true)
+
+	out := captureStdout(t, func() {
+		if err := runState(nil, []string{"dev1"}); err != nil {
+			t.Errorf("expected success for a running container, got %v", err)
+		}
+	})
+	if out != "" {
+		t.Errorf("state should print nothing, got %q", out)
+	}
+
+	stateIs = "stopped"
+	defer func() { stateIs = "RUNNING" }()
+	var status exitStatus
+	if err := runState(nil, []string{"dev1"}); !errors.As(err, &status) || status != 1 {
+		t.Errorf("expected exit status 1, got %v", err)
+	}
+}
@@ -32,8 +32,8 @@ var syncAddCmd = &cobra.Command{
 	Short: "Add a file sync entry",
 	Long: `Add a file to sync from host to container.
 
-Source is relative to the containers.yaml directory.
-Dest is the absolute path inside the container.
+Source is relative to the containers.yaml directory; a source outside it
+is accepted with a warning. Dest is the absolute path inside the container.
 
 Examples:
   lxc-dev-manager sync add dev1 .env /home/dev/project/.env
@@ -71,7 +71,7 @@ func init() {
 func runSync(cmd *cobra.Command, args []string) error {
 	containerName := args[0]
 
-	cfg, _, err := requireContainer(containerName)
+	cfg, _, err := requireContainer(&containerName)
 	if err != nil {
 		return err
 	}
@@ -102,19 +102,17 @@ func runSyncAdd(cmd *cobra.Command, args []string) error {
 	source := args[1]
 	dest := args[2]
 
-	cfg, _, lock, err := requireContainerWithLock(containerName)
+	cfg, _, lock, err := requireContainerWithLock(&containerName)
 	if err != nil {
 		return err
 	}
 	defer func() { _ = lock.Release() }()
 
-	cfg.AddSyncEntry(containerName, config.SyncEntry{
+	if err := operations.AddSyncEntry(cfg, containerName, config.SyncEntry{
 		Source: source,
 		Dest:   dest,
-	})
-
-	if err := cfg.Save(); err != nil {
-		return fmt.Errorf("failed to save config: %w", err)
+	}); err != nil {
+		return err
 	}
 
 	fmt.Printf("Added sync: %s -> %s\n", source, dest)
@@ -125,7 +123,7 @@ func runSyncRm(cmd *cobra.Command, args []string) error {
 	containerName := args[0]
 	source := args[1]
 
-	cfg, _, lock, err := requireContainerWithLock(containerName)
+	cfg, _, lock, err := requireContainerWithLock(&containerName)
 	if err != nil {
 		return err
 	}
@@ -144,7 +142,7 @@ func runSyncRm(cmd *cobra.Command, args []string) error {
 func runSyncList(cmd *cobra.Command, args []string) error {
 	containerName := args[0]
 
-	cfg, _, err := requireContainer(containerName)
+	cfg, _, err := requireContainer(&containerName)
 	if err != nil {
 		return err
 	}
@@ -2,7 +2,11 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
+	"net"
 	"os"
+	"strconv"
+	"strings"
 	"testing"
 
 	"lxc-dev-manager/internal/lxc"
@@ -31,6 +35,9 @@ func setupTestEnv(t *testing.T) *testEnv {
 		t.Fatal(err)
 	}
 
+	// Keep the developer's own settings (e.g. security paths) out of tests
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
 	mock := lxc.NewMockExecutor()
 	lxc.SetExecutor(mock)
 
@@ -105,9 +112,48 @@ func (e *testEnv) setLaunchSuccess() {
 	e.mock.SetOutput("exec", "status: done")
 }
 
-// setListAllContainers sets the output for ListAll
-func (e *testEnv) setListAllContainers(csv string) {
-	e.mock.SetOutput("list -c ns4 -f csv", csv)
+// setListAllContainers sets the output for ListAll from CSV-style rows of
+// name,STATUS,ipv4,ipv6,snapshots,disk-bytes (trailing columns optional)
+func (e *testEnv) setListAllContainers(rows string) {
+	type address struct {
+		Family  string `json:"family"`
+		Address string `json:"address"`
+		Scope   string `json:"scope"`
+	}
+	instances := []map[string]any{}
+	for _, line := range strings.Split(rows, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		cols := append(strings.Split(line, ","), "", "", "", "", "")
+		var addrs []address
+		if ip := strings.TrimSuffix(cols[2], " (eth0)"); ip != "" {
+			addrs = append(addrs, address{"inet", ip, "global"})
+		}
+		if ip := strings.TrimSuffix(cols[3], " (eth0)"); ip != "" {
+			addrs = append(addrs, address{"inet6", ip, "global"})
+		}
+		snapshots := make([]map[string]string, 0)
+		n, _ := strconv.Atoi(cols[4])
+		for i := 0; i < n; i++ {
+			snapshots = append(snapshots, map[string]string{"name": "snap" + strconv.Itoa(i)})
+		}
+		usage, _ := strconv.ParseInt(cols[5], 10, 64)
+		instances = append(instances, map[string]any{
+			"name":      cols[0],
+			"status":    cols[1],
+			"snapshots": snapshots,
+			"state": map[string]any{
+				"network": map[string]any{"eth0": map[string]any{"addresses": addrs}},
+				"disk":    map[string]any{"root": map[string]int64{"usage": usage}},
+			},
+		})
+	}
+	out, err := json.Marshal(instances)
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	e.mock.SetOutput("list --format json", string(out))
 }
 
 // writeMinimalConfig writes a minimal config with empty project
@@ -128,3 +174,37 @@ containers:
     image: ` + image + `
 `)
 }
+
+// captureStdout returns what fn prints to stdout
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = old }()
+
+	done := make(chan string)
+	go func() {
+		var buf bytes.Buffer
+		buf.ReadFrom(r)
+		done <- buf.String()
+	}()
+
+	fn()
+	w.Close()
+	return <-done
+}
+
+// freePort returns a TCP port nothing listens on
+func freePort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port
+}
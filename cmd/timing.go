@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Hidden root flags for profiling the tool itself
+var (
+	cpuProfile string
+	memProfile string
+)
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&cpuProfile, "cpuprofile", "", "write a CPU profile to this file")
+	rootCmd.PersistentFlags().StringVar(&memProfile, "memprofile", "", "write a heap profile to this file when the command ends")
+	rootCmd.PersistentFlags().MarkHidden("cpuprofile")
+	rootCmd.PersistentFlags().MarkHidden("memprofile")
+}
+
+// startProfiling starts the CPU profile asked for with --cpuprofile
+func startProfiling() error {
+	if cpuProfile == "" {
+		return nil
+	}
+	f, err := os.Create(cpuProfile)
+	if err != nil {
+		return fmt.Errorf("failed to create CPU profile: %w", err)
+	}
+	if err := pprof.StartCPUProfile(f); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to start CPU profile: %w", err)
+	}
+	return nil
+}
+
+// stopProfiling finishes the CPU profile and writes the heap profile
+func stopProfiling() error {
+	if cpuProfile != "" {
+		pprof.StopCPUProfile()
+	}
+	if memProfile == "" {
+		return nil
+	}
+	f, err := os.Create(memProfile)
+	if err != nil {
+		return fmt.Errorf("failed to create heap profile: %w", err)
+	}
+	defer f.Close()
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		return fmt.Errorf("failed to write heap profile: %w", err)
+	}
+	return nil
+}
+
+// phaseTimer measures how long each progress step of an operation takes.
+// A step lasts until the container's next step starts.
+type phaseTimer struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	start   time.Time
+	phases  []phase
+	running map[string]int // Container -> index of its current phase
+}
+
+type phase struct {
+	name  string
+	step  string
+	start time.Time
+	took  time.Duration
+}
+
+func newPhaseTimer() *phaseTimer {
+	t := &phaseTimer{now: time.Now, running: make(map[string]int)}
+	t.start = t.now()
+	return t
+}
+
+// step ends name's current phase and starts the next one. The "done" and
+// "failed" steps parallel operations report at the end only end it.
+func (t *phaseTimer) step(name, step string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	t.end(name, now)
+	if step == "done" || step == "failed" {
+		return
+	}
+	t.running[name] = len(t.phases)
+	t.phases = append(t.phases, phase{name: name, step: step, start: now})
+}
+
+// end closes name's current phase. Must be called with t.mu held.
+func (t *phaseTimer) end(name string, now time.Time) {
+	if i, ok := t.running[name]; ok {
+		t.phases[i].took = now.Sub(t.phases[i].start)
+		delete(t.running, name)
+	}
+}
+
+// progress returns a progress callback that times each step, then passes
+// it on to next when set
+func (t *phaseTimer) progress(next func(name, step string)) func(name, step string) {
+	return func(name, step string) {
+		t.step(name, step)
+		if next != nil {
+			next(name, step)
+		}
+	}
+}
+
+// print ends the phases still running and writes one line per phase,
+// with the container name when several were timed, and the total
+func (t *phaseTimer) print(w io.Writer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	for name := range t.running {
+		t.end(name, now)
+	}
+	names := make(map[string]bool)
+	for _, p := range t.phases {
+		names[p.name] = true
+	}
+
+	fmt.Fprintln(w, "\nTiming:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, p := range t.phases {
+		label := p.step
+		if len(names) > 1 {
+			label = fmt.Sprintf("[%s] %s", p.name, p.step)
+		}
+		fmt.Fprintf(tw, "  %s\t%7s\n", label, formatPhase(p.took))
+	}
+	fmt.Fprintf(tw, "  total\t%7s\n", formatPhase(now.Sub(t.start)))
+	tw.Flush()
+}
+
+// formatPhase rounds a duration for display, to a tenth of a second
+func formatPhase(d time.Duration) string {
+	return fmt.Sprintf("%.1fs", d.Seconds())
+}
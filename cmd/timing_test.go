@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPhaseTimer(t *testing.T) {
+	clock := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	timer := newPhaseTimer()
+	timer.now = func() time.Time { return clock }
+	timer.start = clock
+
+	var passed []string
+	progress := timer.progress(func(name, step string) { passed = append(passed, step) })
+
+	progress("dev1", "launching")
+	clock = clock.Add(4200 * time.Millisecond)
+	progress("dev1", "waiting for boot")
+	clock = clock.Add(12 * time.Second)
+	progress("dev1", "done")
+	clock = clock.Add(time.Second)
+
+	var buf bytes.Buffer
+	timer.print(&buf)
+	out := buf.String()
+
+	for _, want := range []string{"launching", "4.2s", "waiting for boot", "12.0s", "total", "17.2s"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "done") || strings.Contains(out, "[dev1]") {
+		t.Errorf("expected no done phase or name prefix for a single container:\n%s", out)
+	}
+	if len(passed) != 3 {
+		t.Errorf("expected every step to be passed on, got %v", passed)
+	}
+}
+
+func TestPhaseTimer_Several(t *testing.T) {
+	timer := newPhaseTimer()
+	timer.step("dev1", "launching")
+	timer.step("dev2", "launching")
+
+	var buf bytes.Buffer
+	timer.print(&buf)
+	if !strings.Contains(buf.String(), "[dev1] launching") || !strings.Contains(buf.String(), "[dev2] launching") {
+		t.Errorf("expected phases prefixed with their container:\n%s", buf.String())
+	}
+}
+
+func TestContainerCreate_Timing(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: ""
+containers: {}
+`)
+	env.setContainerNotExists("dev1")
+	env.setLaunchSuccess()
+	env.mock.SetCallback("launch", func(args []string) {
+		env.setContainerExists("dev1", true)
+	})
+
+	createTiming = true
+	t.Cleanup(func() { createTiming = false })
+
+	out := captureStdout(t, func() {
+		if err := runContainerCreate(nil, []string{"dev1", "ubuntu:24.04"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	for _, step := range []string{"Timing:", "launching", "waiting for boot", "setting up user", "enabling SSH", "creating snapshot", "total"} {
+		if !strings.Contains(out, step) {
+			t.Errorf("expected %q in timing output:\n%s", step, out)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+
+	"lxc-dev-manager/internal/dryrun"
+	"lxc-dev-manager/internal/lxc"
+	"lxc-dev-manager/internal/operations"
+	"lxc-dev-manager/internal/tui"
+
+	"github.com/spf13/cobra"
+)
+
+var uiCmd = &cobra.Command{
+	Use:   "ui",
+	Short: "Interactive dashboard for the project's containers",
+	Long: `Open a terminal dashboard listing the project's containers with live
+status, IPs, ports, snapshots and mounts.
+
+Keys act on the selected container:
+  ↑/↓, k/j   select a container
+  u          start it (like 'up')
+  d          stop it (like 'down')
+  s, enter   open a shell (like 'ssh'); exit the shell to return
+  p          take a snapshot, prompting for its name
+  m          show its mounts
+  r          refresh now (the list also refreshes every few seconds)
+  q          quit
+
+Example:
+  lxc-dev-manager ui`,
+	Args: cobra.NoArgs,
+	RunE: runUI,
+}
+
+func init() {
+	rootCmd.AddCommand(uiCmd)
+}
+
+func runUI(cmd *cobra.Command, args []string) error {
+	if dryrun.Enabled() {
+		return fmt.Errorf("ui does not support --dry-run")
+	}
+	if _, err := requireProject(); err != nil {
+		return err
+	}
+
+	// The dashboard runs for a long time and state changes outside it,
+	// so results must not be cached
+	lxc.EnableCache(false)
+
+	// Anything printed while the dashboard is drawn would garble it
+	hookOutput, logger := operations.HookOutput, slog.Default()
+	operations.HookOutput = io.Discard
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	defer func() {
+		operations.HookOutput = hookOutput
+		slog.SetDefault(logger)
+	}()
+
+	return tui.Run(projectDir)
+}
@@ -2,6 +2,8 @@ package cmd
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"lxc-dev-manager/internal/operations"
 
@@ -9,19 +11,24 @@ import (
 )
 
 var unmountForce bool
+var unmountAll bool
 
 var unmountCmd = &cobra.Command{
-	Use:   "unmount <container> <name-or-path>",
+	Use:   "unmount <container> <name-or-path> | --all <source>",
 	Short: "Unmount a disk from a container",
 	Long: `Unmount a disk device from a container.
 
 The device can be specified by its name or by its container path.
 
+With --all, unmount a host directory from every container it is mounted
+in, e.g. before moving or deleting it. The directory doesn't need to exist.
+
 Examples:
   lxc-dev-manager unmount dev1 repo
   lxc-dev-manager unmount dev1 /repo.git
-  lxc-dev-manager unmount dev1 /workspace --force`,
-	Args: cobra.ExactArgs(2),
+  lxc-dev-manager unmount dev1 /workspace --force
+  lxc-dev-manager unmount --all ~/old-project`,
+	Args: cobra.RangeArgs(1, 2),
 	RunE: runUnmount,
 }
 
@@ -29,14 +36,25 @@ func init() {
 	rootCmd.AddCommand(unmountCmd)
 
 	unmountCmd.Flags().BoolVarP(&unmountForce, "force", "f", false, "Force unmount (no confirmation)")
+	unmountCmd.Flags().BoolVarP(&unmountAll, "all", "a", false, "Unmount a host source path from all containers")
 }
 
 func runUnmount(cmd *cobra.Command, args []string) error {
+	if unmountAll {
+		if len(args) != 1 {
+			return fmt.Errorf("--all takes exactly one host source path")
+		}
+		return runUnmountAll(args[0])
+	}
+	if len(args) != 2 {
+		return fmt.Errorf("requires a container and a mount name or path")
+	}
+
 	containerName := args[0]
 	nameOrPath := args[1]
 
 	// Load config with lock to prevent race conditions
-	cfg, _, lock, err := requireContainerWithLock(containerName)
+	cfg, _, lock, err := requireContainerWithLock(&containerName)
 	if err != nil {
 		return err
 	}
@@ -52,3 +70,43 @@ func runUnmount(cmd *cobra.Command, args []string) error {
 	fmt.Printf("Device unmounted successfully.\n")
 	return nil
 }
+
+func runUnmountAll(source string) error {
+	cfg, lock, err := requireProjectWithLock()
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	matches, err := operations.FindMountsBySource(cfg, source)
+	if err != nil {
+		return err
+	}
+	if len(matches) == 0 {
+		return fmt.Errorf("'%s' is not mounted in any container", source)
+	}
+
+	containers := make([]string, 0, len(matches))
+	for name, device := range matches {
+		containers = append(containers, fmt.Sprintf("%s (%s)", name, device))
+	}
+	sort.Strings(containers)
+	fmt.Printf("'%s' is mounted in: %s\n", source, strings.Join(containers, ", "))
+
+	if !unmountForce {
+		ok, err := confirmPrompt("Unmount from all of them?")
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("Cancelled")
+			return nil
+		}
+	}
+
+	done, err := operations.UnmountSource(cfg, source)
+	for _, name := range done {
+		fmt.Printf("Unmounted from '%s'\n", name)
+	}
+	return err
+}
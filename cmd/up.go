@@ -1,9 +1,12 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"time"
 
+	"lxc-dev-manager/internal/config"
 	"lxc-dev-manager/internal/lxc"
 	"lxc-dev-manager/internal/operations"
 
@@ -15,24 +18,38 @@ var upCmd = &cobra.Command{
 	Short: "Start a container",
 	Long: `Start a stopped container.
 
+Containers listed in its depends_on are started first, along with their
+own dependencies.
+
+If the container has ready conditions (ready: in containers.yaml, e.g.
+systemd or port:5432), up waits until they hold, so a dependency is
+serving before the containers that need it start.
+
 Example:
   lxc-dev-manager up dev1`,
 	Args: cobra.ExactArgs(1),
 	RunE: runUp,
 }
 
+var upTimeout time.Duration
+
 func init() {
 	rootCmd.AddCommand(upCmd)
+	upCmd.Flags().DurationVar(&upTimeout, "timeout", 2*time.Minute, "How long to wait for ready conditions")
 }
 
 func runUp(cmd *cobra.Command, args []string) error {
 	name := args[0]
 
-	cfg, lxcName, err := requireContainer(name)
+	cfg, lxcName, err := requireContainer(&name)
 	if err != nil {
 		return err
 	}
 
+	if err := startDependencies(cfg, name); err != nil {
+		return err
+	}
+
 	// Check current status for user feedback
 	status, err := lxc.GetStatus(lxcName)
 	if err != nil {
@@ -45,6 +62,13 @@ func runUp(cmd *cobra.Command, args []string) error {
 		if ip != "" {
 			fmt.Printf("  IP: %s\n", ip)
 		}
+		// Forward ports added to the config since it started
+		if cfg.AutoProxy(name) {
+			if _, err := operations.StartPortForwards(cfg, name); err != nil {
+				return err
+			}
+			printPortForwards(cfg, name)
+		}
 		return nil
 	}
 
@@ -55,8 +79,14 @@ func runUp(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	// Wait a moment for network
-	time.Sleep(2 * time.Second)
+	if len(cfg.GetReady(name)) > 0 {
+		if err := waitReady(cfg, name); err != nil {
+			return err
+		}
+	} else {
+		// Wait a moment for network
+		time.Sleep(2 * time.Second)
+	}
 
 	// Get IP for display
 	ip, err := lxc.GetIP(lxcName)
@@ -66,6 +96,58 @@ func runUp(cmd *cobra.Command, args []string) error {
 
 	fmt.Printf("Container '%s' started\n", name)
 	fmt.Printf("  IP: %s\n", ip)
+	if cfg.AutoProxy(name) {
+		printPortForwards(cfg, name)
+	}
+
+	refreshDNS(cfg)
+
+	return nil
+}
 
+// printPortForwards lists the ports proxy: auto forwards for a container
+func printPortForwards(cfg *config.Config, name string) {
+	forwards, err := operations.ListPortForwards(cfg, name)
+	if err != nil || len(forwards) == 0 {
+		return
+	}
+	fmt.Println("  Forwarding:")
+	for _, f := range forwards {
+		fmt.Printf("    localhost:%d -> %d\n", f.Local, f.Container)
+	}
+}
+
+// startDependencies starts the stopped containers name depends on
+func startDependencies(cfg *config.Config, name string) error {
+	deps, err := operations.Dependencies(cfg, name)
+	if err != nil {
+		return err
+	}
+	for _, dep := range deps {
+		status, err := lxc.GetStatus(cfg.GetLXCName(dep))
+		if err != nil {
+			return fmt.Errorf("dependency '%s': %w", dep, err)
+		}
+		if status == "RUNNING" {
+			continue
+		}
+		fmt.Printf("Starting dependency '%s'...\n", dep)
+		if err := operations.Start(cfg, dep); err != nil {
+			return fmt.Errorf("failed to start dependency '%s': %w", dep, err)
+		}
+		if len(cfg.GetReady(dep)) > 0 {
+			if err := waitReady(cfg, dep); err != nil {
+				return fmt.Errorf("dependency '%s': %w", dep, err)
+			}
+		}
+	}
 	return nil
 }
+
+// waitReady waits for a started container's ready conditions
+func waitReady(cfg *config.Config, name string) error {
+	fmt.Printf("Waiting for '%s' to be ready (%s)...\n", name, strings.Join(cfg.GetReady(name), ", "))
+	ctx, cancel := context.WithTimeout(context.Background(), upTimeout)
+	defer cancel()
+	return operations.WaitFor(ctx, cfg, name)
+}
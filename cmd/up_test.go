@@ -1,8 +1,14 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"lxc-dev-manager/internal/dns"
 )
 
 func TestUp_Success(t *testing.T) {
@@ -78,3 +84,257 @@ func TestUp_GetStatusFails(t *testing.T) {
 		t.Fatal("expected error")
 	}
 }
+
+func TestUp_RefreshesHostsFile(t *testing.T) {
+	env := setupTestEnv(t)
+	hostsPath := filepath.Join(env.dir, "hosts")
+	os.WriteFile(hostsPath, []byte("127.0.0.1\tlocalhost\n"), 0644)
+	oldHosts := dns.HostsPath
+	dns.HostsPath = hostsPath
+	defer func() { dns.HostsPath = oldHosts }()
+
+	env.writeConfig(`project: test
+dns:
+  mode: hosts
+containers:
+  dev1:
+    image: ubuntu:24.04
+`)
+	env.setContainerExists("test-dev1", false)
+	env.mock.SetOutput("start test-dev1", "")
+	env.setListAllContainers("test-dev1,RUNNING,10.10.10.100 (eth0)")
+
+	if err := runUp(nil, []string{"dev1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, _ := os.ReadFile(hostsPath)
+	if !strings.Contains(string(data), "10.10.10.100\tdev1.test.lxd") {
+		t.Errorf("expected hosts entry, got:\n%s", data)
+	}
+}
+
+func TestUp_RunsHooks(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: ""
+hooks:
+  pre_start: echo "pre {{.Name}}" >> hooks.log
+  post_start: echo "post {{.Name}} {{.IP}} {{quote .ProjectDir}}" >> hooks.log
+containers:
+  dev1:
+    image: ubuntu:24.04
+`)
+	env.setContainerExists("dev1", false)
+	env.mock.SetOutput("start dev1", "")
+	env.mock.SetOutput("list dev1 -c4 -f csv", "10.10.10.100 (eth0)")
+
+	if err := runUp(nil, []string{"dev1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(env.dir, "hooks.log"))
+	if err != nil {
+		t.Fatalf("expected hooks to run in the project directory: %v", err)
+	}
+	want := "pre dev1\npost dev1 10.10.10.100 '" + env.dir + "'\n"
+	if string(data) != want {
+		t.Errorf("hooks.log = %q, want %q", data, want)
+	}
+}
+
+func TestUp_PreStartHookFails(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: ""
+hooks:
+  pre_start: exit 3
+containers:
+  dev1:
+    image: ubuntu:24.04
+`)
+	env.setContainerExists("dev1", false)
+
+	err := runUp(nil, []string{"dev1"})
+	if err == nil || !strings.Contains(err.Error(), "pre_start hook failed") {
+		t.Fatalf("expected pre_start hook error, got %v", err)
+	}
+	if env.mock.HasCall("start", "dev1") {
+		t.Error("should not start when the pre_start hook fails")
+	}
+}
+
+func TestUp_RunsOnStartCommands(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: ""
+defaults:
+  on_start:
+    - sudo systemctl start postgresql
+containers:
+  dev1:
+    image: ubuntu:24.04
+    on_start:
+      - npm install
+      - make db-migrate
+`)
+	env.setContainerExists("dev1", false)
+	env.mock.SetOutput("start dev1", "")
+
+	if err := runUp(nil, []string{"dev1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !env.mock.HasCall("exec", "dev1", "--", "su", "-l", "dev", "-c", `cd "${WORKDIR:-$HOME}" && npm install`) {
+		t.Errorf("expected npm install to run as the user, got calls: %v", env.mock.Calls)
+	}
+	if !env.mock.HasCall("exec", "dev1", "--", "su", "-l", "dev", "-c", `cd "${WORKDIR:-$HOME}" && make db-migrate`) {
+		t.Error("expected make db-migrate to run")
+	}
+	if env.mock.HasCallPrefix("exec", "dev1", "--", "su", "-l", "dev", "-c", `cd "${WORKDIR:-$HOME}" && sudo systemctl`) {
+		t.Error("container on_start should replace defaults.on_start")
+	}
+}
+
+func TestUp_OnStartCommandFails(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: ""
+containers:
+  dev1:
+    image: ubuntu:24.04
+    on_start:
+      - "false"
+      - echo never
+`)
+	env.setContainerExists("dev1", false)
+	env.mock.SetOutput("start dev1", "")
+	env.mock.SetError(`exec dev1 -- su -l dev -c cd "${WORKDIR:-$HOME}" && false`, "exit status 1")
+
+	err := runUp(nil, []string{"dev1"})
+	if err == nil || !strings.Contains(err.Error(), "on_start command") {
+		t.Fatalf("expected on_start error, got %v", err)
+	}
+	if env.mock.HasCallPrefix("exec", "dev1", "--", "su", "-l", "dev", "-c", `cd "${WORKDIR:-$HOME}" && echo never`) {
+		t.Error("should stop at the first failing command")
+	}
+}
+
+func TestUp_StartsDependencies(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: ""
+containers:
+  db:
+    image: ubuntu:24.04
+  web:
+    image: ubuntu:24.04
+    depends_on: [db]
+`)
+	env.setContainerExists("db", false)
+	env.setContainerExists("web", false)
+	env.mock.SetOutput("start", "")
+
+	if err := runUp(nil, []string{"web"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !env.mock.HasCall("start", "db") || !env.mock.HasCall("start", "web") {
+		t.Fatalf("expected db and web to start, got calls: %v", env.mock.Calls)
+	}
+	dbIndex, webIndex := -1, -1
+	for i, call := range env.mock.Calls {
+		if strings.Join(call.Args, " ") == "start db" {
+			dbIndex = i
+		} else if strings.Join(call.Args, " ") == "start web" {
+			webIndex = i
+		}
+	}
+	if dbIndex > webIndex {
+		t.Error("expected db to start before web")
+	}
+}
+
+func TestUp_WaitsForDependencyReady(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: ""
+containers:
+  db:
+    image: ubuntu:24.04
+    ready: [port:5432]
+  web:
+    image: ubuntu:24.04
+    depends_on: [db]
+`)
+	env.setContainerExists("db", false)
+	env.setContainerExists("web", false)
+	env.mock.SetOutput("start", "")
+	env.mock.SetOutput("exec db -- sh -c", "LISTEN 0 4096 0.0.0.0:5432 0.0.0.0:*")
+
+	if err := runUp(nil, []string{"web"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	checkIndex, webIndex := -1, -1
+	for i, call := range env.mock.Calls {
+		switch {
+		case strings.HasPrefix(strings.Join(call.Args, " "), "exec db -- sh -c ss"):
+			checkIndex = i
+		case strings.Join(call.Args, " ") == "start web":
+			webIndex = i
+		}
+	}
+	if checkIndex < 0 || checkIndex > webIndex {
+		t.Errorf("expected db's port to be checked before web starts, got calls: %v", env.mock.Calls)
+	}
+}
+
+func TestUp_ReadyTimeout(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: ""
+containers:
+  dev1:
+    image: ubuntu:24.04
+    ready: [port:8080]
+`)
+	env.setContainerExists("dev1", false)
+	env.mock.SetOutput("start dev1", "")
+
+	upTimeout = 10 * time.Millisecond
+	defer func() { upTimeout = 2 * time.Minute }()
+
+	err := runUp(nil, []string{"dev1"})
+	if err == nil || !strings.Contains(err.Error(), "waiting for: port:8080") {
+		t.Fatalf("expected ready timeout, got %v", err)
+	}
+}
+
+func TestUp_AutoProxy(t *testing.T) {
+	env := setupTestEnv(t)
+	port := freePort(t)
+	env.writeConfig(fmt.Sprintf(`project: ""
+containers:
+  dev1:
+    image: ubuntu:24.04
+    ports: [%d]
+    proxy: auto
+`, port))
+	env.setContainerExists("dev1", false)
+	env.mock.SetOutput("start dev1", "")
+	env.mock.SetOutput("list dev1 -c4 -f csv", "10.10.10.100 (eth0)")
+	env.mock.SetOutput("config device show dev1", fmt.Sprintf(`port-%d:
+  type: proxy
+  listen: tcp:0.0.0.0:%d
+  connect: tcp:127.0.0.1:%d
+`, port, port, port))
+
+	output := captureStdout(t, func() {
+		if err := runUp(nil, []string{"dev1"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	device := fmt.Sprintf("port-%d", port)
+	if !env.mock.HasCall("config", "device", "add", "dev1", device, "proxy",
+		fmt.Sprintf("connect=tcp:127.0.0.1:%d", port), fmt.Sprintf("listen=tcp:0.0.0.0:%d", port)) {
+		t.Errorf("expected a proxy device, got %v", env.mock.Calls)
+	}
+	if !strings.Contains(output, fmt.Sprintf("localhost:%d -> %d", port, port)) {
+		t.Errorf("expected the forward to be listed, got:\n%s", output)
+	}
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"lxc-dev-manager/internal/operations"
+	"lxc-dev-manager/internal/validation"
+
+	"github.com/spf13/cobra"
+)
+
+var usageSnapshots bool
+
+var usageCmd = &cobra.Command{
+	Use:     "usage",
+	Aliases: []string{"du"},
+	Short:   "Show disk usage per container and snapshot",
+	Long: `Report root disk usage for each project container, plus the space held
+by its snapshots, largest first.
+
+Snapshot sizes are read with 'zfs list' and are only available on zfs
+storage pools; other pools show '-'.
+
+Examples:
+  lxc-dev-manager usage
+  lxc-dev-manager du --snapshots`,
+	Args: cobra.NoArgs,
+	RunE: runUsage,
+}
+
+func init() {
+	rootCmd.AddCommand(usageCmd)
+	usageCmd.Flags().BoolVarP(&usageSnapshots, "snapshots", "s", false, "List space held by each snapshot")
+}
+
+func runUsage(cmd *cobra.Command, args []string) error {
+	cfg, err := requireProject()
+	if err != nil {
+		return err
+	}
+
+	usage, err := operations.DiskUsage(cfg)
+	if err != nil {
+		return err
+	}
+
+	if len(usage) == 0 {
+		fmt.Println("No containers found.")
+		return nil
+	}
+
+	var root, snaps, total int64
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tROOT\tSNAPSHOTS\tTOTAL")
+	for _, u := range usage {
+		snapTotal := u.Total - u.Root
+		snapCol := "-"
+		if u.SnapshotsKnown {
+			snapCol = fmt.Sprintf("%s (%d)", validation.FormatSize(snapTotal), len(u.Snapshots))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", u.Name, validation.FormatSize(u.Root), snapCol, validation.FormatSize(u.Total))
+
+		if usageSnapshots {
+			for _, s := range u.Snapshots {
+				fmt.Fprintf(w, "  @%s\t\t%s\t\n", s.Name, validation.FormatSize(s.Used))
+			}
+		}
+
+		root += u.Root
+		snaps += snapTotal
+		total += u.Total
+	}
+	fmt.Fprintf(w, "TOTAL\t%s\t%s\t%s\n", validation.FormatSize(root), validation.FormatSize(snaps), validation.FormatSize(total))
+	w.Flush()
+
+	return nil
+}
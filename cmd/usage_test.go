@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"testing"
+
+	"lxc-dev-manager/internal/lxc"
+)
+
+func TestUsage_ZFSSnapshots(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+  dev2:
+    image: ubuntu:24.04
+`)
+	env.setContainerExists("test-dev1", true)
+	env.setContainerNotExists("test-dev2")
+	env.setListAllContainers("test-dev1,RUNNING")
+	env.mock.SetOutput("query /1.0/instances/test-dev1/state", `{"disk":{"root":{"usage":1073741824}}}`)
+	env.mock.SetOutput("query /1.0/instances/test-dev1", `{"expanded_devices":{"root":{"type":"disk","path":"/","pool":"default"}}}`)
+	env.mock.SetOutput("query /1.0/storage-pools/default", `{"driver":"zfs","config":{"zfs.pool_name":"tank/lxd"}}`)
+
+	orig := lxc.ZFSRunner
+	defer func() { lxc.ZFSRunner = orig }()
+	var dataset string
+	lxc.ZFSRunner = func(args ...string) ([]byte, error) {
+		dataset = args[len(args)-1]
+		return []byte("tank/lxd/containers/test-dev1@snapshot-initial-state\t524288000\n"), nil
+	}
+
+	usageSnapshots = true
+	defer func() { usageSnapshots = false }()
+
+	if err := runUsage(nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if dataset != "tank/lxd/containers/test-dev1" {
+		t.Errorf("expected zfs dataset tank/lxd/containers/test-dev1, got %q", dataset)
+	}
+}
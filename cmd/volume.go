@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"lxc-dev-manager/internal/operations"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	volumePool       string
+	volumeSize       string
+	volumeDeviceName string
+	volumeReadOnly   bool
+)
+
+var volumeCmd = &cobra.Command{
+	Use:   "volume",
+	Short: "Manage custom storage volumes",
+	Long: `Manage LXD custom storage volumes owned by the project.
+
+Volumes live independently of containers, so they survive container
+removal and can be shared between containers (e.g. a package cache).
+Volume names are prefixed with the project name in LXD, like containers.`,
+}
+
+var volumeCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a custom storage volume",
+	Long: `Create a custom storage volume in a storage pool.
+
+Examples:
+  lxc-dev-manager volume create npm-cache
+  lxc-dev-manager volume create pip-cache --size 10GiB
+  lxc-dev-manager volume create data --pool fast`,
+	Args: cobra.ExactArgs(1),
+	RunE: runVolumeCreate,
+}
+
+var volumeListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List project volumes",
+	Long: `List the project's volumes and the containers they are attached to.
+
+Status values:
+  ok      - Volume exists in LXD
+  missing - Volume is in config but not in LXD`,
+	Args: cobra.NoArgs,
+	RunE: runVolumeList,
+}
+
+var volumeAttachCmd = &cobra.Command{
+	Use:   "attach <volume> <container> <path>",
+	Short: "Attach a volume to a container",
+	Long: `Attach a volume to a container at the given path.
+
+A volume can be attached to several containers at once to share data.
+
+Examples:
+  lxc-dev-manager volume attach npm-cache dev1 /home/dev/.npm
+  lxc-dev-manager volume attach npm-cache dev2 /home/dev/.npm
+  lxc-dev-manager volume attach data dev1 /data --ro`,
+	Args: cobra.ExactArgs(3),
+	RunE: runVolumeAttach,
+}
+
+var volumeDetachCmd = &cobra.Command{
+	Use:   "detach <volume> <container>",
+	Short: "Detach a volume from a container",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runVolumeDetach,
+}
+
+var volumeDeleteCmd = &cobra.Command{
+	Use:   "delete <volume>",
+	Short: "Delete a volume and its data",
+	Long: `Delete a custom storage volume. The volume must be detached from all
+containers first.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runVolumeDelete,
+}
+
+func init() {
+	rootCmd.AddCommand(volumeCmd)
+	volumeCmd.AddCommand(volumeCreateCmd)
+	volumeCmd.AddCommand(volumeListCmd)
+	volumeCmd.AddCommand(volumeAttachCmd)
+	volumeCmd.AddCommand(volumeDetachCmd)
+	volumeCmd.AddCommand(volumeDeleteCmd)
+
+	volumeCreateCmd.Flags().StringVar(&volumePool, "pool", "", "Storage pool (default: default)")
+	volumeCreateCmd.Flags().StringVar(&volumeSize, "size", "", "Size quota, e.g. 10GiB (default: unlimited)")
+
+	volumeAttachCmd.Flags().StringVarP(&volumeDeviceName, "name", "n", "", "Device name (default: vol-<volume>)")
+	volumeAttachCmd.Flags().BoolVar(&volumeReadOnly, "ro", false, "Attach read-only")
+}
+
+func runVolumeCreate(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	cfg, lock, err := requireProjectWithLock()
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	if err := operations.CreateVolume(cfg, name, operations.VolumeOpts{
+		Pool: volumePool,
+		Size: volumeSize,
+	}); err != nil {
+		return err
+	}
+
+	fmt.Printf("Volume '%s' created (LXD: %s)\n", name, cfg.GetVolumeLXCName(name))
+	return nil
+}
+
+func runVolumeList(cmd *cobra.Command, args []string) error {
+	cfg, err := requireProject()
+	if err != nil {
+		return err
+	}
+
+	volumes, err := operations.ListVolumes(cfg)
+	if err != nil {
+		return err
+	}
+
+	if len(volumes) == 0 {
+		fmt.Println("No volumes found.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tPOOL\tSIZE\tATTACHED TO\tSTATUS")
+	for _, v := range volumes {
+		size := v.Size
+		if size == "" {
+			size = "-"
+		}
+		attached := strings.Join(v.AttachedTo, ",")
+		if attached == "" {
+			attached = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", v.Name, v.Pool, size, attached, v.Status)
+	}
+	w.Flush()
+
+	return nil
+}
+
+func runVolumeAttach(cmd *cobra.Command, args []string) error {
+	volumeName := args[0]
+	containerName := args[1]
+	path := args[2]
+
+	cfg, _, lock, err := requireContainerWithProjectLock(&containerName)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	deviceName, err := operations.AttachVolume(cfg, volumeName, containerName, path, operations.AttachVolumeOpts{
+		Name:     volumeDeviceName,
+		ReadOnly: volumeReadOnly,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Attached volume '%s' -> '%s:%s' as device '%s'\n", volumeName, containerName, path, deviceName)
+	return nil
+}
+
+func runVolumeDetach(cmd *cobra.Command, args []string) error {
+	volumeName := args[0]
+	containerName := args[1]
+
+	cfg, _, lock, err := requireContainerWithProjectLock(&containerName)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	if err := operations.DetachVolume(cfg, volumeName, containerName); err != nil {
+		return err
+	}
+
+	fmt.Printf("Detached volume '%s' from '%s'\n", volumeName, containerName)
+	return nil
+}
+
+func runVolumeDelete(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	cfg, lock, err := requireProjectWithLock()
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	if err := operations.DeleteVolume(cfg, name); err != nil {
+		return err
+	}
+
+	fmt.Printf("Volume '%s' deleted\n", name)
+	return nil
+}
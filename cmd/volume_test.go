@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVolumeCreate(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers: {}
+`)
+	env.mock.SetError("storage volume show default test-cache", "not found")
+	env.mock.SetOutput("storage volume create", "")
+
+	volumeSize = "10GiB"
+	defer func() { volumeSize = "" }()
+
+	if err := runVolumeCreate(nil, []string{"cache"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !env.mock.HasCall("storage", "volume", "create", "default", "test-cache", "size=10GiB") {
+		t.Error("expected storage volume create call")
+	}
+
+	cfg := env.readConfig()
+	if !strings.Contains(cfg, "volumes:") || !strings.Contains(cfg, "size: 10GiB") {
+		t.Errorf("expected volume in config, got:\n%s", cfg)
+	}
+}
+
+func TestVolumeCreate_AlreadyExists(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+volumes:
+  cache: {}
+containers: {}
+`)
+
+	if err := runVolumeCreate(nil, []string{"cache"}); err == nil {
+		t.Fatal("expected error for duplicate volume")
+	}
+}
+
+func TestVolumeAttach_SharedBetweenContainers(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+volumes:
+  cache: {}
+containers:
+  dev1:
+    image: ubuntu:24.04
+  dev2:
+    image: ubuntu:24.04
+`)
+	env.setContainerExists("test-dev1", true)
+	env.setContainerExists("test-dev2", true)
+	env.mock.SetOutput("config device add", "")
+
+	for _, name := range []string{"dev1", "dev2"} {
+		if err := runVolumeAttach(nil, []string{"cache", name, "/home/dev/.npm"}); err != nil {
+			t.Fatalf("attach to %s: unexpected error: %v", name, err)
+		}
+	}
+
+	if !env.mock.HasCallPrefix("config", "device", "add", "test-dev1", "vol-cache", "disk") {
+		t.Error("expected volume device on dev1")
+	}
+	if !env.mock.HasCallPrefix("config", "device", "add", "test-dev2", "vol-cache", "disk") {
+		t.Error("expected volume device on dev2")
+	}
+
+	cfg := env.readConfig()
+	if strings.Count(cfg, "source: test-cache") != 2 {
+		t.Errorf("expected volume device in both containers, got:\n%s", cfg)
+	}
+}
+
+func TestVolumeAttach_UnknownVolume(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+`)
+	env.setContainerExists("test-dev1", true)
+
+	err := runVolumeAttach(nil, []string{"cache", "dev1", "/cache"})
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected not found error, got: %v", err)
+	}
+}
+
+func TestVolumeDelete_RefusesWhileAttached(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+volumes:
+  cache: {}
+containers:
+  dev1:
+    image: ubuntu:24.04
+    devices:
+      vol-cache:
+        type: disk
+        config:
+          pool: default
+          source: test-cache
+          path: /cache
+`)
+
+	err := runVolumeDelete(nil, []string{"cache"})
+	if err == nil || !strings.Contains(err.Error(), "dev1") {
+		t.Errorf("expected attached error naming dev1, got: %v", err)
+	}
+	if env.mock.HasCallPrefix("storage", "volume", "delete") {
+		t.Error("should not delete an attached volume")
+	}
+}
+
+func TestVolumeDetachAndDelete(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+volumes:
+  cache: {}
+containers:
+  dev1:
+    image: ubuntu:24.04
+    devices:
+      vol-cache:
+        type: disk
+        config:
+          pool: default
+          source: test-cache
+          path: /cache
+`)
+	env.setContainerExists("test-dev1", true)
+	env.mock.SetOutput("config device remove test-dev1 vol-cache", "")
+	env.mock.SetOutput("storage volume show default test-cache", "name: test-cache")
+	env.mock.SetOutput("storage volume delete default test-cache", "")
+
+	if err := runVolumeDetach(nil, []string{"cache", "dev1"}); err != nil {
+		t.Fatalf("detach: unexpected error: %v", err)
+	}
+	if err := runVolumeDelete(nil, []string{"cache"}); err != nil {
+		t.Fatalf("delete: unexpected error: %v", err)
+	}
+
+	if !env.mock.HasCall("storage", "volume", "delete", "default", "test-cache") {
+		t.Error("expected storage volume delete call")
+	}
+	if strings.Contains(env.readConfig(), "cache") {
+		t.Error("expected volume and device to be removed from config")
+	}
+}
+
+func TestVolumeList(t *testing.T) {
+	env := setupTestEnv(t)
+	env.writeConfig(`project: test
+volumes:
+  cache:
+    size: 5GiB
+containers: {}
+`)
+	env.mock.SetOutput("query /1.0/storage-pools/default/volumes/custom?recursion=1",
+		`[{"name":"test-cache","config":{"size":"5GiB"},"used_by":[]}]`)
+
+	if err := runVolumeList(nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -33,7 +33,9 @@ export default defineConfig({
           { text: 'Project', link: '/reference/commands/project' },
           { text: 'Container', link: '/reference/commands/container' },
           { text: 'Snapshot', link: '/reference/commands/snapshot' },
-          { text: 'Image', link: '/reference/commands/image' }
+          { text: 'Image', link: '/reference/commands/image' },
+          { text: 'Volume', link: '/reference/commands/volume' },
+          { text: 'Config', link: '/reference/commands/config' }
         ]
       },
       {
@@ -0,0 +1,290 @@
+// Package compose reads the parts of a docker-compose file that map onto
+// project containers: image, ports, bind mounts, environment and
+// depends_on. Compose allows several spellings for most of these; each
+// accessor normalizes them.
+package compose
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File is a parsed compose file
+type File struct {
+	Dir      string // Directory containing the file; relative paths are resolved against it
+	Services map[string]Service
+}
+
+// Service is one service of a compose file
+type Service struct {
+	Image       string    `yaml:"image"`
+	Build       yaml.Node `yaml:"build"`
+	Ports       []any     `yaml:"ports"`
+	Volumes     []any     `yaml:"volumes"`
+	Environment yaml.Node `yaml:"environment"`
+	EnvFile     yaml.Node `yaml:"env_file"`
+	DependsOn   yaml.Node `yaml:"depends_on"`
+}
+
+// Mount is a bind mount of a host path
+type Mount struct {
+	Source   string
+	Target   string
+	ReadOnly bool
+}
+
+// Load reads and parses the compose file at path
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc struct {
+		Services map[string]Service `yaml:"services"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	if len(doc.Services) == 0 {
+		return nil, fmt.Errorf("%s has no services", path)
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	return &File{Dir: dir, Services: doc.Services}, nil
+}
+
+// Names returns the service names in order
+func (f *File) Names() []string {
+	names := make([]string, 0, len(f.Services))
+	for name := range f.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HasBuild reports whether the service is built from a Dockerfile
+func (s Service) HasBuild() bool {
+	return !s.Build.IsZero()
+}
+
+// HasEnvFile reports whether the service reads variables from env files
+func (s Service) HasEnvFile() bool {
+	return !s.EnvFile.IsZero()
+}
+
+// ContainerPorts returns the container side of each published port.
+// Entries may be numbers, "8080", "8080:80", "127.0.0.1:8080:80/tcp",
+// or long-syntax objects with a target.
+func (s Service) ContainerPorts() ([]int, error) {
+	var ports []int
+	for _, entry := range s.Ports {
+		var spec string
+		switch v := entry.(type) {
+		case int:
+			ports = append(ports, v)
+			continue
+		case string:
+			spec = v
+		case map[string]any:
+			target, ok := v["target"]
+			if !ok {
+				return nil, fmt.Errorf("port %v has no target", v)
+			}
+			spec = fmt.Sprint(target)
+		default:
+			return nil, fmt.Errorf("invalid port %v", entry)
+		}
+
+		spec, _, _ = strings.Cut(spec, "/")
+		if i := strings.LastIndex(spec, ":"); i >= 0 {
+			spec = spec[i+1:]
+		}
+		if strings.Contains(spec, "-") {
+			return nil, fmt.Errorf("port range %q is not supported", spec)
+		}
+		port, err := strconv.Atoi(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port %v", entry)
+		}
+		ports = append(ports, port)
+	}
+	return ports, nil
+}
+
+// BindMounts returns the service's bind mounts with sources made absolute
+// against dir. Named volumes and tmpfs mounts are returned separately since
+// they have no host directory to mount.
+func (s Service) BindMounts(dir string) (binds []Mount, skipped []string, err error) {
+	for _, entry := range s.Volumes {
+		var m Mount
+		switch v := entry.(type) {
+		case string:
+			parts := strings.Split(v, ":")
+			if len(parts) < 2 {
+				skipped = append(skipped, "anonymous volume "+v)
+				continue
+			}
+			m.Source, m.Target = parts[0], parts[1]
+			if len(parts) > 2 {
+				for _, opt := range strings.Split(parts[2], ",") {
+					if opt == "ro" {
+						m.ReadOnly = true
+					}
+				}
+			}
+			if !isPath(m.Source) {
+				skipped = append(skipped, fmt.Sprintf("named volume %s at %s", m.Source, m.Target))
+				continue
+			}
+		case map[string]any:
+			if v["type"] != "bind" {
+				skipped = append(skipped, fmt.Sprintf("%v mount at %v", v["type"], v["target"]))
+				continue
+			}
+			m.Source, _ = v["source"].(string)
+			m.Target, _ = v["target"].(string)
+			m.ReadOnly, _ = v["read_only"].(bool)
+			if m.Source == "" || m.Target == "" {
+				return nil, nil, fmt.Errorf("bind mount %v needs a source and target", v)
+			}
+		default:
+			return nil, nil, fmt.Errorf("invalid volume %v", entry)
+		}
+
+		m.Source = expandHome(m.Source)
+		if !filepath.IsAbs(m.Source) {
+			m.Source = filepath.Join(dir, m.Source)
+		}
+		binds = append(binds, m)
+	}
+	return binds, skipped, nil
+}
+
+// isPath reports whether a short-syntax volume source is a host path
+// rather than a named volume
+func isPath(source string) bool {
+	return strings.HasPrefix(source, "/") || strings.HasPrefix(source, ".") || strings.HasPrefix(source, "~")
+}
+
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
+
+// Env returns the service's environment variables. Compose accepts a map
+// or a list of "KEY=value" strings; variables without a value (passed
+// through from the host in compose) are looked up in the environment.
+func (s Service) Env() (map[string]string, error) {
+	if s.Environment.IsZero() {
+		return nil, nil
+	}
+	env := map[string]string{}
+	switch s.Environment.Kind {
+	case yaml.MappingNode:
+		// Content alternates keys and values
+		for i := 0; i+1 < len(s.Environment.Content); i += 2 {
+			key, value := s.Environment.Content[i].Value, s.Environment.Content[i+1]
+			if value.Tag == "!!null" {
+				env[key] = os.Getenv(key)
+			} else {
+				env[key] = value.Value
+			}
+		}
+	case yaml.SequenceNode:
+		var list []string
+		if err := s.Environment.Decode(&list); err != nil {
+			return nil, err
+		}
+		for _, entry := range list {
+			key, value, found := strings.Cut(entry, "=")
+			if !found {
+				value = os.Getenv(key)
+			}
+			env[key] = value
+		}
+	default:
+		return nil, fmt.Errorf("environment must be a map or a list")
+	}
+	return env, nil
+}
+
+// Dependencies returns the services this one depends on, from either the
+// list or the map (long) form of depends_on
+func (s Service) Dependencies() ([]string, error) {
+	if s.DependsOn.IsZero() {
+		return nil, nil
+	}
+	var deps []string
+	switch s.DependsOn.Kind {
+	case yaml.SequenceNode:
+		if err := s.DependsOn.Decode(&deps); err != nil {
+			return nil, err
+		}
+	case yaml.MappingNode:
+		var m map[string]any
+		if err := s.DependsOn.Decode(&m); err != nil {
+			return nil, err
+		}
+		for dep := range m {
+			deps = append(deps, dep)
+		}
+		sort.Strings(deps)
+	default:
+		return nil, fmt.Errorf("depends_on must be a list or a map")
+	}
+	return deps, nil
+}
+
+// distroImages maps official Docker distro images to LXC image remotes.
+// The tag is appended as the release; latest uses the default release.
+var distroImages = map[string]struct{ prefix, latest string }{
+	"ubuntu":     {"ubuntu:", "24.04"},
+	"debian":     {"images:debian/", "12"},
+	"alpine":     {"images:alpine/", "3.20"},
+	"fedora":     {"images:fedora/", "40"},
+	"rockylinux": {"images:rockylinux/", "9"},
+	"almalinux":  {"images:almalinux/", "9"},
+	"archlinux":  {"images:archlinux", ""},
+}
+
+// ClosestImage returns the LXC image closest to a Docker image. Distro
+// base images map to the same release; anything else (node, postgres,
+// ...) maps to fallback, and exact is false since the service's software
+// has to be installed separately.
+func ClosestImage(image, fallback string) (lxcImage string, exact bool) {
+	name, tag, _ := strings.Cut(image, ":")
+	name = strings.TrimPrefix(name, "docker.io/")
+	name = strings.TrimPrefix(name, "library/")
+
+	distro, ok := distroImages[name]
+	if !ok {
+		return fallback, false
+	}
+	if distro.latest == "" {
+		return distro.prefix, true
+	}
+
+	// Variants such as bookworm-slim or 3.19.1 use the release part
+	tag = strings.TrimSuffix(tag, "-slim")
+	if tag == "" || tag == "latest" {
+		tag = distro.latest
+	}
+	if name == "alpine" && strings.Count(tag, ".") > 1 {
+		tag = tag[:strings.LastIndex(tag, ".")]
+	}
+	return distro.prefix + tag, true
+}
@@ -0,0 +1,178 @@
+package compose
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testCompose = `services:
+  web:
+    build: .
+    ports:
+      - "3000"
+      - "8080:80"
+      - "127.0.0.1:9229:9229/tcp"
+    volumes:
+      - ./src:/app/src
+      - ./config:/app/config:ro
+      - node_modules:/app/node_modules
+    environment:
+      NODE_ENV: development
+      PORT: 3000
+    depends_on:
+      db:
+        condition: service_healthy
+  db:
+    image: postgres:16
+    ports:
+      - target: 5432
+        published: 5432
+    environment:
+      - POSTGRES_PASSWORD=secret
+    volumes:
+      - type: bind
+        source: ./data
+        target: /var/lib/postgresql/data
+      - type: tmpfs
+        target: /tmp
+volumes:
+  node_modules:
+`
+
+func loadTestCompose(t *testing.T) *File {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "docker-compose.yml")
+	if err := os.WriteFile(path, []byte(testCompose), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	return f
+}
+
+func TestLoad(t *testing.T) {
+	f := loadTestCompose(t)
+
+	if !reflect.DeepEqual(f.Names(), []string{"db", "web"}) {
+		t.Errorf("unexpected services: %v", f.Names())
+	}
+	if !f.Services["web"].HasBuild() || f.Services["db"].HasBuild() {
+		t.Error("expected only web to have a build")
+	}
+}
+
+func TestLoad_NoServices(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "compose.yml")
+	os.WriteFile(path, []byte("version: '3'\n"), 0644)
+	if _, err := Load(path); err == nil {
+		t.Error("expected error for a file without services")
+	}
+}
+
+func TestContainerPorts(t *testing.T) {
+	f := loadTestCompose(t)
+
+	ports, err := f.Services["web"].ContainerPorts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ports, []int{3000, 80, 9229}) {
+		t.Errorf("unexpected web ports: %v", ports)
+	}
+
+	ports, err = f.Services["db"].ContainerPorts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ports, []int{5432}) {
+		t.Errorf("unexpected db ports: %v", ports)
+	}
+}
+
+func TestBindMounts(t *testing.T) {
+	f := loadTestCompose(t)
+
+	binds, skipped, err := f.Services["web"].BindMounts(f.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Mount{
+		{Source: filepath.Join(f.Dir, "src"), Target: "/app/src"},
+		{Source: filepath.Join(f.Dir, "config"), Target: "/app/config", ReadOnly: true},
+	}
+	if !reflect.DeepEqual(binds, expected) {
+		t.Errorf("unexpected binds: %+v", binds)
+	}
+	if len(skipped) != 1 {
+		t.Errorf("expected the named volume to be skipped, got %v", skipped)
+	}
+
+	binds, skipped, err = f.Services["db"].BindMounts(f.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(binds) != 1 || binds[0].Target != "/var/lib/postgresql/data" || len(skipped) != 1 {
+		t.Errorf("unexpected db mounts: %+v, skipped %v", binds, skipped)
+	}
+}
+
+func TestEnv(t *testing.T) {
+	f := loadTestCompose(t)
+
+	env, err := f.Services["web"].Env()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(env, map[string]string{"NODE_ENV": "development", "PORT": "3000"}) {
+		t.Errorf("unexpected web env: %v", env)
+	}
+
+	env, err = f.Services["db"].Env()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(env, map[string]string{"POSTGRES_PASSWORD": "secret"}) {
+		t.Errorf("unexpected db env: %v", env)
+	}
+}
+
+func TestDependencies(t *testing.T) {
+	f := loadTestCompose(t)
+
+	deps, err := f.Services["web"].Dependencies()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(deps, []string{"db"}) {
+		t.Errorf("unexpected deps: %v", deps)
+	}
+}
+
+func TestClosestImage(t *testing.T) {
+	tests := []struct {
+		image    string
+		expected string
+		exact    bool
+	}{
+		{"ubuntu:22.04", "ubuntu:22.04", true},
+		{"ubuntu", "ubuntu:24.04", true},
+		{"docker.io/library/debian:bookworm-slim", "images:debian/bookworm", true},
+		{"alpine:3.19.1", "images:alpine/3.19", true},
+		{"archlinux:latest", "images:archlinux", true},
+		{"postgres:16", "fallback", false},
+		{"ghcr.io/acme/app:1.0", "fallback", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			got, exact := ClosestImage(tt.image, "fallback")
+			if got != tt.expected || exact != tt.exact {
+				t.Errorf("ClosestImage(%q) = %q, %v; expected %q, %v", tt.image, got, exact, tt.expected, tt.exact)
+			}
+		})
+	}
+}
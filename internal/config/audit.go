@@ -0,0 +1,109 @@
+package config
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"time"
+)
+
+// AuditFile is the line-delimited JSON log of mutating commands, relative
+// to the project directory
+const AuditFile = ".lxc-dev-manager/audit.log"
+
+// AuditEntry is one line of the audit log
+type AuditEntry struct {
+	Time     time.Time `json:"time"`
+	User     string    `json:"user"`
+	SudoUser string    `json:"sudo_user,omitempty"` // who ran sudo, if anyone
+	Host     string    `json:"host"`
+	PID      int       `json:"pid"`
+	Command  string    `json:"command"`
+	Args     []string  `json:"args"`
+	Error    string    `json:"error,omitempty"` // empty when the command succeeded
+}
+
+// AppendAudit adds entry to dir's audit log, filling in the time, user,
+// host and PID if they aren't set. Each entry is written with a single
+// append so concurrent commands don't interleave lines.
+// If dir is empty, it uses the current working directory.
+func AppendAudit(dir string, entry AuditEntry) error {
+	if dir == "" {
+		dir = "."
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	if entry.User == "" {
+		entry.User = currentUser()
+		entry.SudoUser = os.Getenv("SUDO_USER")
+	}
+	if entry.Host == "" {
+		entry.Host, _ = os.Hostname()
+	}
+	if entry.PID == 0 {
+		entry.PID = os.Getpid()
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	path := filepath.Join(dir, AuditFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadAudit returns the entries of dir's audit log, oldest first. A missing
+// log has no entries.
+// If dir is empty, it uses the current working directory.
+func ReadAudit(dir string) ([]AuditEntry, error) {
+	if dir == "" {
+		dir = "."
+	}
+	f, err := os.Open(filepath.Join(dir, AuditFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return entries, fmt.Errorf("%s line %d: %w", AuditFile, n, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAppendAudit(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := AppendAudit(dir, AuditEntry{Command: "remove", Args: []string{"remove", "dev3", "--force"}}); err != nil {
+		t.Fatalf("AppendAudit failed: %v", err)
+	}
+	if err := AppendAudit(dir, AuditEntry{Command: "container reset", Args: []string{"container", "reset", "dev1"}, Error: "snapshot 'x' does not exist"}); err != nil {
+		t.Fatalf("AppendAudit failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, AuditFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %q", len(lines), data)
+	}
+
+	entries, err := ReadAudit(dir)
+	if err != nil {
+		t.Fatalf("ReadAudit failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	first := entries[0]
+	if first.Command != "remove" || len(first.Args) != 3 || first.Args[1] != "dev3" {
+		t.Errorf("unexpected first entry: %+v", first)
+	}
+	if first.Time.IsZero() || first.User == "" || first.Host == "" || first.PID != os.Getpid() {
+		t.Errorf("expected time, user, host and pid to be filled in: %+v", first)
+	}
+	if first.Error != "" || entries[1].Error == "" {
+		t.Errorf("expected only the second entry to record an error: %+v", entries)
+	}
+}
+
+func TestReadAudit_Missing(t *testing.T) {
+	entries, err := ReadAudit(t.TempDir())
+	if err != nil || len(entries) != 0 {
+		t.Errorf("expected no entries, got %v, %v", entries, err)
+	}
+}
@@ -1,44 +1,217 @@
 package config
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"syscall"
+	"text/template"
 	"time"
 
+	"lxc-dev-manager/internal/dns"
+	"lxc-dev-manager/internal/dryrun"
+	"lxc-dev-manager/internal/errcode"
 	"lxc-dev-manager/internal/validation"
 
 	"gopkg.in/yaml.v3"
 )
 
 // ErrNoProject is returned when no project config file exists
-var ErrNoProject = errors.New("no project found in current directory")
+var ErrNoProject = errcode.New(errcode.NotFound, "", errors.New("no project found in current directory"))
 
 const (
-	ConfigFile  = "containers.yaml"
-	lockFile    = "containers.yaml.lock"
-	lockTimeout = 5 * time.Second
+	ConfigFile = "containers.yaml"
+	lockFile   = "containers.yaml.lock"
+
+	// lockDir holds the per-container lock files and the write lock taken
+	// while merging a container-scoped save into containers.yaml
+	lockDir       = ".containers.yaml.locks"
+	writeLockFile = ".write.lock"
+)
+
+// historyDir holds timestamped backups of containers.yaml, relative to the
+// project directory. Only the newest maxBackups are kept.
+const (
+	historyDir = ".lxc-dev-manager/history"
+	maxBackups = 20
 )
 
+// lockTimeout is how long to wait for a lock before giving up
+var lockTimeout = 5 * time.Second
+
 type Config struct {
-	Dir        string               `yaml:"-"` // directory containing this config file (not serialized)
-	Project    string               `yaml:"project"`
-	Defaults   Defaults             `yaml:"defaults"`
-	Containers map[string]Container `yaml:"containers"`
+	Dir         string               `yaml:"-"` // directory containing this config file (not serialized)
+	Project     string               `yaml:"project"`
+	Naming      string               `yaml:"naming,omitempty"` // How LXC names are built from the project name, see NamingEscaped
+	Defaults    Defaults             `yaml:"defaults"`
+	DNS         DNS                  `yaml:"dns,omitempty"`
+	ImageRemote string               `yaml:"image_remote,omitempty"` // LXC remote that images missing locally are pulled from
+	Hooks       Hooks                `yaml:"hooks,omitempty"`
+	Security    Security             `yaml:"security,omitempty"`
+	Reset       ResetSettings        `yaml:"reset,omitempty"`
+	Volumes     map[string]Volume    `yaml:"volumes,omitempty"`
+	Containers  map[string]Container `yaml:"containers"`
+
+	// scope is the container this config was loaded for by
+	// LoadWithContainerLock. Saves then only write that container's entry.
+	scope string
+
+	// detached is set on the copies made by Detach, whose saves are left
+	// to the caller
+	detached bool
+}
+
+// NamingEscaped doubles the hyphens of the project name in LXC names, so
+// project "web-app" with container "api" is "web--app-api". Container names
+// can't start with a hyphen or contain "--", so the first single hyphen
+// always ends the project prefix and no two projects can claim the same
+// LXC name. New projects use it; configs without naming keep the original
+// "<project>-<container>" scheme, where project "web" with container
+// "app-api" and project "web-app" with container "api" would collide.
+const NamingEscaped = "escaped"
+
+// Proxy modes for containers.<name>.proxy
+const (
+	ProxyManual = "manual" // Ports are forwarded by running the proxy command
+	ProxyAuto   = "auto"   // up forwards the ports and down stops forwarding them
+)
+
+// Hooks are shell commands run on the host around container lifecycle
+// operations. Each is a text/template rendered with a HookContext and run
+// with sh -c from the project directory.
+type Hooks struct {
+	PreCreate  string `yaml:"pre_create,omitempty"`
+	PostCreate string `yaml:"post_create,omitempty"`
+	PreStart   string `yaml:"pre_start,omitempty"`
+	PostStart  string `yaml:"post_start,omitempty"`
+	PreStop    string `yaml:"pre_stop,omitempty"`
+	PostStop   string `yaml:"post_stop,omitempty"`
+	OnCrash    string `yaml:"on_crash,omitempty"` // Run by monitor when a container stops without being asked to
+}
+
+// HookContext is the data available to hook templates
+type HookContext struct {
+	Event      string // e.g. "post_start"
+	Name       string // Container name as in containers.yaml
+	LXCName    string // Full LXC name with project prefix
+	Project    string
+	ProjectDir string
+	Image      string
+	IP         string // Empty before the container is running
+}
+
+// HookFuncs are the functions available in hook templates
+var HookFuncs = template.FuncMap{
+	// quote wraps a value in single quotes for sh
+	"quote": func(s string) string {
+		return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+	},
+}
+
+// Events returns the configured hooks keyed by event name
+func (h Hooks) Events() map[string]string {
+	events := map[string]string{
+		"pre_create":  h.PreCreate,
+		"post_create": h.PostCreate,
+		"pre_start":   h.PreStart,
+		"post_start":  h.PostStart,
+		"pre_stop":    h.PreStop,
+		"post_stop":   h.PostStop,
+		"on_crash":    h.OnCrash,
+	}
+	for event, command := range events {
+		if command == "" {
+			delete(events, event)
+		}
+	}
+	return events
+}
+
+// ParseHook parses a hook command template
+func ParseHook(event, command string) (*template.Template, error) {
+	return template.New(event).Funcs(HookFuncs).Option("missingkey=error").Parse(command)
+}
+
+// DefaultVolumePool is the storage pool used when a volume has none set
+const DefaultVolumePool = "default"
+
+// Volume is a custom storage volume owned by the project. Its LXD name is
+// prefixed with the project name, like containers.
+type Volume struct {
+	Pool string `yaml:"pool,omitempty"` // Storage pool (default: "default")
+	Size string `yaml:"size,omitempty"` // Size quota, e.g. "10GiB"
+}
+
+// ResetSettings configures container reset
+type ResetSettings struct {
+	AutoSnapshot bool `yaml:"auto_snapshot,omitempty"` // Take a pre-reset snapshot before every reset
+}
+
+// DNS configures host-side name registration for containers
+type DNS struct {
+	Mode   string `yaml:"mode,omitempty"`   // "hosts" or "dnsmasq" (empty disables)
+	Domain string `yaml:"domain,omitempty"` // Top-level domain (default: lxd)
+	Sudo   bool   `yaml:"sudo,omitempty"`   // Use sudo when the target file isn't writable
 }
 
 type User struct {
-	Name     string `yaml:"name,omitempty"`
-	Password string `yaml:"password,omitempty"`
+	Name     string   `yaml:"name,omitempty"`
+	Password string   `yaml:"password,omitempty"`
+	Shell    string   `yaml:"shell,omitempty"`    // Login shell, e.g. "zsh" (default: bash)
+	Timezone string   `yaml:"timezone,omitempty"` // e.g. "Europe/Paris" (default: the image's, usually UTC)
+	Locale   string   `yaml:"locale,omitempty"`   // e.g. "en_US.UTF-8"
+	Groups   []string `yaml:"groups,omitempty"`   // Extra groups, e.g. docker, video
 }
 
 type Defaults struct {
-	Ports []int `yaml:"ports"`
-	User  User  `yaml:"user,omitempty"`
+	Ports      []int       `yaml:"ports"`
+	Image      string      `yaml:"image,omitempty"` // Used by container create when no image is given
+	User       User        `yaml:"user,omitempty"`
+	PreferIPv6 bool        `yaml:"prefer_ipv6,omitempty"` // Proxy to the IPv6 address when available
+	Mounts     []Mount     `yaml:"mounts,omitempty"`      // Mounted into every container at create time
+	Workdir    string      `yaml:"workdir,omitempty"`     // Mount the project directory here in new containers
+	VerifyCopy bool        `yaml:"verify_copy,omitempty"` // Compare sha256 checksums after file copies
+	Dotfiles   *Dotfiles   `yaml:"dotfiles,omitempty"`    // Applied for the user in new containers
+	OnStart    []string    `yaml:"on_start,omitempty"`    // Run inside every container after it starts
+	Sync       []SyncEntry `yaml:"sync,omitempty"`        // Added to new containers and synced at create time
+	Setup      []string    `yaml:"setup,omitempty"`       // Run inside new containers before the initial snapshot
+	Privileged bool        `yaml:"privileged,omitempty"`  // Create containers privileged (asks for confirmation)
+	Nesting    *bool       `yaml:"nesting,omitempty"`     // Docker-in-LXC support for new containers (default: true)
+	AptProxy   string      `yaml:"apt_proxy,omitempty"`   // HTTP proxy written to new containers' apt config, e.g. an apt-cacher-ng
+	Ready      []string    `yaml:"ready,omitempty"`       // What up and WaitForReady wait for, e.g. systemd, port:5432
+
+	// StopTimeout is how long down waits for a clean shutdown, as a
+	// duration like "30s" (default: 5s)
+	StopTimeout string `yaml:"stop_timeout,omitempty"`
+
+	// Provisioned into new containers so their context shows on login
+	Motd         bool   `yaml:"motd,omitempty"`          // Banner with the project, container, synced files and ports
+	ShellHistory string `yaml:"shell_history,omitempty"` // Host directory keeping shell history, may use ${PROJECT_DIR}
+}
+
+// Dotfiles is a git repository with the user's shell and editor setup
+type Dotfiles struct {
+	Repo    string `yaml:"repo"`              // Cloned to ~/.dotfiles
+	Install string `yaml:"install,omitempty"` // Script in the repo to run instead of the default lookup
+}
+
+// ProjectDirVar is replaced with the directory containing containers.yaml
+// in mount sources
+const ProjectDirVar = "${PROJECT_DIR}"
+
+// Mount describes a host directory to mount into a container
+type Mount struct {
+	Source string `yaml:"source"`         // Host path, may use ${PROJECT_DIR}
+	Path   string `yaml:"path"`           // Path inside the container
+	Mode   string `yaml:"mode,omitempty"` // "ro" (default) or "rw"
+	Shift  bool   `yaml:"shift,omitempty"`
 }
 
 type Snapshot struct {
@@ -57,12 +230,45 @@ type SyncEntry struct {
 }
 
 type Container struct {
-	Image     string              `yaml:"image"`
-	Ports     []int               `yaml:"ports,omitempty"`
-	User      User                `yaml:"user,omitempty"`
-	Sync      []SyncEntry         `yaml:"sync,omitempty"`
-	Snapshots map[string]Snapshot `yaml:"snapshots,omitempty"`
-	Devices   map[string]Device   `yaml:"devices,omitempty"`
+	Image       string              `yaml:"image"`
+	Description string              `yaml:"description,omitempty"` // Free-form notes shown in list
+	Ports       []int               `yaml:"ports,omitempty"`
+	IP          string              `yaml:"ip,omitempty"` // Static IPv4 address on the managed bridge
+	User        User                `yaml:"user,omitempty"`
+	Sync        []SyncEntry         `yaml:"sync,omitempty"`
+	Snapshots   map[string]Snapshot `yaml:"snapshots,omitempty"`
+	Devices     map[string]Device   `yaml:"devices,omitempty"`
+	IDMap       []string            `yaml:"idmap,omitempty"`      // raw.idmap entries, e.g. "both 1000 1000"
+	Workdir     string              `yaml:"workdir,omitempty"`    // Where the project directory is mounted ($WORKDIR)
+	Disk        string              `yaml:"disk,omitempty"`       // Root disk size limit, e.g. "20GiB"
+	Dotfiles    *Dotfiles           `yaml:"dotfiles,omitempty"`   // Overrides defaults.dotfiles
+	OnStart     []string            `yaml:"on_start,omitempty"`   // Overrides defaults.on_start
+	Env         map[string]string   `yaml:"env,omitempty"`        // Exported inside the container
+	DependsOn   []string            `yaml:"depends_on,omitempty"` // Started before this container by up
+	Privileged  bool                `yaml:"privileged,omitempty"` // Created with security.privileged (root in the container is root on the host)
+	Nesting     *bool               `yaml:"nesting,omitempty"`    // Docker-in-LXC support; unset means it was enabled at create
+	Ready       []string            `yaml:"ready,omitempty"`      // Readiness conditions, overrides defaults.ready
+
+	// PendingSetup lists the provisioning steps (nesting, user, ssh,
+	// snapshot) a create hasn't finished; container repair runs them
+	PendingSetup []string `yaml:"pending_setup,omitempty"`
+
+	// StopTimeout overrides defaults.stop_timeout
+	StopTimeout string `yaml:"stop_timeout,omitempty"`
+
+	// Proxy is ProxyAuto to forward the container's ports while it runs;
+	// unset (or "manual") leaves it to the proxy command
+	Proxy string `yaml:"proxy,omitempty"`
+
+	// Set on the LXC container by container set
+	Limits    Limits `yaml:"limits,omitempty"`
+	Autostart bool   `yaml:"autostart,omitempty"` // boot.autostart: started when the host boots
+}
+
+// Limits are a container's resource limits (LXC limits.* keys)
+type Limits struct {
+	CPU    string `yaml:"cpu,omitempty"`    // CPU count (2) or CPU set (0-3)
+	Memory string `yaml:"memory,omitempty"` // Size (4GiB) or share of host memory (50%)
 }
 
 // Load reads the config from the given directory.
@@ -94,7 +300,7 @@ func Load(dir string) (*Config, error) {
 
 	// Validate configuration
 	if err := cfg.Validate(); err != nil {
-		return nil, fmt.Errorf("invalid configuration: %w", err)
+		return nil, errcode.Errorf(errcode.Validation, "", "invalid configuration: %w", err)
 	}
 
 	return &cfg, nil
@@ -107,43 +313,398 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid project name %q", c.Project)
 	}
 
+	if err := validateSecurity(c.Security); err != nil {
+		return fmt.Errorf("invalid security settings: %w", err)
+	}
+
+	if c.Naming != "" && c.Naming != NamingEscaped {
+		return fmt.Errorf("invalid naming %q: must be empty or %q", c.Naming, NamingEscaped)
+	}
+
+	// Validate DNS settings
+	if err := dns.ValidateMode(c.DNS.Mode); err != nil {
+		return err
+	}
+	if err := dns.ValidateDomain(c.DNS.Domain); err != nil {
+		return err
+	}
+
+	if c.ImageRemote != "" {
+		if err := validation.ValidateRemoteName(c.ImageRemote); err != nil {
+			return fmt.Errorf("invalid image_remote: %w", err)
+		}
+	}
+
 	// Validate default ports
 	if err := validation.ValidatePorts(c.Defaults.Ports); err != nil {
 		return fmt.Errorf("invalid default ports: %w", err)
 	}
 
+	if c.Defaults.Workdir != "" {
+		if err := validation.ValidateContainerPath(c.Defaults.Workdir); err != nil {
+			return fmt.Errorf("invalid default workdir: %w", err)
+		}
+	}
+
+	if err := validateUser(c.Defaults.User); err != nil {
+		return fmt.Errorf("invalid default user: %w", err)
+	}
+
+	if c.Defaults.Dotfiles != nil {
+		if err := validateDotfiles(*c.Defaults.Dotfiles); err != nil {
+			return fmt.Errorf("invalid default dotfiles: %w", err)
+		}
+	}
+
+	for event, command := range c.Hooks.Events() {
+		if _, err := ParseHook(event, command); err != nil {
+			return fmt.Errorf("invalid %s hook: %w", event, err)
+		}
+	}
+
+	if err := validateOnStart(c.Defaults.OnStart); err != nil {
+		return fmt.Errorf("invalid default on_start: %w", err)
+	}
+
+	if c.Defaults.Image != "" && (strings.HasPrefix(c.Defaults.Image, "-") || containsControlChars(c.Defaults.Image)) {
+		return fmt.Errorf("invalid default image %q", c.Defaults.Image)
+	}
+
+	if c.Defaults.AptProxy != "" {
+		if err := validation.ValidateProxyURL(c.Defaults.AptProxy); err != nil {
+			return fmt.Errorf("invalid default apt_proxy: %w", err)
+		}
+	}
+
+	if err := validateReady(c.Defaults.Ready); err != nil {
+		return fmt.Errorf("invalid default ready: %w", err)
+	}
+
+	if err := validateStopTimeout(c.Defaults.StopTimeout); err != nil {
+		return fmt.Errorf("invalid default stop_timeout: %w", err)
+	}
+
+	if err := validateOnStart(c.Defaults.Setup); err != nil {
+		return fmt.Errorf("invalid default setup: %w", err)
+	}
+
+	for i, entry := range c.Defaults.Sync {
+		if err := ValidateSyncEntry(entry); err != nil {
+			return fmt.Errorf("default sync %d: %w", i+1, err)
+		}
+	}
+
+	// Validate default mounts
+	for i, m := range c.Defaults.Mounts {
+		if err := validateMount(m); err != nil {
+			return fmt.Errorf("default mount %d: %w", i+1, err)
+		}
+	}
+
+	// Validate volumes
+	for name := range c.Volumes {
+		if err := validation.ValidateMountName(name); err != nil {
+			return fmt.Errorf("volume '%s': %w", name, err)
+		}
+	}
+
 	// Validate each container
+	ips := make(map[string]string)
 	for name, container := range c.Containers {
-		if err := validation.ValidateFullContainerName(c.Project, name); err != nil {
+		if err := validation.ValidateFullContainerName(c.LXCProject(), name); err != nil {
+			return fmt.Errorf("container '%s': %w", name, err)
+		}
+
+		if err := ValidateContainer(container); err != nil {
 			return fmt.Errorf("container '%s': %w", name, err)
 		}
 
-		if len(container.Ports) > 0 {
-			if err := validation.ValidatePorts(container.Ports); err != nil {
-				return fmt.Errorf("container '%s': %w", name, err)
+		if container.IP != "" {
+			if other, taken := ips[container.IP]; taken {
+				return fmt.Errorf("container '%s': IP %s is already assigned to '%s'", name, container.IP, other)
 			}
+			ips[container.IP] = name
 		}
 
-		// Validate devices
-		for deviceName, device := range container.Devices {
-			if err := validateDevice(deviceName, device); err != nil {
-				return fmt.Errorf("container '%s' device '%s': %w", name, deviceName, err)
+		for _, dep := range container.DependsOn {
+			if _, ok := c.Containers[dep]; !ok || dep == name {
+				return fmt.Errorf("container '%s': depends_on '%s' is not another container in the project", name, dep)
+			}
+		}
+	}
+
+	c.warnSyncSources()
+	return nil
+}
+
+// ValidateContainer checks a container definition on its own: user,
+// sync entries, env var names, devices and the other settings. What
+// depends on the rest of the project (LXC name length, IPs taken by other
+// containers, depends_on) is left to Validate.
+func ValidateContainer(container Container) error {
+	if len(container.Ports) > 0 {
+		if err := validation.ValidatePorts(container.Ports); err != nil {
+			return err
+		}
+	}
+
+	if container.IP != "" {
+		if err := validation.ValidateIPv4(container.IP); err != nil {
+			return err
+		}
+	}
+
+	if containsControlChars(container.Description) {
+		return fmt.Errorf("description must be a single line")
+	}
+
+	if container.Disk != "" {
+		if _, err := validation.ParseSize(container.Disk); err != nil {
+			return fmt.Errorf("invalid disk: %w", err)
+		}
+	}
+
+	if container.Limits.CPU != "" {
+		if err := validation.ValidateCPULimit(container.Limits.CPU); err != nil {
+			return err
+		}
+	}
+	if container.Limits.Memory != "" {
+		if err := validation.ValidateMemoryLimit(container.Limits.Memory); err != nil {
+			return err
+		}
+	}
+
+	if container.Workdir != "" {
+		if err := validation.ValidateContainerPath(container.Workdir); err != nil {
+			return fmt.Errorf("invalid workdir: %w", err)
+		}
+	}
+
+	if err := validateUser(container.User); err != nil {
+		return fmt.Errorf("invalid user: %w", err)
+	}
+
+	if container.Dotfiles != nil {
+		if err := validateDotfiles(*container.Dotfiles); err != nil {
+			return fmt.Errorf("invalid dotfiles: %w", err)
+		}
+	}
+
+	if err := validateOnStart(container.OnStart); err != nil {
+		return fmt.Errorf("invalid on_start: %w", err)
+	}
+
+	if err := validateReady(container.Ready); err != nil {
+		return err
+	}
+
+	if err := validateStopTimeout(container.StopTimeout); err != nil {
+		return fmt.Errorf("invalid stop_timeout: %w", err)
+	}
+
+	if container.Proxy != "" && container.Proxy != ProxyManual && container.Proxy != ProxyAuto {
+		return fmt.Errorf("invalid proxy %q: must be %s or %s", container.Proxy, ProxyAuto, ProxyManual)
+	}
+
+	for _, entry := range container.IDMap {
+		if err := validation.ValidateIDMapEntry(entry); err != nil {
+			return err
+		}
+	}
+
+	for key := range container.Env {
+		if err := validation.ValidateEnvName(key); err != nil {
+			return err
+		}
+	}
+
+	for i, entry := range container.Sync {
+		if err := ValidateSyncEntry(entry); err != nil {
+			return fmt.Errorf("sync %d: %w", i+1, err)
+		}
+	}
+
+	for deviceName, device := range container.Devices {
+		if err := ValidateDevice(deviceName, device); err != nil {
+			return fmt.Errorf("device '%s': %w", deviceName, err)
+		}
+	}
+
+	return nil
+}
+
+// validateUser checks the name, shell, timezone, locale and groups of a user
+func validateUser(u User) error {
+	if u.Name != "" {
+		if err := validation.ValidateUsername(u.Name); err != nil {
+			return err
+		}
+	}
+	for _, group := range u.Groups {
+		if err := validation.ValidateGroupName(group); err != nil {
+			return err
+		}
+	}
+	if u.Shell != "" {
+		if err := validation.ValidateShell(u.Shell); err != nil {
+			return err
+		}
+	}
+	if u.Timezone != "" {
+		if err := validation.ValidateTimezone(u.Timezone); err != nil {
+			return err
+		}
+	}
+	if u.Locale != "" {
+		if err := validation.ValidateLocale(u.Locale); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateOnStart checks that no on_start command is blank
+func validateOnStart(commands []string) error {
+	for i, command := range commands {
+		if strings.TrimSpace(command) == "" {
+			return fmt.Errorf("command %d is empty", i+1)
+		}
+	}
+	return nil
+}
+
+// validateStopTimeout checks a stop_timeout, which may be empty
+func validateStopTimeout(timeout string) error {
+	if timeout == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(timeout)
+	if err != nil {
+		return fmt.Errorf("%q is not a duration like 30s or 2m", timeout)
+	}
+	if d < 0 {
+		return fmt.Errorf("%q must not be negative", timeout)
+	}
+	return nil
+}
+
+// validateReady checks a list of readiness conditions
+func validateReady(conditions []string) error {
+	for _, cond := range conditions {
+		if _, err := validation.ParseReadyCondition(cond); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateDotfiles checks a dotfiles repository and install script
+func validateDotfiles(d Dotfiles) error {
+	if d.Repo == "" {
+		return fmt.Errorf("repo must not be empty")
+	}
+	if strings.HasPrefix(d.Repo, "-") || containsControlChars(d.Repo) {
+		return fmt.Errorf("invalid repo %q", d.Repo)
+	}
+	if d.Install != "" {
+		if filepath.IsAbs(d.Install) || strings.HasPrefix(d.Install, "-") || containsControlChars(d.Install) {
+			return fmt.Errorf("install must be a path inside the repo, got %q", d.Install)
+		}
+		for _, part := range strings.Split(filepath.ToSlash(d.Install), "/") {
+			if part == ".." {
+				return fmt.Errorf("install must be a path inside the repo, got %q", d.Install)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateSyncEntry checks a sync entry has a single-line source and an
+// absolute destination in the container. Whether the source exists is
+// checked when it is synced.
+func ValidateSyncEntry(entry SyncEntry) error {
+	if entry.Source == "" {
+		return errcode.Errorf(errcode.Validation, "", "source must not be empty")
+	}
+	if containsControlChars(entry.Source) {
+		return errcode.Errorf(errcode.Validation, "", "source %q must not contain control characters", entry.Source)
+	}
+	if err := validation.ValidateContainerPath(entry.Dest); err != nil {
+		return fmt.Errorf("invalid dest: %w", err)
+	}
+	return nil
+}
+
+// SyncSourceOutsideProject reports whether entry's source, resolved the way
+// sync resolves it, lies outside the project directory. That's allowed, but
+// the files then aren't part of the project.
+func (c *Config) SyncSourceOutsideProject(entry SyncEntry) bool {
+	dir := c.ProjectDir()
+	source := entry.Source
+	if !filepath.IsAbs(source) {
+		source = filepath.Join(dir, source)
+	}
+	rel, err := filepath.Rel(dir, source)
+	return err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// warnSyncSources warns about sync sources outside the project directory,
+// once the config knows where that is
+func (c *Config) warnSyncSources() {
+	if c.Dir == "" {
+		return
+	}
+	for _, entry := range c.Defaults.Sync {
+		if c.SyncSourceOutsideProject(entry) {
+			slog.Warn("sync source is outside the project directory", "source", entry.Source)
+		}
+	}
+	for name, container := range c.Containers {
+		for _, entry := range container.Sync {
+			if c.SyncSourceOutsideProject(entry) {
+				slog.Warn("sync source is outside the project directory", "container", name, "source", entry.Source)
 			}
 		}
 	}
+}
 
+// validateMount checks a mount definition; the source is checked when it is applied
+func validateMount(m Mount) error {
+	if m.Source == "" {
+		return errcode.Errorf(errcode.Validation, "", "source must not be empty")
+	}
+	if err := validation.ValidateContainerPath(m.Path); err != nil {
+		return err
+	}
+	switch m.Mode {
+	case "", "ro", "rw":
+	default:
+		return fmt.Errorf("invalid mode %q (allowed: ro, rw)", m.Mode)
+	}
 	return nil
 }
 
-// validateDevice validates a single device configuration
-func validateDevice(name string, device Device) error {
+// ValidateDevice validates a single device configuration
+func ValidateDevice(name string, device Device) error {
+	if validation.IsReservedMountName(name) {
+		return errcode.Errorf(errcode.Validation, "", "'%s' is a reserved device name", name)
+	}
+
 	// Device type must not be empty
 	if device.Type == "" {
 		return fmt.Errorf("device type must not be empty")
 	}
 
+	switch device.Type {
+	case validation.DeviceTypeUSB:
+		return validateUSBDevice(device)
+	case validation.DeviceTypeUnixChar:
+		return validateUnixCharDevice(device)
+	}
+
 	// For disk devices, validate required fields
-	if device.Type == "disk" {
+	if device.Type == validation.DeviceTypeDisk {
 		if device.Config == nil {
 			return fmt.Errorf("disk device requires 'source' config key")
 		}
@@ -164,8 +725,69 @@ func validateDevice(name string, device Device) error {
 		if containsControlChars(path) {
 			return fmt.Errorf("path contains control characters")
 		}
+
+		if recursive, ok := device.Config["recursive"]; ok && recursive != "true" && recursive != "false" {
+			return fmt.Errorf("recursive must be \"true\" or \"false\", got %q", recursive)
+		}
+		if propagation, ok := device.Config["propagation"]; ok {
+			if err := validation.ValidatePropagation(propagation); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateUSBDevice validates a usb device (vendorid required, productid optional)
+func validateUSBDevice(device Device) error {
+	vendorID := device.Config["vendorid"]
+	if vendorID == "" {
+		return fmt.Errorf("usb device requires 'vendorid' config key")
+	}
+	if err := validation.ValidateUSBID(vendorID); err != nil {
+		return err
+	}
+	if productID, ok := device.Config["productid"]; ok {
+		if err := validation.ValidateUSBID(productID); err != nil {
+			return err
+		}
 	}
+	return validateOwnership(device)
+}
+
+// validateUnixCharDevice validates a unix-char device (source or path required)
+func validateUnixCharDevice(device Device) error {
+	source := device.Config["source"]
+	path := device.Config["path"]
+	if source == "" && path == "" {
+		return fmt.Errorf("unix-char device requires 'source' or 'path' config key")
+	}
+	if source != "" {
+		if err := validation.ValidateHostDevicePath(source); err != nil {
+			return err
+		}
+	}
+	if path != "" && !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("path must be absolute: %s", path)
+	}
+	return validateOwnership(device)
+}
 
+// validateOwnership validates optional mode/uid/gid keys shared by usb and unix-char devices
+func validateOwnership(device Device) error {
+	if mode, ok := device.Config["mode"]; ok {
+		if err := validation.ValidateFileMode(mode); err != nil {
+			return err
+		}
+	}
+	for _, key := range []string{"uid", "gid"} {
+		if value, ok := device.Config[key]; ok {
+			if _, err := strconv.Atoi(value); err != nil {
+				return fmt.Errorf("invalid %s %q: must be numeric", key, value)
+			}
+		}
+	}
 	return nil
 }
 
@@ -179,24 +801,63 @@ func containsControlChars(s string) bool {
 	return false
 }
 
+// LXCProject returns the project name as it appears in LXC names: with its
+// hyphens doubled under NamingEscaped, as is otherwise
+func (c *Config) LXCProject() string {
+	if c.Naming == NamingEscaped {
+		return strings.ReplaceAll(c.Project, "-", "--")
+	}
+	return c.Project
+}
+
 // GetLXCName returns the full LXC container name with project prefix
 func (c *Config) GetLXCName(shortName string) string {
 	if c.Project == "" {
 		return shortName
 	}
-	return c.Project + "-" + shortName
+	return c.LXCProject() + "-" + shortName
+}
+
+// GetVolumeLXCName returns the LXD name for a project volume
+func (c *Config) GetVolumeLXCName(name string) string {
+	return c.GetLXCName(name)
 }
 
-// GetShortName extracts short name from LXC name by stripping project prefix
-func (c *Config) GetShortName(lxcName string) string {
+// GetShortName extracts the short name from an LXC name by stripping the
+// project prefix. ok is false when the name doesn't belong to this project:
+// it lacks the prefix, or what follows isn't a valid container name (e.g.
+// "web--app-api" for project "web").
+func (c *Config) GetShortName(lxcName string) (name string, ok bool) {
 	if c.Project == "" {
-		return lxcName
+		return lxcName, true
+	}
+	name, ok = strings.CutPrefix(lxcName, c.LXCProject()+"-")
+	if !ok || validation.ValidateContainerName(name) != nil {
+		return "", false
+	}
+	return name, true
+}
+
+// ProjectDir returns the absolute directory containing containers.yaml
+func (c *Config) ProjectDir() string {
+	dir := c.Dir
+	if dir == "" {
+		dir = "."
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		return abs
 	}
-	prefix := c.Project + "-"
-	if strings.HasPrefix(lxcName, prefix) {
-		return strings.TrimPrefix(lxcName, prefix)
+	return dir
+}
+
+// ExpandMountSource replaces ${PROJECT_DIR} in a mount source and resolves
+// relative sources against the project directory
+func (c *Config) ExpandMountSource(source string) string {
+	source = strings.ReplaceAll(source, ProjectDirVar, c.ProjectDir())
+	if !filepath.IsAbs(source) && !strings.HasPrefix(source, "~") {
+		source = filepath.Join(c.ProjectDir(), source)
 	}
-	return lxcName
+	return source
 }
 
 // HasProject returns true if project is initialized
@@ -235,6 +896,13 @@ func (c *Config) Save() error {
 	}
 	configPath := filepath.Join(dir, ConfigFile)
 
+	if c.detached {
+		return nil
+	}
+	if c.scope != "" {
+		return c.saveScoped(dir)
+	}
+
 	data, err := yaml.Marshal(c)
 	if err != nil {
 		return err
@@ -242,12 +910,59 @@ func (c *Config) Save() error {
 	return atomicWriteFile(configPath, data, 0644)
 }
 
-// atomicWriteFile writes data to a file atomically using temp file + rename.
-// This prevents corruption from partial writes if the process is interrupted.
-func atomicWriteFile(filename string, data []byte, perm os.FileMode) error {
-	dir := filepath.Dir(filename)
-	if dir == "" {
-		dir = "."
+// saveScoped writes only the scoped container's entry, merged into the
+// current file so concurrent saves for other containers aren't lost
+func (c *Config) saveScoped(dir string) error {
+	writeLock, err := lockFileWithTimeout(filepath.Join(dir, lockDir, writeLockFile), syscall.LOCK_EX)
+	if err != nil {
+		return err
+	}
+	defer unlockFile(writeLock)
+
+	current, err := Load(dir)
+	if err != nil {
+		return fmt.Errorf("failed to reload config: %w", err)
+	}
+	if container, ok := c.Containers[c.scope]; ok {
+		current.Containers[c.scope] = container
+	} else {
+		// Also drops it from the other containers' depends_on, which
+		// would otherwise fail validation on the next load
+		current.RemoveContainer(c.scope)
+	}
+
+	data, err := yaml.Marshal(current)
+	if err != nil {
+		return err
+	}
+	return atomicWriteFile(filepath.Join(dir, ConfigFile), data, 0644)
+}
+
+// atomicWriteFile writes data to a file atomically using temp file + rename.
+// This prevents corruption from partial writes if the process is interrupted.
+// The previous contents are kept as a backup under historyDir first.
+func atomicWriteFile(filename string, data []byte, perm os.FileMode) error {
+	if dryrun.Enabled() {
+		return replaceFile(filename, data, perm)
+	}
+	if err := backupFile(filename, data); err != nil {
+		return fmt.Errorf("failed to back up %s: %w", filepath.Base(filename), err)
+	}
+	return replaceFile(filename, data, perm)
+}
+
+// replaceFile is atomicWriteFile without the backup
+func replaceFile(filename string, data []byte, perm os.FileMode) error {
+	if dryrun.Enabled() {
+		current, _ := os.ReadFile(filename)
+		if changes := dryrun.Changes(string(current), string(data)); len(changes) > 0 {
+			dryrun.Printf("write %s:\n%s", filepath.Base(filename), strings.Join(changes, "\n"))
+		}
+		return nil
+	}
+	dir := filepath.Dir(filename)
+	if dir == "" {
+		dir = "."
 	}
 
 	tmp, err := os.CreateTemp(dir, ".containers.yaml.tmp.*")
@@ -289,10 +1004,133 @@ func atomicWriteFile(filename string, data []byte, perm os.FileMode) error {
 	return nil
 }
 
-// ConfigLock represents an exclusive lock on the config file.
+// backupFile copies the current contents of filename into historyDir,
+// unless it doesn't exist yet or already holds data, then prunes old backups
+func backupFile(filename string, data []byte) error {
+	current, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(current, data) {
+		return nil
+	}
+
+	dir := filepath.Join(filepath.Dir(filename), historyDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	name := filepath.Base(filename) + "." + time.Now().Format(backupTimeFormat)
+	if err := os.WriteFile(filepath.Join(dir, name), current, 0644); err != nil {
+		return err
+	}
+
+	backups, err := listBackups(filepath.Dir(filename))
+	if err != nil {
+		return err
+	}
+	for len(backups) > maxBackups {
+		os.Remove(backups[len(backups)-1].Path)
+		backups = backups[:len(backups)-1]
+	}
+	return nil
+}
+
+// backupTimeFormat sorts lexically in time order
+const backupTimeFormat = "20060102-150405.000000"
+
+// Backup is a saved copy of containers.yaml
+type Backup struct {
+	Path string
+	Time time.Time
+}
+
+// Backups lists the saved copies of containers.yaml in dir, newest first.
+// If dir is empty, it uses the current working directory.
+func Backups(dir string) ([]Backup, error) {
+	if dir == "" {
+		dir = "."
+	}
+	return listBackups(dir)
+}
+
+func listBackups(dir string) ([]Backup, error) {
+	entries, err := os.ReadDir(filepath.Join(dir, historyDir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []Backup
+	for _, e := range entries {
+		stamp, ok := strings.CutPrefix(e.Name(), ConfigFile+".")
+		if !ok || e.IsDir() {
+			continue
+		}
+		t, err := time.ParseInLocation(backupTimeFormat, stamp, time.Local)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, Backup{Path: filepath.Join(dir, historyDir, e.Name()), Time: t})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Time.After(backups[j].Time) })
+	return backups, nil
+}
+
+// Undo restores containers.yaml from the newest backup and removes that
+// backup, so calling it again steps further back. The restored file must
+// still be a valid configuration.
+// If dir is empty, it uses the current working directory.
+func Undo(dir string) (Backup, error) {
+	if dir == "" {
+		dir = "."
+	}
+	backups, err := listBackups(dir)
+	if err != nil {
+		return Backup{}, err
+	}
+	if len(backups) == 0 {
+		return Backup{}, fmt.Errorf("no backups of %s to restore", ConfigFile)
+	}
+	latest := backups[0]
+
+	data, err := os.ReadFile(latest.Path)
+	if err != nil {
+		return Backup{}, err
+	}
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return Backup{}, fmt.Errorf("backup %s is not valid YAML: %w", filepath.Base(latest.Path), err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return Backup{}, fmt.Errorf("backup %s is not a valid configuration: %w", filepath.Base(latest.Path), err)
+	}
+
+	if err := replaceFile(filepath.Join(dir, ConfigFile), data, 0644); err != nil {
+		return Backup{}, err
+	}
+	if dryrun.Enabled() {
+		return latest, nil
+	}
+	if err := os.Remove(latest.Path); err != nil {
+		return Backup{}, err
+	}
+	return latest, nil
+}
+
+// ConfigLock represents a lock on the config file.
 // Use this when performing Load→Modify→Save operations to prevent race conditions.
+//
+// A project lock (AcquireLock) is exclusive. A container lock
+// (AcquireContainerLock) holds the project lock shared plus an exclusive
+// lock for one container, so operations on different containers can run at
+// the same time while anything project-wide waits for all of them.
 type ConfigLock struct {
-	file *os.File
+	files []*os.File
 }
 
 // AcquireLock acquires an exclusive lock on the config file with timeout.
@@ -301,36 +1139,253 @@ func AcquireLock(dir string) (*ConfigLock, error) {
 	if dir == "" {
 		dir = "."
 	}
-	lockPath := filepath.Join(dir, lockFile)
-	f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0644)
+	f, err := lockFileWithTimeout(filepath.Join(dir, lockFile), syscall.LOCK_EX)
+	if err != nil {
+		return nil, err
+	}
+	return &ConfigLock{files: []*os.File{f}}, nil
+}
+
+// AcquireContainerLock locks a single container's config entry with timeout.
+// If dir is empty, it uses the current working directory.
+func AcquireContainerLock(dir, name string) (*ConfigLock, error) {
+	if dir == "" {
+		dir = "."
+	}
+	// The name becomes a file name, so it must be a plain container name
+	if err := validation.ValidateContainerName(name); err != nil {
+		return nil, fmt.Errorf("invalid container name: %w", err)
+	}
+	project, err := lockFileWithTimeout(filepath.Join(dir, lockFile), syscall.LOCK_SH)
+	if err != nil {
+		return nil, err
+	}
+	container, err := lockFileWithTimeout(filepath.Join(dir, lockDir, name+".lock"), syscall.LOCK_EX)
+	if err != nil {
+		unlockFile(project)
+		return nil, fmt.Errorf("container '%s': %w", name, err)
+	}
+	return &ConfigLock{files: []*os.File{project, container}}, nil
+}
+
+// lockFileWithTimeout opens path (creating it and its directory if needed)
+// and flocks it with how (LOCK_SH or LOCK_EX), retrying until lockTimeout.
+// Exclusive holders record themselves in the file so a stuck lock can be
+// traced to its owner.
+func lockFileWithTimeout(path string, how int) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open lock file: %w", err)
 	}
 
 	deadline := time.Now().Add(lockTimeout)
 	for {
-		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
-		if err == nil {
-			break
+		err := syscall.Flock(int(f.Fd()), how|syscall.LOCK_NB)
+		if err == nil || (!errors.Is(err, syscall.EWOULDBLOCK) && lockByOwnerRecord(f, how)) {
+			if how == syscall.LOCK_EX {
+				writeLockOwner(f)
+			}
+			return f, nil
 		}
 		if time.Now().After(deadline) {
 			f.Close()
-			return nil, fmt.Errorf("timeout waiting for config lock (another instance may be running)")
+			if owner := readLockOwner(path); owner != nil {
+				return nil, errcode.Errorf(errcode.Locked, "", "timeout waiting for config lock held by %s (see 'lock status', or use --force-unlock if it is stale)", owner)
+			}
+			return nil, errcode.Errorf(errcode.Locked, "", "timeout waiting for config lock (another instance may be running)")
 		}
 		time.Sleep(100 * time.Millisecond)
 	}
+}
+
+// lockByOwnerRecord is the fallback for filesystems without flock support
+// (some network mounts): an exclusive lock is free when the file records no
+// owner or a dead one. Shared locks can't be tracked this way and always
+// succeed.
+func lockByOwnerRecord(f *os.File, how int) bool {
+	if how != syscall.LOCK_EX {
+		return true
+	}
+	owner := readLockOwner(f.Name())
+	return owner == nil || owner.Dead()
+}
+
+func unlockFile(f *os.File) error {
+	// Clear our owner record so the file doesn't look held or stale
+	if owner := readLockOwner(f.Name()); owner != nil && owner.isCurrentProcess() {
+		f.Truncate(0)
+	}
+	syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+	return f.Close()
+}
+
+// LockOwner identifies the process holding an exclusive config lock
+type LockOwner struct {
+	PID     int       `yaml:"pid"`
+	Host    string    `yaml:"host"`
+	Since   time.Time `yaml:"since"`
+	Command string    `yaml:"command,omitempty"`
+}
+
+func (o *LockOwner) String() string {
+	s := fmt.Sprintf("pid %d on %s since %s", o.PID, o.Host, o.Since.Local().Format("2006-01-02 15:04:05"))
+	if o.Command != "" {
+		s += fmt.Sprintf(" (%s)", o.Command)
+	}
+	return s
+}
+
+// Dead reports whether the owner is known to be gone: it ran on this host
+// and no process with its PID exists anymore. Owners on other hosts can't
+// be checked and are never considered dead.
+func (o *LockOwner) Dead() bool {
+	host, _ := os.Hostname()
+	if o.Host != host || o.PID <= 0 {
+		return false
+	}
+	err := syscall.Kill(o.PID, 0)
+	return errors.Is(err, syscall.ESRCH)
+}
+
+func (o *LockOwner) isCurrentProcess() bool {
+	host, _ := os.Hostname()
+	return o.Host == host && o.PID == os.Getpid()
+}
+
+func writeLockOwner(f *os.File) {
+	host, _ := os.Hostname()
+	data, err := yaml.Marshal(LockOwner{
+		PID:     os.Getpid(),
+		Host:    host,
+		Since:   time.Now(),
+		Command: strings.Join(os.Args, " "),
+	})
+	if err != nil {
+		return
+	}
+	f.Truncate(0)
+	f.WriteAt(data, 0)
+}
+
+// readLockOwner returns the owner recorded in a lock file, or nil
+func readLockOwner(path string) *LockOwner {
+	data, err := os.ReadFile(path)
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+	var owner LockOwner
+	if err := yaml.Unmarshal(data, &owner); err != nil || owner.PID == 0 {
+		return nil
+	}
+	return &owner
+}
+
+// LockInfo describes one lock file of a project
+type LockInfo struct {
+	Container string // Empty for the project lock
+	Path      string
+	Held      bool       // Some process currently holds the lock
+	Owner     *LockOwner // Last exclusive owner recorded in the file, if any
+}
+
+// Stale reports whether the lock file names an owner that no longer holds
+// it, e.g. because the process was killed before it could clean up
+func (l LockInfo) Stale() bool {
+	if l.Owner == nil {
+		return false
+	}
+	return !l.Held || l.Owner.Dead()
+}
+
+// LockStatus reports the project lock and every container lock in dir.
+// If dir is empty, it uses the current working directory.
+func LockStatus(dir string) ([]LockInfo, error) {
+	if dir == "" {
+		dir = "."
+	}
+
+	paths := []string{filepath.Join(dir, lockFile)}
+	entries, err := os.ReadDir(filepath.Join(dir, lockDir))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".lock") && e.Name() != writeLockFile {
+			paths = append(paths, filepath.Join(dir, lockDir, e.Name()))
+		}
+	}
+
+	var locks []LockInfo
+	for i, path := range paths {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		}
+		info := LockInfo{Path: path, Held: lockHeld(path), Owner: readLockOwner(path)}
+		if i > 0 {
+			info.Container = strings.TrimSuffix(filepath.Base(path), ".lock")
+		}
+		locks = append(locks, info)
+	}
+	return locks, nil
+}
+
+// lockHeld probes whether any process holds a flock on path
+func lockHeld(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err == nil {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		return false
+	}
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return true
+	}
+	// No flock support: go by the owner record
+	owner := readLockOwner(path)
+	return owner != nil && !owner.Dead()
+}
 
-	return &ConfigLock{file: f}, nil
+// ForceUnlock removes the project's stale lock files (see LockInfo.Stale)
+// so the next command can lock it again. Locks some process still holds
+// are left alone: removing one would let the next command lock a new file
+// while the holder keeps the old one. Returns the removed paths and those
+// of the held locks that were kept.
+// If dir is empty, it uses the current working directory.
+func ForceUnlock(dir string) (removed, held []string, err error) {
+	locks, err := LockStatus(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, l := range locks {
+		switch {
+		case l.Held:
+			held = append(held, l.Path)
+		case l.Stale():
+			if err := os.Remove(l.Path); err != nil && !os.IsNotExist(err) {
+				return removed, held, err
+			}
+			removed = append(removed, l.Path)
+		}
+	}
+	return removed, held, nil
 }
 
 // Release releases the config lock.
 func (l *ConfigLock) Release() error {
-	if l.file == nil {
-		return nil
+	var err error
+	for i := len(l.files) - 1; i >= 0; i-- {
+		if e := unlockFile(l.files[i]); e != nil && err == nil {
+			err = e
+		}
 	}
-	syscall.Flock(int(l.file.Fd()), syscall.LOCK_UN)
-	err := l.file.Close()
-	l.file = nil
+	l.files = nil
 	return err
 }
 
@@ -352,14 +1407,302 @@ func LoadWithLock(dir string) (*Config, *ConfigLock, error) {
 	return cfg, lock, nil
 }
 
+// LoadWithContainerLock loads the config while holding a lock on one
+// container. Save then writes back only that container's entry (adding,
+// updating or removing it); changes to anything else are not saved.
+// The caller must call Release() on the returned lock when done.
+func LoadWithContainerLock(dir, name string) (*Config, *ConfigLock, error) {
+	lock, err := AcquireContainerLock(dir, name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cfg, err := Load(dir)
+	if err != nil {
+		lock.Release()
+		return nil, nil, err
+	}
+	cfg.scope = name
+
+	return cfg, lock, nil
+}
+
 func (c *Config) AddContainer(name, image string) {
 	c.Containers[name] = Container{
 		Image: image,
 	}
 }
 
+// RemoveContainer removes a container and drops it from the depends_on
+// of the others
 func (c *Config) RemoveContainer(name string) {
 	delete(c.Containers, name)
+	for other, container := range c.Containers {
+		deps := container.DependsOn[:0:0]
+		for _, dep := range container.DependsOn {
+			if dep != name {
+				deps = append(deps, dep)
+			}
+		}
+		if len(deps) != len(container.DependsOn) {
+			container.DependsOn = deps
+			if len(deps) == 0 {
+				container.DependsOn = nil
+			}
+			c.Containers[other] = container
+		}
+	}
+}
+
+// SetContainerIP records the static IP for a container
+func (c *Config) SetContainerIP(name, ip string) bool {
+	container, ok := c.Containers[name]
+	if !ok {
+		return false
+	}
+	container.IP = ip
+	c.Containers[name] = container
+	return true
+}
+
+// FindContainerByIP returns the container that has the given static IP
+func (c *Config) FindContainerByIP(ip string) (string, bool) {
+	for name, container := range c.Containers {
+		if container.IP == ip {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// SetContainerDescription records the notes for a container.
+// Returns false if the container doesn't exist.
+func (c *Config) SetContainerDescription(name, description string) bool {
+	container, ok := c.Containers[name]
+	if !ok {
+		return false
+	}
+	container.Description = description
+	c.Containers[name] = container
+	return true
+}
+
+// SetContainerWorkdir records where the project directory is mounted.
+// Returns false if the container doesn't exist.
+func (c *Config) SetContainerWorkdir(name, path string) bool {
+	container, ok := c.Containers[name]
+	if !ok {
+		return false
+	}
+	container.Workdir = path
+	c.Containers[name] = container
+	return true
+}
+
+// AddVolume records a project volume
+func (c *Config) AddVolume(name string, volume Volume) {
+	if c.Volumes == nil {
+		c.Volumes = make(map[string]Volume)
+	}
+	c.Volumes[name] = volume
+}
+
+// RemoveVolume removes a project volume
+func (c *Config) RemoveVolume(name string) {
+	delete(c.Volumes, name)
+}
+
+// HasVolume returns true if the project has a volume with this name
+func (c *Config) HasVolume(name string) bool {
+	_, ok := c.Volumes[name]
+	return ok
+}
+
+// FindVolumeAttachments returns container -> device name for every
+// container that has the given volume attached
+func (c *Config) FindVolumeAttachments(name string) map[string]string {
+	volume, ok := c.Volumes[name]
+	if !ok {
+		return nil
+	}
+	pool := volume.Pool
+	if pool == "" {
+		pool = DefaultVolumePool
+	}
+	lxcName := c.GetVolumeLXCName(name)
+
+	attachments := make(map[string]string)
+	for containerName, container := range c.Containers {
+		for deviceName, device := range container.Devices {
+			if device.Type == "disk" && device.Config["pool"] == pool && device.Config["source"] == lxcName {
+				attachments[containerName] = deviceName
+			}
+		}
+	}
+	return attachments
+}
+
+// SetContainerDisk records the root disk size limit.
+// Returns false if the container doesn't exist.
+func (c *Config) SetContainerDisk(name, size string) bool {
+	container, ok := c.Containers[name]
+	if !ok {
+		return false
+	}
+	container.Disk = size
+	c.Containers[name] = container
+	return true
+}
+
+// SetContainerNesting records whether a container has Docker-in-LXC support
+func (c *Config) SetContainerNesting(name string, enabled bool) bool {
+	container, ok := c.Containers[name]
+	if !ok {
+		return false
+	}
+	container.Nesting = &enabled
+	c.Containers[name] = container
+	return true
+}
+
+// SetContainerPendingSetup records the provisioning steps a container
+// still needs; an empty list clears the marker
+func (c *Config) SetContainerPendingSetup(name string, steps []string) bool {
+	container, ok := c.Containers[name]
+	if !ok {
+		return false
+	}
+	container.PendingSetup = steps
+	if len(steps) == 0 {
+		container.PendingSetup = nil
+	}
+	c.Containers[name] = container
+	return true
+}
+
+// SetContainerPrivileged records that a container was created privileged
+func (c *Config) SetContainerPrivileged(name string, privileged bool) bool {
+	container, ok := c.Containers[name]
+	if !ok {
+		return false
+	}
+	container.Privileged = privileged
+	c.Containers[name] = container
+	return true
+}
+
+// SetContainerOnStart records the commands run inside a container after
+// it starts. Returns false if the container doesn't exist.
+func (c *Config) SetContainerOnStart(name string, commands []string) bool {
+	container, ok := c.Containers[name]
+	if !ok {
+		return false
+	}
+	container.OnStart = commands
+	c.Containers[name] = container
+	return true
+}
+
+// SetContainerEnv records the environment variables exported in a
+// container. Returns false if the container doesn't exist.
+func (c *Config) SetContainerEnv(name string, env map[string]string) bool {
+	container, ok := c.Containers[name]
+	if !ok {
+		return false
+	}
+	container.Env = env
+	c.Containers[name] = container
+	return true
+}
+
+// SetContainerDependsOn records the containers started before this one.
+// Returns false if the container doesn't exist.
+func (c *Config) SetContainerDependsOn(name string, deps []string) bool {
+	container, ok := c.Containers[name]
+	if !ok {
+		return false
+	}
+	container.DependsOn = deps
+	c.Containers[name] = container
+	return true
+}
+
+// SetContainerPorts records the proxy ports for a container
+func (c *Config) SetContainerPorts(name string, ports []int) bool {
+	container, ok := c.Containers[name]
+	if !ok {
+		return false
+	}
+	container.Ports = ports
+	c.Containers[name] = container
+	return true
+}
+
+// SetContainerUser records the user for a container
+func (c *Config) SetContainerUser(name string, user User) bool {
+	container, ok := c.Containers[name]
+	if !ok {
+		return false
+	}
+	container.User = user
+	c.Containers[name] = container
+	return true
+}
+
+// AddIDMap appends a raw.idmap entry to a container, ignoring duplicates.
+// Returns false if the container doesn't exist.
+func (c *Config) AddIDMap(name, entry string) bool {
+	container, ok := c.Containers[name]
+	if !ok {
+		return false
+	}
+	for _, existing := range container.IDMap {
+		if existing == entry {
+			return true
+		}
+	}
+	container.IDMap = append(container.IDMap, entry)
+	c.Containers[name] = container
+	return true
+}
+
+// GetIDMap returns the raw.idmap entries for a container
+func (c *Config) GetIDMap(name string) []string {
+	if container, ok := c.Containers[name]; ok {
+		return container.IDMap
+	}
+	return nil
+}
+
+// Detach returns a copy of the config holding only container name, deep
+// copied, so work on that container can go on without whatever guards c.
+// Saving the copy writes nothing: copy the container back with
+// SetContainer and save c instead.
+func (c *Config) Detach(name string) (*Config, error) {
+	detached := *c
+	detached.Containers = map[string]Container{}
+	detached.detached = true
+	if container, ok := c.Containers[name]; ok {
+		data, err := yaml.Marshal(container)
+		if err != nil {
+			return nil, err
+		}
+		var copied Container
+		if err := yaml.Unmarshal(data, &copied); err != nil {
+			return nil, err
+		}
+		detached.Containers[name] = copied
+	}
+	return &detached, nil
+}
+
+// SetContainer replaces the definition of an existing container
+func (c *Config) SetContainer(name string, container Container) bool {
+	if _, ok := c.Containers[name]; !ok {
+		return false
+	}
+	c.Containers[name] = container
+	return true
 }
 
 // SetContainerImage updates the image for a container
@@ -382,6 +1725,38 @@ func (c *Config) GetPorts(name string) []int {
 
 // GetUser returns the user config for a container (per-container > defaults > hardcoded)
 func (c *Config) GetUser(name string) User {
+	user := c.getUser(name)
+	// Shell, timezone, locale and groups fall back to the defaults field by field
+	if container, ok := c.Containers[name]; ok {
+		if container.User.Shell != "" {
+			user.Shell = container.User.Shell
+		}
+		if container.User.Timezone != "" {
+			user.Timezone = container.User.Timezone
+		}
+		if container.User.Locale != "" {
+			user.Locale = container.User.Locale
+		}
+	}
+	if user.Shell == "" {
+		user.Shell = c.Defaults.User.Shell
+	}
+	if user.Timezone == "" {
+		user.Timezone = c.Defaults.User.Timezone
+	}
+	if user.Locale == "" {
+		user.Locale = c.Defaults.User.Locale
+	}
+	if container, ok := c.Containers[name]; ok && len(container.User.Groups) > 0 {
+		user.Groups = container.User.Groups
+	}
+	if len(user.Groups) == 0 {
+		user.Groups = c.Defaults.User.Groups
+	}
+	return user
+}
+
+func (c *Config) getUser(name string) User {
 	// Check per-container first
 	if container, ok := c.Containers[name]; ok && container.User.Name != "" {
 		user := container.User
@@ -406,6 +1781,52 @@ func (c *Config) GetUser(name string) User {
 	return User{Name: "dev", Password: "dev"}
 }
 
+// GetDotfiles returns the dotfiles for a container, falling back to the
+// defaults. Returns nil if none are configured.
+func (c *Config) GetDotfiles(name string) *Dotfiles {
+	if container, ok := c.Containers[name]; ok && container.Dotfiles != nil {
+		return container.Dotfiles
+	}
+	return c.Defaults.Dotfiles
+}
+
+// GetReady returns the readiness conditions of a container, falling back
+// to the defaults. Empty means only cloud-init is waited for.
+func (c *Config) GetReady(name string) []string {
+	if container, ok := c.Containers[name]; ok && len(container.Ready) > 0 {
+		return container.Ready
+	}
+	return c.Defaults.Ready
+}
+
+// AutoProxy reports whether a container's ports are forwarded while it runs
+func (c *Config) AutoProxy(name string) bool {
+	return c.Containers[name].Proxy == ProxyAuto
+}
+
+// GetStopTimeout returns how long to wait for a container to shut down
+// cleanly, falling back to the defaults, then to def. Validate has checked
+// the values parse.
+func (c *Config) GetStopTimeout(name string, def time.Duration) time.Duration {
+	timeout := c.Defaults.StopTimeout
+	if container, ok := c.Containers[name]; ok && container.StopTimeout != "" {
+		timeout = container.StopTimeout
+	}
+	if d, err := time.ParseDuration(timeout); err == nil {
+		return d
+	}
+	return def
+}
+
+// GetOnStart returns the commands run inside a container after it starts,
+// falling back to the defaults
+func (c *Config) GetOnStart(name string) []string {
+	if container, ok := c.Containers[name]; ok && len(container.OnStart) > 0 {
+		return container.OnStart
+	}
+	return c.Defaults.OnStart
+}
+
 func (c *Config) HasContainer(name string) bool {
 	_, ok := c.Containers[name]
 	return ok
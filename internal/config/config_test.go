@@ -1,10 +1,15 @@
 package config
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"time"
+
+	"lxc-dev-manager/internal/dryrun"
 )
 
 // Helper to run tests in a temp directory
@@ -223,6 +228,24 @@ func TestRemoveContainer(t *testing.T) {
 	}
 }
 
+func TestRemoveContainer_DropsDependsOn(t *testing.T) {
+	cfg := &Config{
+		Containers: map[string]Container{
+			"db":  {Image: "ubuntu:24.04"},
+			"web": {Image: "ubuntu:24.04", DependsOn: []string{"db"}},
+		},
+	}
+
+	cfg.RemoveContainer("db")
+
+	if deps := cfg.Containers["web"].DependsOn; deps != nil {
+		t.Errorf("expected depends_on to be cleared, got %v", deps)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected config to stay valid, got %v", err)
+	}
+}
+
 func TestRemoveContainer_NotExists(t *testing.T) {
 	cfg := &Config{
 		Containers: map[string]Container{},
@@ -956,6 +979,128 @@ func TestFindDeviceByPath_NotFound(t *testing.T) {
 	}
 }
 
+func TestValidate_EnvAndDependsOn(t *testing.T) {
+	tests := []struct {
+		name      string
+		container Container
+		wantErr   string
+	}{
+		{"valid", Container{Env: map[string]string{"NODE_ENV": "development"}, DependsOn: []string{"db"}}, ""},
+		{"bad env name", Container{Env: map[string]string{"NODE-ENV": "x"}}, "invalid environment variable name"},
+		{"unknown dependency", Container{DependsOn: []string{"cache"}}, "depends_on 'cache'"},
+		{"self dependency", Container{DependsOn: []string{"web"}}, "depends_on 'web'"},
+		{"auto proxy", Container{Proxy: ProxyAuto}, ""},
+		{"bad proxy", Container{Proxy: "always"}, "invalid proxy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.container.Image = "ubuntu:24.04"
+			cfg := &Config{
+				Project: "test",
+				Containers: map[string]Container{
+					"db":  {Image: "ubuntu:24.04"},
+					"web": tt.container,
+				},
+			}
+
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestGetLXCName_Naming(t *testing.T) {
+	tests := []struct {
+		project, naming, name string
+		want                  string
+	}{
+		{"webapp", "", "dev", "webapp-dev"},
+		{"web-app", "", "api", "web-app-api"},
+		{"webapp", NamingEscaped, "dev", "webapp-dev"},
+		{"web-app", NamingEscaped, "api", "web--app-api"},
+		{"", NamingEscaped, "dev", "dev"},
+	}
+
+	for _, tt := range tests {
+		cfg := &Config{Project: tt.project, Naming: tt.naming}
+		if got := cfg.GetLXCName(tt.name); got != tt.want {
+			t.Errorf("GetLXCName(%q) for project %q naming %q = %q, want %q", tt.name, tt.project, tt.naming, got, tt.want)
+		}
+	}
+}
+
+func TestGetShortName(t *testing.T) {
+	tests := []struct {
+		project, naming, lxcName string
+		want                     string
+		wantOK                   bool
+	}{
+		{"webapp", "", "webapp-dev", "dev", true},
+		{"webapp", "", "other-dev", "", false},
+		{"web", NamingEscaped, "web-app-api", "app-api", true},
+		{"web-app", NamingEscaped, "web--app-api", "api", true},
+		// the escaped names of one project never look like another's
+		{"web", NamingEscaped, "web--app-api", "", false},
+		{"web-app", NamingEscaped, "web-app-api", "", false},
+		{"web", "", "web--app-api", "", false},
+		{"web", "", "web-list", "", false},
+	}
+
+	for _, tt := range tests {
+		cfg := &Config{Project: tt.project, Naming: tt.naming}
+		got, ok := cfg.GetShortName(tt.lxcName)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("GetShortName(%q) for project %q naming %q = %q, %v, want %q, %v", tt.lxcName, tt.project, tt.naming, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestValidate_Naming(t *testing.T) {
+	cfg := &Config{Project: "web-app", Naming: NamingEscaped, Containers: map[string]Container{}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	cfg.Naming = "dotted"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "naming") {
+		t.Errorf("expected naming error, got %v", err)
+	}
+}
+
+func TestValidate_ImageRemote(t *testing.T) {
+	cfg := &Config{Project: "test", ImageRemote: "team-registry", Containers: map[string]Container{}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	cfg.ImageRemote = "team:"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "image_remote") {
+		t.Errorf("expected image_remote error, got %v", err)
+	}
+}
+
+func TestValidate_DNSDomain(t *testing.T) {
+	cfg := &Config{Project: "test", DNS: DNS{Mode: "dnsmasq", Domain: "lab.internal"}, Containers: map[string]Container{}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// Would add a directive to the dnsmasq drop-in
+	cfg.DNS.Domain = "lxd/10.0.0.1\ndhcp-script=/tmp/evil"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "dns domain") {
+		t.Errorf("expected dns domain error, got %v", err)
+	}
+}
+
 func TestValidate_DeviceTypeEmpty(t *testing.T) {
 	cfg := &Config{
 		Project: "test",
@@ -1115,6 +1260,309 @@ func TestValidate_DeviceValid(t *testing.T) {
 	}
 }
 
+func TestValidate_USBDevice(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  map[string]string
+		wantErr bool
+	}{
+		{"vendor and product", map[string]string{"vendorid": "0403", "productid": "6001"}, false},
+		{"vendor only", map[string]string{"vendorid": "0403"}, false},
+		{"missing vendor", map[string]string{"productid": "6001"}, true},
+		{"invalid vendor", map[string]string{"vendorid": "zz"}, true},
+		{"invalid mode", map[string]string{"vendorid": "0403", "mode": "999"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateDevice("usb0", Device{Type: "usb", Config: tt.config})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateDevice() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_UnixCharDevice(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  map[string]string
+		wantErr bool
+	}{
+		{"source only", map[string]string{"source": "/dev/ttyUSB0"}, false},
+		{"source and path", map[string]string{"source": "/dev/ttyUSB0", "path": "/dev/ttyS9"}, false},
+		{"ownership", map[string]string{"source": "/dev/ttyUSB0", "mode": "0660", "uid": "1000", "gid": "20"}, false},
+		{"missing source and path", map[string]string{}, true},
+		{"source outside dev", map[string]string{"source": "/etc/shadow"}, true},
+		{"relative path", map[string]string{"source": "/dev/ttyUSB0", "path": "ttyS9"}, true},
+		{"non-numeric uid", map[string]string{"source": "/dev/ttyUSB0", "uid": "root"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateDevice("serial", Device{Type: "unix-char", Config: tt.config})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateDevice() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_DiskBindOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  map[string]string
+		wantErr bool
+	}{
+		{"recursive", map[string]string{"recursive": "true"}, false},
+		{"propagation", map[string]string{"propagation": "rslave"}, false},
+		{"recursive not bool", map[string]string{"recursive": "yes"}, true},
+		{"unknown propagation", map[string]string{"propagation": "both"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config["source"] = "/srv/tree"
+			tt.config["path"] = "/srv/tree"
+			err := ValidateDevice("tree", Device{Type: "disk", Config: tt.config})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateDevice() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateDevice_ReservedName(t *testing.T) {
+	disk := Device{Type: "disk", Config: map[string]string{"source": "/srv/data", "path": "/data"}}
+
+	for _, name := range []string{"root", "eth0", "config"} {
+		if err := ValidateDevice(name, disk); err == nil {
+			t.Errorf("ValidateDevice(%q) expected reserved name error", name)
+		}
+	}
+	if err := ValidateDevice("data", disk); err != nil {
+		t.Errorf("ValidateDevice(\"data\") unexpected error: %v", err)
+	}
+}
+
+func TestValidate_Dotfiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		dotfiles Dotfiles
+		wantErr  bool
+	}{
+		{"repo only", Dotfiles{Repo: "https://github.com/me/dotfiles.git"}, false},
+		{"ssh repo with install", Dotfiles{Repo: "git@github.com:me/dotfiles.git", Install: "script/setup"}, false},
+		{"missing repo", Dotfiles{Install: "install.sh"}, true},
+		{"option-like repo", Dotfiles{Repo: "--upload-pack=evil"}, true},
+		{"absolute install", Dotfiles{Repo: "https://example.com/d.git", Install: "/tmp/x.sh"}, true},
+		{"install outside repo", Dotfiles{Repo: "https://example.com/d.git", Install: "../x.sh"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := tt.dotfiles
+			cfg := &Config{
+				Defaults:   Defaults{Dotfiles: &d},
+				Containers: map[string]Container{},
+			}
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetUser_Environment(t *testing.T) {
+	cfg := &Config{
+		Defaults: Defaults{User: User{Shell: "zsh", Timezone: "Europe/Paris", Locale: "en_US.UTF-8"}},
+		Containers: map[string]Container{
+			"dev1": {Image: "ubuntu:24.04"},
+			"dev2": {Image: "ubuntu:24.04", User: User{Name: "alice", Timezone: "UTC"}},
+		},
+	}
+
+	u := cfg.GetUser("dev1")
+	if u.Name != "dev" || u.Shell != "zsh" || u.Timezone != "Europe/Paris" || u.Locale != "en_US.UTF-8" {
+		t.Errorf("dev1: unexpected user %+v", u)
+	}
+
+	u = cfg.GetUser("dev2")
+	if u.Name != "alice" || u.Shell != "zsh" || u.Timezone != "UTC" || u.Locale != "en_US.UTF-8" {
+		t.Errorf("dev2: unexpected user %+v", u)
+	}
+}
+
+func TestGetUser_Groups(t *testing.T) {
+	cfg := &Config{
+		Defaults: Defaults{User: User{Groups: []string{"docker"}}},
+		Containers: map[string]Container{
+			"dev1": {Image: "ubuntu:24.04"},
+			"dev2": {Image: "ubuntu:24.04", User: User{Groups: []string{"video", "dialout"}}},
+		},
+	}
+
+	if got := cfg.GetUser("dev1").Groups; strings.Join(got, ",") != "docker" {
+		t.Errorf("dev1: expected default groups, got %v", got)
+	}
+	if got := cfg.GetUser("dev2").Groups; strings.Join(got, ",") != "video,dialout" {
+		t.Errorf("dev2: expected container groups, got %v", got)
+	}
+
+	cfg.Containers["dev3"] = Container{Image: "ubuntu:24.04", User: User{Groups: []string{"Docker Users"}}}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected invalid group name to be rejected")
+	}
+}
+
+func TestValidate_UserEnvironment(t *testing.T) {
+	cfg := &Config{
+		Containers: map[string]Container{
+			"dev1": {Image: "ubuntu:24.04", User: User{Timezone: "../../etc/shadow"}},
+		},
+	}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected invalid timezone to be rejected")
+	}
+
+	cfg.Containers["dev1"] = Container{Image: "ubuntu:24.04", User: User{Shell: "zsh", Timezone: "Asia/Tokyo"}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestValidate_Hooks(t *testing.T) {
+	cfg := &Config{
+		Hooks:      Hooks{PostStart: "./tunnel.sh {{quote .IP}}"},
+		Containers: map[string]Container{},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	cfg.Hooks.PreStop = "echo {{.Name"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "pre_stop") {
+		t.Errorf("expected invalid pre_stop hook error, got %v", err)
+	}
+}
+
+func TestAddIDMap(t *testing.T) {
+	cfg := &Config{
+		Containers: map[string]Container{
+			"dev1": {Image: "ubuntu:24.04"},
+		},
+	}
+
+	if !cfg.AddIDMap("dev1", "both 1000 1000") {
+		t.Fatal("expected AddIDMap to succeed")
+	}
+	cfg.AddIDMap("dev1", "both 1000 1000")
+
+	if got := cfg.GetIDMap("dev1"); len(got) != 1 || got[0] != "both 1000 1000" {
+		t.Errorf("expected single idmap entry, got %v", got)
+	}
+	if cfg.AddIDMap("missing", "both 1000 1000") {
+		t.Error("expected AddIDMap to fail for missing container")
+	}
+}
+
+func TestValidate_InvalidIDMap(t *testing.T) {
+	cfg := &Config{
+		Project: "test",
+		Containers: map[string]Container{
+			"dev1": {Image: "ubuntu:24.04", IDMap: []string{"both 1000"}},
+		},
+	}
+
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "idmap") {
+		t.Errorf("expected idmap validation error, got %v", err)
+	}
+}
+
+func TestExpandMountSource(t *testing.T) {
+	cfg := &Config{Dir: "/home/dev/myproject"}
+
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"${PROJECT_DIR}", "/home/dev/myproject"},
+		{"${PROJECT_DIR}/src", "/home/dev/myproject/src"},
+		{"data", "/home/dev/myproject/data"},
+		{"/srv/shared", "/srv/shared"},
+		{"~/cache", "~/cache"},
+	}
+
+	for _, tt := range tests {
+		if got := cfg.ExpandMountSource(tt.source); got != tt.want {
+			t.Errorf("ExpandMountSource(%q) = %q, want %q", tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestValidate_DefaultMounts(t *testing.T) {
+	tests := []struct {
+		name    string
+		mount   Mount
+		wantErr bool
+	}{
+		{"valid", Mount{Source: "${PROJECT_DIR}", Path: "/workspace", Mode: "rw"}, false},
+		{"default mode", Mount{Source: "/data", Path: "/data"}, false},
+		{"missing source", Mount{Path: "/workspace"}, true},
+		{"relative path", Mount{Source: "/data", Path: "workspace"}, true},
+		{"bad mode", Mount{Source: "/data", Path: "/data", Mode: "rwx"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Project:  "test",
+				Defaults: Defaults{Mounts: []Mount{tt.mount}},
+			}
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_ContainerTemplateDefaults(t *testing.T) {
+	tests := []struct {
+		name     string
+		defaults Defaults
+		wantErr  string
+	}{
+		{"valid", Defaults{Image: "ubuntu:24.04", Sync: []SyncEntry{{Source: ".env", Dest: "/home/dev/.env"}}, Setup: []string{"make deps"}}, ""},
+		{"image flag", Defaults{Image: "--force"}, "default image"},
+		{"empty setup command", Defaults{Setup: []string{" "}}, "default setup"},
+		{"sync without source", Defaults{Sync: []SyncEntry{{Dest: "/app"}}}, "default sync 1"},
+		{"sync relative dest", Defaults{Sync: []SyncEntry{{Source: ".env", Dest: "app/.env"}}}, "default sync 1"},
+		{"apt proxy", Defaults{AptProxy: "http://10.10.10.1:3142"}, ""},
+		{"apt proxy without scheme", Defaults{AptProxy: "10.10.10.1:3142"}, "default apt_proxy"},
+		{"ready", Defaults{Ready: []string{"systemd", "port:5432"}}, ""},
+		{"unknown ready condition", Defaults{Ready: []string{"docker"}}, "default ready"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Project: "test", Defaults: tt.defaults}
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
 // --- Sync Entry Tests ---
 
 func TestLoad_WithSyncEntries(t *testing.T) {
@@ -1215,6 +1663,55 @@ func TestAddSyncEntry_DuplicateSource(t *testing.T) {
 	}
 }
 
+func TestValidateSyncEntry(t *testing.T) {
+	tests := []struct {
+		name    string
+		entry   SyncEntry
+		wantErr string
+	}{
+		{"valid", SyncEntry{Source: ".env", Dest: "/app/.env"}, ""},
+		{"empty source", SyncEntry{Dest: "/app/.env"}, "source must not be empty"},
+		{"control character in source", SyncEntry{Source: ".env\n", Dest: "/app/.env"}, "control characters"},
+		{"relative dest", SyncEntry{Source: ".env", Dest: "app/.env"}, "invalid dest"},
+		{"empty dest", SyncEntry{Source: ".env"}, "invalid dest"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSyncEntry(tt.entry)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSyncSourceOutsideProject(t *testing.T) {
+	cfg := &Config{Dir: "/home/user/project"}
+
+	tests := []struct {
+		source string
+		want   bool
+	}{
+		{".env", false},
+		{"config/app.yaml", false},
+		{"/home/user/project/.env", false},
+		{"../shared/.env", true},
+		{"/etc/hosts", true},
+		{"..env", false},
+	}
+	for _, tt := range tests {
+		if got := cfg.SyncSourceOutsideProject(SyncEntry{Source: tt.source, Dest: "/app/x"}); got != tt.want {
+			t.Errorf("SyncSourceOutsideProject(%q) = %v, want %v", tt.source, got, tt.want)
+		}
+	}
+}
+
 func TestRemoveSyncEntry(t *testing.T) {
 	cfg := &Config{
 		Containers: map[string]Container{
@@ -1301,3 +1798,451 @@ func TestSave_WithSyncEntries(t *testing.T) {
 		}
 	})
 }
+
+func TestValidate_StaticIP(t *testing.T) {
+	cfg := &Config{
+		Project: "test",
+		Containers: map[string]Container{
+			"dev1": {Image: "ubuntu:24.04", IP: "10.10.10.50"},
+		},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected valid config, got %v", err)
+	}
+
+	cfg.Containers["dev1"] = Container{Image: "ubuntu:24.04", IP: "10.10.10.500"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for invalid IP")
+	}
+}
+
+func TestValidate_DuplicateStaticIP(t *testing.T) {
+	cfg := &Config{
+		Project: "test",
+		Containers: map[string]Container{
+			"dev1": {Image: "ubuntu:24.04", IP: "10.10.10.50"},
+			"dev2": {Image: "ubuntu:24.04", IP: "10.10.10.50"},
+		},
+	}
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected error for duplicate IP")
+	}
+	if !strings.Contains(err.Error(), "already assigned") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestContainerLock_ScopedSavesMerge(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, ConfigFile), []byte(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+  dev2:
+    image: ubuntu:24.04
+`), 0644)
+
+	cfg1, lock1, err := LoadWithContainerLock(dir, "dev1")
+	if err != nil {
+		t.Fatalf("lock dev1: %v", err)
+	}
+	defer lock1.Release()
+	cfg2, lock2, err := LoadWithContainerLock(dir, "dev2")
+	if err != nil {
+		t.Fatalf("different containers should lock independently: %v", err)
+	}
+	defer lock2.Release()
+
+	cfg1.SetContainerPorts("dev1", []int{3000})
+	cfg2.SetContainerPorts("dev2", []int{5432})
+	cfg2.Project = "ignored" // outside the scope, not saved
+	if err := cfg1.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg2.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := cfg.Containers["dev1"].Ports; len(p) != 1 || p[0] != 3000 {
+		t.Errorf("dev1 ports lost: %v", p)
+	}
+	if p := cfg.Containers["dev2"].Ports; len(p) != 1 || p[0] != 5432 {
+		t.Errorf("dev2 ports lost: %v", p)
+	}
+	if cfg.Project != "test" {
+		t.Errorf("scoped save changed project to %q", cfg.Project)
+	}
+}
+
+func TestContainerLock_ScopedSaveRemoves(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, ConfigFile), []byte(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+  dev2:
+    image: ubuntu:24.04
+    depends_on: [dev1]
+`), 0644)
+
+	cfg, lock, err := LoadWithContainerLock(dir, "dev1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Release()
+	cfg.RemoveContainer("dev1")
+	if err := cfg.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err = Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.HasContainer("dev1") || !cfg.HasContainer("dev2") {
+		t.Errorf("expected only dev1 to be removed, got %v", cfg.Containers)
+	}
+	if deps := cfg.Containers["dev2"].DependsOn; len(deps) != 0 {
+		t.Errorf("expected dev1 to be dropped from depends_on, got %v", deps)
+	}
+}
+
+func TestDetach(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, ConfigFile), []byte(`project: test
+containers:
+  dev1:
+    image: ubuntu:24.04
+    devices:
+      data:
+        type: disk
+        config:
+          path: /data
+          source: /srv/data
+  dev2:
+    image: ubuntu:24.04
+`), 0644)
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	detached, err := cfg.Detach("dev1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detached.HasContainer("dev2") {
+		t.Error("expected only dev1 in the detached copy")
+	}
+	detached.AddDevice("dev1", "src", Device{Type: "disk", Config: map[string]string{"path": "/src"}})
+	detached.Containers["dev1"].Devices["data"].Config["path"] = "/changed"
+	if err := detached.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.HasDevice("dev1", "src") || cfg.Containers["dev1"].Devices["data"].Config["path"] != "/data" {
+		t.Errorf("changes to the detached copy leaked into the config: %+v", cfg.Containers["dev1"])
+	}
+	saved, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.HasDevice("dev1", "src") {
+		t.Error("saving the detached copy should not write the config file")
+	}
+
+	cfg.SetContainer("dev1", detached.Containers["dev1"])
+	if !cfg.HasDevice("dev1", "src") || !cfg.HasContainer("dev2") {
+		t.Errorf("expected the detached container to be merged back, got %v", cfg.Containers)
+	}
+}
+
+func TestContainerLock_Exclusion(t *testing.T) {
+	orig := lockTimeout
+	lockTimeout = 200 * time.Millisecond
+	defer func() { lockTimeout = orig }()
+
+	dir := t.TempDir()
+	lock, err := AcquireContainerLock(dir, "dev1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := AcquireContainerLock(dir, "dev1"); err == nil {
+		t.Error("expected the same container to stay locked")
+	}
+	if _, err := AcquireLock(dir); err == nil {
+		t.Error("expected the project lock to wait for container locks")
+	}
+
+	lock.Release()
+	project, err := AcquireLock(dir)
+	if err != nil {
+		t.Fatalf("expected project lock after release: %v", err)
+	}
+	defer project.Release()
+	if _, err := AcquireContainerLock(dir, "dev2"); err == nil {
+		t.Error("expected container locks to wait for the project lock")
+	}
+}
+
+func TestContainerLock_InvalidName(t *testing.T) {
+	if _, err := AcquireContainerLock(t.TempDir(), "../escape"); err == nil {
+		t.Error("expected invalid container name to be rejected")
+	}
+}
+
+func TestLockOwner_RecordedAndCleared(t *testing.T) {
+	orig := lockTimeout
+	lockTimeout = 200 * time.Millisecond
+	defer func() { lockTimeout = orig }()
+
+	dir := t.TempDir()
+	lock, err := AcquireLock(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	owner := readLockOwner(filepath.Join(dir, lockFile))
+	if owner == nil || owner.PID != os.Getpid() {
+		t.Fatalf("expected lock owner to be recorded, got %+v", owner)
+	}
+
+	_, err = AcquireLock(dir)
+	if err == nil || !strings.Contains(err.Error(), "held by pid") {
+		t.Errorf("expected timeout error naming the owner, got %v", err)
+	}
+
+	lock.Release()
+	if owner := readLockOwner(filepath.Join(dir, lockFile)); owner != nil {
+		t.Errorf("expected owner record to be cleared on release, got %+v", owner)
+	}
+}
+
+func TestLockStatus_Stale(t *testing.T) {
+	dir := t.TempDir()
+	host, _ := os.Hostname()
+
+	// A record left behind by a process that died without releasing
+	os.MkdirAll(filepath.Join(dir, lockDir), 0755)
+	stale := "pid: 999999999\nhost: " + host + "\nsince: 2024-01-01T00:00:00Z\ncommand: lxc-dev-manager sync add dev1\n"
+	os.WriteFile(filepath.Join(dir, lockDir, "dev1.lock"), []byte(stale), 0644)
+	os.WriteFile(filepath.Join(dir, lockFile), nil, 0644)
+
+	locks, err := LockStatus(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(locks) != 2 {
+		t.Fatalf("expected project and dev1 locks, got %+v", locks)
+	}
+	if locks[0].Container != "" || locks[0].Stale() {
+		t.Errorf("expected a free project lock, got %+v", locks[0])
+	}
+	if locks[1].Container != "dev1" || !locks[1].Stale() {
+		t.Errorf("expected stale dev1 lock, got %+v", locks[1])
+	}
+
+	// A lock a live process holds, even with a stale owner record
+	held, err := AcquireContainerLock(dir, "dev2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer held.Release()
+	os.WriteFile(filepath.Join(dir, lockDir, "dev2.lock"), []byte(stale), 0644)
+
+	removed, kept, err := ForceUnlock(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 || filepath.Base(removed[0]) != "dev1.lock" {
+		t.Errorf("expected only the stale dev1 lock to be removed, got %v", removed)
+	}
+	if !slices.Contains(kept, filepath.Join(dir, lockDir, "dev2.lock")) {
+		t.Errorf("expected the held dev2 lock to be kept, got %v", kept)
+	}
+	if _, err := os.Stat(filepath.Join(dir, lockDir, "dev2.lock")); err != nil {
+		t.Errorf("held lock was removed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, lockFile)); err != nil {
+		t.Errorf("free project lock was removed: %v", err)
+	}
+}
+
+func TestSave_KeepsBackupsAndUndo(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, ConfigFile), []byte("project: test\ncontainers: {}\n"), 0644)
+
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.AddContainer("dev1", "ubuntu:24.04")
+	if err := cfg.Save(); err != nil {
+		t.Fatal(err)
+	}
+	// Saving unchanged content doesn't add a backup
+	if err := cfg.Save(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond)
+	cfg.AddContainer("dev2", "ubuntu:24.04")
+	if err := cfg.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	backups, err := Backups(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("expected 2 backups, got %d", len(backups))
+	}
+
+	if _, err := Undo(dir); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.HasContainer("dev1") || cfg.HasContainer("dev2") {
+		t.Errorf("expected state with only dev1, got %v", cfg.Containers)
+	}
+
+	if _, err := Undo(dir); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Containers) != 0 {
+		t.Errorf("expected original empty config, got %v", cfg.Containers)
+	}
+
+	if _, err := Undo(dir); err == nil {
+		t.Error("expected error when no backups are left")
+	}
+}
+
+func TestSave_PrunesOldBackups(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, ConfigFile), []byte("project: test\ncontainers: {}\n"), 0644)
+
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < maxBackups+5; i++ {
+		cfg.Defaults.Ports = []int{3000 + i}
+		if err := cfg.Save(); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	backups, err := Backups(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != maxBackups {
+		t.Errorf("expected %d backups, got %d", maxBackups, len(backups))
+	}
+}
+
+func TestSave_DryRun(t *testing.T) {
+	dir := t.TempDir()
+	original := "project: test\ncontainers: {}\n"
+	os.WriteFile(filepath.Join(dir, ConfigFile), []byte(original), 0644)
+
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	dryrun.Enable(&out)
+	defer dryrun.Disable()
+
+	cfg.AddContainer("dev1", "ubuntu:24.04")
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dir, ConfigFile))
+	if string(data) != original {
+		t.Errorf("expected config to be left alone, got %q", data)
+	}
+	if backups, _ := Backups(dir); len(backups) != 0 {
+		t.Errorf("expected no backup in dry-run mode, got %d", len(backups))
+	}
+	if !strings.Contains(out.String(), "[dry-run] write containers.yaml:") || !strings.Contains(out.String(), "+         image: ubuntu:24.04") {
+		t.Errorf("expected the change to be printed, got %q", out.String())
+	}
+}
+
+func TestStopTimeout(t *testing.T) {
+	cfg := &Config{
+		Project:  "test",
+		Defaults: Defaults{StopTimeout: "10s"},
+		Containers: map[string]Container{
+			"db":  {Image: "ubuntu:24.04", StopTimeout: "1m"},
+			"web": {Image: "ubuntu:24.04"},
+		},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cfg.GetStopTimeout("db", 5*time.Second); got != time.Minute {
+		t.Errorf("db stop timeout = %v, want 1m", got)
+	}
+	if got := cfg.GetStopTimeout("web", 5*time.Second); got != 10*time.Second {
+		t.Errorf("web stop timeout = %v, want the default 10s", got)
+	}
+
+	cfg.Defaults.StopTimeout = ""
+	if got := cfg.GetStopTimeout("web", 5*time.Second); got != 5*time.Second {
+		t.Errorf("web stop timeout = %v, want the fallback 5s", got)
+	}
+
+	cfg.Containers["web"] = Container{Image: "ubuntu:24.04", StopTimeout: "30"}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "stop_timeout") {
+		t.Errorf("expected a stop_timeout error, got %v", err)
+	}
+}
+
+func TestValidateContainer(t *testing.T) {
+	tests := []struct {
+		name      string
+		container Container
+		wantErr   string
+	}{
+		{"valid", Container{User: User{Name: "dev"}, Sync: []SyncEntry{{Source: ".env", Dest: "/app/.env"}}}, ""},
+		{"bad user name", Container{User: User{Name: "Dev User"}}, "invalid user name"},
+		{"sync without source", Container{Sync: []SyncEntry{{Dest: "/app"}}}, "sync 1: source must not be empty"},
+		{"sync relative dest", Container{Sync: []SyncEntry{{Source: ".env", Dest: "app/.env"}}}, "sync 1"},
+		{"bad env name", Container{Env: map[string]string{"1X": "x"}}, "invalid environment variable name"},
+		{"bad device", Container{Devices: map[string]Device{"data": {Type: "disk"}}}, "device 'data'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateContainer(tt.container)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Edit lets edit change a copy of dir's containers.yaml, then saves it back
+// if it still parses and validates. When it doesn't, retry is called with
+// the problem; returning true runs edit again on the same copy, false
+// gives up without saving. Returns whether containers.yaml changed.
+// If dir is empty, it uses the current working directory.
+func Edit(dir string, edit func(path string) error, retry func(err error) bool) (bool, error) {
+	if dir == "" {
+		dir = "."
+	}
+	configPath := filepath.Join(dir, ConfigFile)
+
+	original, err := os.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, ErrNoProject
+		}
+		return false, err
+	}
+
+	tempDir, err := os.MkdirTemp("", "lxc-config-edit-")
+	if err != nil {
+		return false, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// Keep the file name so editors pick YAML syntax
+	editPath := filepath.Join(tempDir, ConfigFile)
+	if err := os.WriteFile(editPath, original, 0600); err != nil {
+		return false, fmt.Errorf("failed to create temp file: %w", err)
+	}
+
+	for {
+		if err := edit(editPath); err != nil {
+			return false, err
+		}
+
+		edited, err := os.ReadFile(editPath)
+		if err != nil {
+			return false, err
+		}
+		if bytes.Equal(edited, original) {
+			return false, nil
+		}
+
+		if err := validateDocument(edited); err != nil {
+			if retry(err) {
+				continue
+			}
+			return false, fmt.Errorf("%s not changed: %w", ConfigFile, err)
+		}
+
+		if err := atomicWriteFile(configPath, edited, 0644); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+}
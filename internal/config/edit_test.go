@@ -0,0 +1,83 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEdit_Saves(t *testing.T) {
+	dir := writePathTestConfig(t)
+
+	changed, err := Edit(dir, func(path string) error {
+		data, _ := os.ReadFile(path)
+		return os.WriteFile(path, []byte(strings.Replace(string(data), "[3000]", "[3000, 8080]", 1)), 0600)
+	}, func(error) bool { return false })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !changed {
+		t.Fatal("expected change to be saved")
+	}
+
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := cfg.Containers["dev1"].Ports; len(p) != 2 {
+		t.Errorf("expected edited ports, got %v", p)
+	}
+}
+
+func TestEdit_NoChanges(t *testing.T) {
+	dir := writePathTestConfig(t)
+
+	changed, err := Edit(dir, func(string) error { return nil }, func(error) bool { return false })
+	if err != nil || changed {
+		t.Errorf("expected no change, got changed=%v err=%v", changed, err)
+	}
+	if backups, _ := Backups(dir); len(backups) != 0 {
+		t.Errorf("expected no backup for an unchanged file, got %d", len(backups))
+	}
+}
+
+func TestEdit_InvalidRetries(t *testing.T) {
+	dir := writePathTestConfig(t)
+
+	edits := []string{
+		"project: test\ncontainers:\n  dev1:\n    image: [broken\n",      // bad YAML
+		"project: test\ncontainers:\n  dev1:\n    imgae: ubuntu:24.04\n", // unknown key
+		"project: test\ncontainers:\n  dev1:\n    image: ubuntu:24.04\n    ports: [3001]\n",
+	}
+	calls, retries := 0, 0
+	changed, err := Edit(dir, func(path string) error {
+		calls++
+		return os.WriteFile(path, []byte(edits[calls-1]), 0600)
+	}, func(error) bool {
+		retries++
+		return true
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !changed || calls != 3 || retries != 2 {
+		t.Errorf("expected 3 edits and 2 retries, got changed=%v calls=%d retries=%d", changed, calls, retries)
+	}
+}
+
+func TestEdit_InvalidGivesUp(t *testing.T) {
+	dir := writePathTestConfig(t)
+
+	_, err := Edit(dir, func(path string) error {
+		return os.WriteFile(path, []byte("project: test\ncontainers:\n  dev1:\n    ports: [70000]\n"), 0600)
+	}, func(error) bool { return false })
+	if err == nil {
+		t.Fatal("expected error for invalid config")
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dir, ConfigFile))
+	if string(data) != pathTestConfig {
+		t.Error("expected containers.yaml to be left unchanged")
+	}
+}
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"lxc-dev-manager/internal/errcode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// GetValue returns the YAML at a dotted path (e.g. "defaults.user.name" or
+// "containers.dev1.ports") in dir's containers.yaml. Scalars are returned
+// as their plain value, everything else as YAML.
+// If dir is empty, it uses the current working directory.
+func GetValue(dir, path string) (string, error) {
+	doc, err := readDocument(dir)
+	if err != nil {
+		return "", err
+	}
+
+	node, err := lookupPath(doc.Content[0], path, false)
+	if err != nil {
+		return "", err
+	}
+	if node.Kind == yaml.ScalarNode {
+		return node.Value, nil
+	}
+	out, err := yaml.Marshal(node)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(out), "\n"), nil
+}
+
+// SetValue sets the value at a dotted path in dir's containers.yaml.
+// value is parsed as YAML, so "[3000, 8080]" is a list and "alice" a
+// string. Missing mapping keys along the path are created. The result is
+// checked for unknown keys and validated before it's saved; comments and
+// key order elsewhere in the file are kept.
+// If dir is empty, it uses the current working directory.
+func SetValue(dir, path, value string) error {
+	if dir == "" {
+		dir = "."
+	}
+	doc, err := readDocument(dir)
+	if err != nil {
+		return err
+	}
+
+	var parsed yaml.Node
+	if err := yaml.Unmarshal([]byte(value), &parsed); err != nil {
+		return errcode.Errorf(errcode.Validation, "", "invalid value: %w", err)
+	}
+	newValue := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+	if len(parsed.Content) > 0 {
+		newValue = parsed.Content[0]
+	}
+
+	node, err := lookupPath(doc.Content[0], path, true)
+	if err != nil {
+		return err
+	}
+	*node = *newValue
+
+	data, err := yaml.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	if err := validateDocument(data); err != nil {
+		return errcode.Errorf(errcode.Validation, "", "invalid value for %s: %w", path, err)
+	}
+
+	return atomicWriteFile(filepath.Join(dir, ConfigFile), data, 0644)
+}
+
+// validateDocument rejects YAML with unknown keys (typos) and anything
+// Load would refuse
+func validateDocument(data []byte) error {
+	var cfg Config
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && err != io.EOF {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return errcode.Errorf(errcode.Validation, "", "invalid configuration: %w", err)
+	}
+	return nil
+}
+
+// readDocument parses containers.yaml into a document node whose only
+// child is the top-level mapping
+func readDocument(dir string) (*yaml.Node, error) {
+	if dir == "" {
+		dir = "."
+	}
+	data, err := os.ReadFile(filepath.Join(dir, ConfigFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNoProject
+		}
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid YAML in %s: %w", ConfigFile, err)
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	return &doc, nil
+}
+
+// lookupPath walks a dotted path from root. Mapping keys are matched by
+// name and sequence items by index. With create set, missing mapping keys
+// (and null values on the way) become new entries.
+func lookupPath(root *yaml.Node, path string, create bool) (*yaml.Node, error) {
+	if path == "" {
+		return nil, fmt.Errorf("empty path")
+	}
+
+	node := root
+	keys := strings.Split(path, ".")
+	for i, key := range keys {
+		at := strings.Join(keys[:i+1], ".")
+		if key == "" {
+			return nil, fmt.Errorf("invalid path %q", path)
+		}
+
+		if create && node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+			*node = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		}
+
+		switch node.Kind {
+		case yaml.MappingNode:
+			var next *yaml.Node
+			for j := 0; j+1 < len(node.Content); j += 2 {
+				if node.Content[j].Value == key {
+					next = node.Content[j+1]
+					break
+				}
+			}
+			if next == nil {
+				if !create {
+					return nil, fmt.Errorf("%s is not set", at)
+				}
+				next = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+				node.Style = 0 // a flow-style {} grows into a block mapping
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, next)
+			}
+			node = next
+		case yaml.SequenceNode:
+			idx, err := strconv.Atoi(key)
+			if err != nil || idx < 0 || idx >= len(node.Content) {
+				return nil, fmt.Errorf("%s: no list item %q (list has %d items)", at, key, len(node.Content))
+			}
+			node = node.Content[idx]
+		default:
+			return nil, fmt.Errorf("%s: %s is not a mapping or list", at, strings.Join(keys[:i], "."))
+		}
+	}
+	return node, nil
+}
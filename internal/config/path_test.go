@@ -0,0 +1,118 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const pathTestConfig = `# Project config
+project: test
+defaults:
+  user:
+    name: dev
+containers:
+  dev1:
+    image: ubuntu:24.04 # base image
+    ports: [3000]
+    sync:
+      - source: ./app
+        dest: /app
+`
+
+func writePathTestConfig(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ConfigFile), []byte(pathTestConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestGetValue(t *testing.T) {
+	dir := writePathTestConfig(t)
+
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{"defaults.user.name", "dev", false},
+		{"containers.dev1.ports", "[3000]", false},
+		{"containers.dev1.sync.0.dest", "/app", false},
+		{"containers.dev1.sync.1.dest", "", true},
+		{"containers.dev2", "", true},
+		{"project.name", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := GetValue(dir, tt.path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("GetValue(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("GetValue(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestSetValue(t *testing.T) {
+	dir := writePathTestConfig(t)
+
+	if err := SetValue(dir, "containers.dev1.ports", "[3000, 8080]"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := SetValue(dir, "defaults.user.shell", "zsh"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := SetValue(dir, "containers.dev1.dotfiles.repo", "https://example.com/dotfiles.git"); err != nil {
+		t.Fatalf("unexpected error creating nested keys: %v", err)
+	}
+
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := cfg.Containers["dev1"].Ports; len(p) != 2 || p[1] != 8080 {
+		t.Errorf("expected ports [3000 8080], got %v", p)
+	}
+	if cfg.Defaults.User.Shell != "zsh" {
+		t.Errorf("expected default shell zsh, got %q", cfg.Defaults.User.Shell)
+	}
+	if d := cfg.Containers["dev1"].Dotfiles; d == nil || d.Repo == "" {
+		t.Errorf("expected dotfiles repo to be set, got %+v", d)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dir, ConfigFile))
+	if !strings.Contains(string(data), "# Project config") || !strings.Contains(string(data), "# base image") {
+		t.Errorf("expected comments to be kept, got:\n%s", data)
+	}
+}
+
+func TestSetValue_Rejected(t *testing.T) {
+	tests := []struct {
+		path  string
+		value string
+	}{
+		{"containers.dev1.port", "3000"},          // unknown key
+		{"containers.dev1.ports", "[70000]"},      // invalid port
+		{"containers.dev1.ports", "not-a-list"},   // wrong type
+		{"containers.dev1.image.name", "x"},       // through a scalar
+		{"defaults.user.name", "[unterminated"},   // invalid YAML
+		{"containers.dev1.sync.5.dest", "/other"}, // missing list item
+	}
+
+	for _, tt := range tests {
+		dir := writePathTestConfig(t)
+		if err := SetValue(dir, tt.path, tt.value); err == nil {
+			t.Errorf("SetValue(%q, %q) expected error", tt.path, tt.value)
+		}
+		data, _ := os.ReadFile(filepath.Join(dir, ConfigFile))
+		if string(data) != pathTestConfig {
+			t.Errorf("SetValue(%q, %q) changed the file despite failing", tt.path, tt.value)
+		}
+	}
+}
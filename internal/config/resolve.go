@@ -0,0 +1,139 @@
+package config
+
+import (
+	"sort"
+	"strings"
+
+	"lxc-dev-manager/internal/errcode"
+)
+
+// maxSuggestions is how many close names a not-found error lists
+const maxSuggestions = 3
+
+// ResolveContainer maps a container name as typed to a container in the
+// config. An exact name always wins. Otherwise an abbreviation that matches
+// exactly one container is accepted: first as a prefix ("ap" for "api"),
+// then as letters in order ("d1" for "dev1"). Ambiguous abbreviations are an
+// error, and unknown names get "did you mean" suggestions.
+func (c *Config) ResolveContainer(name string) (string, error) {
+	if c.HasContainer(name) {
+		return name, nil
+	}
+
+	names := make([]string, 0, len(c.Containers))
+	for n := range c.Containers {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	if name != "" {
+		for _, match := range []func(string, string) bool{strings.HasPrefix, isAbbreviation} {
+			var matches []string
+			for _, n := range names {
+				if match(n, name) {
+					matches = append(matches, n)
+				}
+			}
+			switch {
+			case len(matches) == 1:
+				return matches[0], nil
+			case len(matches) > 1:
+				return "", errcode.Errorf(errcode.Validation, name, "container '%s' is ambiguous: it could be %s", name, quoteList(matches, "or"))
+			}
+		}
+	}
+
+	return "", c.ContainerNotFound(name)
+}
+
+// ContainerNotFound returns the error for an unknown container name,
+// suggesting close matches
+func (c *Config) ContainerNotFound(name string) error {
+	if suggestions := c.SuggestContainers(name); len(suggestions) > 0 {
+		return errcode.Errorf(errcode.NotFound, name, "container '%s' not found in project config; did you mean %s?", name, quoteList(suggestions, "or"))
+	}
+	return errcode.Errorf(errcode.NotFound, name, "container '%s' not found in project config", name)
+}
+
+// SuggestContainers returns up to maxSuggestions container names within a
+// small edit distance of name, closest first
+func (c *Config) SuggestContainers(name string) []string {
+	type candidate struct {
+		name     string
+		distance int
+	}
+	limit := max(1, len(name)/3)
+
+	var candidates []candidate
+	for n := range c.Containers {
+		if d := editDistance(strings.ToLower(name), strings.ToLower(n)); d <= limit {
+			candidates = append(candidates, candidate{n, d})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].name < candidates[j].name
+	})
+
+	var out []string
+	for i := 0; i < len(candidates) && i < maxSuggestions; i++ {
+		out = append(out, candidates[i].name)
+	}
+	return out
+}
+
+// isAbbreviation reports whether abbr's characters appear in s in order,
+// starting with s's first character
+func isAbbreviation(s, abbr string) bool {
+	if abbr == "" || s == "" || s[0] != abbr[0] {
+		return false
+	}
+	i := 0
+	for j := 0; j < len(s) && i < len(abbr); j++ {
+		if s[j] == abbr[i] {
+			i++
+		}
+	}
+	return i == len(abbr)
+}
+
+// editDistance is the optimal string alignment distance between a and b:
+// insertions, deletions, substitutions and swaps of adjacent characters
+// each count as one edit
+func editDistance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+// quoteList formats names as 'a', 'b' or 'c'
+func quoteList(names []string, conj string) string {
+	quoted := make([]string, len(names))
+	for i, n := range names {
+		quoted[i] = "'" + n + "'"
+	}
+	if len(quoted) == 1 {
+		return quoted[0]
+	}
+	return strings.Join(quoted[:len(quoted)-1], ", ") + " " + conj + " " + quoted[len(quoted)-1]
+}
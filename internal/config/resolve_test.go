@@ -0,0 +1,76 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func resolveTestConfig() *Config {
+	return &Config{Containers: map[string]Container{
+		"dev1":   {Image: "ubuntu:24.04"},
+		"dev2":   {Image: "ubuntu:24.04"},
+		"api":    {Image: "ubuntu:24.04"},
+		"worker": {Image: "ubuntu:24.04"},
+	}}
+}
+
+func TestResolveContainer(t *testing.T) {
+	cfg := resolveTestConfig()
+
+	tests := []struct {
+		in      string
+		want    string
+		wantErr string
+	}{
+		{"dev1", "dev1", ""},
+		{"ap", "api", ""},
+		{"w", "worker", ""},
+		{"wkr", "worker", ""},
+		{"d1", "dev1", ""},
+		{"dev", "", "ambiguous: it could be 'dev1' or 'dev2'"},
+		{"dve1", "", "did you mean 'dev1'?"},
+		{"devv", "", "did you mean 'dev1' or 'dev2'?"},
+		{"zzz", "", "not found in project config"},
+		{"", "", "not found in project config"},
+	}
+	for _, tt := range tests {
+		got, err := cfg.ResolveContainer(tt.in)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ResolveContainer(%q) error = %v, want %q", tt.in, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ResolveContainer(%q) = %q, %v, want %q", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestResolveContainer_ExactWins(t *testing.T) {
+	cfg := &Config{Containers: map[string]Container{
+		"db":    {Image: "ubuntu:24.04"},
+		"db-v2": {Image: "ubuntu:24.04"},
+	}}
+	if got, err := cfg.ResolveContainer("db"); err != nil || got != "db" {
+		t.Errorf("expected exact match to win, got %q, %v", got, err)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"dev1", "dev1", 0},
+		{"dve1", "dev1", 1},
+		{"dev", "dev1", 1},
+		{"kitten", "sitting", 3},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
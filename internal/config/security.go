@@ -0,0 +1,234 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"lxc-dev-manager/internal/errcode"
+	"lxc-dev-manager/internal/validation"
+
+	"gopkg.in/yaml.v3"
+)
+
+// UserConfigFile is the per-user settings file, relative to the user config
+// directory ($XDG_CONFIG_HOME or ~/.config on Linux)
+const UserConfigFile = "lxc-dev-manager/config.yaml"
+
+// SystemConfigPath is the system-wide settings file, for administrators of
+// shared machines. Users can't relax what it sets.
+var SystemConfigPath = "/etc/lxc-dev-manager/config.yaml"
+
+// Security adjusts which host paths can be mounted. Blocked and risky
+// entries extend the built-in lists; allowed entries take paths off them
+// and need override_builtin to make the relaxation explicit.
+type Security struct {
+	BlockedHostPaths    []string `yaml:"blocked_host_paths,omitempty"`    // Can't be mounted (exact path)
+	BlockedHostPatterns []string `yaml:"blocked_host_patterns,omitempty"` // Can't be mounted (path suffix, e.g. /.kube)
+	RiskyHostPaths      []string `yaml:"risky_host_paths,omitempty"`      // Mounted only after confirmation or --allow-risky
+	AllowedHostPaths    []string `yaml:"allowed_host_paths,omitempty"`    // Built-in blocked or risky entries to drop
+	OverrideBuiltin     bool     `yaml:"override_builtin,omitempty"`      // Required for allowed_host_paths
+}
+
+// Policy hard-denies mount options regardless of command-line flags. It is
+// only read from the system and user settings files, never from a project.
+type Policy struct {
+	DenyRisky             bool     `yaml:"deny_risky,omitempty"`              // Risky paths can't be mounted, even with --allow-risky
+	DenyRWOutsideProject  bool     `yaml:"deny_rw_outside_project,omitempty"` // Read-write mounts only from inside the project directory
+	ReadOnlyPaths         []string `yaml:"read_only_paths,omitempty"`         // Paths (and everything below) only mounted read-only
+	DenySecurityOverrides bool     `yaml:"deny_security_overrides,omitempty"` // Reject allowed_host_paths in user and project settings
+}
+
+// GlobalConfig holds the settings that apply to every project. The system
+// and per-user settings files share this format.
+type GlobalConfig struct {
+	Security Security `yaml:"security,omitempty"`
+	Policy   Policy   `yaml:"policy,omitempty"`
+}
+
+// UserConfigPath returns the path of the per-user settings file
+func UserConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, UserConfigFile), nil
+}
+
+// LoadUserConfig reads the per-user settings file. A missing file gives an
+// empty config.
+func LoadUserConfig() (*GlobalConfig, error) {
+	path, err := UserConfigPath()
+	if err != nil {
+		return &GlobalConfig{}, nil
+	}
+	return loadGlobalConfig(path)
+}
+
+// LoadSystemConfig reads the system-wide settings file. A missing file
+// gives an empty config.
+func LoadSystemConfig() (*GlobalConfig, error) {
+	return loadGlobalConfig(SystemConfigPath)
+}
+
+func loadGlobalConfig(path string) (*GlobalConfig, error) {
+	var cfg GlobalConfig
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &cfg, nil
+		}
+		return nil, err
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && err != io.EOF {
+		return nil, errcode.Errorf(errcode.Validation, "", "invalid YAML in %s: %w", path, err)
+	}
+	if err := validateSecurity(cfg.Security); err != nil {
+		return nil, errcode.Errorf(errcode.Validation, "", "invalid security settings in %s: %w", path, err)
+	}
+	for _, p := range cfg.Policy.ReadOnlyPaths {
+		if !strings.HasPrefix(p, "/") || filepath.Clean(p) != p || containsControlChars(p) {
+			return nil, errcode.Errorf(errcode.Validation, "", "invalid policy in %s: read_only_paths: %q must be an absolute, clean path", path, p)
+		}
+	}
+	return &cfg, nil
+}
+
+// loadGlobalConfigs returns the system and then the user settings
+func loadGlobalConfigs() (system, user *GlobalConfig, err error) {
+	if system, err = LoadSystemConfig(); err != nil {
+		return nil, nil, err
+	}
+	if user, err = LoadUserConfig(); err != nil {
+		return nil, nil, err
+	}
+	return system, user, nil
+}
+
+// LoadPolicy returns the mount policy: the system and user policies
+// combined, so anything either denies is denied
+func LoadPolicy() (Policy, error) {
+	system, user, err := loadGlobalConfigs()
+	if err != nil {
+		return Policy{}, err
+	}
+	return mergePolicies(system.Policy, user.Policy), nil
+}
+
+func mergePolicies(a, b Policy) Policy {
+	return Policy{
+		DenyRisky:             a.DenyRisky || b.DenyRisky,
+		DenyRWOutsideProject:  a.DenyRWOutsideProject || b.DenyRWOutsideProject,
+		ReadOnlyPaths:         append(append([]string(nil), a.ReadOnlyPaths...), b.ReadOnlyPaths...),
+		DenySecurityOverrides: a.DenySecurityOverrides || b.DenySecurityOverrides,
+	}
+}
+
+// CheckMount returns an error when the policy denies mounting the resolved
+// host path source. risky says whether the path policy flagged it.
+func (p Policy) CheckMount(source, projectDir string, readWrite, risky bool) error {
+	if risky && p.DenyRisky {
+		return errcode.Errorf(errcode.Validation, "", "mounting '%s' is denied by policy (deny_risky)", source)
+	}
+	if !readWrite {
+		return nil
+	}
+	if p.DenyRWOutsideProject {
+		if resolved, err := filepath.EvalSymlinks(projectDir); err == nil {
+			projectDir = resolved
+		}
+		if !isWithin(source, projectDir) {
+			return errcode.Errorf(errcode.Validation, "", "read-write mounts outside the project directory are denied by policy (deny_rw_outside_project); mount '%s' read-only", source)
+		}
+	}
+	for _, dir := range p.ReadOnlyPaths {
+		if isWithin(source, dir) {
+			return errcode.Errorf(errcode.Validation, "", "read-write mounts under %s are denied by policy (read_only_paths); mount '%s' read-only", dir, source)
+		}
+	}
+	return nil
+}
+
+// isWithin reports whether path is dir or below it
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, "../")
+}
+
+// PathPolicy returns the host path policy for this project: the built-in
+// lists with the system's, the user's and then the project's security
+// settings applied. Paths blocked at one level can't be allowed again by a
+// later one.
+func (c *Config) PathPolicy() (validation.PathPolicy, error) {
+	system, user, err := loadGlobalConfigs()
+	if err != nil {
+		return validation.PathPolicy{}, err
+	}
+	policy := mergePolicies(system.Policy, user.Policy)
+
+	path := validation.DefaultPathPolicy()
+	levels := []struct {
+		name     string
+		security Security
+	}{
+		{"system", system.Security},
+		{"user", user.Security},
+		{"project", c.Security},
+	}
+	for _, level := range levels {
+		if !level.security.OverrideBuiltin || len(level.security.AllowedHostPaths) == 0 {
+			continue
+		}
+		if policy.DenySecurityOverrides && level.name != "system" {
+			return validation.PathPolicy{}, errcode.Errorf(errcode.Validation, "", "allowed_host_paths in the %s settings is denied by policy (deny_security_overrides)", level.name)
+		}
+		path.Allow(level.security.AllowedHostPaths...)
+	}
+	for _, level := range levels {
+		path.BlockedPaths = append(path.BlockedPaths, level.security.BlockedHostPaths...)
+		path.BlockedPatterns = append(path.BlockedPatterns, level.security.BlockedHostPatterns...)
+		path.RiskyPaths = append(path.RiskyPaths, level.security.RiskyHostPaths...)
+	}
+	return path, nil
+}
+
+// validateSecurity checks the entries of a security section
+func validateSecurity(s Security) error {
+	for _, list := range []struct {
+		key   string
+		paths []string
+	}{
+		{"blocked_host_paths", s.BlockedHostPaths},
+		{"blocked_host_patterns", s.BlockedHostPatterns},
+		{"risky_host_paths", s.RiskyHostPaths},
+		{"allowed_host_paths", s.AllowedHostPaths},
+	} {
+		for _, path := range list.paths {
+			if !strings.HasPrefix(path, "/") || filepath.Clean(path) != path || containsControlChars(path) {
+				return fmt.Errorf("%s: %q must be an absolute, clean path", list.key, path)
+			}
+		}
+	}
+
+	if len(s.AllowedHostPaths) == 0 {
+		return nil
+	}
+	if !s.OverrideBuiltin {
+		return errors.New("allowed_host_paths relaxes the built-in protections; set override_builtin: true to confirm")
+	}
+	builtin := validation.DefaultPathPolicy()
+	for _, path := range s.AllowedHostPaths {
+		if !slices.Contains(builtin.BlockedPaths, path) && !slices.Contains(builtin.BlockedPatterns, path) && !slices.Contains(builtin.RiskyPaths, path) {
+			return fmt.Errorf("allowed_host_paths: %q is not a built-in blocked or risky path", path)
+		}
+	}
+	return nil
+}
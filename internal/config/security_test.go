@@ -0,0 +1,209 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// writeUserConfig points the user config directory at a temp dir holding
+// the given config.yaml
+func writeUserConfig(t *testing.T, content string) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	path := filepath.Join(dir, UserConfigFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateSecurity(t *testing.T) {
+	tests := []struct {
+		name     string
+		security Security
+		wantErr  string
+	}{
+		{"empty", Security{}, ""},
+		{"extend", Security{BlockedHostPaths: []string{"/srv/secrets"}, BlockedHostPatterns: []string{"/.kube"}, RiskyHostPaths: []string{"/mnt"}}, ""},
+		{"relax", Security{AllowedHostPaths: []string{"/home", "/.aws"}, OverrideBuiltin: true}, ""},
+		{"relax without override", Security{AllowedHostPaths: []string{"/home"}}, "override_builtin"},
+		{"relax unknown path", Security{AllowedHostPaths: []string{"/srv"}, OverrideBuiltin: true}, "not a built-in"},
+		{"relative path", Security{BlockedHostPaths: []string{"secrets"}}, "blocked_host_paths"},
+		{"unclean path", Security{RiskyHostPaths: []string{"/mnt/"}}, "risky_host_paths"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSecurity(tt.security)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestPathPolicy_MergesUserAndProject(t *testing.T) {
+	writeUserConfig(t, `security:
+  blocked_host_paths: [/srv/secrets]
+  allowed_host_paths: [/home]
+  override_builtin: true
+`)
+	cfg := &Config{Project: "test", Security: Security{
+		BlockedHostPatterns: []string{"/.kube"},
+		RiskyHostPaths:      []string{"/mnt"},
+	}}
+
+	policy, err := cfg.PathPolicy()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Contains(policy.BlockedPaths, "/srv/secrets") || !slices.Contains(policy.BlockedPaths, "/etc") {
+		t.Errorf("expected user and built-in blocked paths, got %v", policy.BlockedPaths)
+	}
+	if !slices.Contains(policy.BlockedPatterns, "/.kube") {
+		t.Errorf("expected project blocked pattern, got %v", policy.BlockedPatterns)
+	}
+	if slices.Contains(policy.RiskyPaths, "/home") || !slices.Contains(policy.RiskyPaths, "/mnt") {
+		t.Errorf("expected /home allowed and /mnt risky, got %v", policy.RiskyPaths)
+	}
+}
+
+func TestPathPolicy_ProjectCannotAllowUserBlockedPath(t *testing.T) {
+	writeUserConfig(t, `security:
+  blocked_host_paths: [/home]
+`)
+	cfg := &Config{Project: "test", Security: Security{AllowedHostPaths: []string{"/home"}, OverrideBuiltin: true}}
+
+	policy, err := cfg.PathPolicy()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Contains(policy.BlockedPaths, "/home") {
+		t.Errorf("expected /home to stay blocked, got %v", policy.BlockedPaths)
+	}
+}
+
+func TestLoadUserConfig(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	if cfg, err := LoadUserConfig(); err != nil || len(cfg.Security.BlockedHostPaths) != 0 {
+		t.Errorf("expected empty config without a file, got %+v, %v", cfg, err)
+	}
+
+	writeUserConfig(t, "security:\n  blocked_paths: [/srv]\n")
+	if _, err := LoadUserConfig(); err == nil {
+		t.Error("expected error for unknown key")
+	}
+
+	writeUserConfig(t, "security:\n  allowed_host_paths: [/home]\n")
+	if _, err := LoadUserConfig(); err == nil || !strings.Contains(err.Error(), "override_builtin") {
+		t.Errorf("expected override_builtin error, got %v", err)
+	}
+}
+
+// writeSystemConfig points SystemConfigPath at a temp file with content
+func writeSystemConfig(t *testing.T, content string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := SystemConfigPath
+	SystemConfigPath = path
+	t.Cleanup(func() { SystemConfigPath = old })
+}
+
+func TestPolicy_CheckMount(t *testing.T) {
+	project := t.TempDir()
+	policy := Policy{DenyRisky: true, DenyRWOutsideProject: true, ReadOnlyPaths: []string{"/home"}}
+
+	tests := []struct {
+		name      string
+		policy    Policy
+		source    string
+		readWrite bool
+		risky     bool
+		wantErr   string
+	}{
+		{"ro outside project", policy, "/srv/data", false, false, ""},
+		{"rw inside project", Policy{DenyRWOutsideProject: true}, filepath.Join(project, "src"), true, false, ""},
+		{"rw outside project", policy, "/srv/data", true, false, "deny_rw_outside_project"},
+		{"rw sibling with project prefix", Policy{DenyRWOutsideProject: true}, project + "-other", true, false, "deny_rw_outside_project"},
+		{"risky", policy, "/tmp", false, true, "deny_risky"},
+		{"rw under read-only path", Policy{ReadOnlyPaths: []string{"/home"}}, "/home/alice/src", true, false, "read_only_paths"},
+		{"ro under read-only path", Policy{ReadOnlyPaths: []string{"/home"}}, "/home/alice/src", false, false, ""},
+		{"rw next to read-only path", Policy{ReadOnlyPaths: []string{"/home"}}, "/homework", true, false, ""},
+		{"no policy", Policy{}, "/tmp", true, true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.CheckMount(tt.source, project, tt.readWrite, tt.risky)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLoadPolicy_CombinesSystemAndUser(t *testing.T) {
+	writeSystemConfig(t, `policy:
+  deny_risky: true
+  read_only_paths: [/home]
+`)
+	writeUserConfig(t, `policy:
+  deny_rw_outside_project: true
+  read_only_paths: [/srv]
+`)
+
+	policy, err := LoadPolicy()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !policy.DenyRisky || !policy.DenyRWOutsideProject {
+		t.Errorf("expected both denials, got %+v", policy)
+	}
+	if !slices.Equal(policy.ReadOnlyPaths, []string{"/home", "/srv"}) {
+		t.Errorf("expected read-only paths from both files, got %v", policy.ReadOnlyPaths)
+	}
+}
+
+func TestPathPolicy_DenySecurityOverrides(t *testing.T) {
+	writeSystemConfig(t, `policy:
+  deny_security_overrides: true
+security:
+  blocked_host_paths: [/srv/secrets]
+`)
+	writeUserConfig(t, "")
+	cfg := &Config{Project: "test", Security: Security{AllowedHostPaths: []string{"/home"}, OverrideBuiltin: true}}
+
+	if _, err := cfg.PathPolicy(); err == nil || !strings.Contains(err.Error(), "deny_security_overrides") {
+		t.Fatalf("expected project override to be denied, got %v", err)
+	}
+
+	cfg.Security = Security{}
+	policy, err := cfg.PathPolicy()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Contains(policy.BlockedPaths, "/srv/secrets") {
+		t.Errorf("expected system blocked path, got %v", policy.BlockedPaths)
+	}
+}
@@ -0,0 +1,270 @@
+// Package devcontainer reads and writes the subset of the Development
+// Containers spec (devcontainer.json) that maps onto project containers:
+// image, forwarded ports, user, workspace folder, bind mounts and the
+// lifecycle commands.
+package devcontainer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultPath is where tools look for the spec, relative to the project
+const DefaultPath = ".devcontainer/devcontainer.json"
+
+// WorkspaceVar is replaced with the project directory in mount sources
+const WorkspaceVar = "${localWorkspaceFolder}"
+
+// Spec is a devcontainer.json document. Lifecycle commands and ports keep
+// the spec's loose typing; use Commands and Ports to read them.
+type Spec struct {
+	Name              string         `json:"name,omitempty"`
+	Image             string         `json:"image,omitempty"`
+	Build             map[string]any `json:"build,omitempty"`
+	ForwardPorts      []any          `json:"forwardPorts,omitempty"`
+	RemoteUser        string         `json:"remoteUser,omitempty"`
+	ContainerUser     string         `json:"containerUser,omitempty"`
+	WorkspaceFolder   string         `json:"workspaceFolder,omitempty"`
+	Mounts            []any          `json:"mounts,omitempty"`
+	PostCreateCommand any            `json:"postCreateCommand,omitempty"`
+	PostStartCommand  any            `json:"postStartCommand,omitempty"`
+	Customizations    map[string]any `json:"customizations,omitempty"`
+}
+
+// Mount is a bind mount from the spec
+type Mount struct {
+	Source   string
+	Target   string
+	ReadOnly bool
+}
+
+// Load reads and parses the spec at path
+func Load(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	spec, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	return spec, nil
+}
+
+// Parse parses a devcontainer.json document. Comments and trailing commas
+// are allowed, as in the files VS Code writes.
+func Parse(data []byte) (*Spec, error) {
+	var spec Spec
+	if err := json.Unmarshal(stripJSONC(data), &spec); err != nil {
+		return nil, err
+	}
+	return &spec, nil
+}
+
+// Marshal renders the spec as indented JSON
+func (s *Spec) Marshal() ([]byte, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// User returns the user tools should connect as
+func (s *Spec) User() string {
+	if s.RemoteUser != "" {
+		return s.RemoteUser
+	}
+	return s.ContainerUser
+}
+
+// Ports returns the forwarded container ports. Entries may be numbers or
+// "host:port" strings; only the port is kept.
+func (s *Spec) Ports() ([]int, error) {
+	var ports []int
+	for _, entry := range s.ForwardPorts {
+		switch v := entry.(type) {
+		case float64:
+			ports = append(ports, int(v))
+		case string:
+			_, portStr, found := strings.Cut(v, ":")
+			if !found {
+				portStr = v
+			}
+			port, err := strconv.Atoi(portStr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid forwardPorts entry %q", v)
+			}
+			ports = append(ports, port)
+		default:
+			return nil, fmt.Errorf("invalid forwardPorts entry %v", entry)
+		}
+	}
+	return ports, nil
+}
+
+// BindMounts returns the spec's bind mounts. Entries may be Docker
+// "--mount" strings or objects; volume and tmpfs mounts are returned
+// separately since they have no host directory to mount.
+func (s *Spec) BindMounts() (binds []Mount, skipped []string, err error) {
+	for _, entry := range s.Mounts {
+		fields := map[string]string{}
+		switch v := entry.(type) {
+		case string:
+			for _, part := range strings.Split(v, ",") {
+				key, value, _ := strings.Cut(part, "=")
+				fields[strings.TrimSpace(key)] = strings.TrimSpace(value)
+			}
+		case map[string]any:
+			for key, value := range v {
+				fields[key] = fmt.Sprint(value)
+			}
+		default:
+			return nil, nil, fmt.Errorf("invalid mounts entry %v", entry)
+		}
+
+		source := firstOf(fields, "source", "src")
+		target := firstOf(fields, "target", "dst", "destination")
+		if fields["type"] != "bind" {
+			skipped = append(skipped, fmt.Sprintf("%s mount %s", fields["type"], target))
+			continue
+		}
+		if source == "" || target == "" {
+			return nil, nil, fmt.Errorf("mounts entry %v needs a source and target", entry)
+		}
+		_, readonly := fields["readonly"]
+		if _, ro := fields["ro"]; ro {
+			readonly = true
+		}
+		binds = append(binds, Mount{Source: source, Target: target, ReadOnly: readonly})
+	}
+	return binds, skipped, nil
+}
+
+// MountString renders m as a Docker "--mount" string
+func MountString(m Mount) string {
+	s := "source=" + m.Source + ",target=" + m.Target + ",type=bind"
+	if m.ReadOnly {
+		s += ",readonly"
+	}
+	return s
+}
+
+func firstOf(fields map[string]string, keys ...string) string {
+	for _, key := range keys {
+		if v := fields[key]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// Commands normalizes a lifecycle command to shell command lines. The spec
+// allows a string (run by a shell), an array (run directly) or an object
+// of named commands; named commands are returned in name order.
+func Commands(command any) ([]string, error) {
+	switch v := command.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		if v == "" {
+			return nil, nil
+		}
+		return []string{v}, nil
+	case []any:
+		args := make([]string, 0, len(v))
+		for _, arg := range v {
+			s, ok := arg.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid command argument %v", arg)
+			}
+			args = append(args, shellQuote(s))
+		}
+		if len(args) == 0 {
+			return nil, nil
+		}
+		return []string{strings.Join(args, " ")}, nil
+	case map[string]any:
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var commands []string
+		for _, name := range names {
+			sub, err := Commands(v[name])
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			commands = append(commands, sub...)
+		}
+		return commands, nil
+	}
+	return nil, fmt.Errorf("invalid command %v", command)
+}
+
+// shellQuote quotes s for sh unless it's plainly safe
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./=:@%+", r))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// stripJSONC removes // and /* */ comments and trailing commas outside
+// strings so the standard decoder accepts the document
+func stripJSONC(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if inString {
+			out = append(out, c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out = append(out, data[i])
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+
+		switch {
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			if i < len(data) {
+				out = append(out, '\n')
+			}
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			i += 2
+			for i+1 < len(data) && !(data[i] == '*' && data[i+1] == '/') {
+				i++
+			}
+			i++
+		case c == ']' || c == '}':
+			// Drop a comma left dangling before the closing bracket
+			j := len(out) - 1
+			for j >= 0 && (out[j] == ' ' || out[j] == '\t' || out[j] == '\n' || out[j] == '\r') {
+				j--
+			}
+			if j >= 0 && out[j] == ',' {
+				out = append(out[:j], out[j+1:]...)
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
@@ -0,0 +1,110 @@
+package devcontainer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse_JSONC(t *testing.T) {
+	data := []byte(`{
+	// The image to use
+	"name": "web // app",
+	"image": "ubuntu:24.04", /* inline */
+	"forwardPorts": [3000, "localhost:5432",],
+	"mounts": [
+		"source=${localWorkspaceFolder}/data,target=/data,type=bind,readonly",
+		{"source": "cache", "target": "/cache", "type": "volume"},
+	],
+	"remoteUser": "dev",
+}`)
+
+	spec, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if spec.Name != "web // app" || spec.Image != "ubuntu:24.04" || spec.User() != "dev" {
+		t.Errorf("unexpected spec: %+v", spec)
+	}
+
+	ports, err := spec.Ports()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ports, []int{3000, 5432}) {
+		t.Errorf("unexpected ports: %v", ports)
+	}
+
+	binds, skipped, err := spec.BindMounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Mount{{Source: "${localWorkspaceFolder}/data", Target: "/data", ReadOnly: true}}
+	if !reflect.DeepEqual(binds, expected) {
+		t.Errorf("unexpected binds: %+v", binds)
+	}
+	if len(skipped) != 1 || skipped[0] != "volume mount /cache" {
+		t.Errorf("unexpected skipped mounts: %v", skipped)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	if _, err := Parse([]byte(`{"image": }`)); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
+
+func TestCommands(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  any
+		expected []string
+	}{
+		{"nil", nil, nil},
+		{"string", "npm install && npm run build", []string{"npm install && npm run build"}},
+		{"array", []any{"echo", "hello world"}, []string{"echo 'hello world'"}},
+		{"object", map[string]any{"b": "make", "a": []any{"npm", "ci"}}, []string{"npm ci", "make"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Commands(tt.command)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Commands(%v) = %q, expected %q", tt.command, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCommands_Invalid(t *testing.T) {
+	if _, err := Commands(42.0); err == nil {
+		t.Error("expected error for a number")
+	}
+}
+
+func TestMarshal_RoundTrip(t *testing.T) {
+	spec := &Spec{
+		Name:         "dev1",
+		Image:        "ubuntu:24.04",
+		ForwardPorts: []any{3000},
+		Mounts:       []any{MountString(Mount{Source: "/src", Target: "/dst", ReadOnly: true})},
+	}
+	data, err := spec.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	binds, _, err := parsed.BindMounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Image != "ubuntu:24.04" || len(binds) != 1 || binds[0].Target != "/dst" || !binds[0].ReadOnly {
+		t.Errorf("unexpected round trip: %+v %+v", parsed, binds)
+	}
+}
@@ -0,0 +1,319 @@
+// Package dns maintains host-side name resolution for project containers,
+// either as a managed block in /etc/hosts or as a dnsmasq drop-in file.
+package dns
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"syscall"
+
+	"lxc-dev-manager/internal/dryrun"
+)
+
+const (
+	// ModeHosts writes entries into a managed block of the hosts file
+	ModeHosts = "hosts"
+	// ModeDnsmasq writes a dnsmasq drop-in with address= lines
+	ModeDnsmasq = "dnsmasq"
+
+	// DefaultDomain is the top-level domain used when none is configured
+	DefaultDomain = "lxd"
+)
+
+var (
+	// HostsPath is the hosts file managed in ModeHosts
+	HostsPath = "/etc/hosts"
+	// DnsmasqDir is the drop-in directory used in ModeDnsmasq
+	DnsmasqDir = "/etc/dnsmasq.d"
+	// LockPath serializes hosts file updates between projects
+	LockPath = filepath.Join(os.TempDir(), "lxc-dev-manager-dns.lock")
+)
+
+// Entry maps a hostname to an IP address
+type Entry struct {
+	Hostname string
+	IP       string
+}
+
+// Options controls how entries are written
+type Options struct {
+	Mode   string
+	Domain string
+	Sudo   bool // Write through sudo when the file isn't writable
+}
+
+// ValidateMode checks that mode is a supported DNS mode (empty means disabled)
+func ValidateMode(mode string) error {
+	switch mode {
+	case "", ModeHosts, ModeDnsmasq:
+		return nil
+	}
+	return fmt.Errorf("invalid dns mode %q (allowed: %s, %s)", mode, ModeHosts, ModeDnsmasq)
+}
+
+// ValidateDomain checks that domain is a DNS name: dot-separated labels of
+// letters, digits and inner hyphens (empty means DefaultDomain). It ends up
+// in /etc/hosts and dnsmasq files written as root, so nothing else may.
+func ValidateDomain(domain string) error {
+	if domain == "" {
+		return nil
+	}
+	if len(domain) > 253 {
+		return fmt.Errorf("invalid dns domain: longer than 253 characters")
+	}
+	for _, label := range strings.Split(domain, ".") {
+		if !domainLabel.MatchString(label) {
+			return fmt.Errorf("invalid dns domain %q: labels must be 1-63 letters, digits or inner hyphens", domain)
+		}
+	}
+	return nil
+}
+
+// domainLabel matches one label of a domain name
+var domainLabel = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?$`)
+
+// Hostname builds "<container>.<project>.<domain>", omitting the project if empty
+func Hostname(container, project, domain string) string {
+	if domain == "" {
+		domain = DefaultDomain
+	}
+	if project == "" {
+		return container + "." + domain
+	}
+	return container + "." + project + "." + domain
+}
+
+// Apply writes the entries for a project, replacing any previous entries
+func Apply(project string, entries []Entry, opts Options) error {
+	switch opts.Mode {
+	case ModeHosts:
+		// Other projects update their own blocks of the same file
+		unlock, err := lockHosts()
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		current, err := os.ReadFile(HostsPath)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", HostsPath, err)
+		}
+		updated := RenderHostsBlock(string(current), project, entries)
+		if updated == string(current) {
+			return nil
+		}
+		return writeFile(HostsPath, []byte(updated), opts.Sudo)
+
+	case ModeDnsmasq:
+		path := DnsmasqPath(project)
+		if len(entries) == 0 {
+			return removeFile(path, opts.Sudo)
+		}
+		if err := writeFile(path, []byte(RenderDnsmasq(project, entries)), opts.Sudo); err != nil {
+			return err
+		}
+		return reloadDnsmasq(opts.Sudo)
+
+	case "":
+		return fmt.Errorf("dns is not configured for this project")
+	}
+	return ValidateMode(opts.Mode)
+}
+
+// Clear removes all entries for a project
+func Clear(project string, opts Options) error {
+	return Apply(project, nil, opts)
+}
+
+// DnsmasqPath returns the drop-in file path for a project
+func DnsmasqPath(project string) string {
+	name := "lxc-dev-manager.conf"
+	if project != "" {
+		name = "lxc-dev-manager-" + project + ".conf"
+	}
+	return filepath.Join(DnsmasqDir, name)
+}
+
+func blockMarkers(project string) (string, string) {
+	label := "lxc-dev-manager"
+	if project != "" {
+		label += " " + project
+	}
+	return "# BEGIN " + label, "# END " + label
+}
+
+// RenderHostsBlock returns hosts file content with the project's managed block
+// replaced by entries. An empty entry list removes the block entirely.
+func RenderHostsBlock(content, project string, entries []Entry) string {
+	begin, end := blockMarkers(project)
+
+	var kept []string
+	inBlock := false
+	for _, line := range strings.Split(content, "\n") {
+		switch {
+		case line == begin:
+			inBlock = true
+		case line == end && inBlock:
+			inBlock = false
+		case !inBlock:
+			kept = append(kept, line)
+		}
+	}
+
+	result := strings.TrimRight(strings.Join(kept, "\n"), "\n")
+	if len(entries) == 0 {
+		return result + "\n"
+	}
+
+	var b strings.Builder
+	b.WriteString(result)
+	if result != "" {
+		b.WriteString("\n")
+	}
+	b.WriteString(begin + "\n")
+	for _, e := range sortedEntries(entries) {
+		fmt.Fprintf(&b, "%s\t%s\n", e.IP, e.Hostname)
+	}
+	b.WriteString(end + "\n")
+	return b.String()
+}
+
+// RenderDnsmasq returns a dnsmasq drop-in mapping each hostname to its IP
+func RenderDnsmasq(project string, entries []Entry) string {
+	var b strings.Builder
+	begin, _ := blockMarkers(project)
+	b.WriteString(strings.Replace(begin, "# BEGIN", "# Managed by", 1) + "\n")
+	for _, e := range sortedEntries(entries) {
+		fmt.Fprintf(&b, "address=/%s/%s\n", e.Hostname, e.IP)
+	}
+	return b.String()
+}
+
+func sortedEntries(entries []Entry) []Entry {
+	sorted := append([]Entry(nil), entries...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Hostname < sorted[j].Hostname
+	})
+	return sorted
+}
+
+// lockHosts takes the lock that serializes hosts file updates across
+// projects and returns the function releasing it
+func lockHosts() (func(), error) {
+	// Read-only so another user's lock file can be locked too
+	f, err := os.OpenFile(LockPath, os.O_RDONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", LockPath, err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock %s: %w", LockPath, err)
+	}
+	return func() { f.Close() }, nil
+}
+
+// writeFile replaces path with data through a temporary file and a rename,
+// so it is never seen half-written, using sudo if permission is denied
+func writeFile(path string, data []byte, sudo bool) error {
+	if dryrun.Enabled() {
+		current, _ := os.ReadFile(path)
+		dryrun.Printf("write %s:\n%s", path, strings.Join(dryrun.Changes(string(current), string(data)), "\n"))
+		return nil
+	}
+	err := replaceFile(path, data)
+	if err == nil {
+		return nil
+	}
+	if !os.IsPermission(err) || !sudo {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return sudoReplaceFile(path, data)
+}
+
+func replaceFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // Fails harmlessly once renamed
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// sudoReplaceFile is replaceFile for files only root can write: the data
+// is staged next to path with sudo install, then renamed over it
+func sudoReplaceFile(path string, data []byte) error {
+	local, err := os.CreateTemp("", "lxc-dev-manager-dns-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(local.Name())
+	_, err = local.Write(data)
+	if closeErr := local.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	staged := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".lxc-dev-manager.tmp")
+	if output, err := exec.Command("sudo", "install", "-m", "0644", local.Name(), staged).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to write %s with sudo: %s", path, strings.TrimSpace(string(output)))
+	}
+	if output, err := exec.Command("sudo", "mv", "-f", staged, path).CombinedOutput(); err != nil {
+		exec.Command("sudo", "rm", "-f", staged).Run()
+		return fmt.Errorf("failed to write %s with sudo: %s", path, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func removeFile(path string, sudo bool) error {
+	if dryrun.Enabled() {
+		if _, err := os.Stat(path); err == nil {
+			dryrun.Printf("remove %s", path)
+		}
+		return nil
+	}
+	err := os.Remove(path)
+	if err == nil || os.IsNotExist(err) {
+		return nil
+	}
+	if !os.IsPermission(err) || !sudo {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+
+	if output, err := exec.Command("sudo", "rm", "-f", path).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to remove %s with sudo: %s", path, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func reloadDnsmasq(sudo bool) error {
+	args := []string{"systemctl", "reload-or-restart", "dnsmasq"}
+	if sudo {
+		args = append([]string{"sudo"}, args...)
+	}
+	if dryrun.Enabled() {
+		dryrun.Printf("%s", dryrun.Command(args[0], args[1:]...))
+		return nil
+	}
+	if output, err := exec.Command(args[0], args[1:]...).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to reload dnsmasq: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package dns
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestHostname(t *testing.T) {
+	tests := []struct {
+		container, project, domain string
+		expected                   string
+	}{
+		{"dev1", "webapp", "", "dev1.webapp.lxd"},
+		{"dev1", "webapp", "test", "dev1.webapp.test"},
+		{"dev1", "", "", "dev1.lxd"},
+	}
+
+	for _, tt := range tests {
+		if got := Hostname(tt.container, tt.project, tt.domain); got != tt.expected {
+			t.Errorf("Hostname(%q, %q, %q) = %q, expected %q", tt.container, tt.project, tt.domain, got, tt.expected)
+		}
+	}
+}
+
+func TestRenderHostsBlock_AddsBlock(t *testing.T) {
+	content := "127.0.0.1\tlocalhost\n"
+	result := RenderHostsBlock(content, "webapp", []Entry{
+		{Hostname: "dev2.webapp.lxd", IP: "10.0.0.3"},
+		{Hostname: "dev1.webapp.lxd", IP: "10.0.0.2"},
+	})
+
+	expected := "127.0.0.1\tlocalhost\n" +
+		"# BEGIN lxc-dev-manager webapp\n" +
+		"10.0.0.2\tdev1.webapp.lxd\n" +
+		"10.0.0.3\tdev2.webapp.lxd\n" +
+		"# END lxc-dev-manager webapp\n"
+	if result != expected {
+		t.Errorf("unexpected content:\n%s", result)
+	}
+}
+
+func TestRenderHostsBlock_ReplacesExistingBlock(t *testing.T) {
+	content := "127.0.0.1\tlocalhost\n" +
+		"# BEGIN lxc-dev-manager webapp\n" +
+		"10.0.0.9\tdev1.webapp.lxd\n" +
+		"# END lxc-dev-manager webapp\n" +
+		"192.168.1.1\trouter\n"
+
+	result := RenderHostsBlock(content, "webapp", []Entry{
+		{Hostname: "dev1.webapp.lxd", IP: "10.0.0.2"},
+	})
+
+	if strings.Contains(result, "10.0.0.9") {
+		t.Error("old entry should be removed")
+	}
+	if !strings.Contains(result, "10.0.0.2\tdev1.webapp.lxd") {
+		t.Error("new entry should be present")
+	}
+	if !strings.Contains(result, "192.168.1.1\trouter") {
+		t.Error("unrelated lines should be kept")
+	}
+	if strings.Count(result, "# BEGIN lxc-dev-manager webapp") != 1 {
+		t.Error("expected exactly one managed block")
+	}
+}
+
+func TestRenderHostsBlock_KeepsOtherProjects(t *testing.T) {
+	content := "# BEGIN lxc-dev-manager other\n" +
+		"10.0.0.5\tdev1.other.lxd\n" +
+		"# END lxc-dev-manager other\n"
+
+	result := RenderHostsBlock(content, "webapp", nil)
+	if !strings.Contains(result, "10.0.0.5\tdev1.other.lxd") {
+		t.Error("other project's block should be untouched")
+	}
+}
+
+func TestRenderHostsBlock_EmptyRemovesBlock(t *testing.T) {
+	content := "127.0.0.1\tlocalhost\n" +
+		"# BEGIN lxc-dev-manager webapp\n" +
+		"10.0.0.2\tdev1.webapp.lxd\n" +
+		"# END lxc-dev-manager webapp\n"
+
+	result := RenderHostsBlock(content, "webapp", nil)
+	if result != "127.0.0.1\tlocalhost\n" {
+		t.Errorf("expected block to be removed, got:\n%s", result)
+	}
+}
+
+func TestRenderDnsmasq(t *testing.T) {
+	result := RenderDnsmasq("webapp", []Entry{
+		{Hostname: "dev1.webapp.lxd", IP: "10.0.0.2"},
+	})
+	if !strings.Contains(result, "address=/dev1.webapp.lxd/10.0.0.2\n") {
+		t.Errorf("unexpected content:\n%s", result)
+	}
+}
+
+func TestApply_Hosts(t *testing.T) {
+	dir := t.TempDir()
+	old, oldLock := HostsPath, LockPath
+	HostsPath, LockPath = filepath.Join(dir, "hosts"), filepath.Join(dir, "dns.lock")
+	defer func() { HostsPath, LockPath = old, oldLock }()
+
+	if err := os.WriteFile(HostsPath, []byte("127.0.0.1\tlocalhost\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := Options{Mode: ModeHosts}
+	if err := Apply("webapp", []Entry{{Hostname: "dev1.webapp.lxd", IP: "10.0.0.2"}}, opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, _ := os.ReadFile(HostsPath)
+	if !strings.Contains(string(data), "10.0.0.2\tdev1.webapp.lxd") {
+		t.Errorf("expected entry in hosts file, got:\n%s", data)
+	}
+
+	if err := Clear("webapp", opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ = os.ReadFile(HostsPath)
+	if string(data) != "127.0.0.1\tlocalhost\n" {
+		t.Errorf("expected hosts file restored, got:\n%s", data)
+	}
+}
+
+func TestApply_NotConfigured(t *testing.T) {
+	if err := Apply("webapp", nil, Options{}); err == nil {
+		t.Error("expected error when mode is empty")
+	}
+}
+
+func TestValidateMode(t *testing.T) {
+	for _, mode := range []string{"", ModeHosts, ModeDnsmasq} {
+		if err := ValidateMode(mode); err != nil {
+			t.Errorf("ValidateMode(%q) unexpected error: %v", mode, err)
+		}
+	}
+	if err := ValidateMode("bind"); err == nil {
+		t.Error("expected error for unsupported mode")
+	}
+}
+
+func TestApply_HostsConcurrentProjects(t *testing.T) {
+	dir := t.TempDir()
+	old, oldLock := HostsPath, LockPath
+	HostsPath, LockPath = filepath.Join(dir, "hosts"), filepath.Join(dir, "dns.lock")
+	defer func() { HostsPath, LockPath = old, oldLock }()
+	if err := os.WriteFile(HostsPath, []byte("127.0.0.1\tlocalhost\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			project := fmt.Sprintf("p%d", i)
+			entry := Entry{Hostname: "dev1." + project + ".lxd", IP: fmt.Sprintf("10.0.0.%d", i+2)}
+			if err := Apply(project, []Entry{entry}, Options{Mode: ModeHosts}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	data, _ := os.ReadFile(HostsPath)
+	for i := range 8 {
+		if !strings.Contains(string(data), fmt.Sprintf("dev1.p%d.lxd", i)) {
+			t.Errorf("entry of project p%d was lost:\n%s", i, data)
+		}
+	}
+}
+
+func TestValidateDomain(t *testing.T) {
+	for _, domain := range []string{"", "lxd", "dev.example.com", "my-lab.internal"} {
+		if err := ValidateDomain(domain); err != nil {
+			t.Errorf("ValidateDomain(%q) unexpected error: %v", domain, err)
+		}
+	}
+	for _, domain := range []string{"lxd\ndhcp-script=/tmp/x", "lxd/1.2.3.4", "-lxd", "a..b", "lxd.", "with space", strings.Repeat("a", 64)} {
+		if err := ValidateDomain(domain); err == nil {
+			t.Errorf("ValidateDomain(%q) expected an error", domain)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// Package dryrun holds the process-wide dry-run switch. When it's on,
+// anything that would change LXC, the config or host files is printed
+// instead of done; read-only queries still run so commands can plan.
+package dryrun
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	mu  sync.Mutex
+	out io.Writer
+)
+
+// Enable turns dry-run mode on, printing skipped actions to w
+func Enable(w io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+	out = w
+}
+
+// Disable turns dry-run mode off
+func Disable() {
+	mu.Lock()
+	defer mu.Unlock()
+	out = nil
+}
+
+// Enabled reports whether dry-run mode is on
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return out != nil
+}
+
+// Printf reports a skipped action. Each line is prefixed with "[dry-run]".
+func Printf(format string, args ...any) {
+	mu.Lock()
+	defer mu.Unlock()
+	if out == nil {
+		return
+	}
+	msg := strings.TrimRight(fmt.Sprintf(format, args...), "\n")
+	for _, line := range strings.Split(msg, "\n") {
+		fmt.Fprintf(out, "[dry-run] %s\n", line)
+	}
+}
+
+// Command formats a command line for display, quoting arguments that the
+// shell would split or interpret
+func Command(name string, args ...string) string {
+	parts := make([]string, 0, len(args)+1)
+	parts = append(parts, name)
+	for _, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n'\"\\$`*?;&|<>()[]{}#~") {
+			arg = strconv.Quote(arg)
+		}
+		parts = append(parts, arg)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Diff returns a line diff from before to after: unchanged lines are
+// indented by two spaces, removed ones start with "- " and added ones
+// with "+ "
+func Diff(before, after string) []string {
+	a := splitLines(before)
+	b := splitLines(after)
+
+	// Longest common subsequence table, filled from the end
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []string
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, "  "+a[i])
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] > lcs[i+1][j]):
+			lines = append(lines, "+ "+b[j])
+			j++
+		default:
+			lines = append(lines, "- "+a[i])
+			i++
+		}
+	}
+	return lines
+}
+
+// Changes is Diff without the unchanged lines
+func Changes(before, after string) []string {
+	var changed []string
+	for _, line := range Diff(before, after) {
+		if !strings.HasPrefix(line, "  ") {
+			changed = append(changed, line)
+		}
+	}
+	return changed
+}
+
+func splitLines(s string) []string {
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
@@ -0,0 +1,48 @@
+package dryrun
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestPrintf(t *testing.T) {
+	var buf bytes.Buffer
+	Printf("ignored while disabled")
+
+	Enable(&buf)
+	defer Disable()
+	if !Enabled() {
+		t.Fatal("expected dry-run to be enabled")
+	}
+	Printf("would write %s:\n+ ports: [3000]", "containers.yaml")
+
+	want := "[dry-run] would write containers.yaml:\n[dry-run] + ports: [3000]\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestCommand(t *testing.T) {
+	got := Command("lxc", "exec", "dev1", "--", "sh", "-c", "echo hi > /tmp/x")
+	want := `lxc exec dev1 -- sh -c "echo hi > /tmp/x"`
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestChanges(t *testing.T) {
+	before := "project: p\ncontainers:\n  dev1:\n    ports: [3000]\n"
+	after := "project: p\ncontainers:\n  dev1:\n    ports: [3000, 8080]\n  dev2:\n    image: ubuntu\n"
+
+	got := Changes(before, after)
+	want := []string{
+		"-     ports: [3000]",
+		"+     ports: [3000, 8080]",
+		"+   dev2:",
+		"+     image: ubuntu",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
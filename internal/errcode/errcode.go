@@ -0,0 +1,146 @@
+// Package errcode classifies errors by failure type so the CLI can exit
+// with a distinct status for each and report them as JSON. Errors are
+// tagged where they're created; wrapping them with %w keeps the tag.
+package errcode
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Code names a kind of failure
+type Code string
+
+// Failure types, from least to most specific
+const (
+	General    Code = "error"
+	Usage      Code = "usage"
+	NotFound   Code = "not_found"
+	NotRunning Code = "not_running"
+	Validation Code = "validation"
+	LXC        Code = "lxc_failure"
+	Locked     Code = "locked"
+)
+
+// exitCodes maps each code to the process exit status it produces
+var exitCodes = map[Code]int{
+	General:    1,
+	Usage:      2,
+	NotFound:   3,
+	NotRunning: 4,
+	Validation: 5,
+	LXC:        6,
+	Locked:     7,
+}
+
+// ExitCode returns the exit status for code (1 for unknown codes)
+func (c Code) ExitCode() int {
+	if status, ok := exitCodes[c]; ok {
+		return status
+	}
+	return 1
+}
+
+// HTTPStatus returns the response status for code, for the servers that
+// report failures over HTTP
+func (c Code) HTTPStatus() int {
+	switch c {
+	case NotFound:
+		return http.StatusNotFound
+	case NotRunning, Locked:
+		return http.StatusConflict
+	case Validation, Usage:
+		return http.StatusBadRequest
+	case LXC:
+		return http.StatusBadGateway
+	}
+	return http.StatusInternalServerError
+}
+
+// Error is an error tagged with a failure type and, when known, the
+// container it's about
+type Error struct {
+	Code      Code
+	Container string
+	Err       error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New tags err with code. A nil err stays nil.
+func New(code Code, container string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Code: code, Container: container, Err: err}
+}
+
+// Errorf formats an error tagged with code
+func Errorf(code Code, container, format string, args ...any) error {
+	return &Error{Code: code, Container: container, Err: fmt.Errorf(format, args...)}
+}
+
+// Of returns the failure type of err: the innermost tag wins over outer
+// ones, since it was set closest to the cause. Untagged errors are General.
+func Of(err error) Code {
+	code := General
+	for err != nil {
+		var e *Error
+		if !errors.As(err, &e) {
+			break
+		}
+		code = e.Code
+		err = e.Err
+	}
+	return code
+}
+
+// ContainerOf returns the container named by the innermost tag that has one
+func ContainerOf(err error) string {
+	container := ""
+	for err != nil {
+		var e *Error
+		if !errors.As(err, &e) {
+			break
+		}
+		if e.Container != "" {
+			container = e.Container
+		}
+		err = e.Err
+	}
+	return container
+}
+
+// usagePrefixes are the starts of cobra's own argument and flag errors,
+// which aren't tagged at their source
+var usagePrefixes = []string{
+	"unknown command",
+	"unknown flag",
+	"unknown shorthand flag",
+	"flag needs an argument",
+	"invalid argument",
+	"required flag",
+	"accepts ",
+	"requires at least",
+	"requires at most",
+	"if any flags in the group",
+}
+
+// IsUsage reports whether err's message is one of cobra's usage errors
+func IsUsage(err error) bool {
+	msg := err.Error()
+	for _, prefix := range usagePrefixes {
+		if strings.HasPrefix(msg, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package errcode
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestOf(t *testing.T) {
+	notFound := Errorf(NotFound, "dev1", "container '%s' not found", "dev1")
+
+	tests := []struct {
+		name string
+		err  error
+		want Code
+	}{
+		{"untagged", errors.New("boom"), General},
+		{"tagged", notFound, NotFound},
+		{"wrapped", fmt.Errorf("failed to start: %w", notFound), NotFound},
+		{"innermost wins", Errorf(Validation, "", "invalid configuration: %w", Errorf(LXC, "", "lxc failed")), LXC},
+		{"nil", nil, General},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Of(tt.err); got != tt.want {
+				t.Errorf("Of() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContainerOf(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", Errorf(LXC, "", "inner: %w", Errorf(NotFound, "dev1", "missing")))
+	if got := ContainerOf(err); got != "dev1" {
+		t.Errorf("ContainerOf() = %q, want dev1", got)
+	}
+	if got := ContainerOf(errors.New("plain")); got != "" {
+		t.Errorf("ContainerOf() = %q, want empty", got)
+	}
+}
+
+func TestError_KeepsMessageAndCause(t *testing.T) {
+	cause := errors.New("no such file")
+	err := New(NotFound, "", cause)
+	if err.Error() != "no such file" {
+		t.Errorf("Error() = %q", err.Error())
+	}
+	if !errors.Is(err, cause) {
+		t.Error("expected the cause to be unwrappable")
+	}
+	if New(NotFound, "", nil) != nil {
+		t.Error("expected nil for a nil error")
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	tests := map[Code]int{
+		General:    1,
+		Usage:      2,
+		NotFound:   3,
+		NotRunning: 4,
+		Validation: 5,
+		LXC:        6,
+		Locked:     7,
+		"other":    1,
+	}
+	for code, want := range tests {
+		if got := code.ExitCode(); got != want {
+			t.Errorf("%s.ExitCode() = %d, want %d", code, got, want)
+		}
+	}
+}
+
+func TestIsUsage(t *testing.T) {
+	if !IsUsage(errors.New("accepts 1 arg(s), received 0")) {
+		t.Error("expected an argument count error to be a usage error")
+	}
+	if !IsUsage(errors.New(`unknown command "bogus" for "lxc-dev-manager"`)) {
+		t.Error("expected an unknown command to be a usage error")
+	}
+	if IsUsage(errors.New("failed to start container")) {
+		t.Error("expected other errors not to be usage errors")
+	}
+}
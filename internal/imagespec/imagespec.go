@@ -0,0 +1,115 @@
+// Package imagespec reads image build specs: YAML files describing how to
+// provision a base image into a reusable one.
+//
+//	base: ubuntu:24.04
+//	alias: webapp-base
+//	packages: [build-essential, postgresql-client]
+//	files:
+//	  - source: ./bashrc
+//	    dest: /etc/skel/.bashrc
+//	run:
+//	  - curl -fsSL https://deb.nodesource.com/setup_20.x | bash -
+//	  - apt-get install -y nodejs
+package imagespec
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"lxc-dev-manager/internal/validation"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Spec describes an image build
+type Spec struct {
+	Base     string   `yaml:"base"`
+	Alias    string   `yaml:"alias"`
+	Packages []string `yaml:"packages,omitempty"`
+	Files    []File   `yaml:"files,omitempty"`
+	Run      []string `yaml:"run,omitempty"`
+}
+
+// File is a host file or directory copied into the image
+type File struct {
+	Source string `yaml:"source"` // Relative paths are resolved against the spec's directory
+	Dest   string `yaml:"dest"`
+	Mode   string `yaml:"mode,omitempty"` // Octal permissions, e.g. "0755"
+}
+
+// Load reads and validates the spec at path, resolving file sources
+// against its directory
+func Load(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	spec, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	for i, f := range spec.Files {
+		if !filepath.IsAbs(f.Source) {
+			spec.Files[i].Source = filepath.Join(dir, f.Source)
+		}
+	}
+	return spec, nil
+}
+
+// Parse decodes and validates a spec. Unknown keys are rejected so typos
+// don't silently drop a step.
+func Parse(data []byte) (*Spec, error) {
+	var spec Spec
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&spec); err != nil {
+		if err == io.EOF {
+			return nil, fmt.Errorf("spec is empty")
+		}
+		return nil, err
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	return &spec, nil
+}
+
+// Validate checks the spec's required fields
+func (s *Spec) Validate() error {
+	if s.Base == "" {
+		return fmt.Errorf("base is required")
+	}
+	for _, pkg := range s.Packages {
+		if pkg == "" || strings.ContainsAny(pkg, " \t'\"`$;&|<>") {
+			return fmt.Errorf("invalid package name %q", pkg)
+		}
+	}
+	for _, f := range s.Files {
+		if f.Source == "" || f.Dest == "" {
+			return fmt.Errorf("file entries need a source and dest")
+		}
+		if err := validation.ValidateContainerPath(f.Dest); err != nil {
+			return err
+		}
+		if f.Mode != "" {
+			if err := validation.ValidateFileMode(f.Mode); err != nil {
+				return err
+			}
+		}
+	}
+	for _, command := range s.Run {
+		if strings.TrimSpace(command) == "" {
+			return fmt.Errorf("run commands must not be empty")
+		}
+	}
+	return nil
+}
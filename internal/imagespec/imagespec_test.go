@@ -0,0 +1,62 @@
+package imagespec
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "image.yaml")
+	os.WriteFile(path, []byte(`base: ubuntu:24.04
+alias: webapp-base
+packages: [git]
+files:
+  - source: ./bashrc
+    dest: /etc/skel/.bashrc
+  - source: /opt/tools
+    dest: /opt/tools
+run:
+  - make install
+`), 0644)
+
+	spec, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if spec.Base != "ubuntu:24.04" || spec.Alias != "webapp-base" || !reflect.DeepEqual(spec.Run, []string{"make install"}) {
+		t.Errorf("unexpected spec: %+v", spec)
+	}
+	if spec.Files[0].Source != filepath.Join(dir, "bashrc") || spec.Files[1].Source != "/opt/tools" {
+		t.Errorf("expected relative sources resolved against the spec, got %+v", spec.Files)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		wantErr string
+	}{
+		{"empty", "", "empty"},
+		{"no base", "alias: x\n", "base is required"},
+		{"unknown field", "base: ubuntu\npackage: [git]\n", "package"},
+		{"bad package", "base: ubuntu\npackages: ['git; rm -rf /']\n", "invalid package"},
+		{"relative dest", "base: ubuntu\nfiles: [{source: a, dest: etc/a}]\n", "absolute"},
+		{"missing source", "base: ubuntu\nfiles: [{dest: /etc/a}]\n", "source and dest"},
+		{"bad mode", "base: ubuntu\nfiles: [{source: a, dest: /etc/a, mode: rwx}]\n", "octal"},
+		{"empty command", "base: ubuntu\nrun: ['  ']\n", "empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.spec))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
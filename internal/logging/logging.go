@@ -0,0 +1,63 @@
+// Package logging configures the process-wide slog logger used for
+// diagnostics. Regular command output is still printed directly; logs go
+// to stderr so they can be collected separately.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Supported log formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// DefaultLevel only shows warnings and errors, keeping normal runs quiet
+const DefaultLevel = "warn"
+
+// ParseLevel converts a level name (debug, info, warn, error) to a slog.Level
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("invalid log level '%s' (expected debug, info, warn or error)", s)
+}
+
+// NewHandler returns a handler writing records at or above level to w in
+// the given format
+func NewHandler(w io.Writer, level, format string) (slog.Handler, error) {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	switch strings.ToLower(format) {
+	case "", FormatText:
+		return slog.NewTextHandler(w, opts), nil
+	case FormatJSON:
+		return slog.NewJSONHandler(w, opts), nil
+	}
+	return nil, fmt.Errorf("invalid log format '%s' (expected %s or %s)", format, FormatText, FormatJSON)
+}
+
+// Setup installs a handler built by NewHandler as the slog default
+func Setup(w io.Writer, level, format string) error {
+	h, err := NewHandler(w, level, format)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(slog.New(h))
+	return nil
+}
@@ -0,0 +1,72 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    slog.Level
+		wantErr bool
+	}{
+		{"debug", slog.LevelDebug, false},
+		{"INFO", slog.LevelInfo, false},
+		{"warn", slog.LevelWarn, false},
+		{"warning", slog.LevelWarn, false},
+		{"error", slog.LevelError, false},
+		{"verbose", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseLevel(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNewHandler_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	h, err := NewHandler(&buf, "debug", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	slog.New(h).Debug("lxc command", "args", "list")
+
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("expected JSON record, got %q: %v", buf.String(), err)
+	}
+	if rec["msg"] != "lxc command" || rec["args"] != "list" || rec["level"] != "DEBUG" {
+		t.Errorf("unexpected record: %v", rec)
+	}
+}
+
+func TestNewHandler_Level(t *testing.T) {
+	var buf bytes.Buffer
+	h, err := NewHandler(&buf, "warn", "text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(h)
+	logger.Info("hidden")
+	logger.Warn("shown")
+
+	if strings.Contains(buf.String(), "hidden") || !strings.Contains(buf.String(), "shown") {
+		t.Errorf("expected only the warning, got %q", buf.String())
+	}
+}
+
+func TestNewHandler_InvalidFormat(t *testing.T) {
+	if _, err := NewHandler(&bytes.Buffer{}, "info", "xml"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
@@ -0,0 +1,143 @@
+package lxc
+
+import "sync"
+
+// resultCache remembers Exists, GetStatus and ImageExists answers so a
+// single command doesn't repeat the same "lxc info" calls. It's off by
+// default (long-lived SDK users would see stale state) and is turned on by
+// the CLI for the length of one run. Functions that change a container or
+// image drop the affected entries, and SetExecutor drops them all since
+// they are only valid for the executor that produced them.
+type resultCache struct {
+	mu      sync.Mutex
+	enabled bool
+	exists  map[string]bool
+	status  map[string]string
+	images  map[string]bool
+
+	// How to switch users in each container. It depends on the image, not
+	// the container's state, so it's kept even when caching is off.
+	userSwitch map[string]string
+}
+
+var cache resultCache
+
+// EnableCache turns result caching on or off and clears any cached results
+func EnableCache(enabled bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.enabled = enabled
+	cache.resetLocked()
+}
+
+// InvalidateCache drops every cached result
+func InvalidateCache() {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.resetLocked()
+}
+
+func (c *resultCache) resetLocked() {
+	c.exists = make(map[string]bool)
+	c.status = make(map[string]string)
+	c.images = make(map[string]bool)
+	c.userSwitch = make(map[string]string)
+}
+
+// usableLocked reports whether the cache is on
+func (c *resultCache) usableLocked() bool {
+	if c.enabled && c.exists == nil {
+		c.resetLocked()
+	}
+	return c.enabled
+}
+
+func (c *resultCache) getExists(name string) (bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.usableLocked() {
+		return false, false
+	}
+	v, ok := c.exists[name]
+	return v, ok
+}
+
+func (c *resultCache) setExists(name string, exists bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.usableLocked() {
+		c.exists[name] = exists
+	}
+}
+
+func (c *resultCache) getStatus(name string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.usableLocked() {
+		return "", false
+	}
+	v, ok := c.status[name]
+	return v, ok
+}
+
+func (c *resultCache) setStatus(name, status string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.usableLocked() {
+		c.status[name] = status
+	}
+}
+
+func (c *resultCache) getImage(alias string) (bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.usableLocked() {
+		return false, false
+	}
+	v, ok := c.images[alias]
+	return v, ok
+}
+
+func (c *resultCache) setImage(alias string, exists bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.usableLocked() {
+		c.images[alias] = exists
+	}
+}
+
+func (c *resultCache) getUserSwitch(name string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.userSwitch[name]
+	return v, ok
+}
+
+func (c *resultCache) setUserSwitch(name, method string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.userSwitch == nil {
+		c.userSwitch = make(map[string]string)
+	}
+	c.userSwitch[name] = method
+}
+
+// invalidateContainers drops cached results for the given containers
+func (c *resultCache) invalidateContainers(names ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, name := range names {
+		delete(c.exists, name)
+		delete(c.status, name)
+		delete(c.userSwitch, name)
+	}
+}
+
+// invalidateImages drops cached results for the given image aliases
+func (c *resultCache) invalidateImages(aliases ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, alias := range aliases {
+		delete(c.images, alias)
+	}
+}
@@ -0,0 +1,109 @@
+package lxc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"lxc-dev-manager/internal/dryrun"
+)
+
+// DryRunExecutor passes read-only lxc commands to Executor and prints the
+// rest instead of running them, reporting success with empty output
+type DryRunExecutor struct {
+	Executor Executor
+}
+
+// EnableDryRun wraps DefaultExecutor in a DryRunExecutor
+func EnableDryRun() {
+	if _, ok := DefaultExecutor.(*DryRunExecutor); !ok {
+		DefaultExecutor = &DryRunExecutor{Executor: DefaultExecutor}
+	}
+}
+
+func (e *DryRunExecutor) Run(args ...string) ([]byte, error) {
+	if readOnlyCommand(args) {
+		return e.Executor.Run(args...)
+	}
+	dryrun.Printf("%s", dryrun.Command("lxc", args...))
+	return nil, nil
+}
+
+func (e *DryRunExecutor) RunCombined(args ...string) ([]byte, error) {
+	if readOnlyCommand(args) {
+		return e.Executor.RunCombined(args...)
+	}
+	dryrun.Printf("%s", dryrun.Command("lxc", args...))
+	return nil, nil
+}
+
+// RunPipe implements PipeExecutor. The destination of a pipe always
+// changes something, so neither side is run.
+func (e *DryRunExecutor) RunPipe(src, dest []string) ([]byte, error) {
+	dryrun.Printf("%s | %s", dryrun.Command("lxc", src...), dryrun.Command("lxc", dest...))
+	return nil, nil
+}
+
+// RunStream implements StreamExecutor. Streams only watch, so they run.
+func (e *DryRunExecutor) RunStream(ctx context.Context, w io.Writer, args ...string) error {
+	se, ok := e.Executor.(StreamExecutor)
+	if !ok {
+		return fmt.Errorf("executor does not support streaming")
+	}
+	return se.RunStream(ctx, w, args...)
+}
+
+// readOnlyVerbs are lxc subcommands that only report state
+var readOnlyVerbs = map[string]bool{
+	"list": true, "info": true, "show": true, "get": true,
+}
+
+// readOnlyProbes are commands run with "lxc exec" to inspect a container
+var readOnlyProbes = map[string]bool{
+	"cloud-init": true, "du": true, "ls": true, "stat": true, "id": true,
+	"cat": true, "test": true, "getent": true,
+}
+
+// readOnlyCommand reports whether an lxc command line leaves everything
+// as it was, so dry-run mode can still run it
+func readOnlyCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	switch args[0] {
+	case "list", "info", "version":
+		return true
+	case "query":
+		for _, arg := range args[1:] {
+			if arg == "-X" || arg == "--request" || strings.HasPrefix(arg, "--request=") {
+				return false
+			}
+		}
+		return true
+	case "file":
+		// Pulling to stdout only reads; pulling to a path writes host files
+		return len(args) > 1 && args[1] == "pull" && args[len(args)-1] == "-"
+	case "exec":
+		for i, arg := range args {
+			if arg == "--" && i+1 < len(args) {
+				if args[i+1] == "sh" && i+3 < len(args) && args[i+2] == "-c" {
+					// Listening port probe
+					return strings.HasPrefix(args[i+3], "ss ")
+				}
+				return readOnlyProbes[args[i+1]]
+			}
+		}
+		return false
+	case "config", "image", "network", "storage", "profile":
+		// The verb follows any nouns: "config device show", "storage volume list"
+		for _, arg := range args[1:] {
+			switch arg {
+			case "device", "volume", "alias", "trust", "template":
+				continue
+			}
+			return readOnlyVerbs[arg]
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package lxc
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"lxc-dev-manager/internal/dryrun"
+)
+
+func TestReadOnlyCommand(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"list", "--format", "json"}, true},
+		{[]string{"info", "dev1"}, true},
+		{[]string{"query", "/1.0/instances/dev1"}, true},
+		{[]string{"query", "-X", "DELETE", "/1.0/instances/dev1"}, false},
+		{[]string{"config", "get", "dev1", "limits.cpu"}, true},
+		{[]string{"config", "set", "dev1", "limits.cpu", "2"}, false},
+		{[]string{"config", "device", "show", "dev1"}, true},
+		{[]string{"config", "device", "add", "dev1", "data", "disk"}, false},
+		{[]string{"storage", "volume", "show", "default", "vol1"}, true},
+		{[]string{"storage", "volume", "delete", "default", "vol1"}, false},
+		{[]string{"image", "list", "--format=csv"}, true},
+		{[]string{"image", "alias", "delete", "base"}, false},
+		{[]string{"file", "pull", "dev1/etc/hosts", "-"}, true},
+		{[]string{"file", "pull", "dev1/etc/hosts", "/tmp/hosts"}, false},
+		{[]string{"exec", "dev1", "--", "stat", "-c", "%F", "/etc"}, true},
+		{[]string{"exec", "dev1", "--", "sh", "-c", "ss -Hltn 2>/dev/null || netstat -ltn"}, true},
+		{[]string{"exec", "dev1", "--", "sh", "-c", "rm -rf /tmp/x"}, false},
+		{[]string{"exec", "dev1", "--", "useradd", "alice"}, false},
+		{[]string{"snapshot", "dev1", "before-upgrade"}, false},
+		{[]string{"delete", "dev1", "--force"}, false},
+	}
+	for _, tt := range tests {
+		if got := readOnlyCommand(tt.args); got != tt.want {
+			t.Errorf("readOnlyCommand(%v) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestDryRunExecutor(t *testing.T) {
+	mock := setupMock(t)
+	mock.SetOutput("list dev1 -cs -f csv", "RUNNING")
+
+	var out bytes.Buffer
+	dryrun.Enable(&out)
+	defer dryrun.Disable()
+	EnableDryRun()
+
+	status, err := GetStatus("dev1")
+	if err != nil || status != "RUNNING" {
+		t.Fatalf("expected read-only command to run, got %q, %v", status, err)
+	}
+	if err := Delete("dev1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if mock.HasCallPrefix("delete") {
+		t.Error("delete must not be run in dry-run mode")
+	}
+	if !strings.Contains(out.String(), "[dry-run] lxc delete dev1 --force") {
+		t.Errorf("expected delete to be printed, got %q", out.String())
+	}
+}
@@ -1,7 +1,17 @@
 package lxc
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
 	"os/exec"
+	"strings"
+	"time"
+
+	"lxc-dev-manager/internal/tracing"
 )
 
 // Executor interface for running LXC commands (allows mocking)
@@ -14,24 +24,156 @@ type Executor interface {
 type RealExecutor struct{}
 
 func (e *RealExecutor) Run(args ...string) ([]byte, error) {
+	start := time.Now()
 	cmd := exec.Command("lxc", args...)
-	return cmd.Output()
+	out, err := cmd.Output()
+	var stderr []byte
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		stderr = exitErr.Stderr
+	}
+	logCommand(args, start, err, stderr)
+	return out, err
 }
 
 func (e *RealExecutor) RunCombined(args ...string) ([]byte, error) {
+	start := time.Now()
 	cmd := exec.Command("lxc", args...)
-	return cmd.CombinedOutput()
+	out, err := cmd.CombinedOutput()
+	logCommand(args, start, err, out)
+	return out, err
+}
+
+// logCommand records an executed lxc command at debug level, including
+// the command's error output when it failed, and as a trace span
+func logCommand(args []string, start time.Time, err error, output []byte) {
+	traceCommand(args, start, err)
+
+	attrs := []any{"args", strings.Join(args, " "), "duration", time.Since(start)}
+	if err != nil {
+		attrs = append(attrs, "error", err)
+		if msg := strings.TrimSpace(string(output)); msg != "" {
+			attrs = append(attrs, "output", msg)
+		}
+	}
+	slog.Debug("lxc command", attrs...)
+}
+
+// traceCommand records an lxc command as a span named after its verb.
+// Spans leave the host, so for exec only the program is kept, not its
+// arguments, which can carry passwords.
+func traceCommand(args []string, start time.Time, err error) {
+	if len(args) == 0 || !tracing.Enabled() {
+		return
+	}
+	shown := args
+	attrs := []any{}
+	for i, arg := range args {
+		if arg == "--" {
+			shown = args[:i]
+			if i+1 < len(args) {
+				attrs = append(attrs, "lxc.exec", args[i+1])
+			}
+			break
+		}
+	}
+	attrs = append(attrs, "lxc.args", strings.Join(shown, " "))
+	tracing.Record("lxc "+args[0], start, err, attrs...)
+}
+
+// PipeExecutor is implemented by executors that can stream the stdout of
+// one lxc command into the stdin of another
+type PipeExecutor interface {
+	RunPipe(src, dest []string) ([]byte, error)
+}
+
+// RunPipe runs both commands with src's stdout connected to dest's stdin.
+// Returns the combined output of dest plus src's stderr.
+func (e *RealExecutor) RunPipe(src, dest []string) ([]byte, error) {
+	start := time.Now()
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+
+	var output bytes.Buffer
+	srcCmd := exec.Command("lxc", src...)
+	srcCmd.Stdout = w
+	srcCmd.Stderr = &output
+	destCmd := exec.Command("lxc", dest...)
+	destCmd.Stdin = r
+	destCmd.Stdout = &output
+	destCmd.Stderr = &output
+
+	if err := srcCmd.Start(); err != nil {
+		r.Close()
+		w.Close()
+		return nil, err
+	}
+	if err := destCmd.Start(); err != nil {
+		r.Close()
+		w.Close()
+		srcCmd.Process.Kill()
+		srcCmd.Wait()
+		return nil, err
+	}
+	// The children hold their own copies of the pipe ends
+	r.Close()
+	w.Close()
+
+	destErr := destCmd.Wait()
+	srcErr := srcCmd.Wait()
+	err = destErr
+	if err == nil {
+		err = srcErr
+	}
+	piped := append(append(append([]string{}, src...), "|", "lxc"), dest...)
+	logCommand(piped, start, err, output.Bytes())
+	return output.Bytes(), err
+}
+
+// StreamExecutor is implemented by executors that can run a long-lived lxc
+// command, such as monitor, passing its stdout on as it's produced
+type StreamExecutor interface {
+	RunStream(ctx context.Context, w io.Writer, args ...string) error
+}
+
+// RunStream implements StreamExecutor. The command is killed when ctx is
+// done, which isn't reported as an error.
+func (e *RealExecutor) RunStream(ctx context.Context, w io.Writer, args ...string) error {
+	start := time.Now()
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "lxc", args...)
+	cmd.Stdout = w
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		err = nil
+	}
+	logCommand(args, start, err, stderr.Bytes())
+	if err != nil && stderr.Len() > 0 {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return err
 }
 
 // DefaultExecutor is the executor used by default
 var DefaultExecutor Executor = &RealExecutor{}
 
-// SetExecutor sets the executor (for testing)
+// SetExecutor sets the executor (for testing, and for SDK clients), dropping
+// results cached from the previous one
 func SetExecutor(e Executor) {
 	DefaultExecutor = e
+	InvalidateCache()
 }
 
 // ResetExecutor resets to the real executor
 func ResetExecutor() {
 	DefaultExecutor = &RealExecutor{}
+	InvalidateCache()
+}
+
+// ZFSRunner runs host zfs commands, used for snapshot space accounting
+// that LXD doesn't expose (replaceable for testing)
+var ZFSRunner = func(args ...string) ([]byte, error) {
+	return exec.Command("zfs", args...).Output()
 }
@@ -1,21 +1,49 @@
 package lxc
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"os/exec"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"lxc-dev-manager/internal/dryrun"
+	"lxc-dev-manager/internal/errcode"
+	"lxc-dev-manager/internal/validation"
+
 	"gopkg.in/yaml.v3"
 )
 
+// commandError formats an error for an lxc command that failed or returned
+// output that couldn't be understood
+func commandError(format string, args ...any) error {
+	return errcode.Errorf(errcode.LXC, "", format, args...)
+}
+
 // Launch creates and starts a new container
 func Launch(name, image string) error {
+	cache.invalidateContainers(name)
 	output, err := DefaultExecutor.RunCombined("launch", image, name)
 	if err != nil {
-		return fmt.Errorf("failed to launch container: %s", string(output))
+		return commandError("failed to launch container: %s", string(output))
+	}
+	return nil
+}
+
+// Init creates a container without starting it
+func Init(name, image string) error {
+	cache.invalidateContainers(name)
+	output, err := DefaultExecutor.RunCombined("init", image, name)
+	if err != nil {
+		return commandError("failed to create container: %s", string(output))
 	}
 	return nil
 }
@@ -24,33 +52,422 @@ func Launch(name, image string) error {
 func ConfigSet(name, key, value string) error {
 	output, err := DefaultExecutor.RunCombined("config", "set", name, key, value)
 	if err != nil {
-		return fmt.Errorf("failed to set config %s: %s", key, string(output))
+		return commandError("failed to set config %s: %s", key, string(output))
+	}
+	return nil
+}
+
+// ConfigUnset removes a config key from a container
+func ConfigUnset(name, key string) error {
+	output, err := DefaultExecutor.RunCombined("config", "unset", name, key)
+	if err != nil {
+		return commandError("failed to unset config %s: %s", key, string(output))
+	}
+	return nil
+}
+
+// ConfigGet reads a config key from a container
+func ConfigGet(name, key string) (string, error) {
+	output, err := DefaultExecutor.RunCombined("config", "get", name, key)
+	if err != nil {
+		return "", commandError("failed to get config %s: %s", key, string(output))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// SetRawIDMap replaces the container's raw.idmap with the given entries.
+// The new mapping takes effect on the next container start.
+func SetRawIDMap(name string, entries []string) error {
+	return ConfigSet(name, "raw.idmap", strings.Join(entries, "\n"))
+}
+
+// IDMapEntry is one range of a container's UID/GID map as reported by
+// volatile.last_state.idmap
+type IDMapEntry struct {
+	IsUID    bool  `json:"Isuid"`
+	IsGID    bool  `json:"Isgid"`
+	HostID   int64 `json:"Hostid"`
+	NsID     int64 `json:"Nsid"`
+	MapRange int64 `json:"Maprange"`
+}
+
+// GetIDMap returns the container's effective UID/GID map. It falls back to
+// the map for the next start when the container has never been started.
+// An empty result means the container is not remapped (e.g. privileged).
+func GetIDMap(name string) ([]IDMapEntry, error) {
+	for _, key := range []string{"volatile.last_state.idmap", "volatile.idmap.next"} {
+		value, err := ConfigGet(name, key)
+		if err != nil {
+			return nil, err
+		}
+		if value == "" || value == "[]" {
+			continue
+		}
+		var entries []IDMapEntry
+		if err := json.Unmarshal([]byte(value), &entries); err != nil {
+			return nil, commandError("failed to parse %s: %v", key, err)
+		}
+		return entries, nil
+	}
+	return nil, nil
+}
+
+// HostUID maps a container UID to the host UID it runs as
+func HostUID(entries []IDMapEntry, containerUID int) (int, bool) {
+	id := int64(containerUID)
+	for _, e := range entries {
+		if e.IsUID && id >= e.NsID && id < e.NsID+e.MapRange {
+			return int(e.HostID + id - e.NsID), true
+		}
+	}
+	return 0, false
+}
+
+// UserUID returns the UID of a user inside a running container
+func UserUID(name, username string) (int, error) {
+	output, err := DefaultExecutor.Run("exec", name, "--", "id", "-u", username)
+	if err != nil {
+		return 0, commandError("failed to look up uid for %s: %s", username, strings.TrimSpace(string(output)))
+	}
+	uid, err := strconv.Atoi(strings.TrimSpace(string(output)))
+	if err != nil {
+		return 0, commandError("unexpected id output: %q", strings.TrimSpace(string(output)))
+	}
+	return uid, nil
+}
+
+// Ways of running a command as another user in a container, in the order
+// DetectUserSwitch prefers them
+const (
+	UserSwitchSu      = "su"      // su -l: login shell, PAM and supplementary groups
+	UserSwitchRunuser = "runuser" // runuser -l, for images whose su isn't util-linux's
+	UserSwitchSetpriv = "setpriv" // setpriv with the user's ids; not a login
+	UserSwitchUID     = "uid"     // lxc exec --user/--group; needs nothing in the image
+)
+
+// userSwitchProbe prints the first user-switching method the image supports.
+// busybox su (Alpine) has no --version and doesn't run commands like
+// util-linux su, so it isn't used.
+const userSwitchProbe = `if su --version >/dev/null 2>&1; then echo su
+elif command -v runuser >/dev/null 2>&1; then echo runuser
+elif command -v setpriv >/dev/null 2>&1; then echo setpriv
+else echo uid
+fi`
+
+// UserSwitch is how to run commands as a user in a container. The zero
+// value uses su -l.
+type UserSwitch struct {
+	Method string
+	UID    int    // setpriv and uid methods
+	GID    int    // setpriv and uid methods
+	Home   string // setpriv and uid methods
+	Shell  string // setpriv and uid methods
+}
+
+// DetectUserSwitch finds how to run commands as username in a container.
+// The method is detected once per container and cached; the setpriv and uid
+// methods also look the user up in the container's passwd database. When
+// detection fails, su -l is assumed.
+func DetectUserSwitch(container, username string) UserSwitch {
+	method, ok := cache.getUserSwitch(container)
+	if !ok {
+		output, err := DefaultExecutor.Run("exec", container, "--", "sh", "-c", userSwitchProbe)
+		method = strings.TrimSpace(string(output))
+		switch {
+		case err != nil:
+			slog.Debug("couldn't detect how to switch users, assuming su", "container", container, "error", err)
+			return UserSwitch{Method: UserSwitchSu}
+		case method != UserSwitchRunuser && method != UserSwitchSetpriv && method != UserSwitchUID:
+			method = UserSwitchSu
+		}
+		cache.setUserSwitch(container, method)
+	}
+
+	sw := UserSwitch{Method: method}
+	if method != UserSwitchSetpriv && method != UserSwitchUID {
+		return sw
+	}
+	output, err := DefaultExecutor.Run("exec", container, "--", "sh", "-c",
+		`getent passwd "$1" 2>/dev/null || grep "^$1:" /etc/passwd`, "sh", username)
+	fields := strings.Split(strings.TrimSpace(string(output)), ":")
+	if err != nil || len(fields) < 7 {
+		slog.Debug("couldn't look up user, assuming su", "container", container, "user", username, "error", err)
+		return UserSwitch{Method: UserSwitchSu}
+	}
+	sw.UID, _ = strconv.Atoi(fields[2])
+	sw.GID, _ = strconv.Atoi(fields[3])
+	sw.Home, sw.Shell = fields[5], fields[6]
+	if sw.Shell == "" {
+		sw.Shell = "/bin/sh"
+	}
+	return sw
+}
+
+// ExecArgs returns the lxc exec arguments that run command in a container
+// as username. With no command, they open the user's login shell.
+func (s UserSwitch) ExecArgs(container, username string, command []string) []string {
+	args := []string{"exec", container}
+	switch s.Method {
+	case UserSwitchRunuser:
+		return append(append(args, "--", "runuser", "-l", username), command...)
+	case UserSwitchSetpriv, UserSwitchUID:
+		args = append(args, "--cwd", s.Home,
+			"--env", "HOME="+s.Home, "--env", "USER="+username,
+			"--env", "LOGNAME="+username, "--env", "SHELL="+s.Shell)
+		if s.Method == UserSwitchUID {
+			args = append(args, "--user", strconv.Itoa(s.UID), "--group", strconv.Itoa(s.GID), "--")
+		} else {
+			args = append(args, "--", "setpriv",
+				fmt.Sprintf("--reuid=%d", s.UID), fmt.Sprintf("--regid=%d", s.GID), "--init-groups")
+		}
+		if len(command) == 0 {
+			command = []string{s.Shell, "-l"}
+		}
+		return append(args, command...)
+	default:
+		return append(append(args, "--", "su", "-l", username), command...)
+	}
+}
+
+// ScriptArgs is like ExecArgs, but runs script through the user's shell
+func (s UserSwitch) ScriptArgs(container, username, script string) []string {
+	switch s.Method {
+	case UserSwitchSetpriv, UserSwitchUID:
+		return s.ExecArgs(container, username, []string{s.Shell, "-lc", script})
+	default:
+		// su and runuser hand what follows the user to the login shell
+		return s.ExecArgs(container, username, []string{"-c", script})
+	}
+}
+
+// Terminal multiplexers attach runs sessions in
+const (
+	MultiplexerZellij = "zellij"
+	MultiplexerTmux   = "tmux"
+)
+
+// InstalledMultiplexer returns the multiplexer installed in a container,
+// preferring zellij, or "" when there is none
+func InstalledMultiplexer(container string) string {
+	output, err := DefaultExecutor.Run("exec", container, "--", "sh", "-c",
+		"command -v zellij >/dev/null && echo zellij || { command -v tmux >/dev/null && echo tmux; }")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// InstallMultiplexer installs zellij or tmux in a container if it is
+// missing. zellij comes from its GitHub releases, tmux from the
+// distribution's packages.
+func InstallMultiplexer(container, multiplexer string) error {
+	var script string
+	switch multiplexer {
+	case MultiplexerZellij:
+		script = `set -e
+command -v zellij >/dev/null && exit 0
+url="https://github.com/zellij-org/zellij/releases/latest/download/zellij-$(uname -m)-unknown-linux-musl.tar.gz"
+tmp=$(mktemp)
+if command -v curl >/dev/null; then curl -fsSL -o "$tmp" "$url"; else wget -qO "$tmp" "$url"; fi
+tar -xzf "$tmp" -C /usr/local/bin zellij
+rm -f "$tmp"`
+	case MultiplexerTmux:
+		script = `set -e
+command -v tmux >/dev/null && exit 0
+if command -v apt-get >/dev/null; then
+	apt-get update -qq
+	DEBIAN_FRONTEND=noninteractive apt-get install -y -qq tmux
+else
+	apk add --no-cache tmux
+fi`
+	default:
+		return fmt.Errorf("unknown multiplexer %q: must be zellij or tmux", multiplexer)
+	}
+	// sh rather than ExecScript's bash, which minimal images lack
+	if err := Exec(container, "sh", "-c", script); err != nil {
+		return commandError("failed to install %s: %w", multiplexer, err)
 	}
 	return nil
 }
 
+// ListeningSocket is a TCP socket in LISTEN state inside a container
+type ListeningSocket struct {
+	Address string
+	Port    int
+}
+
+// ListeningPorts lists TCP listening sockets inside a running container,
+// using ss and falling back to netstat
+func ListeningPorts(name string) ([]ListeningSocket, error) {
+	output, err := DefaultExecutor.Run("exec", name, "--", "sh", "-c", "ss -Hltn 2>/dev/null || netstat -ltn")
+	if err != nil {
+		return nil, commandError("failed to list listening ports: %s", strings.TrimSpace(string(output)))
+	}
+	return parseListeningSockets(string(output)), nil
+}
+
+// parseListeningSockets reads `ss -Hltn` or `netstat -ltn` output. Both put
+// the local address in the fourth column; header lines don't parse and are skipped.
+func parseListeningSockets(output string) []ListeningSocket {
+	var sockets []ListeningSocket
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		local := fields[3]
+		idx := strings.LastIndex(local, ":")
+		if idx < 0 {
+			continue
+		}
+		port, err := strconv.Atoi(local[idx+1:])
+		if err != nil {
+			continue
+		}
+		addr := strings.Trim(local[:idx], "[]")
+		if i := strings.Index(addr, "%"); i >= 0 {
+			addr = addr[:i]
+		}
+		sockets = append(sockets, ListeningSocket{Address: addr, Port: port})
+	}
+	return sockets
+}
+
+// ExportEnv makes an environment variable visible both to `lxc exec`
+// (environment.<key>) and to login shells via /etc/profile.d
+func ExportEnv(name, key, value string) error {
+	if err := ConfigSet(name, "environment."+key, value); err != nil {
+		return err
+	}
+	line := fmt.Sprintf("export %s=%s", key, shellQuote(value))
+	script := fmt.Sprintf("printf '%%s\\n' %s > /etc/profile.d/lxc-dev-manager-%s.sh",
+		shellQuote(line), strings.ToLower(key))
+	return ExecScript(name, script)
+}
+
+// shellQuote wraps s in single quotes for safe use in a shell script
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// dotfilesScripts are tried in order when a dotfiles repo has no install script configured
+var dotfilesScripts = []string{"install.sh", "install", "bootstrap.sh", "bootstrap", "setup.sh", "setup", "script/bootstrap", "script/setup"}
+
+// ApplyDotfiles clones (or updates) a dotfiles repository to ~/.dotfiles for
+// a user and runs its install script. Without one, the repo's top-level
+// dotfiles are symlinked into the home directory.
+func ApplyDotfiles(name, username, repo, install string) error {
+	if err := ExecScript(name, "command -v git >/dev/null || (apt-get update -qq && apt-get install -y -qq git)"); err != nil {
+		return commandError("failed to install git: %w", err)
+	}
+
+	scripts := dotfilesScripts
+	if install != "" {
+		scripts = []string{install}
+	}
+	quoted := make([]string, len(scripts))
+	for i, s := range scripts {
+		quoted[i] = shellQuote(s)
+	}
+
+	script := fmt.Sprintf(`set -e
+dir="$HOME/.dotfiles"
+if [ -d "$dir/.git" ]; then
+	git -C "$dir" pull --ff-only
+else
+	git clone --depth 1 -- %s "$dir"
+fi
+cd "$dir"
+for s in %s; do
+	if [ -f "$s" ]; then
+		if [ -x "$s" ]; then "./$s"; else sh "./$s"; fi
+		exit 0
+	fi
+done
+if [ -n %s ]; then
+	echo "install script not found in dotfiles repo" >&2
+	exit 1
+fi
+for f in .[!.]*; do
+	case "$f" in .git|.github|.gitignore|.gitmodules) continue ;; esac
+	ln -sfn "$dir/$f" "$HOME/$f"
+done`, shellQuote(repo), strings.Join(quoted, " "), shellQuote(install))
+
+	if err := Exec(name, "su", "-l", username, "-c", script); err != nil {
+		return commandError("failed to apply dotfiles: %w", err)
+	}
+	return nil
+}
+
+// nestingKeys are the settings Docker-in-LXC needs, in the order they're set
+var nestingKeys = []string{
+	"security.nesting",
+	"security.syscalls.intercept.mknod",
+	"security.syscalls.intercept.setxattr",
+}
+
 // EnableNesting enables Docker-in-LXC support
 func EnableNesting(name string) error {
-	configs := map[string]string{
-		"security.nesting":                     "true",
-		"security.syscalls.intercept.mknod":    "true",
-		"security.syscalls.intercept.setxattr": "true",
-	}
+	return SetNesting(name, true)
+}
 
-	for key, value := range configs {
-		if err := ConfigSet(name, key, value); err != nil {
+// SetNesting turns Docker-in-LXC support on or off. A running container
+// picks the change up on its next start.
+func SetNesting(name string, enabled bool) error {
+	for _, key := range nestingKeys {
+		if err := ConfigSet(name, key, strconv.FormatBool(enabled)); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// IsNesting checks if security.nesting is enabled for a container
+func IsNesting(name string) (bool, error) {
+	output, err := DefaultExecutor.RunCombined("config", "get", name, "security.nesting")
+	if err != nil {
+		return false, commandError("failed to get nesting status: %s", string(output))
+	}
+	return strings.TrimSpace(string(output)) == "true", nil
+}
+
+// InstallDocker installs and starts the distribution's Docker package if
+// missing, and adds username to the docker group
+func InstallDocker(containerName, username string) error {
+	script := fmt.Sprintf(`set -e
+command -v docker >/dev/null || {
+	apt-get update -qq
+	DEBIAN_FRONTEND=noninteractive apt-get install -y -qq docker.io
+}
+systemctl enable --now docker >/dev/null 2>&1 || service docker start
+usermod -aG docker %s`, shellQuote(username))
+	if err := ExecScript(containerName, script); err != nil {
+		return commandError("failed to install docker: %w", err)
+	}
+	return nil
+}
+
+// DockerInfo returns the storage driver of the Docker daemon in a container
+// and the filesystem type of its /sys/fs/cgroup (cgroup2fs for cgroup v2)
+func DockerInfo(name string) (driver, cgroupFS string, err error) {
+	output, err := DefaultExecutor.RunCombined("exec", name, "--", "sh", "-c",
+		"stat -fc %T /sys/fs/cgroup && docker info --format '{{.Driver}}'")
+	if err != nil {
+		return "", "", commandError("docker is not working: %s", strings.TrimSpace(string(output)))
+	}
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if len(lines) < 2 {
+		return "", "", commandError("unexpected docker info output: %s", string(output))
+	}
+	return strings.TrimSpace(lines[len(lines)-1]), strings.TrimSpace(lines[0]), nil
+}
+
 // Exec runs a command inside a container
 func Exec(name string, args ...string) error {
 	cmdArgs := append([]string{"exec", name, "--"}, args...)
 	output, err := DefaultExecutor.RunCombined(cmdArgs...)
 	if err != nil {
-		return fmt.Errorf("exec failed: %s", string(output))
+		return commandError("exec failed: %s", string(output))
 	}
 	return nil
 }
@@ -79,6 +496,127 @@ func SetupUser(containerName, username, password string) error {
 	return ExecScript(containerName, script)
 }
 
+// AddUserGroups adds a user to extra groups, creating groups that don't exist
+// yet (e.g. docker before docker is installed)
+func AddUserGroups(containerName, username string, groups []string) error {
+	var script strings.Builder
+	script.WriteString("set -e\n")
+	for _, group := range groups {
+		fmt.Fprintf(&script, "getent group %[1]s >/dev/null || groupadd %[1]s\n", shellQuote(group))
+	}
+	fmt.Fprintf(&script, "usermod -aG %s %s", shellQuote(strings.Join(groups, ",")), shellQuote(username))
+	if err := ExecScript(containerName, script.String()); err != nil {
+		return commandError("failed to add user to groups: %w", err)
+	}
+	return nil
+}
+
+// SetShell installs a login shell if missing and makes it the user's default.
+// shell is a program name (zsh) or an absolute path.
+func SetShell(containerName, username, shell string) error {
+	pkg := path.Base(shell)
+	script := fmt.Sprintf(`set -e
+shell=%s
+command -v "$shell" >/dev/null || {
+	apt-get update -qq
+	apt-get install -y -qq %s
+}
+path=$(command -v "$shell")
+grep -qx "$path" /etc/shells || echo "$path" >> /etc/shells
+chsh -s "$path" %s`, shellQuote(shell), shellQuote(pkg), shellQuote(username))
+	if err := ExecScript(containerName, script); err != nil {
+		return commandError("failed to set shell: %w", err)
+	}
+	return nil
+}
+
+// AptProxyFile is where SetAptProxy writes the apt proxy setting
+const AptProxyFile = "/etc/apt/apt.conf.d/01lxc-dev-manager-proxy"
+
+// SetAptProxy points apt at an HTTP proxy. Images without apt are left
+// alone.
+func SetAptProxy(containerName, proxy string) error {
+	script := fmt.Sprintf(`[ -d /etc/apt/apt.conf.d ] || exit 0
+printf 'Acquire::http::Proxy "%%s";\n' %s > %s`, shellQuote(proxy), AptProxyFile)
+	if err := ExecScript(containerName, script); err != nil {
+		return commandError("failed to set apt proxy: %w", err)
+	}
+	return nil
+}
+
+// MotdFile holds the login banner written by SetMotd
+const MotdFile = "/etc/lxc-dev-manager/motd"
+
+// SetMotd writes a banner that interactive login shells print. It's shown
+// from /etc/profile.d rather than /etc/motd, which su -l doesn't print.
+func SetMotd(containerName, text string) error {
+	show := fmt.Sprintf(`case $- in *i*) cat %s 2>/dev/null ;; esac`, MotdFile)
+	script := fmt.Sprintf(`set -e
+mkdir -p %s
+printf '%%s' %s > %s
+printf '%%s\n' %s > /etc/profile.d/lxc-dev-manager-motd.sh`,
+		path.Dir(MotdFile), shellQuote(text), MotdFile, shellQuote(show))
+	if err := ExecScript(containerName, script); err != nil {
+		return commandError("failed to write motd: %w", err)
+	}
+	return nil
+}
+
+// HistoryDir is where the shell_history directory is mounted in containers
+const HistoryDir = "/var/lib/lxc-dev-manager/history"
+
+// SetHistoryFile points login shells' history at a file per user named
+// after prefix in HistoryDir. bash appends to it after every command, so
+// history survives shells that are killed rather than exited.
+func SetHistoryFile(containerName, prefix string) error {
+	profile := fmt.Sprintf(`export HISTFILE=%s"_${USER:-$(id -un)}_history"
+if [ -n "$BASH_VERSION" ]; then
+	shopt -s histappend
+	PROMPT_COMMAND="history -a${PROMPT_COMMAND:+; $PROMPT_COMMAND}"
+fi`, shellQuote(path.Join(HistoryDir, prefix)))
+	script := fmt.Sprintf("printf '%%s\n' %s > /etc/profile.d/lxc-dev-manager-history.sh", shellQuote(profile))
+	if err := ExecScript(containerName, script); err != nil {
+		return commandError("failed to set shell history file: %w", err)
+	}
+	return nil
+}
+
+// SetTimezone sets the system timezone (e.g. Europe/Paris)
+func SetTimezone(containerName, timezone string) error {
+	script := fmt.Sprintf(`set -e
+tz=%s
+[ -e "/usr/share/zoneinfo/$tz" ] || {
+	apt-get update -qq
+	DEBIAN_FRONTEND=noninteractive apt-get install -y -qq tzdata
+}
+[ -e "/usr/share/zoneinfo/$tz" ] || { echo "unknown timezone: $tz" >&2; exit 1; }
+ln -sfn "/usr/share/zoneinfo/$tz" /etc/localtime
+echo "$tz" > /etc/timezone`, shellQuote(timezone))
+	if err := ExecScript(containerName, script); err != nil {
+		return commandError("failed to set timezone: %w", err)
+	}
+	return nil
+}
+
+// SetLocale generates a locale and makes it the system default LANG
+func SetLocale(containerName, locale string) error {
+	script := fmt.Sprintf(`set -e
+locale=%s
+command -v locale-gen >/dev/null || {
+	apt-get update -qq
+	DEBIAN_FRONTEND=noninteractive apt-get install -y -qq locales
+}
+case "$locale" in
+	C|C.*|POSIX) ;;
+	*) locale-gen "$locale" ;;
+esac
+update-locale LANG="$locale"`, shellQuote(locale))
+	if err := ExecScript(containerName, script); err != nil {
+		return commandError("failed to set locale: %w", err)
+	}
+	return nil
+}
+
 // EnableSSH ensures SSH is installed and running
 func EnableSSH(name string) error {
 	script := `
@@ -95,63 +633,165 @@ func EnableSSH(name string) error {
 	return ExecScript(name, script)
 }
 
+// AuthorizeKey adds a public key to a user's authorized_keys unless it's
+// already there
+func AuthorizeKey(name, username, publicKey string) error {
+	script := fmt.Sprintf(`set -e
+home=$(getent passwd %[1]s | cut -d: -f6)
+mkdir -p "$home/.ssh"
+touch "$home/.ssh/authorized_keys"
+grep -qxF %[2]s "$home/.ssh/authorized_keys" || printf '%%s\n' %[2]s >> "$home/.ssh/authorized_keys"
+chmod 700 "$home/.ssh"
+chmod 600 "$home/.ssh/authorized_keys"
+chown -R %[1]s: "$home/.ssh"`, shellQuote(username), shellQuote(publicKey))
+	if err := ExecScript(name, script); err != nil {
+		return commandError("failed to authorize SSH key: %w", err)
+	}
+	return nil
+}
+
 // WaitForReady waits for container to be ready (cloud-init complete)
 func WaitForReady(name string, timeout time.Duration) error {
-	deadline := time.Now().Add(timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return WaitFor(ctx, name, validation.ReadyCloudInit)
+}
 
-	for time.Now().Before(deadline) {
-		// Check if cloud-init is done
-		output, err := DefaultExecutor.RunCombined("exec", name, "--", "cloud-init", "status")
-		if err == nil && strings.Contains(string(output), "done") {
+// WaitFor polls a container until every readiness condition holds (see
+// validation.ParseReadyCondition) or ctx is done
+func WaitFor(ctx context.Context, name string, conditions ...string) error {
+	if dryrun.Enabled() {
+		return nil // Nothing was started
+	}
+
+	pending := slices.Clone(conditions)
+	for _, cond := range pending {
+		if _, err := validation.ParseReadyCondition(cond); err != nil {
+			return err
+		}
+	}
+
+	for {
+		var waiting []string
+		for _, cond := range pending {
+			if !checkReady(name, cond) {
+				waiting = append(waiting, cond)
+			}
+		}
+		if len(waiting) == 0 {
 			return nil
 		}
+		pending = waiting
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return commandError("timeout waiting for container to be ready (waiting for: %s)", strings.Join(pending, ", "))
+			}
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+}
 
-		// Also check if it's just running (no cloud-init)
+// checkReady reports whether a single readiness condition holds
+func checkReady(name, cond string) bool {
+	switch cond {
+	case validation.ReadyNetwork:
+		ip, err := GetIP(name)
+		return err == nil && ip != ""
+	case validation.ReadySystemd:
+		output, _ := DefaultExecutor.RunCombined("exec", name, "--", "systemctl", "is-system-running")
+		state := strings.TrimSpace(string(output))
+		// Images without systemd have nothing to wait for
+		return state == "running" || state == "degraded" || strings.Contains(state, "not found")
+	case validation.ReadyCloudInit:
+		output, err := DefaultExecutor.RunCombined("exec", name, "--", "cloud-init", "status")
+		if err == nil && strings.Contains(string(output), "done") {
+			return true
+		}
 		if strings.Contains(string(output), "not found") {
-			// No cloud-init, assume ready
+			// No cloud-init, give the init system a moment and assume ready
 			time.Sleep(2 * time.Second)
-			return nil
+			return true
 		}
-
-		time.Sleep(1 * time.Second)
+		return false
 	}
 
-	return fmt.Errorf("timeout waiting for container to be ready")
+	port, _ := validation.ParseReadyCondition(cond)
+	sockets, err := ListeningPorts(name)
+	if err != nil {
+		return false
+	}
+	return slices.ContainsFunc(sockets, func(s ListeningSocket) bool { return s.Port == port })
 }
 
 // Start starts a stopped container
 func Start(name string) error {
+	cache.invalidateContainers(name)
 	output, err := DefaultExecutor.RunCombined("start", name)
 	if err != nil {
-		return fmt.Errorf("failed to start container: %s", string(output))
+		return commandError("failed to start container: %s", string(output))
 	}
 	return nil
 }
 
-// Stop stops a running container
+// DefaultStopTimeout is how long Stop waits for a clean shutdown. It is
+// short to avoid long waits; containers.yaml's stop_timeout raises it.
+const DefaultStopTimeout = 5 * time.Second
+
+// Stop stops a running container, waiting DefaultStopTimeout for a clean
+// shutdown
 func Stop(name string) error {
-	// Use a short timeout to avoid long waits for graceful shutdown
-	output, err := DefaultExecutor.RunCombined("stop", name, "--timeout=5")
+	return StopWithTimeout(name, DefaultStopTimeout, false)
+}
+
+// StopWithTimeout asks a container to shut down cleanly and waits up to
+// timeout (rounded up to whole seconds). If it is still running after that
+// and force is set, it is killed; a timeout of zero or less with force
+// kills it straight away.
+func StopWithTimeout(name string, timeout time.Duration, force bool) error {
+	cache.invalidateContainers(name)
+	if timeout <= 0 && force {
+		return forceStop(name)
+	}
+
+	seconds := int((timeout + time.Second - 1) / time.Second)
+	output, err := DefaultExecutor.RunCombined("stop", name, fmt.Sprintf("--timeout=%d", max(seconds, 1)))
+	if err == nil {
+		return nil
+	}
+	if !force {
+		return commandError("failed to stop container: %s", string(output))
+	}
+	slog.Warn("container didn't shut down in time, forcing it", "container", name, "timeout", timeout)
+	return forceStop(name)
+}
+
+func forceStop(name string) error {
+	output, err := DefaultExecutor.RunCombined("stop", name, "--force")
 	if err != nil {
-		return fmt.Errorf("failed to stop container: %s", string(output))
+		return commandError("failed to force stop container: %s", string(output))
 	}
 	return nil
 }
 
 // Delete removes a container
 func Delete(name string) error {
+	cache.invalidateContainers(name)
 	output, err := DefaultExecutor.RunCombined("delete", name, "--force")
 	if err != nil {
-		return fmt.Errorf("failed to delete container: %s", string(output))
+		return commandError("failed to delete container: %s", string(output))
 	}
 	return nil
 }
 
 // Publish creates an image from a container
 func Publish(name, alias string) error {
+	cache.invalidateImages(alias)
 	output, err := DefaultExecutor.RunCombined("publish", name, "--alias", alias)
 	if err != nil {
-		return fmt.Errorf("failed to publish container: %s", string(output))
+		return commandError("failed to publish container: %s", string(output))
 	}
 	return nil
 }
@@ -160,7 +800,7 @@ func Publish(name, alias string) error {
 func Snapshot(container, snapshotName string) error {
 	output, err := DefaultExecutor.RunCombined("snapshot", container, snapshotName)
 	if err != nil {
-		return fmt.Errorf("failed to create snapshot: %s", string(output))
+		return commandError("failed to create snapshot: %s", string(output))
 	}
 	return nil
 }
@@ -169,16 +809,26 @@ func Snapshot(container, snapshotName string) error {
 func DeleteSnapshot(container, snapshotName string) error {
 	output, err := DefaultExecutor.RunCombined("delete", container+"/"+snapshotName)
 	if err != nil {
-		return fmt.Errorf("failed to delete snapshot: %s", string(output))
+		return commandError("failed to delete snapshot: %s", string(output))
+	}
+	return nil
+}
+
+// RenameSnapshot renames a snapshot of a container
+func RenameSnapshot(container, oldName, newName string) error {
+	output, err := DefaultExecutor.RunCombined("move", container+"/"+oldName, container+"/"+newName)
+	if err != nil {
+		return commandError("failed to rename snapshot: %s", string(output))
 	}
 	return nil
 }
 
 // Restore restores a container from a snapshot
 func Restore(container, snapshotName string) error {
+	cache.invalidateContainers(container)
 	output, err := DefaultExecutor.RunCombined("restore", container, snapshotName)
 	if err != nil {
-		return fmt.Errorf("failed to restore snapshot: %s", string(output))
+		return commandError("failed to restore snapshot: %s", string(output))
 	}
 	return nil
 }
@@ -191,19 +841,31 @@ func SnapshotExists(container, snapshotName string) bool {
 
 // Copy creates a clone of an existing container
 func Copy(source, dest string) error {
+	cache.invalidateContainers(dest)
 	output, err := DefaultExecutor.RunCombined("copy", source, dest)
 	if err != nil {
-		return fmt.Errorf("failed to copy container: %s", string(output))
+		return commandError("failed to copy container: %s", string(output))
+	}
+	return nil
+}
+
+// Rename renames a stopped container (snapshots move with it)
+func Rename(oldName, newName string) error {
+	cache.invalidateContainers(oldName, newName)
+	output, err := DefaultExecutor.RunCombined("move", oldName, newName)
+	if err != nil {
+		return commandError("failed to rename container: %s", string(output))
 	}
 	return nil
 }
 
 // CopySnapshot creates a container from a snapshot of another container
 func CopySnapshot(source, snapshotName, dest string) error {
+	cache.invalidateContainers(dest)
 	snapshotPath := source + "/" + snapshotName
 	output, err := DefaultExecutor.RunCombined("copy", snapshotPath, dest)
 	if err != nil {
-		return fmt.Errorf("failed to copy from snapshot: %s", string(output))
+		return commandError("failed to copy from snapshot: %s", string(output))
 	}
 	return nil
 }
@@ -227,7 +889,7 @@ func FilePush(container, localPath, remotePath string, recursive bool) error {
 		if strings.Contains(errMsg, "Not Found") || strings.Contains(errMsg, "not found") {
 			return fmt.Errorf("destination path '%s' not found in container (does the directory exist?)", remotePath)
 		}
-		return fmt.Errorf("failed to copy to container: %s", errMsg)
+		return commandError("failed to copy to container: %s", errMsg)
 	}
 	return nil
 }
@@ -245,11 +907,225 @@ func FilePull(container, remotePath, localPath string, recursive bool) error {
 		if strings.Contains(errMsg, "Not Found") || strings.Contains(errMsg, "not found") {
 			return fmt.Errorf("source path '%s' not found in container", remotePath)
 		}
-		return fmt.Errorf("failed to copy from container: %s", errMsg)
+		return commandError("failed to copy from container: %s", errMsg)
+	}
+	return nil
+}
+
+// ReadFile returns the contents of a file in a container
+func ReadFile(container, path string) ([]byte, error) {
+	output, err := DefaultExecutor.Run("file", "pull", container+"/"+path, "-")
+	if err != nil {
+		return nil, commandError("failed to read '%s': %v", path, err)
+	}
+	return output, nil
+}
+
+// ListDir returns a long listing of a path in a container
+func ListDir(container, path string) (string, error) {
+	output, err := DefaultExecutor.RunCombined("exec", container, "--", "ls", "-la", "--", path)
+	if err != nil {
+		return "", commandError("failed to list '%s': %s", path, strings.TrimSpace(string(output)))
+	}
+	return string(output), nil
+}
+
+// RemovePath deletes a file, or a directory tree when recursive, in a container
+func RemovePath(container, path string, recursive bool) error {
+	args := []string{"exec", container, "--", "rm", "-f", "--", path}
+	if recursive {
+		args = []string{"exec", container, "--", "rm", "-rf", "--", path}
+	}
+	output, err := DefaultExecutor.RunCombined(args...)
+	if err != nil {
+		return commandError("failed to remove '%s': %s", path, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// FileOwner returns the numeric owner, group and octal mode of a path in a container
+func FileOwner(container, path string) (uid, gid int, mode string, err error) {
+	output, err := DefaultExecutor.Run("exec", container, "--", "stat", "-c", "%u %g %a", "--", path)
+	if err != nil {
+		return 0, 0, "", commandError("failed to stat '%s': %v", path, err)
+	}
+	fields := strings.Fields(string(output))
+	if len(fields) != 3 {
+		return 0, 0, "", commandError("unexpected stat output: %q", strings.TrimSpace(string(output)))
+	}
+	if uid, err = strconv.Atoi(fields[0]); err != nil {
+		return 0, 0, "", commandError("unexpected stat output: %q", strings.TrimSpace(string(output)))
+	}
+	if gid, err = strconv.Atoi(fields[1]); err != nil {
+		return 0, 0, "", commandError("unexpected stat output: %q", strings.TrimSpace(string(output)))
+	}
+	return uid, gid, fields[2], nil
+}
+
+// FilePushAs copies a single file into a container with the given
+// ownership and octal mode
+func FilePushAs(container, localPath, remotePath string, uid, gid int, mode string) error {
+	output, err := DefaultExecutor.RunCombined("file", "push",
+		"--uid", strconv.Itoa(uid), "--gid", strconv.Itoa(gid), "--mode", mode,
+		localPath, container+"/"+remotePath)
+	if err != nil {
+		return commandError("failed to copy to container: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// LifecycleEvent is an instance lifecycle event reported by lxc monitor
+type LifecycleEvent struct {
+	Action    string // e.g. instance-started, instance-stopped, instance-shutdown
+	Instance  string // Full LXC name
+	Requested bool   // Caused by an API request (lxc stop, ...) rather than from inside the container
+	Time      time.Time
+}
+
+// Monitor passes instance lifecycle events to fn until ctx is done
+func Monitor(ctx context.Context, fn func(LifecycleEvent)) error {
+	se, ok := DefaultExecutor.(StreamExecutor)
+	if !ok {
+		return fmt.Errorf("executor does not support streaming")
+	}
+
+	r, w := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- decodeLifecycleEvents(r, fn)
+		r.Close()
+	}()
+	err := se.RunStream(ctx, w, "monitor", "--type=lifecycle", "--format=json")
+	w.Close()
+	if decodeErr := <-done; err == nil {
+		err = decodeErr
+	}
+	if err != nil {
+		return commandError("lxc monitor failed: %v", err)
+	}
+	return nil
+}
+
+// decodeLifecycleEvents reads the JSON events lxc monitor prints and
+// passes the instance ones to fn
+func decodeLifecycleEvents(r io.Reader, fn func(LifecycleEvent)) error {
+	dec := json.NewDecoder(r)
+	for {
+		var event struct {
+			Type      string    `json:"type"`
+			Timestamp time.Time `json:"timestamp"`
+			Metadata  struct {
+				Action    string          `json:"action"`
+				Source    string          `json:"source"`
+				Requestor json.RawMessage `json:"requestor"`
+			} `json:"metadata"`
+		}
+		if err := dec.Decode(&event); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("failed to parse monitor event: %w", err)
+		}
+
+		instance, ok := strings.CutPrefix(event.Metadata.Source, "/1.0/instances/")
+		if event.Type != "lifecycle" || !ok {
+			continue
+		}
+		// Snapshot and backup events name the instance first
+		instance, _, _ = strings.Cut(instance, "/")
+		if i := strings.Index(instance, "?"); i >= 0 {
+			instance = instance[:i]
+		}
+		requestor := strings.TrimSpace(string(event.Metadata.Requestor))
+		fn(LifecycleEvent{
+			Action:    event.Metadata.Action,
+			Instance:  instance,
+			Requested: requestor != "" && requestor != "null",
+			Time:      event.Timestamp,
+		})
+	}
+}
+
+// CanStreamCopy reports whether the executor can pipe between containers,
+// which StreamCopy needs
+func CanStreamCopy() bool {
+	_, ok := DefaultExecutor.(PipeExecutor)
+	return ok
+}
+
+// StreamCopy copies a file or directory from one container to another by
+// piping it between the two without touching the host disk. A directory's
+// contents are extracted into destPath, which is created if needed.
+func StreamCopy(srcContainer, srcPath, destContainer, destPath string, dir bool) error {
+	pe, ok := DefaultExecutor.(PipeExecutor)
+	if !ok {
+		return fmt.Errorf("executor does not support streaming")
+	}
+
+	src := []string{"exec", srcContainer, "--", "cat", "--", srcPath}
+	dest := []string{"exec", destContainer, "--", "sh", "-c", `cat > "$1"`, "sh", destPath}
+	if dir {
+		src = []string{"exec", srcContainer, "--", "tar", "-C", srcPath, "-cf", "-", "."}
+		dest = []string{"exec", destContainer, "--", "sh", "-c", `mkdir -p "$1" && tar -C "$1" -xf -`, "sh", destPath}
+	}
+
+	output, err := pe.RunPipe(src, dest)
+	if err != nil {
+		msg := strings.TrimSpace(string(output))
+		if msg == "" {
+			msg = err.Error()
+		}
+		return commandError("failed to copy between containers: %s", msg)
 	}
 	return nil
 }
 
+// PathSize returns the apparent size in bytes of a file or directory in a container
+func PathSize(container, path string) (int64, error) {
+	output, err := DefaultExecutor.Run("exec", container, "--", "du", "-sb", path)
+	if err != nil {
+		return 0, commandError("failed to get size of %s: %v", path, err)
+	}
+	fields := strings.Fields(string(output))
+	if len(fields) == 0 {
+		return 0, commandError("unexpected du output: %q", strings.TrimSpace(string(output)))
+	}
+	size, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return 0, commandError("unexpected du output: %q", strings.TrimSpace(string(output)))
+	}
+	return size, nil
+}
+
+// Checksums returns sha256 checksums of a file, or of every regular file
+// under a directory keyed by slash-separated path relative to it. A single
+// file is keyed by "".
+func Checksums(container, path string, dir bool) (map[string]string, error) {
+	args := []string{"exec", container, "--", "sha256sum", "--", path}
+	if dir {
+		args = []string{"exec", container, "--", "sh", "-c", `cd "$1" && find . -type f -exec sha256sum -- {} +`, "sh", path}
+	}
+	output, err := DefaultExecutor.Run(args...)
+	if err != nil {
+		return nil, commandError("failed to checksum %s: %v", path, err)
+	}
+
+	sums := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		// "<hash>  <name>"
+		hash, name, ok := strings.Cut(line, "  ")
+		if !ok {
+			continue
+		}
+		if dir {
+			sums[strings.TrimPrefix(name, "./")] = hash
+		} else {
+			sums[""] = hash
+		}
+	}
+	return sums, nil
+}
+
 // FileExists checks if a file exists in a container
 func FileExists(container, path string) bool {
 	err := Exec(container, "test", "-e", path)
@@ -266,13 +1142,13 @@ func IsDir(container, path string) bool {
 func ListSnapshots(container string) ([]string, error) {
 	output, err := DefaultExecutor.Run("query", "/1.0/instances/"+container+"/snapshots")
 	if err != nil {
-		return nil, fmt.Errorf("failed to list snapshots: %v", err)
+		return nil, commandError("failed to list snapshots: %v", err)
 	}
 
 	// Parse JSON array of snapshot paths like ["/1.0/instances/foo/snapshots/snap1"]
 	var paths []string
 	if err := json.Unmarshal(output, &paths); err != nil {
-		return nil, fmt.Errorf("failed to parse snapshots: %v", err)
+		return nil, commandError("failed to parse snapshots: %v", err)
 	}
 
 	// Extract snapshot names from paths
@@ -286,76 +1162,185 @@ func ListSnapshots(container string) ([]string, error) {
 	return names, nil
 }
 
+// PublishOpts holds metadata for a published image
+type PublishOpts struct {
+	Public     bool      // Let other hosts pull the image without trusting this one
+	ExpiresAt  time.Time // Zero for no expiry
+	Properties map[string]string
+}
+
 // PublishSnapshotWithProgress publishes a container snapshot as an image,
-// streaming progress output to the provided writers
-func PublishSnapshotWithProgress(container, snapshotName, alias string, stdout, stderr io.Writer) error {
+// streaming progress output to the provided writers. The container is
+// recorded in the image's source_container property.
+func PublishSnapshotWithProgress(container, snapshotName, alias string, opts PublishOpts, stdout, stderr io.Writer) error {
+	cache.invalidateImages(alias)
 	source := container
 	if snapshotName != "" {
 		source = container + "/" + snapshotName
 	}
 
-	cmd := exec.Command("lxc", "publish", source, "--alias", alias)
+	// Properties follow the target remote, which must then be given
+	args := []string{"publish", source, "local:", "--alias", alias}
+	if opts.Public {
+		args = append(args, "--public")
+	}
+	if !opts.ExpiresAt.IsZero() {
+		args = append(args, "--expire="+opts.ExpiresAt.UTC().Format(time.RFC3339))
+	}
+	keys := make([]string, 0, len(opts.Properties))
+	for key := range opts.Properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		args = append(args, key+"="+opts.Properties[key])
+	}
+	args = append(args, SourceContainerProperty+"="+container)
+	if dryrun.Enabled() {
+		dryrun.Printf("%s", dryrun.Command("lxc", args...))
+		return nil
+	}
+	start := time.Now()
+	cmd := exec.Command("lxc", args...)
 	cmd.Stdout = stdout
 	cmd.Stderr = stderr
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to publish image: %w", err)
+	err := cmd.Run()
+	logCommand(args, start, err, nil)
+	if err != nil {
+		return commandError("failed to publish image: %w", err)
 	}
 	return nil
 }
 
-// ImageInfo holds information about an image
-type ImageInfo struct {
-	Alias       string
-	Fingerprint string
-	Size        string
-	Description string
-	CreatedAt   string
+// SourceContainerProperty is the image property recording the container
+// an image was published from
+const SourceContainerProperty = "source_container"
+
+// ImageDetail describes a local image
+type ImageDetail struct {
+	Fingerprint  string
+	Aliases      []string
+	Size         int64
+	Architecture string
+	Public       bool
+	Cached       bool // Downloaded automatically from a remote, not published locally
+	CreatedAt    time.Time
+	LastUsedAt   time.Time         // Zero if never used
+	ExpiresAt    time.Time         // Zero if the image doesn't expire
+	Properties   map[string]string // description, os, release, source_container, ...
 }
 
-// ListImages returns all local images
-func ListImages(all bool) ([]ImageInfo, error) {
-	// Format: l=alias, f=fingerprint, s=size, d=description
-	output, err := DefaultExecutor.Run("image", "list", "--format=csv", "-c", "lfsd")
+// ListImageDetails returns every local image
+func ListImageDetails() ([]ImageDetail, error) {
+	output, err := DefaultExecutor.Run("query", "/1.0/images?recursion=1")
 	if err != nil {
-		return nil, fmt.Errorf("failed to list images: %v", err)
+		return nil, commandError("failed to list images: %v", err)
 	}
 
-	var images []ImageInfo
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if len(strings.TrimSpace(string(output))) == 0 {
+		return nil, nil
+	}
 
-	for _, line := range lines {
-		if line == "" {
-			continue
+	var raw []struct {
+		Fingerprint string `json:"fingerprint"`
+		Aliases     []struct {
+			Name string `json:"name"`
+		} `json:"aliases"`
+		Size         int64             `json:"size"`
+		Architecture string            `json:"architecture"`
+		Public       bool              `json:"public"`
+		Cached       bool              `json:"cached"`
+		CreatedAt    time.Time         `json:"created_at"`
+		LastUsedAt   time.Time         `json:"last_used_at"`
+		ExpiresAt    time.Time         `json:"expires_at"`
+		Properties   map[string]string `json:"properties"`
+	}
+	if err := json.Unmarshal(output, &raw); err != nil {
+		return nil, commandError("failed to parse image list: %v", err)
+	}
+
+	images := make([]ImageDetail, 0, len(raw))
+	for _, img := range raw {
+		detail := ImageDetail{
+			Fingerprint:  img.Fingerprint,
+			Size:         img.Size,
+			Architecture: img.Architecture,
+			Public:       img.Public,
+			Cached:       img.Cached,
+			CreatedAt:    img.CreatedAt,
+			LastUsedAt:   img.LastUsedAt,
+			ExpiresAt:    img.ExpiresAt,
+			Properties:   img.Properties,
 		}
-		parts := strings.SplitN(line, ",", 4)
-		if len(parts) >= 3 {
-			info := ImageInfo{
-				Alias:       parts[0],
-				Fingerprint: parts[1],
-				Size:        parts[2],
-			}
-			if len(parts) >= 4 {
-				info.Description = parts[3]
-			}
+		// LXD reports unset dates (never used, no expiry) as the zero time of year 1
+		if detail.LastUsedAt.Year() <= 1 {
+			detail.LastUsedAt = time.Time{}
+		}
+		if detail.ExpiresAt.Year() <= 1 {
+			detail.ExpiresAt = time.Time{}
+		}
+		for _, alias := range img.Aliases {
+			detail.Aliases = append(detail.Aliases, alias.Name)
+		}
+		images = append(images, detail)
+	}
+	return images, nil
+}
 
-			// Skip non-aliased images unless all is true
-			if !all && info.Alias == "" {
-				continue
-			}
+// HostArchitectures returns the architectures the LXD server can run
+// containers for, its native architecture first
+func HostArchitectures() ([]string, error) {
+	output, err := DefaultExecutor.Run("query", "/1.0")
+	if err != nil {
+		return nil, commandError("failed to get server info: %v", err)
+	}
+	if len(strings.TrimSpace(string(output))) == 0 {
+		return nil, nil
+	}
 
-			images = append(images, info)
-		}
+	var server struct {
+		Environment struct {
+			Architectures []string `json:"architectures"`
+		} `json:"environment"`
+	}
+	if err := json.Unmarshal(output, &server); err != nil {
+		return nil, commandError("failed to parse server info: %v", err)
+	}
+	return server.Environment.Architectures, nil
+}
+
+// InstanceBaseImages returns the fingerprints of the images instances
+// were created from, mapped to the instances using each
+func InstanceBaseImages() (map[string][]string, error) {
+	output, err := DefaultExecutor.Run("query", "/1.0/instances?recursion=1")
+	if err != nil {
+		return nil, commandError("failed to list containers: %v", err)
 	}
 
-	return images, nil
+	var raw []struct {
+		Name   string            `json:"name"`
+		Config map[string]string `json:"config"`
+	}
+	if err := json.Unmarshal(output, &raw); err != nil {
+		return nil, commandError("failed to parse container list: %v", err)
+	}
+
+	used := make(map[string][]string)
+	for _, inst := range raw {
+		if fp := inst.Config["volatile.base_image"]; fp != "" {
+			used[fp] = append(used[fp], inst.Name)
+		}
+	}
+	return used, nil
 }
 
 // DeleteImage deletes an image by alias or fingerprint
 func DeleteImage(alias string) error {
+	cache.invalidateImages(alias)
 	output, err := DefaultExecutor.RunCombined("image", "delete", alias)
 	if err != nil {
-		return fmt.Errorf("failed to delete image: %s", string(output))
+		return commandError("failed to delete image: %s", string(output))
 	}
 	return nil
 }
@@ -364,12 +1349,12 @@ func DeleteImage(alias string) error {
 func GetImageFingerprint(alias string) (string, error) {
 	output, err := DefaultExecutor.Run("image", "list", alias, "--format=csv", "-c", "f")
 	if err != nil {
-		return "", fmt.Errorf("failed to get image fingerprint: %v", err)
+		return "", commandError("failed to get image fingerprint: %v", err)
 	}
 
 	fp := strings.TrimSpace(string(output))
 	if fp == "" {
-		return "", fmt.Errorf("image '%s' not found", alias)
+		return "", errcode.Errorf(errcode.NotFound, "", "image '%s' not found", alias)
 	}
 
 	// May have multiple lines, take first
@@ -382,6 +1367,7 @@ func GetImageFingerprint(alias string) (string, error) {
 
 // RenameImage renames an image by creating a new alias and deleting the old one
 func RenameImage(oldAlias, newAlias string) error {
+	cache.invalidateImages(oldAlias, newAlias)
 	// Get fingerprint of old alias
 	fp, err := GetImageFingerprint(oldAlias)
 	if err != nil {
@@ -391,7 +1377,7 @@ func RenameImage(oldAlias, newAlias string) error {
 	// Create new alias
 	output, err := DefaultExecutor.RunCombined("image", "alias", "create", newAlias, fp)
 	if err != nil {
-		return fmt.Errorf("failed to create new alias: %s", string(output))
+		return commandError("failed to create new alias: %s", string(output))
 	}
 
 	// Delete old alias
@@ -399,34 +1385,94 @@ func RenameImage(oldAlias, newAlias string) error {
 	if err != nil {
 		// Try to clean up new alias
 		DefaultExecutor.RunCombined("image", "alias", "delete", newAlias)
-		return fmt.Errorf("failed to delete old alias: %s", string(output))
+		return commandError("failed to delete old alias: %s", string(output))
 	}
 
 	return nil
 }
 
+// ExportImage writes an image's files into dir: a single tarball for
+// unified images, or a metadata tarball and a rootfs for split ones
+func ExportImage(alias, dir string) error {
+	output, err := DefaultExecutor.RunCombined("image", "export", alias, dir+"/")
+	if err != nil {
+		return commandError("failed to export image: %s", string(output))
+	}
+	return nil
+}
+
+// ImportImage imports an image from its files (a unified tarball, or a
+// metadata tarball and a rootfs) under an alias
+func ImportImage(alias string, files ...string) error {
+	cache.invalidateImages(alias)
+	args := append(append([]string{"image", "import"}, files...), "--alias", alias)
+	output, err := DefaultExecutor.RunCombined(args...)
+	if err != nil {
+		return commandError("failed to import image: %s", string(output))
+	}
+	return nil
+}
+
+// CopyImage copies an image between remotes, giving the copy an alias.
+// source is "[remote:]alias" and dest a remote name ("local" for this host).
+func CopyImage(source, dest, alias string) error {
+	if dest == "local" {
+		cache.invalidateImages(alias)
+	}
+	output, err := DefaultExecutor.RunCombined("image", "copy", source, dest+":", "--alias", alias)
+	if err != nil {
+		return commandError("failed to copy image: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
 // ImageExists checks if an image exists by alias
 func ImageExists(alias string) bool {
+	if exists, ok := cache.getImage(alias); ok {
+		return exists
+	}
 	_, err := GetImageFingerprint(alias)
+	cache.setImage(alias, err == nil)
 	return err == nil
 }
 
-// GetIP returns the container's IP address (prefers eth0)
+// GetIP returns the container's IPv4 address (prefers eth0)
 func GetIP(name string) (string, error) {
 	output, err := DefaultExecutor.Run("list", name, "-c4", "-f", "csv")
 	if err != nil {
-		return "", fmt.Errorf("failed to get IP: %v", err)
+		return "", commandError("failed to get IP: %v", err)
 	}
 
-	// Output format: "IP1 (iface1)\nIP2 (iface2)\n..." with surrounding quotes
-	content := strings.TrimSpace(string(output))
+	ip := parseIPList(string(output))
+	if ip == "" {
+		return "", fmt.Errorf("container has no IP address")
+	}
+	return ip, nil
+}
+
+// GetIPv6 returns the container's global IPv6 address (prefers eth0)
+func GetIPv6(name string) (string, error) {
+	output, err := DefaultExecutor.Run("list", name, "-c6", "-f", "csv")
+	if err != nil {
+		return "", commandError("failed to get IPv6: %v", err)
+	}
+
+	ip := parseIPList(string(output))
+	if ip == "" {
+		return "", fmt.Errorf("container has no IPv6 address")
+	}
+	return ip, nil
+}
+
+// parseIPList extracts the preferred address from an LXC address column.
+// Format: "IP1 (iface1)\nIP2 (iface2)\n..." with optional surrounding quotes.
+// Returns the eth0 address if present, otherwise the first address.
+func parseIPList(content string) string {
+	content = strings.TrimSpace(content)
 	content = strings.Trim(content, "\"")
 
-	// Parse each line looking for eth0 first, then fall back to first IP
-	lines := strings.Split(content, "\n")
 	var firstIP string
-
-	for _, line := range lines {
+	for _, line := range strings.Split(content, "\n") {
 		line = strings.TrimSpace(line)
 		if line == "" {
 			continue
@@ -440,7 +1486,7 @@ func GetIP(name string) (string, error) {
 
 		// Prefer eth0
 		if strings.Contains(line, "(eth0)") {
-			return ip, nil
+			return ip
 		}
 
 		// Save first valid IP as fallback
@@ -449,66 +1495,288 @@ func GetIP(name string) (string, error) {
 		}
 	}
 
-	if firstIP == "" {
-		return "", fmt.Errorf("container has no IP address")
+	return firstIP
+}
+
+// GetNetwork returns the managed network the container's eth0 is attached to
+func GetNetwork(name string) (string, error) {
+	output, err := DefaultExecutor.Run("query", "/1.0/instances/"+name)
+	if err != nil {
+		return "", commandError("failed to query container: %v", err)
+	}
+
+	var instance struct {
+		ExpandedDevices map[string]map[string]string `json:"expanded_devices"`
+	}
+	if err := json.Unmarshal(output, &instance); err != nil {
+		return "", commandError("failed to parse container info: %v", err)
+	}
+
+	eth0, ok := instance.ExpandedDevices["eth0"]
+	if !ok {
+		return "", fmt.Errorf("container has no eth0 device")
+	}
+	if eth0["network"] != "" {
+		return eth0["network"], nil
+	}
+	if eth0["parent"] != "" {
+		return eth0["parent"], nil
+	}
+	return "", fmt.Errorf("eth0 is not attached to a managed network")
+}
+
+// InstanceDetails holds instance settings not covered by list
+type InstanceDetails struct {
+	LastUsedAt time.Time         // Last start time
+	Limits     map[string]string // limits.* keys from the expanded config
+	RootSize   string            // size of the root disk device, empty if unlimited
+}
+
+// GetInstanceDetails returns the last start time and resource limits of a container
+func GetInstanceDetails(name string) (InstanceDetails, error) {
+	output, err := DefaultExecutor.Run("query", "/1.0/instances/"+name)
+	if err != nil {
+		return InstanceDetails{}, commandError("failed to query container: %v", err)
+	}
+
+	var instance struct {
+		LastUsedAt      time.Time                    `json:"last_used_at"`
+		ExpandedConfig  map[string]string            `json:"expanded_config"`
+		ExpandedDevices map[string]map[string]string `json:"expanded_devices"`
+	}
+	if err := json.Unmarshal(output, &instance); err != nil {
+		return InstanceDetails{}, commandError("failed to parse container info: %v", err)
+	}
+
+	details := InstanceDetails{
+		LastUsedAt: instance.LastUsedAt,
+		Limits:     make(map[string]string),
+		RootSize:   instance.ExpandedDevices["root"]["size"],
+	}
+	for key, value := range instance.ExpandedConfig {
+		if strings.HasPrefix(key, "limits.") {
+			details.Limits[key] = value
+		}
+	}
+	return details, nil
+}
+
+// GetRootPool returns the storage pool backing a container's root disk
+func GetRootPool(name string) (string, error) {
+	output, err := DefaultExecutor.Run("query", "/1.0/instances/"+name)
+	if err != nil {
+		return "", commandError("failed to query container: %v", err)
+	}
+
+	var instance struct {
+		ExpandedDevices map[string]map[string]string `json:"expanded_devices"`
+	}
+	if err := json.Unmarshal(output, &instance); err != nil {
+		return "", commandError("failed to parse container info: %v", err)
 	}
 
-	return firstIP, nil
+	root, ok := instance.ExpandedDevices["root"]
+	if !ok || root["pool"] == "" {
+		return "", fmt.Errorf("container has no root disk on a storage pool")
+	}
+	return root["pool"], nil
+}
+
+// PoolSpace returns the total and used bytes of a storage pool
+func PoolSpace(pool string) (total, used int64, err error) {
+	output, err := DefaultExecutor.Run("query", "/1.0/storage-pools/"+pool+"/resources")
+	if err != nil {
+		return 0, 0, commandError("failed to query pool %s: %v", pool, err)
+	}
+
+	var resources struct {
+		Space struct {
+			Total int64 `json:"total"`
+			Used  int64 `json:"used"`
+		} `json:"space"`
+	}
+	if err := json.Unmarshal(output, &resources); err != nil {
+		return 0, 0, commandError("failed to parse pool resources: %v", err)
+	}
+	return resources.Space.Total, resources.Space.Used, nil
+}
+
+// SetRootDiskSize sets the size quota of a container's root disk.
+// The root device is usually inherited from a profile, so it is overridden
+// first; if the container already has a local root device it is set directly.
+func SetRootDiskSize(name, size string) error {
+	output, err := DefaultExecutor.RunCombined("config", "device", "override", name, "root", "size="+size)
+	if err == nil {
+		return nil
+	}
+
+	output, err = DefaultExecutor.RunCombined("config", "device", "set", name, "root", "size", size)
+	if err != nil {
+		return commandError("failed to set disk size: %s", string(output))
+	}
+	return nil
+}
+
+// GetNetworkSubnet returns the IPv4 address of a managed network in CIDR form (e.g. 10.10.10.1/24)
+func GetNetworkSubnet(network string) (string, error) {
+	output, err := DefaultExecutor.Run("network", "get", network, "ipv4.address")
+	if err != nil {
+		return "", commandError("failed to get network address: %v", err)
+	}
+	cidr := strings.TrimSpace(string(output))
+	if cidr == "" || cidr == "none" {
+		return "", fmt.Errorf("network '%s' has no IPv4 subnet", network)
+	}
+	return cidr, nil
+}
+
+// SetStaticIP pins the container's eth0 IPv4 address.
+// eth0 usually comes from a profile, so it is overridden on the instance first;
+// if the device is already local, its key is set directly.
+func SetStaticIP(name, ip string) error {
+	output, err := DefaultExecutor.RunCombined("config", "device", "override", name, "eth0", "ipv4.address="+ip)
+	if err == nil {
+		return nil
+	}
+
+	output, err = DefaultExecutor.RunCombined("config", "device", "set", name, "eth0", "ipv4.address", ip)
+	if err != nil {
+		return commandError("failed to set static IP: %s", string(output))
+	}
+	return nil
+}
+
+// ClearStaticIP removes a static IPv4 address from eth0 so it uses DHCP again
+func ClearStaticIP(name string) error {
+	output, err := DefaultExecutor.RunCombined("config", "device", "unset", name, "eth0", "ipv4.address")
+	if err != nil {
+		return commandError("failed to clear static IP: %s", string(output))
+	}
+	return nil
+}
+
+// ShowLog returns the container's LXC log, as shown by "lxc info --show-log"
+func ShowLog(name string) (string, error) {
+	output, err := DefaultExecutor.Run("info", name, "--show-log")
+	if err != nil {
+		return "", commandError("failed to read log: %v", err)
+	}
+	log := string(output)
+	if i := strings.Index(log, "\nLog:\n"); i >= 0 {
+		log = log[i+len("\nLog:\n"):]
+	}
+	return strings.TrimSpace(log), nil
 }
 
 // GetStatus returns the container status
 func GetStatus(name string) (string, error) {
+	if status, ok := cache.getStatus(name); ok {
+		return status, nil
+	}
 	output, err := DefaultExecutor.Run("list", name, "-cs", "-f", "csv")
 	if err != nil {
-		return "", fmt.Errorf("failed to get status: %v", err)
+		return "", commandError("failed to get status: %v", err)
 	}
-	return strings.TrimSpace(string(output)), nil
+	status := strings.TrimSpace(string(output))
+	cache.setStatus(name, status)
+	return status, nil
 }
 
 // Exists checks if a container exists
 func Exists(name string) bool {
+	if exists, ok := cache.getExists(name); ok {
+		return exists
+	}
 	_, err := DefaultExecutor.Run("info", name)
+	cache.setExists(name, err == nil)
 	return err == nil
 }
 
 // ContainerInfo holds container information
 type ContainerInfo struct {
-	Name   string
-	Status string
-	IP     string
+	Name      string
+	Status    string
+	IP        string
+	IPv6      string
+	Snapshots int   // Number of snapshots
+	DiskUsage int64 // Bytes used by the root disk, 0 if unknown
 }
 
-// ListAll returns all containers with their status and IP
+// ListAll returns all containers with their status, IPv4/IPv6 addresses,
+// snapshot count and disk usage from a single "lxc list --format json" call
 func ListAll() ([]ContainerInfo, error) {
-	output, err := DefaultExecutor.Run("list", "-c", "ns4", "-f", "csv")
+	output, err := DefaultExecutor.Run("list", "--format", "json")
 	if err != nil {
-		return nil, fmt.Errorf("failed to list containers: %v", err)
+		return nil, commandError("failed to list containers: %v", err)
 	}
+	return parseInstanceList(output)
+}
 
-	var containers []ContainerInfo
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+// instanceJSON is the subset of "lxc list --format json" that ListAll reads
+type instanceJSON struct {
+	Name      string            `json:"name"`
+	Status    string            `json:"status"`
+	Snapshots []json.RawMessage `json:"snapshots"`
+	State     *struct {
+		Network map[string]struct {
+			Addresses []struct {
+				Family  string `json:"family"`
+				Address string `json:"address"`
+				Scope   string `json:"scope"`
+			} `json:"addresses"`
+		} `json:"network"`
+		Disk map[string]struct {
+			Usage int64 `json:"usage"`
+		} `json:"disk"`
+	} `json:"state"`
+}
 
-	for _, line := range lines {
-		if line == "" {
-			continue
+func parseInstanceList(output []byte) ([]ContainerInfo, error) {
+	if len(strings.TrimSpace(string(output))) == 0 {
+		return nil, nil
+	}
+
+	var instances []instanceJSON
+	if err := json.Unmarshal(output, &instances); err != nil {
+		return nil, commandError("failed to parse container list: %v", err)
+	}
+
+	containers := make([]ContainerInfo, 0, len(instances))
+	for _, inst := range instances {
+		info := ContainerInfo{
+			Name:      inst.Name,
+			Status:    strings.ToUpper(inst.Status),
+			Snapshots: len(inst.Snapshots),
 		}
-		parts := strings.Split(line, ",")
-		if len(parts) >= 2 {
-			info := ContainerInfo{
-				Name:   parts[0],
-				Status: parts[1],
+		if inst.State != nil {
+			info.DiskUsage = inst.State.Disk["root"].Usage
+
+			// Prefer eth0, then the first other interface by name, like lxc list
+			ifaces := make([]string, 0, len(inst.State.Network))
+			for name := range inst.State.Network {
+				if name != "lo" {
+					ifaces = append(ifaces, name)
+				}
 			}
-			if len(parts) >= 3 {
-				ip := parts[2]
-				if idx := strings.Index(ip, " "); idx > 0 {
-					ip = ip[:idx]
+			sort.Slice(ifaces, func(i, j int) bool {
+				return ifaces[i] == "eth0" || (ifaces[j] != "eth0" && ifaces[i] < ifaces[j])
+			})
+			for _, name := range ifaces {
+				for _, addr := range inst.State.Network[name].Addresses {
+					if addr.Scope != "global" {
+						continue
+					}
+					if addr.Family == "inet" && info.IP == "" {
+						info.IP = addr.Address
+					}
+					if addr.Family == "inet6" && info.IPv6 == "" {
+						info.IPv6 = addr.Address
+					}
 				}
-				info.IP = ip
 			}
-			containers = append(containers, info)
 		}
+		containers = append(containers, info)
 	}
-
 	return containers, nil
 }
 
@@ -522,12 +1790,17 @@ type DeviceInfo struct {
 // DeviceAdd adds a device to a container
 func DeviceAdd(container, name, deviceType string, config map[string]string) error {
 	args := []string{"config", "device", "add", container, name, deviceType}
-	for key, value := range config {
-		args = append(args, key+"="+value)
+	keys := make([]string, 0, len(config))
+	for key := range config {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		args = append(args, key+"="+config[key])
 	}
 	output, err := DefaultExecutor.RunCombined(args...)
 	if err != nil {
-		return fmt.Errorf("failed to add device: %s", string(output))
+		return commandError("failed to add device: %s", string(output))
 	}
 	return nil
 }
@@ -536,7 +1809,7 @@ func DeviceAdd(container, name, deviceType string, config map[string]string) err
 func DeviceRemove(container, name string) error {
 	output, err := DefaultExecutor.RunCombined("config", "device", "remove", container, name)
 	if err != nil {
-		return fmt.Errorf("failed to remove device: %s", string(output))
+		return commandError("failed to remove device: %s", string(output))
 	}
 	return nil
 }
@@ -545,7 +1818,7 @@ func DeviceRemove(container, name string) error {
 func DeviceList(container string) ([]DeviceInfo, error) {
 	output, err := DefaultExecutor.RunCombined("config", "device", "show", container)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list devices: %s", string(output))
+		return nil, commandError("failed to list devices: %s", string(output))
 	}
 
 	// Parse YAML output
@@ -556,7 +1829,7 @@ func DeviceList(container string) ([]DeviceInfo, error) {
 	//   ...
 	var rawDevices map[string]map[string]string
 	if err := yaml.Unmarshal(output, &rawDevices); err != nil {
-		return nil, fmt.Errorf("failed to parse device list: %v", err)
+		return nil, commandError("failed to parse device list: %v", err)
 	}
 
 	var devices []DeviceInfo
@@ -596,7 +1869,144 @@ func DeviceExists(container, name string) (bool, error) {
 func IsPrivileged(container string) (bool, error) {
 	output, err := DefaultExecutor.RunCombined("config", "get", container, "security.privileged")
 	if err != nil {
-		return false, fmt.Errorf("failed to get privileged status: %s", string(output))
+		return false, commandError("failed to get privileged status: %s", string(output))
 	}
 	return strings.TrimSpace(string(output)) == "true", nil
 }
+
+// StorageVolume describes an LXD custom storage volume
+type StorageVolume struct {
+	Name   string
+	Pool   string
+	Size   string   // Quota from config (empty if unlimited)
+	UsedBy []string // Instance names the volume is attached to
+}
+
+// VolumeCreate creates a custom storage volume, optionally with a size quota
+func VolumeCreate(pool, name, size string) error {
+	args := []string{"storage", "volume", "create", pool, name}
+	if size != "" {
+		args = append(args, "size="+size)
+	}
+	output, err := DefaultExecutor.RunCombined(args...)
+	if err != nil {
+		return commandError("failed to create volume: %s", string(output))
+	}
+	return nil
+}
+
+// VolumeDelete deletes a custom storage volume
+func VolumeDelete(pool, name string) error {
+	output, err := DefaultExecutor.RunCombined("storage", "volume", "delete", pool, name)
+	if err != nil {
+		return commandError("failed to delete volume: %s", string(output))
+	}
+	return nil
+}
+
+// VolumeExists checks if a custom storage volume exists in a pool
+func VolumeExists(pool, name string) bool {
+	_, err := DefaultExecutor.Run("storage", "volume", "show", pool, name)
+	return err == nil
+}
+
+// VolumeList returns all custom storage volumes in a pool
+func VolumeList(pool string) ([]StorageVolume, error) {
+	output, err := DefaultExecutor.Run("query", "/1.0/storage-pools/"+pool+"/volumes/custom?recursion=1")
+	if err != nil {
+		return nil, commandError("failed to list volumes in pool %s: %v", pool, err)
+	}
+
+	var raw []struct {
+		Name   string            `json:"name"`
+		Config map[string]string `json:"config"`
+		UsedBy []string          `json:"used_by"`
+	}
+	if err := json.Unmarshal(output, &raw); err != nil {
+		return nil, commandError("failed to parse volume list: %v", err)
+	}
+
+	volumes := make([]StorageVolume, 0, len(raw))
+	for _, v := range raw {
+		vol := StorageVolume{Name: v.Name, Pool: pool, Size: v.Config["size"]}
+		for _, ref := range v.UsedBy {
+			// "/1.0/instances/<name>?project=default"
+			ref = strings.SplitN(ref, "?", 2)[0]
+			if strings.HasPrefix(ref, "/1.0/instances/") {
+				vol.UsedBy = append(vol.UsedBy, strings.TrimPrefix(ref, "/1.0/instances/"))
+			}
+		}
+		volumes = append(volumes, vol)
+	}
+	return volumes, nil
+}
+
+// GetDiskUsage returns the bytes used by a container's root disk
+func GetDiskUsage(name string) (int64, error) {
+	output, err := DefaultExecutor.Run("query", "/1.0/instances/"+name+"/state")
+	if err != nil {
+		return 0, commandError("failed to query container state: %v", err)
+	}
+
+	var state struct {
+		Disk map[string]struct {
+			Usage int64 `json:"usage"`
+		} `json:"disk"`
+	}
+	if err := json.Unmarshal(output, &state); err != nil {
+		return 0, commandError("failed to parse container state: %v", err)
+	}
+	return state.Disk["root"].Usage, nil
+}
+
+// GetPoolDriver returns a storage pool's driver and, for zfs pools, the
+// backing zfs dataset
+func GetPoolDriver(pool string) (driver, zfsPool string, err error) {
+	output, err := DefaultExecutor.Run("query", "/1.0/storage-pools/"+pool)
+	if err != nil {
+		return "", "", commandError("failed to query pool %s: %v", pool, err)
+	}
+
+	var info struct {
+		Driver string            `json:"driver"`
+		Config map[string]string `json:"config"`
+	}
+	if err := json.Unmarshal(output, &info); err != nil {
+		return "", "", commandError("failed to parse pool info: %v", err)
+	}
+
+	zfsPool = info.Config["zfs.pool_name"]
+	if zfsPool == "" {
+		zfsPool = pool
+	}
+	return info.Driver, zfsPool, nil
+}
+
+// ZFSSnapshotUsage returns the space held by each snapshot of a container
+// on a zfs pool, keyed by snapshot name
+func ZFSSnapshotUsage(zfsPool, name string) (map[string]int64, error) {
+	dataset := zfsPool + "/containers/" + name
+	output, err := ZFSRunner("list", "-Hp", "-t", "snapshot", "-o", "name,used", "-r", dataset)
+	if err != nil {
+		return nil, commandError("failed to list zfs snapshots for %s: %v", dataset, err)
+	}
+
+	usage := make(map[string]int64)
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		_, snap, ok := strings.Cut(fields[0], "@")
+		if !ok {
+			continue
+		}
+		used, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		// LXD names zfs snapshots "snapshot-<name>"
+		usage[strings.TrimPrefix(snap, "snapshot-")] = used
+	}
+	return usage, nil
+}
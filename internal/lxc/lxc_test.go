@@ -1,9 +1,13 @@
 package lxc
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"log/slog"
 	"strings"
 	"testing"
+	"time"
 )
 
 func setupMock(t *testing.T) *MockExecutor {
@@ -100,11 +104,13 @@ func TestGetStatus_CommandError(t *testing.T) {
 	}
 }
 
-func TestListAll_ParsesCSV(t *testing.T) {
+func TestListAll_ParsesJSON(t *testing.T) {
 	mock := setupMock(t)
-	mock.SetOutput("list -c ns4 -f csv", `dev1,RUNNING,10.10.10.45 (eth0)
-dev2,STOPPED,
-dev3,RUNNING,10.10.10.46 (eth0)`)
+	mock.SetOutput("list --format json", `[
+ {"name":"dev1","status":"Running","state":{"network":{"eth0":{"addresses":[{"family":"inet","address":"10.10.10.45","scope":"global"}]}}}},
+ {"name":"dev2","status":"Stopped","state":null},
+ {"name":"dev3","status":"Running","state":{"network":{"eth0":{"addresses":[{"family":"inet","address":"10.10.10.46","scope":"global"}]}}}}
+]`)
 
 	containers, err := ListAll()
 	if err != nil {
@@ -125,7 +131,7 @@ dev3,RUNNING,10.10.10.46 (eth0)`)
 
 func TestListAll_Empty(t *testing.T) {
 	mock := setupMock(t)
-	mock.SetOutput("list -c ns4 -f csv", "")
+	mock.SetOutput("list --format json", "[]")
 
 	containers, err := ListAll()
 	if err != nil {
@@ -139,7 +145,7 @@ func TestListAll_Empty(t *testing.T) {
 
 func TestListAll_CommandError(t *testing.T) {
 	mock := setupMock(t)
-	mock.SetError("list -c ns4 -f csv", "permission denied")
+	mock.SetError("list --format json", "permission denied")
 
 	_, err := ListAll()
 	if err == nil {
@@ -445,113 +451,64 @@ func TestDeleteSnapshot_Error(t *testing.T) {
 	}
 }
 
-// Tests for ListImages function
-func TestListImages_Success(t *testing.T) {
+// Tests for ListImageDetails function
+func TestListImageDetails_Success(t *testing.T) {
 	mock := setupMock(t)
-	mock.SetOutput("image list --format=csv -c lfsd", `my-base,abc123def456,500MiB,Ubuntu 24.04
-dev-image,def789ghi012,1.2GiB,Custom dev image`)
+	mock.SetOutput("query /1.0/images?recursion=1", `[
+ {"fingerprint":"abc123def456","aliases":[{"name":"my-base"},{"name":"base"}],"size":524288000,
+  "architecture":"x86_64","cached":false,"created_at":"2026-01-02T03:04:05Z","last_used_at":"0001-01-01T00:00:00Z",
+  "properties":{"description":"Ubuntu 24.04","source_container":"proj-dev1"}},
+ {"fingerprint":"def456","aliases":[],"size":1024,"cached":true,"created_at":"2026-01-01T00:00:00Z"}
+]`)
 
-	images, err := ListImages(false)
+	images, err := ListImageDetails()
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-
 	if len(images) != 2 {
 		t.Fatalf("expected 2 images, got %d", len(images))
 	}
 
-	if images[0].Alias != "my-base" {
-		t.Errorf("expected alias 'my-base', got '%s'", images[0].Alias)
+	img := images[0]
+	if img.Fingerprint != "abc123def456" || img.Size != 524288000 || img.Architecture != "x86_64" {
+		t.Errorf("unexpected image: %+v", img)
+	}
+	if len(img.Aliases) != 2 || img.Aliases[0] != "my-base" {
+		t.Errorf("expected aliases [my-base base], got %v", img.Aliases)
 	}
-	if images[0].Fingerprint != "abc123def456" {
-		t.Errorf("expected fingerprint 'abc123def456', got '%s'", images[0].Fingerprint)
+	if img.Properties["description"] != "Ubuntu 24.04" || img.Properties[SourceContainerProperty] != "proj-dev1" {
+		t.Errorf("unexpected properties: %v", img.Properties)
 	}
-	if images[0].Size != "500MiB" {
-		t.Errorf("expected size '500MiB', got '%s'", images[0].Size)
+	if !img.LastUsedAt.IsZero() {
+		t.Errorf("expected zero last used time, got %v", img.LastUsedAt)
 	}
-	if images[0].Description != "Ubuntu 24.04" {
-		t.Errorf("expected description 'Ubuntu 24.04', got '%s'", images[0].Description)
+	if !images[1].Cached || len(images[1].Aliases) != 0 {
+		t.Errorf("expected cached image without aliases, got %+v", images[1])
 	}
 }
 
-func TestListImages_Empty(t *testing.T) {
+func TestListImageDetails_Empty(t *testing.T) {
 	mock := setupMock(t)
-	mock.SetOutput("image list --format=csv -c lfsd", "")
+	mock.SetOutput("query /1.0/images?recursion=1", "")
 
-	images, err := ListImages(false)
+	images, err := ListImageDetails()
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-
 	if len(images) != 0 {
 		t.Errorf("expected 0 images, got %d", len(images))
 	}
 }
 
-func TestListImages_FiltersCachedImages(t *testing.T) {
+func TestListImageDetails_Error(t *testing.T) {
 	mock := setupMock(t)
-	// One aliased, one cached (no alias)
-	mock.SetOutput("image list --format=csv -c lfsd", `my-base,abc123,500MiB,Ubuntu
-,def456,300MiB,cached image`)
-
-	images, err := ListImages(false)
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
+	mock.SetError("query /1.0/images?recursion=1", "permission denied")
 
-	// Should only return aliased image when all=false
-	if len(images) != 1 {
-		t.Errorf("expected 1 image (filtered), got %d", len(images))
-	}
-	if images[0].Alias != "my-base" {
-		t.Errorf("expected 'my-base', got '%s'", images[0].Alias)
-	}
-}
-
-func TestListImages_ShowsAllWithFlag(t *testing.T) {
-	mock := setupMock(t)
-	mock.SetOutput("image list --format=csv -c lfsd", `my-base,abc123,500MiB,Ubuntu
-,def456,300MiB,cached image`)
-
-	images, err := ListImages(true)
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
-
-	// Should return all images when all=true
-	if len(images) != 2 {
-		t.Errorf("expected 2 images, got %d", len(images))
-	}
-}
-
-func TestListImages_Error(t *testing.T) {
-	mock := setupMock(t)
-	mock.SetError("image list --format=csv -c lfsd", "permission denied")
-
-	_, err := ListImages(false)
-	if err == nil {
+	if _, err := ListImageDetails(); err == nil {
 		t.Fatal("expected error")
 	}
 }
 
-func TestListImages_PartialCSV(t *testing.T) {
-	mock := setupMock(t)
-	// Only 3 columns (no description)
-	mock.SetOutput("image list --format=csv -c lfsd", "my-base,abc123,500MiB")
-
-	images, err := ListImages(false)
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
-
-	if len(images) != 1 {
-		t.Fatalf("expected 1 image, got %d", len(images))
-	}
-	if images[0].Description != "" {
-		t.Errorf("expected empty description, got '%s'", images[0].Description)
-	}
-}
-
 // Tests for DeleteImage function
 func TestDeleteImage_Success(t *testing.T) {
 	mock := setupMock(t)
@@ -830,7 +787,7 @@ func TestListSnapshots_InvalidJSON(t *testing.T) {
 // Tests for DeviceAdd function
 func TestDeviceAdd(t *testing.T) {
 	mock := setupMock(t)
-	mock.SetOutput("config device add dev1 repo disk source=/host/path path=/container/path", "")
+	mock.SetOutput("config device add dev1 repo disk path=/container/path source=/host/path", "")
 
 	config := map[string]string{
 		"source": "/host/path",
@@ -848,7 +805,7 @@ func TestDeviceAdd(t *testing.T) {
 
 func TestDeviceAdd_Error(t *testing.T) {
 	mock := setupMock(t)
-	mock.SetError("config device add dev1 repo disk source=/host/path path=/container/path", "container not found")
+	mock.SetError("config device add dev1 repo disk path=/container/path source=/host/path", "container not found")
 
 	config := map[string]string{
 		"source": "/host/path",
@@ -1084,3 +1041,472 @@ func TestIsPrivileged_Error(t *testing.T) {
 		t.Errorf("unexpected error message: %v", err)
 	}
 }
+
+func TestGetIPv6_ParsesOutput(t *testing.T) {
+	mock := setupMock(t)
+	mock.SetOutput("list dev1 -c6 -f csv", "fd42:1::10 (eth0)")
+
+	ip, err := GetIPv6("dev1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ip != "fd42:1::10" {
+		t.Errorf("expected fd42:1::10, got %s", ip)
+	}
+}
+
+func TestGetIPv6_NoIP(t *testing.T) {
+	mock := setupMock(t)
+	mock.SetOutput("list dev1 -c6 -f csv", "")
+
+	if _, err := GetIPv6("dev1"); err == nil {
+		t.Fatal("expected error for no IPv6")
+	}
+}
+
+func TestListAll_DualStack(t *testing.T) {
+	mock := setupMock(t)
+	mock.SetOutput("list --format json", `[
+ {"name":"dev1","status":"Running","state":{"network":{
+  "docker0":{"addresses":[{"family":"inet","address":"172.17.0.1","scope":"global"}]},
+  "eth0":{"addresses":[
+   {"family":"inet","address":"10.10.10.45","scope":"global"},
+   {"family":"inet6","address":"fe80::1","scope":"link"},
+   {"family":"inet6","address":"fd42:1::10","scope":"global"}]},
+  "lo":{"addresses":[{"family":"inet","address":"127.0.0.1","scope":"local"}]}}}},
+ {"name":"dev2","status":"Stopped","state":{"network":{}}}
+]`)
+
+	containers, err := ListAll()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(containers) != 2 {
+		t.Fatalf("expected 2 containers, got %d", len(containers))
+	}
+	if containers[0].IP != "10.10.10.45" {
+		t.Errorf("expected eth0 IPv4, got %q", containers[0].IP)
+	}
+	if containers[0].IPv6 != "fd42:1::10" {
+		t.Errorf("expected IPv6 fd42:1::10, got %q", containers[0].IPv6)
+	}
+	if containers[1].IP != "" || containers[1].IPv6 != "" {
+		t.Errorf("expected no addresses for stopped container, got %+v", containers[1])
+	}
+}
+
+func TestGetIDMap_ParsesLastState(t *testing.T) {
+	mock := setupMock(t)
+	mock.SetOutput("config get dev1 volatile.last_state.idmap",
+		`[{"Isuid":true,"Isgid":false,"Hostid":1000000,"Nsid":0,"Maprange":1000000000},{"Isuid":false,"Isgid":true,"Hostid":1000000,"Nsid":0,"Maprange":1000000000}]`)
+
+	entries, err := GetIDMap("dev1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+
+	host, ok := HostUID(entries, 1000)
+	if !ok || host != 1001000 {
+		t.Errorf("expected container UID 1000 to map to 1001000, got %d (ok=%v)", host, ok)
+	}
+}
+
+func TestGetIDMap_FallsBackToNext(t *testing.T) {
+	mock := setupMock(t)
+	mock.SetOutput("config get dev1 volatile.last_state.idmap", "")
+	mock.SetOutput("config get dev1 volatile.idmap.next",
+		`[{"Isuid":true,"Isgid":true,"Hostid":1000,"Nsid":1000,"Maprange":1}]`)
+
+	entries, err := GetIDMap("dev1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if host, ok := HostUID(entries, 1000); !ok || host != 1000 {
+		t.Errorf("expected identity mapping for 1000, got %d (ok=%v)", host, ok)
+	}
+	if _, ok := HostUID(entries, 0); ok {
+		t.Error("expected UID 0 to be unmapped")
+	}
+}
+
+func TestVolumeList_ParsesUsedBy(t *testing.T) {
+	mock := setupMock(t)
+	mock.SetOutput("query /1.0/storage-pools/default/volumes/custom?recursion=1",
+		`[{"name":"proj-cache","config":{"size":"10GiB"},"used_by":["/1.0/instances/proj-dev1","/1.0/instances/proj-dev2?project=default"]}]`)
+
+	volumes, err := VolumeList("default")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(volumes) != 1 {
+		t.Fatalf("expected 1 volume, got %d", len(volumes))
+	}
+
+	v := volumes[0]
+	if v.Name != "proj-cache" || v.Size != "10GiB" || v.Pool != "default" {
+		t.Errorf("unexpected volume: %+v", v)
+	}
+	if len(v.UsedBy) != 2 || v.UsedBy[0] != "proj-dev1" || v.UsedBy[1] != "proj-dev2" {
+		t.Errorf("unexpected used_by: %v", v.UsedBy)
+	}
+}
+
+func TestZFSSnapshotUsage(t *testing.T) {
+	orig := ZFSRunner
+	defer func() { ZFSRunner = orig }()
+
+	var gotArgs []string
+	ZFSRunner = func(args ...string) ([]byte, error) {
+		gotArgs = args
+		return []byte("default/containers/proj-dev1@snapshot-initial-state\t1048576\n" +
+			"default/containers/proj-dev1@snapshot-checkpoint\t2097152\n"), nil
+	}
+
+	usage, err := ZFSSnapshotUsage("default", "proj-dev1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotArgs[len(gotArgs)-1] != "default/containers/proj-dev1" {
+		t.Errorf("unexpected dataset: %v", gotArgs)
+	}
+	if usage["initial-state"] != 1048576 || usage["checkpoint"] != 2097152 {
+		t.Errorf("unexpected usage: %v", usage)
+	}
+}
+
+func TestGetDiskUsage(t *testing.T) {
+	mock := setupMock(t)
+	mock.SetOutput("query /1.0/instances/dev1/state", `{"disk":{"root":{"usage":5368709120}}}`)
+
+	used, err := GetDiskUsage("dev1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if used != 5368709120 {
+		t.Errorf("expected 5368709120, got %d", used)
+	}
+}
+
+func TestListeningPorts(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []ListeningSocket
+	}{
+		{
+			name:   "ss",
+			output: "LISTEN 0 4096 127.0.0.53%lo:53 0.0.0.0:*\nLISTEN 0 511 *:3000 *:*\nLISTEN 0 128 [::]:22 [::]:*\n",
+			want:   []ListeningSocket{{"127.0.0.53", 53}, {"*", 3000}, {"::", 22}},
+		},
+		{
+			name: "netstat",
+			output: "Active Internet connections (only servers)\n" +
+				"Proto Recv-Q Send-Q Local Address           Foreign Address         State\n" +
+				"tcp        0      0 0.0.0.0:8000            0.0.0.0:*               LISTEN\n" +
+				"tcp6       0      0 :::5173                 :::*                    LISTEN\n",
+			want: []ListeningSocket{{"0.0.0.0", 8000}, {"::", 5173}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := setupMock(t)
+			mock.SetOutput("exec dev1 -- sh -c", tt.output)
+
+			got, err := ListeningPorts("dev1")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("socket %d: expected %v, got %v", i, tt.want[i], got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestChecksums_Directory(t *testing.T) {
+	mock := setupMock(t)
+	mock.SetOutput("exec dev1 -- sh -c", "aaa  ./main.go\nbbb  ./src/lib with space.go\n")
+
+	sums, err := Checksums("dev1", "/app", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sums["main.go"] != "aaa" || sums["src/lib with space.go"] != "bbb" {
+		t.Errorf("unexpected checksums: %v", sums)
+	}
+}
+
+func TestChecksums_File(t *testing.T) {
+	mock := setupMock(t)
+	mock.SetOutput("exec dev1 -- sha256sum -- /etc/hosts", "ccc  /etc/hosts\n")
+
+	sums, err := Checksums("dev1", "/etc/hosts", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sums) != 1 || sums[""] != "ccc" {
+		t.Errorf("unexpected checksums: %v", sums)
+	}
+}
+
+func TestAddUserGroups(t *testing.T) {
+	mock := setupMock(t)
+
+	if err := AddUserGroups("dev1", "dev", []string{"docker", "video"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mock.Calls) != 1 {
+		t.Fatalf("expected one call, got %v", mock.Calls)
+	}
+	script := mock.Calls[0].Args[len(mock.Calls[0].Args)-1]
+	for _, want := range []string{"groupadd 'docker'", "groupadd 'video'", "usermod -aG 'docker,video' 'dev'"} {
+		if !strings.Contains(script, want) {
+			t.Errorf("expected script to contain %q, got:\n%s", want, script)
+		}
+	}
+}
+
+func TestAuthorizeKey(t *testing.T) {
+	mock := setupMock(t)
+
+	if err := AuthorizeKey("dev1", "dev", "ssh-ed25519 AAAA user@host"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	script := mock.Calls[0].Args[len(mock.Calls[0].Args)-1]
+	for _, want := range []string{"getent passwd 'dev'", "grep -qxF 'ssh-ed25519 AAAA user@host'", "chown -R 'dev':"} {
+		if !strings.Contains(script, want) {
+			t.Errorf("expected script to contain %q, got:\n%s", want, script)
+		}
+	}
+}
+
+func TestListAll_SnapshotsAndDisk(t *testing.T) {
+	mock := setupMock(t)
+	mock.SetOutput("list --format json", `[
+ {"name":"dev1","status":"Running","snapshots":[{"name":"a"},{"name":"b"},{"name":"c"}],"state":{"disk":{"root":{"usage":1610612736}}}},
+ {"name":"dev2","status":"Stopped","snapshots":null,"state":{"disk":{"root":{"usage":512}}}}
+]`)
+
+	containers, err := ListAll()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if containers[0].Snapshots != 3 || containers[0].DiskUsage != 3<<29 {
+		t.Errorf("unexpected container 0: %+v", containers[0])
+	}
+	if containers[1].Snapshots != 0 || containers[1].DiskUsage != 512 {
+		t.Errorf("unexpected container 1: %+v", containers[1])
+	}
+}
+
+func TestListAll_InvalidJSON(t *testing.T) {
+	mock := setupMock(t)
+	mock.SetOutput("list --format json", "not json")
+
+	if _, err := ListAll(); err == nil {
+		t.Fatal("expected error for invalid JSON")
+	}
+}
+
+func TestCache_ExistsAndStatus(t *testing.T) {
+	mock := setupMock(t)
+	EnableCache(true)
+	t.Cleanup(func() { EnableCache(false) })
+	mock.SetOutput("info dev1", "")
+	mock.SetOutput("list dev1 -cs -f csv", "RUNNING")
+
+	for i := 0; i < 3; i++ {
+		if !Exists("dev1") {
+			t.Fatal("expected dev1 to exist")
+		}
+		if status, _ := GetStatus("dev1"); status != "RUNNING" {
+			t.Fatalf("expected RUNNING, got %q", status)
+		}
+	}
+	if len(mock.Calls) != 2 {
+		t.Errorf("expected 2 lxc calls with caching, got %d", len(mock.Calls))
+	}
+
+	// Stopping drops the cached status
+	mock.SetOutput("list dev1 -cs -f csv", "STOPPED")
+	if err := Stop("dev1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status, _ := GetStatus("dev1"); status != "STOPPED" {
+		t.Errorf("expected STOPPED after stop, got %q", status)
+	}
+}
+
+func TestCache_ImageExistsInvalidatedByDelete(t *testing.T) {
+	mock := setupMock(t)
+	EnableCache(true)
+	t.Cleanup(func() { EnableCache(false) })
+	mock.SetOutput("image list myimg --format=csv -c f", "abc123")
+
+	if !ImageExists("myimg") || !ImageExists("myimg") {
+		t.Fatal("expected image to exist")
+	}
+	if len(mock.Calls) != 1 {
+		t.Errorf("expected 1 lxc call with caching, got %d", len(mock.Calls))
+	}
+
+	mock.SetOutput("image list myimg --format=csv -c f", "")
+	if err := DeleteImage("myimg"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ImageExists("myimg") {
+		t.Error("expected image lookup to be redone after delete")
+	}
+}
+
+// valueExecutor is an executor passed by value whose type can't be
+// compared, as SDK users may write one
+type valueExecutor struct {
+	outputs map[string]string
+}
+
+func (e valueExecutor) Run(args ...string) ([]byte, error) {
+	return []byte(e.outputs[strings.Join(args, " ")]), nil
+}
+
+func (e valueExecutor) RunCombined(args ...string) ([]byte, error) {
+	return e.Run(args...)
+}
+
+func TestCache_UncomparableExecutor(t *testing.T) {
+	SetExecutor(valueExecutor{outputs: map[string]string{
+		"exec dev1 -- sh -c " + userSwitchProbe: UserSwitchRunuser,
+	}})
+	t.Cleanup(ResetExecutor)
+	EnableCache(true)
+	t.Cleanup(func() { EnableCache(false) })
+
+	for i := 0; i < 2; i++ {
+		if sw := DetectUserSwitch("dev1", "dev"); sw.Method != UserSwitchRunuser {
+			t.Fatalf("expected runuser, got %+v", sw)
+		}
+		Exists("dev1")
+	}
+
+	// Swapping the executor drops what the previous one reported
+	mock := setupMock(t)
+	mock.SetError("info dev1", "not found")
+	if Exists("dev1") {
+		t.Error("expected the new executor to be asked")
+	}
+}
+
+func TestCache_DisabledByDefault(t *testing.T) {
+	mock := setupMock(t)
+	mock.SetOutput("info dev1", "")
+
+	Exists("dev1")
+	Exists("dev1")
+	if len(mock.Calls) != 2 {
+		t.Errorf("expected no caching by default, got %d calls", len(mock.Calls))
+	}
+}
+
+func TestLogCommand(t *testing.T) {
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	defer slog.SetDefault(prev)
+
+	logCommand([]string{"start", "dev1"}, time.Now(), errors.New("exit status 1"), []byte("Error: not found\n"))
+
+	out := buf.String()
+	for _, want := range []string{"level=DEBUG", `args="start dev1"`, `error="exit status 1"`, `output="Error: not found"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %s in log record, got %q", want, out)
+		}
+	}
+}
+
+func TestMonitor_DecodesLifecycleEvents(t *testing.T) {
+	mock := setupMock(t)
+	mock.SetOutput("monitor --type=lifecycle --format=json", `{"type":"lifecycle","timestamp":"2024-05-01T10:00:00Z","metadata":{"action":"instance-stopped","source":"/1.0/instances/webapp-db","requestor":{"username":"dev","protocol":"unix"}}}
+{"type":"logging","timestamp":"2024-05-01T10:00:01Z","metadata":{"message":"ignored"}}
+{"type":"lifecycle","timestamp":"2024-05-01T10:00:02Z","metadata":{"action":"instance-stopped","source":"/1.0/instances/webapp-dev?project=default","requestor":null}}
+{"type":"lifecycle","timestamp":"2024-05-01T10:00:03Z","metadata":{"action":"instance-snapshot-created","source":"/1.0/instances/webapp-dev/snapshots/snap0"}}
+`)
+
+	var events []LifecycleEvent
+	if err := Monitor(context.Background(), func(e LifecycleEvent) { events = append(events, e) }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("expected 3 lifecycle events, got %d: %+v", len(events), events)
+	}
+	if events[0].Instance != "webapp-db" || !events[0].Requested {
+		t.Errorf("expected a requested stop of webapp-db, got %+v", events[0])
+	}
+	if events[1].Instance != "webapp-dev" || events[1].Requested || events[1].Action != "instance-stopped" {
+		t.Errorf("expected an unrequested stop of webapp-dev, got %+v", events[1])
+	}
+	if events[2].Instance != "webapp-dev" {
+		t.Errorf("expected the snapshot event to name the instance, got %+v", events[2])
+	}
+}
+
+func TestMonitor_InvalidJSON(t *testing.T) {
+	mock := setupMock(t)
+	mock.SetOutput("monitor --type=lifecycle", "not json")
+
+	if err := Monitor(context.Background(), func(LifecycleEvent) {}); err == nil {
+		t.Error("expected an error for unparseable monitor output")
+	}
+}
+
+func TestDetectUserSwitch(t *testing.T) {
+	mock := setupMock(t)
+	t.Cleanup(InvalidateCache)
+	mock.SetOutput("exec dev1 -- sh -c if su", "su\n")
+	mock.SetOutput("exec alpine -- sh -c if su", "uid\n")
+	mock.SetOutput("exec alpine -- sh -c getent", "dev:x:1000:1001:Linux User,,,:/home/dev:/bin/ash\n")
+
+	if sw := DetectUserSwitch("dev1", "dev"); sw.Method != UserSwitchSu {
+		t.Errorf("expected su, got %+v", sw)
+	}
+
+	sw := DetectUserSwitch("alpine", "dev")
+	want := UserSwitch{Method: UserSwitchUID, UID: 1000, GID: 1001, Home: "/home/dev", Shell: "/bin/ash"}
+	if sw != want {
+		t.Errorf("expected %+v, got %+v", want, sw)
+	}
+
+	// The method is detected once per container
+	DetectUserSwitch("alpine", "dev")
+	probes := 0
+	for _, call := range mock.Calls {
+		if strings.HasPrefix(strings.Join(call.Args, " "), "exec alpine -- sh -c if su") {
+			probes++
+		}
+	}
+	if probes != 1 {
+		t.Errorf("expected 1 probe, got %d", probes)
+	}
+}
+
+func TestDetectUserSwitch_ProbeFails(t *testing.T) {
+	mock := setupMock(t)
+	t.Cleanup(InvalidateCache)
+	mock.SetError("exec dev1 -- sh -c if su", "Error: Instance is not running")
+
+	if sw := DetectUserSwitch("dev1", "dev"); sw.Method != UserSwitchSu {
+		t.Errorf("expected su when detection fails, got %+v", sw)
+	}
+	if _, ok := cache.getUserSwitch("dev1"); ok {
+		t.Error("a failed detection shouldn't be cached")
+	}
+}
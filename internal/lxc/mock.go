@@ -1,12 +1,17 @@
 package lxc
 
 import (
+	"context"
 	"errors"
+	"io"
 	"strings"
+	"sync"
 )
 
 // MockExecutor is a mock LXC executor for testing
 type MockExecutor struct {
+	mu sync.Mutex
+
 	// Calls records all calls made
 	Calls []MockCall
 
@@ -43,16 +48,42 @@ func NewMockExecutor() *MockExecutor {
 
 // Run implements Executor
 func (m *MockExecutor) Run(args ...string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.Calls = append(m.Calls, MockCall{Args: args})
 	return m.getResponse(args)
 }
 
 // RunCombined implements Executor
 func (m *MockExecutor) RunCombined(args ...string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.Calls = append(m.Calls, MockCall{Args: args})
 	return m.getResponse(args)
 }
 
+// RunPipe implements PipeExecutor, recording both commands as calls
+func (m *MockExecutor) RunPipe(src, dest []string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Calls = append(m.Calls, MockCall{Args: src}, MockCall{Args: dest})
+	if output, err := m.getResponse(src); err != nil {
+		return output, err
+	}
+	return m.getResponse(dest)
+}
+
+// RunStream implements StreamExecutor, writing the matching response's
+// output to w in one go
+func (m *MockExecutor) RunStream(ctx context.Context, w io.Writer, args ...string) error {
+	m.mu.Lock()
+	m.Calls = append(m.Calls, MockCall{Args: args})
+	output, err := m.getResponse(args)
+	m.mu.Unlock()
+	w.Write(output)
+	return err
+}
+
 func (m *MockExecutor) getResponse(args []string) ([]byte, error) {
 	key := strings.Join(args, " ")
 
@@ -73,12 +104,18 @@ func (m *MockExecutor) getResponse(args []string) ([]byte, error) {
 		return resp.Output, resp.Err
 	}
 
-	// Try prefix match
-	for pattern, resp := range m.Responses {
-		if strings.HasPrefix(key, pattern) {
-			return resp.Output, resp.Err
+	// Try prefix match, the longest pattern winning so that responses
+	// for "info c1/snap" and "info c1" don't depend on map order
+	best, found := "", false
+	for pattern := range m.Responses {
+		if strings.HasPrefix(key, pattern) && (!found || len(pattern) > len(best)) {
+			best, found = pattern, true
 		}
 	}
+	if found {
+		resp := m.Responses[best]
+		return resp.Output, resp.Err
+	}
 
 	// Return default
 	return m.DefaultResponse.Output, m.DefaultResponse.Err
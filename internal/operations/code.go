@@ -0,0 +1,177 @@
+package operations
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/dns"
+	"lxc-dev-manager/internal/dryrun"
+	"lxc-dev-manager/internal/errcode"
+	"lxc-dev-manager/internal/lxc"
+)
+
+// sshPublicKeys are the host keys offered to containers, in order of preference
+var sshPublicKeys = []string{"id_ed25519.pub", "id_ecdsa.pub", "id_rsa.pub"}
+
+// RemoteSSH describes how an editor reaches a container over SSH
+type RemoteSSH struct {
+	Host       string // Host alias written to the SSH config
+	HostName   string
+	User       string
+	Path       string // Directory to open: the project mount, else the user's home
+	ConfigPath string
+}
+
+// PrepareRemoteSSH makes a running container reachable over SSH with key
+// auth: sshd is installed and started, the host user's public key from
+// sshDir is authorized for the container user, and a Host entry named
+// after the container is written to (or refreshed in) sshDir/config.
+func PrepareRemoteSSH(cfg *config.Config, name, sshDir string) (*RemoteSSH, error) {
+	if !cfg.HasContainer(name) {
+		return nil, containerNotFound(name)
+	}
+
+	lxcName := cfg.GetLXCName(name)
+	if !lxc.Exists(lxcName) {
+		return nil, containerNotInLXC(name, lxcName)
+	}
+	status, err := lxc.GetStatus(lxcName)
+	if err != nil {
+		return nil, err
+	}
+	if status != "RUNNING" {
+		return nil, containerNotRunning(name)
+	}
+
+	keyPath, publicKey, err := readPublicKey(sshDir)
+	if err != nil {
+		return nil, err
+	}
+	host, err := sshHost(cfg, name)
+	if err != nil {
+		return nil, err
+	}
+
+	user := cfg.GetUser(name).Name
+	remote := &RemoteSSH{
+		Host:       lxcName,
+		HostName:   host,
+		User:       user,
+		Path:       cfg.Containers[name].Workdir,
+		ConfigPath: filepath.Join(sshDir, "config"),
+	}
+	if remote.Path == "" {
+		remote.Path = "/home/" + user
+		if user == "root" {
+			remote.Path = "/root"
+		}
+	}
+
+	if err := lxc.EnableSSH(lxcName); err != nil {
+		return nil, fmt.Errorf("failed to set up sshd: %w", err)
+	}
+	if err := lxc.AuthorizeKey(lxcName, user, publicKey); err != nil {
+		return nil, err
+	}
+
+	identity := strings.TrimSuffix(keyPath, ".pub")
+	entry := sshHostEntry(lxcName, host, user, identity)
+	if err := writeSSHConfigEntry(remote.ConfigPath, lxcName, entry); err != nil {
+		return nil, err
+	}
+	return remote, nil
+}
+
+// readPublicKey returns the first public key found in sshDir
+func readPublicKey(sshDir string) (string, string, error) {
+	for _, name := range sshPublicKeys {
+		path := filepath.Join(sshDir, name)
+		data, err := os.ReadFile(path)
+		if err == nil {
+			return path, strings.TrimSpace(string(data)), nil
+		}
+	}
+	return "", "", errcode.Errorf(errcode.Validation, "", "no SSH public key in %s (create one with ssh-keygen -t ed25519)", sshDir)
+}
+
+// sshHost returns the address editors connect to: the container's static
+// IP, its DNS name when dns is configured, or else its current IP, which
+// needs it running
+func sshHost(cfg *config.Config, name string) (string, error) {
+	if ip := cfg.Containers[name].IP; ip != "" {
+		return ip, nil
+	}
+	if cfg.DNS.Mode != "" {
+		return dns.Hostname(name, cfg.Project, cfg.DNS.Domain), nil
+	}
+
+	lxcName := cfg.GetLXCName(name)
+	if !lxc.Exists(lxcName) {
+		return "", containerNotInLXC(name, lxcName)
+	}
+	ip, err := lxc.GetIP(lxcName)
+	if err != nil || ip == "" {
+		return "", errcode.Errorf(errcode.NotRunning, name, "container '%s' has no IP address; start it or give it a static IP", name)
+	}
+	return ip, nil
+}
+
+// sshHostEntry renders an SSH config Host entry. Containers are recreated
+// often, so their host keys aren't checked.
+func sshHostEntry(alias, host, user, identity string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Host %s\n    HostName %s\n    User %s\n", alias, host, user)
+	if identity != "" {
+		fmt.Fprintf(&b, "    IdentityFile %s\n", identity)
+	}
+	b.WriteString("    StrictHostKeyChecking no\n    UserKnownHostsFile /dev/null\n")
+	return b.String()
+}
+
+// RenderSSHConfigEntry returns SSH config content with the managed entry
+// for alias replaced by entry, appended if it wasn't there yet
+func RenderSSHConfigEntry(content, alias, entry string) string {
+	begin, end := "# BEGIN lxc-dev-manager "+alias, "# END lxc-dev-manager "+alias
+
+	var kept []string
+	inBlock := false
+	for _, line := range strings.Split(content, "\n") {
+		switch {
+		case line == begin:
+			inBlock = true
+		case line == end && inBlock:
+			inBlock = false
+		case !inBlock:
+			kept = append(kept, line)
+		}
+	}
+
+	result := strings.TrimRight(strings.Join(kept, "\n"), "\n")
+	if result != "" {
+		result += "\n\n"
+	}
+	return result + begin + "\n" + entry + end + "\n"
+}
+
+func writeSSHConfigEntry(path, alias, entry string) error {
+	if dryrun.Enabled() {
+		dryrun.Printf("write Host %s to %s", alias, path)
+		return nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	updated := RenderSSHConfigEntry(string(content), alias, entry)
+	if err := os.WriteFile(path, []byte(updated), 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package operations
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderSSHConfigEntry(t *testing.T) {
+	entry := sshHostEntry("app-dev1", "10.0.0.5", "dev", "")
+
+	content := RenderSSHConfigEntry("Host github.com\n    User git\n", "app-dev1", entry)
+	expected := "Host github.com\n    User git\n\n# BEGIN lxc-dev-manager app-dev1\n" + entry + "# END lxc-dev-manager app-dev1\n"
+	if content != expected {
+		t.Errorf("unexpected content:\n%s", content)
+	}
+
+	// Refreshing replaces the entry and leaves others alone
+	other := RenderSSHConfigEntry(content, "app-dev2", sshHostEntry("app-dev2", "10.0.0.6", "dev", ""))
+	updated := RenderSSHConfigEntry(other, "app-dev1", sshHostEntry("app-dev1", "10.0.0.9", "dev", ""))
+	if updated == other {
+		t.Fatal("expected the entry to change")
+	}
+	refreshed := RenderSSHConfigEntry(updated, "app-dev1", entry)
+	if RenderSSHConfigEntry(refreshed, "app-dev1", entry) != refreshed {
+		t.Error("expected rendering to be idempotent")
+	}
+	for _, want := range []string{"HostName 10.0.0.5", "HostName 10.0.0.6", "Host github.com"} {
+		if !strings.Contains(refreshed, want) {
+			t.Errorf("expected %q in:\n%s", want, refreshed)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package operations
+
+import (
+	"fmt"
+	"strings"
+
+	"lxc-dev-manager/internal/compose"
+	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/errcode"
+	"lxc-dev-manager/internal/validation"
+)
+
+// DefaultComposeImage is launched for services whose image has no LXC
+// equivalent (node, postgres, ...)
+const DefaultComposeImage = "ubuntu:24.04"
+
+// ComposeImport is a container creation derived from a compose service
+type ComposeImport struct {
+	Name       string // Container name (the service name with '_' replaced)
+	Service    string
+	Image      string
+	ExactImage bool   // false when Image is a stand-in for the service's image
+	FromImage  string // The service's image or "build"
+	Opts       CreateContainerOpts
+	DependsOn  []string // Container names
+	Skipped    []string // Parts of the service that have no equivalent
+}
+
+// PlanComposeImport maps each compose service onto a container creation,
+// ordered so dependencies come first. Nothing is created. fallback is the
+// image used for services without a distro base image.
+func PlanComposeImport(cfg *config.Config, file *compose.File, fallback string) ([]*ComposeImport, error) {
+	if fallback == "" {
+		fallback = DefaultComposeImage
+	}
+
+	plans := make(map[string]*ComposeImport, len(file.Services))
+	for _, serviceName := range file.Names() {
+		service := file.Services[serviceName]
+		plan := &ComposeImport{Name: composeContainerName(serviceName), Service: serviceName}
+		invalidf := func(format string, args ...any) error {
+			return errcode.Errorf(errcode.Validation, "", "service '%s': %s", serviceName, fmt.Sprintf(format, args...))
+		}
+
+		if err := validation.ValidateContainerName(plan.Name); err != nil {
+			return nil, invalidf("%v", err)
+		}
+		if err := validation.ValidateFullContainerName(cfg.LXCProject(), plan.Name); err != nil {
+			return nil, invalidf("%v", err)
+		}
+		if cfg.HasContainer(plan.Name) {
+			return nil, invalidf("container '%s' already exists in config", plan.Name)
+		}
+
+		plan.FromImage = service.Image
+		if service.Image == "" {
+			if !service.HasBuild() {
+				return nil, invalidf("no image or build")
+			}
+			plan.FromImage = "build"
+			plan.Image = fallback
+		} else {
+			plan.Image, plan.ExactImage = compose.ClosestImage(service.Image, fallback)
+		}
+
+		ports, err := service.ContainerPorts()
+		if err != nil {
+			return nil, invalidf("%v", err)
+		}
+		plan.Opts.Ports = ports
+
+		binds, skipped, err := service.BindMounts(file.Dir)
+		if err != nil {
+			return nil, invalidf("%v", err)
+		}
+		plan.Skipped = skipped
+		for _, b := range binds {
+			mode := "rw"
+			if b.ReadOnly {
+				mode = "ro"
+			}
+			plan.Opts.Mounts = append(plan.Opts.Mounts, config.Mount{Source: b.Source, Path: b.Target, Mode: mode})
+		}
+
+		if plan.Opts.Env, err = service.Env(); err != nil {
+			return nil, invalidf("environment: %v", err)
+		}
+		if service.HasEnvFile() {
+			plan.Skipped = append(plan.Skipped, "env_file (copy the variables into environment)")
+		}
+
+		deps, err := service.Dependencies()
+		if err != nil {
+			return nil, invalidf("%v", err)
+		}
+		for _, dep := range deps {
+			if _, ok := file.Services[dep]; !ok {
+				return nil, invalidf("depends_on unknown service '%s'", dep)
+			}
+			plan.DependsOn = append(plan.DependsOn, composeContainerName(dep))
+		}
+
+		plans[plan.Name] = plan
+	}
+
+	// Order so every container is created after its dependencies
+	var ordered []*ComposeImport
+	state := map[string]int{} // 1 while visiting, 2 once ordered
+	var visit func(string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case 1:
+			return errcode.Errorf(errcode.Validation, "", "depends_on cycle involving service '%s'", plans[name].Service)
+		case 2:
+			return nil
+		}
+		state[name] = 1
+		for _, dep := range plans[name].DependsOn {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		state[name] = 2
+		ordered = append(ordered, plans[name])
+		return nil
+	}
+	for _, serviceName := range file.Names() {
+		if err := visit(composeContainerName(serviceName)); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+// composeContainerName turns a service name into a container name
+func composeContainerName(service string) string {
+	return strings.ReplaceAll(service, "_", "-")
+}
+
+// ImportCompose creates the planned containers in order and records their
+// depends_on. It stops at the first failure; the containers created
+// before it are kept.
+func ImportCompose(cfg *config.Config, plans []*ComposeImport) error {
+	for _, plan := range plans {
+		if err := CreateContainer(cfg, plan.Name, plan.Image, plan.Opts); err != nil {
+			return fmt.Errorf("service '%s': %w", plan.Service, err)
+		}
+		if len(plan.DependsOn) > 0 {
+			cfg.SetContainerDependsOn(plan.Name, plan.DependsOn)
+			if err := cfg.Save(); err != nil {
+				return fmt.Errorf("failed to save config: %w", err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package operations
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"lxc-dev-manager/internal/compose"
+	"lxc-dev-manager/internal/config"
+)
+
+func loadCompose(t *testing.T, content string) *compose.File {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "compose.yml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := compose.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestPlanComposeImport(t *testing.T) {
+	cfg := &config.Config{Project: "webapp", Containers: map[string]config.Container{}}
+	file := loadCompose(t, `services:
+  web:
+    build: .
+    volumes: ["./src:/app/src:ro", "cache:/cache"]
+    env_file: .env
+    depends_on: [api_server]
+  api_server:
+    image: alpine:3.19
+    depends_on: [db]
+  db:
+    image: postgres:16
+`)
+
+	plans, err := PlanComposeImport(cfg, file, "")
+	if err != nil {
+		t.Fatalf("PlanComposeImport failed: %v", err)
+	}
+
+	var names []string
+	for _, plan := range plans {
+		names = append(names, plan.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"db", "api-server", "web"}) {
+		t.Fatalf("expected dependencies first, got %v", names)
+	}
+
+	db, api, web := plans[0], plans[1], plans[2]
+	if db.Image != DefaultComposeImage || db.ExactImage {
+		t.Errorf("expected postgres to fall back, got %s (exact %v)", db.Image, db.ExactImage)
+	}
+	if api.Image != "images:alpine/3.19" || !api.ExactImage || !reflect.DeepEqual(api.DependsOn, []string{"db"}) {
+		t.Errorf("unexpected api-server plan: %+v", api)
+	}
+	if web.FromImage != "build" || !reflect.DeepEqual(web.DependsOn, []string{"api-server"}) {
+		t.Errorf("unexpected web plan: %+v", web)
+	}
+	expectedMounts := []config.Mount{{Source: filepath.Join(file.Dir, "src"), Path: "/app/src", Mode: "ro"}}
+	if !reflect.DeepEqual(web.Opts.Mounts, expectedMounts) {
+		t.Errorf("unexpected web mounts: %+v", web.Opts.Mounts)
+	}
+	if len(web.Skipped) != 2 {
+		t.Errorf("expected the named volume and env_file to be skipped, got %v", web.Skipped)
+	}
+}
+
+func TestPlanComposeImport_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		compose string
+		wantErr string
+	}{
+		{"cycle", "services:\n  a:\n    image: ubuntu\n    depends_on: [b]\n  b:\n    image: ubuntu\n    depends_on: [a]\n", "cycle"},
+		{"unknown dependency", "services:\n  a:\n    image: ubuntu\n    depends_on: [b]\n", "unknown service 'b'"},
+		{"no image", "services:\n  a:\n    ports: [80]\n", "no image or build"},
+		{"existing container", "services:\n  dev1:\n    image: ubuntu\n", "already exists"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Project: "webapp", Containers: map[string]config.Container{"dev1": {Image: "ubuntu:24.04"}}}
+			_, err := PlanComposeImport(cfg, loadCompose(t, tt.compose), "")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
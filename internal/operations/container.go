@@ -1,29 +1,238 @@
 package operations
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+	"unicode"
 
 	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/errcode"
 	"lxc-dev-manager/internal/lxc"
+	"lxc-dev-manager/internal/tracing"
 	"lxc-dev-manager/internal/validation"
 )
 
-// CreateContainer creates a new container
-func CreateContainer(cfg *config.Config, name, image string, opts CreateContainerOpts) error {
+// CreateContainer creates a new container. An empty image uses the
+// project's defaults.image.
+func CreateContainer(cfg *config.Config, name, image string, opts CreateContainerOpts) (err error) {
+	defer InvalidateInventory(cfg)
+	span := tracing.Start("operations.CreateContainer", "container", name)
+	defer span.EndErr(&err)
+
+	image, err = DefaultImage(cfg, image)
+	if err != nil {
+		return err
+	}
+	image, err = archImage(image, opts.Arch)
+	if err != nil {
+		return err
+	}
+	plan, err := planCreate(cfg, name, image, opts)
+	if err != nil {
+		return err
+	}
+	plan.span = span
+	if err := pullImage(cfg, image, plan.progress); err != nil {
+		return err
+	}
+	if err := checkImageArch(image, opts.Arch); err != nil {
+		return err
+	}
+	err = plan.run(cfg, &sync.Mutex{})
+	plan.endPhase(err)
+	return err
+}
+
+// CreateContainers creates several containers from the same image, running
+// up to workers creations at once. Every name is validated before anything
+// is launched; after that each container succeeds or fails on its own and
+// the failures are returned keyed by name.
+func CreateContainers(cfg *config.Config, names []string, image string, opts CreateContainerOpts, workers int) (failed map[string]error, err error) {
+	defer InvalidateInventory(cfg)
+	span := tracing.Start("operations.CreateContainers", "containers", len(names), "workers", workers)
+	defer span.EndErr(&err)
+
+	if opts.IP != "" && len(names) > 1 {
+		return nil, fmt.Errorf("a static IP can only be given when creating a single container")
+	}
+
+	image, err = DefaultImage(cfg, image)
+	if err != nil {
+		return nil, err
+	}
+	image, err = archImage(image, opts.Arch)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(names))
+	plans := make([]*createPlan, 0, len(names))
+	for _, name := range names {
+		if seen[name] {
+			return nil, fmt.Errorf("container '%s' is listed more than once", name)
+		}
+		seen[name] = true
+
+		plan, err := planCreate(cfg, name, image, opts)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		plan.span = span
+		plans = append(plans, plan)
+	}
+
+	// Pull once up front rather than racing a pull per container
+	err = pullImage(cfg, image, func(step string) {
+		for _, plan := range plans {
+			plan.progress(step)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := checkImageArch(image, opts.Arch); err != nil {
+		return nil, err
+	}
+
+	if workers < 1 {
+		workers = 1
+	}
+
+	// Config reads and writes go through mu; only LXC work runs in parallel
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	failed = make(map[string]error)
+	sem := make(chan struct{}, workers)
+	for _, plan := range plans {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(plan *createPlan) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			err := plan.run(cfg, &mu)
+			plan.endPhase(err)
+			if err != nil {
+				plan.progress("failed")
+				mu.Lock()
+				failed[plan.name] = err
+				mu.Unlock()
+				return
+			}
+			plan.progress("done")
+		}(plan)
+	}
+	wg.Wait()
+
+	return failed, nil
+}
+
+// DefaultImage returns image, falling back to the project's defaults.image
+func DefaultImage(cfg *config.Config, image string) (string, error) {
+	if image == "" {
+		image = cfg.Defaults.Image
+	}
+	if image == "" {
+		return "", errcode.Errorf(errcode.Usage, "", "no image given and defaults.image is not set in %s", config.ConfigFile)
+	}
+	return image, nil
+}
+
+// archImage selects the arch variant of a remote image: image servers
+// publish each alias per architecture as <alias>/<arch>. Local images are
+// left alone; checkImageArch verifies them.
+func archImage(image, arch string) (string, error) {
+	if arch == "" {
+		return image, nil
+	}
+	name, err := validation.ParseArchitecture(arch)
+	if err != nil {
+		return "", err
+	}
+	if !strings.Contains(image, ":") {
+		return image, nil
+	}
+	return image + "/" + validation.ImageServerArchitecture(name), nil
+}
+
+// checkImageArch fails when a local image isn't built for the requested
+// architecture, and warns when the image to launch isn't the host's native
+// architecture: LXD runs it only if the host supports it (e.g. i686 on
+// x86_64), otherwise the launch fails.
+func checkImageArch(image, arch string) error {
+	want := ""
+	if arch != "" {
+		var err error
+		if want, err = validation.ParseArchitecture(arch); err != nil {
+			return err
+		}
+	}
+
+	got := want
+	if !strings.Contains(image, ":") {
+		info, err := GetImageInfo(image)
+		if err != nil {
+			// Not a local alias or fingerprint prefix LXD would find; let the launch report it
+			return nil
+		}
+		got = info.Architecture
+		if want != "" && got != want {
+			return errcode.Errorf(errcode.Validation, "", "image '%s' is %s, not %s", image, got, want)
+		}
+	}
+	if got == "" {
+		return nil
+	}
+
+	host, err := lxc.HostArchitectures()
+	if err != nil || len(host) == 0 {
+		slog.Debug("skipping image architecture check", "error", err)
+		return nil
+	}
+	if got != host[0] {
+		slog.Warn("image architecture doesn't match the host", "image", image, "architecture", got,
+			"host", host[0], "supported", strings.Join(host, ","))
+	}
+	return nil
+}
+
+// createPlan is a validated container creation. Everything that needs the
+// config is resolved up front so the slow LXC steps can run without it.
+type createPlan struct {
+	name     string
+	lxcName  string
+	image    string
+	opts     CreateContainerOpts
+	user     config.User
+	dotfiles *config.Dotfiles
+	nesting  bool          // Whether nesting ended up enabled
+	span     *tracing.Span // Parent of the progress step spans
+	phase    *tracing.Span // Span of the current progress step
+	finished []string      // Provisioning steps done so far
+}
+
+// planCreate validates a creation request against the config and LXC
+func planCreate(cfg *config.Config, name, image string, opts CreateContainerOpts) (*createPlan, error) {
 	// Validate container name
 	if err := validation.ValidateContainerName(name); err != nil {
-		return fmt.Errorf("invalid container name: %w", err)
+		return nil, fmt.Errorf("invalid container name: %w", err)
 	}
 
 	// Validate combined name (project + container)
-	if err := validation.ValidateFullContainerName(cfg.Project, name); err != nil {
-		return err
+	if err := validation.ValidateFullContainerName(cfg.LXCProject(), name); err != nil {
+		return nil, err
 	}
 
 	// Check if already exists in config
 	if cfg.HasContainer(name) {
-		return fmt.Errorf("container '%s' already exists in config", name)
+		return nil, kindErrorf(ErrContainerExists, "container '%s' already exists in config", name)
 	}
 
 	// Get full LXC name with prefix
@@ -31,22 +240,35 @@ func CreateContainer(cfg *config.Config, name, image string, opts CreateContaine
 
 	// Check if already exists in LXC
 	if lxc.Exists(lxcName) {
-		return fmt.Errorf("container '%s' already exists in LXC", lxcName)
+		return nil, kindErrorf(ErrContainerExists, "container '%s' already exists in LXC", lxcName)
 	}
 
-	// Launch container
-	if err := lxc.Launch(lxcName, image); err != nil {
-		return err
+	// Check the definition as loading it back will
+	if err := config.ValidateContainer(config.Container{
+		Ports:   opts.Ports,
+		IP:      opts.IP,
+		User:    config.User{Name: opts.User},
+		Workdir: opts.Workdir,
+		Disk:    opts.Disk,
+		Env:     opts.Env,
+	}); err != nil {
+		return nil, err
+	}
+	if opts.NoUser && (opts.User != "" || opts.Password != "") {
+		return nil, errcode.Errorf(errcode.Validation, name, "a user can't be given when skipping user setup")
 	}
 
-	// Enable nesting for Docker support
-	if err := lxc.EnableNesting(lxcName); err != nil {
-		// Non-fatal, container created but nesting not enabled
+	for _, m := range opts.Mounts {
+		if err := validation.ValidateContainerPath(m.Path); err != nil {
+			return nil, fmt.Errorf("invalid mount '%s': %w", m.Path, err)
+		}
 	}
 
-	// Wait for container to be ready
-	if err := lxc.WaitForReady(lxcName, 60*time.Second); err != nil {
-		return err
+	// Check the static IP isn't already taken by another container
+	if opts.IP != "" {
+		if other, taken := cfg.FindContainerByIP(opts.IP); taken {
+			return nil, fmt.Errorf("IP %s is already assigned to container '%s'", opts.IP, other)
+		}
 	}
 
 	// Get user config
@@ -57,41 +279,590 @@ func CreateContainer(cfg *config.Config, name, image string, opts CreateContaine
 	if opts.Password != "" {
 		user.Password = opts.Password
 	}
+	dotfiles := cfg.GetDotfiles(name)
+	if opts.NoUser {
+		user = config.User{Name: "root"}
+		if dotfiles != nil {
+			slog.Warn("skipping dotfiles, there's no user to install them for", "container", name)
+			dotfiles = nil
+		}
+	}
+
+	if opts.Nesting == nil {
+		opts.Nesting = cfg.Defaults.Nesting
+	}
+
+	if opts.Privileged {
+		if err := checkPrivilegedCreate(cfg, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	// Project setup commands run before the ones given for this container
+	if len(cfg.Defaults.Setup) > 0 {
+		opts.Setup = append(append([]string(nil), cfg.Defaults.Setup...), opts.Setup...)
+	}
+
+	return &createPlan{
+		name:     name,
+		lxcName:  lxcName,
+		image:    image,
+		opts:     opts,
+		user:     user,
+		dotfiles: dotfiles,
+	}, nil
+}
+
+// checkPrivilegedCreate refuses, before anything is launched, the mounts a
+// privileged container would reject once created
+func checkPrivilegedCreate(cfg *config.Config, opts CreateContainerOpts) error {
+	if opts.Workdir != "" || cfg.Defaults.Workdir != "" {
+		return kindErrorf(ErrPrivilegedMount, "privileged containers can't mount the project directory read-write; drop --mount-project or defaults.workdir")
+	}
+	mounts := append(append([]config.Mount(nil), cfg.Defaults.Mounts...), opts.Mounts...)
+	for _, m := range mounts {
+		source := cfg.ExpandMountSource(m.Source)
+		if err := checkPrivilegedMount(source, m.Mode == "rw"); err != nil {
+			return fmt.Errorf("mount '%s' -> '%s': %w", m.Source, m.Path, err)
+		}
+	}
+	return nil
+}
+
+func (p *createPlan) progress(step string) {
+	slog.Debug("create", "container", p.name, "step", step)
+	p.endPhase(nil)
+	if step != "done" && step != "failed" {
+		p.phase = tracing.StartChild(p.span, "create: "+step, "container", p.name)
+	}
+	if p.opts.Progress != nil {
+		p.opts.Progress(p.name, step)
+	}
+}
+
+// endPhase ends the span of the last progress step
+func (p *createPlan) endPhase(err error) {
+	p.phase.End(err)
+	p.phase = nil
+}
+
+// run performs the creation. mu guards cfg and is only held around config
+// reads and writes, never around LXC calls. If setup fails before the
+// container is registered and its mounts applied, the container is deleted
+// again (unless opts.NoRollback) so the name can be reused; failures after
+// that leave a usable container and are reported as such.
+func (p *createPlan) run(cfg *config.Config, mu sync.Locker) (err error) {
+	lxcName := p.lxcName
+
+	if err := p.hook(cfg, mu, "pre_create"); err != nil {
+		return err
+	}
+
+	launched, created := false, false
+	defer func() {
+		if err != nil && launched && !created {
+			p.rollback(cfg, mu)
+		}
+	}()
+
+	// Launch container (static IPs must be applied before first start)
+	p.progress("launching")
+	launched = true
+	if p.opts.IP != "" || p.opts.Privileged {
+		if err := launchConfigured(lxcName, p.image, p.opts); err != nil {
+			return err
+		}
+	} else if err := lxc.Launch(lxcName, p.image); err != nil {
+		return err
+	}
+
+	// Limit the root disk before setup fills it
+	if p.opts.Disk != "" {
+		if err := applyDiskSize(lxcName, p.opts.Disk); err != nil {
+			return err
+		}
+	}
+
+	// Enable nesting for Docker support
+	if p.opts.Nesting == nil || *p.opts.Nesting {
+		if err := lxc.EnableNesting(lxcName); err != nil {
+			if p.opts.Nesting != nil {
+				return fmt.Errorf("failed to enable nesting: %w", err)
+			}
+			slog.Warn("failed to enable nesting, Docker won't run in this container; set nesting: false to skip it", "container", p.name, "error", err)
+		} else {
+			p.nesting = true
+		}
+		// Recorded as nesting: false when it fell back, so nothing is pending
+		p.finish(SetupNesting)
+	}
+
+	// Wait for container to be ready
+	p.progress("waiting for boot")
+	if err := lxc.WaitForReady(lxcName, 60*time.Second); err != nil {
+		return err
+	}
+
+	// Point apt at the cache before anything installs packages
+	if cfg.Defaults.AptProxy != "" {
+		if err := lxc.SetAptProxy(lxcName, cfg.Defaults.AptProxy); err != nil {
+			slog.Warn("failed to set apt proxy", "container", p.name, "proxy", cfg.Defaults.AptProxy, "error", err)
+		}
+	}
 
 	// Set up user
-	if err := lxc.SetupUser(lxcName, user.Name, user.Password); err != nil {
-		return fmt.Errorf("failed to set up user: %w", err)
+	if !p.opts.NoUser {
+		p.progress("setting up user")
+		if err := lxc.SetupUser(lxcName, p.user.Name, p.user.Password); err != nil {
+			return fmt.Errorf("failed to set up user: %w", err)
+		}
+		if err := applyUserEnvironment(lxcName, p.user); err != nil {
+			return err
+		}
+		p.finish(SetupUser)
+	}
+	if err := exportEnv(lxcName, p.opts.Env); err != nil {
+		return err
 	}
 
 	// Enable SSH
-	if err := lxc.EnableSSH(lxcName); err != nil {
-		return fmt.Errorf("failed to enable SSH: %w", err)
+	if !p.opts.NoSSH {
+		p.progress("enabling SSH")
+		if err := lxc.EnableSSH(lxcName); err != nil {
+			return fmt.Errorf("failed to enable SSH: %w", err)
+		}
+		p.finish(SetupSSH)
+	}
+
+	p.progress("applying mounts")
+	if err := p.register(cfg, mu); err != nil {
+		return err
+	}
+	created = true
+
+	// Written once everything it lists is in place
+	if cfg.Defaults.Motd {
+		mu.Lock()
+		text := motd(cfg, p.name)
+		mu.Unlock()
+		if err := lxc.SetMotd(lxcName, text); err != nil {
+			slog.Warn("failed to write motd", "container", p.name, "error", err)
+		}
+	}
+
+	// Dotfiles go into the initial snapshot too
+	if p.dotfiles != nil {
+		p.progress("installing dotfiles")
+		if err := lxc.ApplyDotfiles(lxcName, p.user.Name, p.dotfiles.Repo, p.dotfiles.Install); err != nil {
+			return fmt.Errorf("container created, but %w", err)
+		}
+	}
+
+	if len(p.opts.Setup) > 0 {
+		p.progress("running setup")
+		if err := runUserCommands(lxcName, p.user.Name, p.opts.Setup, "setup"); err != nil {
+			return fmt.Errorf("container created, but %w", err)
+		}
+	}
+
+	// Create initial snapshot for reset
+	if !p.opts.NoSnapshot {
+		p.progress("creating snapshot")
+		if err := lxc.Snapshot(lxcName, "initial-state"); err == nil {
+			p.finish(SetupSnapshot)
+			mu.Lock()
+			cfg.AddSnapshot(p.name, "initial-state", "Initial state after setup")
+			cfg.SetContainerPendingSetup(p.name, p.pendingSetup())
+			err := cfg.Save()
+			mu.Unlock()
+			if err != nil {
+				return fmt.Errorf("container created, but failed to save config: %w", err)
+			}
+		} else {
+			slog.Warn("failed to create initial snapshot", "container", p.name, "error", err)
+		}
+	}
+
+	if p.opts.NoStart {
+		mu.Lock()
+		timeout := stopTimeout(cfg, p.name)
+		mu.Unlock()
+		if err := lxc.StopWithTimeout(lxcName, timeout, false); err != nil {
+			return fmt.Errorf("container created, but %w", err)
+		}
 	}
 
+	if err := p.hook(cfg, mu, "post_create"); err != nil {
+		return fmt.Errorf("container created, but %w", err)
+	}
+
+	slog.Info("container created", "container", p.name, "lxc_name", lxcName, "image", p.image)
+	return nil
+}
+
+// rollback deletes a container whose setup failed, and its config entry
+// if it was registered, or leaves it in place with opts.NoRollback
+func (p *createPlan) rollback(cfg *config.Config, mu sync.Locker) {
+	if p.opts.NoRollback {
+		p.keepFailed(cfg, mu)
+		return
+	}
+
+	p.progress("rolling back")
+	// A failed launch may not have created anything to delete
+	if err := lxc.Delete(p.lxcName); err != nil && lxc.Exists(p.lxcName) {
+		slog.Warn("failed to delete the failed container", "container", p.name, "lxc_name", p.lxcName, "error", err)
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if cfg.HasContainer(p.name) {
+		cfg.RemoveContainer(p.name)
+		if err := cfg.Save(); err != nil {
+			slog.Warn("failed to remove the failed container from the config", "container", p.name, "error", err)
+		}
+	}
+}
+
+// keepFailed leaves a container whose setup failed in place, adding it to
+// the config with the steps it still needs so container repair can finish
+// them
+func (p *createPlan) keepFailed(cfg *config.Config, mu sync.Locker) {
+	if !lxc.Exists(p.lxcName) {
+		return
+	}
+	slog.Warn("leaving the failed container in place; run container repair to finish its setup", "container", p.name, "lxc_name", p.lxcName)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !cfg.HasContainer(p.name) {
+		cfg.AddContainer(p.name, p.image)
+		if p.opts.User != "" || p.opts.Password != "" || p.opts.NoUser {
+			cfg.SetContainerUser(p.name, config.User{Name: p.user.Name, Password: p.user.Password})
+		}
+		if !p.nesting && !slices.Contains(p.pendingSetup(), SetupNesting) {
+			cfg.SetContainerNesting(p.name, false)
+		}
+	}
+	cfg.SetContainerPendingSetup(p.name, p.pendingSetup())
+	if err := cfg.Save(); err != nil {
+		slog.Warn("failed to add the failed container to the config", "container", p.name, "error", err)
+	}
+}
+
+// finish records that a provisioning step is done
+func (p *createPlan) finish(step string) {
+	p.finished = append(p.finished, step)
+}
+
+// pendingSetup returns the provisioning steps asked for that haven't
+// finished, in the order create runs them
+func (p *createPlan) pendingSetup() []string {
+	wanted := []struct {
+		step string
+		on   bool
+	}{
+		{SetupNesting, p.opts.Nesting == nil || *p.opts.Nesting},
+		{SetupUser, !p.opts.NoUser},
+		{SetupSSH, !p.opts.NoSSH},
+		{SetupSnapshot, !p.opts.NoSnapshot},
+	}
+	var pending []string
+	for _, w := range wanted {
+		if w.on && !slices.Contains(p.finished, w.step) {
+			pending = append(pending, w.step)
+		}
+	}
+	return pending
+}
+
+// register adds the container to the config, applies its mounts and syncs
+// the project's default sync entries. The mounts are applied to a detached
+// copy of the container's entry, so mu is only held to add the container
+// and to merge the copy back.
+func (p *createPlan) register(cfg *config.Config, mu sync.Locker) error {
+	mu.Lock()
 	// Add to config with short name
-	cfg.AddContainer(name, image)
+	cfg.AddContainer(p.name, p.image)
+	if p.opts.IP != "" {
+		cfg.SetContainerIP(p.name, p.opts.IP)
+	}
+	if p.opts.Disk != "" {
+		cfg.SetContainerDisk(p.name, p.opts.Disk)
+	}
+	if p.opts.Privileged {
+		cfg.SetContainerPrivileged(p.name, true)
+	}
+	if !p.nesting {
+		cfg.SetContainerNesting(p.name, false)
+	}
+	if len(p.opts.Ports) > 0 {
+		cfg.SetContainerPorts(p.name, p.opts.Ports)
+	}
+	if len(p.opts.Env) > 0 {
+		cfg.SetContainerEnv(p.name, p.opts.Env)
+	}
+	if p.opts.User != "" || p.opts.Password != "" || p.opts.NoUser {
+		// Keep ssh/exec using the account that was actually created
+		cfg.SetContainerUser(p.name, config.User{Name: p.user.Name, Password: p.user.Password})
+	}
+	for _, entry := range cfg.Defaults.Sync {
+		cfg.AddSyncEntry(p.name, entry)
+	}
+	cfg.SetContainerPendingSetup(p.name, p.pendingSetup())
 	if err := cfg.Save(); err != nil {
+		mu.Unlock()
 		return fmt.Errorf("failed to save config: %w", err)
 	}
+	own, err := cfg.Detach(p.name)
+	mu.Unlock()
+	if err != nil {
+		return err
+	}
 
-	// Create initial snapshot for reset
-	if err := lxc.Snapshot(lxcName, "initial-state"); err == nil {
-		cfg.AddSnapshot(name, "initial-state", "Initial state after setup")
-		cfg.Save()
+	// Mounts applied before a failure are merged too, for rollback or
+	// container repair
+	err = p.applyMounts(own)
+	mu.Lock()
+	cfg.SetContainer(p.name, own.Containers[p.name])
+	saveErr := cfg.Save()
+	mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if saveErr != nil {
+		return fmt.Errorf("failed to save config: %w", saveErr)
+	}
+
+	// Missing sync sources shouldn't fail the create; 'sync' can retry them
+	for _, entry := range own.Defaults.Sync {
+		if err := syncEntry(own, p.name, own.ProjectDir(), entry); err != nil {
+			slog.Warn("failed to sync file", "container", p.name, "source", entry.Source, "error", err)
+		}
+	}
+	return nil
+}
+
+// applyMounts applies the project-wide mounts and the mounts asked for at
+// create, before the initial snapshot so resets keep them
+func (p *createPlan) applyMounts(cfg *config.Config) error {
+	workdir := p.opts.Workdir
+	if workdir == "" {
+		workdir = cfg.Defaults.Workdir
+	}
+	if workdir != "" {
+		if err := MountProject(cfg, p.name, workdir); err != nil {
+			return fmt.Errorf("mounting the project directory failed: %w", err)
+		}
+	}
+	if cfg.Defaults.ShellHistory != "" {
+		if err := MountHistory(cfg, p.name, cfg.ExpandMountSource(cfg.Defaults.ShellHistory)); err != nil {
+			return fmt.Errorf("mounting the shell history directory failed: %w", err)
+		}
+	}
+	if err := applyDefaultMounts(cfg, p.name); err != nil {
+		return err
+	}
+	return applyMounts(cfg, p.name, p.opts.Mounts)
+}
+
+// hook runs a create hook with a detached copy of cfg, so mu isn't held
+// while it waits for an IP or runs its command
+func (p *createPlan) hook(cfg *config.Config, mu sync.Locker, event string) error {
+	mu.Lock()
+	own, err := cfg.Detach(p.name)
+	mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return runHook(own, event, p.name, p.image)
+}
+
+// ProjectMountName is the device name used for the project directory mount
+const ProjectMountName = "project"
+
+// MountProject mounts the directory containing containers.yaml read-write
+// (with shifting) at workdir and exports it as $WORKDIR for the user
+func MountProject(cfg *config.Config, name, workdir string) error {
+	if _, err := Mount(cfg, name, cfg.ProjectDir(), workdir, MountOpts{
+		Name:           ProjectMountName,
+		ReadWrite:      true,
+		Shift:          true,
+		AllowRiskyPath: true, // the user chose to keep the project here
+	}); err != nil {
+		return err
+	}
+
+	if err := lxc.ExportEnv(cfg.GetLXCName(name), "WORKDIR", workdir); err != nil {
+		return fmt.Errorf("failed to export WORKDIR: %w", err)
+	}
+
+	cfg.SetContainerWorkdir(name, workdir)
+	return cfg.Save()
+}
+
+// HistoryMountName is the device name used for the shell_history mount
+const HistoryMountName = "history"
+
+// MountHistory mounts dir read-write (with shifting) at lxc.HistoryDir,
+// creating it on the host first, and points the container's login shells
+// at a history file in it, so history outlives the container
+func MountHistory(cfg *config.Config, name, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	if _, err := Mount(cfg, name, dir, lxc.HistoryDir, MountOpts{
+		Name:           HistoryMountName,
+		ReadWrite:      true,
+		Shift:          true,
+		AllowRiskyPath: true, // the user chose to keep history here
+	}); err != nil {
+		return err
+	}
+	return lxc.SetHistoryFile(cfg.GetLXCName(name), name)
+}
+
+// motd is the login banner written to new containers with defaults.motd
+func motd(cfg *config.Config, name string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "lxc-dev-manager: container '%s'", name)
+	if cfg.Project != "" {
+		fmt.Fprintf(&b, " in project '%s'", cfg.Project)
+	}
+	b.WriteString("\n")
+	if container, ok := cfg.Containers[name]; ok {
+		fmt.Fprintf(&b, "  Image:   %s\n", container.Image)
+		if container.Workdir != "" {
+			fmt.Fprintf(&b, "  Workdir: %s\n", container.Workdir)
+		}
+	}
+	if ports := cfg.GetPorts(name); len(ports) > 0 {
+		list := make([]string, len(ports))
+		for i, port := range ports {
+			list[i] = strconv.Itoa(port)
+		}
+		fmt.Fprintf(&b, "  Ports:   %s (forward with: lxc-dev-manager proxy %s)\n", strings.Join(list, ", "), name)
+	}
+	if entries := cfg.GetSyncEntries(name); len(entries) > 0 {
+		dests := make([]string, len(entries))
+		for i, entry := range entries {
+			dests[i] = entry.Dest
+		}
+		fmt.Fprintf(&b, "  Synced:  %s\n", strings.Join(dests, ", "))
+	}
+	return b.String()
+}
+
+// applyDefaultMounts mounts every defaults.mounts entry into a new container
+func applyDefaultMounts(cfg *config.Config, name string) error {
+	for _, m := range cfg.Defaults.Mounts {
+		source := cfg.ExpandMountSource(m.Source)
+		if _, err := Mount(cfg, name, source, m.Path, MountOpts{
+			ReadWrite: m.Mode == "rw",
+			Shift:     m.Shift,
+		}); err != nil {
+			return fmt.Errorf("default mount '%s' -> '%s' failed: %w", source, m.Path, err)
+		}
+	}
+	return nil
+}
+
+// applyMounts mounts the extra mounts requested at create time
+func applyMounts(cfg *config.Config, name string, mounts []config.Mount) error {
+	for _, m := range mounts {
+		if _, err := Mount(cfg, name, m.Source, m.Path, MountOpts{
+			ReadWrite: m.Mode == "rw",
+			Shift:     m.Shift,
+		}); err != nil {
+			return fmt.Errorf("mount '%s' -> '%s' failed: %w", m.Source, m.Path, err)
+		}
+	}
+	return nil
+}
+
+// ParseMountSpec parses a "source:path[:ro|:rw]" mount spec. Mounts are
+// read-write unless ":ro" is given.
+func ParseMountSpec(spec string) (config.Mount, error) {
+	parts := strings.Split(spec, ":")
+	m := config.Mount{Mode: "rw"}
+	switch len(parts) {
+	case 3:
+		if parts[2] != "ro" && parts[2] != "rw" {
+			return m, fmt.Errorf("invalid mount %q: mode must be ro or rw", spec)
+		}
+		m.Mode = parts[2]
+		fallthrough
+	case 2:
+		m.Source, m.Path = parts[0], parts[1]
+	default:
+		return m, fmt.Errorf("invalid mount %q: expected source:path[:ro]", spec)
+	}
+	if m.Source == "" || m.Path == "" {
+		return m, fmt.Errorf("invalid mount %q: expected source:path[:ro]", spec)
+	}
+	return m, nil
+}
+
+// launchConfigured creates the container stopped, applies the settings
+// that must be in place before the first start (a static IP, checked
+// against the bridge subnet, and security.privileged), then starts it.
+// A failure leaves the container to run's rollback.
+func launchConfigured(lxcName, image string, opts CreateContainerOpts) error {
+	if err := lxc.Init(lxcName, image); err != nil {
+		return err
+	}
+
+	if opts.IP != "" {
+		if err := applyStaticIP(lxcName, opts.IP); err != nil {
+			return err
+		}
+	}
+
+	if opts.Privileged {
+		if err := lxc.ConfigSet(lxcName, "security.privileged", "true"); err != nil {
+			return err
+		}
+	}
+
+	if err := lxc.Start(lxcName); err != nil {
+		return err
 	}
 
 	return nil
 }
 
+// applyStaticIP validates ip against the container's network and sets it on eth0
+func applyStaticIP(lxcName, ip string) error {
+	network, err := lxc.GetNetwork(lxcName)
+	if err != nil {
+		return fmt.Errorf("failed to determine container network: %w", err)
+	}
+
+	subnet, err := lxc.GetNetworkSubnet(network)
+	if err != nil {
+		return err
+	}
+
+	if err := validation.ValidateIPInSubnet(ip, subnet); err != nil {
+		return fmt.Errorf("invalid static IP for network '%s': %w", network, err)
+	}
+
+	return lxc.SetStaticIP(lxcName, ip)
+}
+
 // Start starts a stopped container
-func Start(cfg *config.Config, name string) error {
+func Start(cfg *config.Config, name string) (err error) {
+	defer InvalidateInventory(cfg)
+	defer tracing.Start("operations.Start", "container", name).EndErr(&err)
+
 	if !cfg.HasContainer(name) {
-		return fmt.Errorf("container '%s' not found in config", name)
+		return containerNotFound(name)
 	}
 
 	lxcName := cfg.GetLXCName(name)
 	if !lxc.Exists(lxcName) {
-		return fmt.Errorf("container '%s' does not exist in LXC", lxcName)
+		return containerNotInLXC(name, lxcName)
 	}
 
 	status, err := lxc.GetStatus(lxcName)
@@ -103,18 +874,39 @@ func Start(cfg *config.Config, name string) error {
 		return nil // Already running
 	}
 
-	return lxc.Start(lxcName)
+	if err := runHook(cfg, "pre_start", name, ""); err != nil {
+		return err
+	}
+	if err := lxc.Start(lxcName); err != nil {
+		return err
+	}
+	slog.Info("container started", "container", name, "lxc_name", lxcName)
+	if err := runOnStart(cfg, name); err != nil {
+		return fmt.Errorf("container started, but %w", err)
+	}
+	if err := runHook(cfg, "post_start", name, ""); err != nil {
+		return fmt.Errorf("container started, but %w", err)
+	}
+	if cfg.AutoProxy(name) {
+		if _, err := StartPortForwards(cfg, name); err != nil {
+			return fmt.Errorf("container started, but %w", err)
+		}
+	}
+	return nil
 }
 
 // Stop stops a running container
-func Stop(cfg *config.Config, name string) error {
+func Stop(cfg *config.Config, name string, opts StopOpts) (err error) {
+	defer InvalidateInventory(cfg)
+	defer tracing.Start("operations.Stop", "container", name).EndErr(&err)
+
 	if !cfg.HasContainer(name) {
-		return fmt.Errorf("container '%s' not found in config", name)
+		return containerNotFound(name)
 	}
 
 	lxcName := cfg.GetLXCName(name)
 	if !lxc.Exists(lxcName) {
-		return fmt.Errorf("container '%s' does not exist in LXC", lxcName)
+		return containerNotInLXC(name, lxcName)
 	}
 
 	status, err := lxc.GetStatus(lxcName)
@@ -126,18 +918,45 @@ func Stop(cfg *config.Config, name string) error {
 		return nil // Already stopped
 	}
 
-	return lxc.Stop(lxcName)
+	if err := runHook(cfg, "pre_stop", name, ""); err != nil {
+		return err
+	}
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = stopTimeout(cfg, name)
+	}
+	if err := lxc.StopWithTimeout(lxcName, timeout, opts.Force); err != nil {
+		return err
+	}
+	slog.Info("container stopped", "container", name, "lxc_name", lxcName)
+	if cfg.AutoProxy(name) {
+		if err := StopPortForwards(cfg, name); err != nil {
+			return fmt.Errorf("container stopped, but %w", err)
+		}
+	}
+	if err := runHook(cfg, "post_stop", name, ""); err != nil {
+		return fmt.Errorf("container stopped, but %w", err)
+	}
+	return nil
+}
+
+// stopTimeout returns how long a container gets to shut down cleanly
+func stopTimeout(cfg *config.Config, name string) time.Duration {
+	return cfg.GetStopTimeout(name, lxc.DefaultStopTimeout)
 }
 
 // Remove removes a container
-func Remove(cfg *config.Config, name string, force bool) error {
+func Remove(cfg *config.Config, name string, force bool) (err error) {
+	defer InvalidateInventory(cfg)
+	defer tracing.Start("operations.Remove", "container", name).EndErr(&err)
+
 	lxcName := cfg.GetLXCName(name)
 
 	existsInLXC := lxc.Exists(lxcName)
 	existsInConfig := cfg.HasContainer(name)
 
 	if !existsInLXC && !existsInConfig {
-		return fmt.Errorf("container '%s' not found", name)
+		return kindErrorf(ErrContainerNotFound, "container '%s' not found", name)
 	}
 
 	// Delete from LXC if exists
@@ -155,18 +974,29 @@ func Remove(cfg *config.Config, name string, force bool) error {
 		}
 	}
 
+	slog.Info("container removed", "container", name, "lxc_name", lxcName, "in_lxc", existsInLXC, "in_config", existsInConfig)
 	return nil
 }
 
-// Reset resets a container to a snapshot
-func Reset(cfg *config.Config, name, snapshotName string) error {
+// PreResetPrefix starts the names of the snapshots taken before a reset
+const PreResetPrefix = "pre-reset-"
+
+// Reset resets a container to a snapshot, then brings its devices back in
+// line with containers.yaml. With opts.SnapshotFirst or
+// reset.auto_snapshot the current state is first kept as a timestamped
+// pre-reset snapshot, whose name is returned, also when the reset fails
+// after it.
+func Reset(cfg *config.Config, name, snapshotName string, opts ResetOpts) (preReset string, err error) {
+	defer InvalidateInventory(cfg)
+	defer tracing.Start("operations.Reset", "container", name, "snapshot", snapshotName).EndErr(&err)
+
 	if !cfg.HasContainer(name) {
-		return fmt.Errorf("container '%s' not found in config", name)
+		return "", containerNotFound(name)
 	}
 
 	lxcName := cfg.GetLXCName(name)
 	if !lxc.Exists(lxcName) {
-		return fmt.Errorf("container '%s' does not exist in LXC", lxcName)
+		return "", containerNotInLXC(name, lxcName)
 	}
 
 	if snapshotName == "" {
@@ -176,185 +1006,435 @@ func Reset(cfg *config.Config, name, snapshotName string) error {
 	// Check if snapshot exists
 	if !lxc.SnapshotExists(lxcName, snapshotName) {
 		if snapshotName == "initial-state" {
-			return fmt.Errorf("container '%s' has no initial-state snapshot (created before this feature was added)", name)
+			return "", kindErrorf(ErrSnapshotNotFound, "container '%s' has no initial-state snapshot (created before this feature was added, or with --no-snapshot)", name)
+		}
+		return "", kindErrorf(ErrSnapshotNotFound, "snapshot '%s' does not exist", snapshotName)
+	}
+
+	// Keep the work the reset would throw away
+	if opts.SnapshotFirst || cfg.Reset.AutoSnapshot {
+		preReset = PreResetPrefix + time.Now().Format("20060102-150405")
+		description := fmt.Sprintf("Taken before reset to '%s'", snapshotName)
+		if err := CreateSnapshot(cfg, name, preReset, description); err != nil {
+			return "", fmt.Errorf("failed to take pre-reset snapshot: %w", err)
 		}
-		return fmt.Errorf("snapshot '%s' does not exist", snapshotName)
 	}
 
 	// Check if running
 	status, err := lxc.GetStatus(lxcName)
 	if err != nil {
-		return err
+		return preReset, err
 	}
 	wasRunning := status == "RUNNING"
 
-	// Stop if running
+	// Stop if running. Its state is about to be replaced, so it's killed
+	// if it doesn't shut down in time.
 	if wasRunning {
-		if err := lxc.Stop(lxcName); err != nil {
-			return err
+		if err := lxc.StopWithTimeout(lxcName, stopTimeout(cfg, name), true); err != nil {
+			return preReset, err
 		}
 	}
 
 	// Restore from snapshot
 	if err := lxc.Restore(lxcName, snapshotName); err != nil {
-		return err
+		return preReset, err
 	}
 
+	// The snapshot brought back the devices it was taken with
+	devicesErr := ReconcileDevices(cfg, name, opts.PruneDevices)
+
 	// Restart if was running
 	if wasRunning {
 		if err := lxc.Start(lxcName); err != nil {
-			return err
+			return preReset, err
+		}
+	}
+	if devicesErr != nil {
+		return preReset, fmt.Errorf("container reset, but %w", devicesErr)
+	}
+
+	if opts.SyncFiles {
+		if wasRunning {
+			if err := SyncFiles(cfg, name, cfg.ProjectDir()); err != nil {
+				return preReset, fmt.Errorf("container reset, but %w", err)
+			}
+		} else {
+			slog.Warn("container is stopped, sync entries not copied", "container", name)
 		}
 	}
 
-	return nil
+	slog.Info("container reset", "container", name, "snapshot", snapshotName, "pre_reset", preReset)
+	return preReset, nil
 }
 
 // Clone clones a container
-func Clone(cfg *config.Config, sourceName, newName string, opts CloneOpts) error {
+func Clone(cfg *config.Config, sourceName, newName string, opts CloneOpts) (err error) {
+	defer InvalidateInventory(cfg)
+	defer tracing.Start("operations.Clone", "source", sourceName, "container", newName).EndErr(&err)
+
+	plan, err := planClone(cfg, sourceName, newName, opts)
+	if err != nil {
+		return err
+	}
+	return plan.run(cfg, &sync.Mutex{})
+}
+
+// CloneContainers clones one container into several new ones, copying up to
+// workers at once. Like CreateContainers, every name is validated first and
+// per-container failures are returned keyed by name.
+func CloneContainers(cfg *config.Config, sourceName string, newNames []string, opts CloneOpts, workers int) (map[string]error, error) {
+	defer InvalidateInventory(cfg)
+
+	seen := make(map[string]bool, len(newNames))
+	plans := make([]*clonePlan, 0, len(newNames))
+	for _, name := range newNames {
+		if seen[name] {
+			return nil, fmt.Errorf("container '%s' is listed more than once", name)
+		}
+		seen[name] = true
+
+		plan, err := planClone(cfg, sourceName, name, opts)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		plans = append(plans, plan)
+	}
+
+	if workers < 1 {
+		workers = 1
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	failed := make(map[string]error)
+	sem := make(chan struct{}, workers)
+	for _, plan := range plans {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(plan *clonePlan) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := plan.run(cfg, &mu); err != nil {
+				plan.progress("failed")
+				mu.Lock()
+				failed[plan.newName] = err
+				mu.Unlock()
+				return
+			}
+			plan.progress("done")
+		}(plan)
+	}
+	wg.Wait()
+
+	return failed, nil
+}
+
+// clonePlan is a validated clone with the source definition resolved up front
+type clonePlan struct {
+	sourceName string
+	sourceLXC  string
+	newName    string
+	newLXC     string
+	source     config.Container
+	opts       CloneOpts
+}
+
+// planClone validates a clone request against the config and LXC
+func planClone(cfg *config.Config, sourceName, newName string, opts CloneOpts) (*clonePlan, error) {
 	// Validate new container name
 	if err := validation.ValidateContainerName(newName); err != nil {
-		return fmt.Errorf("invalid container name: %w", err)
+		return nil, fmt.Errorf("invalid container name: %w", err)
 	}
 
-	if err := validation.ValidateFullContainerName(cfg.Project, newName); err != nil {
-		return err
+	if err := validation.ValidateFullContainerName(cfg.LXCProject(), newName); err != nil {
+		return nil, err
 	}
 
 	// Check source exists
 	if !cfg.HasContainer(sourceName) {
-		return fmt.Errorf("source container '%s' not found in config", sourceName)
+		return nil, errcode.New(errcode.NotFound, sourceName, kindErrorf(ErrContainerNotFound, "source container '%s' not found in config", sourceName))
 	}
 
 	sourceLXC := cfg.GetLXCName(sourceName)
 	if !lxc.Exists(sourceLXC) {
-		return fmt.Errorf("source container '%s' does not exist in LXC", sourceLXC)
+		return nil, errcode.New(errcode.NotFound, sourceName, kindErrorf(ErrContainerNotFound, "source container '%s' does not exist in LXC", sourceLXC))
 	}
 
 	// Check if new name already exists
 	if cfg.HasContainer(newName) {
-		return fmt.Errorf("container '%s' already exists in config", newName)
+		return nil, kindErrorf(ErrContainerExists, "container '%s' already exists in config", newName)
 	}
 
 	newLXC := cfg.GetLXCName(newName)
 	if lxc.Exists(newLXC) {
-		return fmt.Errorf("container '%s' already exists in LXC", newLXC)
+		return nil, kindErrorf(ErrContainerExists, "container '%s' already exists in LXC", newLXC)
 	}
 
 	// If cloning from snapshot, verify it exists
 	if opts.FromSnapshot != "" {
 		if !lxc.SnapshotExists(sourceLXC, opts.FromSnapshot) {
-			return fmt.Errorf("snapshot '%s' does not exist on container '%s'", opts.FromSnapshot, sourceName)
+			return nil, kindErrorf(ErrSnapshotNotFound, "snapshot '%s' does not exist on container '%s'", opts.FromSnapshot, sourceName)
 		}
 	}
 
+	// The clone gets the same devices, so their host sources must still be valid
+	source := cfg.Containers[sourceName]
+	if err := validateCloneDevices(cfg, source.Devices); err != nil {
+		return nil, err
+	}
+
+	return &clonePlan{
+		sourceName: sourceName,
+		sourceLXC:  sourceLXC,
+		newName:    newName,
+		newLXC:     newLXC,
+		source:     source,
+		opts:       opts,
+	}, nil
+}
+
+func (p *clonePlan) progress(step string) {
+	slog.Debug("clone", "container", p.newName, "source", p.sourceName, "step", step)
+	if p.opts.Progress != nil {
+		p.opts.Progress(p.newName, step)
+	}
+}
+
+// run performs the clone. mu guards cfg and is only held around config writes.
+func (p *clonePlan) run(cfg *config.Config, mu sync.Locker) error {
+	newLXC := p.newLXC
+
 	// Perform the clone
-	if opts.FromSnapshot != "" {
-		if err := lxc.CopySnapshot(sourceLXC, opts.FromSnapshot, newLXC); err != nil {
+	p.progress("copying")
+	if p.opts.FromSnapshot != "" {
+		if err := lxc.CopySnapshot(p.sourceLXC, p.opts.FromSnapshot, newLXC); err != nil {
 			return err
 		}
 	} else {
-		if err := lxc.Copy(sourceLXC, newLXC); err != nil {
+		if err := lxc.Copy(p.sourceLXC, newLXC); err != nil {
 			return err
 		}
 	}
 
-	// Get source container config to copy image info
-	sourceImage := "cloned"
-	if sourceContainer, ok := cfg.Containers[sourceName]; ok {
-		sourceImage = sourceContainer.Image
+	// A static IP can't be shared, so the clone falls back to DHCP
+	if p.source.IP != "" {
+		if err := lxc.ClearStaticIP(newLXC); err != nil {
+			lxc.Delete(newLXC)
+			return err
+		}
 	}
 
-	// Add to config
-	cfg.AddContainer(newName, sourceImage+":cloned-from-"+sourceName)
-	if err := cfg.Save(); err != nil {
+	// Add to config with the source's definition
+	mu.Lock()
+	cfg.Containers[p.newName] = cloneContainer(p.source, p.sourceName)
+	err := cfg.Save()
+	mu.Unlock()
+	if err != nil {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
 
 	// Create initial snapshot for reset
+	p.progress("creating snapshot")
 	if err := lxc.Snapshot(newLXC, "initial-state"); err == nil {
-		cfg.AddSnapshot(newName, "initial-state", "Initial state after clone")
+		mu.Lock()
+		cfg.AddSnapshot(p.newName, "initial-state", "Initial state after clone")
 		cfg.Save()
+		mu.Unlock()
+	} else {
+		slog.Warn("failed to create initial snapshot", "container", p.newName, "error", err)
 	}
 
 	// Start the cloned container
-	lxc.Start(newLXC)
+	p.progress("starting")
+	if err := lxc.Start(newLXC); err != nil {
+		slog.Warn("failed to start clone", "container", p.newName, "error", err)
+	}
+
+	slog.Info("container cloned", "container", p.newName, "source", p.sourceName)
+	return nil
+}
+
+// cloneContainer copies a container definition for a clone. Snapshots and the
+// static IP belong to the source and are not copied.
+func cloneContainer(source config.Container, sourceName string) config.Container {
+	clone := source
+	clone.Image = source.Image + ":cloned-from-" + sourceName
+	clone.IP = ""
+	clone.Snapshots = nil
+	clone.Ports = append([]int(nil), source.Ports...)
+	clone.User.Groups = append([]string(nil), source.User.Groups...)
+	clone.Sync = append([]config.SyncEntry(nil), source.Sync...)
+	clone.IDMap = append([]string(nil), source.IDMap...)
+	clone.OnStart = append([]string(nil), source.OnStart...)
+	clone.DependsOn = append([]string(nil), source.DependsOn...)
+	if source.Env != nil {
+		clone.Env = make(map[string]string, len(source.Env))
+		for k, v := range source.Env {
+			clone.Env[k] = v
+		}
+	}
+	if source.Dotfiles != nil {
+		dotfiles := *source.Dotfiles
+		clone.Dotfiles = &dotfiles
+	}
+	if source.Devices != nil {
+		clone.Devices = make(map[string]config.Device, len(source.Devices))
+		for name, device := range source.Devices {
+			deviceConfig := make(map[string]string, len(device.Config))
+			for k, v := range device.Config {
+				deviceConfig[k] = v
+			}
+			clone.Devices[name] = config.Device{Type: device.Type, Config: deviceConfig}
+		}
+	}
+	return clone
+}
 
+// validateCloneDevices re-checks device definitions and disk sources so a
+// clone doesn't inherit mounts of host paths that are now blocked, denied
+// by policy or gone
+func validateCloneDevices(cfg *config.Config, devices map[string]config.Device) error {
+	policy, err := cfg.PathPolicy()
+	if err != nil {
+		return err
+	}
+	for name, device := range devices {
+		if err := config.ValidateDevice(name, device); err != nil {
+			return fmt.Errorf("device '%s': %w", name, err)
+		}
+		source := device.Config["source"]
+		if device.Type != validation.DeviceTypeDisk || source == "" || device.Config["pool"] != "" {
+			continue
+		}
+		resolved, warning, err := policy.ValidateSourcePath(source)
+		if err != nil {
+			return fmt.Errorf("device '%s': %w", name, err)
+		}
+		if err := CheckMountPolicy(cfg, resolved, device.Config["readonly"] != "true", warning != ""); err != nil {
+			return fmt.Errorf("device '%s': %w", name, err)
+		}
+	}
 	return nil
 }
 
+// SetDescription sets the free-form notes for a container. An empty
+// description clears them.
+func SetDescription(cfg *config.Config, name, description string) error {
+	if !cfg.HasContainer(name) {
+		return containerNotFound(name)
+	}
+
+	description = strings.TrimSpace(description)
+	if strings.IndexFunc(description, unicode.IsControl) >= 0 {
+		return fmt.Errorf("description must be a single line")
+	}
+
+	cfg.SetContainerDescription(name, description)
+	return cfg.Save()
+}
+
 // List returns all containers in the project
 func List(cfg *config.Config) ([]ContainerInfo, error) {
 	if len(cfg.Containers) == 0 {
 		return nil, nil
 	}
 
-	// Get all LXC container info
-	lxcContainers, err := lxc.ListAll()
+	lxcInfo, err := Inventory(cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	// Build lookup map
-	lxcInfo := make(map[string]lxc.ContainerInfo)
-	for _, c := range lxcContainers {
-		lxcInfo[c.Name] = c
-	}
-
 	var result []ContainerInfo
 	for name, container := range cfg.Containers {
 		lxcName := cfg.GetLXCName(name)
 
 		status := "NOT FOUND"
-		ip := ""
-
-		if info, ok := lxcInfo[lxcName]; ok {
+		info, ok := lxcInfo[lxcName]
+		if ok {
 			status = info.Status
-			ip = info.IP
 		}
 
-		ports := cfg.GetPorts(name)
+		mounts := 0
+		for _, device := range container.Devices {
+			if device.Type == validation.DeviceTypeDisk {
+				mounts++
+			}
+		}
 
 		result = append(result, ContainerInfo{
-			Name:   name,
-			Image:  container.Image,
-			Status: status,
-			IP:     ip,
-			Ports:  ports,
+			Name:        name,
+			Image:       container.Image,
+			Description: container.Description,
+			Status:      status,
+			IP:          info.IP,
+			IPv6:        info.IPv6,
+			Ports:       cfg.GetPorts(name),
+			Snapshots:   info.Snapshots,
+			Mounts:      mounts,
+			DiskUsage:   info.DiskUsage,
 		})
 	}
 
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
 	return result, nil
 }
 
 // Status returns the status of a container
 func Status(cfg *config.Config, name string) (string, error) {
 	if !cfg.HasContainer(name) {
-		return "", fmt.Errorf("container '%s' not found in config", name)
+		return "", containerNotFound(name)
 	}
 
 	lxcName := cfg.GetLXCName(name)
 	if !lxc.Exists(lxcName) {
-		return "", fmt.Errorf("container '%s' does not exist in LXC", lxcName)
+		return "", containerNotInLXC(name, lxcName)
 	}
 
 	return lxc.GetStatus(lxcName)
 }
 
+// Log returns a container's LXC log
+func Log(cfg *config.Config, name string) (string, error) {
+	if !cfg.HasContainer(name) {
+		return "", containerNotFound(name)
+	}
+
+	lxcName := cfg.GetLXCName(name)
+	if !lxc.Exists(lxcName) {
+		return "", containerNotInLXC(name, lxcName)
+	}
+
+	return lxc.ShowLog(lxcName)
+}
+
 // IP returns the IP address of a container
 func IP(cfg *config.Config, name string) (string, error) {
 	if !cfg.HasContainer(name) {
-		return "", fmt.Errorf("container '%s' not found in config", name)
+		return "", containerNotFound(name)
 	}
 
 	lxcName := cfg.GetLXCName(name)
 	if !lxc.Exists(lxcName) {
-		return "", fmt.Errorf("container '%s' does not exist in LXC", lxcName)
+		return "", containerNotInLXC(name, lxcName)
 	}
 
 	return lxc.GetIP(lxcName)
 }
 
+// IPv6 returns the global IPv6 address of a container
+func IPv6(cfg *config.Config, name string) (string, error) {
+	if !cfg.HasContainer(name) {
+		return "", containerNotFound(name)
+	}
+
+	lxcName := cfg.GetLXCName(name)
+	if !lxc.Exists(lxcName) {
+		return "", containerNotInLXC(name, lxcName)
+	}
+
+	return lxc.GetIPv6(lxcName)
+}
+
 // Exists checks if a container exists
 func Exists(cfg *config.Config, name string) bool {
 	if !cfg.HasContainer(name) {
@@ -365,16 +1445,130 @@ func Exists(cfg *config.Config, name string) bool {
 	return lxc.Exists(lxcName)
 }
 
-// WaitForReady waits for a container to be ready
+// WaitForReady waits for a container to be ready, as defined by its ready
+// conditions (default: cloud-init finished)
 func WaitForReady(cfg *config.Config, name string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return WaitFor(ctx, cfg, name)
+}
+
+// WaitFor waits until every readiness condition holds for a container, or
+// ctx is done. Without conditions, the container's ready conditions from
+// the config are used.
+func WaitFor(ctx context.Context, cfg *config.Config, name string, conditions ...string) error {
 	if !cfg.HasContainer(name) {
-		return fmt.Errorf("container '%s' not found in config", name)
+		return containerNotFound(name)
 	}
 
 	lxcName := cfg.GetLXCName(name)
 	if !lxc.Exists(lxcName) {
-		return fmt.Errorf("container '%s' does not exist in LXC", lxcName)
+		return containerNotInLXC(name, lxcName)
+	}
+
+	if len(conditions) == 0 {
+		conditions = cfg.GetReady(name)
+	}
+	if len(conditions) == 0 {
+		conditions = []string{validation.ReadyCloudInit}
 	}
+	return lxc.WaitFor(ctx, lxcName, conditions...)
+}
 
-	return lxc.WaitForReady(lxcName, timeout)
+// applyUserEnvironment sets the configured groups, login shell, timezone and locale
+func applyUserEnvironment(lxcName string, user config.User) error {
+	if len(user.Groups) > 0 {
+		if err := lxc.AddUserGroups(lxcName, user.Name, user.Groups); err != nil {
+			return err
+		}
+	}
+	if user.Shell != "" {
+		if err := lxc.SetShell(lxcName, user.Name, user.Shell); err != nil {
+			return err
+		}
+	}
+	if user.Timezone != "" {
+		if err := lxc.SetTimezone(lxcName, user.Timezone); err != nil {
+			return err
+		}
+	}
+	if user.Locale != "" {
+		if err := lxc.SetLocale(lxcName, user.Locale); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportEnv exports environment variables inside the container, in name order
+func exportEnv(lxcName string, env map[string]string) error {
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := lxc.ExportEnv(lxcName, key, env[key]); err != nil {
+			return fmt.Errorf("failed to export %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// Dependencies returns the containers name depends on, directly or through
+// other dependencies, in the order they should be started
+func Dependencies(cfg *config.Config, name string) ([]string, error) {
+	if !cfg.HasContainer(name) {
+		return nil, containerNotFound(name)
+	}
+
+	var order []string
+	state := map[string]int{} // 1 while visiting, 2 once ordered
+	var visit func(string, []string) error
+	visit = func(current string, path []string) error {
+		switch state[current] {
+		case 1:
+			return errcode.Errorf(errcode.Validation, name, "depends_on cycle: %s", strings.Join(append(path, current), " -> "))
+		case 2:
+			return nil
+		}
+		state[current] = 1
+		for _, dep := range cfg.Containers[current].DependsOn {
+			if err := visit(dep, append(path, current)); err != nil {
+				return err
+			}
+		}
+		state[current] = 2
+		if current != name {
+			order = append(order, current)
+		}
+		return nil
+	}
+	if err := visit(name, nil); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// runOnStart runs the configured on_start commands inside the container as
+// the user, from $WORKDIR when set. Stops at the first failing command.
+func runOnStart(cfg *config.Config, name string) error {
+	commands := cfg.GetOnStart(name)
+	if len(commands) == 0 {
+		return nil
+	}
+	return runUserCommands(cfg.GetLXCName(name), cfg.GetUser(name).Name, commands, "on_start")
+}
+
+// runUserCommands runs shell commands inside the container as user, from
+// $WORKDIR when set. Stops at the first failing command; kind names the
+// commands in the error.
+func runUserCommands(lxcName, user string, commands []string, kind string) error {
+	for _, command := range commands {
+		script := `cd "${WORKDIR:-$HOME}" && ` + command
+		if err := lxc.Exec(lxcName, "su", "-l", user, "-c", script); err != nil {
+			return fmt.Errorf("%s command %q failed: %w", kind, command, err)
+		}
+	}
+	return nil
 }
@@ -0,0 +1,81 @@
+package operations
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/lxc"
+)
+
+// trackedLocker records whether it is held
+type trackedLocker struct {
+	sync.Mutex
+	held bool
+}
+
+func (l *trackedLocker) Lock() {
+	l.Mutex.Lock()
+	l.held = true
+}
+
+func (l *trackedLocker) Unlock() {
+	l.held = false
+	l.Mutex.Unlock()
+}
+
+func TestCreatePlan_MountsRunWithoutLock(t *testing.T) {
+	mock := lxc.NewMockExecutor()
+	lxc.SetExecutor(mock)
+	t.Cleanup(lxc.ResetExecutor)
+	mock.DefaultResponse = lxc.MockResponse{}
+	mock.SetOutput("exec", "status: done")
+	mock.SetError("info test-dev1", "not found")
+	mock.SetCallback("launch", func(args []string) {
+		mock.SetOutput("info test-dev1", "Name: test-dev1")
+	})
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, config.ConfigFile), []byte("project: test\ncontainers: {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := t.TempDir()
+	plan, err := planCreate(cfg, "dev1", "ubuntu:24.04", CreateContainerOpts{
+		Mounts:     []config.Mount{{Source: src, Path: "/src", Mode: "ro"}},
+		NoUser:     true,
+		NoSSH:      true,
+		NoSnapshot: true,
+	})
+	if err != nil {
+		t.Fatalf("planCreate() error = %v", err)
+	}
+
+	mu := &trackedLocker{}
+	heldDuringMount := false
+	mock.SetCallback("config device add", func(args []string) {
+		heldDuringMount = heldDuringMount || mu.held
+	})
+	if err := plan.run(cfg, mu); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if !mock.HasCallPrefix("config", "device", "add", "test-dev1") {
+		t.Fatal("expected the mount to be added")
+	}
+	if heldDuringMount {
+		t.Error("the config lock should not be held while mounting")
+	}
+
+	saved, err := config.Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, found := saved.FindDeviceByPath("dev1", "/src"); !found {
+		t.Errorf("expected the mount to be merged back into the config, got %+v", saved.Containers["dev1"])
+	}
+}
@@ -0,0 +1,172 @@
+package operations
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/devcontainer"
+	"lxc-dev-manager/internal/errcode"
+)
+
+// DevcontainerSSHConfig is the SSH config written next to an exported
+// devcontainer.json
+const DevcontainerSSHConfig = "ssh_config"
+
+// ExportDevcontainer describes a container as a devcontainer.json spec and
+// an SSH config entry editors can attach through. The SSH host is the
+// container's static IP, its DNS name when dns is configured, or else its
+// current IP, which needs it running.
+func ExportDevcontainer(cfg *config.Config, name string) (*devcontainer.Spec, string, error) {
+	if !cfg.HasContainer(name) {
+		return nil, "", containerNotFound(name)
+	}
+
+	lxcName := cfg.GetLXCName(name)
+	container := cfg.Containers[name]
+	host, err := sshHost(cfg, name)
+	if err != nil {
+		return nil, "", err
+	}
+
+	user := cfg.GetUser(name).Name
+	spec := &devcontainer.Spec{
+		Name:            name,
+		Image:           container.Image,
+		RemoteUser:      user,
+		WorkspaceFolder: container.Workdir,
+		Customizations: map[string]any{
+			"lxc-dev-manager": map[string]any{
+				"container": name,
+				"sshHost":   lxcName,
+			},
+			"vscode": map[string]any{
+				"settings": map[string]any{
+					"remote.SSH.configFile": "${localWorkspaceFolder}/.devcontainer/" + DevcontainerSSHConfig,
+				},
+			},
+		},
+	}
+	for _, port := range cfg.GetPorts(name) {
+		spec.ForwardPorts = append(spec.ForwardPorts, port)
+	}
+	for _, m := range exportMounts(cfg, name) {
+		spec.Mounts = append(spec.Mounts, devcontainer.MountString(m))
+	}
+	if onStart := cfg.GetOnStart(name); len(onStart) > 0 {
+		spec.PostStartCommand = strings.Join(onStart, " && ")
+	}
+
+	sshConfig := "# Generated by lxc-dev-manager devcontainer export\n" + sshHostEntry(lxcName, host, user, "")
+	return spec, sshConfig, nil
+}
+
+// exportMounts returns the container's host directory mounts, with
+// sources inside the project written relative to the workspace. The
+// project mount itself is described by workspaceFolder.
+func exportMounts(cfg *config.Config, name string) []devcontainer.Mount {
+	devices := cfg.GetDevices(name)
+	deviceNames := make([]string, 0, len(devices))
+	for deviceName := range devices {
+		deviceNames = append(deviceNames, deviceName)
+	}
+	sort.Strings(deviceNames)
+
+	projectDir := cfg.ProjectDir()
+	var mounts []devcontainer.Mount
+	for _, deviceName := range deviceNames {
+		device := devices[deviceName]
+		source, path := device.Config["source"], device.Config["path"]
+		if device.Type != "disk" || deviceName == ProjectMountName || device.Config["pool"] != "" || source == "" || path == "" {
+			continue
+		}
+		if rel, err := filepath.Rel(projectDir, source); err == nil && !strings.HasPrefix(rel, "..") {
+			source = devcontainer.WorkspaceVar
+			if rel != "." {
+				source += "/" + filepath.ToSlash(rel)
+			}
+		}
+		mounts = append(mounts, devcontainer.Mount{
+			Source:   source,
+			Target:   path,
+			ReadOnly: device.Config["readonly"] == "true",
+		})
+	}
+	return mounts
+}
+
+// DevcontainerImport is a container creation derived from a devcontainer.json
+type DevcontainerImport struct {
+	Image   string
+	Opts    CreateContainerOpts
+	OnStart []string // From postStartCommand, recorded as the container's on_start
+	Skipped []string // Parts of the spec that have no equivalent
+}
+
+// PlanDevcontainerImport maps a spec onto creation options: forwardPorts
+// become ports, remoteUser the user, workspaceFolder the workdir, bind
+// mounts mounts, postCreateCommand the setup commands and postStartCommand
+// on_start. image overrides the spec's image, which must otherwise be
+// one LXC can launch.
+func PlanDevcontainerImport(cfg *config.Config, spec *devcontainer.Spec, image string) (*DevcontainerImport, error) {
+	plan := &DevcontainerImport{Image: image}
+	if plan.Image == "" {
+		if spec.Image == "" {
+			if spec.Build != nil {
+				return nil, errcode.New(errcode.Validation, "", fmt.Errorf("devcontainer builds from a Dockerfile; pass an LXC image with --image"))
+			}
+			return nil, errcode.New(errcode.Validation, "", fmt.Errorf("devcontainer has no image; pass one with --image"))
+		}
+		plan.Image = spec.Image
+	}
+
+	ports, err := spec.Ports()
+	if err != nil {
+		return nil, errcode.New(errcode.Validation, "", err)
+	}
+	plan.Opts.Ports = ports
+
+	if user := spec.User(); user != "" && user != "root" {
+		plan.Opts.User = user
+	}
+	plan.Opts.Workdir = spec.WorkspaceFolder
+
+	binds, skipped, err := spec.BindMounts()
+	if err != nil {
+		return nil, errcode.New(errcode.Validation, "", err)
+	}
+	plan.Skipped = skipped
+	for _, b := range binds {
+		mode := "rw"
+		if b.ReadOnly {
+			mode = "ro"
+		}
+		source := strings.ReplaceAll(b.Source, devcontainer.WorkspaceVar, cfg.ProjectDir())
+		plan.Opts.Mounts = append(plan.Opts.Mounts, config.Mount{Source: source, Path: b.Target, Mode: mode})
+	}
+
+	if plan.Opts.Setup, err = devcontainer.Commands(spec.PostCreateCommand); err != nil {
+		return nil, errcode.New(errcode.Validation, "", fmt.Errorf("invalid postCreateCommand: %w", err))
+	}
+	if plan.OnStart, err = devcontainer.Commands(spec.PostStartCommand); err != nil {
+		return nil, errcode.New(errcode.Validation, "", fmt.Errorf("invalid postStartCommand: %w", err))
+	}
+	if spec.Build != nil && image != "" {
+		plan.Skipped = append(plan.Skipped, "build (replaced by --image)")
+	}
+	return plan, nil
+}
+
+// ImportDevcontainer creates a container from a planned import
+func ImportDevcontainer(cfg *config.Config, name string, plan *DevcontainerImport) error {
+	if err := CreateContainer(cfg, name, plan.Image, plan.Opts); err != nil {
+		return err
+	}
+	if len(plan.OnStart) == 0 {
+		return nil
+	}
+	cfg.SetContainerOnStart(name, plan.OnStart)
+	return cfg.Save()
+}
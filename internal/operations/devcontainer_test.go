@@ -0,0 +1,117 @@
+package operations
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/devcontainer"
+)
+
+func TestExportDevcontainer(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		Dir:     dir,
+		Project: "webapp",
+		Containers: map[string]config.Container{
+			"dev1": {
+				Image:   "ubuntu:24.04",
+				IP:      "10.0.0.5",
+				Ports:   []int{3000},
+				Workdir: "/workspace",
+				User:    config.User{Name: "alice"},
+				OnStart: []string{"npm run dev"},
+				Devices: map[string]config.Device{
+					ProjectMountName: {Type: "disk", Config: map[string]string{"source": dir, "path": "/workspace"}},
+					"data":           {Type: "disk", Config: map[string]string{"source": dir + "/data", "path": "/data", "readonly": "true"}},
+					"cache":          {Type: "disk", Config: map[string]string{"source": "/var/cache/app", "path": "/cache"}},
+				},
+			},
+		},
+	}
+
+	spec, sshConfig, err := ExportDevcontainer(cfg, "dev1")
+	if err != nil {
+		t.Fatalf("ExportDevcontainer failed: %v", err)
+	}
+
+	if spec.Image != "ubuntu:24.04" || spec.RemoteUser != "alice" || spec.WorkspaceFolder != "/workspace" || spec.PostStartCommand != "npm run dev" {
+		t.Errorf("unexpected spec: %+v", spec)
+	}
+	if !reflect.DeepEqual(spec.ForwardPorts, []any{3000}) {
+		t.Errorf("unexpected ports: %v", spec.ForwardPorts)
+	}
+	expectedMounts := []any{
+		"source=/var/cache/app,target=/cache,type=bind",
+		"source=${localWorkspaceFolder}/data,target=/data,type=bind,readonly",
+	}
+	if !reflect.DeepEqual(spec.Mounts, expectedMounts) {
+		t.Errorf("unexpected mounts: %v", spec.Mounts)
+	}
+	if !strings.Contains(sshConfig, "Host webapp-dev1\n    HostName 10.0.0.5\n    User alice\n") {
+		t.Errorf("unexpected ssh config:\n%s", sshConfig)
+	}
+}
+
+func TestExportDevcontainer_NotFound(t *testing.T) {
+	cfg := &config.Config{Project: "webapp", Containers: map[string]config.Container{}}
+	if _, _, err := ExportDevcontainer(cfg, "dev1"); err == nil {
+		t.Error("expected error for unknown container")
+	}
+}
+
+func TestPlanDevcontainerImport(t *testing.T) {
+	cfg := &config.Config{Dir: "/home/alice/webapp", Project: "webapp"}
+	spec, err := devcontainer.Parse([]byte(`{
+		"image": "ubuntu:24.04",
+		"forwardPorts": [3000, "db:5432"],
+		"remoteUser": "alice",
+		"workspaceFolder": "/workspace",
+		"mounts": ["source=${localWorkspaceFolder}/data,target=/data,type=bind,readonly", "source=vol,target=/vol,type=volume"],
+		"postCreateCommand": "npm install",
+		"postStartCommand": ["npm", "run", "dev"]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := PlanDevcontainerImport(cfg, spec, "")
+	if err != nil {
+		t.Fatalf("PlanDevcontainerImport failed: %v", err)
+	}
+
+	if plan.Image != "ubuntu:24.04" || plan.Opts.User != "alice" || plan.Opts.Workdir != "/workspace" {
+		t.Errorf("unexpected plan: %+v", plan)
+	}
+	if !reflect.DeepEqual(plan.Opts.Ports, []int{3000, 5432}) {
+		t.Errorf("unexpected ports: %v", plan.Opts.Ports)
+	}
+	expectedMounts := []config.Mount{{Source: "/home/alice/webapp/data", Path: "/data", Mode: "ro"}}
+	if !reflect.DeepEqual(plan.Opts.Mounts, expectedMounts) {
+		t.Errorf("unexpected mounts: %+v", plan.Opts.Mounts)
+	}
+	if !reflect.DeepEqual(plan.Opts.Setup, []string{"npm install"}) || !reflect.DeepEqual(plan.OnStart, []string{"npm run dev"}) {
+		t.Errorf("unexpected commands: setup %q, on_start %q", plan.Opts.Setup, plan.OnStart)
+	}
+	if len(plan.Skipped) != 1 {
+		t.Errorf("expected the volume mount to be skipped, got %v", plan.Skipped)
+	}
+}
+
+func TestPlanDevcontainerImport_BuildNeedsImage(t *testing.T) {
+	cfg := &config.Config{Project: "webapp"}
+	spec := &devcontainer.Spec{Build: map[string]any{"dockerfile": "Dockerfile"}}
+
+	if _, err := PlanDevcontainerImport(cfg, spec, ""); err == nil || !strings.Contains(err.Error(), "--image") {
+		t.Errorf("expected --image error, got %v", err)
+	}
+
+	plan, err := PlanDevcontainerImport(cfg, spec, "ubuntu:24.04")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Image != "ubuntu:24.04" {
+		t.Errorf("expected --image to be used, got %q", plan.Image)
+	}
+}
@@ -0,0 +1,277 @@
+package operations
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"path"
+	"sort"
+
+	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/lxc"
+	"lxc-dev-manager/internal/validation"
+)
+
+// AddDevice passes a host device (usb or unix-char) through to a container
+func AddDevice(cfg *config.Config, containerName, deviceType string, opts DeviceOpts) (string, error) {
+	if !cfg.HasContainer(containerName) {
+		return "", containerNotFound(containerName)
+	}
+
+	lxcName := cfg.GetLXCName(containerName)
+	if !lxc.Exists(lxcName) {
+		return "", containerNotInLXC(containerName, lxcName)
+	}
+
+	// Build config map for the device type
+	deviceConfig := make(map[string]string)
+	switch deviceType {
+	case validation.DeviceTypeUSB:
+		deviceConfig["vendorid"] = opts.VendorID
+		if opts.ProductID != "" {
+			deviceConfig["productid"] = opts.ProductID
+		}
+	case validation.DeviceTypeUnixChar:
+		deviceConfig["source"] = opts.Source
+		if opts.Path != "" {
+			deviceConfig["path"] = opts.Path
+		}
+	default:
+		return "", fmt.Errorf("unsupported device type '%s' (allowed: %s, %s)", deviceType, validation.DeviceTypeUSB, validation.DeviceTypeUnixChar)
+	}
+	if opts.Mode != "" {
+		deviceConfig["mode"] = opts.Mode
+	}
+	if opts.UID != "" {
+		deviceConfig["uid"] = opts.UID
+	}
+	if opts.GID != "" {
+		deviceConfig["gid"] = opts.GID
+	}
+
+	device := config.Device{Type: deviceType, Config: deviceConfig}
+	if err := config.ValidateDevice("", device); err != nil {
+		return "", fmt.Errorf("invalid %s device: %w", deviceType, err)
+	}
+
+	// Generate device name if not provided
+	deviceName := opts.Name
+	if deviceName == "" {
+		deviceName = generateDeviceName(deviceType, opts)
+	}
+
+	if err := validation.ValidateMountName(deviceName); err != nil {
+		return "", fmt.Errorf("invalid device name: %w", err)
+	}
+
+	if cfg.HasDevice(containerName, deviceName) {
+		return "", fmt.Errorf("device '%s' already exists on container '%s'", deviceName, containerName)
+	}
+
+	if err := lxc.DeviceAdd(lxcName, deviceName, deviceType, deviceConfig); err != nil {
+		return "", fmt.Errorf("failed to add device to container: %w", err)
+	}
+
+	cfg.AddDevice(containerName, deviceName, device)
+	if err := cfg.Save(); err != nil {
+		// Try to rollback LXC device if config save fails
+		lxc.DeviceRemove(lxcName, deviceName)
+		return "", fmt.Errorf("failed to save config: %w", err)
+	}
+
+	return deviceName, nil
+}
+
+// RemoveDevice removes a passthrough device from a container
+func RemoveDevice(cfg *config.Config, containerName, deviceName string) error {
+	if !cfg.HasContainer(containerName) {
+		return containerNotFound(containerName)
+	}
+
+	lxcName := cfg.GetLXCName(containerName)
+	if !lxc.Exists(lxcName) {
+		return containerNotInLXC(containerName, lxcName)
+	}
+
+	device, ok := cfg.GetDevices(containerName)[deviceName]
+	if !ok {
+		return fmt.Errorf("device '%s' not found in container '%s'", deviceName, containerName)
+	}
+	if device.Type == validation.DeviceTypeDisk {
+		return fmt.Errorf("device '%s' is a mount; use unmount instead", deviceName)
+	}
+
+	if err := lxc.DeviceRemove(lxcName, deviceName); err != nil {
+		return fmt.Errorf("failed to remove device from LXC: %w", err)
+	}
+
+	cfg.RemoveDevice(containerName, deviceName)
+	if err := cfg.Save(); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	return nil
+}
+
+// ListDevices lists all passthrough (non-disk) devices for a container
+func ListDevices(cfg *config.Config, containerName string) ([]DeviceInfo, error) {
+	if !cfg.HasContainer(containerName) {
+		return nil, containerNotFound(containerName)
+	}
+
+	lxcName := cfg.GetLXCName(containerName)
+	if !lxc.Exists(lxcName) {
+		return nil, containerNotInLXC(containerName, lxcName)
+	}
+
+	lxcDevices, err := lxc.DeviceList(lxcName)
+	if err != nil {
+		return nil, err
+	}
+
+	lxcPassthrough := make(map[string]lxc.DeviceInfo)
+	for _, dev := range lxcDevices {
+		if isPassthroughType(dev.Type) {
+			lxcPassthrough[dev.Name] = dev
+		}
+	}
+
+	var devices []DeviceInfo
+	seen := make(map[string]bool)
+
+	for name, device := range cfg.GetDevices(containerName) {
+		if !isPassthroughType(device.Type) {
+			continue
+		}
+		seen[name] = true
+
+		status := "missing"
+		if _, ok := lxcPassthrough[name]; ok {
+			status = "ok"
+		}
+		devices = append(devices, DeviceInfo{
+			Name:   name,
+			Type:   device.Type,
+			Config: device.Config,
+			Status: status,
+		})
+	}
+
+	for name, dev := range lxcPassthrough {
+		if seen[name] {
+			continue
+		}
+		devices = append(devices, DeviceInfo{
+			Name:   name,
+			Type:   dev.Type,
+			Config: dev.Config,
+			Status: "untracked",
+		})
+	}
+
+	sort.Slice(devices, func(i, j int) bool {
+		return devices[i].Name < devices[j].Name
+	})
+
+	return devices, nil
+}
+
+func isPassthroughType(deviceType string) bool {
+	return deviceType == validation.DeviceTypeUSB || deviceType == validation.DeviceTypeUnixChar
+}
+
+// generateDeviceName builds a default name like "usb-0403-6001" or "char-ttyUSB0"
+func generateDeviceName(deviceType string, opts DeviceOpts) string {
+	if deviceType == validation.DeviceTypeUSB {
+		name := "usb-" + opts.VendorID
+		if opts.ProductID != "" {
+			name += "-" + opts.ProductID
+		}
+		return name
+	}
+	return validation.GenerateMountName("char-" + path.Base(opts.Source))
+}
+
+// ReconcileDevices makes a container's LXC devices match containers.yaml,
+// as reset needs after restoring a snapshot taken before the devices
+// changed. Like SyncMounts, configured devices that are missing are added
+// again, and so are ones that differ; each is checked against the path and
+// mount policy first, as clone does, so editing containers.yaml can't get a
+// blocked host path mounted. Devices of the types this tool manages that
+// the config doesn't list are only removed with prune, and otherwise left
+// attached. Profile overrides such as root and eth0 are left alone, and
+// raw.idmap is set back for mounts that need it.
+func ReconcileDevices(cfg *config.Config, containerName string, prune bool) error {
+	if !cfg.HasContainer(containerName) {
+		return containerNotFound(containerName)
+	}
+
+	lxcName := cfg.GetLXCName(containerName)
+	if !lxc.Exists(lxcName) {
+		return containerNotInLXC(containerName, lxcName)
+	}
+
+	lxcDevices, err := lxc.DeviceList(lxcName)
+	if err != nil {
+		return err
+	}
+	current := make(map[string]lxc.DeviceInfo, len(lxcDevices))
+	for _, dev := range lxcDevices {
+		current[dev.Name] = dev
+	}
+	configured := cfg.GetDevices(containerName)
+
+	for name, dev := range current {
+		if _, ok := configured[name]; ok || !isManagedType(dev.Type) || validation.IsReservedMountName(name) {
+			continue
+		}
+		if !prune {
+			slog.Warn("device not in config left attached", "container", containerName, "device", name)
+			continue
+		}
+		if err := lxc.DeviceRemove(lxcName, name); err != nil {
+			return fmt.Errorf("failed to remove device '%s': %w", name, err)
+		}
+		slog.Info("removed device not in config", "container", containerName, "device", name)
+	}
+
+	names := make([]string, 0, len(configured))
+	for name := range configured {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var errs []error
+	for _, name := range names {
+		device := configured[name]
+		dev, attached := current[name]
+		if attached && dev.Type == device.Type && maps.Equal(dev.Config, device.Config) {
+			continue
+		}
+		if err := validateCloneDevices(cfg, map[string]config.Device{name: device}); err != nil {
+			errs = append(errs, fmt.Errorf("not re-adding %w", err))
+			continue
+		}
+		if attached {
+			if err := lxc.DeviceRemove(lxcName, name); err != nil {
+				return fmt.Errorf("failed to remove stale device '%s': %w", name, err)
+			}
+		}
+		if err := lxc.DeviceAdd(lxcName, name, device.Type, device.Config); err != nil {
+			return fmt.Errorf("failed to re-add device '%s': %w", name, err)
+		}
+	}
+
+	if idmap := cfg.GetIDMap(containerName); len(idmap) > 0 {
+		if err := lxc.SetRawIDMap(lxcName, idmap); err != nil {
+			return err
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// isManagedType reports whether devices of deviceType are added by mount,
+// volume attach or device add, and so tracked in containers.yaml
+func isManagedType(deviceType string) bool {
+	return deviceType == validation.DeviceTypeDisk || isPassthroughType(deviceType)
+}
@@ -0,0 +1,56 @@
+package operations
+
+import (
+	"fmt"
+
+	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/lxc"
+	"lxc-dev-manager/internal/validation"
+)
+
+// ResizeDisk changes the root disk size limit of an existing container
+func ResizeDisk(cfg *config.Config, name, size string) error {
+	if !cfg.HasContainer(name) {
+		return containerNotFound(name)
+	}
+
+	lxcName := cfg.GetLXCName(name)
+	if !lxc.Exists(lxcName) {
+		return containerNotInLXC(name, lxcName)
+	}
+
+	if err := applyDiskSize(lxcName, size); err != nil {
+		return err
+	}
+
+	cfg.SetContainerDisk(name, size)
+	if err := cfg.Save(); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	return nil
+}
+
+// applyDiskSize checks size against the capacity of the container's root
+// pool and sets it on the root device
+func applyDiskSize(lxcName, size string) error {
+	bytes, err := validation.ParseSize(size)
+	if err != nil {
+		return err
+	}
+
+	pool, err := lxc.GetRootPool(lxcName)
+	if err != nil {
+		return err
+	}
+
+	total, _, err := lxc.PoolSpace(pool)
+	if err != nil {
+		return err
+	}
+	if total > 0 && bytes > total {
+		return fmt.Errorf("disk size %s exceeds the capacity of pool '%s' (%s)", size, pool, validation.FormatSize(total))
+	}
+
+	return lxc.SetRootDiskSize(lxcName, size)
+}
@@ -0,0 +1,62 @@
+package operations
+
+import (
+	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/dns"
+)
+
+// DNSEnabled reports whether host DNS registration is configured for the project
+func DNSEnabled(cfg *config.Config) bool {
+	return cfg.DNS.Mode != ""
+}
+
+// DNSEntries returns hostname mappings for all running containers with an IP
+func DNSEntries(cfg *config.Config) ([]dns.Entry, error) {
+	containers, err := List(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []dns.Entry
+	for _, c := range containers {
+		if c.Status != "RUNNING" || c.IP == "" {
+			continue
+		}
+		entries = append(entries, dns.Entry{
+			Hostname: dns.Hostname(c.Name, cfg.Project, cfg.DNS.Domain),
+			IP:       c.IP,
+		})
+	}
+	return entries, nil
+}
+
+// RefreshDNS rewrites the project's host DNS entries from current container IPs.
+// It is a no-op when DNS registration is not configured.
+func RefreshDNS(cfg *config.Config) ([]dns.Entry, error) {
+	if !DNSEnabled(cfg) {
+		return nil, nil
+	}
+
+	entries, err := DNSEntries(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := dns.Apply(cfg.Project, entries, dnsOptions(cfg)); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ClearDNS removes all of the project's host DNS entries
+func ClearDNS(cfg *config.Config) error {
+	return dns.Clear(cfg.Project, dnsOptions(cfg))
+}
+
+func dnsOptions(cfg *config.Config) dns.Options {
+	return dns.Options{
+		Mode:   cfg.DNS.Mode,
+		Domain: cfg.DNS.Domain,
+		Sudo:   cfg.DNS.Sudo,
+	}
+}
@@ -0,0 +1,108 @@
+package operations
+
+import (
+	"fmt"
+	"time"
+
+	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/lxc"
+)
+
+// DockerReport describes the Docker setup of a container
+type DockerReport struct {
+	StorageDriver string   // e.g. overlay2, or vfs when overlay isn't usable
+	CgroupFS      string   // cgroup2fs for cgroup v2, tmpfs for v1
+	Restarted     bool     // Nesting was off, so the container was restarted
+	Warnings      []string // Settings that work but will cause trouble
+}
+
+// SetNesting turns Docker-in-LXC support on or off for a container and
+// records it in the config. It reports whether the container is running
+// and needs a restart for the change to apply.
+func SetNesting(cfg *config.Config, name string, enabled bool) (restartNeeded bool, err error) {
+	if !cfg.HasContainer(name) {
+		return false, containerNotFound(name)
+	}
+
+	lxcName := cfg.GetLXCName(name)
+	if !lxc.Exists(lxcName) {
+		return false, containerNotInLXC(name, lxcName)
+	}
+
+	if err := lxc.SetNesting(lxcName, enabled); err != nil {
+		return false, err
+	}
+	cfg.SetContainerNesting(name, enabled)
+	if err := cfg.Save(); err != nil {
+		return false, err
+	}
+
+	status, err := lxc.GetStatus(lxcName)
+	if err != nil {
+		return false, err
+	}
+	return status == "RUNNING", nil
+}
+
+// SetupDocker installs Docker in a running container and checks that it
+// works. Nesting is enabled first if needed, which restarts the container.
+// The storage driver and cgroup version are checked and reported, since
+// Docker runs on either but is slow or limited on some.
+func SetupDocker(cfg *config.Config, name string) (*DockerReport, error) {
+	if !cfg.HasContainer(name) {
+		return nil, containerNotFound(name)
+	}
+
+	lxcName := cfg.GetLXCName(name)
+	if !lxc.Exists(lxcName) {
+		return nil, containerNotInLXC(name, lxcName)
+	}
+	status, err := lxc.GetStatus(lxcName)
+	if err != nil {
+		return nil, err
+	}
+	if status != "RUNNING" {
+		return nil, containerNotRunning(name)
+	}
+
+	report := &DockerReport{}
+	nesting, err := lxc.IsNesting(lxcName)
+	if err != nil {
+		return nil, err
+	}
+	if !nesting {
+		if err := lxc.EnableNesting(lxcName); err != nil {
+			return nil, fmt.Errorf("failed to enable nesting: %w", err)
+		}
+		cfg.SetContainerNesting(name, true)
+		if err := cfg.Save(); err != nil {
+			return nil, err
+		}
+		if err := lxc.StopWithTimeout(lxcName, stopTimeout(cfg, name), false); err != nil {
+			return nil, err
+		}
+		if err := lxc.Start(lxcName); err != nil {
+			return nil, err
+		}
+		if err := lxc.WaitForReady(lxcName, 60*time.Second); err != nil {
+			return nil, err
+		}
+		report.Restarted = true
+	}
+
+	if err := lxc.InstallDocker(lxcName, cfg.GetUser(name).Name); err != nil {
+		return nil, err
+	}
+
+	report.StorageDriver, report.CgroupFS, err = lxc.DockerInfo(lxcName)
+	if err != nil {
+		return nil, err
+	}
+	if report.StorageDriver == "vfs" {
+		report.Warnings = append(report.Warnings, "Docker uses the vfs storage driver, which copies every image layer in full; use a btrfs or zfs storage pool, or a kernel with overlayfs support for unprivileged containers, to get overlay2")
+	}
+	if report.CgroupFS != "cgroup2fs" {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("the container uses cgroup v1 (%s); Docker resource limits may not work, enable cgroup v2 on the host", report.CgroupFS))
+	}
+	return report, nil
+}
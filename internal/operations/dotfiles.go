@@ -0,0 +1,36 @@
+package operations
+
+import (
+	"fmt"
+
+	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/lxc"
+)
+
+// ApplyDotfiles clones or updates the configured dotfiles repository for
+// the container user and runs its install script
+func ApplyDotfiles(cfg *config.Config, name string) error {
+	if !cfg.HasContainer(name) {
+		return containerNotFound(name)
+	}
+
+	lxcName := cfg.GetLXCName(name)
+	if !lxc.Exists(lxcName) {
+		return containerNotInLXC(name, lxcName)
+	}
+
+	status, err := lxc.GetStatus(lxcName)
+	if err != nil {
+		return err
+	}
+	if status != "RUNNING" {
+		return containerNotRunning(name)
+	}
+
+	dotfiles := cfg.GetDotfiles(name)
+	if dotfiles == nil {
+		return fmt.Errorf("no dotfiles configured for container '%s'", name)
+	}
+
+	return lxc.ApplyDotfiles(lxcName, cfg.GetUser(name).Name, dotfiles.Repo, dotfiles.Install)
+}
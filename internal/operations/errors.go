@@ -0,0 +1,75 @@
+package operations
+
+import (
+	"errors"
+	"fmt"
+
+	"lxc-dev-manager/internal/errcode"
+)
+
+// Kinds of failure callers can tell apart with errors.Is. Errors keep
+// their specific message and carry the kind alongside it (see kindErrorf);
+// pkg/lxcmgr exports these as its sentinel errors.
+var (
+	ErrContainerNotFound = errors.New("container not found")
+	ErrContainerExists   = errors.New("container already exists")
+	ErrContainerStopped  = errors.New("container is stopped")
+
+	ErrSnapshotNotFound  = errors.New("snapshot not found")
+	ErrSnapshotExists    = errors.New("snapshot already exists")
+	ErrSnapshotProtected = errors.New("snapshot is protected") // initial-state
+
+	ErrMountNotFound        = errors.New("mount not found")
+	ErrMountExists          = errors.New("mount already exists")
+	ErrMountPathConflict    = errors.New("mount path already in use")
+	ErrInvalidSourcePath    = errors.New("invalid source path")
+	ErrInvalidContainerPath = errors.New("invalid container path")
+	ErrBlockedPath          = errors.New("path is blocked for security")
+	ErrPrivilegedMount      = errors.New("operation not allowed on privileged container")
+	ErrRiskyPath            = errors.New("path is risky and requires explicit permission")
+
+	ErrImageNotFound = errors.New("image not found")
+	ErrImageExists   = errors.New("image already exists")
+)
+
+// kindError is an error that also matches its kind
+type kindError struct {
+	err  error
+	kind error
+}
+
+func (e *kindError) Error() string {
+	return e.err.Error()
+}
+
+func (e *kindError) Unwrap() []error {
+	return []error{e.err, e.kind}
+}
+
+// kindErrorf formats an error that errors.Is matches against kind
+func kindErrorf(kind error, format string, args ...any) error {
+	return &kindError{err: fmt.Errorf(format, args...), kind: kind}
+}
+
+// withKind makes err also match kind. A nil err stays nil.
+func withKind(kind, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &kindError{err: err, kind: kind}
+}
+
+// containerNotFound reports a container missing from the config
+func containerNotFound(name string) error {
+	return errcode.New(errcode.NotFound, name, kindErrorf(ErrContainerNotFound, "container '%s' not found in config", name))
+}
+
+// containerNotInLXC reports a configured container that LXC doesn't have
+func containerNotInLXC(name, lxcName string) error {
+	return errcode.New(errcode.NotFound, name, kindErrorf(ErrContainerNotFound, "container '%s' does not exist in LXC", lxcName))
+}
+
+// containerNotRunning reports a stopped container an operation needs running
+func containerNotRunning(name string) error {
+	return errcode.New(errcode.NotRunning, name, kindErrorf(ErrContainerStopped, "container '%s' is not running", name))
+}
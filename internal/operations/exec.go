@@ -2,23 +2,27 @@ package operations
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 	"os/exec"
+	"strings"
 	"syscall"
 
 	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/errcode"
 	"lxc-dev-manager/internal/lxc"
+	"lxc-dev-manager/internal/validation"
 )
 
 // Exec runs a command inside a container and returns the output
 func Exec(cfg *config.Config, name string, cmd []string) ([]byte, error) {
 	if !cfg.HasContainer(name) {
-		return nil, fmt.Errorf("container '%s' not found in config", name)
+		return nil, containerNotFound(name)
 	}
 
 	lxcName := cfg.GetLXCName(name)
 	if !lxc.Exists(lxcName) {
-		return nil, fmt.Errorf("container '%s' does not exist in LXC", lxcName)
+		return nil, containerNotInLXC(name, lxcName)
 	}
 
 	// Check if running
@@ -27,11 +31,12 @@ func Exec(cfg *config.Config, name string, cmd []string) ([]byte, error) {
 		return nil, err
 	}
 	if status != "RUNNING" {
-		return nil, fmt.Errorf("container '%s' is not running", name)
+		return nil, containerNotRunning(name)
 	}
 
 	// Build command
 	args := append([]string{"exec", lxcName, "--"}, cmd...)
+	slog.Debug("lxc command", "args", strings.Join(args, " "))
 	execCmd := exec.Command("lxc", args...)
 	return execCmd.CombinedOutput()
 }
@@ -39,12 +44,12 @@ func Exec(cfg *config.Config, name string, cmd []string) ([]byte, error) {
 // ExecInteractive runs an interactive command inside a container
 func ExecInteractive(cfg *config.Config, name string, cmd []string) error {
 	if !cfg.HasContainer(name) {
-		return fmt.Errorf("container '%s' not found in config", name)
+		return containerNotFound(name)
 	}
 
 	lxcName := cfg.GetLXCName(name)
 	if !lxc.Exists(lxcName) {
-		return fmt.Errorf("container '%s' does not exist in LXC", lxcName)
+		return containerNotInLXC(name, lxcName)
 	}
 
 	// Check if running
@@ -53,7 +58,7 @@ func ExecInteractive(cfg *config.Config, name string, cmd []string) error {
 		return err
 	}
 	if status != "RUNNING" {
-		return fmt.Errorf("container '%s' is not running", name)
+		return containerNotRunning(name)
 	}
 
 	// Build command
@@ -65,18 +70,19 @@ func ExecInteractive(cfg *config.Config, name string, cmd []string) error {
 	}
 
 	// Use syscall.Exec to replace the process for proper TTY handling
+	slog.Debug("lxc command", "args", strings.Join(args, " "), "interactive", true)
 	return syscall.Exec(lxcPath, append([]string{"lxc"}, args...), os.Environ())
 }
 
 // Shell opens an interactive shell in a container
 func Shell(cfg *config.Config, name string, opts ShellOpts) error {
 	if !cfg.HasContainer(name) {
-		return fmt.Errorf("container '%s' not found in config", name)
+		return containerNotFound(name)
 	}
 
 	lxcName := cfg.GetLXCName(name)
 	if !lxc.Exists(lxcName) {
-		return fmt.Errorf("container '%s' does not exist in LXC", lxcName)
+		return containerNotInLXC(name, lxcName)
 	}
 
 	// Check if running
@@ -85,7 +91,7 @@ func Shell(cfg *config.Config, name string, opts ShellOpts) error {
 		return err
 	}
 	if status != "RUNNING" {
-		return fmt.Errorf("container '%s' is not running", name)
+		return containerNotRunning(name)
 	}
 
 	// Determine which user to use
@@ -95,14 +101,11 @@ func Shell(cfg *config.Config, name string, opts ShellOpts) error {
 	}
 
 	// Build lxc exec command
-	args := []string{"exec", lxcName, "--"}
+	var sw lxc.UserSwitch
 	if user != "" && user != "root" {
-		// Use su -l to get a proper login shell with all supplementary groups loaded
-		args = append(args, "su", "-l", user)
-	} else {
-		// Root shell
-		args = append(args, "bash", "-l")
+		sw = lxc.DetectUserSwitch(lxcName, user)
 	}
+	args := BuildShellArgs(lxcName, user, sw)
 
 	lxcPath, err := exec.LookPath("lxc")
 	if err != nil {
@@ -110,18 +113,96 @@ func Shell(cfg *config.Config, name string, opts ShellOpts) error {
 	}
 
 	// Use syscall.Exec to replace the process for proper TTY handling
+	slog.Debug("lxc command", "args", strings.Join(args, " "), "interactive", true)
 	return syscall.Exec(lxcPath, append([]string{"lxc"}, args...), os.Environ())
 }
 
-// BuildShellArgs constructs the lxc exec arguments for Shell
-func BuildShellArgs(lxcName, user string) []string {
-	args := []string{"exec", lxcName, "--"}
-
+// BuildShellArgs constructs the lxc exec arguments for Shell. A user's
+// login shell is opened through sw (from lxc.DetectUserSwitch): su -l by
+// default, so PAM runs and supplementary groups are loaded.
+func BuildShellArgs(lxcName, user string, sw lxc.UserSwitch) []string {
 	if user != "" && user != "root" {
-		args = append(args, "su", "-l", user)
-	} else {
-		args = append(args, "bash", "-l")
+		return sw.ExecArgs(lxcName, user, nil)
+	}
+	return []string{"exec", lxcName, "--", "bash", "-l"}
+}
+
+// DefaultSession is the session Attach opens when none is given
+const DefaultSession = "main"
+
+// Attach creates or attaches to a zellij or tmux session in a container,
+// as the user, so work in it survives the terminal disconnecting. The
+// multiplexer is installed first if missing.
+func Attach(cfg *config.Config, name string, opts AttachOpts, progress func(step string)) error {
+	args, err := AttachArgs(cfg, name, opts, progress)
+	if err != nil {
+		return err
+	}
+
+	lxcPath, err := exec.LookPath("lxc")
+	if err != nil {
+		return fmt.Errorf("lxc command not found: %w", err)
+	}
+
+	// Use syscall.Exec to replace the process for proper TTY handling
+	slog.Debug("lxc command", "args", strings.Join(args, " "), "interactive", true)
+	return syscall.Exec(lxcPath, append([]string{"lxc"}, args...), os.Environ())
+}
+
+// AttachArgs prepares a container for Attach and returns the lxc exec
+// arguments that open the session
+func AttachArgs(cfg *config.Config, name string, opts AttachOpts, progress func(step string)) ([]string, error) {
+	session := opts.Session
+	if session == "" {
+		session = DefaultSession
+	}
+	if err := validation.ValidateSessionName(session); err != nil {
+		return nil, err
+	}
+	if opts.Multiplexer != "" && opts.Multiplexer != lxc.MultiplexerZellij && opts.Multiplexer != lxc.MultiplexerTmux {
+		return nil, errcode.Errorf(errcode.Usage, name, "unknown multiplexer '%s': must be zellij or tmux", opts.Multiplexer)
+	}
+
+	if !cfg.HasContainer(name) {
+		return nil, containerNotFound(name)
 	}
 
-	return args
+	lxcName := cfg.GetLXCName(name)
+	if !lxc.Exists(lxcName) {
+		return nil, containerNotInLXC(name, lxcName)
+	}
+
+	// Check if running
+	status, err := lxc.GetStatus(lxcName)
+	if err != nil {
+		return nil, err
+	}
+	if status != "RUNNING" {
+		return nil, containerNotRunning(name)
+	}
+	if progress == nil {
+		progress = func(string) {}
+	}
+
+	multiplexer := opts.Multiplexer
+	if multiplexer == "" {
+		multiplexer = lxc.InstalledMultiplexer(lxcName)
+	}
+	if multiplexer == "" {
+		multiplexer = lxc.MultiplexerZellij
+	}
+	progress("checking " + multiplexer)
+	if err := lxc.InstallMultiplexer(lxcName, multiplexer); err != nil {
+		return nil, err
+	}
+
+	user := opts.User
+	if user == "" {
+		user = cfg.GetUser(name).Name
+	}
+	command := "zellij attach --create " + session
+	if multiplexer == lxc.MultiplexerTmux {
+		command = "tmux new-session -A -s " + session
+	}
+	return lxc.DetectUserSwitch(lxcName, user).ScriptArgs(lxcName, user, command), nil
 }
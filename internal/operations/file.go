@@ -8,18 +8,19 @@ import (
 	"strings"
 
 	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/dryrun"
 	"lxc-dev-manager/internal/lxc"
 )
 
 // CopyToContainer copies a file or directory from host to container
 func CopyToContainer(cfg *config.Config, containerName, localPath, remotePath string, opts CopyOpts) error {
 	if !cfg.HasContainer(containerName) {
-		return fmt.Errorf("container '%s' not found in config", containerName)
+		return containerNotFound(containerName)
 	}
 
 	lxcName := cfg.GetLXCName(containerName)
 	if !lxc.Exists(lxcName) {
-		return fmt.Errorf("container '%s' does not exist in LXC", lxcName)
+		return containerNotInLXC(containerName, lxcName)
 	}
 
 	// Validate source exists on host
@@ -35,31 +36,30 @@ func CopyToContainer(cfg *config.Config, containerName, localPath, remotePath st
 		return fmt.Errorf("destination path cannot be empty")
 	}
 
-	// Expand ~ to user's home directory
-	if strings.HasPrefix(remotePath, "~/") {
-		user := cfg.GetUser(containerName)
-		remotePath = "/home/" + user.Name + remotePath[1:]
-	} else if remotePath == "~" {
-		user := cfg.GetUser(containerName)
-		remotePath = "/home/" + user.Name
-	}
+	remotePath = expandHome(cfg, containerName, remotePath)
 
 	// Determine if recursive (directory)
 	recursive := info.IsDir()
 
-	// Get the destination directory to check/create
-	destDir := path.Dir(remotePath)
-
-	// Check if destination directory exists
-	user := cfg.GetUser(containerName)
-	if !lxc.DirExists(lxcName, destDir) {
-		if !opts.AutoCreateDir {
-			return fmt.Errorf("destination directory '%s' does not exist", destDir)
+	// Push a filtered copy when the directory has exclude patterns
+	if recursive {
+		excludes, err := loadExcludes(localPath, opts.Exclude)
+		if err != nil {
+			return err
 		}
-		if err := lxc.Exec(lxcName, "mkdir", "-p", destDir); err != nil {
-			return fmt.Errorf("failed to create directory: %w", err)
+		if excludes != nil {
+			tempDir, staged, err := stageFiltered(localPath, excludes)
+			if err != nil {
+				return err
+			}
+			defer os.RemoveAll(tempDir)
+			localPath = staged
 		}
-		lxc.Exec(lxcName, "chown", user.Name+":"+user.Name, destDir)
+	}
+
+	user := cfg.GetUser(containerName)
+	if err := ensureDestDir(lxcName, path.Dir(remotePath), user.Name, opts); err != nil {
+		return err
 	}
 
 	// Push the file
@@ -68,43 +68,46 @@ func CopyToContainer(cfg *config.Config, containerName, localPath, remotePath st
 		pushPath = path.Dir(remotePath)
 	}
 
-	if err := lxc.FilePush(lxcName, localPath, pushPath, recursive); err != nil {
+	var total int64
+	if opts.Progress != nil {
+		total = localSize(localPath)
+	}
+	measure := func() int64 {
+		size, _ := lxc.PathSize(lxcName, remotePath)
+		return size
+	}
+	if err := trackProgress(total, measure, opts.Progress, func() error {
+		return lxc.FilePush(lxcName, localPath, pushPath, recursive)
+	}); err != nil {
 		return err
 	}
 
-	// Fix ownership
-	if recursive {
-		if err := lxc.Exec(lxcName, "chown", "-R", user.Name+":"+user.Name, remotePath); err != nil {
-			return fmt.Errorf("could not set ownership: %w", err)
+	if opts.Verify || cfg.Defaults.VerifyCopy {
+		// A recursive push lands in a directory named after the source
+		pushed := remotePath
+		if recursive {
+			pushed = path.Join(pushPath, filepath.Base(localPath))
 		}
-	} else {
-		if err := lxc.Exec(lxcName, "chown", user.Name+":"+user.Name, remotePath); err != nil {
-			return fmt.Errorf("could not set ownership: %w", err)
+		if err := verifyPush(lxcName, localPath, pushed, recursive); err != nil {
+			return err
 		}
 	}
 
-	return nil
+	return chownCopy(lxcName, remotePath, user.Name, recursive)
 }
 
 // CopyFromContainer copies a file or directory from container to host
-func CopyFromContainer(cfg *config.Config, containerName, remotePath, localPath string) error {
+func CopyFromContainer(cfg *config.Config, containerName, remotePath, localPath string, opts CopyOpts) error {
 	if !cfg.HasContainer(containerName) {
-		return fmt.Errorf("container '%s' not found in config", containerName)
+		return containerNotFound(containerName)
 	}
 
 	lxcName := cfg.GetLXCName(containerName)
 	if !lxc.Exists(lxcName) {
-		return fmt.Errorf("container '%s' does not exist in LXC", lxcName)
+		return containerNotInLXC(containerName, lxcName)
 	}
 
-	// Expand ~ to user's home directory
-	if strings.HasPrefix(remotePath, "~/") {
-		user := cfg.GetUser(containerName)
-		remotePath = "/home/" + user.Name + remotePath[1:]
-	} else if remotePath == "~" {
-		user := cfg.GetUser(containerName)
-		remotePath = "/home/" + user.Name
-	}
+	remotePath = expandHome(cfg, containerName, remotePath)
 
 	// Check if source exists in container
 	if !lxc.FileExists(lxcName, remotePath) {
@@ -116,37 +119,297 @@ func CopyFromContainer(cfg *config.Config, containerName, remotePath, localPath
 
 	// Ensure local destination directory exists
 	localDir := filepath.Dir(localPath)
-	if err := os.MkdirAll(localDir, 0755); err != nil {
+	if dryrun.Enabled() {
+		dryrun.Printf("create directory %s", localDir)
+	} else if err := os.MkdirAll(localDir, 0755); err != nil {
 		return fmt.Errorf("failed to create local directory: %w", err)
 	}
 
 	// Pull the file
-	if err := lxc.FilePull(lxcName, remotePath, localPath, recursive); err != nil {
+	var total int64
+	if opts.Progress != nil {
+		total, _ = lxc.PathSize(lxcName, remotePath)
+	}
+	measure := func() int64 {
+		return localSize(localPath)
+	}
+	if err := trackProgress(total, measure, opts.Progress, func() error {
+		return lxc.FilePull(lxcName, remotePath, localPath, recursive)
+	}); err != nil {
 		return err
 	}
 
+	if opts.Verify || cfg.Defaults.VerifyCopy {
+		// A recursive pull lands in a directory named after the source
+		pulled := localPath
+		if recursive {
+			pulled = filepath.Join(localPath, path.Base(remotePath))
+		}
+		if err := verifyPull(lxcName, remotePath, pulled, recursive); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
-// CopyBetweenContainers copies a file or directory from one container to another
+// CopyBetweenContainers copies a file or directory from one container to
+// another. The data is streamed between the containers; only when exclude
+// patterns apply (which are matched on the host) does it go through a
+// temporary directory on the host.
 func CopyBetweenContainers(cfg *config.Config, srcContainer, srcPath, destContainer, destPath string, opts CopyOpts) error {
-	// Create temp directory for intermediate storage
+	for _, name := range []string{srcContainer, destContainer} {
+		if !cfg.HasContainer(name) {
+			return containerNotFound(name)
+		}
+		if lxcName := cfg.GetLXCName(name); !lxc.Exists(lxcName) {
+			return containerNotInLXC(name, lxcName)
+		}
+	}
+	if destPath == "" {
+		return fmt.Errorf("destination path cannot be empty")
+	}
+
+	srcLXC := cfg.GetLXCName(srcContainer)
+	destLXC := cfg.GetLXCName(destContainer)
+	srcPath = expandHome(cfg, srcContainer, srcPath)
+	destPath = expandHome(cfg, destContainer, destPath)
+
+	if !lxc.FileExists(srcLXC, srcPath) {
+		return fmt.Errorf("source '%s' does not exist in container %s", srcPath, srcContainer)
+	}
+	recursive := lxc.IsDir(srcLXC, srcPath)
+
+	// Streaming can't filter, and needs an executor that can pipe
+	if len(opts.Exclude) > 0 || !lxc.CanStreamCopy() || (recursive && lxc.FileExists(srcLXC, path.Join(srcPath, IgnoreFile))) {
+		return copyViaHost(cfg, srcContainer, srcPath, destContainer, destPath, recursive, opts)
+	}
+
+	user := cfg.GetUser(destContainer)
+	if err := ensureDestDir(destLXC, path.Dir(destPath), user.Name, opts); err != nil {
+		return err
+	}
+
+	var total int64
+	if opts.Progress != nil {
+		total, _ = lxc.PathSize(srcLXC, srcPath)
+	}
+	measure := func() int64 {
+		size, _ := lxc.PathSize(destLXC, destPath)
+		return size
+	}
+	if err := trackProgress(total, measure, opts.Progress, func() error {
+		return lxc.StreamCopy(srcLXC, srcPath, destLXC, destPath, recursive)
+	}); err != nil {
+		return err
+	}
+
+	if opts.Verify || cfg.Defaults.VerifyCopy {
+		src, err := lxc.Checksums(srcLXC, srcPath, recursive)
+		if err != nil {
+			return err
+		}
+		dest, err := lxc.Checksums(destLXC, destPath, recursive)
+		if err != nil {
+			return err
+		}
+		if err := compareChecksums(src, dest); err != nil {
+			return err
+		}
+	}
+
+	return chownCopy(destLXC, destPath, user.Name, recursive)
+}
+
+// copyViaHost copies between containers by pulling into a temporary
+// directory on the host and pushing from there
+func copyViaHost(cfg *config.Config, srcContainer, srcPath, destContainer, destPath string, recursive bool, opts CopyOpts) error {
 	tempDir, err := os.MkdirTemp("", "lxc-copy-")
 	if err != nil {
 		return fmt.Errorf("failed to create temp directory: %w", err)
 	}
 	defer os.RemoveAll(tempDir)
 
-	// Pull from source container to temp
-	tempPath := filepath.Join(tempDir, filepath.Base(srcPath))
-	if err := CopyFromContainer(cfg, srcContainer, srcPath, tempPath); err != nil {
+	// Staged under the destination's name, as a recursive push lands in a
+	// directory named after what it pushes; this puts a directory at
+	// destPath like the streamed copy does
+	tempPath := filepath.Join(tempDir, path.Base(destPath))
+	pullTarget := tempPath
+	if recursive {
+		// A recursive pull creates a directory named after the source inside its target
+		pullTarget = tempDir
+	}
+	if err := CopyFromContainer(cfg, srcContainer, srcPath, pullTarget, CopyOpts{Verify: opts.Verify, Progress: opts.Progress}); err != nil {
 		return fmt.Errorf("failed to pull from source: %w", err)
 	}
+	if pulled := filepath.Join(tempDir, path.Base(srcPath)); recursive && pulled != tempPath {
+		if err := os.Rename(pulled, tempPath); err != nil {
+			return fmt.Errorf("failed to stage copy: %w", err)
+		}
+	}
 
-	// Push to destination container
 	if err := CopyToContainer(cfg, destContainer, tempPath, destPath, opts); err != nil {
 		return fmt.Errorf("failed to push to destination: %w", err)
 	}
+	return nil
+}
 
+// expandHome expands a leading ~ to the container user's home directory
+func expandHome(cfg *config.Config, containerName, p string) string {
+	if strings.HasPrefix(p, "~/") {
+		return "/home/" + cfg.GetUser(containerName).Name + p[1:]
+	} else if p == "~" {
+		return "/home/" + cfg.GetUser(containerName).Name
+	}
+	return p
+}
+
+// ensureDestDir makes sure the destination directory of a copy exists,
+// creating it (owned by user) if allowed
+func ensureDestDir(lxcName, dir, user string, opts CopyOpts) error {
+	if lxc.DirExists(lxcName, dir) {
+		return nil
+	}
+	if !opts.AutoCreateDir && (opts.ConfirmCreateDir == nil || !opts.ConfirmCreateDir(dir)) {
+		return fmt.Errorf("destination directory '%s' does not exist", dir)
+	}
+	if err := lxc.Exec(lxcName, "mkdir", "-p", dir); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	lxc.Exec(lxcName, "chown", user+":"+user, dir)
 	return nil
 }
+
+// chownCopy gives the copied file or directory to the container user
+func chownCopy(lxcName, p, user string, recursive bool) error {
+	args := []string{"chown", user + ":" + user, p}
+	if recursive {
+		args = []string{"chown", "-R", user + ":" + user, p}
+	}
+	if err := lxc.Exec(lxcName, args...); err != nil {
+		return fmt.Errorf("could not set ownership: %w", err)
+	}
+	return nil
+}
+
+// fileContainer resolves a container for the file commands
+func fileContainer(cfg *config.Config, containerName string) (string, error) {
+	if !cfg.HasContainer(containerName) {
+		return "", containerNotFound(containerName)
+	}
+	lxcName := cfg.GetLXCName(containerName)
+	if !lxc.Exists(lxcName) {
+		return "", containerNotInLXC(containerName, lxcName)
+	}
+	return lxcName, nil
+}
+
+// ListFiles returns a long listing of a path in a container
+func ListFiles(cfg *config.Config, containerName, remotePath string) (string, error) {
+	lxcName, err := fileContainer(cfg, containerName)
+	if err != nil {
+		return "", err
+	}
+	return lxc.ListDir(lxcName, expandHome(cfg, containerName, remotePath))
+}
+
+// ReadFile returns the contents of a file in a container
+func ReadFile(cfg *config.Config, containerName, remotePath string) ([]byte, error) {
+	lxcName, err := fileContainer(cfg, containerName)
+	if err != nil {
+		return nil, err
+	}
+	remotePath = expandHome(cfg, containerName, remotePath)
+
+	if lxc.IsDir(lxcName, remotePath) {
+		return nil, fmt.Errorf("'%s' is a directory", remotePath)
+	}
+	return lxc.ReadFile(lxcName, remotePath)
+}
+
+// RemoveFile deletes a file in a container, or a directory tree with recursive
+func RemoveFile(cfg *config.Config, containerName, remotePath string, recursive bool) error {
+	lxcName, err := fileContainer(cfg, containerName)
+	if err != nil {
+		return err
+	}
+	remotePath = expandHome(cfg, containerName, remotePath)
+
+	if path.Clean(remotePath) == "/" {
+		return fmt.Errorf("refusing to remove '/'")
+	}
+	if !lxc.FileExists(lxcName, remotePath) {
+		return fmt.Errorf("'%s' does not exist in container %s", remotePath, containerName)
+	}
+	if !recursive && lxc.IsDir(lxcName, remotePath) {
+		return fmt.Errorf("'%s' is a directory (use recursive removal)", remotePath)
+	}
+	return lxc.RemovePath(lxcName, remotePath, recursive)
+}
+
+// EditFile pulls a file from a container into a temporary copy, lets edit
+// change it, and pushes it back with its ownership and mode if it changed.
+// A missing file is created, owned by the container user. Returns whether
+// the file was written.
+func EditFile(cfg *config.Config, containerName, remotePath string, edit func(localPath string) error) (bool, error) {
+	lxcName, err := fileContainer(cfg, containerName)
+	if err != nil {
+		return false, err
+	}
+	remotePath = expandHome(cfg, containerName, remotePath)
+
+	if lxc.IsDir(lxcName, remotePath) {
+		return false, fmt.Errorf("'%s' is a directory", remotePath)
+	}
+
+	tempDir, err := os.MkdirTemp("", "lxc-edit-")
+	if err != nil {
+		return false, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// Keep the file name so editors pick the right syntax
+	localPath := filepath.Join(tempDir, path.Base(remotePath))
+
+	var uid, gid int
+	mode := "0644"
+	if lxc.FileExists(lxcName, remotePath) {
+		if uid, gid, mode, err = lxc.FileOwner(lxcName, remotePath); err != nil {
+			return false, err
+		}
+		if err := lxc.FilePull(lxcName, remotePath, localPath, false); err != nil {
+			return false, err
+		}
+	} else {
+		if !lxc.DirExists(lxcName, path.Dir(remotePath)) {
+			return false, fmt.Errorf("directory '%s' does not exist in container %s", path.Dir(remotePath), containerName)
+		}
+		if uid, err = lxc.UserUID(lxcName, cfg.GetUser(containerName).Name); err != nil {
+			return false, err
+		}
+		gid = uid
+		if err := os.WriteFile(localPath, nil, 0600); err != nil {
+			return false, fmt.Errorf("failed to create temp file: %w", err)
+		}
+	}
+
+	before, err := fileChecksum(localPath)
+	if err != nil {
+		return false, err
+	}
+	if err := edit(localPath); err != nil {
+		return false, err
+	}
+	after, err := fileChecksum(localPath)
+	if err != nil {
+		return false, err
+	}
+	if before == after {
+		return false, nil
+	}
+
+	if err := lxc.FilePushAs(lxcName, localPath, remotePath, uid, gid, mode); err != nil {
+		return false, err
+	}
+	return true, nil
+}
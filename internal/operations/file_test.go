@@ -0,0 +1,40 @@
+package operations
+
+import (
+	"os"
+	"testing"
+
+	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/lxc"
+)
+
+func TestCopyBetweenContainers_WithoutPipeExecutor(t *testing.T) {
+	mock := lxc.NewMockExecutor()
+	// Hides RunPipe, as an executor passed to the SDK may
+	lxc.SetExecutor(struct{ lxc.Executor }{mock})
+	t.Cleanup(lxc.ResetExecutor)
+
+	cfg := &config.Config{
+		Project: "test",
+		Containers: map[string]config.Container{
+			"dev1": {Image: "ubuntu:24.04"},
+			"dev2": {Image: "ubuntu:24.04"},
+		},
+	}
+	mock.SetOutput("info test-dev1", "Name: test-dev1")
+	mock.SetOutput("info test-dev2", "Name: test-dev2")
+	mock.SetError("exec test-dev1 -- test -d", "not a directory")
+	mock.SetCallback("file pull", func(args []string) {
+		os.WriteFile(args[len(args)-1], []byte("data"), 0644)
+	})
+
+	if err := CopyBetweenContainers(cfg, "dev1", "/tmp/data.txt", "dev2", "/tmp/data.txt", CopyOpts{}); err != nil {
+		t.Fatalf("CopyBetweenContainers() error = %v", err)
+	}
+	if !mock.HasCallPrefix("file", "pull", "test-dev1//tmp/data.txt") {
+		t.Error("expected the copy to go through the host")
+	}
+	if !mock.HasCallPrefix("file", "push") {
+		t.Error("expected the file to be pushed to dev2")
+	}
+}
@@ -0,0 +1,93 @@
+package operations
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/dryrun"
+	"lxc-dev-manager/internal/lxc"
+)
+
+// hookIPTimeout bounds how long a hook that uses {{.IP}} waits for the
+// container to get an address
+const hookIPTimeout = 15 * time.Second
+
+// HookOutput receives the stdout and stderr of hook commands
+var HookOutput io.Writer = os.Stderr
+
+// runHook renders and runs the hook configured for event, if any.
+// Hooks run on the host with sh -c from the project directory.
+func runHook(cfg *config.Config, event, name, image string) error {
+	command := cfg.Hooks.Events()[event]
+	if command == "" {
+		return nil
+	}
+
+	tmpl, err := config.ParseHook(event, command)
+	if err != nil {
+		return fmt.Errorf("invalid %s hook: %w", event, err)
+	}
+
+	lxcName := cfg.GetLXCName(name)
+	ctx := config.HookContext{
+		Event:      event,
+		Name:       name,
+		LXCName:    lxcName,
+		Project:    cfg.Project,
+		ProjectDir: cfg.ProjectDir(),
+		Image:      image,
+	}
+	if image == "" {
+		ctx.Image = cfg.Containers[name].Image
+	}
+	if strings.Contains(command, ".IP") && (strings.HasPrefix(event, "post_") || event == "pre_stop") && !dryrun.Enabled() {
+		ctx.IP = waitForHookIP(lxcName)
+	}
+
+	var rendered strings.Builder
+	if err := tmpl.Execute(&rendered, ctx); err != nil {
+		return fmt.Errorf("invalid %s hook: %w", event, err)
+	}
+
+	if dryrun.Enabled() {
+		dryrun.Printf("run %s hook: %s", event, rendered.String())
+		return nil
+	}
+
+	slog.Debug("running hook", "event", event, "container", name, "command", rendered.String())
+	cmd := exec.Command("sh", "-c", rendered.String())
+	cmd.Dir = ctx.ProjectDir
+	cmd.Stdout = HookOutput
+	cmd.Stderr = HookOutput
+	cmd.Env = append(os.Environ(),
+		"LXC_DEV_EVENT="+event,
+		"LXC_DEV_CONTAINER="+name,
+		"LXC_DEV_LXC_NAME="+lxcName,
+		"LXC_DEV_IP="+ctx.IP,
+	)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s hook failed: %w", event, err)
+	}
+	return nil
+}
+
+// waitForHookIP returns the container's IPv4 address, waiting briefly for
+// DHCP after a start. Returns "" if none shows up in time.
+func waitForHookIP(lxcName string) string {
+	deadline := time.Now().Add(hookIPTimeout)
+	for {
+		if ip, err := lxc.GetIP(lxcName); err == nil {
+			return ip
+		}
+		if time.Now().After(deadline) {
+			return ""
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
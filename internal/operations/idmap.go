@@ -0,0 +1,86 @@
+package operations
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+
+	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/lxc"
+)
+
+// IDMapAuto asks Mount to derive the raw.idmap entry from the source owner
+const IDMapAuto = "auto"
+
+// DefaultContainerUID is assumed when the container user's UID can't be queried
+// (e.g. the container is stopped). It matches the first user created by useradd.
+const DefaultContainerUID = 1000
+
+// HostOwnerUID returns the UID that owns a path on the host
+func HostOwnerUID(path string) (int, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, fmt.Errorf("cannot determine owner of %s", path)
+	}
+	return int(stat.Uid), nil
+}
+
+// ContainerUserUID returns the UID of the container's configured user,
+// falling back to DefaultContainerUID when it can't be looked up.
+func ContainerUserUID(cfg *config.Config, containerName string) int {
+	user := cfg.GetUser(containerName)
+	if user.Name == "" {
+		return DefaultContainerUID
+	}
+	uid, err := lxc.UserUID(cfg.GetLXCName(containerName), user.Name)
+	if err != nil {
+		return DefaultContainerUID
+	}
+	return uid
+}
+
+// SuggestIDMap builds a raw.idmap entry mapping the owner of source on the
+// host to the container user, so rw mounts aren't owned by nobody:nogroup.
+func SuggestIDMap(cfg *config.Config, containerName, source string) (string, error) {
+	hostUID, err := HostOwnerUID(source)
+	if err != nil {
+		return "", fmt.Errorf("failed to detect owner of %s: %w", source, err)
+	}
+	return fmt.Sprintf("both %d %d", hostUID, ContainerUserUID(cfg, containerName)), nil
+}
+
+// HasIDMap reports whether a container already has the given raw.idmap entry
+func HasIDMap(cfg *config.Config, containerName, entry string) bool {
+	for _, existing := range cfg.GetIDMap(containerName) {
+		if existing == entry {
+			return true
+		}
+	}
+	return false
+}
+
+// OwnershipMismatch reports whether files owned by source's host owner would
+// appear as someone other than the container user (typically nobody:nogroup).
+// It returns the host owner and the host UID the container user maps to.
+// Containers without a UID map (privileged) never mismatch.
+func OwnershipMismatch(cfg *config.Config, containerName, source string) (mismatch bool, hostUID, mappedUID int, err error) {
+	hostUID, err = HostOwnerUID(source)
+	if err != nil {
+		return false, 0, 0, err
+	}
+
+	entries, err := lxc.GetIDMap(cfg.GetLXCName(containerName))
+	if err != nil {
+		return false, 0, 0, err
+	}
+
+	mappedUID, ok := lxc.HostUID(entries, ContainerUserUID(cfg, containerName))
+	if !ok {
+		return false, hostUID, 0, nil
+	}
+	return mappedUID != hostUID, hostUID, mappedUID, nil
+}
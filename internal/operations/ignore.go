@@ -0,0 +1,189 @@
+package operations
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreFile lists exclude patterns at the root of a copied directory
+const IgnoreFile = ".lxcignore"
+
+// excludeMatcher decides which paths of a copied directory are skipped.
+//
+// Patterns without a slash match a file or directory name at any depth
+// (e.g. "node_modules", "*.log"). Patterns containing a slash match the path
+// relative to the copied directory (e.g. "/build", "docs/*.pdf"). A trailing
+// slash restricts a pattern to directories. Excluding a directory skips
+// everything below it.
+type excludeMatcher struct {
+	patterns []excludePattern
+}
+
+type excludePattern struct {
+	glob     string
+	anchored bool // Match the relative path instead of the name
+	dirOnly  bool
+}
+
+// loadExcludes combines the patterns from root's ignore file with extra.
+// Returns nil when there is nothing to exclude.
+func loadExcludes(root string, extra []string) (*excludeMatcher, error) {
+	var lines []string
+
+	f, err := os.Open(filepath.Join(root, IgnoreFile))
+	if err == nil {
+		lines, err = readIgnoreLines(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", IgnoreFile, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read %s: %w", IgnoreFile, err)
+	}
+
+	lines = append(lines, extra...)
+	if len(lines) == 0 {
+		return nil, nil
+	}
+
+	m := &excludeMatcher{}
+	for _, line := range lines {
+		p := excludePattern{glob: line}
+		if strings.HasSuffix(p.glob, "/") {
+			p.dirOnly = true
+			p.glob = strings.TrimSuffix(p.glob, "/")
+		}
+		if strings.Contains(p.glob, "/") {
+			p.anchored = true
+			p.glob = strings.TrimPrefix(p.glob, "/")
+		}
+		if p.glob == "" {
+			continue
+		}
+		if _, err := filepath.Match(p.glob, ""); err != nil {
+			return nil, fmt.Errorf("invalid exclude pattern %q", line)
+		}
+		m.patterns = append(m.patterns, p)
+	}
+	return m, nil
+}
+
+// readIgnoreLines returns the patterns of an ignore file, skipping blank
+// lines and # comments
+func readIgnoreLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// excluded reports whether rel (slash-separated, relative to the copied
+// directory) is skipped
+func (m *excludeMatcher) excluded(rel string, isDir bool) bool {
+	name := filepath.Base(rel)
+	for _, p := range m.patterns {
+		if p.dirOnly && !isDir {
+			continue
+		}
+		target := name
+		if p.anchored {
+			target = rel
+		}
+		if ok, _ := filepath.Match(p.glob, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// stageFiltered builds a copy of the directory src without excluded paths
+// (and without the ignore file itself) in a temporary directory. Files are
+// hard-linked when possible. The staged copy keeps src's base name; the
+// caller removes the returned temp directory.
+func stageFiltered(src string, m *excludeMatcher) (tempDir, staged string, err error) {
+	tempDir, err = os.MkdirTemp("", "lxc-copy-")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	staged = filepath.Join(tempDir, filepath.Base(src))
+
+	err = filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		dest := filepath.Join(staged, rel)
+
+		if rel != "." {
+			rel = filepath.ToSlash(rel)
+			if rel == IgnoreFile || m.excluded(rel, d.IsDir()) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(dest, info.Mode().Perm())
+		case d.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(target, dest)
+		case d.Type().IsRegular():
+			if os.Link(p, dest) == nil {
+				return nil
+			}
+			return copyFile(p, dest, info.Mode().Perm())
+		default:
+			// Sockets, fifos and devices aren't copied
+			return nil
+		}
+	})
+	if err != nil {
+		os.RemoveAll(tempDir)
+		return "", "", fmt.Errorf("failed to prepare filtered copy: %w", err)
+	}
+	return tempDir, staged, nil
+}
+
+// copyFile copies a regular file's content
+func copyFile(src, dest string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
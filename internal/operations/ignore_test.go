@@ -0,0 +1,94 @@
+package operations
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExcludeMatcher(t *testing.T) {
+	m, err := loadExcludes(t.TempDir(), []string{"node_modules", "*.log", "/build", "docs/*.pdf", "cache/"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		rel   string
+		isDir bool
+		want  bool
+	}{
+		{"node_modules", true, true},
+		{"web/node_modules", true, true},
+		{"debug.log", false, true},
+		{"logs/app.log", false, true},
+		{"build", true, true},
+		{"src/build", true, false},
+		{"docs/guide.pdf", false, true},
+		{"docs/guide.md", false, false},
+		{"cache", true, true},
+		{"cache", false, false},
+		{"src/main.go", false, false},
+	}
+
+	for _, tt := range tests {
+		if got := m.excluded(tt.rel, tt.isDir); got != tt.want {
+			t.Errorf("excluded(%q, dir=%v) = %v, want %v", tt.rel, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestLoadExcludes_NoPatterns(t *testing.T) {
+	m, err := loadExcludes(t.TempDir(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m != nil {
+		t.Error("expected no matcher without patterns")
+	}
+}
+
+func TestLoadExcludes_InvalidPattern(t *testing.T) {
+	if _, err := loadExcludes(t.TempDir(), []string{"[abc"}); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+}
+
+func TestStageFiltered(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "app")
+	os.MkdirAll(filepath.Join(src, "node_modules", "dep"), 0755)
+	os.MkdirAll(filepath.Join(src, ".git"), 0755)
+	os.MkdirAll(filepath.Join(src, "src"), 0755)
+	os.WriteFile(filepath.Join(src, "node_modules", "dep", "index.js"), []byte("x"), 0644)
+	os.WriteFile(filepath.Join(src, ".git", "HEAD"), []byte("ref"), 0644)
+	os.WriteFile(filepath.Join(src, "src", "main.go"), []byte("package main"), 0644)
+	os.WriteFile(filepath.Join(src, ".env"), []byte("KEY=1"), 0600)
+	os.WriteFile(filepath.Join(src, IgnoreFile), []byte("# deps\nnode_modules\n\n.git/\n"), 0644)
+
+	m, err := loadExcludes(src, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tempDir, staged, err := stageFiltered(src, m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	if filepath.Base(staged) != "app" {
+		t.Errorf("expected staged copy to keep base name, got %s", staged)
+	}
+	for _, rel := range []string{"src/main.go", ".env"} {
+		if _, err := os.Stat(filepath.Join(staged, rel)); err != nil {
+			t.Errorf("expected %s to be copied: %v", rel, err)
+		}
+	}
+	for _, rel := range []string{"node_modules", ".git", IgnoreFile} {
+		if _, err := os.Stat(filepath.Join(staged, rel)); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be excluded", rel)
+		}
+	}
+	if info, err := os.Stat(filepath.Join(staged, ".env")); err == nil && info.Mode().Perm() != 0600 {
+		t.Errorf("expected .env to keep mode 0600, got %v", info.Mode().Perm())
+	}
+}
@@ -3,97 +3,254 @@ package operations
 import (
 	"fmt"
 	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/dryrun"
+	"lxc-dev-manager/internal/errcode"
 	"lxc-dev-manager/internal/lxc"
+	"lxc-dev-manager/internal/tracing"
+	"lxc-dev-manager/internal/validation"
 )
 
-// ListImages returns all local images
+// ListImages returns local images sorted by alias. Cached images (pulled
+// automatically, without an alias) are only included when all is set.
 func ListImages(all bool) ([]ImageInfo, error) {
-	images, err := lxc.ListImages(all)
+	images, err := lxc.ListImageDetails()
 	if err != nil {
 		return nil, err
 	}
 
 	var result []ImageInfo
 	for _, img := range images {
-		result = append(result, ImageInfo{
-			Alias:       img.Alias,
-			Fingerprint: img.Fingerprint,
-			Size:        img.Size,
-			Description: img.Description,
-		})
+		if !all && len(img.Aliases) == 0 {
+			continue
+		}
+		result = append(result, newImageInfo(img))
 	}
 
+	SortImages(result, "name")
 	return result, nil
 }
 
-// CreateImage creates an image from a container
-func CreateImage(cfg *config.Config, containerName, imageName string, stdout, stderr io.Writer) error {
+// GetImageInfo returns the image with the given alias, or whose
+// fingerprint starts with name
+func GetImageInfo(name string) (*ImageInfo, error) {
+	images, err := lxc.ListImageDetails()
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []lxc.ImageDetail
+	for _, img := range images {
+		for _, alias := range img.Aliases {
+			if alias == name {
+				info := newImageInfo(img)
+				return &info, nil
+			}
+		}
+		if strings.HasPrefix(img.Fingerprint, name) {
+			matches = append(matches, img)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, errcode.New(errcode.NotFound, "", kindErrorf(ErrImageNotFound, "image '%s' not found", name))
+	case 1:
+		info := newImageInfo(matches[0])
+		return &info, nil
+	default:
+		return nil, errcode.Errorf(errcode.Usage, "", "fingerprint '%s' matches %d images; give more characters", name, len(matches))
+	}
+}
+
+func newImageInfo(img lxc.ImageDetail) ImageInfo {
+	info := ImageInfo{
+		Aliases:         img.Aliases,
+		Fingerprint:     img.Fingerprint,
+		Size:            validation.FormatSize(img.Size),
+		SizeBytes:       img.Size,
+		Description:     img.Properties["description"],
+		Architecture:    img.Architecture,
+		Public:          img.Public,
+		Cached:          img.Cached,
+		CreatedAt:       img.CreatedAt,
+		LastUsedAt:      img.LastUsedAt,
+		ExpiresAt:       img.ExpiresAt,
+		SourceContainer: img.Properties[lxc.SourceContainerProperty],
+		Properties:      img.Properties,
+	}
+	if info.Aliases == nil {
+		info.Aliases = []string{}
+	}
+	if info.Properties == nil {
+		info.Properties = map[string]string{}
+	}
+	if len(img.Aliases) > 0 {
+		info.Alias = img.Aliases[0]
+	}
+	return info
+}
+
+// ImageSortKeys are the values SortImages accepts
+var ImageSortKeys = []string{"name", "size", "created"}
+
+// SortImages sorts images in place by name, size (largest first) or
+// created (newest first). Cached images without an alias sort by
+// fingerprint after the named ones.
+func SortImages(images []ImageInfo, by string) error {
+	var less func(a, b ImageInfo) bool
+	switch by {
+	case "", "name":
+		less = func(a, b ImageInfo) bool {
+			if (a.Alias == "") != (b.Alias == "") {
+				return a.Alias != ""
+			}
+			if a.Alias != b.Alias {
+				return a.Alias < b.Alias
+			}
+			return a.Fingerprint < b.Fingerprint
+		}
+	case "size":
+		less = func(a, b ImageInfo) bool { return a.SizeBytes > b.SizeBytes }
+	case "created":
+		less = func(a, b ImageInfo) bool { return a.CreatedAt.After(b.CreatedAt) }
+	default:
+		return errcode.Errorf(errcode.Usage, "", "invalid sort key '%s' (use %s)", by, strings.Join(ImageSortKeys, ", "))
+	}
+	sort.SliceStable(images, func(i, j int) bool { return less(images[i], images[j]) })
+	return nil
+}
+
+// FilterImages returns the images with an alias, fingerprint prefix,
+// description or architecture containing filter, ignoring case
+func FilterImages(images []ImageInfo, filter string) []ImageInfo {
+	if filter == "" {
+		return images
+	}
+	filter = strings.ToLower(filter)
+
+	var result []ImageInfo
+	for _, img := range images {
+		fields := append([]string{img.Description, img.Architecture}, img.Aliases...)
+		match := strings.HasPrefix(strings.ToLower(img.Fingerprint), filter)
+		for _, field := range fields {
+			match = match || strings.Contains(strings.ToLower(field), filter)
+		}
+		if match {
+			result = append(result, img)
+		}
+	}
+	return result
+}
+
+// CreateImage creates an image from a container. The image is published
+// from a snapshot, so a running container keeps running; the snapshot is
+// then crash-consistent, like pulling the plug. With opts.Stop, a running
+// container is stopped for the snapshot and restarted right after it, so
+// the image gets a cleanly shut down filesystem.
+func CreateImage(cfg *config.Config, containerName, imageName string, opts CreateImageOpts, stdout, stderr io.Writer) (err error) {
+	defer InvalidateInventory(cfg)
+	defer tracing.Start("operations.CreateImage", "container", containerName, "image", imageName).EndErr(&err)
+
 	if !cfg.HasContainer(containerName) {
-		return fmt.Errorf("container '%s' not found in config", containerName)
+		return containerNotFound(containerName)
+	}
+
+	publish, err := publishOpts(opts, time.Now())
+	if err != nil {
+		return err
 	}
 
 	lxcName := cfg.GetLXCName(containerName)
 	if !lxc.Exists(lxcName) {
-		return fmt.Errorf("container '%s' does not exist in LXC", lxcName)
+		return containerNotInLXC(containerName, lxcName)
 	}
 
 	snapshotName := fmt.Sprintf("snapshot-%d", time.Now().Unix())
 
-	// Check if running, stop if so
+	// Check if running, stop if asked to
 	status, err := lxc.GetStatus(lxcName)
 	if err != nil {
 		return err
 	}
 
-	wasRunning := status == "RUNNING"
+	stopped := opts.Stop && status == "RUNNING"
+
+	progress := func(step string) {
+		slog.Debug("image create", "container", containerName, "image", imageName, "step", step)
+		if opts.Progress != nil {
+			opts.Progress(step)
+		}
+	}
 
-	// Stop container if running
-	if wasRunning {
-		if err := lxc.Stop(lxcName); err != nil {
+	if stopped {
+		progress("stopping")
+		if err := lxc.StopWithTimeout(lxcName, stopTimeout(cfg, containerName), false); err != nil {
 			return err
 		}
 	}
 
 	// Create snapshot (instant with ZFS/btrfs)
-	if err := lxc.Snapshot(lxcName, snapshotName); err != nil {
-		// Try to restart if it was running
-		if wasRunning {
-			lxc.Start(lxcName)
+	progress("creating snapshot")
+	err = lxc.Snapshot(lxcName, snapshotName)
+
+	// Publishing reads the snapshot, so the container can be restarted now
+	if stopped {
+		progress("restarting")
+		if startErr := lxc.Start(lxcName); startErr != nil && err == nil {
+			lxc.DeleteSnapshot(lxcName, snapshotName)
+			return fmt.Errorf("failed to restart container: %w", startErr)
 		}
+	}
+	if err != nil {
 		return err
 	}
 
 	// Publish snapshot as image
-	err = lxc.PublishSnapshotWithProgress(lxcName, snapshotName, imageName, stdout, stderr)
+	progress("publishing")
+	err = lxc.PublishSnapshotWithProgress(lxcName, snapshotName, imageName, publish, stdout, stderr)
 
 	// Clean up snapshot regardless of publish result
 	lxc.DeleteSnapshot(lxcName, snapshotName)
 
-	if err != nil {
-		// Try to restart if it was running
-		if wasRunning {
-			lxc.Start(lxcName)
-		}
-		return err
+	return err
+}
+
+// publishOpts turns image creation options into publish metadata
+func publishOpts(opts CreateImageOpts, now time.Time) (lxc.PublishOpts, error) {
+	if opts.Expiry < 0 {
+		return lxc.PublishOpts{}, errcode.Errorf(errcode.Validation, "", "expiry must be positive")
 	}
 
-	// Restart if was running
-	if wasRunning {
-		if err := lxc.Start(lxcName); err != nil {
-			return fmt.Errorf("failed to restart container: %w", err)
+	properties := make(map[string]string, len(opts.Properties)+1)
+	for key, value := range opts.Properties {
+		if err := validation.ValidateImageProperty(key); err != nil {
+			return lxc.PublishOpts{}, err
 		}
+		properties[key] = value
+	}
+	if opts.Description != "" {
+		properties["description"] = opts.Description
 	}
 
-	return nil
+	publish := lxc.PublishOpts{Public: opts.Public, Properties: properties}
+	if opts.Expiry > 0 {
+		publish.ExpiresAt = now.Add(opts.Expiry)
+	}
+	return publish, nil
 }
 
 // DeleteImage deletes an image by alias
 func DeleteImage(name string) error {
 	if !lxc.ImageExists(name) {
-		return fmt.Errorf("image '%s' not found", name)
+		return kindErrorf(ErrImageNotFound, "image '%s' not found", name)
 	}
 
 	return lxc.DeleteImage(name)
@@ -102,11 +259,11 @@ func DeleteImage(name string) error {
 // RenameImage renames an image
 func RenameImage(oldName, newName string) error {
 	if !lxc.ImageExists(oldName) {
-		return fmt.Errorf("image '%s' not found", oldName)
+		return kindErrorf(ErrImageNotFound, "image '%s' not found", oldName)
 	}
 
 	if lxc.ImageExists(newName) {
-		return fmt.Errorf("image '%s' already exists", newName)
+		return kindErrorf(ErrImageExists, "image '%s' already exists", newName)
 	}
 
 	return lxc.RenameImage(oldName, newName)
@@ -116,3 +273,214 @@ func RenameImage(oldName, newName string) error {
 func ImageExists(name string) bool {
 	return lxc.ImageExists(name)
 }
+
+// SplitRootfsSuffix is appended to an export path for the rootfs of a
+// split image, whose metadata is written to the path itself
+const SplitRootfsSuffix = ".rootfs"
+
+// ExportImage writes an image to path so it can be imported on another
+// machine. Split images produce a second file, path+SplitRootfsSuffix.
+// Returns the files written.
+func ExportImage(alias, path string, force bool) ([]string, error) {
+	if !lxc.ImageExists(alias) {
+		return nil, errcode.New(errcode.NotFound, "", kindErrorf(ErrImageNotFound, "image '%s' not found", alias))
+	}
+	if !force {
+		for _, p := range []string{path, path + SplitRootfsSuffix} {
+			if _, err := os.Stat(p); err == nil {
+				return nil, errcode.Errorf(errcode.Validation, "", "%s already exists (use --force to overwrite)", p)
+			}
+		}
+	}
+
+	// Export next to the destination so the files can be renamed into place
+	tmpDir, err := os.MkdirTemp(filepath.Dir(path), ".lxc-dev-manager-export-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := lxc.ExportImage(alias, tmpDir); err != nil {
+		return nil, err
+	}
+	if dryrun.Enabled() {
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		return nil, err
+	}
+	var meta, rootfs string
+	switch len(entries) {
+	case 1:
+		meta = entries[0].Name()
+	case 2:
+		// LXD names the metadata tarball meta-<fingerprint>
+		meta, rootfs = entries[0].Name(), entries[1].Name()
+		if strings.HasPrefix(rootfs, "meta-") {
+			meta, rootfs = rootfs, meta
+		}
+	default:
+		return nil, fmt.Errorf("unexpected export output: %d files", len(entries))
+	}
+
+	written := []string{path}
+	if err := os.Rename(filepath.Join(tmpDir, meta), path); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if rootfs != "" {
+		if err := os.Rename(filepath.Join(tmpDir, rootfs), path+SplitRootfsSuffix); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", path+SplitRootfsSuffix, err)
+		}
+		written = append(written, path+SplitRootfsSuffix)
+	}
+	return written, nil
+}
+
+// ImportImage imports an exported image under alias. rootfs is only needed
+// for split images; it defaults to path+SplitRootfsSuffix when that exists.
+// With force an existing image with the alias is replaced.
+func ImportImage(path, rootfs, alias string, force bool) error {
+	if alias == "" {
+		return errcode.Errorf(errcode.Usage, "", "an alias is required (--alias)")
+	}
+	if _, err := os.Stat(path); err != nil {
+		return errcode.New(errcode.NotFound, "", err)
+	}
+	if rootfs == "" {
+		if _, err := os.Stat(path + SplitRootfsSuffix); err == nil {
+			rootfs = path + SplitRootfsSuffix
+		}
+	} else if _, err := os.Stat(rootfs); err != nil {
+		return errcode.New(errcode.NotFound, "", err)
+	}
+
+	if lxc.ImageExists(alias) {
+		if !force {
+			return errcode.New(errcode.Validation, "", kindErrorf(ErrImageExists, "image '%s' already exists (use --force to replace it)", alias))
+		}
+		if err := lxc.DeleteImage(alias); err != nil {
+			return err
+		}
+	}
+
+	files := []string{path}
+	if rootfs != "" {
+		files = append(files, rootfs)
+	}
+	return lxc.ImportImage(alias, files...)
+}
+
+// PushImage copies a local image to an LXC remote under the same alias,
+// so other machines can pull it through image_remote
+func PushImage(alias, remote string) error {
+	if err := validation.ValidateRemoteName(remote); err != nil {
+		return errcode.New(errcode.Validation, "", err)
+	}
+	if !lxc.ImageExists(alias) {
+		return errcode.New(errcode.NotFound, "", kindErrorf(ErrImageNotFound, "image '%s' not found", alias))
+	}
+	return lxc.CopyImage(alias, remote, alias)
+}
+
+// pullImage copies image from the project's image_remote when it names an
+// alias (no "remote:" prefix) that isn't available locally. progress is
+// called before pulling.
+func pullImage(cfg *config.Config, image string, progress func(step string)) error {
+	if cfg.ImageRemote == "" || strings.Contains(image, ":") || lxc.ImageExists(image) {
+		return nil
+	}
+	progress("pulling image from " + cfg.ImageRemote)
+	if err := lxc.CopyImage(cfg.ImageRemote+":"+image, "local", image); err != nil {
+		return fmt.Errorf("image '%s' is not local and pulling it from %s failed: %w", image, cfg.ImageRemote, err)
+	}
+	return nil
+}
+
+// PrunableImage is an image prune would delete
+type PrunableImage struct {
+	lxc.ImageDetail
+	Age time.Duration // Since it was last used, or created if never used
+}
+
+// Name returns the image's first alias, or its short fingerprint
+func (p PrunableImage) Name() string {
+	if len(p.Aliases) > 0 {
+		return p.Aliases[0]
+	}
+	if len(p.Fingerprint) > 12 {
+		return p.Fingerprint[:12]
+	}
+	return p.Fingerprint
+}
+
+// FindPrunableImages returns local images no container was created from
+// and that haven't been used for olderThan. Containers of every project
+// count, since they're all LXC instances on this host; aliases named in
+// cfg's containers.yaml (which may be nil) are kept too, so images
+// waiting to be used by the project aren't removed.
+func FindPrunableImages(cfg *config.Config, olderThan time.Duration, now time.Time) ([]PrunableImage, error) {
+	images, err := lxc.ListImageDetails()
+	if err != nil {
+		return nil, err
+	}
+	used, err := lxc.InstanceBaseImages()
+	if err != nil {
+		return nil, err
+	}
+
+	referenced := map[string]bool{}
+	if cfg != nil {
+		for _, container := range cfg.Containers {
+			referenced[container.Image] = true
+		}
+	}
+
+	var prunable []PrunableImage
+	for _, img := range images {
+		if len(used[img.Fingerprint]) > 0 {
+			continue
+		}
+		keep := false
+		for _, alias := range img.Aliases {
+			keep = keep || referenced[alias]
+		}
+		if keep {
+			continue
+		}
+
+		last := img.CreatedAt
+		if img.LastUsedAt.After(last) {
+			last = img.LastUsedAt
+		}
+		age := now.Sub(last)
+		if age < olderThan {
+			continue
+		}
+		prunable = append(prunable, PrunableImage{ImageDetail: img, Age: age})
+	}
+
+	sort.Slice(prunable, func(i, j int) bool { return prunable[i].Age > prunable[j].Age })
+	return prunable, nil
+}
+
+// PruneImages deletes images found by FindPrunableImages, continuing past
+// failures. Returns the bytes freed and the failures keyed by image name.
+func PruneImages(images []PrunableImage) (int64, map[string]error) {
+	var freed int64
+	failed := make(map[string]error)
+	for _, img := range images {
+		// Deleting by alias also drops the alias from the image cache
+		target := img.Fingerprint
+		if len(img.Aliases) > 0 {
+			target = img.Aliases[0]
+		}
+		if err := lxc.DeleteImage(target); err != nil {
+			failed[img.Name()] = err
+			continue
+		}
+		freed += img.Size
+	}
+	return freed, failed
+}
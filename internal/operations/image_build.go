@@ -0,0 +1,170 @@
+package operations
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"lxc-dev-manager/internal/errcode"
+	"lxc-dev-manager/internal/imagespec"
+	"lxc-dev-manager/internal/lxc"
+	"lxc-dev-manager/internal/tracing"
+)
+
+// BuildImageOpts contains options for building an image from a spec
+type BuildImageOpts struct {
+	Alias string // Overrides the spec's alias
+	Force bool   // Replace an existing image with the same alias
+	// StopTimeout is how long the build container gets to shut down before
+	// publishing; lxc.DefaultStopTimeout if zero
+	StopTimeout time.Duration
+	// Progress, if set, is called as each build step starts
+	Progress func(step string)
+}
+
+// installPackagesScript installs packages with whichever package manager
+// the base image has
+const installPackagesScript = `set -e
+if command -v apt-get >/dev/null; then
+	export DEBIAN_FRONTEND=noninteractive
+	apt-get update -qq
+	apt-get install -y -qq %[1]s
+elif command -v dnf >/dev/null; then
+	dnf install -y -q %[1]s
+elif command -v apk >/dev/null; then
+	apk add --no-cache %[1]s
+elif command -v pacman >/dev/null; then
+	pacman -Sy --noconfirm %[1]s
+else
+	echo "no supported package manager found" >&2
+	exit 1
+fi`
+
+// BuildImage launches a temporary container from the spec's base image,
+// installs its packages, copies its files, runs its commands as root and
+// publishes the result under the alias. The temporary container is
+// deleted whether or not the build succeeds.
+func BuildImage(spec *imagespec.Spec, opts BuildImageOpts) (_ string, err error) {
+	alias := opts.Alias
+	if alias == "" {
+		alias = spec.Alias
+	}
+	defer tracing.Start("operations.BuildImage", "image", alias).EndErr(&err)
+	if alias == "" {
+		return "", errcode.Errorf(errcode.Validation, "", "image alias is required (set alias in the spec or pass --alias)")
+	}
+	if lxc.ImageExists(alias) && !opts.Force {
+		return "", errcode.New(errcode.Validation, "", kindErrorf(ErrImageExists, "image '%s' already exists (use --force to replace it)", alias))
+	}
+	for _, f := range spec.Files {
+		if _, err := os.Stat(f.Source); err != nil {
+			return "", errcode.New(errcode.Validation, "", fmt.Errorf("file source: %w", err))
+		}
+	}
+
+	var phase *tracing.Span
+	defer func() { phase.End(err) }()
+	progress := func(step string) {
+		slog.Debug("image build", "alias", alias, "step", step)
+		phase.End(nil)
+		phase = tracing.Start("build: "+step, "image", alias)
+		if opts.Progress != nil {
+			opts.Progress(step)
+		}
+	}
+
+	buildName := fmt.Sprintf("lxc-dev-manager-build-%d", time.Now().Unix())
+	progress("launching " + spec.Base)
+	if err := lxc.Launch(buildName, spec.Base); err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := lxc.Delete(buildName); err != nil {
+			slog.Warn("failed to delete build container", "container", buildName, "error", err)
+		}
+	}()
+
+	progress("waiting for boot")
+	if err := lxc.WaitForReady(buildName, 60*time.Second); err != nil {
+		return "", err
+	}
+
+	if len(spec.Packages) > 0 {
+		progress("installing packages")
+		if err := lxc.ExecScript(buildName, fmt.Sprintf(installPackagesScript, strings.Join(spec.Packages, " "))); err != nil {
+			return "", fmt.Errorf("failed to install packages: %w", err)
+		}
+	}
+
+	for _, f := range spec.Files {
+		progress("copying " + f.Dest)
+		if err := pushBuildFile(buildName, f); err != nil {
+			return "", err
+		}
+	}
+
+	for i, command := range spec.Run {
+		progress(fmt.Sprintf("running command %d/%d", i+1, len(spec.Run)))
+		if err := lxc.ExecScript(buildName, "set -e\n"+command); err != nil {
+			return "", fmt.Errorf("run command %q failed: %w", command, err)
+		}
+	}
+
+	progress("stopping")
+	timeout := opts.StopTimeout
+	if timeout <= 0 {
+		timeout = lxc.DefaultStopTimeout
+	}
+	if err := lxc.StopWithTimeout(buildName, timeout, false); err != nil {
+		return "", err
+	}
+
+	if opts.Force && lxc.ImageExists(alias) {
+		if err := lxc.DeleteImage(alias); err != nil {
+			return "", err
+		}
+	}
+	progress("publishing " + alias)
+	if err := lxc.Publish(buildName, alias); err != nil {
+		return "", err
+	}
+	return alias, nil
+}
+
+// pushBuildFile copies a host file or directory to its destination in the
+// build container, creating parent directories
+func pushBuildFile(buildName string, f imagespec.File) error {
+	info, err := os.Stat(f.Source)
+	if err != nil {
+		return err
+	}
+
+	parent := filepath.Dir(f.Dest)
+	if err := lxc.Exec(buildName, "mkdir", "-p", parent); err != nil {
+		return fmt.Errorf("failed to create %s: %w", parent, err)
+	}
+
+	if info.IsDir() {
+		// A recursive push creates the source's basename inside the target
+		if err := lxc.FilePush(buildName, f.Source, parent+"/", true); err != nil {
+			return err
+		}
+		if pushed := filepath.Join(parent, filepath.Base(f.Source)); pushed != filepath.Clean(f.Dest) {
+			if err := lxc.Exec(buildName, "mv", "-T", pushed, f.Dest); err != nil {
+				return fmt.Errorf("failed to move %s to %s: %w", pushed, f.Dest, err)
+			}
+		}
+	} else if err := lxc.FilePush(buildName, f.Source, f.Dest, false); err != nil {
+		return err
+	}
+
+	if f.Mode != "" {
+		if err := lxc.Exec(buildName, "chmod", f.Mode, f.Dest); err != nil {
+			return fmt.Errorf("failed to set mode of %s: %w", f.Dest, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package operations
+
+import (
+	"testing"
+	"time"
+)
+
+func testImages() []ImageInfo {
+	now := time.Now()
+	return []ImageInfo{
+		{Alias: "web", Aliases: []string{"web"}, Fingerprint: "bbb", SizeBytes: 100, Architecture: "x86_64", CreatedAt: now.Add(-2 * time.Hour)},
+		{Fingerprint: "ccc", SizeBytes: 300, Architecture: "x86_64", Description: "Ubuntu noble", CreatedAt: now},
+		{Alias: "api", Aliases: []string{"api", "backend"}, Fingerprint: "aaa", SizeBytes: 200, Architecture: "aarch64", CreatedAt: now.Add(-time.Hour)},
+	}
+}
+
+func imageFingerprints(images []ImageInfo) string {
+	var s string
+	for _, img := range images {
+		s += img.Fingerprint + " "
+	}
+	return s
+}
+
+func TestSortImages(t *testing.T) {
+	tests := []struct {
+		by   string
+		want string
+	}{
+		{"name", "aaa bbb ccc "},
+		{"size", "ccc aaa bbb "},
+		{"created", "ccc aaa bbb "},
+	}
+	for _, tt := range tests {
+		images := testImages()
+		if err := SortImages(images, tt.by); err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.by, err)
+		}
+		if got := imageFingerprints(images); got != tt.want {
+			t.Errorf("sort by %s: got %q, want %q", tt.by, got, tt.want)
+		}
+	}
+
+	if err := SortImages(testImages(), "age"); err == nil {
+		t.Error("expected error for unknown sort key")
+	}
+}
+
+func TestFilterImages(t *testing.T) {
+	tests := []struct {
+		filter string
+		want   string
+	}{
+		{"", "bbb ccc aaa "},
+		{"backend", "aaa "},
+		{"AARCH", "aaa "},
+		{"noble", "ccc "},
+		{"bb", "bbb "},
+		{"nothing", ""},
+	}
+	for _, tt := range tests {
+		if got := imageFingerprints(FilterImages(testImages(), tt.filter)); got != tt.want {
+			t.Errorf("filter %q: got %q, want %q", tt.filter, got, tt.want)
+		}
+	}
+}
+
+func TestPublishOpts(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	publish, err := publishOpts(CreateImageOpts{
+		Description: "PR 1234 repro",
+		Public:      true,
+		Expiry:      14 * 24 * time.Hour,
+		Properties:  map[string]string{"node": "20", "description": "overridden"},
+	}, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !publish.Public {
+		t.Error("expected public image")
+	}
+	if want := now.Add(14 * 24 * time.Hour); !publish.ExpiresAt.Equal(want) {
+		t.Errorf("expected expiry %v, got %v", want, publish.ExpiresAt)
+	}
+	if publish.Properties["description"] != "PR 1234 repro" || publish.Properties["node"] != "20" {
+		t.Errorf("unexpected properties: %v", publish.Properties)
+	}
+
+	publish, err = publishOpts(CreateImageOpts{}, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !publish.ExpiresAt.IsZero() || publish.Public || len(publish.Properties) != 0 {
+		t.Errorf("expected no metadata, got %+v", publish)
+	}
+
+	if _, err := publishOpts(CreateImageOpts{Properties: map[string]string{"source_container": "x"}}, now); err == nil {
+		t.Error("expected error for reserved property")
+	}
+}
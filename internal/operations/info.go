@@ -0,0 +1,71 @@
+package operations
+
+import (
+	"sort"
+
+	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/lxc"
+)
+
+// Info gathers configuration and live state for a container. Only a missing
+// container is an error; details LXC can't report are left empty.
+func Info(cfg *config.Config, name string) (*ContainerDetails, error) {
+	if !cfg.HasContainer(name) {
+		return nil, containerNotFound(name)
+	}
+
+	lxcName := cfg.GetLXCName(name)
+	if !lxc.Exists(lxcName) {
+		return nil, containerNotInLXC(name, lxcName)
+	}
+
+	container := cfg.Containers[name]
+	details := &ContainerDetails{
+		Name:        name,
+		LXCName:     lxcName,
+		Image:       container.Image,
+		Description: container.Description,
+		Ports:       cfg.GetPorts(name),
+		User:        cfg.GetUser(name).Name,
+		Sync:        container.Sync,
+		Disk:        container.Disk,
+	}
+
+	status, err := lxc.GetStatus(lxcName)
+	if err != nil {
+		return nil, err
+	}
+	details.Status = status
+
+	if instance, err := lxc.GetInstanceDetails(lxcName); err == nil {
+		details.Limits = instance.Limits
+		if details.Disk == "" {
+			details.Disk = instance.RootSize
+		}
+		if status == "RUNNING" {
+			details.StartedAt = instance.LastUsedAt
+		}
+	}
+
+	if status == "RUNNING" {
+		details.IP, _ = lxc.GetIP(lxcName)
+		details.IPv6, _ = lxc.GetIPv6(lxcName)
+
+		if sockets, err := lxc.ListeningPorts(lxcName); err == nil {
+			seen := make(map[int]bool)
+			for _, s := range sockets {
+				if !seen[s.Port] {
+					seen[s.Port] = true
+					details.Listening = append(details.Listening, s.Port)
+				}
+			}
+			sort.Ints(details.Listening)
+		}
+	}
+
+	details.Mounts, _ = ListMounts(cfg, name)
+	details.Snapshots, _ = ListSnapshots(cfg, name)
+	details.DiskUsage, _ = lxc.GetDiskUsage(lxcName)
+
+	return details, nil
+}
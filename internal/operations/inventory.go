@@ -0,0 +1,46 @@
+package operations
+
+import (
+	"sync"
+
+	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/lxc"
+)
+
+// Inventories are cached per loaded config, so a command that looks at many
+// containers makes one "lxc list" call instead of several per container.
+var (
+	inventoryMu sync.Mutex
+	inventories = make(map[*config.Config]map[string]lxc.ContainerInfo)
+)
+
+// Inventory returns the LXC state of every instance keyed by LXC name. The
+// first call for a config runs a single "lxc list"; later calls reuse the
+// result until an operation that changes containers invalidates it.
+func Inventory(cfg *config.Config) (map[string]lxc.ContainerInfo, error) {
+	inventoryMu.Lock()
+	defer inventoryMu.Unlock()
+
+	if inv, ok := inventories[cfg]; ok {
+		return inv, nil
+	}
+
+	containers, err := lxc.ListAll()
+	if err != nil {
+		return nil, err
+	}
+	inv := make(map[string]lxc.ContainerInfo, len(containers))
+	for _, c := range containers {
+		inv[c.Name] = c
+	}
+	inventories[cfg] = inv
+	return inv, nil
+}
+
+// InvalidateInventory drops the cached inventory for cfg so the next
+// Inventory call queries LXC again
+func InvalidateInventory(cfg *config.Config) {
+	inventoryMu.Lock()
+	defer inventoryMu.Unlock()
+	delete(inventories, cfg)
+}
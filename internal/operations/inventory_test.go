@@ -0,0 +1,39 @@
+package operations
+
+import (
+	"testing"
+
+	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/lxc"
+)
+
+func TestInventory_CachedPerConfig(t *testing.T) {
+	mock := lxc.NewMockExecutor()
+	lxc.SetExecutor(mock)
+	defer lxc.ResetExecutor()
+	mock.SetOutput("list --format json", `[{"name":"test-dev1","status":"Running"}]`)
+
+	cfg := &config.Config{Project: "test", Containers: map[string]config.Container{"dev1": {Image: "ubuntu:24.04"}}}
+	defer InvalidateInventory(cfg)
+
+	for i := 0; i < 3; i++ {
+		inv, err := Inventory(cfg)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if inv["test-dev1"].Status != "RUNNING" {
+			t.Fatalf("unexpected inventory: %+v", inv)
+		}
+	}
+	if len(mock.Calls) != 1 {
+		t.Errorf("expected a single lxc list call, got %d", len(mock.Calls))
+	}
+
+	InvalidateInventory(cfg)
+	if _, err := Inventory(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mock.Calls) != 2 {
+		t.Errorf("expected invalidation to trigger a new lxc list call, got %d calls", len(mock.Calls))
+	}
+}
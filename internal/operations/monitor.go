@@ -0,0 +1,70 @@
+package operations
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/lxc"
+)
+
+// Crash is a container that stopped without being asked to: its init
+// process died, or it was shut down from inside
+type Crash struct {
+	Name   string
+	Action string // The lifecycle event, e.g. instance-stopped
+	Time   time.Time
+}
+
+// Monitor watches containers (all of the project's when names is empty)
+// until ctx is done. When a running one stops without an API request
+// behind it, the on_crash hook runs and onCrash is called. Stops made with
+// down, lxc stop and the like are not reported.
+func Monitor(ctx context.Context, cfg *config.Config, names []string, onCrash func(Crash)) error {
+	if len(names) == 0 {
+		names = sortedKeys(cfg.Containers)
+	}
+
+	watched := make(map[string]string) // LXC name -> container name
+	running := make(map[string]bool)
+	for _, name := range names {
+		if !cfg.HasContainer(name) {
+			return containerNotFound(name)
+		}
+		lxcName := cfg.GetLXCName(name)
+		watched[lxcName] = name
+		if status, err := lxc.GetStatus(lxcName); err == nil && status == "RUNNING" {
+			running[name] = true
+		}
+	}
+
+	return lxc.Monitor(ctx, func(event lxc.LifecycleEvent) {
+		name, ok := watched[event.Instance]
+		if !ok {
+			return
+		}
+
+		switch event.Action {
+		case "instance-started", "instance-restarted", "instance-resumed":
+			running[name] = true
+		case "instance-stopped", "instance-shutdown":
+			// A stop can be reported as both shutdown and stopped
+			if !running[name] {
+				return
+			}
+			running[name] = false
+			if event.Requested {
+				return
+			}
+
+			slog.Info("container stopped unexpectedly", "container", name, "action", event.Action)
+			if err := runHook(cfg, "on_crash", name, ""); err != nil {
+				slog.Warn("on_crash hook failed", "container", name, "error", err)
+			}
+			if onCrash != nil {
+				onCrash(Crash{Name: name, Action: event.Action, Time: event.Time})
+			}
+		}
+	})
+}
@@ -1,7 +1,9 @@
 package operations
 
 import (
+	"errors"
 	"fmt"
+	"path/filepath"
 	"sort"
 	"strings"
 
@@ -10,37 +12,77 @@ import (
 	"lxc-dev-manager/internal/validation"
 )
 
+// checkPrivilegedMount returns an error for mounts that privileged
+// containers, whose root is the host's root, can't have
+func checkPrivilegedMount(source string, readWrite bool) error {
+	if readWrite {
+		return kindErrorf(ErrPrivilegedMount, "read-write mounts are disabled for privileged containers")
+	}
+	if strings.HasPrefix(source, "/home") {
+		return kindErrorf(ErrPrivilegedMount, "mounting /home to privileged containers is blocked for security reasons")
+	}
+	return nil
+}
+
+// CheckMountPolicy returns an error when the system or user policy denies
+// mounting the resolved host path source, whatever the command-line flags
+func CheckMountPolicy(cfg *config.Config, source string, readWrite, risky bool) error {
+	policy, err := config.LoadPolicy()
+	if err != nil {
+		return err
+	}
+	return policy.CheckMount(source, cfg.ProjectDir(), readWrite, risky)
+}
+
 // Mount mounts a host directory into a container
 func Mount(cfg *config.Config, containerName, sourcePath, containerPath string, opts MountOpts) (string, error) {
 	if !cfg.HasContainer(containerName) {
-		return "", fmt.Errorf("container '%s' not found in config", containerName)
+		return "", containerNotFound(containerName)
 	}
 
 	lxcName := cfg.GetLXCName(containerName)
 	if !lxc.Exists(lxcName) {
-		return "", fmt.Errorf("container '%s' does not exist in LXC", lxcName)
+		return "", containerNotInLXC(containerName, lxcName)
 	}
 
-	// Validate source path
-	resolvedSource, warning, err := validation.ValidateSourcePath(sourcePath)
+	// Validate source path against the project's path policy
+	policy, err := cfg.PathPolicy()
+	if err != nil {
+		return "", err
+	}
+	resolvedSource, warning, err := policy.ValidateSourcePath(sourcePath)
 	if err != nil {
-		return "", fmt.Errorf("invalid source path: %w", err)
+		return "", kindErrorf(ErrInvalidSourcePath, "invalid source path: %w", err)
+	}
+
+	if err := CheckMountPolicy(cfg, resolvedSource, opts.ReadWrite, warning != ""); err != nil {
+		return "", withKind(ErrBlockedPath, err)
 	}
 
 	// Check risky path
 	if warning != "" && !opts.AllowRiskyPath {
-		return "", fmt.Errorf("risky path: %s", warning)
+		return "", kindErrorf(ErrRiskyPath, "risky path: %s", warning)
 	}
 
 	// Validate container path
 	if err := validation.ValidateContainerPath(containerPath); err != nil {
-		return "", fmt.Errorf("invalid container path: %w", err)
+		return "", kindErrorf(ErrInvalidContainerPath, "invalid container path: %w", err)
+	}
+
+	if opts.Propagation != "" {
+		if err := validation.ValidatePropagation(opts.Propagation); err != nil {
+			return "", err
+		}
 	}
 
 	// Generate mount name if not provided
 	deviceName := opts.Name
 	if deviceName == "" {
-		deviceName = validation.GenerateMountName(resolvedSource)
+		if opts.NoAutoSuffix {
+			deviceName = validation.GenerateMountName(resolvedSource)
+		} else {
+			deviceName = GenerateUniqueMountName(resolvedSource, cfg.GetDevices(containerName))
+		}
 	}
 
 	// Validate mount name
@@ -50,12 +92,16 @@ func Mount(cfg *config.Config, containerName, sourcePath, containerPath string,
 
 	// Check for name conflict
 	if cfg.HasDevice(containerName, deviceName) {
-		return "", fmt.Errorf("device '%s' already exists on container '%s'", deviceName, containerName)
+		return "", kindErrorf(ErrMountExists, "device '%s' already exists on container '%s'", deviceName, containerName)
 	}
 
 	// Check for path conflict
 	if existingName, found := cfg.FindDeviceByPath(containerName, containerPath); found {
-		return "", fmt.Errorf("container path '%s' is already mounted by device '%s'", containerPath, existingName)
+		return "", kindErrorf(ErrMountPathConflict, "container path '%s' is already mounted by device '%s'", containerPath, existingName)
+	}
+
+	if opts.Shift && opts.IDMap != "" {
+		return "", fmt.Errorf("use either shift or idmap, not both")
 	}
 
 	// Check privileged container restrictions
@@ -65,11 +111,25 @@ func Mount(cfg *config.Config, containerName, sourcePath, containerPath string,
 	}
 
 	if privileged {
-		if opts.ReadWrite {
-			return "", fmt.Errorf("read-write mounts are disabled for privileged containers")
+		if err := checkPrivilegedMount(resolvedSource, opts.ReadWrite); err != nil {
+			return "", err
 		}
-		if strings.HasPrefix(resolvedSource, "/home") {
-			return "", fmt.Errorf("mounting /home to privileged containers is blocked for security reasons")
+		if opts.IDMap != "" {
+			return "", kindErrorf(ErrPrivilegedMount, "idmap has no effect on privileged containers")
+		}
+	}
+
+	// Resolve the UID/GID mapping
+	idmapEntry := opts.IDMap
+	if idmapEntry == IDMapAuto {
+		idmapEntry, err = SuggestIDMap(cfg, containerName, resolvedSource)
+		if err != nil {
+			return "", err
+		}
+	}
+	if idmapEntry != "" {
+		if err := validation.ValidateIDMapEntry(idmapEntry); err != nil {
+			return "", err
 		}
 	}
 
@@ -81,7 +141,13 @@ func Mount(cfg *config.Config, containerName, sourcePath, containerPath string,
 	if !opts.ReadWrite {
 		deviceConfig["readonly"] = "true"
 	}
-	if opts.Shift {
+	if opts.Recursive {
+		deviceConfig["recursive"] = "true"
+	}
+	if opts.Propagation != "" {
+		deviceConfig["propagation"] = opts.Propagation
+	}
+	if opts.Shift || autoShift(cfg, containerName, resolvedSource, privileged, idmapEntry, opts) {
 		deviceConfig["shift"] = "true"
 	}
 
@@ -90,6 +156,17 @@ func Mount(cfg *config.Config, containerName, sourcePath, containerPath string,
 		return "", fmt.Errorf("failed to add device to container: %w", err)
 	}
 
+	// Apply raw.idmap (takes effect on next start)
+	previousIDMap := cfg.GetIDMap(containerName)
+	idmapChanged := idmapEntry != "" && !HasIDMap(cfg, containerName, idmapEntry)
+	if idmapChanged {
+		cfg.AddIDMap(containerName, idmapEntry)
+		if err := lxc.SetRawIDMap(lxcName, cfg.GetIDMap(containerName)); err != nil {
+			lxc.DeviceRemove(lxcName, deviceName)
+			return "", fmt.Errorf("failed to set raw.idmap: %w", err)
+		}
+	}
+
 	// Add device to config
 	cfg.AddDevice(containerName, deviceName, config.Device{
 		Type:   "disk",
@@ -100,21 +177,60 @@ func Mount(cfg *config.Config, containerName, sourcePath, containerPath string,
 	if err := cfg.Save(); err != nil {
 		// Try to rollback LXC device if config save fails
 		lxc.DeviceRemove(lxcName, deviceName)
+		if idmapChanged {
+			lxc.SetRawIDMap(lxcName, previousIDMap)
+		}
 		return "", fmt.Errorf("failed to save config: %w", err)
 	}
 
 	return deviceName, nil
 }
 
+// GenerateUniqueMountName generates a mount name for source like
+// validation.GenerateMountName, suffixed with -2, -3, ... if needed so it
+// doesn't clash with any of the existing devices
+func GenerateUniqueMountName(source string, existing map[string]config.Device) string {
+	return uniqueMountName(validation.GenerateMountName(source), existing)
+}
+
+// uniqueMountName appends -2, -3, ... to name until it isn't in existing
+func uniqueMountName(name string, existing map[string]config.Device) string {
+	if _, ok := existing[name]; !ok {
+		return name
+	}
+	for i := 2; ; i++ {
+		suffix := fmt.Sprintf("-%d", i)
+		base := name
+		if len(base)+len(suffix) > validation.MaxMountNameLength {
+			base = strings.TrimSuffix(base[:validation.MaxMountNameLength-len(suffix)], "-")
+		}
+		candidate := base + suffix
+		if _, ok := existing[candidate]; !ok {
+			return candidate
+		}
+	}
+}
+
+// autoShift decides whether a rw mount needs shifting because the source is
+// owned by a host UID the container user doesn't map to. Detection errors
+// leave the mount unshifted rather than failing it.
+func autoShift(cfg *config.Config, containerName, source string, privileged bool, idmapEntry string, opts MountOpts) bool {
+	if !opts.ReadWrite || opts.NoAutoShift || privileged || idmapEntry != "" {
+		return false
+	}
+	mismatch, _, _, err := OwnershipMismatch(cfg, containerName, source)
+	return err == nil && mismatch
+}
+
 // Unmount removes a mount from a container
 func Unmount(cfg *config.Config, containerName, nameOrPath string) error {
 	if !cfg.HasContainer(containerName) {
-		return fmt.Errorf("container '%s' not found in config", containerName)
+		return containerNotFound(containerName)
 	}
 
 	lxcName := cfg.GetLXCName(containerName)
 	if !lxc.Exists(lxcName) {
-		return fmt.Errorf("container '%s' does not exist in LXC", lxcName)
+		return containerNotInLXC(containerName, lxcName)
 	}
 
 	// Determine if the argument is a path or a device name
@@ -124,7 +240,7 @@ func Unmount(cfg *config.Config, containerName, nameOrPath string) error {
 		var found bool
 		deviceName, found = cfg.FindDeviceByPath(containerName, nameOrPath)
 		if !found {
-			return fmt.Errorf("no device found with path '%s' in container '%s'", nameOrPath, containerName)
+			return kindErrorf(ErrMountNotFound, "no device found with path '%s' in container '%s'", nameOrPath, containerName)
 		}
 	} else {
 		deviceName = nameOrPath
@@ -132,7 +248,7 @@ func Unmount(cfg *config.Config, containerName, nameOrPath string) error {
 
 	// Verify device exists in config
 	if !cfg.HasDevice(containerName, deviceName) {
-		return fmt.Errorf("device '%s' not found in container '%s'", deviceName, containerName)
+		return kindErrorf(ErrMountNotFound, "device '%s' not found in container '%s'", deviceName, containerName)
 	}
 
 	// Remove device from LXC
@@ -152,12 +268,12 @@ func Unmount(cfg *config.Config, containerName, nameOrPath string) error {
 // ListMounts lists all mounts for a container
 func ListMounts(cfg *config.Config, containerName string) ([]MountInfo, error) {
 	if !cfg.HasContainer(containerName) {
-		return nil, fmt.Errorf("container '%s' not found in config", containerName)
+		return nil, containerNotFound(containerName)
 	}
 
 	lxcName := cfg.GetLXCName(containerName)
 	if !lxc.Exists(lxcName) {
-		return nil, fmt.Errorf("container '%s' does not exist in LXC", lxcName)
+		return nil, containerNotInLXC(containerName, lxcName)
 	}
 
 	// Get devices from config
@@ -233,12 +349,12 @@ func ListMounts(cfg *config.Config, containerName string) ([]MountInfo, error) {
 // SyncMounts synchronizes mounts between config and LXC
 func SyncMounts(cfg *config.Config, containerName string) error {
 	if !cfg.HasContainer(containerName) {
-		return fmt.Errorf("container '%s' not found in config", containerName)
+		return containerNotFound(containerName)
 	}
 
 	lxcName := cfg.GetLXCName(containerName)
 	if !lxc.Exists(lxcName) {
-		return fmt.Errorf("container '%s' does not exist in LXC", lxcName)
+		return containerNotInLXC(containerName, lxcName)
 	}
 
 	mounts, err := ListMounts(cfg, containerName)
@@ -282,3 +398,107 @@ func SyncMounts(cfg *config.Config, containerName string) error {
 
 	return nil
 }
+
+// ListAllMounts lists the mounts of every project container that exists in LXC
+func ListAllMounts(cfg *config.Config) ([]ContainerMountInfo, error) {
+	names := make([]string, 0, len(cfg.Containers))
+	for name := range cfg.Containers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	inv, err := Inventory(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []ContainerMountInfo
+	for _, name := range names {
+		if _, ok := inv[cfg.GetLXCName(name)]; !ok {
+			continue
+		}
+		mounts, err := ListMounts(cfg, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range mounts {
+			result = append(result, ContainerMountInfo{Container: name, MountInfo: m})
+		}
+	}
+	return result, nil
+}
+
+// FindMountsBySource returns container -> device name for every disk device
+// in config whose host source is sourcePath. The source doesn't need to
+// exist anymore (e.g. a directory that was moved or deleted).
+func FindMountsBySource(cfg *config.Config, sourcePath string) (map[string]string, error) {
+	candidates, err := sourceCandidates(sourcePath)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make(map[string]string)
+	for containerName, container := range cfg.Containers {
+		for deviceName, device := range container.Devices {
+			if device.Type != "disk" || device.Config["pool"] != "" {
+				continue
+			}
+			if candidates[filepath.Clean(device.Config["source"])] {
+				matches[containerName] = deviceName
+			}
+		}
+	}
+	return matches, nil
+}
+
+// UnmountSource unmounts a host directory from every container it is mounted
+// in. It keeps going on failures and returns the containers it unmounted from
+// along with a combined error.
+func UnmountSource(cfg *config.Config, sourcePath string) ([]string, error) {
+	matches, err := FindMountsBySource(cfg, sourcePath)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("'%s' is not mounted in any container", sourcePath)
+	}
+
+	var done []string
+	var errs []error
+	for _, containerName := range sortedKeys(matches) {
+		deviceName := matches[containerName]
+		if lxc.Exists(cfg.GetLXCName(containerName)) {
+			if err := Unmount(cfg, containerName, deviceName); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", containerName, err))
+				continue
+			}
+		} else {
+			// Container is gone from LXC; just drop the stale config entry
+			cfg.RemoveDevice(containerName, deviceName)
+			if err := cfg.Save(); err != nil {
+				errs = append(errs, fmt.Errorf("%s: failed to save config: %w", containerName, err))
+				continue
+			}
+		}
+		done = append(done, containerName)
+	}
+
+	return done, errors.Join(errs...)
+}
+
+// sourceCandidates returns the absolute and symlink-resolved forms of a path
+func sourceCandidates(sourcePath string) (map[string]bool, error) {
+	if sourcePath == "" {
+		return nil, fmt.Errorf("source path cannot be empty")
+	}
+	absPath, err := filepath.Abs(sourcePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	candidates := map[string]bool{filepath.Clean(absPath): true}
+	if resolved, err := filepath.EvalSymlinks(absPath); err == nil {
+		candidates[filepath.Clean(resolved)] = true
+	}
+	return candidates, nil
+}
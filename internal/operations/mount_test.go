@@ -0,0 +1,47 @@
+package operations
+
+import (
+	"strings"
+	"testing"
+
+	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/validation"
+)
+
+func TestGenerateUniqueMountName(t *testing.T) {
+	long := strings.Repeat("a", validation.MaxMountNameLength)
+	existing := map[string]config.Device{
+		"repo":   {Type: "disk"},
+		"repo-2": {Type: "disk"},
+		long:     {Type: "disk"},
+	}
+
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"/home/user/fresh", "fresh"},
+		{"/home/user/repo", "repo-3"},
+		{"/srv/" + long, long[:validation.MaxMountNameLength-2] + "-2"},
+		{"/srv/config", "mount-config"},
+	}
+
+	for _, tt := range tests {
+		got := GenerateUniqueMountName(tt.source, existing)
+		if got != tt.want {
+			t.Errorf("GenerateUniqueMountName(%q) = %q, want %q", tt.source, got, tt.want)
+		}
+		if len(got) > validation.MaxMountNameLength {
+			t.Errorf("GenerateUniqueMountName(%q) exceeds max length: %d", tt.source, len(got))
+		}
+		if err := validation.ValidateMountName(got); err != nil {
+			t.Errorf("GenerateUniqueMountName(%q) = %q is invalid: %v", tt.source, got, err)
+		}
+	}
+}
+
+func TestGenerateUniqueMountName_NoDevices(t *testing.T) {
+	if got := GenerateUniqueMountName("/home/user/repo", nil); got != "repo" {
+		t.Errorf("GenerateUniqueMountName() = %q, want %q", got, "repo")
+	}
+}
@@ -0,0 +1,136 @@
+package operations
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/lxc"
+	"lxc-dev-manager/internal/validation"
+)
+
+// MoveToProject moves a container from cfg to the project in dest. The LXC
+// container is renamed to the destination prefix and its config entry
+// (devices, snapshot metadata, sync entries) migrates to dest's
+// containers.yaml. A running container is stopped for the rename and
+// started again afterwards. Both configs should be locked by the caller.
+func MoveToProject(cfg, dest *config.Config, name string) error {
+	defer InvalidateInventory(cfg)
+	defer InvalidateInventory(dest)
+
+	if !cfg.HasContainer(name) {
+		return containerNotFound(name)
+	}
+
+	srcDir, _ := filepath.Abs(cfg.ProjectDir())
+	destDir, _ := filepath.Abs(dest.ProjectDir())
+	if srcDir == destDir {
+		return fmt.Errorf("container '%s' is already in this project", name)
+	}
+
+	if err := validation.ValidateFullContainerName(dest.LXCProject(), name); err != nil {
+		return err
+	}
+	if dest.HasContainer(name) {
+		return kindErrorf(ErrContainerExists, "container '%s' already exists in project '%s'", name, dest.Project)
+	}
+
+	original := cfg.Containers[name]
+	container := original
+	if container.IP != "" {
+		if other, taken := dest.FindContainerByIP(container.IP); taken {
+			return fmt.Errorf("IP %s is already assigned to container '%s' in project '%s'", container.IP, other, dest.Project)
+		}
+	}
+	for deviceName, device := range container.Devices {
+		if device.Config["pool"] != "" {
+			return fmt.Errorf("volume '%s' belongs to project '%s'; detach it before moving", deviceName, cfg.Project)
+		}
+	}
+
+	oldLXC := cfg.GetLXCName(name)
+	newLXC := dest.GetLXCName(name)
+	if !lxc.Exists(oldLXC) {
+		return containerNotInLXC(name, oldLXC)
+	}
+	if newLXC != oldLXC && lxc.Exists(newLXC) {
+		return kindErrorf(ErrContainerExists, "container '%s' already exists in LXC", newLXC)
+	}
+
+	status, err := lxc.GetStatus(oldLXC)
+	if err != nil {
+		return err
+	}
+	running := status == "RUNNING"
+
+	if newLXC != oldLXC {
+		if running {
+			if err := lxc.StopWithTimeout(oldLXC, stopTimeout(cfg, name), false); err != nil {
+				return err
+			}
+		}
+		if err := lxc.Rename(oldLXC, newLXC); err != nil {
+			if running {
+				lxc.Start(oldLXC)
+			}
+			return err
+		}
+	}
+
+	// Undo the rename if either config can't be written
+	rollback := func() {
+		if newLXC != oldLXC {
+			lxc.Rename(newLXC, oldLXC)
+			if running {
+				lxc.Start(oldLXC)
+			}
+		}
+	}
+
+	container.Sync = rebaseSyncEntries(container.Sync, srcDir, destDir)
+	container.DependsOn = nil // the dependencies stay in this project
+	if dest.Containers == nil {
+		dest.Containers = make(map[string]config.Container)
+	}
+	dest.Containers[name] = container
+	if err := dest.Save(); err != nil {
+		delete(dest.Containers, name)
+		rollback()
+		return fmt.Errorf("failed to save destination config: %w", err)
+	}
+
+	cfg.RemoveContainer(name)
+	if err := cfg.Save(); err != nil {
+		cfg.Containers[name] = original
+		delete(dest.Containers, name)
+		dest.Save()
+		rollback()
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	if running && newLXC != oldLXC {
+		if err := lxc.Start(newLXC); err != nil {
+			return fmt.Errorf("container moved, but %w", err)
+		}
+	}
+	return nil
+}
+
+// rebaseSyncEntries rewrites relative sync sources so they keep pointing at
+// the same host paths from the new project directory
+func rebaseSyncEntries(entries []config.SyncEntry, srcDir, destDir string) []config.SyncEntry {
+	rebased := make([]config.SyncEntry, len(entries))
+	for i, entry := range entries {
+		rebased[i] = entry
+		if filepath.IsAbs(entry.Source) {
+			continue
+		}
+		abs := filepath.Join(srcDir, entry.Source)
+		if rel, err := filepath.Rel(destDir, abs); err == nil {
+			rebased[i].Source = rel
+		} else {
+			rebased[i].Source = abs
+		}
+	}
+	return rebased
+}
@@ -0,0 +1,87 @@
+package operations
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/lxc"
+)
+
+// portDialTimeout bounds each host-side reachability probe
+const portDialTimeout = 2 * time.Second
+
+// CheckPorts reports, for each configured port of a running container,
+// whether something listens on it inside the container and whether it
+// can be reached from the host
+func CheckPorts(cfg *config.Config, name string, opts ProxyOpts) ([]PortCheck, string, error) {
+	if !cfg.HasContainer(name) {
+		return nil, "", containerNotFound(name)
+	}
+
+	lxcName := cfg.GetLXCName(name)
+	if !lxc.Exists(lxcName) {
+		return nil, "", containerNotInLXC(name, lxcName)
+	}
+
+	status, err := lxc.GetStatus(lxcName)
+	if err != nil {
+		return nil, "", err
+	}
+	if status != "RUNNING" {
+		return nil, "", containerNotRunning(name)
+	}
+
+	ports := cfg.GetPorts(name)
+	if len(ports) == 0 {
+		return nil, "", fmt.Errorf("no ports configured for container '%s'", name)
+	}
+
+	ip, err := proxyTargetIP(lxcName, opts.PreferIPv6 || cfg.Defaults.PreferIPv6)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get container IP: %w", err)
+	}
+
+	sockets, err := lxc.ListeningPorts(lxcName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	checks := make([]PortCheck, 0, len(ports))
+	for _, port := range ports {
+		check := PortCheck{Port: port}
+		for _, s := range sockets {
+			if s.Port == port {
+				check.Listening = true
+				check.Addresses = append(check.Addresses, s.Address)
+			}
+		}
+		check.LoopbackOnly = check.Listening && allLoopback(check.Addresses)
+
+		conn, err := net.DialTimeout("tcp", net.JoinHostPort(ip, strconv.Itoa(port)), portDialTimeout)
+		if err == nil {
+			conn.Close()
+			check.Reachable = true
+		}
+		checks = append(checks, check)
+	}
+
+	return checks, ip, nil
+}
+
+// allLoopback reports whether every address is a loopback address
+func allLoopback(addrs []string) bool {
+	for _, addr := range addrs {
+		if strings.EqualFold(addr, "localhost") {
+			continue
+		}
+		ip := net.ParseIP(addr)
+		if ip == nil || !ip.IsLoopback() {
+			return false
+		}
+	}
+	return len(addrs) > 0
+}
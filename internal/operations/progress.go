@@ -0,0 +1,100 @@
+package operations
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// progressInterval is how often a running copy is measured
+const progressInterval = 500 * time.Millisecond
+
+// CopyProgress describes how far a file copy has got
+type CopyProgress struct {
+	Total    int64 // Bytes to copy, 0 if unknown
+	Copied   int64 // Bytes found at the destination so far
+	Elapsed  time.Duration
+	Finished bool // Set on the final report once the copy has completed
+}
+
+// ETA estimates the remaining time from the average rate so far.
+// Returns 0 when it can't be estimated.
+func (p CopyProgress) ETA() time.Duration {
+	if p.Total <= 0 || p.Copied <= 0 || p.Copied >= p.Total {
+		return 0
+	}
+	rate := float64(p.Copied) / p.Elapsed.Seconds()
+	return time.Duration(float64(p.Total-p.Copied) / rate * float64(time.Second))
+}
+
+// Percent returns the completed fraction in percent, or -1 if the total is unknown
+func (p CopyProgress) Percent() float64 {
+	if p.Total <= 0 {
+		return -1
+	}
+	return float64(p.Copied) / float64(p.Total) * 100
+}
+
+// trackProgress runs copy while periodically calling measure and reporting
+// through cb. Without a callback the copy simply runs.
+func trackProgress(total int64, measure func() int64, cb func(CopyProgress), copy func() error) error {
+	if cb == nil {
+		return copy()
+	}
+
+	start := time.Now()
+	report := func(copied int64, finished bool) {
+		if total > 0 && copied > total {
+			copied = total
+		}
+		cb(CopyProgress{Total: total, Copied: copied, Elapsed: time.Since(start), Finished: finished})
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				report(measure(), false)
+			}
+		}
+	}()
+
+	err := copy()
+	close(done)
+	wg.Wait()
+
+	if err == nil {
+		final := total
+		if final <= 0 {
+			final = measure()
+		}
+		report(final, true)
+	}
+	return err
+}
+
+// localSize returns the total size of the regular files under path
+func localSize(path string) int64 {
+	var size int64
+	filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
@@ -0,0 +1,69 @@
+package operations
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCopyProgress_ETA(t *testing.T) {
+	p := CopyProgress{Total: 100, Copied: 25, Elapsed: 10 * time.Second}
+	if eta := p.ETA(); eta != 30*time.Second {
+		t.Errorf("expected ETA 30s, got %s", eta)
+	}
+	if pct := p.Percent(); pct != 25 {
+		t.Errorf("expected 25%%, got %v", pct)
+	}
+
+	unknown := CopyProgress{Copied: 25, Elapsed: 10 * time.Second}
+	if unknown.ETA() != 0 || unknown.Percent() != -1 {
+		t.Errorf("expected no estimate for unknown total, got %+v", unknown)
+	}
+}
+
+func TestTrackProgress_ReportsFinal(t *testing.T) {
+	var reports []CopyProgress
+	err := trackProgress(100, func() int64 { return 40 }, func(p CopyProgress) {
+		reports = append(reports, p)
+	}, func() error { return nil })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(reports) == 0 {
+		t.Fatal("expected at least one progress report")
+	}
+	last := reports[len(reports)-1]
+	if !last.Finished || last.Copied != 100 {
+		t.Errorf("expected finished report with all bytes copied, got %+v", last)
+	}
+}
+
+func TestTrackProgress_NoFinalOnError(t *testing.T) {
+	var finished bool
+	err := trackProgress(100, func() int64 { return 0 }, func(p CopyProgress) {
+		finished = finished || p.Finished
+	}, func() error { return errors.New("push failed") })
+	if err == nil {
+		t.Fatal("expected copy error")
+	}
+	if finished {
+		t.Error("failed copy should not report finished")
+	}
+}
+
+func TestLocalSize(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a"), make([]byte, 100), 0644)
+	os.MkdirAll(filepath.Join(dir, "sub"), 0755)
+	os.WriteFile(filepath.Join(dir, "sub", "b"), make([]byte, 50), 0644)
+
+	if size := localSize(dir); size != 150 {
+		t.Errorf("expected 150 bytes, got %d", size)
+	}
+	if size := localSize(filepath.Join(dir, "a")); size != 100 {
+		t.Errorf("expected 100 bytes, got %d", size)
+	}
+}
@@ -3,11 +3,17 @@ package operations
 import (
 	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/dryrun"
+	"lxc-dev-manager/internal/errcode"
 	"lxc-dev-manager/internal/lxc"
+	"lxc-dev-manager/internal/validation"
 )
 
 // CreateProject creates a new project in the specified directory.
@@ -46,12 +52,19 @@ func CreateProject(dir string, opts CreateProjectOpts) (*config.Config, error) {
 	cfg = &config.Config{
 		Dir:     cfgDir,
 		Project: projectName,
+		Naming:  config.NamingEscaped,
 		Defaults: config.Defaults{
 			Ports: opts.Ports,
 		},
 		Containers: make(map[string]config.Container),
 	}
 
+	if !opts.Force {
+		if err := checkPrefixCollisions(cfg); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := cfg.Save(); err != nil {
 		return nil, fmt.Errorf("failed to save config: %w", err)
 	}
@@ -59,22 +72,65 @@ func CreateProject(dir string, opts CreateProjectOpts) (*config.Config, error) {
 	return cfg, nil
 }
 
-// DeleteProject deletes a project and all its containers.
+// checkPrefixCollisions refuses a project whose prefix is already used by
+// LXC containers, e.g. those of project "web-app" under the original naming
+// ("web-app-api") for a new project "web". They would show up as the new
+// project's containers and block creating ones with the same name.
+func checkPrefixCollisions(cfg *config.Config) error {
+	containers, err := lxc.ListAll()
+	if err != nil {
+		slog.Warn("could not check existing containers for prefix collisions", "project", cfg.Project, "error", err)
+		return nil
+	}
+
+	var taken []string
+	for _, c := range containers {
+		if _, ok := cfg.GetShortName(c.Name); ok {
+			taken = append(taken, c.Name)
+		}
+	}
+	if len(taken) == 0 {
+		return nil
+	}
+	sort.Strings(taken)
+	return errcode.Errorf(errcode.Validation, "", "project name %q collides with existing LXC containers (%s); choose another name or use --force",
+		cfg.Project, strings.Join(taken, ", "))
+}
+
+// DeleteProject deletes a project's containers and its containers.yaml.
 // If dir is empty, it uses the current working directory.
-func DeleteProject(dir string, force bool) error {
+//
+// Without opts.Force the first container that fails to delete stops the
+// deletion and containers.yaml is kept, so it can be retried. With it the
+// remaining containers are still deleted and containers.yaml removed, and
+// the failures are returned together.
+func DeleteProject(dir string, opts DeleteProjectOpts) error {
 	cfg, err := config.Load(dir)
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
+	defer InvalidateInventory(cfg)
 
 	// Delete all containers
 	var deleteErrors []error
-	for name := range cfg.Containers {
-		lxcName := cfg.GetLXCName(name)
-		if lxc.Exists(lxcName) {
+	if !opts.KeepContainers {
+		names := make([]string, 0, len(cfg.Containers))
+		for name := range cfg.Containers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			lxcName := cfg.GetLXCName(name)
+			if !lxc.Exists(lxcName) {
+				continue
+			}
+			if opts.Progress != nil {
+				opts.Progress(name)
+			}
 			if err := lxc.Delete(lxcName); err != nil {
-				if !force {
-					return fmt.Errorf("failed to delete container %s: %w", name, err)
+				if !opts.Force {
+					return fmt.Errorf("failed to delete container %s (use --force to delete the rest anyway): %w", name, err)
 				}
 				deleteErrors = append(deleteErrors, fmt.Errorf("%s: %w", name, err))
 			}
@@ -87,14 +143,124 @@ func DeleteProject(dir string, force bool) error {
 		cfgDir = "."
 	}
 	configPath := filepath.Join(cfgDir, config.ConfigFile)
-	if err := os.Remove(configPath); err != nil {
+	if dryrun.Enabled() {
+		dryrun.Printf("remove %s", configPath)
+	} else if err := os.Remove(configPath); err != nil {
 		return fmt.Errorf("failed to remove config: %w", err)
 	}
 
+	slog.Info("project deleted", "project", cfg.Project, "kept_containers", opts.KeepContainers, "failed", len(deleteErrors))
 	if len(deleteErrors) > 0 {
-		return fmt.Errorf("some containers failed to delete: %v", deleteErrors)
+		return fmt.Errorf("some containers failed to delete: %w", errors.Join(deleteErrors...))
+	}
+
+	return nil
+}
+
+// RenameProject renames the project to newName. Every container that exists
+// in LXC is renamed to the new prefix (stopped for the rename and started
+// again if it was running), and containers.yaml is rewritten with the new
+// project name and escaped naming. If a rename fails, the containers already renamed are moved
+// back. Projects with volumes can't be renamed, as the volumes carry the
+// project prefix too. cfg should be locked by the caller.
+func RenameProject(cfg *config.Config, newName string, opts RenameProjectOpts) error {
+	defer InvalidateInventory(cfg)
+
+	if !config.IsValidProjectName(newName) {
+		return errcode.Errorf(errcode.Validation, "", "invalid project name %q: must contain only letters, numbers, hyphens, and underscores", newName)
+	}
+	if newName == cfg.Project {
+		return fmt.Errorf("project is already named '%s'", newName)
+	}
+	if len(cfg.Volumes) > 0 {
+		return fmt.Errorf("project has volumes (%s); delete them before renaming", strings.Join(sortedKeys(cfg.Volumes), ", "))
+	}
+
+	names := sortedKeys(cfg.Containers)
+	// Every container gets a new name anyway, so move to escaped naming too
+	renamed := &config.Config{Project: newName, Naming: config.NamingEscaped}
+	for _, name := range names {
+		if err := validation.ValidateFullContainerName(renamed.LXCProject(), name); err != nil {
+			return err
+		}
+		if newLXC := renamed.GetLXCName(name); lxc.Exists(newLXC) {
+			return kindErrorf(ErrContainerExists, "container '%s' already exists in LXC", newLXC)
+		}
 	}
 
+	type move struct {
+		oldLXC, newLXC string
+		running        bool
+	}
+	var done []move
+	rollback := func() {
+		for i := len(done) - 1; i >= 0; i-- {
+			lxc.Rename(done[i].newLXC, done[i].oldLXC)
+			if done[i].running {
+				lxc.Start(done[i].oldLXC)
+			}
+		}
+	}
+
+	for _, name := range names {
+		m := move{oldLXC: cfg.GetLXCName(name), newLXC: renamed.GetLXCName(name)}
+		if !lxc.Exists(m.oldLXC) {
+			continue
+		}
+		if opts.Progress != nil {
+			opts.Progress(name)
+		}
+		status, err := lxc.GetStatus(m.oldLXC)
+		if err != nil {
+			rollback()
+			return err
+		}
+		m.running = status == "RUNNING"
+		if m.running {
+			if err := lxc.StopWithTimeout(m.oldLXC, stopTimeout(cfg, name), false); err != nil {
+				rollback()
+				return err
+			}
+		}
+		if err := lxc.Rename(m.oldLXC, m.newLXC); err != nil {
+			if m.running {
+				lxc.Start(m.oldLXC)
+			}
+			rollback()
+			return fmt.Errorf("failed to rename container %s: %w", name, err)
+		}
+		done = append(done, m)
+	}
+
+	// DNS entries are keyed by project, so drop the old ones before switching
+	if err := ClearDNS(cfg); err != nil {
+		slog.Warn("failed to clear DNS entries", "project", cfg.Project, "error", err)
+	}
+
+	oldName, oldNaming := cfg.Project, cfg.Naming
+	cfg.Project, cfg.Naming = newName, renamed.Naming
+	if err := cfg.Save(); err != nil {
+		cfg.Project, cfg.Naming = oldName, oldNaming
+		rollback()
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	var startErrors []error
+	for _, m := range done {
+		if m.running {
+			if err := lxc.Start(m.newLXC); err != nil {
+				startErrors = append(startErrors, err)
+			}
+		}
+	}
+	if _, err := RefreshDNS(cfg); err != nil {
+		slog.Warn("failed to refresh DNS entries", "project", cfg.Project, "error", err)
+	}
+
+	slog.Info("project renamed", "from", oldName, "to", newName, "containers", len(done))
+	if len(startErrors) > 0 {
+		return fmt.Errorf("project renamed, but %w", errors.Join(startErrors...))
+	}
 	return nil
 }
 
@@ -0,0 +1,156 @@
+package operations
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/errcode"
+	"lxc-dev-manager/internal/lxc"
+)
+
+// ContainerProperties are the keys SetProperties accepts
+var ContainerProperties = []string{
+	"autostart",
+	"image",
+	"limits.cpu",
+	"limits.memory",
+	"ports",
+	"user.name",
+}
+
+// SetProperties updates several properties of a container at once, keyed
+// by the names in ContainerProperties. The updated definition is validated
+// as a whole before anything changes, and a new user.name must exist in the
+// running container; limits and autostart are then set on the LXC
+// container, and the config is saved. An empty value clears a property
+// (ports fall back to defaults.ports).
+func SetProperties(cfg *config.Config, name string, properties map[string]string) error {
+	container, ok := cfg.Containers[name]
+	if !ok {
+		return containerNotFound(name)
+	}
+	if len(properties) == 0 {
+		return errcode.Errorf(errcode.Usage, name, "no properties to set")
+	}
+
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// LXC config keys to change, an empty value unsets
+	lxcConfig := make(map[string]string)
+	checkUser := false
+	for _, key := range keys {
+		value := strings.TrimSpace(properties[key])
+		switch key {
+		case "autostart":
+			autostart := false
+			if value != "" {
+				var err error
+				if autostart, err = strconv.ParseBool(value); err != nil {
+					return errcode.Errorf(errcode.Validation, name, "invalid autostart '%s' (expected true or false)", value)
+				}
+			}
+			container.Autostart = autostart
+			lxcConfig["boot.autostart"] = strconv.FormatBool(autostart)
+		case "image":
+			if value == "" {
+				return errcode.Errorf(errcode.Validation, name, "image cannot be empty")
+			}
+			container.Image = value
+		case "limits.cpu":
+			container.Limits.CPU = value
+			lxcConfig[key] = value
+		case "limits.memory":
+			container.Limits.Memory = value
+			lxcConfig[key] = value
+		case "ports":
+			ports, err := parsePorts(value)
+			if err != nil {
+				return errcode.New(errcode.Validation, name, err)
+			}
+			container.Ports = ports
+		case "user.name":
+			container.User.Name = value
+			checkUser = value != ""
+		default:
+			return errcode.Errorf(errcode.Usage, name, "unknown property '%s' (allowed: %s)", key, strings.Join(ContainerProperties, ", "))
+		}
+	}
+
+	if err := config.ValidateContainer(container); err != nil {
+		return err
+	}
+
+	lxcName := cfg.GetLXCName(name)
+	if checkUser {
+		if err := checkUserExists(name, lxcName, container.User.Name); err != nil {
+			return err
+		}
+	}
+
+	if len(lxcConfig) > 0 {
+		if !lxc.Exists(lxcName) {
+			return containerNotInLXC(name, lxcName)
+		}
+		lxcKeys := make([]string, 0, len(lxcConfig))
+		for key := range lxcConfig {
+			lxcKeys = append(lxcKeys, key)
+		}
+		sort.Strings(lxcKeys)
+		for _, key := range lxcKeys {
+			var err error
+			if value := lxcConfig[key]; value == "" {
+				err = lxc.ConfigUnset(lxcName, key)
+			} else {
+				err = lxc.ConfigSet(lxcName, key, value)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	cfg.SetContainer(name, container)
+	if err := cfg.Save(); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	return nil
+}
+
+// checkUserExists returns an error unless username exists in the running
+// container, so ssh and exec aren't pointed at a missing account
+func checkUserExists(name, lxcName, username string) error {
+	if !lxc.Exists(lxcName) {
+		return containerNotInLXC(name, lxcName)
+	}
+	if status, _ := lxc.GetStatus(lxcName); status != "RUNNING" {
+		return errcode.New(errcode.NotRunning, name, kindErrorf(ErrContainerStopped, "container '%s' must be running to check that user '%s' exists", name, username))
+	}
+	if _, err := lxc.UserUID(lxcName, username); err != nil {
+		return errcode.Errorf(errcode.Validation, name, "user '%s' doesn't exist in container '%s'", username, name)
+	}
+	return nil
+}
+
+// parsePorts parses a comma-separated port list such as "3000,5173"
+func parsePorts(s string) ([]int, error) {
+	var ports []int
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		port, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port %q", field)
+		}
+		ports = append(ports, port)
+	}
+	return ports, nil
+}
@@ -2,21 +2,36 @@ package operations
 
 import (
 	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
 
 	"lxc-dev-manager/internal/config"
 	"lxc-dev-manager/internal/lxc"
 	"lxc-dev-manager/internal/proxy"
 )
 
-// StartProxy starts proxying ports for a container
-func StartProxy(cfg *config.Config, name string) (*proxy.Manager, string, []int, error) {
+// StartProxy starts proxying ports for a container.
+// IPv4 is used by default; with PreferIPv6 (or defaults.prefer_ipv6) the
+// container's IPv6 address is used when it has one. If the preferred family
+// has no address, the other one is used.
+//
+// Every port is checked before any is forwarded. A port another project's
+// proxy or another program listens on fails with a *proxy.ConflictError
+// naming it, unless opts.AutoPort forwards it from the next free port.
+//
+// With opts.TLS, the local CA is created on first use and each port
+// serves HTTPS for localhost and *.localhost.
+func StartProxy(cfg *config.Config, name string, opts ProxyOpts) (*proxy.Manager, string, []PortForward, error) {
 	if !cfg.HasContainer(name) {
-		return nil, "", nil, fmt.Errorf("container '%s' not found in config", name)
+		return nil, "", nil, containerNotFound(name)
 	}
 
 	lxcName := cfg.GetLXCName(name)
 	if !lxc.Exists(lxcName) {
-		return nil, "", nil, fmt.Errorf("container '%s' does not exist in LXC", lxcName)
+		return nil, "", nil, containerNotInLXC(name, lxcName)
 	}
 
 	// Check if running
@@ -25,11 +40,11 @@ func StartProxy(cfg *config.Config, name string) (*proxy.Manager, string, []int,
 		return nil, "", nil, err
 	}
 	if status != "RUNNING" {
-		return nil, "", nil, fmt.Errorf("container '%s' is not running", name)
+		return nil, "", nil, containerNotRunning(name)
 	}
 
 	// Get container IP
-	ip, err := lxc.GetIP(lxcName)
+	ip, err := proxyTargetIP(lxcName, opts.PreferIPv6 || cfg.Defaults.PreferIPv6)
 	if err != nil {
 		return nil, "", nil, fmt.Errorf("failed to get container IP: %w", err)
 	}
@@ -40,15 +55,192 @@ func StartProxy(cfg *config.Config, name string) (*proxy.Manager, string, []int,
 		return nil, "", nil, fmt.Errorf("no ports configured for container '%s'", name)
 	}
 
+	forwards, err := planForwards(ports, opts.AutoPort)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
 	// Start proxies
-	manager := proxy.NewManager()
+	manager := proxy.NewManagerFor(proxy.Owner{
+		Project:   cfg.Project,
+		Dir:       cfg.ProjectDir(),
+		Container: name,
+		PID:       os.Getpid(),
+	})
 
-	for _, port := range ports {
-		if err := manager.Add(port, ip, port); err != nil {
+	add := manager.Add
+	if opts.TLS {
+		dir, err := proxy.DefaultCADir()
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("failed to locate local CA: %w", err)
+		}
+		ca, err := proxy.LoadOrCreateCA(dir)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		manager.SetCA(ca)
+		add = manager.AddTLS
+		for i := range forwards {
+			forwards[i].TLS = true
+		}
+	}
+
+	for _, f := range forwards {
+		if err := add(f.Local, ip, f.Container); err != nil {
 			manager.StopAll()
-			return nil, "", nil, fmt.Errorf("failed to start proxy for port %d: %w", port, err)
+			return nil, "", nil, fmt.Errorf("failed to start proxy for port %d: %w", f.Local, err)
+		}
+	}
+
+	return manager, ip, forwards, nil
+}
+
+// ProxyStats returns the stats of the ports the project's running proxies
+// forward, across processes, for every container or only name's. Ports
+// forwarded by proxy: auto are handled by LXD and have no stats.
+func ProxyStats(cfg *config.Config, name string) ([]proxy.Forward, error) {
+	if name != "" && !cfg.HasContainer(name) {
+		return nil, containerNotFound(name)
+	}
+
+	all, err := proxy.List()
+	if err != nil {
+		return nil, err
+	}
+	var forwards []proxy.Forward
+	for _, f := range all {
+		if f.Owner.Dir == cfg.ProjectDir() && (name == "" || f.Owner.Container == name) {
+			forwards = append(forwards, f)
 		}
 	}
+	return forwards, nil
+}
+
+// autoPortRange is how far past a taken port AutoPort looks for a free one
+const autoPortRange = 100
+
+// planForwards picks the local port for each container port: the same
+// port, or with autoPort the next free one when it is taken. Other
+// configured ports are skipped so they stay available for themselves.
+func planForwards(ports []int, autoPort bool) ([]PortForward, error) {
+	used := make(map[int]bool)
+	for _, port := range ports {
+		used[port] = true
+	}
 
-	return manager, ip, ports, nil
+	forwards := make([]PortForward, 0, len(ports))
+	for _, port := range ports {
+		err := proxy.CheckPort(port)
+		if err == nil {
+			forwards = append(forwards, PortForward{Local: port, Container: port})
+			continue
+		}
+		if !autoPort {
+			return nil, err
+		}
+
+		local := 0
+		for candidate := port + 1; candidate <= min(port+autoPortRange, 65535); candidate++ {
+			if !used[candidate] && proxy.CheckPort(candidate) == nil {
+				local = candidate
+				break
+			}
+		}
+		if local == 0 {
+			return nil, fmt.Errorf("%w, and no port up to %d is free", err, min(port+autoPortRange, 65535))
+		}
+		used[local] = true
+		forwards = append(forwards, PortForward{Local: local, Container: port})
+	}
+	return forwards, nil
+}
+
+// proxyTargetIP picks the address to forward to, falling back to the other family
+func proxyTargetIP(lxcName string, preferIPv6 bool) (string, error) {
+	primary, fallback := lxc.GetIP, lxc.GetIPv6
+	if preferIPv6 {
+		primary, fallback = lxc.GetIPv6, lxc.GetIP
+	}
+
+	ip, err := primary(lxcName)
+	if err == nil {
+		return ip, nil
+	}
+	if ip, fallbackErr := fallback(lxcName); fallbackErr == nil {
+		return ip, nil
+	}
+	return "", err
+}
+
+// portDevicePrefix names the LXD proxy devices StartPortForwards adds
+const portDevicePrefix = "port-"
+
+// StartPortForwards forwards a running container's configured ports from
+// the host through LXD proxy devices, which LXD keeps listening while the
+// container runs, so no proxy process is needed. It's what proxy: auto runs
+// on start. Forwards from an earlier call are replaced; ports already taken
+// on the host are skipped with a warning naming their owner.
+func StartPortForwards(cfg *config.Config, name string) ([]PortForward, error) {
+	if !cfg.HasContainer(name) {
+		return nil, containerNotFound(name)
+	}
+
+	lxcName := cfg.GetLXCName(name)
+	if err := StopPortForwards(cfg, name); err != nil {
+		return nil, err
+	}
+
+	var forwards []PortForward
+	for _, port := range cfg.GetPorts(name) {
+		if err := proxy.CheckPort(port); err != nil {
+			slog.Warn("not forwarding port", "container", name, "error", err)
+			continue
+		}
+		if err := lxc.DeviceAdd(lxcName, portDevicePrefix+strconv.Itoa(port), "proxy", map[string]string{
+			"listen":  fmt.Sprintf("tcp:0.0.0.0:%d", port),
+			"connect": fmt.Sprintf("tcp:127.0.0.1:%d", port),
+		}); err != nil {
+			return forwards, fmt.Errorf("failed to forward port %d: %w", port, err)
+		}
+		forwards = append(forwards, PortForward{Local: port, Container: port})
+	}
+	return forwards, nil
+}
+
+// StopPortForwards removes the proxy devices StartPortForwards added
+func StopPortForwards(cfg *config.Config, name string) error {
+	lxcName := cfg.GetLXCName(name)
+	devices, err := lxc.DeviceList(lxcName)
+	if err != nil {
+		return err
+	}
+	for _, device := range devices {
+		if device.Type == "proxy" && strings.HasPrefix(device.Name, portDevicePrefix) {
+			if err := lxc.DeviceRemove(lxcName, device.Name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ListPortForwards returns the forwards StartPortForwards set up
+func ListPortForwards(cfg *config.Config, name string) ([]PortForward, error) {
+	devices, err := lxc.DeviceList(cfg.GetLXCName(name))
+	if err != nil {
+		return nil, err
+	}
+	var forwards []PortForward
+	for _, device := range devices {
+		if device.Type != "proxy" || !strings.HasPrefix(device.Name, portDevicePrefix) {
+			continue
+		}
+		local, err1 := strconv.Atoi(device.Config["listen"][strings.LastIndex(device.Config["listen"], ":")+1:])
+		remote, err2 := strconv.Atoi(device.Config["connect"][strings.LastIndex(device.Config["connect"], ":")+1:])
+		if err1 == nil && err2 == nil {
+			forwards = append(forwards, PortForward{Local: local, Container: remote})
+		}
+	}
+	slices.SortFunc(forwards, func(a, b PortForward) int { return a.Local - b.Local })
+	return forwards, nil
 }
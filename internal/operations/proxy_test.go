@@ -0,0 +1,80 @@
+package operations
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"lxc-dev-manager/internal/proxy"
+)
+
+func TestProxyTargetIP_DefaultsToIPv4(t *testing.T) {
+	mock := setupSyncMock(t)
+	mock.SetOutput("list test-dev1 -c4 -f csv", "10.10.10.5 (eth0)")
+	mock.SetOutput("list test-dev1 -c6 -f csv", "fd42::5 (eth0)")
+
+	ip, err := proxyTargetIP("test-dev1", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ip != "10.10.10.5" {
+		t.Errorf("expected IPv4, got %s", ip)
+	}
+}
+
+func TestProxyTargetIP_PreferIPv6(t *testing.T) {
+	mock := setupSyncMock(t)
+	mock.SetOutput("list test-dev1 -c4 -f csv", "10.10.10.5 (eth0)")
+	mock.SetOutput("list test-dev1 -c6 -f csv", "fd42::5 (eth0)")
+
+	ip, err := proxyTargetIP("test-dev1", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ip != "fd42::5" {
+		t.Errorf("expected IPv6, got %s", ip)
+	}
+}
+
+func TestProxyTargetIP_FallsBackToOtherFamily(t *testing.T) {
+	mock := setupSyncMock(t)
+	mock.SetOutput("list test-dev1 -c4 -f csv", "")
+	mock.SetOutput("list test-dev1 -c6 -f csv", "fd42::5 (eth0)")
+
+	ip, err := proxyTargetIP("test-dev1", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ip != "fd42::5" {
+		t.Errorf("expected IPv6 fallback, got %s", ip)
+	}
+}
+
+func TestPlanForwards(t *testing.T) {
+	old := proxy.RegistryDir
+	proxy.RegistryDir = t.TempDir()
+	t.Cleanup(func() { proxy.RegistryDir = old })
+
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	taken := listener.Addr().(*net.TCPAddr).Port
+
+	var conflict *proxy.ConflictError
+	if _, err := planForwards([]int{taken}, false); !errors.As(err, &conflict) || conflict.Port != taken {
+		t.Fatalf("expected a conflict on port %d, got %v", taken, err)
+	}
+
+	forwards, err := planForwards([]int{taken, taken + 1}, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if forwards[0].Container != taken || forwards[0].Local == taken || forwards[0].Local == taken+1 {
+		t.Errorf("expected the taken port to move past the configured ones, got %+v", forwards)
+	}
+	if forwards[1] != (PortForward{Local: taken + 1, Container: taken + 1}) {
+		t.Errorf("expected the free port to be kept, got %+v", forwards[1])
+	}
+}
@@ -0,0 +1,136 @@
+package operations
+
+import (
+	"fmt"
+	"slices"
+
+	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/errcode"
+	"lxc-dev-manager/internal/lxc"
+)
+
+// Provisioning steps of a create, recorded in a container's pending_setup
+// until they finish
+const (
+	SetupNesting  = "nesting"
+	SetupUser     = "user"
+	SetupSSH      = "ssh"
+	SetupSnapshot = "snapshot"
+)
+
+// RepairStep is the outcome of checking one provisioning step
+type RepairStep struct {
+	Step     string
+	Repaired bool // The step was missing and has been run
+}
+
+// RepairReport describes what Repair found and did
+type RepairReport struct {
+	Steps         []RepairStep
+	RestartNeeded bool // Nesting was enabled on a running container
+}
+
+// Repair finishes the setup of a container a create left incomplete. The
+// nesting, user and SSH steps are checked on the container and re-run when
+// missing; the initial-state snapshot is only taken when pending_setup
+// lists it, as a snapshot taken later isn't the state right after setup.
+// pending_setup is cleared once every step is done. The container must be
+// running.
+func Repair(cfg *config.Config, name string, progress func(step string)) (*RepairReport, error) {
+	if !cfg.HasContainer(name) {
+		return nil, containerNotFound(name)
+	}
+
+	lxcName := cfg.GetLXCName(name)
+	if !lxc.Exists(lxcName) {
+		return nil, containerNotInLXC(name, lxcName)
+	}
+	status, err := lxc.GetStatus(lxcName)
+	if err != nil {
+		return nil, err
+	}
+	if status != "RUNNING" {
+		return nil, errcode.New(errcode.NotRunning, name, kindErrorf(ErrContainerStopped, "container '%s' must be running to repair it", name))
+	}
+	if progress == nil {
+		progress = func(string) {}
+	}
+
+	container := cfg.Containers[name]
+	pending := container.PendingSetup
+	user := cfg.GetUser(name)
+	report := &RepairReport{}
+	check := func(step string, missing func() bool, run func() error) error {
+		progress("checking " + step)
+		if !missing() {
+			report.Steps = append(report.Steps, RepairStep{Step: step})
+			return nil
+		}
+		progress("running " + step)
+		if err := run(); err != nil {
+			return fmt.Errorf("%s: %w", step, err)
+		}
+		report.Steps = append(report.Steps, RepairStep{Step: step, Repaired: true})
+		return nil
+	}
+
+	err = check(SetupNesting, func() bool {
+		if container.Nesting != nil && !*container.Nesting {
+			return false
+		}
+		enabled, err := lxc.IsNesting(lxcName)
+		return err == nil && !enabled
+	}, func() error {
+		report.RestartNeeded = true
+		return lxc.EnableNesting(lxcName)
+	})
+	if err != nil {
+		return report, err
+	}
+
+	// A container created without a user runs everything as root
+	if user.Name != "root" {
+		err = check(SetupUser, func() bool {
+			_, err := lxc.UserUID(lxcName, user.Name)
+			return err != nil
+		}, func() error {
+			if err := lxc.SetupUser(lxcName, user.Name, user.Password); err != nil {
+				return err
+			}
+			return applyUserEnvironment(lxcName, user)
+		})
+		if err != nil {
+			return report, err
+		}
+	}
+
+	err = check(SetupSSH, func() bool {
+		return !lxc.FileExists(lxcName, "/usr/sbin/sshd")
+	}, func() error {
+		return lxc.EnableSSH(lxcName)
+	})
+	if err != nil {
+		return report, err
+	}
+
+	if slices.Contains(pending, SetupSnapshot) {
+		err = check(SetupSnapshot, func() bool {
+			return !lxc.SnapshotExists(lxcName, "initial-state")
+		}, func() error {
+			if err := lxc.Snapshot(lxcName, "initial-state"); err != nil {
+				return err
+			}
+			cfg.AddSnapshot(name, "initial-state", "Initial state after setup")
+			return nil
+		})
+		if err != nil {
+			return report, err
+		}
+	}
+
+	cfg.SetContainerPendingSetup(name, nil)
+	if err := cfg.Save(); err != nil {
+		return report, err
+	}
+	return report, nil
+}
@@ -2,27 +2,33 @@ package operations
 
 import (
 	"fmt"
+	"log/slog"
+	"slices"
 	"sort"
 	"time"
 
 	"lxc-dev-manager/internal/config"
 	"lxc-dev-manager/internal/lxc"
+	"lxc-dev-manager/internal/tracing"
 )
 
 // CreateSnapshot creates a snapshot of a container
-func CreateSnapshot(cfg *config.Config, containerName, snapshotName, description string) error {
+func CreateSnapshot(cfg *config.Config, containerName, snapshotName, description string) (err error) {
+	defer InvalidateInventory(cfg)
+	defer tracing.Start("operations.CreateSnapshot", "container", containerName, "snapshot", snapshotName).EndErr(&err)
+
 	if !cfg.HasContainer(containerName) {
-		return fmt.Errorf("container '%s' not found in config", containerName)
+		return containerNotFound(containerName)
 	}
 
 	lxcName := cfg.GetLXCName(containerName)
 	if !lxc.Exists(lxcName) {
-		return fmt.Errorf("container '%s' does not exist in LXC", lxcName)
+		return containerNotInLXC(containerName, lxcName)
 	}
 
 	// Check if snapshot already exists
 	if lxc.SnapshotExists(lxcName, snapshotName) {
-		return fmt.Errorf("snapshot '%s' already exists", snapshotName)
+		return kindErrorf(ErrSnapshotExists, "snapshot '%s' already exists", snapshotName)
 	}
 
 	if err := lxc.Snapshot(lxcName, snapshotName); err != nil {
@@ -38,15 +44,73 @@ func CreateSnapshot(cfg *config.Config, containerName, snapshotName, description
 	return nil
 }
 
+// rebasePrefix starts the name the new initial-state is taken under until
+// the old one is gone
+const rebasePrefix = "rebase-"
+
+// Rebase replaces a container's initial-state snapshot with its current
+// state, so reset goes back to it from then on. A container without one
+// gets one. The new state is snapshotted under a temporary name first, so
+// the old initial-state is only deleted once it has a replacement.
+func Rebase(cfg *config.Config, name string) (err error) {
+	defer InvalidateInventory(cfg)
+	defer tracing.Start("operations.Rebase", "container", name).EndErr(&err)
+
+	if !cfg.HasContainer(name) {
+		return containerNotFound(name)
+	}
+
+	lxcName := cfg.GetLXCName(name)
+	if !lxc.Exists(lxcName) {
+		return containerNotInLXC(name, lxcName)
+	}
+
+	temp := rebasePrefix + time.Now().Format("20060102-150405")
+	if err := lxc.Snapshot(lxcName, temp); err != nil {
+		return err
+	}
+
+	if lxc.SnapshotExists(lxcName, "initial-state") {
+		if err := lxc.DeleteSnapshot(lxcName, "initial-state"); err != nil {
+			if cleanupErr := lxc.DeleteSnapshot(lxcName, temp); cleanupErr != nil {
+				slog.Warn("failed to delete snapshot", "container", name, "snapshot", temp, "error", cleanupErr)
+			}
+			return err
+		}
+		cfg.RemoveSnapshot(name, "initial-state")
+	}
+
+	if err := lxc.RenameSnapshot(lxcName, temp, "initial-state"); err != nil {
+		// The new state is safe under its temporary name, keep track of it
+		cfg.AddSnapshot(name, temp, "Initial state, rebased")
+		pending := cfg.Containers[name].PendingSetup
+		if !slices.Contains(pending, SetupSnapshot) {
+			cfg.SetContainerPendingSetup(name, append(slices.Clone(pending), SetupSnapshot))
+		}
+		if saveErr := cfg.Save(); saveErr != nil {
+			slog.Warn("failed to save config", "error", saveErr)
+		}
+		return fmt.Errorf("the new state was kept as snapshot '%s' but couldn't become initial-state, run container repair to take it: %w", temp, err)
+	}
+
+	cfg.AddSnapshot(name, "initial-state", "Initial state, rebased")
+	if err := cfg.Save(); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	slog.Info("initial-state rebased", "container", name)
+	return nil
+}
+
 // ListSnapshots lists all snapshots for a container
 func ListSnapshots(cfg *config.Config, containerName string) ([]SnapshotInfo, error) {
 	if !cfg.HasContainer(containerName) {
-		return nil, fmt.Errorf("container '%s' not found in config", containerName)
+		return nil, containerNotFound(containerName)
 	}
 
 	lxcName := cfg.GetLXCName(containerName)
 	if !lxc.Exists(lxcName) {
-		return nil, fmt.Errorf("container '%s' does not exist in LXC", lxcName)
+		return nil, containerNotInLXC(containerName, lxcName)
 	}
 
 	// Get snapshots from LXC
@@ -91,22 +155,24 @@ func ListSnapshots(cfg *config.Config, containerName string) ([]SnapshotInfo, er
 
 // DeleteSnapshot deletes a snapshot from a container
 func DeleteSnapshot(cfg *config.Config, containerName, snapshotName string) error {
+	defer InvalidateInventory(cfg)
+
 	if !cfg.HasContainer(containerName) {
-		return fmt.Errorf("container '%s' not found in config", containerName)
+		return containerNotFound(containerName)
 	}
 
 	lxcName := cfg.GetLXCName(containerName)
 	if !lxc.Exists(lxcName) {
-		return fmt.Errorf("container '%s' does not exist in LXC", lxcName)
+		return containerNotInLXC(containerName, lxcName)
 	}
 
 	// Prevent deleting initial-state
 	if snapshotName == "initial-state" {
-		return fmt.Errorf("cannot delete 'initial-state' snapshot")
+		return kindErrorf(ErrSnapshotProtected, "cannot delete 'initial-state' snapshot")
 	}
 
 	if !lxc.SnapshotExists(lxcName, snapshotName) {
-		return fmt.Errorf("snapshot '%s' does not exist", snapshotName)
+		return kindErrorf(ErrSnapshotNotFound, "snapshot '%s' does not exist", snapshotName)
 	}
 
 	if err := lxc.DeleteSnapshot(lxcName, snapshotName); err != nil {
@@ -2,11 +2,13 @@ package operations
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/errcode"
 	"lxc-dev-manager/internal/lxc"
 )
 
@@ -15,7 +17,7 @@ import (
 // Errors are collected per-file; all entries are attempted even if some fail.
 func SyncFiles(cfg *config.Config, containerName, baseDir string) error {
 	if !cfg.HasContainer(containerName) {
-		return fmt.Errorf("container '%s' not found in config", containerName)
+		return containerNotFound(containerName)
 	}
 
 	entries := cfg.GetSyncEntries(containerName)
@@ -25,7 +27,7 @@ func SyncFiles(cfg *config.Config, containerName, baseDir string) error {
 
 	lxcName := cfg.GetLXCName(containerName)
 	if !lxc.Exists(lxcName) {
-		return fmt.Errorf("container '%s' does not exist in LXC", lxcName)
+		return containerNotInLXC(containerName, lxcName)
 	}
 
 	status, err := lxc.GetStatus(lxcName)
@@ -33,7 +35,7 @@ func SyncFiles(cfg *config.Config, containerName, baseDir string) error {
 		return fmt.Errorf("failed to get container status: %w", err)
 	}
 	if status != "RUNNING" {
-		return fmt.Errorf("container '%s' is not running (status: %s)", containerName, status)
+		return errcode.New(errcode.NotRunning, containerName, kindErrorf(ErrContainerStopped, "container '%s' is not running (status: %s)", containerName, status))
 	}
 
 	var errors []string
@@ -49,6 +51,27 @@ func SyncFiles(cfg *config.Config, containerName, baseDir string) error {
 	return nil
 }
 
+// AddSyncEntry validates entry and adds it to a container's sync entries,
+// replacing one with the same source, then saves the config. A source
+// outside the project directory is accepted with a warning.
+func AddSyncEntry(cfg *config.Config, containerName string, entry config.SyncEntry) error {
+	if !cfg.HasContainer(containerName) {
+		return containerNotFound(containerName)
+	}
+	if err := config.ValidateSyncEntry(entry); err != nil {
+		return err
+	}
+	if cfg.SyncSourceOutsideProject(entry) {
+		slog.Warn("sync source is outside the project directory", "container", containerName, "source", entry.Source)
+	}
+
+	cfg.AddSyncEntry(containerName, entry)
+	if err := cfg.Save(); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	return nil
+}
+
 // syncEntry copies a single file/directory from host to container.
 func syncEntry(cfg *config.Config, containerName, baseDir string, entry config.SyncEntry) error {
 	// Resolve source path
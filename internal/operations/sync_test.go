@@ -1,6 +1,7 @@
 package operations
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -201,3 +202,43 @@ func TestSyncFiles_ContainerNotFound(t *testing.T) {
 		t.Fatal("expected error for unknown container")
 	}
 }
+
+func TestAddSyncEntry_Saves(t *testing.T) {
+	cfg, dir := setupSyncTest(t, nil)
+	cfg.Dir = dir
+
+	if err := AddSyncEntry(cfg, "dev1", config.SyncEntry{Source: ".env", Dest: "/app/.env"}); err != nil {
+		t.Fatalf("AddSyncEntry() error = %v", err)
+	}
+
+	loaded, err := config.Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries := loaded.GetSyncEntries("dev1"); len(entries) != 1 || entries[0].Dest != "/app/.env" {
+		t.Errorf("saved entries = %+v", entries)
+	}
+}
+
+func TestAddSyncEntry_InvalidDest(t *testing.T) {
+	cfg, dir := setupSyncTest(t, nil)
+	cfg.Dir = dir
+
+	err := AddSyncEntry(cfg, "dev1", config.SyncEntry{Source: ".env", Dest: "app/.env"})
+	if err == nil {
+		t.Fatal("expected error for relative dest")
+	}
+	if len(cfg.GetSyncEntries("dev1")) != 0 {
+		t.Error("invalid entry was added")
+	}
+}
+
+func TestAddSyncEntry_ContainerNotFound(t *testing.T) {
+	cfg, dir := setupSyncTest(t, nil)
+	cfg.Dir = dir
+
+	err := AddSyncEntry(cfg, "nonexistent", config.SyncEntry{Source: ".env", Dest: "/app/.env"})
+	if !errors.Is(err, ErrContainerNotFound) {
+		t.Errorf("error = %v, want ErrContainerNotFound", err)
+	}
+}
@@ -16,11 +16,62 @@ type CreateContainerOpts struct {
 	Ports    []int
 	User     string
 	Password string
+	IP       string            // Static IPv4 address (empty for DHCP)
+	Workdir  string            // Mount the project directory here (default: defaults.workdir)
+	Disk     string            // Root disk size limit, e.g. "20GiB" (empty for no limit)
+	Mounts   []config.Mount    // Applied after defaults.mounts, before the initial snapshot
+	Env      map[string]string // Exported inside the container
+	Setup    []string          // Run inside as the user before the initial snapshot
+	NoStart  bool              // Leave the container stopped once setup is done
+	Arch     string            // Architecture of remote images, e.g. arm64 (default: the host's)
+
+	// Skip setup steps, for throwaway containers like build sandboxes.
+	// Without a user, shell and exec run as root and dotfiles are skipped.
+	// Without the initial-state snapshot, reset needs a named snapshot.
+	NoUser     bool
+	NoSSH      bool
+	NoSnapshot bool
+
+	// NoRollback leaves a container whose setup failed in place for
+	// inspection instead of deleting it
+	NoRollback bool
+
+	// Privileged sets security.privileged. It must be asked for explicitly:
+	// defaults.privileged is only applied by the confirming container
+	// create command. Read-write and /home mounts are refused, as for any
+	// privileged container.
+	Privileged bool
+
+	// Nesting turns Docker-in-LXC support on or off (default:
+	// defaults.nesting, else on). Failing to enable it only warns unless
+	// it was asked for explicitly.
+	Nesting *bool
+
+	// Progress, if set, is called as each setup step starts. CreateContainers
+	// calls it from several goroutines at once.
+	Progress func(name, step string)
+}
+
+// StopOpts holds options for stopping a container
+type StopOpts struct {
+	Timeout time.Duration // Grace period for a clean shutdown (default: stop_timeout, else 5s; negative with Force: none)
+	Force   bool          // Kill the container if it hasn't shut down by then
+}
+
+// ResetOpts holds options for resetting a container to a snapshot
+type ResetOpts struct {
+	SnapshotFirst bool // Keep the current state as a pre-reset snapshot (always on with reset.auto_snapshot)
+	SyncFiles     bool // Copy the sync entries again once a running container is back up
+	PruneDevices  bool // Detach the disk, USB and character devices the config doesn't list
 }
 
 // CloneOpts holds options for container cloning
 type CloneOpts struct {
 	FromSnapshot string
+
+	// Progress, if set, is called as each step starts. CloneContainers
+	// calls it from several goroutines at once.
+	Progress func(name, step string)
 }
 
 // MountOpts holds options for mounting
@@ -28,12 +79,78 @@ type MountOpts struct {
 	Name           string
 	ReadWrite      bool
 	Shift          bool
+	IDMap          string // raw.idmap entry (e.g. "both 1000 1000") or IDMapAuto
+	Recursive      bool   // Bind nested mounts under the source too
+	Propagation    string // Bind propagation mode (e.g. "shared", "slave")
+	NoAutoShift    bool   // Don't enable shift when source and container UIDs mismatch
+	NoAutoSuffix   bool   // Fail instead of suffixing an auto-generated name that is taken
 	AllowRiskyPath bool
 }
 
+// VolumeOpts holds options for volume creation
+type VolumeOpts struct {
+	Pool string // Storage pool (default: "default")
+	Size string // Size quota, e.g. "10GiB" (empty for unlimited)
+}
+
+// AttachVolumeOpts holds options for attaching a volume to a container
+type AttachVolumeOpts struct {
+	Name     string // Device name (default: vol-<volume>)
+	ReadOnly bool
+}
+
+// DeviceOpts holds options for passthrough devices
+type DeviceOpts struct {
+	Name      string
+	VendorID  string // usb: 4-digit hex vendor ID
+	ProductID string // usb: 4-digit hex product ID (optional)
+	Source    string // unix-char: host device node (e.g. /dev/ttyUSB0)
+	Path      string // unix-char: path inside the container (default: same as source)
+	Mode      string // Octal file mode inside the container (e.g. 0660)
+	UID       string
+	GID       string
+}
+
 // CopyOpts holds options for file copy operations
 type CopyOpts struct {
 	AutoCreateDir bool
+	// ConfirmCreateDir is asked before creating a missing destination
+	// directory when AutoCreateDir is false
+	ConfirmCreateDir func(dir string) bool
+	// Exclude lists patterns of paths to skip when copying a directory,
+	// in addition to those in its .lxcignore file
+	Exclude []string
+	// Verify compares sha256 checksums on both sides after the copy
+	// (also enabled by defaults.verify_copy)
+	Verify bool
+	// Progress is called periodically while copying, and once with
+	// Finished set when each transfer completes
+	Progress func(CopyProgress)
+}
+
+// ProxyOpts holds options for port proxying
+type ProxyOpts struct {
+	PreferIPv6 bool // Forward to the container's IPv6 address when it has one
+	AutoPort   bool // Forward a port that's taken locally from the next free one instead of failing
+	// TLS terminates HTTPS on every forwarded port with a certificate from
+	// the local CA (see proxy.DefaultCADir), forwarding plain HTTP
+	TLS bool
+}
+
+// PortForward is a container port forwarded from a local port
+type PortForward struct {
+	Local     int
+	Container int
+	TLS       bool // HTTPS is terminated on the local port
+}
+
+// PortCheck is the result of checking one configured port
+type PortCheck struct {
+	Port         int
+	Listening    bool     // Something listens on the port inside the container
+	Addresses    []string // Addresses the listeners are bound to
+	LoopbackOnly bool     // Listeners are bound to loopback only, so unreachable from outside
+	Reachable    bool     // A TCP connection from the host succeeded
 }
 
 // ShellOpts holds options for shell access
@@ -41,6 +158,13 @@ type ShellOpts struct {
 	User string
 }
 
+// AttachOpts holds options for attaching to a terminal multiplexer session
+type AttachOpts struct {
+	Session     string // Session name (default: main)
+	Multiplexer string // zellij or tmux (default: the one installed, else zellij)
+	User        string // Default: configured user
+}
+
 // MountInfo holds combined mount information
 type MountInfo struct {
 	Name   string
@@ -50,6 +174,45 @@ type MountInfo struct {
 	Status string // "ok", "untracked", "missing"
 }
 
+// VolumeInfo holds custom volume information
+type VolumeInfo struct {
+	Name       string
+	LXCName    string
+	Pool       string
+	Size       string
+	AttachedTo []string // Container short names
+	Status     string   // "ok" or "missing"
+}
+
+// ContainerMountInfo holds mount information along with its container
+type ContainerMountInfo struct {
+	Container string
+	MountInfo
+}
+
+// DiskUsageInfo holds disk usage for one container
+type DiskUsageInfo struct {
+	Name           string
+	Root           int64 // Bytes used by the root disk
+	Snapshots      []SnapshotUsage
+	SnapshotsKnown bool  // False when the pool can't report snapshot space
+	Total          int64 // Root plus snapshots
+}
+
+// SnapshotUsage holds the space held by a single snapshot
+type SnapshotUsage struct {
+	Name string
+	Used int64
+}
+
+// DeviceInfo holds passthrough device information
+type DeviceInfo struct {
+	Name   string
+	Type   string // "usb" or "unix-char"
+	Config map[string]string
+	Status string // "ok", "untracked", "missing"
+}
+
 // SnapshotInfo holds snapshot information
 type SnapshotInfo struct {
 	Name        string
@@ -59,25 +222,88 @@ type SnapshotInfo struct {
 
 // ContainerInfo holds container status information
 type ContainerInfo struct {
-	Name   string
-	Image  string
-	Status string
-	IP     string
-	Ports  []int
+	Name        string
+	Image       string
+	Description string
+	Status      string
+	IP          string
+	IPv6        string
+	Ports       []int
+	Snapshots   int   // Number of snapshots in LXC
+	Mounts      int   // Number of disk devices in config
+	DiskUsage   int64 // Bytes used by the root disk, 0 if unknown
+}
+
+// ContainerDetails holds everything info shows about one container. Parts
+// that can't be read (e.g. ports of a stopped container) are left empty.
+type ContainerDetails struct {
+	Name        string
+	LXCName     string
+	Image       string
+	Description string
+	Status      string
+	IP          string
+	IPv6        string
+	StartedAt   time.Time // Zero when not running
+	Ports       []int     // Configured proxy ports
+	Listening   []int     // Ports actually listening inside the container
+	User        string
+	Mounts      []MountInfo
+	Sync        []config.SyncEntry
+	Snapshots   []SnapshotInfo
+	Limits      map[string]string // limits.* settings
+	Disk        string            // Root disk size limit
+	DiskUsage   int64             // Bytes used by the root disk
 }
 
 // ImageInfo holds image information
 type ImageInfo struct {
-	Alias       string
-	Fingerprint string
-	Size        string
-	Description string
+	Alias           string            `json:"alias"` // First alias, empty for cached images
+	Aliases         []string          `json:"aliases"`
+	Fingerprint     string            `json:"fingerprint"`
+	Size            string            `json:"size"` // Human readable, e.g. "512.0MiB"
+	SizeBytes       int64             `json:"size_bytes"`
+	Description     string            `json:"description"`
+	Architecture    string            `json:"architecture"`
+	Public          bool              `json:"public"`
+	Cached          bool              `json:"cached"`
+	CreatedAt       time.Time         `json:"created_at"`
+	LastUsedAt      time.Time         `json:"last_used_at"`               // Zero if never used
+	ExpiresAt       time.Time         `json:"expires_at"`                 // Zero if the image doesn't expire
+	SourceContainer string            `json:"source_container,omitempty"` // LXC container the image was published from
+	Properties      map[string]string `json:"properties"`
 }
 
 // CreateProjectOpts holds options for project creation
 type CreateProjectOpts struct {
 	Name  string
 	Ports []int
+	Force bool // Create even if LXC containers already use the project prefix
+}
+
+// CreateImageOpts holds options for image creation
+type CreateImageOpts struct {
+	Stop        bool              // Stop a running container for the snapshot, then restart it
+	Description string            // Shown by image list and image info
+	Public      bool              // Let other hosts pull the image without trusting this one
+	Expiry      time.Duration     // Delete the image this long after creation (0 for never)
+	Properties  map[string]string // Extra image properties
+	// Progress, if set, is called as each step starts
+	Progress func(step string)
+}
+
+// DeleteProjectOpts holds options for project deletion
+type DeleteProjectOpts struct {
+	KeepContainers bool // Only remove containers.yaml, leaving the LXC containers
+	Force          bool // Keep going when a container fails to delete
+	// Progress, if set, is called before each container is deleted
+	Progress func(name string)
+}
+
+// RenameProjectOpts holds options for project renaming
+type RenameProjectOpts struct {
+	// Progress, if set, is called before each container is renamed
+	Progress func(name string)
 }
 
 // ImageCreateWriter wraps stdout/stderr for image creation progress
@@ -0,0 +1,72 @@
+package operations
+
+import (
+	"sort"
+
+	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/lxc"
+)
+
+// DiskUsage reports root disk and snapshot space for every project container
+// that exists in LXC, largest first. Snapshot sizes are only available on
+// zfs pools; elsewhere SnapshotsKnown is false.
+func DiskUsage(cfg *config.Config) ([]DiskUsageInfo, error) {
+	// Pool lookups are shared between containers
+	type poolInfo struct {
+		driver  string
+		zfsPool string
+	}
+	pools := make(map[string]poolInfo)
+
+	inv, err := Inventory(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []DiskUsageInfo
+	for name := range cfg.Containers {
+		lxcName := cfg.GetLXCName(name)
+		if _, ok := inv[lxcName]; !ok {
+			continue
+		}
+
+		root, err := lxc.GetDiskUsage(lxcName)
+		if err != nil {
+			return nil, err
+		}
+
+		info := DiskUsageInfo{Name: name, Root: root, Total: root}
+
+		pool, err := lxc.GetRootPool(lxcName)
+		if err == nil {
+			p, ok := pools[pool]
+			if !ok {
+				p.driver, p.zfsPool, _ = lxc.GetPoolDriver(pool)
+				pools[pool] = p
+			}
+			if p.driver == "zfs" {
+				if snaps, err := lxc.ZFSSnapshotUsage(p.zfsPool, lxcName); err == nil {
+					info.SnapshotsKnown = true
+					for snap, used := range snaps {
+						info.Snapshots = append(info.Snapshots, SnapshotUsage{Name: snap, Used: used})
+						info.Total += used
+					}
+				}
+			}
+		}
+
+		sort.Slice(info.Snapshots, func(i, j int) bool {
+			return info.Snapshots[i].Used > info.Snapshots[j].Used
+		})
+		result = append(result, info)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Total != result[j].Total {
+			return result[i].Total > result[j].Total
+		}
+		return result[i].Name < result[j].Name
+	})
+
+	return result, nil
+}
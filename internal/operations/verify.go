@@ -0,0 +1,123 @@
+package operations
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"lxc-dev-manager/internal/lxc"
+)
+
+// maxReportedMismatches caps the file names listed in a verification error
+const maxReportedMismatches = 5
+
+// localChecksums returns sha256 checksums of a file, or of every regular
+// file under a directory, keyed like lxc.Checksums
+func localChecksums(path string, dir bool) (map[string]string, error) {
+	sums := make(map[string]string)
+	if !dir {
+		sum, err := fileChecksum(path)
+		if err != nil {
+			return nil, err
+		}
+		sums[""] = sum
+		return sums, nil
+	}
+
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(path, p)
+		if err != nil {
+			return err
+		}
+		sum, err := fileChecksum(p)
+		if err != nil {
+			return err
+		}
+		sums[filepath.ToSlash(rel)] = sum
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sums, nil
+}
+
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// compareChecksums checks that every source file arrived with the same
+// checksum. Extra files at the destination are ignored.
+func compareChecksums(src, dest map[string]string) error {
+	var bad []string
+	for name, sum := range src {
+		if dest[name] != sum {
+			bad = append(bad, name)
+		}
+	}
+	if len(bad) == 0 {
+		return nil
+	}
+
+	sort.Strings(bad)
+	if len(bad) == 1 && bad[0] == "" {
+		return fmt.Errorf("checksum mismatch after copy: file differs")
+	}
+	shown := bad
+	if len(shown) > maxReportedMismatches {
+		shown = shown[:maxReportedMismatches]
+	}
+	msg := strings.Join(shown, ", ")
+	if len(bad) > len(shown) {
+		msg += fmt.Sprintf(" and %d more", len(bad)-len(shown))
+	}
+	return fmt.Errorf("checksum mismatch after copy: %d file(s) missing or different: %s", len(bad), msg)
+}
+
+// verifyPush compares a pushed file or directory with its copy in the container
+func verifyPush(lxcName, localPath, remotePath string, dir bool) error {
+	src, err := localChecksums(localPath, dir)
+	if err != nil {
+		return fmt.Errorf("failed to checksum source: %w", err)
+	}
+	dest, err := lxc.Checksums(lxcName, remotePath, dir)
+	if err != nil {
+		return err
+	}
+	return compareChecksums(src, dest)
+}
+
+// verifyPull compares a file or directory in the container with its pulled copy
+func verifyPull(lxcName, remotePath, localPath string, dir bool) error {
+	src, err := lxc.Checksums(lxcName, remotePath, dir)
+	if err != nil {
+		return err
+	}
+	dest, err := localChecksums(localPath, dir)
+	if err != nil {
+		return fmt.Errorf("failed to checksum copy: %w", err)
+	}
+	return compareChecksums(src, dest)
+}
@@ -0,0 +1,212 @@
+package operations
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/errcode"
+	"lxc-dev-manager/internal/lxc"
+	"lxc-dev-manager/internal/validation"
+)
+
+// CreateVolume creates a custom storage volume and records it in config
+func CreateVolume(cfg *config.Config, name string, opts VolumeOpts) error {
+	if err := validation.ValidateMountName(name); err != nil {
+		return fmt.Errorf("invalid volume name: %w", err)
+	}
+
+	if cfg.HasVolume(name) {
+		return fmt.Errorf("volume '%s' already exists in config", name)
+	}
+
+	pool := volumePool(opts.Pool)
+	lxcName := cfg.GetVolumeLXCName(name)
+	if lxc.VolumeExists(pool, lxcName) {
+		return fmt.Errorf("volume '%s' already exists in pool '%s'", lxcName, pool)
+	}
+
+	if err := lxc.VolumeCreate(pool, lxcName, opts.Size); err != nil {
+		return err
+	}
+
+	cfg.AddVolume(name, config.Volume{Pool: opts.Pool, Size: opts.Size})
+	if err := cfg.Save(); err != nil {
+		// Try to rollback the volume if config save fails
+		lxc.VolumeDelete(pool, lxcName)
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteVolume deletes a volume. It refuses while the volume is attached.
+func DeleteVolume(cfg *config.Config, name string) error {
+	volume, ok := cfg.Volumes[name]
+	if !ok {
+		return errcode.Errorf(errcode.NotFound, "", "volume '%s' not found in config", name)
+	}
+
+	if attached := cfg.FindVolumeAttachments(name); len(attached) > 0 {
+		return fmt.Errorf("volume '%s' is attached to %s; detach it first", name, strings.Join(sortedKeys(attached), ", "))
+	}
+
+	pool := volumePool(volume.Pool)
+	lxcName := cfg.GetVolumeLXCName(name)
+	if lxc.VolumeExists(pool, lxcName) {
+		if err := lxc.VolumeDelete(pool, lxcName); err != nil {
+			return err
+		}
+	}
+
+	cfg.RemoveVolume(name)
+	if err := cfg.Save(); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	return nil
+}
+
+// AttachVolume attaches a volume to a container as a disk device
+func AttachVolume(cfg *config.Config, volumeName, containerName, path string, opts AttachVolumeOpts) (string, error) {
+	volume, ok := cfg.Volumes[volumeName]
+	if !ok {
+		return "", errcode.Errorf(errcode.NotFound, "", "volume '%s' not found in config", volumeName)
+	}
+
+	if !cfg.HasContainer(containerName) {
+		return "", containerNotFound(containerName)
+	}
+
+	lxcName := cfg.GetLXCName(containerName)
+	if !lxc.Exists(lxcName) {
+		return "", containerNotInLXC(containerName, lxcName)
+	}
+
+	if err := validation.ValidateContainerPath(path); err != nil {
+		return "", kindErrorf(ErrInvalidContainerPath, "invalid container path: %w", err)
+	}
+
+	if existing, found := cfg.FindVolumeAttachments(volumeName)[containerName]; found {
+		return "", fmt.Errorf("volume '%s' is already attached to '%s' as device '%s'", volumeName, containerName, existing)
+	}
+
+	deviceName := opts.Name
+	if deviceName == "" {
+		deviceName = "vol-" + volumeName
+	}
+	if err := validation.ValidateMountName(deviceName); err != nil {
+		return "", fmt.Errorf("invalid device name: %w", err)
+	}
+	if cfg.HasDevice(containerName, deviceName) {
+		return "", kindErrorf(ErrMountExists, "device '%s' already exists on container '%s'", deviceName, containerName)
+	}
+	if existingName, found := cfg.FindDeviceByPath(containerName, path); found {
+		return "", kindErrorf(ErrMountPathConflict, "container path '%s' is already mounted by device '%s'", path, existingName)
+	}
+
+	deviceConfig := map[string]string{
+		"pool":   volumePool(volume.Pool),
+		"source": cfg.GetVolumeLXCName(volumeName),
+		"path":   path,
+	}
+	if opts.ReadOnly {
+		deviceConfig["readonly"] = "true"
+	}
+
+	if err := lxc.DeviceAdd(lxcName, deviceName, "disk", deviceConfig); err != nil {
+		return "", fmt.Errorf("failed to attach volume: %w", err)
+	}
+
+	cfg.AddDevice(containerName, deviceName, config.Device{
+		Type:   "disk",
+		Config: deviceConfig,
+	})
+	if err := cfg.Save(); err != nil {
+		// Try to rollback LXC device if config save fails
+		lxc.DeviceRemove(lxcName, deviceName)
+		return "", fmt.Errorf("failed to save config: %w", err)
+	}
+
+	return deviceName, nil
+}
+
+// DetachVolume removes a volume's device from a container
+func DetachVolume(cfg *config.Config, volumeName, containerName string) error {
+	if !cfg.HasVolume(volumeName) {
+		return errcode.Errorf(errcode.NotFound, "", "volume '%s' not found in config", volumeName)
+	}
+
+	deviceName, found := cfg.FindVolumeAttachments(volumeName)[containerName]
+	if !found {
+		return fmt.Errorf("volume '%s' is not attached to '%s'", volumeName, containerName)
+	}
+
+	return Unmount(cfg, containerName, deviceName)
+}
+
+// ListVolumes lists project volumes with their attachments and LXD status
+func ListVolumes(cfg *config.Config) ([]VolumeInfo, error) {
+	// Query each pool once
+	pools := make(map[string]map[string]lxc.StorageVolume)
+	for _, volume := range cfg.Volumes {
+		pool := volumePool(volume.Pool)
+		if _, done := pools[pool]; done {
+			continue
+		}
+		list, err := lxc.VolumeList(pool)
+		if err != nil {
+			return nil, err
+		}
+		byName := make(map[string]lxc.StorageVolume)
+		for _, v := range list {
+			byName[v.Name] = v
+		}
+		pools[pool] = byName
+	}
+
+	var volumes []VolumeInfo
+	for name, volume := range cfg.Volumes {
+		pool := volumePool(volume.Pool)
+		lxcName := cfg.GetVolumeLXCName(name)
+
+		info := VolumeInfo{
+			Name:       name,
+			LXCName:    lxcName,
+			Pool:       pool,
+			Size:       volume.Size,
+			AttachedTo: sortedKeys(cfg.FindVolumeAttachments(name)),
+			Status:     "missing",
+		}
+		if v, ok := pools[pool][lxcName]; ok {
+			info.Status = "ok"
+			if v.Size != "" {
+				info.Size = v.Size
+			}
+		}
+		volumes = append(volumes, info)
+	}
+
+	sort.Slice(volumes, func(i, j int) bool {
+		return volumes[i].Name < volumes[j].Name
+	})
+
+	return volumes, nil
+}
+
+func volumePool(pool string) string {
+	if pool == "" {
+		return config.DefaultVolumePool
+	}
+	return pool
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -1,10 +1,14 @@
 package proxy
 
 import (
+	"crypto/tls"
 	"fmt"
 	"io"
+	"log/slog"
 	"net"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -15,23 +19,44 @@ const (
 	ConnectionTimeout = 30 * time.Second
 	// DialTimeout is the timeout for establishing remote connections
 	DialTimeout = 5 * time.Second
+	// StatsInterval is how often a manager with an owner publishes its stats
+	// for 'proxy stats'
+	StatsInterval = time.Second
 )
 
 // Proxy represents a TCP proxy for a single port
 type Proxy struct {
 	LocalPort  int
 	RemoteAddr string
+	TLS        *tls.Config // Terminate TLS with it before forwarding, when set
 	listener   net.Listener
 	done       chan struct{}
 	wg         sync.WaitGroup
 	connSem    chan struct{} // Semaphore for limiting concurrent connections
+
+	active, total, rejected, failed atomic.Int64
+	bytesIn, bytesOut               atomic.Int64
+	lastConn                        atomic.Int64 // Unix nanoseconds
+}
+
+// Stats are a proxy's connection counters since it started
+type Stats struct {
+	Port           int       `json:"port"`
+	Remote         string    `json:"remote"`
+	Active         int64     `json:"active"`    // Open connections
+	Total          int64     `json:"total"`     // Connections accepted
+	Rejected       int64     `json:"rejected"`  // Refused at MaxConnectionsPerProxy
+	Failed         int64     `json:"failed"`    // Accepted, but the container couldn't be reached
+	BytesIn        int64     `json:"bytes_in"`  // From clients to the container
+	BytesOut       int64     `json:"bytes_out"` // From the container to clients
+	LastConnection time.Time `json:"last_connection,omitzero"`
 }
 
-// New creates a new proxy
+// New creates a new proxy. remoteHost may be an IPv4 or IPv6 address.
 func New(localPort int, remoteHost string, remotePort int) *Proxy {
 	return &Proxy{
 		LocalPort:  localPort,
-		RemoteAddr: fmt.Sprintf("%s:%d", remoteHost, remotePort),
+		RemoteAddr: net.JoinHostPort(remoteHost, strconv.Itoa(remotePort)),
 		done:       make(chan struct{}),
 		connSem:    make(chan struct{}, MaxConnectionsPerProxy),
 	}
@@ -43,6 +68,9 @@ func (p *Proxy) Start() error {
 	if err != nil {
 		return fmt.Errorf("failed to listen on port %d: %w", p.LocalPort, err)
 	}
+	if p.TLS != nil {
+		listener = tls.NewListener(listener, p.TLS)
+	}
 	p.listener = listener
 
 	p.wg.Add(1)
@@ -51,6 +79,24 @@ func (p *Proxy) Start() error {
 	return nil
 }
 
+// Stats returns the proxy's connection counters
+func (p *Proxy) Stats() Stats {
+	stats := Stats{
+		Port:     p.LocalPort,
+		Remote:   p.RemoteAddr,
+		Active:   p.active.Load(),
+		Total:    p.total.Load(),
+		Rejected: p.rejected.Load(),
+		Failed:   p.failed.Load(),
+		BytesIn:  p.bytesIn.Load(),
+		BytesOut: p.bytesOut.Load(),
+	}
+	if last := p.lastConn.Load(); last != 0 {
+		stats.LastConnection = time.Unix(0, last)
+	}
+	return stats
+}
+
 // Stop stops the proxy
 func (p *Proxy) Stop() {
 	close(p.done)
@@ -81,6 +127,9 @@ func (p *Proxy) acceptLoop() {
 			go p.handleConnection(conn)
 		default:
 			// At capacity - reject connection
+			p.rejected.Add(1)
+			slog.Debug("proxy connection rejected", "port", p.LocalPort, "client", conn.RemoteAddr().String(),
+				"reason", "too many connections")
 			conn.Close()
 		}
 	}
@@ -93,6 +142,14 @@ func (p *Proxy) handleConnection(local net.Conn) {
 		p.wg.Done()
 	}()
 
+	start := time.Now()
+	client := local.RemoteAddr().String()
+	p.total.Add(1)
+	p.lastConn.Store(start.UnixNano())
+	p.active.Add(1)
+	defer p.active.Add(-1)
+	slog.Debug("proxy connection opened", "port", p.LocalPort, "client", client)
+
 	// Set deadline on local connection
 	local.SetDeadline(time.Now().Add(ConnectionTimeout))
 
@@ -100,6 +157,8 @@ func (p *Proxy) handleConnection(local net.Conn) {
 	dialer := net.Dialer{Timeout: DialTimeout}
 	remote, err := dialer.Dial("tcp", p.RemoteAddr)
 	if err != nil {
+		p.failed.Add(1)
+		slog.Debug("proxy connection failed", "port", p.LocalPort, "client", client, "remote", p.RemoteAddr, "error", err)
 		return
 	}
 	defer remote.Close()
@@ -110,33 +169,56 @@ func (p *Proxy) handleConnection(local net.Conn) {
 	// Bidirectional copy with proper cleanup
 	done := make(chan struct{}, 2)
 
+	var in, out int64
 	go func() {
-		io.Copy(remote, local)
+		in, _ = io.Copy(&countingWriter{remote, &p.bytesIn}, local)
 		// Half-close: signal we're done writing to remote
-		if tc, ok := remote.(*net.TCPConn); ok {
-			tc.CloseWrite()
-		}
+		closeWrite(remote)
 		done <- struct{}{}
 	}()
 
 	go func() {
-		io.Copy(local, remote)
+		out, _ = io.Copy(&countingWriter{local, &p.bytesOut}, remote)
 		// Half-close: signal we're done writing to local
-		if tc, ok := local.(*net.TCPConn); ok {
-			tc.CloseWrite()
-		}
+		closeWrite(local)
 		done <- struct{}{}
 	}()
 
 	// Wait for BOTH directions to complete (prevents goroutine leak)
 	<-done
 	<-done
+	slog.Debug("proxy connection closed", "port", p.LocalPort, "client", client,
+		"bytes_in", in, "bytes_out", out, "duration", time.Since(start).Round(time.Millisecond))
+}
+
+// countingWriter adds what it writes to n, so stats include open connections
+type countingWriter struct {
+	w io.Writer
+	n *atomic.Int64
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// closeWrite half-closes conn when it supports it, as *net.TCPConn and
+// *tls.Conn do
+func closeWrite(conn net.Conn) {
+	if cw, ok := conn.(interface{ CloseWrite() error }); ok {
+		cw.CloseWrite()
+	}
 }
 
 // Manager manages multiple proxies
 type Manager struct {
 	proxies []*Proxy
+	owner   *Owner // Registered for each port, see NewManagerFor
+	ca      *CA    // Terminates TLS on ports added with AddTLS
 	mu      sync.Mutex
+
+	publishStop, publishDone chan struct{} // Stats publishing, with an owner
 }
 
 // NewManager creates a new proxy manager
@@ -144,27 +226,114 @@ func NewManager() *Manager {
 	return &Manager{}
 }
 
+// NewManagerFor creates a proxy manager that records owner in the registry
+// for each port it forwards, until StopAll
+func NewManagerFor(owner Owner) *Manager {
+	return &Manager{owner: &owner}
+}
+
+// SetCA sets the local CA issuing certificates for ports added with AddTLS
+func (m *Manager) SetCA(ca *CA) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ca = ca
+}
+
 // Add adds a proxy for a port
 func (m *Manager) Add(localPort int, remoteHost string, remotePort int) error {
+	return m.add(New(localPort, remoteHost, remotePort))
+}
+
+// AddTLS adds a proxy for a port that terminates HTTPS with a certificate
+// from the manager's CA and forwards plain HTTP to remotePort
+func (m *Manager) AddTLS(localPort int, remoteHost string, remotePort int) error {
 	m.mu.Lock()
-	defer m.mu.Unlock()
+	ca := m.ca
+	m.mu.Unlock()
+	if ca == nil {
+		return fmt.Errorf("no CA set to terminate TLS on port %d", localPort)
+	}
 
 	proxy := New(localPort, remoteHost, remotePort)
+	proxy.TLS = ca.TLSConfig()
+	return m.add(proxy)
+}
+
+func (m *Manager) add(proxy *Proxy) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	localPort := proxy.LocalPort
 	if err := proxy.Start(); err != nil {
 		return err
 	}
 
 	m.proxies = append(m.proxies, proxy)
+	if m.owner != nil {
+		if err := Register(localPort, *m.owner); err != nil {
+			slog.Debug("failed to register proxy", "port", localPort, "error", err)
+		}
+		PublishStats(proxy.Stats())
+		if m.publishStop == nil {
+			m.publishStop, m.publishDone = make(chan struct{}), make(chan struct{})
+			go m.publishLoop(m.publishStop, m.publishDone)
+		}
+	}
 	return nil
 }
 
+// Stats returns the stats of each proxy, in the order they were added
+func (m *Manager) Stats() []Stats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := make([]Stats, 0, len(m.proxies))
+	for _, p := range m.proxies {
+		stats = append(stats, p.Stats())
+	}
+	return stats
+}
+
+// publishLoop records the stats in the registry, so 'proxy stats' can read
+// them from another process
+func (m *Manager) publishLoop(stop, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(StatsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			for _, stats := range m.Stats() {
+				if err := PublishStats(stats); err != nil {
+					slog.Debug("failed to publish proxy stats", "port", stats.Port, "error", err)
+				}
+			}
+		}
+	}
+}
+
 // StopAll stops all proxies
 func (m *Manager) StopAll() {
+	m.mu.Lock()
+	stop, done := m.publishStop, m.publishDone
+	m.publishStop, m.publishDone = nil, nil
+	m.mu.Unlock()
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	for _, p := range m.proxies {
 		p.Stop()
+		if m.owner != nil {
+			Unregister(p.LocalPort)
+		}
 	}
 	m.proxies = nil
 }
@@ -1,9 +1,12 @@
 package proxy
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net"
+	"os"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -417,3 +420,207 @@ func TestManager_AddDuplicatePort(t *testing.T) {
 		t.Error("expected error when adding duplicate port")
 	}
 }
+
+func TestNew_IPv6RemoteAddr(t *testing.T) {
+	proxy := New(8080, "fd42::10", 80)
+	if proxy.RemoteAddr != "[fd42::10]:80" {
+		t.Errorf("expected bracketed IPv6 address, got %q", proxy.RemoteAddr)
+	}
+}
+
+func TestProxy_ForwardsToIPv6(t *testing.T) {
+	remote, err := net.Listen("tcp", "[::1]:0")
+	if err != nil {
+		t.Skip("IPv6 loopback not available")
+	}
+	go func() {
+		conn, err := remote.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		io.Copy(conn, conn)
+	}()
+	defer remote.Close()
+
+	localPort := getFreePort(t)
+	proxy := New(localPort, "::1", remote.Addr().(*net.TCPAddr).Port)
+	if err := proxy.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer proxy.Stop()
+
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", localPort))
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	conn.Write([]byte("ping"))
+	buf := make([]byte, 4)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+	if string(buf[:n]) != "ping" {
+		t.Errorf("expected ping, got %q", string(buf[:n]))
+	}
+}
+
+func TestCheckPort_Registry(t *testing.T) {
+	old := RegistryDir
+	RegistryDir = t.TempDir()
+	t.Cleanup(func() { RegistryDir = old })
+
+	port := getFreePort(t)
+	if err := CheckPort(port); err != nil {
+		t.Fatalf("expected a free port, got %v", err)
+	}
+
+	// A port forwarded by a running proxy names its owner
+	manager := NewManagerFor(Owner{Project: "shop", Container: "api", PID: os.Getpid()})
+	if err := manager.Add(port, "127.0.0.1", 8080); err != nil {
+		t.Fatalf("failed to add proxy: %v", err)
+	}
+	var conflict *ConflictError
+	if err := CheckPort(port); !errors.As(err, &conflict) || conflict.Owner == nil || conflict.Owner.Project != "shop" {
+		t.Errorf("expected a conflict with project shop, got %v", err)
+	}
+	if !strings.Contains(CheckPort(port).Error(), "container 'api' of project 'shop'") {
+		t.Errorf("unexpected message: %v", CheckPort(port))
+	}
+
+	manager.StopAll()
+	if _, ok := Lookup(port); ok {
+		t.Error("expected StopAll to unregister the port")
+	}
+}
+
+func TestCheckPort_OtherProgramAndStaleEntry(t *testing.T) {
+	old := RegistryDir
+	RegistryDir = t.TempDir()
+	t.Cleanup(func() { RegistryDir = old })
+
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	var conflict *ConflictError
+	if err := CheckPort(port); !errors.As(err, &conflict) || conflict.Owner != nil {
+		t.Errorf("expected a conflict without owner, got %v", err)
+	}
+
+	// Entries of proxies that exited are ignored and removed
+	free := getFreePort(t)
+	if err := Register(free, Owner{Project: "gone", PID: 1 << 30}); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckPort(free); err != nil {
+		t.Errorf("expected a stale entry to be ignored, got %v", err)
+	}
+	if _, err := os.Stat(registryFile(free)); !os.IsNotExist(err) {
+		t.Error("expected the stale entry to be removed")
+	}
+}
+
+func TestProxy_Stats(t *testing.T) {
+	localPort := getFreePort(t)
+	remotePort := getFreePort(t)
+
+	echoServer, done := startEchoServer(t, remotePort)
+	defer func() {
+		close(done)
+		echoServer.Close()
+	}()
+
+	proxy := New(localPort, "127.0.0.1", remotePort)
+	if err := proxy.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer proxy.Stop()
+
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", localPort))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Write([]byte("ping"))
+	buf := make([]byte, 4)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := proxy.Stats()
+	if stats.Active != 1 || stats.Total != 1 || stats.BytesIn != 4 || stats.BytesOut != 4 {
+		t.Errorf("unexpected stats with an open connection: %+v", stats)
+	}
+	if stats.LastConnection.IsZero() {
+		t.Error("expected the last connection time to be set")
+	}
+
+	conn.Close()
+	deadline := time.Now().Add(time.Second)
+	for proxy.Stats().Active != 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if stats := proxy.Stats(); stats.Active != 0 || stats.Total != 1 {
+		t.Errorf("unexpected stats after close: %+v", stats)
+	}
+}
+
+func TestProxy_StatsFailed(t *testing.T) {
+	localPort := getFreePort(t)
+
+	proxy := New(localPort, "127.0.0.1", getFreePort(t)) // Nothing listens
+	if err := proxy.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer proxy.Stop()
+
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", localPort))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	conn.Read(make([]byte, 1)) // Closed once the dial fails
+	conn.Close()
+
+	if stats := proxy.Stats(); stats.Failed != 1 || stats.Total != 1 {
+		t.Errorf("expected one failed connection, got %+v", stats)
+	}
+}
+
+func TestList_PublishedStats(t *testing.T) {
+	old := RegistryDir
+	RegistryDir = t.TempDir()
+	t.Cleanup(func() { RegistryDir = old })
+
+	port := getFreePort(t)
+	manager := NewManagerFor(Owner{Project: "shop", Container: "api", PID: os.Getpid()})
+	if err := manager.Add(port, "127.0.0.1", 8080); err != nil {
+		t.Fatal(err)
+	}
+
+	forwards, err := List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(forwards) != 1 || forwards[0].Owner.Container != "api" || forwards[0].Stats.Port != port {
+		t.Fatalf("unexpected forwards: %+v", forwards)
+	}
+	if forwards[0].Stats.Remote != "127.0.0.1:8080" {
+		t.Errorf("expected stats published on add, got %+v", forwards[0].Stats)
+	}
+
+	manager.StopAll()
+	if forwards, _ := List(); len(forwards) != 0 {
+		t.Errorf("expected no forwards after StopAll, got %+v", forwards)
+	}
+	if _, err := os.Stat(statsFile(port)); !os.IsNotExist(err) {
+		t.Error("expected the stats file to be removed")
+	}
+}
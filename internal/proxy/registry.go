@@ -0,0 +1,171 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// Owner identifies the proxy forwarding a port, so another project trying
+// to forward the same port can say who holds it
+type Owner struct {
+	Project   string `json:"project"`
+	Dir       string `json:"dir"` // Project directory
+	Container string `json:"container"`
+	PID       int    `json:"pid"`
+}
+
+func (o Owner) String() string {
+	if o.Project == "" {
+		return fmt.Sprintf("container '%s' (%s, pid %d)", o.Container, o.Dir, o.PID)
+	}
+	return fmt.Sprintf("container '%s' of project '%s' (pid %d)", o.Container, o.Project, o.PID)
+}
+
+// RegistryDir holds a file per forwarded local port, naming its owner. It
+// is shared by every project of the user; files of proxies that are no
+// longer running are ignored and removed.
+var RegistryDir = defaultRegistryDir()
+
+func defaultRegistryDir() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "lxc-dev-manager", "proxies")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("lxc-dev-manager-%d", os.Getuid()), "proxies")
+}
+
+func registryFile(port int) string {
+	return filepath.Join(RegistryDir, strconv.Itoa(port)+".json")
+}
+
+func statsFile(port int) string {
+	return filepath.Join(RegistryDir, strconv.Itoa(port)+".stats.json")
+}
+
+// Register records owner as forwarding port
+func Register(port int, owner Owner) error {
+	if err := os.MkdirAll(RegistryDir, 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(owner)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(registryFile(port), data, 0600)
+}
+
+// Unregister removes the record for port if this process owns it
+func Unregister(port int) {
+	if owner, ok := Lookup(port); ok && owner.PID == os.Getpid() {
+		os.Remove(registryFile(port))
+		os.Remove(statsFile(port))
+	}
+}
+
+// Lookup returns the owner recorded for port, if its process is running
+func Lookup(port int) (Owner, bool) {
+	data, err := os.ReadFile(registryFile(port))
+	if err != nil {
+		return Owner{}, false
+	}
+	var owner Owner
+	if err := json.Unmarshal(data, &owner); err != nil || !processAlive(owner.PID) {
+		os.Remove(registryFile(port))
+		os.Remove(statsFile(port))
+		return Owner{}, false
+	}
+	return owner, true
+}
+
+// PublishStats records a registered proxy's stats. The file is replaced
+// whole, so readers never see a partial write.
+func PublishStats(stats Stats) error {
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return err
+	}
+	tmp := statsFile(stats.Port) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, statsFile(stats.Port))
+}
+
+// Forward is a port forwarded by a running proxy
+type Forward struct {
+	Owner Owner
+	Stats Stats // As last published; Port is always set
+}
+
+// List returns the ports forwarded by running proxies, by port
+func List() ([]Forward, error) {
+	entries, err := os.ReadDir(RegistryDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var forwards []Forward
+	for _, entry := range entries {
+		port, err := strconv.Atoi(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			continue // Stats and temporary files
+		}
+		owner, ok := Lookup(port)
+		if !ok {
+			continue
+		}
+		forward := Forward{Owner: owner, Stats: Stats{Port: port}}
+		if data, err := os.ReadFile(statsFile(port)); err == nil {
+			json.Unmarshal(data, &forward.Stats)
+		}
+		forwards = append(forwards, forward)
+	}
+	slices.SortFunc(forwards, func(a, b Forward) int { return a.Stats.Port - b.Stats.Port })
+	return forwards, nil
+}
+
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// ConflictError reports a local port that can't be forwarded because
+// something else listens on it
+type ConflictError struct {
+	Port  int
+	Owner *Owner // nil when the port isn't held by a proxy
+}
+
+func (e *ConflictError) Error() string {
+	if e.Owner != nil {
+		return fmt.Sprintf("port %d is already forwarded by %s", e.Port, e.Owner)
+	}
+	return fmt.Sprintf("port %d is already in use by another program", e.Port)
+}
+
+// CheckPort reports a *ConflictError when port can't be listened on,
+// naming the proxy that forwards it when there is one
+func CheckPort(port int) error {
+	if owner, ok := Lookup(port); ok {
+		return &ConflictError{Port: port, Owner: &owner}
+	}
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return &ConflictError{Port: port}
+	}
+	listener.Close()
+	return nil
+}
@@ -0,0 +1,193 @@
+package proxy
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CADir is the CA directory, relative to the user config directory
+// ($XDG_CONFIG_HOME or ~/.config on Linux)
+const CADir = "lxc-dev-manager/ca"
+
+// CA files in the CA directory
+const (
+	CACertFile = "ca.pem"     // Add this to the browser or system trust store
+	CAKeyFile  = "ca-key.pem" // Never leaves the machine
+)
+
+// DefaultCADir returns where the local CA is kept
+func DefaultCADir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, CADir), nil
+}
+
+// CA is a local certificate authority, mkcert-style, that issues
+// certificates for localhost and *.localhost names, so the proxy can
+// terminate HTTPS. The CA is created once and kept; trusting its
+// certificate makes the proxy's certificates trusted.
+type CA struct {
+	CertFile string
+
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+
+	mu     sync.Mutex
+	leaves map[string]*tls.Certificate // By host name
+}
+
+// LoadOrCreateCA loads the CA in dir, creating it first if it doesn't exist
+func LoadOrCreateCA(dir string) (*CA, error) {
+	certFile, keyFile := filepath.Join(dir, CACertFile), filepath.Join(dir, CAKeyFile)
+	ca := &CA{CertFile: certFile, leaves: make(map[string]*tls.Certificate)}
+
+	certPEM, err := os.ReadFile(certFile)
+	if errors.Is(err, os.ErrNotExist) {
+		if err := ca.create(dir); err != nil {
+			return nil, fmt.Errorf("failed to create local CA: %w", err)
+		}
+		return ca, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	certBlock, _ := pem.Decode(certPEM)
+	keyBlock, _ := pem.Decode(keyPEM)
+	if certBlock == nil || keyBlock == nil {
+		return nil, fmt.Errorf("invalid local CA in %s", dir)
+	}
+	if ca.cert, err = x509.ParseCertificate(certBlock.Bytes); err != nil {
+		return nil, fmt.Errorf("invalid local CA certificate: %w", err)
+	}
+	if ca.key, err = x509.ParseECPrivateKey(keyBlock.Bytes); err != nil {
+		return nil, fmt.Errorf("invalid local CA key: %w", err)
+	}
+	if !ca.cert.PermittedDNSDomainsCritical {
+		slog.Warn("the local CA can sign certificates for any site; delete it and remove it from the trust store to get one limited to localhost", "dir", dir)
+	}
+	return ca, nil
+}
+
+func (ca *CA) create(dir string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber(),
+		Subject:               pkix.Name{Organization: []string{"lxc-dev-manager"}, CommonName: "lxc-dev-manager local CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+		// Trusting the CA then can't let it vouch for any other site
+		PermittedDNSDomainsCritical: true,
+		PermittedDNSDomains:         []string{"localhost", ".localhost"},
+		PermittedIPRanges: []*net.IPNet{
+			{IP: net.IPv4(127, 0, 0, 0).To4(), Mask: net.CIDRMask(8, 32)},
+			{IP: net.IPv6loopback, Mask: net.CIDRMask(128, 128)},
+		},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, CAKeyFile), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		return err
+	}
+	if err := os.WriteFile(ca.CertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		return err
+	}
+
+	ca.cert, err = x509.ParseCertificate(der)
+	ca.key = key
+	return err
+}
+
+// TLSConfig returns a server config presenting a certificate for the name
+// the client asks for when it is localhost or under .localhost, and one for
+// localhost otherwise (e.g. when connecting by IP address)
+func (ca *CA) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			host := strings.ToLower(strings.TrimSuffix(hello.ServerName, "."))
+			if host != "localhost" && !strings.HasSuffix(host, ".localhost") {
+				host = "localhost"
+			}
+			return ca.Certificate(host)
+		},
+	}
+}
+
+// Certificate returns a certificate for host signed by the CA, issuing it
+// on first use. localhost's also covers the loopback addresses.
+func (ca *CA) Certificate(host string) (*tls.Certificate, error) {
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+	if leaf, ok := ca.leaves[host]; ok {
+		return leaf, nil
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: serialNumber(),
+		Subject:      pkix.Name{Organization: []string{"lxc-dev-manager"}, CommonName: host},
+		DNSNames:     []string{host},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().AddDate(1, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if host == "localhost" {
+		template.IPAddresses = []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue certificate for %s: %w", host, err)
+	}
+
+	leaf := &tls.Certificate{Certificate: [][]byte{der, ca.cert.Raw}, PrivateKey: key}
+	ca.leaves[host] = leaf
+	return leaf, nil
+}
+
+func serialNumber() *big.Int {
+	serial, _ := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	return serial
+}
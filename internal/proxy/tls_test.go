@@ -0,0 +1,155 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadOrCreateCA_CreatesAndReloads(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "ca")
+
+	ca, err := LoadOrCreateCA(dir)
+	if err != nil {
+		t.Fatalf("LoadOrCreateCA() error = %v", err)
+	}
+	if !ca.cert.IsCA {
+		t.Error("created certificate is not a CA")
+	}
+	info, err := os.Stat(filepath.Join(dir, CAKeyFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("key mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	reloaded, err := LoadOrCreateCA(dir)
+	if err != nil {
+		t.Fatalf("LoadOrCreateCA() reload error = %v", err)
+	}
+	if !reloaded.cert.Equal(ca.cert) {
+		t.Error("reloading created a new CA")
+	}
+}
+
+func TestCA_Certificate(t *testing.T) {
+	ca, err := LoadOrCreateCA(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	for _, host := range []string{"localhost", "app.localhost"} {
+		cert, err := ca.Certificate(host)
+		if err != nil {
+			t.Fatalf("Certificate(%q) error = %v", host, err)
+		}
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := leaf.Verify(x509.VerifyOptions{DNSName: host, Roots: roots}); err != nil {
+			t.Errorf("certificate for %q doesn't verify: %v", host, err)
+		}
+
+		again, _ := ca.Certificate(host)
+		if again != cert {
+			t.Errorf("Certificate(%q) issued a second certificate", host)
+		}
+	}
+}
+
+func TestCA_NameConstraints(t *testing.T) {
+	ca, err := LoadOrCreateCA(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	verify := func(host string, opts x509.VerifyOptions) error {
+		cert, err := ca.Certificate(host)
+		if err != nil {
+			t.Fatal(err)
+		}
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts.Roots = roots
+		_, err = leaf.Verify(opts)
+		return err
+	}
+
+	if err := verify("localhost", x509.VerifyOptions{DNSName: "127.0.0.1"}); err != nil {
+		t.Errorf("localhost certificate doesn't verify for 127.0.0.1: %v", err)
+	}
+	// Even if it were asked to, the CA can't vouch for other sites
+	if err := verify("example.com", x509.VerifyOptions{DNSName: "example.com"}); err == nil {
+		t.Error("expected a certificate for example.com not to verify")
+	}
+}
+
+func TestManager_AddTLS(t *testing.T) {
+	localPort := getFreePort(t)
+	remotePort := getFreePort(t)
+
+	echoServer, done := startEchoServer(t, remotePort)
+	defer func() {
+		close(done)
+		echoServer.Close()
+	}()
+
+	ca, err := LoadOrCreateCA(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager := NewManager()
+	defer manager.StopAll()
+	manager.SetCA(ca)
+
+	if err := manager.AddTLS(localPort, "127.0.0.1", remotePort); err != nil {
+		t.Fatalf("AddTLS() error = %v", err)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	conn, err := tls.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", localPort), &tls.Config{
+		ServerName: "app.localhost",
+		RootCAs:    roots,
+	})
+	if err != nil {
+		t.Fatalf("TLS handshake failed: %v", err)
+	}
+	defer conn.Close()
+
+	// The echo server sees plain bytes, so they come back decrypted
+	testData := "GET / HTTP/1.1"
+	if _, err := conn.Write([]byte(testData)); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, len(testData))
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+	if string(buf[:n]) != testData {
+		t.Errorf("expected %q, got %q", testData, string(buf[:n]))
+	}
+}
+
+func TestManager_AddTLSWithoutCA(t *testing.T) {
+	manager := NewManager()
+	defer manager.StopAll()
+
+	if err := manager.AddTLS(getFreePort(t), "127.0.0.1", 8080); err == nil {
+		t.Fatal("expected error without a CA")
+	}
+}
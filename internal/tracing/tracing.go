@@ -0,0 +1,394 @@
+// Package tracing records optional OpenTelemetry spans for operations and
+// lxc commands and exports them with OTLP over HTTP (JSON encoding) when
+// the run ends. It is off unless an OTLP endpoint is set in the standard
+// OTEL_EXPORTER_OTLP_* environment variables, and costs nothing then.
+//
+// Spans started with Start nest by call order: a new span's parent is the
+// innermost span still open. Work running concurrently (create -n 3) uses
+// StartChild with an explicit parent instead, so goroutines don't nest
+// under each other; lxc commands they run are attributed to the innermost
+// span opened with Start, e.g. the whole batch.
+package tracing
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Environment variables read by Setup, as defined by the OpenTelemetry spec
+const (
+	EnvEndpoint       = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	EnvTracesEndpoint = "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"
+	EnvHeaders        = "OTEL_EXPORTER_OTLP_HEADERS"
+	EnvServiceName    = "OTEL_SERVICE_NAME"
+	EnvSDKDisabled    = "OTEL_SDK_DISABLED"
+)
+
+// DefaultServiceName is reported when OTEL_SERVICE_NAME isn't set
+const DefaultServiceName = "lxc-dev-manager"
+
+// flushThreshold bounds how many finished spans are kept in memory before
+// they are sent, for long-running commands like serve
+const flushThreshold = 512
+
+// exportTimeout bounds how long an export may delay the command
+const exportTimeout = 5 * time.Second
+
+// Span is a timed unit of work. A nil *Span is valid and does nothing, so
+// callers don't need to check whether tracing is enabled.
+type Span struct {
+	name    string
+	traceID string
+	spanID  string
+	parent  *Span
+	start   time.Time
+	attrs   map[string]any
+	ended   bool
+}
+
+var (
+	mu       sync.Mutex
+	exporter *otlpExporter
+	current  *Span
+	finished []finishedSpan
+)
+
+type finishedSpan struct {
+	span *Span
+	end  time.Time
+	err  error
+}
+
+type otlpExporter struct {
+	url     string
+	headers map[string]string
+	service string
+	client  *http.Client
+}
+
+// Setup enables tracing from the environment. It does nothing when no OTLP
+// endpoint is set or OTEL_SDK_DISABLED is true.
+func Setup() error {
+	if strings.EqualFold(os.Getenv(EnvSDKDisabled), "true") {
+		return nil
+	}
+
+	url := os.Getenv(EnvTracesEndpoint)
+	if url == "" {
+		base := os.Getenv(EnvEndpoint)
+		if base == "" {
+			return nil
+		}
+		url = strings.TrimSuffix(base, "/") + "/v1/traces"
+	}
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return fmt.Errorf("invalid OTLP endpoint '%s': only http:// and https:// are supported", url)
+	}
+
+	headers, err := parseHeaders(os.Getenv(EnvHeaders))
+	if err != nil {
+		return err
+	}
+	service := os.Getenv(EnvServiceName)
+	if service == "" {
+		service = DefaultServiceName
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	exporter = &otlpExporter{
+		url:     url,
+		headers: headers,
+		service: service,
+		client:  &http.Client{Timeout: exportTimeout},
+	}
+	return nil
+}
+
+// parseHeaders reads "key1=value1,key2=value2" with URL-encoded values
+func parseHeaders(s string) (map[string]string, error) {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid %s entry '%s': expected key=value", EnvHeaders, pair)
+		}
+		headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return headers, nil
+}
+
+// Enabled reports whether spans are being recorded
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return exporter != nil
+}
+
+// Start begins a span as a child of the innermost open one. attrs are
+// key-value pairs, as with slog. Returns nil when tracing is disabled.
+func Start(name string, attrs ...any) *Span {
+	mu.Lock()
+	defer mu.Unlock()
+	if exporter == nil {
+		return nil
+	}
+	s := newSpan(name, current, time.Now(), attrs)
+	current = s
+	return s
+}
+
+// StartChild begins a span under parent without making it the innermost
+// open span, for work that runs alongside other spans, such as one
+// container of a parallel create. A nil parent starts a new trace.
+func StartChild(parent *Span, name string, attrs ...any) *Span {
+	mu.Lock()
+	defer mu.Unlock()
+	if exporter == nil {
+		return nil
+	}
+	return newSpan(name, parent, time.Now(), attrs)
+}
+
+func newSpan(name string, parent *Span, start time.Time, attrs []any) *Span {
+	s := &Span{name: name, parent: parent, start: start, spanID: randomHex(8), attrs: toAttrs(attrs)}
+	if parent != nil {
+		s.traceID = parent.traceID
+	} else {
+		s.traceID = randomHex(16)
+	}
+	return s
+}
+
+// End finishes the span, marking it failed when err is non-nil
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	mu.Lock()
+	s.ended = true
+	// Spans can end out of order, so skip any ended ones
+	for current != nil && current.ended {
+		current = current.parent
+	}
+	full := record(s, time.Now(), err)
+	mu.Unlock()
+	if full {
+		Flush()
+	}
+}
+
+// EndErr is End for deferred calls: it reads the error from a named
+// result once the function returns
+func (s *Span) EndErr(err *error) {
+	if s == nil {
+		return
+	}
+	s.End(*err)
+}
+
+// Record adds a span for work that has already completed, such as an lxc
+// command, as a child of the innermost open span
+func Record(name string, start time.Time, err error, attrs ...any) {
+	mu.Lock()
+	if exporter == nil {
+		mu.Unlock()
+		return
+	}
+	full := record(newSpan(name, current, start, attrs), time.Now(), err)
+	mu.Unlock()
+	if full {
+		Flush()
+	}
+}
+
+// record queues a finished span and reports whether the queue should be
+// flushed. Must be called with mu held.
+func record(s *Span, end time.Time, err error) bool {
+	if exporter == nil {
+		return false
+	}
+	finished = append(finished, finishedSpan{span: s, end: end, err: err})
+	return len(finished) >= flushThreshold
+}
+
+// Flush sends the finished spans to the collector. Spans that can't be
+// sent are dropped rather than retried, so a missing collector never
+// slows a command down more than once.
+func Flush() error {
+	mu.Lock()
+	exp, spans := exporter, finished
+	finished = nil
+	mu.Unlock()
+	if exp == nil || len(spans) == 0 {
+		return nil
+	}
+	return exp.export(spans)
+}
+
+func (e *otlpExporter) export(spans []finishedSpan) error {
+	body, err := json.Marshal(e.payload(spans))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export traces: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("failed to export traces: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// OTLP JSON encoding, see
+// https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding
+type (
+	otlpPayload struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpAttr `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID      string     `json:"traceId"`
+		SpanID       string     `json:"spanId"`
+		ParentSpanID string     `json:"parentSpanId,omitempty"`
+		Name         string     `json:"name"`
+		Kind         int        `json:"kind"`
+		Start        string     `json:"startTimeUnixNano"`
+		End          string     `json:"endTimeUnixNano"`
+		Attributes   []otlpAttr `json:"attributes,omitempty"`
+		Status       otlpStatus `json:"status"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code"`
+		Message string `json:"message,omitempty"`
+	}
+	otlpAttr struct {
+		Key   string         `json:"key"`
+		Value map[string]any `json:"value"`
+	}
+)
+
+// Span kinds and status codes from the OTLP protocol
+const (
+	spanKindInternal = 1
+	statusOK         = 1
+	statusError      = 2
+)
+
+func (e *otlpExporter) payload(spans []finishedSpan) otlpPayload {
+	out := make([]otlpSpan, 0, len(spans))
+	for _, f := range spans {
+		s := otlpSpan{
+			TraceID:    f.span.traceID,
+			SpanID:     f.span.spanID,
+			Name:       f.span.name,
+			Kind:       spanKindInternal,
+			Start:      strconv.FormatInt(f.span.start.UnixNano(), 10),
+			End:        strconv.FormatInt(f.end.UnixNano(), 10),
+			Attributes: encodeAttrs(f.span.attrs),
+			Status:     otlpStatus{Code: statusOK},
+		}
+		if f.span.parent != nil {
+			s.ParentSpanID = f.span.parent.spanID
+		}
+		if f.err != nil {
+			s.Status = otlpStatus{Code: statusError, Message: f.err.Error()}
+		}
+		out = append(out, s)
+	}
+
+	return otlpPayload{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: encodeAttrs(map[string]any{"service.name": e.service})},
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: DefaultServiceName},
+			Spans: out,
+		}},
+	}}}
+}
+
+// toAttrs turns slog-style key-value pairs into a map; a trailing key
+// without a value is dropped
+func toAttrs(kv []any) map[string]any {
+	if len(kv) < 2 {
+		return nil
+	}
+	attrs := make(map[string]any, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		attrs[fmt.Sprint(kv[i])] = kv[i+1]
+	}
+	return attrs
+}
+
+func encodeAttrs(attrs map[string]any) []otlpAttr {
+	out := make([]otlpAttr, 0, len(attrs))
+	for k, v := range attrs {
+		var value map[string]any
+		switch v := v.(type) {
+		case bool:
+			value = map[string]any{"boolValue": v}
+		case int:
+			value = map[string]any{"intValue": strconv.Itoa(v)}
+		case int64:
+			value = map[string]any{"intValue": strconv.FormatInt(v, 10)}
+		case float64:
+			value = map[string]any{"doubleValue": v}
+		default:
+			value = map[string]any{"stringValue": fmt.Sprint(v)}
+		}
+		out = append(out, otlpAttr{Key: k, Value: value})
+	}
+	return out
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// reset disables tracing and drops recorded spans, for tests
+func reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	exporter = nil
+	current = nil
+	finished = nil
+}
@@ -0,0 +1,155 @@
+package tracing
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// collector starts an OTLP endpoint and enables tracing against it,
+// returning the decoded spans of each export
+func collector(t *testing.T) *[]otlpPayload {
+	t.Helper()
+	var payloads []otlpPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected request: %s %s", r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("expected headers from %s, got %v", EnvHeaders, r.Header)
+		}
+		body, _ := io.ReadAll(r.Body)
+		var p otlpPayload
+		if err := json.Unmarshal(body, &p); err != nil {
+			t.Errorf("invalid payload %s: %v", body, err)
+		}
+		payloads = append(payloads, p)
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(reset)
+
+	t.Setenv(EnvEndpoint, srv.URL+"/")
+	t.Setenv(EnvHeaders, "Authorization=Bearer secret")
+	t.Setenv(EnvServiceName, "")
+	if err := Setup(); err != nil {
+		t.Fatal(err)
+	}
+	return &payloads
+}
+
+func TestExport_NestsSpans(t *testing.T) {
+	payloads := collector(t)
+
+	root := Start("create", "container", "dev1")
+	Record("lxc launch", time.Now().Add(-time.Second), nil, "lxc.args", "launch ubuntu:24.04 dev1")
+	child := Start("create: waiting for boot")
+	child.End(errors.New("timeout"))
+	root.End(nil)
+
+	if err := Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(*payloads) != 1 {
+		t.Fatalf("expected one export, got %d", len(*payloads))
+	}
+	rs := (*payloads)[0].ResourceSpans[0]
+	if rs.Resource.Attributes[0].Value["stringValue"] != DefaultServiceName {
+		t.Errorf("expected the default service name, got %v", rs.Resource.Attributes)
+	}
+	spans := rs.ScopeSpans[0].Spans
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %d", len(spans))
+	}
+	launch, boot, create := spans[0], spans[1], spans[2]
+	if create.ParentSpanID != "" || launch.ParentSpanID != create.SpanID || boot.ParentSpanID != create.SpanID {
+		t.Errorf("expected both spans under create, got %+v", spans)
+	}
+	if launch.TraceID != create.TraceID || len(create.TraceID) != 32 || len(create.SpanID) != 16 {
+		t.Errorf("unexpected ids: %+v", spans)
+	}
+	if boot.Status.Code != statusError || boot.Status.Message != "timeout" || create.Status.Code != statusOK {
+		t.Errorf("unexpected statuses: %+v / %+v", boot.Status, create.Status)
+	}
+
+	// Nothing left to send
+	if err := Flush(); err != nil || len(*payloads) != 1 {
+		t.Errorf("expected no second export, got %d (err %v)", len(*payloads), err)
+	}
+}
+
+func TestExport_ConcurrentSpans(t *testing.T) {
+	payloads := collector(t)
+
+	batch := Start("create", "containers", 2)
+	// Two containers created alongside each other, ending out of order
+	dev1 := StartChild(batch, "create: launching", "container", "dev1")
+	dev2 := StartChild(batch, "create: launching", "container", "dev2")
+	Record("lxc launch", time.Now(), nil)
+	dev1.End(nil)
+	dev2.End(nil)
+	// A span ended before its child doesn't stay the parent of new ones
+	outer := Start("outer")
+	inner := Start("inner")
+	outer.End(nil)
+	inner.End(nil)
+	after := Start("after")
+	after.End(nil)
+	batch.End(nil)
+
+	if err := Flush(); err != nil {
+		t.Fatal(err)
+	}
+	parents := make(map[string]string)
+	ids := make(map[string]string)
+	for _, s := range (*payloads)[0].ResourceSpans[0].ScopeSpans[0].Spans {
+		key := s.Name
+		for _, a := range s.Attributes {
+			if a.Key == "container" {
+				key += " " + a.Value["stringValue"].(string)
+			}
+		}
+		parents[key] = s.ParentSpanID
+		ids[key] = s.SpanID
+	}
+	for _, key := range []string{"create: launching dev1", "create: launching dev2", "lxc launch", "outer", "after"} {
+		if parents[key] != ids["create"] {
+			t.Errorf("expected %q under the batch span, got parent %q", key, parents[key])
+		}
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	t.Cleanup(reset)
+	t.Setenv(EnvEndpoint, "")
+	t.Setenv(EnvTracesEndpoint, "")
+	if err := Setup(); err != nil {
+		t.Fatal(err)
+	}
+
+	span := Start("create")
+	if span != nil || Enabled() {
+		t.Error("expected tracing to be off without an endpoint")
+	}
+	span.End(nil)
+	if err := Flush(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSetup_Invalid(t *testing.T) {
+	t.Cleanup(reset)
+	t.Setenv(EnvTracesEndpoint, "localhost:4318")
+	if err := Setup(); err == nil {
+		t.Error("expected an error for an endpoint without a scheme")
+	}
+
+	t.Setenv(EnvTracesEndpoint, "http://localhost:4318/v1/traces")
+	t.Setenv(EnvHeaders, "no-equals-sign")
+	if err := Setup(); err == nil {
+		t.Error("expected an error for a malformed header")
+	}
+}
@@ -0,0 +1,410 @@
+// Package tui is the interactive dashboard behind "lxc-dev-manager ui". It
+// lists the project's containers with live status and runs the common
+// operations on the selected one from single keys.
+package tui
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/errcode"
+	"lxc-dev-manager/internal/lxc"
+	"lxc-dev-manager/internal/operations"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// refreshInterval is how often the container list is re-read from LXC
+const refreshInterval = 2 * time.Second
+
+type mode int
+
+const (
+	modeList     mode = iota
+	modeMounts        // showing the selected container's mounts
+	modeSnapshot      // typing a snapshot name
+)
+
+// Model is the dashboard's state
+type Model struct {
+	dir        string
+	project    string
+	containers []operations.ContainerInfo
+	cursor     int
+	mode       mode
+	mounts     []operations.MountInfo
+	input      string // snapshot name being typed
+	busy       string // action in progress, if any
+	message    string // outcome of the last action
+	err        error
+}
+
+// New returns a dashboard for the project in dir (the current directory if
+// empty)
+func New(dir string) Model {
+	return Model{dir: dir}
+}
+
+// Run shows the dashboard until the user quits
+func Run(dir string) error {
+	_, err := tea.NewProgram(New(dir), tea.WithAltScreen()).Run()
+	return err
+}
+
+type (
+	tickMsg time.Time
+
+	containersMsg struct {
+		project    string
+		containers []operations.ContainerInfo
+		err        error
+	}
+
+	mountsMsg struct {
+		mounts []operations.MountInfo
+		err    error
+	}
+
+	actionMsg struct {
+		message string
+		err     error
+	}
+)
+
+func (m Model) Init() tea.Cmd {
+	return tea.Batch(m.refresh(), tick())
+}
+
+func tick() tea.Cmd {
+	return tea.Tick(refreshInterval, func(t time.Time) tea.Msg { return tickMsg(t) })
+}
+
+// refresh re-reads the config and the containers' state. The config is
+// loaded fresh each time so changes made from other terminals show up.
+func (m Model) refresh() tea.Cmd {
+	return func() tea.Msg {
+		cfg, err := config.Load(m.dir)
+		if err != nil {
+			return containersMsg{err: err}
+		}
+		defer operations.InvalidateInventory(cfg)
+		containers, err := operations.List(cfg)
+		return containersMsg{project: cfg.Project, containers: containers, err: err}
+	}
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tickMsg:
+		return m, tea.Batch(m.refresh(), tick())
+
+	case containersMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		m.err = nil
+		m.project = msg.project
+		m.containers = msg.containers
+		if m.cursor >= len(m.containers) {
+			m.cursor = max(len(m.containers)-1, 0)
+		}
+		return m, nil
+
+	case mountsMsg:
+		m.busy = ""
+		if msg.err != nil {
+			m.message = "Error: " + msg.err.Error()
+			return m, nil
+		}
+		m.mounts = msg.mounts
+		m.mode = modeMounts
+		return m, nil
+
+	case actionMsg:
+		m.busy = ""
+		if msg.err != nil {
+			m.message = "Error: " + msg.err.Error()
+		} else {
+			m.message = msg.message
+		}
+		return m, m.refresh()
+
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+	return m, nil
+}
+
+func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() == "ctrl+c" {
+		return m, tea.Quit
+	}
+
+	switch m.mode {
+	case modeSnapshot:
+		return m.handleSnapshotKey(msg)
+	case modeMounts:
+		switch msg.String() {
+		case "esc", "q", "m":
+			m.mode = modeList
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "q":
+		return m, tea.Quit
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+		return m, nil
+	case "down", "j":
+		if m.cursor < len(m.containers)-1 {
+			m.cursor++
+		}
+		return m, nil
+	case "r":
+		return m, m.refresh()
+	}
+
+	name := m.selected()
+	if name == "" || m.busy != "" {
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "u":
+		m.busy = fmt.Sprintf("Starting '%s'...", name)
+		return m, m.withContainer(name, func(cfg *config.Config) (string, error) {
+			if err := operations.Start(cfg, name); err != nil {
+				return "", err
+			}
+			operations.RefreshDNS(cfg)
+			return fmt.Sprintf("Container '%s' started", name), nil
+		})
+	case "d":
+		m.busy = fmt.Sprintf("Stopping '%s'...", name)
+		return m, m.withContainer(name, func(cfg *config.Config) (string, error) {
+			if err := operations.Stop(cfg, name, operations.StopOpts{}); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Container '%s' stopped", name), nil
+		})
+	case "s", "enter":
+		return m, m.shell(name)
+	case "p":
+		m.mode = modeSnapshot
+		m.input = ""
+		return m, nil
+	case "m":
+		m.busy = fmt.Sprintf("Reading mounts of '%s'...", name)
+		return m, m.listMounts(name)
+	}
+	return m, nil
+}
+
+func (m Model) handleSnapshotKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.mode = modeList
+	case tea.KeyEnter:
+		m.mode = modeList
+		name, snapshot := m.selected(), strings.TrimSpace(m.input)
+		if name == "" || snapshot == "" {
+			return m, nil
+		}
+		m.busy = fmt.Sprintf("Creating snapshot '%s' of '%s'...", snapshot, name)
+		return m, m.withContainer(name, func(cfg *config.Config) (string, error) {
+			if err := operations.CreateSnapshot(cfg, name, snapshot, ""); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Snapshot '%s' of '%s' created", snapshot, name), nil
+		})
+	case tea.KeyBackspace:
+		if r := []rune(m.input); len(r) > 0 {
+			m.input = string(r[:len(r)-1])
+		}
+	case tea.KeyRunes:
+		m.input += string(msg.Runes)
+	}
+	return m, nil
+}
+
+func (m Model) selected() string {
+	if m.cursor < len(m.containers) {
+		return m.containers[m.cursor].Name
+	}
+	return ""
+}
+
+// withContainer runs fn holding the container's config lock, as the
+// matching CLI commands do
+func (m Model) withContainer(name string, fn func(cfg *config.Config) (string, error)) tea.Cmd {
+	return func() tea.Msg {
+		cfg, lock, err := config.LoadWithContainerLock(m.dir, name)
+		if err != nil {
+			return actionMsg{err: err}
+		}
+		defer lock.Release()
+		message, err := fn(cfg)
+		return actionMsg{message: message, err: err}
+	}
+}
+
+func (m Model) listMounts(name string) tea.Cmd {
+	return func() tea.Msg {
+		cfg, err := config.Load(m.dir)
+		if err != nil {
+			return mountsMsg{err: err}
+		}
+		mounts, err := operations.ListMounts(cfg, name)
+		return mountsMsg{mounts: mounts, err: err}
+	}
+}
+
+// shell suspends the dashboard for an interactive shell in the container
+func (m Model) shell(name string) tea.Cmd {
+	cfg, err := config.Load(m.dir)
+	if err != nil {
+		return func() tea.Msg { return actionMsg{err: err} }
+	}
+	if status, err := operations.Status(cfg, name); err != nil {
+		return func() tea.Msg { return actionMsg{err: err} }
+	} else if status != "RUNNING" {
+		return func() tea.Msg {
+			return actionMsg{err: errcode.Errorf(errcode.NotRunning, name, "container '%s' is not running", name)}
+		}
+	}
+
+	lxcName, user := cfg.GetLXCName(name), cfg.GetUser(name).Name
+	args := operations.BuildShellArgs(lxcName, user, lxc.DetectUserSwitch(lxcName, user))
+	return tea.ExecProcess(exec.Command("lxc", args...), func(err error) tea.Msg {
+		return actionMsg{err: err}
+	})
+}
+
+func (m Model) View() string {
+	var b strings.Builder
+
+	title := "lxc-dev-manager"
+	if m.project != "" {
+		title += " - " + m.project
+	}
+	b.WriteString(title + "\n\n")
+
+	if m.err != nil {
+		b.WriteString("Error: " + m.err.Error() + "\n\n")
+	}
+
+	switch m.mode {
+	case modeMounts:
+		m.viewMounts(&b)
+		b.WriteString("\n" + m.statusLine())
+		b.WriteString("esc back\n")
+		return b.String()
+	case modeSnapshot:
+		m.viewContainers(&b)
+		fmt.Fprintf(&b, "\nSnapshot name for '%s': %s_\n", m.selected(), m.input)
+		b.WriteString("enter create  esc cancel\n")
+		return b.String()
+	}
+
+	m.viewContainers(&b)
+	b.WriteString("\n" + m.statusLine())
+	b.WriteString("↑/↓ select  u up  d down  s shell  p snapshot  m mounts  r refresh  q quit\n")
+	return b.String()
+}
+
+func (m Model) viewContainers(b *strings.Builder) {
+	if len(m.containers) == 0 {
+		b.WriteString("No containers in this project\n")
+		return
+	}
+
+	rows := [][]string{{"NAME", "STATUS", "IP", "PORTS", "SNAPSHOTS", "MOUNTS"}}
+	for _, c := range m.containers {
+		rows = append(rows, []string{
+			c.Name,
+			c.Status,
+			orDash(c.IP),
+			orDash(formatPorts(c.Ports)),
+			fmt.Sprint(c.Snapshots),
+			fmt.Sprint(c.Mounts),
+		})
+	}
+	for i, line := range alignColumns(rows) {
+		marker := "  "
+		if i > 0 && i-1 == m.cursor {
+			marker = "> "
+		}
+		b.WriteString(marker + line + "\n")
+	}
+}
+
+func (m Model) viewMounts(b *strings.Builder) {
+	fmt.Fprintf(b, "Mounts of '%s':\n\n", m.selected())
+	if len(m.mounts) == 0 {
+		b.WriteString("  (none)\n")
+		return
+	}
+	rows := [][]string{{"NAME", "SOURCE", "PATH", "MODE", "STATUS"}}
+	for _, mt := range m.mounts {
+		rows = append(rows, []string{mt.Name, mt.Source, mt.Path, mt.Mode, mt.Status})
+	}
+	for _, line := range alignColumns(rows) {
+		b.WriteString("  " + line + "\n")
+	}
+}
+
+func (m Model) statusLine() string {
+	switch {
+	case m.busy != "":
+		return m.busy + "\n\n"
+	case m.message != "":
+		return m.message + "\n\n"
+	}
+	return ""
+}
+
+// alignColumns pads each cell to its column's widest value
+func alignColumns(rows [][]string) []string {
+	var widths []int
+	for _, row := range rows {
+		for i, cell := range row {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], len([]rune(cell)))
+		}
+	}
+
+	lines := make([]string, len(rows))
+	for r, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = cell + strings.Repeat(" ", widths[i]-len([]rune(cell)))
+		}
+		lines[r] = strings.TrimRight(strings.Join(cells, "  "), " ")
+	}
+	return lines
+}
+
+func formatPorts(ports []int) string {
+	s := make([]string, len(ports))
+	for i, p := range ports {
+		s[i] = fmt.Sprint(p)
+	}
+	return strings.Join(s, ",")
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,137 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"lxc-dev-manager/internal/lxc"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func setupDashboard(t *testing.T) (Model, *lxc.MockExecutor) {
+	t.Helper()
+	dir := t.TempDir()
+	cfg := "project: test\ncontainers:\n  api:\n    image: ubuntu:24.04\n  dev1:\n    image: ubuntu:24.04\n    ports: [3000]\n"
+	if err := os.WriteFile(filepath.Join(dir, "containers.yaml"), []byte(cfg), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mock := lxc.NewMockExecutor()
+	lxc.SetExecutor(mock)
+	t.Cleanup(lxc.ResetExecutor)
+	mock.SetOutput("list --format json", `[{"name":"test-api","status":"Stopped"},{"name":"test-dev1","status":"Running"}]`)
+
+	m := New(dir)
+	return update(t, m, m.refresh()()), mock
+}
+
+func update(t *testing.T, m Model, msg tea.Msg) Model {
+	t.Helper()
+	next, _ := m.Update(msg)
+	return next.(Model)
+}
+
+func key(s string) tea.KeyMsg {
+	switch s {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+// press sends a key and runs the command it returns, feeding the result
+// back in
+func press(t *testing.T, m Model, k string) Model {
+	t.Helper()
+	next, cmd := m.Update(key(k))
+	m = next.(Model)
+	if cmd != nil {
+		if msg := cmd(); msg != nil {
+			m = update(t, m, msg)
+		}
+	}
+	return m
+}
+
+func TestDashboard_ListsContainers(t *testing.T) {
+	m, _ := setupDashboard(t)
+
+	if len(m.containers) != 2 {
+		t.Fatalf("expected 2 containers, got %+v", m.containers)
+	}
+	view := m.View()
+	for _, want := range []string{"test", "> api", "STOPPED", "dev1", "RUNNING", "3000"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected view to contain %q:\n%s", want, view)
+		}
+	}
+}
+
+func TestDashboard_Navigation(t *testing.T) {
+	m, _ := setupDashboard(t)
+
+	m = update(t, m, key("j"))
+	if m.selected() != "dev1" {
+		t.Errorf("expected dev1 selected, got %s", m.selected())
+	}
+	m = update(t, m, key("j"))
+	if m.selected() != "dev1" {
+		t.Errorf("expected selection to stop at the last container, got %s", m.selected())
+	}
+	m = update(t, m, key("k"))
+	if m.selected() != "api" {
+		t.Errorf("expected api selected, got %s", m.selected())
+	}
+}
+
+func TestDashboard_Stop(t *testing.T) {
+	m, mock := setupDashboard(t)
+	mock.SetOutput("list test-dev1 -cs -f csv", "RUNNING")
+
+	m = update(t, m, key("j"))
+	m = press(t, m, "d")
+
+	if !mock.HasCallPrefix("stop", "test-dev1") {
+		t.Errorf("expected lxc stop, got calls %v", mock.Calls)
+	}
+	if m.message != "Container 'dev1' stopped" {
+		t.Errorf("unexpected message %q", m.message)
+	}
+}
+
+func TestDashboard_Snapshot(t *testing.T) {
+	m, mock := setupDashboard(t)
+	mock.SetError("info test-api/base", "not found")
+
+	m = update(t, m, key("p"))
+	if m.mode != modeSnapshot {
+		t.Fatal("expected snapshot name prompt")
+	}
+	m = update(t, m, key("base"))
+	m = press(t, m, "enter")
+
+	if !mock.HasCall("snapshot", "test-api", "base") {
+		t.Errorf("expected lxc snapshot, got calls %v", mock.Calls)
+	}
+	if m.mode != modeList || !strings.Contains(m.message, "created") {
+		t.Errorf("unexpected state: mode %d, message %q", m.mode, m.message)
+	}
+}
+
+func TestDashboard_ActionError(t *testing.T) {
+	m, mock := setupDashboard(t)
+	mock.SetOutput("list test-dev1 -cs -f csv", "RUNNING")
+	mock.SetError("stop", "device busy")
+
+	m = update(t, m, key("j"))
+	m = press(t, m, "d")
+
+	if !strings.HasPrefix(m.message, "Error:") || !strings.Contains(m.View(), "failed to stop container") {
+		t.Errorf("expected the error to be shown, got %q", m.message)
+	}
+}
@@ -2,10 +2,18 @@ package validation
 
 import (
 	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
+
+	"lxc-dev-manager/internal/errcode"
 )
 
 const (
@@ -25,10 +33,58 @@ const (
 	MaxMountNameLength = 50
 )
 
+const (
+	// DeviceTypeDisk is a host directory mount
+	DeviceTypeDisk = "disk"
+	// DeviceTypeUSB is a USB device passed through by vendor/product ID
+	DeviceTypeUSB = "usb"
+	// DeviceTypeUnixChar is a host character device (e.g. /dev/ttyUSB0)
+	DeviceTypeUnixChar = "unix-char"
+)
+
 var (
 	// LXC naming rules: start with letter, alphanumeric + hyphens
 	containerNameRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9-]*$`)
 
+	// USB vendor/product IDs are 4 hex digits (e.g. 0403)
+	usbIDRegex = regexp.MustCompile(`^[0-9a-fA-F]{4}$`)
+
+	// File modes for unix-char devices (e.g. 0660)
+	fileModeRegex = regexp.MustCompile(`^0?[0-7]{3}$`)
+
+	// raw.idmap entries: "<uid|gid|both> <host>[-<host>] <container>[-<container>]"
+	idmapEntryRegex = regexp.MustCompile(`^(uid|gid|both) ([0-9]+)(-[0-9]+)? ([0-9]+)(-[0-9]+)?$`)
+
+	// Sizes such as 20GiB, 500MB or 1073741824
+	sizeRegex = regexp.MustCompile(`^([0-9]+)(B|kB|KB|MB|GB|TB|KiB|MiB|GiB|TiB)?$`)
+
+	// Login shells: a program name (zsh) or an absolute path (/usr/bin/fish)
+	shellRegex = regexp.MustCompile(`^(/[a-zA-Z0-9._-]+)*/?[a-zA-Z0-9._-]+$`)
+
+	// tz database names such as UTC, Europe/Paris or America/Argentina/Buenos_Aires
+	timezoneRegex = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_+-]*(/[A-Za-z0-9_+-]+)*$`)
+
+	// Locales such as C.UTF-8, en_US.UTF-8 or de_DE@euro
+	localeRegex = regexp.MustCompile(`^[A-Za-z]{1,8}(_[A-Za-z]{2,3})?(\.[A-Za-z0-9-]+)?(@[A-Za-z0-9]+)?$`)
+
+	// Environment variable names such as NODE_ENV
+	envNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+	// LXC remote names such as images or team-registry
+	remoteNameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+	// Image property keys such as os or build.commit
+	imagePropertyRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+	// Terminal multiplexer session names such as main or api-debug
+	sessionNameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+	// limits.cpu: a CPU count (2) or a set of CPUs (0-3, 1,3)
+	cpuLimitRegex = regexp.MustCompile(`^[0-9]+(-[0-9]+)?(,[0-9]+(-[0-9]+)?)*$`)
+
+	// Unix user and group names (useradd/groupadd NAME_REGEX)
+	groupNameRegex = regexp.MustCompile(`^[a-z_][a-z0-9_-]{0,31}$`)
+
 	// Reserved names that conflict with LXC commands/concepts
 	reservedNames = map[string]bool{
 		"list":     true,
@@ -41,6 +97,24 @@ var (
 		"config":   true,
 	}
 
+	// ReservedMountNames are mount and device names that can't be used: the
+	// root disk and network device every container gets from its profile,
+	// and words that read as `lxc config device` subcommands
+	ReservedMountNames = []string{
+		"root",
+		"eth0",
+		"config",
+		"device",
+		"add",
+		"get",
+		"list",
+		"override",
+		"remove",
+		"set",
+		"show",
+		"unset",
+	}
+
 	// BlockedHostPaths are paths that cannot be mounted from the host
 	BlockedHostPaths = []string{
 		"/",
@@ -84,38 +158,38 @@ func ValidateContainerName(name string) error {
 	name = strings.TrimSpace(name)
 
 	if name == "" {
-		return fmt.Errorf("container name cannot be empty")
+		return invalid("container name cannot be empty")
 	}
 
 	if len(name) > MaxContainerNameLength {
-		return fmt.Errorf("container name too long: %d characters (max %d)",
+		return invalid("container name too long: %d characters (max %d)",
 			len(name), MaxContainerNameLength)
 	}
 
 	if !containerNameRegex.MatchString(name) {
 		if name[0] >= '0' && name[0] <= '9' {
-			return fmt.Errorf("container name must start with a letter, not '%c'", name[0])
+			return invalid("container name must start with a letter, not '%c'", name[0])
 		}
 		if strings.Contains(name, " ") {
-			return fmt.Errorf("container name cannot contain spaces")
+			return invalid("container name cannot contain spaces")
 		}
 		if strings.Contains(name, "_") {
-			return fmt.Errorf("container name cannot contain underscores (use hyphens instead)")
+			return invalid("container name cannot contain underscores (use hyphens instead)")
 		}
-		return fmt.Errorf("container name contains invalid characters (allowed: letters, numbers, hyphens)")
+		return invalid("container name contains invalid characters (allowed: letters, numbers, hyphens)")
 	}
 
 	if strings.HasPrefix(name, "-") || strings.HasSuffix(name, "-") {
-		return fmt.Errorf("container name cannot start or end with a hyphen")
+		return invalid("container name cannot start or end with a hyphen")
 	}
 
 	if strings.Contains(name, "--") {
-		return fmt.Errorf("container name cannot contain consecutive hyphens")
+		return invalid("container name cannot contain consecutive hyphens")
 	}
 
 	nameLower := strings.ToLower(name)
 	if reservedNames[nameLower] {
-		return fmt.Errorf("'%s' is a reserved name", name)
+		return invalid("'%s' is a reserved name", name)
 	}
 
 	return nil
@@ -133,7 +207,7 @@ func ValidateFullContainerName(project, container string) error {
 	}
 
 	if len(fullName) > MaxCombinedLength {
-		return fmt.Errorf("full container name '%s' too long: %d characters (max %d). "+
+		return invalid("full container name '%s' too long: %d characters (max %d). "+
 			"Use a shorter project or container name",
 			fullName, len(fullName), MaxCombinedLength)
 	}
@@ -144,7 +218,7 @@ func ValidateFullContainerName(project, container string) error {
 // ValidatePort checks if a port number is valid
 func ValidatePort(port int) error {
 	if port < MinPort || port > MaxPort {
-		return fmt.Errorf("invalid port %d: must be between %d and %d",
+		return invalid("invalid port %d: must be between %d and %d",
 			port, MinPort, MaxPort)
 	}
 	return nil
@@ -160,7 +234,7 @@ func ValidatePorts(ports []int) error {
 		}
 
 		if seen[port] {
-			return fmt.Errorf("duplicate port %d in configuration", port)
+			return invalid("duplicate port %d in configuration", port)
 		}
 		seen[port] = true
 	}
@@ -168,11 +242,99 @@ func ValidatePorts(ports []int) error {
 	return nil
 }
 
-// ValidateSourcePath validates a host source path for mounting.
+// ValidateIPv4 checks that a string is a plain IPv4 address
+func ValidateIPv4(ip string) error {
+	parsed := net.ParseIP(ip)
+	if parsed == nil || parsed.To4() == nil || strings.Contains(ip, ":") {
+		return invalid("invalid IPv4 address %q", ip)
+	}
+	return nil
+}
+
+// ValidateIPInSubnet checks that an IPv4 address is a usable host address
+// within the given CIDR (e.g. "10.10.10.1/24"). The network, broadcast and
+// gateway (the address part of the CIDR) addresses are rejected.
+func ValidateIPInSubnet(ip, cidr string) error {
+	if err := ValidateIPv4(ip); err != nil {
+		return err
+	}
+
+	gateway, subnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return invalid("invalid subnet %q: %w", cidr, err)
+	}
+
+	addr := net.ParseIP(ip).To4()
+	if !subnet.Contains(addr) {
+		return invalid("IP %s is not in subnet %s", ip, subnet.String())
+	}
+
+	if addr.Equal(gateway) {
+		return invalid("IP %s is the network gateway", ip)
+	}
+
+	network := subnet.IP.To4()
+	broadcast := make(net.IP, len(network))
+	for i := range network {
+		broadcast[i] = network[i] | ^subnet.Mask[i]
+	}
+	if addr.Equal(network) {
+		return invalid("IP %s is the network address of %s", ip, subnet.String())
+	}
+	if addr.Equal(broadcast) {
+		return invalid("IP %s is the broadcast address of %s", ip, subnet.String())
+	}
+
+	return nil
+}
+
+// PathPolicy lists the host paths that can't be mounted and those that are
+// risky (mounted only after confirmation). Paths match the resolved source
+// exactly; patterns match as a suffix.
+type PathPolicy struct {
+	BlockedPaths    []string
+	BlockedPatterns []string
+	RiskyPaths      []string
+}
+
+// DefaultPathPolicy returns a policy with the built-in lists
+func DefaultPathPolicy() PathPolicy {
+	return PathPolicy{
+		BlockedPaths:    append([]string(nil), BlockedHostPaths...),
+		BlockedPatterns: append([]string(nil), BlockedHostPatterns...),
+		RiskyPaths:      append([]string(nil), RiskyHostPaths...),
+	}
+}
+
+// Allow removes entries from every list of the policy
+func (p *PathPolicy) Allow(entries ...string) {
+	drop := func(list []string) []string {
+		kept := list[:0:0]
+		for _, item := range list {
+			if !slices.Contains(entries, item) {
+				kept = append(kept, item)
+			}
+		}
+		return kept
+	}
+	p.BlockedPaths = drop(p.BlockedPaths)
+	p.BlockedPatterns = drop(p.BlockedPatterns)
+	p.RiskyPaths = drop(p.RiskyPaths)
+}
+
+// ValidateSourcePath validates a host source path for mounting against the
+// built-in path policy.
 // Returns the resolved absolute path, a warning message (empty if none), and an error.
 func ValidateSourcePath(source string) (resolvedPath string, warning string, err error) {
+	return DefaultPathPolicy().ValidateSourcePath(source)
+}
+
+// ValidateSourcePath validates a host source path for mounting against the
+// policy. Returns the resolved absolute path, a warning message (empty if
+// none), and an error.
+func (p PathPolicy) ValidateSourcePath(source string) (resolvedPath string, warning string, err error) {
 	if source == "" {
-		return "", "", fmt.Errorf("source path cannot be empty")
+		return "", "", invalid("source path cannot be empty")
 	}
 
 	// Convert to absolute path
@@ -185,7 +347,7 @@ func ValidateSourcePath(source string) (resolvedPath string, warning string, err
 	resolvedPath, err = filepath.EvalSymlinks(absPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return "", "", fmt.Errorf("source path does not exist: %s", absPath)
+			return "", "", invalid("source path does not exist: %s", absPath)
 		}
 		return "", "", fmt.Errorf("failed to resolve symlinks: %w", err)
 	}
@@ -197,32 +359,32 @@ func ValidateSourcePath(source string) (resolvedPath string, warning string, err
 	info, err := os.Stat(resolvedPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return "", "", fmt.Errorf("source path does not exist: %s", resolvedPath)
+			return "", "", invalid("source path does not exist: %s", resolvedPath)
 		}
 		return "", "", fmt.Errorf("failed to stat source path: %w", err)
 	}
 
 	// Check is directory (not file)
 	if !info.IsDir() {
-		return "", "", fmt.Errorf("source path must be a directory, not a file: %s", resolvedPath)
+		return "", "", invalid("source path must be a directory, not a file: %s", resolvedPath)
 	}
 
-	// Check against BlockedHostPaths
-	for _, blocked := range BlockedHostPaths {
+	// Check against blocked paths
+	for _, blocked := range p.BlockedPaths {
 		if resolvedPath == blocked {
-			return "", "", fmt.Errorf("mounting '%s' is not allowed for security reasons", resolvedPath)
+			return "", "", invalid("mounting '%s' is not allowed for security reasons", resolvedPath)
 		}
 	}
 
-	// Check against BlockedHostPatterns (suffix match)
-	for _, pattern := range BlockedHostPatterns {
+	// Check against blocked patterns (suffix match)
+	for _, pattern := range p.BlockedPatterns {
 		if strings.HasSuffix(resolvedPath, pattern) {
-			return "", "", fmt.Errorf("mounting paths matching '%s' is not allowed for security reasons", pattern)
+			return "", "", invalid("mounting paths matching '%s' is not allowed for security reasons", pattern)
 		}
 	}
 
-	// Check against RiskyHostPaths (return warning, not error)
-	for _, risky := range RiskyHostPaths {
+	// Check against risky paths (return warning, not error)
+	for _, risky := range p.RiskyPaths {
 		if resolvedPath == risky {
 			warning = fmt.Sprintf("mounting '%s' is risky and may expose sensitive data", resolvedPath)
 			break
@@ -235,23 +397,23 @@ func ValidateSourcePath(source string) (resolvedPath string, warning string, err
 // ValidateContainerPath validates a path inside a container
 func ValidateContainerPath(path string) error {
 	if path == "" {
-		return fmt.Errorf("container path cannot be empty")
+		return invalid("container path cannot be empty")
 	}
 
 	// Must be absolute (starts with /)
 	if !strings.HasPrefix(path, "/") {
-		return fmt.Errorf("container path must be absolute (start with /): %s", path)
+		return invalid("container path must be absolute (start with /): %s", path)
 	}
 
 	// Max length check
 	if len(path) > MaxContainerPathLength {
-		return fmt.Errorf("container path too long: %d characters (max %d)", len(path), MaxContainerPathLength)
+		return invalid("container path too long: %d characters (max %d)", len(path), MaxContainerPathLength)
 	}
 
 	// Check for control characters
 	for _, c := range path {
 		if c == '\x00' || c == '\n' || c == '\r' || c == '\t' {
-			return fmt.Errorf("container path cannot contain control characters")
+			return invalid("container path cannot contain control characters")
 		}
 	}
 
@@ -260,13 +422,13 @@ func ValidateContainerPath(path string) error {
 
 	// No .. traversal after cleaning
 	if strings.Contains(cleanPath, "..") {
-		return fmt.Errorf("container path cannot contain path traversal (..)")
+		return invalid("container path cannot contain path traversal (..)")
 	}
 
 	// Check against BlockedContainerPaths
 	for _, blocked := range BlockedContainerPaths {
 		if cleanPath == blocked {
-			return fmt.Errorf("mounting to '%s' inside container is not allowed", blocked)
+			return invalid("mounting to '%s' inside container is not allowed", blocked)
 		}
 	}
 
@@ -278,35 +440,366 @@ func ValidateMountName(name string) error {
 	name = strings.TrimSpace(name)
 
 	if name == "" {
-		return fmt.Errorf("mount name cannot be empty")
+		return invalid("mount name cannot be empty")
 	}
 
 	if len(name) > MaxMountNameLength {
-		return fmt.Errorf("mount name too long: %d characters (max %d)",
+		return invalid("mount name too long: %d characters (max %d)",
 			len(name), MaxMountNameLength)
 	}
 
 	if !containerNameRegex.MatchString(name) {
 		if name[0] >= '0' && name[0] <= '9' {
-			return fmt.Errorf("mount name must start with a letter, not '%c'", name[0])
+			return invalid("mount name must start with a letter, not '%c'", name[0])
 		}
 		if strings.Contains(name, " ") {
-			return fmt.Errorf("mount name cannot contain spaces")
+			return invalid("mount name cannot contain spaces")
 		}
 		if strings.Contains(name, "_") {
-			return fmt.Errorf("mount name cannot contain underscores (use hyphens instead)")
+			return invalid("mount name cannot contain underscores (use hyphens instead)")
 		}
-		return fmt.Errorf("mount name contains invalid characters (allowed: letters, numbers, hyphens)")
+		return invalid("mount name contains invalid characters (allowed: letters, numbers, hyphens)")
 	}
 
 	if strings.HasPrefix(name, "-") || strings.HasSuffix(name, "-") {
-		return fmt.Errorf("mount name cannot start or end with a hyphen")
+		return invalid("mount name cannot start or end with a hyphen")
 	}
 
 	if strings.Contains(name, "--") {
-		return fmt.Errorf("mount name cannot contain consecutive hyphens")
+		return invalid("mount name cannot contain consecutive hyphens")
+	}
+
+	if IsReservedMountName(name) {
+		return invalid("'%s' is a reserved mount name", name)
+	}
+
+	return nil
+}
+
+// IsReservedMountName reports whether name is in ReservedMountNames,
+// ignoring case
+func IsReservedMountName(name string) bool {
+	for _, reserved := range ReservedMountNames {
+		if strings.EqualFold(name, reserved) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateUSBID checks a USB vendor or product ID (4 hex digits)
+func ValidateUSBID(id string) error {
+	if !usbIDRegex.MatchString(id) {
+		return invalid("invalid USB ID %q: must be 4 hex digits (e.g. 0403)", id)
+	}
+	return nil
+}
+
+// ValidateShell checks a login shell name (zsh) or absolute path (/usr/bin/zsh)
+func ValidateShell(shell string) error {
+	if !shellRegex.MatchString(shell) || strings.Contains(shell, "..") {
+		return invalid("invalid shell %q: must be a program name or absolute path (e.g. zsh)", shell)
+	}
+	return nil
+}
+
+// ValidateTimezone checks a tz database name such as Europe/Paris
+func ValidateTimezone(tz string) error {
+	if !timezoneRegex.MatchString(tz) {
+		return invalid("invalid timezone %q: must be a tz database name (e.g. Europe/Paris)", tz)
+	}
+	return nil
+}
+
+// ValidateLocale checks a locale name such as en_US.UTF-8
+func ValidateLocale(locale string) error {
+	if !localeRegex.MatchString(locale) {
+		return invalid("invalid locale %q: expected e.g. en_US.UTF-8", locale)
+	}
+	return nil
+}
+
+// ValidateEnvName checks an environment variable name such as NODE_ENV
+func ValidateEnvName(name string) error {
+	if !envNameRegex.MatchString(name) {
+		return invalid("invalid environment variable name %q: must be letters, digits or '_', not starting with a digit", name)
+	}
+	return nil
+}
+
+// ValidateRemoteName checks an LXC remote name such as team-registry
+func ValidateRemoteName(name string) error {
+	if !remoteNameRegex.MatchString(name) {
+		return invalid("invalid remote name %q: must be letters, digits, '.', '_' or '-'", name)
+	}
+	return nil
+}
+
+// ValidateSessionName checks a zellij or tmux session name such as main
+func ValidateSessionName(name string) error {
+	if !sessionNameRegex.MatchString(name) {
+		return invalid("invalid session name %q: must be letters, digits, '.', '_' or '-'", name)
+	}
+	return nil
+}
+
+// ValidateImageProperty checks an image property key such as build.commit.
+// source_container is reserved: it's recorded when an image is created.
+func ValidateImageProperty(key string) error {
+	if !imagePropertyRegex.MatchString(key) {
+		return invalid("invalid image property %q: must be letters, digits, '.', '_' or '-'", key)
+	}
+	if key == "source_container" {
+		return invalid("image property %q is set automatically", key)
+	}
+	return nil
+}
+
+// Readiness conditions a container can be waited on for. A port condition
+// is written ReadyPortPrefix followed by the port number, e.g. port:5432.
+const (
+	ReadyNetwork    = "network"    // eth0 has an IPv4 address
+	ReadySystemd    = "systemd"    // systemctl is-system-running reports running or degraded
+	ReadyCloudInit  = "cloud-init" // cloud-init finished, or isn't installed
+	ReadyPortPrefix = "port:"      // Something listens on the TCP port
+)
+
+// ParseReadyCondition checks a readiness condition and returns the port
+// for a port condition (0 otherwise)
+func ParseReadyCondition(cond string) (port int, err error) {
+	switch cond {
+	case ReadyNetwork, ReadySystemd, ReadyCloudInit:
+		return 0, nil
+	}
+	if rest, ok := strings.CutPrefix(cond, ReadyPortPrefix); ok {
+		port, err := strconv.Atoi(rest)
+		if err != nil {
+			return 0, invalid("invalid readiness condition %q: port must be a number", cond)
+		}
+		if err := ValidatePort(port); err != nil {
+			return 0, err
+		}
+		return port, nil
+	}
+	return 0, invalid("invalid readiness condition %q: must be network, systemd, cloud-init or port:<n>", cond)
+}
+
+// ValidateProxyURL checks an HTTP proxy URL such as http://10.10.10.1:3142
+func ValidateProxyURL(proxy string) error {
+	u, err := url.Parse(proxy)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return invalid("invalid proxy URL %q: must be http://host[:port] or https://host[:port]", proxy)
+	}
+	if strings.ContainsAny(proxy, "\"\\' ") || strings.IndexFunc(proxy, unicode.IsControl) >= 0 {
+		return invalid("invalid proxy URL %q: must not contain quotes, spaces or control characters", proxy)
+	}
+	return nil
+}
+
+// ValidateUsername checks a unix user name such as dev
+func ValidateUsername(name string) error {
+	if !groupNameRegex.MatchString(name) {
+		return invalid("invalid user name %q: must be lowercase letters, digits, '_' or '-'", name)
 	}
+	return nil
+}
+
+// ValidateCPULimit checks a limits.cpu value: a number of CPUs such as 2,
+// or the CPUs to pin to such as 0-3 or 1,3
+func ValidateCPULimit(limit string) error {
+	if !cpuLimitRegex.MatchString(limit) || limit == "0" {
+		return invalid("invalid CPU limit %q: expected a CPU count (e.g. 2) or CPU set (e.g. 0-3)", limit)
+	}
+	return nil
+}
+
+// ValidateMemoryLimit checks a limits.memory value: a size such as 4GiB or
+// a percentage of the host's memory such as 50%
+func ValidateMemoryLimit(limit string) error {
+	if percent, ok := strings.CutSuffix(limit, "%"); ok {
+		n, err := strconv.Atoi(percent)
+		if err != nil || n < 1 || n > 100 {
+			return invalid("invalid memory limit %q: percentage must be between 1%% and 100%%", limit)
+		}
+		return nil
+	}
+	if _, err := ParseSize(limit); err != nil {
+		return invalid("invalid memory limit %q: expected a size (e.g. 4GiB) or a percentage (e.g. 50%%)", limit)
+	}
+	return nil
+}
+
+// ValidateGroupName checks a unix group name such as docker
+func ValidateGroupName(group string) error {
+	if !groupNameRegex.MatchString(group) {
+		return invalid("invalid group %q: must be lowercase letters, digits, '_' or '-'", group)
+	}
+	return nil
+}
 
+// ValidateFileMode checks an octal file mode such as 0660
+func ValidateFileMode(mode string) error {
+	if !fileModeRegex.MatchString(mode) {
+		return invalid("invalid mode %q: must be octal (e.g. 0660)", mode)
+	}
+	return nil
+}
+
+// ValidateIDMapEntry checks a single raw.idmap line such as "both 1000 1000"
+func ValidateIDMapEntry(entry string) error {
+	m := idmapEntryRegex.FindStringSubmatch(entry)
+	if m == nil {
+		return invalid("invalid idmap entry %q: expected \"<uid|gid|both> <host-id> <container-id>\"", entry)
+	}
+
+	// Ranges must be the same size on both sides
+	hostRange, containerRange := m[3] != "", m[5] != ""
+	if hostRange != containerRange {
+		return invalid("invalid idmap entry %q: host and container ranges must both be single IDs or both be ranges", entry)
+	}
+	if hostRange {
+		hostStart, _ := strconv.Atoi(m[2])
+		hostEnd, _ := strconv.Atoi(m[3][1:])
+		ctStart, _ := strconv.Atoi(m[4])
+		ctEnd, _ := strconv.Atoi(m[5][1:])
+		if hostEnd < hostStart || ctEnd < ctStart {
+			return invalid("invalid idmap entry %q: range end is before start", entry)
+		}
+		if hostEnd-hostStart != ctEnd-ctStart {
+			return invalid("invalid idmap entry %q: host and container ranges differ in size", entry)
+		}
+	}
+	return nil
+}
+
+// sizeUnits maps LXD size suffixes to bytes
+var sizeUnits = map[string]int64{
+	"":    1,
+	"B":   1,
+	"kB":  1000,
+	"KB":  1000,
+	"MB":  1000 * 1000,
+	"GB":  1000 * 1000 * 1000,
+	"TB":  1000 * 1000 * 1000 * 1000,
+	"KiB": 1 << 10,
+	"MiB": 1 << 20,
+	"GiB": 1 << 30,
+	"TiB": 1 << 40,
+}
+
+// ParseSize converts an LXD size string (e.g. "20GiB") to bytes
+func ParseSize(size string) (int64, error) {
+	m := sizeRegex.FindStringSubmatch(strings.TrimSpace(size))
+	if m == nil {
+		return 0, invalid("invalid size %q: expected a number with an optional unit (e.g. 20GiB, 500MB)", size)
+	}
+	n, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return 0, invalid("invalid size %q: %v", size, err)
+	}
+	if n == 0 {
+		return 0, invalid("invalid size %q: must be greater than zero", size)
+	}
+	return n * sizeUnits[m[2]], nil
+}
+
+// ParseAge converts an age such as "30d", "2w" or "12h" to a duration.
+// Days and weeks are accepted on top of Go's duration units.
+func ParseAge(age string) (time.Duration, error) {
+	age = strings.TrimSpace(age)
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(age, suffix); ok {
+			days, err := strconv.Atoi(n)
+			if err != nil || days < 0 {
+				return 0, invalid("invalid age %q: expected e.g. 30d, 2w or 12h", age)
+			}
+			return time.Duration(days) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(age)
+	if err != nil || d < 0 {
+		return 0, invalid("invalid age %q: expected e.g. 30d, 2w or 12h", age)
+	}
+	return d, nil
+}
+
+// architectures maps LXD's architecture names to the Debian-style names
+// image servers use in aliases such as ubuntu/24.04/arm64
+var architectures = map[string]string{
+	"x86_64":  "amd64",
+	"aarch64": "arm64",
+	"armv7l":  "armhf",
+	"i686":    "i386",
+	"ppc64le": "ppc64el",
+	"s390x":   "s390x",
+	"riscv64": "riscv64",
+}
+
+// ParseArchitecture accepts an architecture by its LXD name (aarch64) or
+// its Debian name (arm64) and returns the LXD name
+func ParseArchitecture(arch string) (string, error) {
+	if _, ok := architectures[arch]; ok {
+		return arch, nil
+	}
+	for name, debian := range architectures {
+		if arch == debian {
+			return name, nil
+		}
+	}
+	return "", invalid("unknown architecture %q: expected e.g. amd64, arm64, x86_64 or aarch64", arch)
+}
+
+// ImageServerArchitecture returns the name image servers use for an LXD
+// architecture name
+func ImageServerArchitecture(arch string) string {
+	if debian, ok := architectures[arch]; ok {
+		return debian
+	}
+	return arch
+}
+
+// FormatSize renders bytes with binary units (e.g. 20.0GiB)
+func FormatSize(bytes int64) string {
+	units := []string{"KiB", "MiB", "GiB", "TiB"}
+	if bytes < 1024 {
+		return fmt.Sprintf("%dB", bytes)
+	}
+	value := float64(bytes)
+	unit := ""
+	for _, u := range units {
+		value /= 1024
+		unit = u
+		if value < 1024 {
+			break
+		}
+	}
+	return fmt.Sprintf("%.1f%s", value, unit)
+}
+
+// PropagationModes are the bind propagation modes LXD accepts for disk devices
+var PropagationModes = []string{"private", "shared", "slave", "unbindable", "rprivate", "rshared", "rslave", "runbindable"}
+
+// ValidatePropagation checks a disk device propagation mode
+func ValidatePropagation(mode string) error {
+	for _, m := range PropagationModes {
+		if mode == m {
+			return nil
+		}
+	}
+	return invalid("invalid propagation %q (allowed: %s)", mode, strings.Join(PropagationModes, ", "))
+}
+
+// ValidateHostDevicePath checks a host device node path such as /dev/ttyUSB0
+func ValidateHostDevicePath(path string) error {
+	if path == "" {
+		return invalid("device path cannot be empty")
+	}
+	if strings.Contains(path, "..") {
+		return invalid("device path cannot contain path traversal (..)")
+	}
+	if !strings.HasPrefix(filepath.Clean(path), "/dev/") {
+		return invalid("device path must be under /dev: %s", path)
+	}
 	return nil
 }
 
@@ -335,8 +828,8 @@ func GenerateMountName(sourcePath string) string {
 	// Remove trailing hyphen
 	name = strings.TrimSuffix(name, "-")
 
-	// Ensure starts with letter (prefix with "mount-" if starts with number or empty)
-	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+	// Ensure starts with letter and isn't reserved (prefix with "mount-")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') || IsReservedMountName(name) {
 		name = "mount-" + name
 	}
 
@@ -349,3 +842,8 @@ func GenerateMountName(sourcePath string) string {
 
 	return name
 }
+
+// invalid formats an error for input that failed validation
+func invalid(format string, args ...any) error {
+	return errcode.Errorf(errcode.Validation, "", format, args...)
+}
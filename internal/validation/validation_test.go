@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestValidateContainerName(t *testing.T) {
@@ -287,6 +288,44 @@ func TestValidateSourcePath_RiskyPath(t *testing.T) {
 	}
 }
 
+func TestPathPolicy_Allow(t *testing.T) {
+	if _, err := os.Stat("/tmp"); os.IsNotExist(err) {
+		t.Skip("/tmp does not exist on this system")
+	}
+
+	policy := DefaultPathPolicy()
+	policy.Allow("/tmp", "/.ssh")
+
+	if _, warning, err := policy.ValidateSourcePath("/tmp"); err != nil || warning != "" {
+		t.Errorf("expected /tmp to be allowed without warning, got %q, %v", warning, err)
+	}
+	sshDir := filepath.Join(t.TempDir(), ".ssh")
+	if err := os.Mkdir(sshDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := policy.ValidateSourcePath(sshDir); err != nil {
+		t.Errorf("expected .ssh pattern to be allowed, got %v", err)
+	}
+
+	// the built-in lists are untouched
+	if _, warning, _ := ValidateSourcePath("/tmp"); warning == "" {
+		t.Error("expected the default policy to still warn for /tmp")
+	}
+}
+
+func TestPathPolicy_CustomBlockedPath(t *testing.T) {
+	dir := t.TempDir()
+	policy := DefaultPathPolicy()
+	policy.BlockedPaths = append(policy.BlockedPaths, dir)
+
+	if _, _, err := policy.ValidateSourcePath(dir); err == nil || !strings.Contains(err.Error(), "not allowed") {
+		t.Errorf("expected blocked path error, got %v", err)
+	}
+	if _, _, err := policy.ValidateSourcePath(filepath.Join(dir, ".")); err == nil {
+		t.Error("expected the cleaned path to be blocked too")
+	}
+}
+
 func TestValidateSourcePath_ResolvesSymlink(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -479,6 +518,10 @@ func TestValidateMountName_Invalid(t *testing.T) {
 		{"trailing hyphen", "mount-", "cannot start or end with a hyphen"},
 		{"consecutive hyphens", "my--mount", "consecutive hyphens"},
 		{"empty", "", "cannot be empty"},
+		{"reserved config", "config", "reserved mount name"},
+		{"reserved root disk", "root", "reserved mount name"},
+		{"reserved nic", "eth0", "reserved mount name"},
+		{"reserved any case", "Override", "reserved mount name"},
 	}
 
 	for _, tt := range tests {
@@ -557,6 +600,46 @@ func TestGenerateMountName_StartsWithNumber(t *testing.T) {
 	}
 }
 
+func TestGenerateMountName_Reserved(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"/home/user/project/config", "mount-config"},
+		{"/srv/Root", "mount-Root"},
+		{"/srv/configs", "configs"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result := GenerateMountName(tt.input)
+			if result != tt.expected {
+				t.Errorf("GenerateMountName(%q) = %q, expected %q", tt.input, result, tt.expected)
+			}
+			if err := ValidateMountName(result); err != nil {
+				t.Errorf("generated name %q is invalid: %v", result, err)
+			}
+		})
+	}
+}
+
+func TestValidateMountName_ReservedListIsSeparate(t *testing.T) {
+	// Container names and mount names have their own reserved words
+	if err := ValidateMountName("snapshot"); err != nil {
+		t.Errorf("ValidateMountName(\"snapshot\") unexpected error: %v", err)
+	}
+	if err := ValidateContainerName("root"); err != nil {
+		t.Errorf("ValidateContainerName(\"root\") unexpected error: %v", err)
+	}
+
+	saved := ReservedMountNames
+	t.Cleanup(func() { ReservedMountNames = saved })
+	ReservedMountNames = append([]string{"cache"}, saved...)
+	if err := ValidateMountName("cache"); err == nil {
+		t.Error("expected error for name added to ReservedMountNames")
+	}
+}
+
 func TestGenerateMountName_TooLong(t *testing.T) {
 	// Create a path with a very long base name
 	longName := strings.Repeat("a", 100)
@@ -572,3 +655,358 @@ func TestGenerateMountName_TooLong(t *testing.T) {
 			MaxMountNameLength, len(result), result)
 	}
 }
+
+func TestValidateIPInSubnet(t *testing.T) {
+	tests := []struct {
+		ip      string
+		cidr    string
+		wantErr bool
+		errMsg  string
+	}{
+		{"10.10.10.50", "10.10.10.1/24", false, ""},
+		{"10.10.10.254", "10.10.10.1/24", false, ""},
+		{"10.10.11.50", "10.10.10.1/24", true, "not in subnet"},
+		{"10.10.10.1", "10.10.10.1/24", true, "gateway"},
+		{"10.10.10.0", "10.10.10.1/24", true, "network address"},
+		{"10.10.10.255", "10.10.10.1/24", true, "broadcast"},
+		{"not-an-ip", "10.10.10.1/24", true, "invalid IPv4"},
+		{"fd42::10", "10.10.10.1/24", true, "invalid IPv4"},
+		{"10.10.10.50", "garbage", true, "invalid subnet"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip+"_"+tt.cidr, func(t *testing.T) {
+			err := ValidateIPInSubnet(tt.ip, tt.cidr)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ValidateIPInSubnet(%q, %q) expected error", tt.ip, tt.cidr)
+				} else if !strings.Contains(err.Error(), tt.errMsg) {
+					t.Errorf("expected error containing %q, got %q", tt.errMsg, err.Error())
+				}
+			} else if err != nil {
+				t.Errorf("ValidateIPInSubnet(%q, %q) unexpected error: %v", tt.ip, tt.cidr, err)
+			}
+		})
+	}
+}
+
+func TestValidateUSBID(t *testing.T) {
+	tests := []struct {
+		id      string
+		wantErr bool
+	}{
+		{"0403", false},
+		{"abcd", false},
+		{"ABCD", false},
+		{"", true},
+		{"403", true},
+		{"04031", true},
+		{"xyz1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			err := ValidateUSBID(tt.id)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateUSBID(%q) error = %v, wantErr %v", tt.id, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateUserEnvironment(t *testing.T) {
+	tests := []struct {
+		name     string
+		validate func(string) error
+		value    string
+		wantErr  bool
+	}{
+		{"shell name", ValidateShell, "zsh", false},
+		{"shell path", ValidateShell, "/usr/bin/fish", false},
+		{"shell with args", ValidateShell, "zsh -l", true},
+		{"shell traversal", ValidateShell, "/usr/../bin/zsh", true},
+		{"timezone UTC", ValidateTimezone, "UTC", false},
+		{"timezone region", ValidateTimezone, "America/Argentina/Buenos_Aires", false},
+		{"timezone offset", ValidateTimezone, "Etc/GMT+2", false},
+		{"timezone traversal", ValidateTimezone, "../etc/passwd", true},
+		{"timezone absolute", ValidateTimezone, "/etc/passwd", true},
+		{"locale", ValidateLocale, "en_US.UTF-8", false},
+		{"locale C", ValidateLocale, "C.UTF-8", false},
+		{"locale modifier", ValidateLocale, "de_DE@euro", false},
+		{"locale injection", ValidateLocale, "en_US; rm -rf /", true},
+		{"env name", ValidateEnvName, "NODE_ENV", false},
+		{"env name leading digit", ValidateEnvName, "1PASSWORD", true},
+		{"env name injection", ValidateEnvName, "A;B", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.validate(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validate(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateHostDevicePath(t *testing.T) {
+	tests := []struct {
+		path    string
+		wantErr bool
+	}{
+		{"/dev/ttyUSB0", false},
+		{"/dev/bus/usb/001/002", false},
+		{"/etc/passwd", true},
+		{"/dev/../etc/passwd", true},
+		{"ttyUSB0", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			err := ValidateHostDevicePath(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateHostDevicePath(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidatePropagation(t *testing.T) {
+	for _, mode := range PropagationModes {
+		if err := ValidatePropagation(mode); err != nil {
+			t.Errorf("ValidatePropagation(%q) unexpected error: %v", mode, err)
+		}
+	}
+	for _, mode := range []string{"", "Shared", "slaves", "rw"} {
+		if err := ValidatePropagation(mode); err == nil {
+			t.Errorf("ValidatePropagation(%q) expected error", mode)
+		}
+	}
+}
+
+func TestValidateImageProperty(t *testing.T) {
+	for _, key := range []string{"os", "build.commit", "node_version", "release-1"} {
+		if err := ValidateImageProperty(key); err != nil {
+			t.Errorf("ValidateImageProperty(%q) unexpected error: %v", key, err)
+		}
+	}
+	for _, key := range []string{"", ".hidden", "has space", "a=b", "source_container"} {
+		if err := ValidateImageProperty(key); err == nil {
+			t.Errorf("ValidateImageProperty(%q) expected error", key)
+		}
+	}
+}
+
+func TestValidateIDMapEntry(t *testing.T) {
+	tests := []struct {
+		entry   string
+		wantErr bool
+	}{
+		{"both 1000 1000", false},
+		{"uid 1000 0", false},
+		{"gid 100 100", false},
+		{"both 1000-1009 2000-2009", false},
+		{"", true},
+		{"both 1000", true},
+		{"user 1000 1000", true},
+		{"both -1 1000", true},
+		{"both 1000-1009 2000", true},
+		{"both 1000-1009 2000-2005", true},
+		{"both 1009-1000 2009-2000", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.entry, func(t *testing.T) {
+			err := ValidateIDMapEntry(tt.entry)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateIDMapEntry(%q) error = %v, wantErr %v", tt.entry, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseReadyCondition(t *testing.T) {
+	tests := []struct {
+		cond     string
+		wantPort int
+		wantErr  bool
+	}{
+		{"network", 0, false},
+		{"systemd", 0, false},
+		{"cloud-init", 0, false},
+		{"port:5432", 5432, false},
+		{"", 0, true},
+		{"docker", 0, true},
+		{"port:", 0, true},
+		{"port:http", 0, true},
+		{"port:70000", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cond, func(t *testing.T) {
+			port, err := ParseReadyCondition(tt.cond)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseReadyCondition(%q) error = %v, wantErr %v", tt.cond, err, tt.wantErr)
+			}
+			if port != tt.wantPort {
+				t.Errorf("ParseReadyCondition(%q) port = %d, want %d", tt.cond, port, tt.wantPort)
+			}
+		})
+	}
+}
+
+func TestValidateProxyURL(t *testing.T) {
+	tests := []struct {
+		proxy   string
+		wantErr bool
+	}{
+		{"http://10.10.10.1:3142", false},
+		{"https://proxy.example.com", false},
+		{"http://user:pw@proxy:3128/", false},
+		{"", true},
+		{"10.10.10.1:3142", true},
+		{"ftp://proxy", true},
+		{"http://", true},
+		{`http://proxy";`, true},
+		{"http://proxy\n", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.proxy, func(t *testing.T) {
+			err := ValidateProxyURL(tt.proxy)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateProxyURL(%q) error = %v, wantErr %v", tt.proxy, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		size    string
+		want    int64
+		wantErr bool
+	}{
+		{"20GiB", 20 << 30, false},
+		{"500MB", 500 * 1000 * 1000, false},
+		{"1024", 1024, false},
+		{"1TiB", 1 << 40, false},
+		{"", 0, true},
+		{"0GiB", 0, true},
+		{"20 GiB", 0, true},
+		{"20G", 0, true},
+		{"-5GiB", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.size, func(t *testing.T) {
+			got, err := ParseSize(tt.size)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSize(%q) error = %v, wantErr %v", tt.size, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseSize(%q) = %d, want %d", tt.size, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		age     string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"30d", 30 * 24 * time.Hour, false},
+		{"2w", 14 * 24 * time.Hour, false},
+		{"12h", 12 * time.Hour, false},
+		{"0d", 0, false},
+		{"", 0, true},
+		{"d", 0, true},
+		{"-1d", 0, true},
+		{"1.5d", 0, true},
+		{"30days", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.age, func(t *testing.T) {
+			got, err := ParseAge(tt.age)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseAge(%q) error = %v, wantErr %v", tt.age, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseAge(%q) = %v, want %v", tt.age, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseArchitecture(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"x86_64", "x86_64"},
+		{"amd64", "x86_64"},
+		{"arm64", "aarch64"},
+		{"aarch64", "aarch64"},
+		{"armhf", "armv7l"},
+	}
+	for _, tt := range tests {
+		got, err := ParseArchitecture(tt.input)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseArchitecture(%q) = %q, %v; want %q", tt.input, got, err, tt.want)
+		}
+	}
+	for _, arch := range []string{"", "arm", "AMD64", "x64"} {
+		if _, err := ParseArchitecture(arch); err == nil {
+			t.Errorf("ParseArchitecture(%q) expected error", arch)
+		}
+	}
+
+	if got := ImageServerArchitecture("aarch64"); got != "arm64" {
+		t.Errorf("ImageServerArchitecture(aarch64) = %q, want arm64", got)
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		bytes int64
+		want  string
+	}{
+		{512, "512B"},
+		{1536, "1.5KiB"},
+		{20 << 30, "20.0GiB"},
+		{3 << 40, "3.0TiB"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatSize(tt.bytes); got != tt.want {
+			t.Errorf("FormatSize(%d) = %q, want %q", tt.bytes, got, tt.want)
+		}
+	}
+}
+
+func TestValidateResourceLimits(t *testing.T) {
+	for _, limit := range []string{"1", "2", "0-3", "1,3", "0-1,4"} {
+		if err := ValidateCPULimit(limit); err != nil {
+			t.Errorf("ValidateCPULimit(%q) unexpected error: %v", limit, err)
+		}
+	}
+	for _, limit := range []string{"", "0", "two", "1-", "-1", "1.5"} {
+		if err := ValidateCPULimit(limit); err == nil {
+			t.Errorf("ValidateCPULimit(%q) expected error", limit)
+		}
+	}
+
+	for _, limit := range []string{"4GiB", "512MB", "50%", "100%"} {
+		if err := ValidateMemoryLimit(limit); err != nil {
+			t.Errorf("ValidateMemoryLimit(%q) unexpected error: %v", limit, err)
+		}
+	}
+	for _, limit := range []string{"", "lots", "0", "0%", "150%", "%"} {
+		if err := ValidateMemoryLimit(limit); err == nil {
+			t.Errorf("ValidateMemoryLimit(%q) expected error", limit)
+		}
+	}
+}
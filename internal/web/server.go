@@ -0,0 +1,339 @@
+// Package web serves the dashboard behind "lxc-dev-manager serve": a JSON
+// API over the operations package and a single embedded page that uses it.
+package web
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/errcode"
+	"lxc-dev-manager/internal/operations"
+)
+
+//go:embed static
+var static embed.FS
+
+// Server handles dashboard and API requests for one project
+type Server struct {
+	dir   string
+	hosts map[string]bool // Host header names accepted, any if nil
+}
+
+// NewServer returns a server for the project in dir (the current directory
+// if empty)
+func NewServer(dir string) *Server {
+	return &Server{dir: dir}
+}
+
+// AllowHosts makes the server reject requests whose Host header (without
+// the port) isn't one of hosts. Set when listening on loopback, it stops a
+// site whose DNS name was rebound to 127.0.0.1 from reaching the API with
+// its own name as both Host and Origin.
+func (s *Server) AllowHosts(hosts ...string) *Server {
+	s.hosts = make(map[string]bool, len(hosts))
+	for _, host := range hosts {
+		s.hosts[strings.ToLower(strings.Trim(host, "[]"))] = true
+	}
+	return s
+}
+
+// Handler returns the HTTP handler for the dashboard and API
+func (s *Server) Handler() http.Handler {
+	pages, _ := fs.Sub(static, "static") // only fails for invalid paths
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /", http.FileServerFS(pages))
+	mux.HandleFunc("GET /api/containers", s.listContainers)
+	mux.HandleFunc("GET /api/containers/{name}", s.containerDetails)
+	mux.HandleFunc("GET /api/containers/{name}/log", s.containerLog)
+	mux.HandleFunc("POST /api/containers/{name}/start", s.action("start", s.start))
+	mux.HandleFunc("POST /api/containers/{name}/stop", s.action("stop", s.stop))
+	mux.HandleFunc("POST /api/containers/{name}/reset", s.action("reset", s.reset))
+	if s.hosts == nil {
+		return mux
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.hosts[requestHost(r)] {
+			writeJSON(w, http.StatusForbidden, errorJSON{Code: errcode.Validation, Message: fmt.Sprintf("host '%s' is not allowed", r.Host)})
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// requestHost returns the lowercased host name a request was sent to,
+// without the port or IPv6 brackets
+func requestHost(r *http.Request) string {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(strings.Trim(host, "[]"))
+}
+
+// containerJSON is a container as listed by the API
+type containerJSON struct {
+	Name        string `json:"name"`
+	Image       string `json:"image"`
+	Description string `json:"description,omitempty"`
+	Status      string `json:"status"`
+	IP          string `json:"ip,omitempty"`
+	IPv6        string `json:"ipv6,omitempty"`
+	Ports       []int  `json:"ports"`
+	Snapshots   int    `json:"snapshots"`
+	Mounts      int    `json:"mounts"`
+	DiskUsage   int64  `json:"disk_usage"`
+}
+
+// detailsJSON is one container with its snapshots and mounts
+type detailsJSON struct {
+	Name        string         `json:"name"`
+	Image       string         `json:"image"`
+	Description string         `json:"description,omitempty"`
+	Status      string         `json:"status"`
+	IP          string         `json:"ip,omitempty"`
+	IPv6        string         `json:"ipv6,omitempty"`
+	StartedAt   *time.Time     `json:"started_at,omitempty"`
+	User        string         `json:"user"`
+	Ports       []int          `json:"ports"`
+	Listening   []int          `json:"listening"`
+	Mounts      []mountJSON    `json:"mounts"`
+	Snapshots   []snapshotJSON `json:"snapshots"`
+	DiskUsage   int64          `json:"disk_usage"`
+}
+
+type mountJSON struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+	Path   string `json:"path"`
+	Mode   string `json:"mode"`
+	Status string `json:"status"`
+}
+
+type snapshotJSON struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+}
+
+// errorJSON matches what --json-errors prints
+type errorJSON struct {
+	Code      errcode.Code `json:"code"`
+	Message   string       `json:"message"`
+	Container string       `json:"container"`
+}
+
+func (s *Server) listContainers(w http.ResponseWriter, r *http.Request) {
+	cfg, err := config.Load(s.dir)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer operations.InvalidateInventory(cfg)
+
+	containers, err := operations.List(cfg)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	out := make([]containerJSON, 0, len(containers))
+	for _, c := range containers {
+		out = append(out, containerJSON{
+			Name:        c.Name,
+			Image:       c.Image,
+			Description: c.Description,
+			Status:      c.Status,
+			IP:          c.IP,
+			IPv6:        c.IPv6,
+			Ports:       nonNil(c.Ports),
+			Snapshots:   c.Snapshots,
+			Mounts:      c.Mounts,
+			DiskUsage:   c.DiskUsage,
+		})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) containerDetails(w http.ResponseWriter, r *http.Request) {
+	cfg, err := config.Load(s.dir)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	d, err := operations.Info(cfg, r.PathValue("name"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	out := detailsJSON{
+		Name:        d.Name,
+		Image:       d.Image,
+		Description: d.Description,
+		Status:      d.Status,
+		IP:          d.IP,
+		IPv6:        d.IPv6,
+		StartedAt:   timeOrNil(d.StartedAt),
+		User:        d.User,
+		Ports:       nonNil(d.Ports),
+		Listening:   nonNil(d.Listening),
+		Mounts:      []mountJSON{},
+		Snapshots:   []snapshotJSON{},
+		DiskUsage:   d.DiskUsage,
+	}
+	for _, m := range d.Mounts {
+		out.Mounts = append(out.Mounts, mountJSON{Name: m.Name, Source: m.Source, Path: m.Path, Mode: m.Mode, Status: m.Status})
+	}
+	for _, sn := range d.Snapshots {
+		out.Snapshots = append(out.Snapshots, snapshotJSON{Name: sn.Name, Description: sn.Description, CreatedAt: timeOrNil(sn.CreatedAt)})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) containerLog(w http.ResponseWriter, r *http.Request) {
+	cfg, err := config.Load(s.dir)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	log, err := operations.Log(cfg, r.PathValue("name"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"log": log})
+}
+
+// action wraps a handler that changes a container: it rejects cross-site
+// requests, holds the container's config lock like the matching CLI
+// command, and records the change in the audit log
+func (s *Server) action(verb string, fn func(cfg *config.Config, name string, r *http.Request) (string, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !sameOrigin(r) {
+			writeJSON(w, http.StatusForbidden, errorJSON{Code: errcode.Validation, Message: "cross-origin requests are not allowed"})
+			return
+		}
+
+		// Check the name before it's used for a lock file
+		name := r.PathValue("name")
+		cfg, err := config.Load(s.dir)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if !cfg.HasContainer(name) {
+			writeError(w, cfg.ContainerNotFound(name))
+			return
+		}
+
+		cfg, lock, err := config.LoadWithContainerLock(s.dir, name)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		message, err := fn(cfg, name, r)
+		lock.Release()
+
+		s.audit(verb, name, err)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"message": message})
+	}
+}
+
+func (s *Server) start(cfg *config.Config, name string, r *http.Request) (string, error) {
+	if err := operations.Start(cfg, name); err != nil {
+		return "", err
+	}
+	if _, err := operations.RefreshDNS(cfg); err != nil {
+		slog.Warn("failed to update DNS entries", "error", err)
+	}
+	return fmt.Sprintf("Container '%s' started", name), nil
+}
+
+func (s *Server) stop(cfg *config.Config, name string, r *http.Request) (string, error) {
+	if err := operations.Stop(cfg, name, operations.StopOpts{}); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Container '%s' stopped", name), nil
+}
+
+func (s *Server) reset(cfg *config.Config, name string, r *http.Request) (string, error) {
+	snapshot := r.URL.Query().Get("snapshot")
+	if snapshot == "" {
+		snapshot = "initial-state"
+	}
+	preReset, err := operations.Reset(cfg, name, snapshot, operations.ResetOpts{})
+	if err != nil {
+		return "", err
+	}
+	if preReset != "" {
+		return fmt.Sprintf("Container '%s' reset to '%s' (previous state kept as '%s')", name, snapshot, preReset), nil
+	}
+	return fmt.Sprintf("Container '%s' reset to '%s'", name, snapshot), nil
+}
+
+// audit records a change made through the API the way the CLI records
+// commands, with "serve" as the command
+func (s *Server) audit(verb, name string, err error) {
+	entry := config.AuditEntry{Command: "serve", Args: []string{verb, name}}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if err := config.AppendAudit(s.dir, entry); err != nil {
+		slog.Warn("failed to write audit log", "error", err)
+	}
+}
+
+// sameOrigin reports whether a browser request came from the dashboard
+// itself. Requests without an Origin header (curl, scripts) are allowed.
+// This alone doesn't stop DNS rebinding, see AllowHosts.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	code := errcode.Of(err)
+	writeJSON(w, code.HTTPStatus(), errorJSON{
+		Code:      code,
+		Message:   err.Error(),
+		Container: errcode.ContainerOf(err),
+	})
+}
+
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func nonNil(ports []int) []int {
+	if ports == nil {
+		return []int{}
+	}
+	return ports
+}
@@ -0,0 +1,177 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/lxc"
+)
+
+func setupServer(t *testing.T) (http.Handler, *lxc.MockExecutor, string) {
+	t.Helper()
+	dir := t.TempDir()
+	cfg := "project: test\ncontainers:\n  dev1:\n    image: ubuntu:24.04\n    ports: [3000]\n"
+	if err := os.WriteFile(filepath.Join(dir, config.ConfigFile), []byte(cfg), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mock := lxc.NewMockExecutor()
+	lxc.SetExecutor(mock)
+	t.Cleanup(lxc.ResetExecutor)
+	mock.SetOutput("list --format json", `[{"name":"test-dev1","status":"Running"}]`)
+	mock.SetOutput("list test-dev1 -cs -f csv", "RUNNING")
+
+	return NewServer(dir).Handler(), mock, dir
+}
+
+func do(t *testing.T, h http.Handler, method, path string, header map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestListContainers(t *testing.T) {
+	h, _, _ := setupServer(t)
+
+	rec := do(t, h, "GET", "/api/containers", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var got []containerJSON
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Name != "dev1" || got[0].Status != "RUNNING" || got[0].Ports[0] != 3000 {
+		t.Errorf("unexpected containers: %+v", got)
+	}
+}
+
+func TestContainerLog(t *testing.T) {
+	h, mock, _ := setupServer(t)
+	mock.SetOutput("info test-dev1 --show-log", "Name: test-dev1\nStatus: RUNNING\n\nLog:\n\nlxc test-dev1 start\n")
+
+	rec := do(t, h, "GET", "/api/containers/dev1/log", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	if !strings.Contains(rec.Body.String(), `"log":"lxc test-dev1 start"`) {
+		t.Errorf("unexpected body: %s", rec.Body)
+	}
+}
+
+func TestStop(t *testing.T) {
+	h, mock, dir := setupServer(t)
+
+	rec := do(t, h, "POST", "/api/containers/dev1/stop", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	if !mock.HasCallPrefix("stop", "test-dev1") {
+		t.Errorf("expected lxc stop, got calls %v", mock.Calls)
+	}
+
+	entries, err := config.ReadAudit(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Command != "serve" || strings.Join(entries[0].Args, " ") != "stop dev1" {
+		t.Errorf("unexpected audit entries: %+v", entries)
+	}
+}
+
+func TestReset_Snapshot(t *testing.T) {
+	h, mock, _ := setupServer(t)
+
+	rec := do(t, h, "POST", "/api/containers/dev1/reset?snapshot=base", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	if !mock.HasCall("restore", "test-dev1", "base") {
+		t.Errorf("expected lxc restore, got calls %v", mock.Calls)
+	}
+}
+
+func TestAction_NotFound(t *testing.T) {
+	h, _, _ := setupServer(t)
+
+	rec := do(t, h, "POST", "/api/containers/web/start", nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", rec.Code, rec.Body)
+	}
+	var got errorJSON
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Code != "not_found" || got.Container != "web" {
+		t.Errorf("unexpected error: %+v", got)
+	}
+}
+
+func TestAction_CrossOrigin(t *testing.T) {
+	h, mock, _ := setupServer(t)
+
+	rec := do(t, h, "POST", "/api/containers/dev1/stop", map[string]string{"Origin": "http://evil.example"})
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", rec.Code)
+	}
+	if mock.HasCallPrefix("stop") {
+		t.Error("expected no lxc stop for a cross-origin request")
+	}
+}
+
+func TestAllowHosts(t *testing.T) {
+	_, mock, dir := setupServer(t)
+	h := NewServer(dir).AllowHosts("localhost", "127.0.0.1", "::1").Handler()
+
+	for _, host := range []string{"localhost:8420", "127.0.0.1:8420", "[::1]:8420", "LOCALHOST"} {
+		req := httptest.NewRequest("GET", "/api/containers", nil)
+		req.Host = host
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("Host %s: expected 200, got %d", host, rec.Code)
+		}
+	}
+
+	// A rebound name sends matching Host and Origin headers
+	req := httptest.NewRequest("POST", "/api/containers/dev1/stop", nil)
+	req.Host = "evil.example:8420"
+	req.Header.Set("Origin", "http://evil.example:8420")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", rec.Code)
+	}
+	if mock.HasCallPrefix("stop") {
+		t.Error("expected no lxc stop for a rebound host")
+	}
+}
+
+func TestAction_RequiresPost(t *testing.T) {
+	h, mock, _ := setupServer(t)
+
+	rec := do(t, h, "GET", "/api/containers/dev1/stop", nil)
+	if rec.Code == http.StatusOK || mock.HasCallPrefix("stop") {
+		t.Errorf("expected GET not to stop the container, got %d", rec.Code)
+	}
+}
+
+func TestDashboardPage(t *testing.T) {
+	h, _, _ := setupServer(t)
+
+	rec := do(t, h, "GET", "/", nil)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "<title>lxc-dev-manager</title>") {
+		t.Errorf("unexpected response %d: %.100s", rec.Code, rec.Body)
+	}
+}
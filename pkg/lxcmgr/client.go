@@ -1,48 +1,58 @@
 package lxcmgr
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"sync"
 
 	"lxc-dev-manager/internal/config"
 	"lxc-dev-manager/internal/lxc"
 	"lxc-dev-manager/internal/operations"
 )
 
-// Client manages containers within an lxc-dev-manager project
+// Client manages containers within an lxc-dev-manager project. It is safe
+// for concurrent use: methods read containers.yaml again when it has changed
+// since it was last read, and changes to it are made under the project's
+// config lock, so they don't clobber each other or other processes' changes.
 type Client struct {
 	dir      string
-	cfg      *config.Config
 	executor lxc.Executor
+	pinned   bool // WithoutAutoReload
+
+	mu     sync.Mutex
+	cfg    *config.Config // Only read; replaced, never modified in place
+	loaded os.FileInfo    // containers.yaml as it was when cfg was read
+}
+
+// Executor runs lxc commands. args are what follows "lxc", e.g.
+// ["list", "dev1", "-cs", "-f", "csv"]. Run returns the command's stdout;
+// RunCombined returns stdout and stderr together. Package lxcmgrtest has a
+// fake for unit tests.
+type Executor interface {
+	Run(args ...string) ([]byte, error)
+	RunCombined(args ...string) ([]byte, error)
 }
 
 // New opens an existing project
-func New(projectDir string) (*Client, error) {
-	absDir, err := filepath.Abs(projectDir)
-	if err != nil {
-		return nil, err
-	}
+func New(projectDir string, opts ...ClientOption) (*Client, error) {
+	return open(projectDir, lxc.DefaultExecutor, opts)
+}
 
-	// Verify directory exists
-	if _, err := os.Stat(absDir); os.IsNotExist(err) {
-		return nil, ErrProjectNotFound
-	}
+// NewWithExecutor opens an existing project, running lxc commands through
+// executor (for testing). The executor is process-wide: it replaces the
+// one every client in the process uses.
+func NewWithExecutor(projectDir string, executor Executor, opts ...ClientOption) (*Client, error) {
+	lxc.SetExecutor(executor)
+	return open(projectDir, executor, opts)
+}
 
-	// Load config
-	cfg, err := operations.LoadProject(absDir)
-	if err != nil {
-		return nil, err
+func open(projectDir string, executor lxc.Executor, opts []ClientOption) (*Client, error) {
+	o := &clientOpts{}
+	for _, opt := range opts {
+		opt(o)
 	}
 
-	return &Client{
-		dir:      absDir,
-		cfg:      cfg,
-		executor: lxc.DefaultExecutor,
-	}, nil
-}
-
-// NewWithExecutor creates a client with a custom executor (for testing)
-func NewWithExecutor(projectDir string, executor lxc.Executor) (*Client, error) {
 	absDir, err := filepath.Abs(projectDir)
 	if err != nil {
 		return nil, err
@@ -56,18 +66,31 @@ func NewWithExecutor(projectDir string, executor lxc.Executor) (*Client, error)
 	// Load config
 	cfg, err := operations.LoadProject(absDir)
 	if err != nil {
+		if errors.Is(err, config.ErrNoProject) {
+			return nil, ErrProjectNotFound
+		}
 		return nil, err
 	}
 
-	return &Client{
-		dir:      absDir,
-		cfg:      cfg,
-		executor: executor,
-	}, nil
+	c := &Client{dir: absDir, executor: executor, pinned: o.noAutoReload}
+	c.setConfig(cfg)
+	return c, nil
 }
 
 // NewProject creates a new project and returns a client
 func NewProject(dir string, opts ...ProjectOption) (*Client, error) {
+	return newProject(dir, lxc.DefaultExecutor, opts)
+}
+
+// NewProjectWithExecutor creates a new project and returns a client
+// running lxc commands through executor (for testing). Like
+// NewWithExecutor, it replaces the executor of every client.
+func NewProjectWithExecutor(dir string, executor Executor, opts ...ProjectOption) (*Client, error) {
+	lxc.SetExecutor(executor)
+	return newProject(dir, executor, opts)
+}
+
+func newProject(dir string, executor lxc.Executor, opts []ProjectOption) (*Client, error) {
 	o := &projectOpts{}
 	for _, opt := range opts {
 		opt(o)
@@ -91,16 +114,59 @@ func NewProject(dir string, opts ...ProjectOption) (*Client, error) {
 		return nil, err
 	}
 
-	return &Client{
-		dir:      absDir,
-		cfg:      cfg,
-		executor: lxc.DefaultExecutor,
-	}, nil
+	c := &Client{dir: absDir, executor: executor}
+	c.setConfig(cfg)
+	return c, nil
+}
+
+// config returns the project's config, reading containers.yaml again first
+// if it changed since it was last read, unless the client was opened
+// WithoutAutoReload. The result is shared between
+// goroutines and must not be modified; make changes on a config loaded with
+// config.LoadWithLock and pass it to setConfig. If containers.yaml can't be
+// read, the error is returned along with the last config read.
+func (c *Client) config() (*config.Config, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pinned {
+		return c.cfg, nil
+	}
+
+	info, err := os.Stat(filepath.Join(c.dir, config.ConfigFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return c.cfg, ErrProjectNotFound
+		}
+		return c.cfg, err
+	}
+	if c.loaded != nil && os.SameFile(info, c.loaded) && info.ModTime().Equal(c.loaded.ModTime()) && info.Size() == c.loaded.Size() {
+		return c.cfg, nil
+	}
+
+	cfg, err := operations.LoadProject(c.dir)
+	if err != nil {
+		if errors.Is(err, config.ErrNoProject) {
+			err = ErrProjectNotFound
+		}
+		return c.cfg, err
+	}
+	c.cfg, c.loaded = cfg, info
+	return cfg, nil
+}
+
+// setConfig replaces the config with one just loaded or saved
+func (c *Client) setConfig(cfg *config.Config) {
+	info, _ := os.Stat(filepath.Join(c.dir, config.ConfigFile))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cfg, c.loaded = cfg, info
 }
 
 // ProjectName returns the project name
 func (c *Client) ProjectName() string {
-	return c.cfg.Project
+	cfg, _ := c.config()
+	return cfg.Project
 }
 
 // Dir returns the project directory
@@ -110,16 +176,49 @@ func (c *Client) Dir() string {
 
 // DeleteProject deletes the project and all its containers
 func (c *Client) DeleteProject(force bool) error {
-	return operations.DeleteProject(c.dir, force)
+	return operations.DeleteProject(c.dir, operations.DeleteProjectOpts{Force: force})
+}
+
+// RenameProject renames the project and moves its containers to the new prefix
+func (c *Client) RenameProject(newName string) error {
+	cfg, lock, err := config.LoadWithLock(c.dir)
+	if err != nil {
+		if errors.Is(err, config.ErrNoProject) {
+			return ErrProjectNotFound
+		}
+		return err
+	}
+	defer lock.Release()
+
+	if err := operations.RenameProject(cfg, newName, operations.RenameProjectOpts{}); err != nil {
+		return err
+	}
+	c.setConfig(cfg)
+	return nil
+}
+
+// Validate checks containers.yaml as it is now on disk, the way loading it
+// does: every container definition and the project settings. An invalid
+// file fails with a *ProjectError matching ErrValidation.
+func (c *Client) Validate() error {
+	if _, err := config.Load(c.dir); err != nil {
+		if errors.Is(err, config.ErrNoProject) {
+			return ErrProjectNotFound
+		}
+		return &ProjectError{Project: c.ProjectName(), Op: "validate", Err: err}
+	}
+	return nil
 }
 
-// Reload reloads the configuration from disk
+// Reload reloads the configuration from disk. Methods already do this when
+// containers.yaml has changed, so unless the client was opened
+// WithoutAutoReload, it's only needed to surface an error reading it.
 func (c *Client) Reload() error {
 	cfg, err := operations.LoadProject(c.dir)
 	if err != nil {
 		return err
 	}
-	c.cfg = cfg
+	c.setConfig(cfg)
 	return nil
 }
 
@@ -1,10 +1,17 @@
 package lxcmgr
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"lxc-dev-manager/internal/config"
 	"lxc-dev-manager/internal/lxc"
 )
 
@@ -87,8 +94,39 @@ func TestNew_ProjectNotFound(t *testing.T) {
 	defer os.RemoveAll(tmpDir)
 
 	_, err = New(tmpDir)
-	if err == nil {
-		t.Error("Expected error for non-existent project")
+	if !errors.Is(err, ErrProjectNotFound) {
+		t.Errorf("Expected ErrProjectNotFound, got %v", err)
+	}
+}
+
+func TestPackageLevel_ListSnapshots(t *testing.T) {
+	tmpDir, cleanup := setupTestProject(t)
+	defer cleanup()
+
+	mock, mockCleanup := setupMockExecutor(t)
+	defer mockCleanup()
+	mock.SetOutput("query /1.0/instances/test-project-dev1/snapshots", `["/1.0/instances/test-project-dev1/snapshots/initial-state"]`)
+
+	snapshots, err := ListSnapshots(tmpDir, "dev1")
+	if err != nil {
+		t.Fatalf("ListSnapshots() failed: %v", err)
+	}
+	if len(snapshots) != 1 || snapshots[0].Name != "initial-state" {
+		t.Errorf("Unexpected snapshots: %+v", snapshots)
+	}
+}
+
+func TestPackageLevel_ProjectNotFound(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	if err := Mount(tmpDir, "dev1", tmpDir, "/mnt"); !errors.Is(err, ErrProjectNotFound) {
+		t.Errorf("Mount(): expected ErrProjectNotFound, got %v", err)
+	}
+	if _, err := ListMounts(filepath.Join(tmpDir, "missing"), "dev1"); !errors.Is(err, ErrProjectNotFound) {
+		t.Errorf("ListMounts(): expected ErrProjectNotFound, got %v", err)
+	}
+	if err := CreateSnapshot(tmpDir, "dev1", "snap", ""); !errors.Is(err, ErrProjectNotFound) {
+		t.Errorf("CreateSnapshot(): expected ErrProjectNotFound, got %v", err)
 	}
 }
 
@@ -130,7 +168,10 @@ func TestClient_List(t *testing.T) {
 	mock.SetOutput("info test-project-dev2", "")
 
 	// Mock list output
-	mock.SetOutput("list -c ns4 -f csv", "test-project-dev1,RUNNING,10.0.0.1\ntest-project-dev2,STOPPED,")
+	mock.SetOutput("list --format json", `[
+		{"name":"test-project-dev1","status":"Running","state":{"network":{"eth0":{"addresses":[{"family":"inet","address":"10.0.0.1","scope":"global"}]}}}},
+		{"name":"test-project-dev2","status":"Stopped","state":null}
+	]`)
 
 	client, err := New(tmpDir)
 	if err != nil {
@@ -314,6 +355,156 @@ func TestClient_IP(t *testing.T) {
 	}
 }
 
+func TestClient_WaitFor(t *testing.T) {
+	tmpDir, cleanup := setupTestProject(t)
+	defer cleanup()
+
+	mock, mockCleanup := setupMockExecutor(t)
+	defer mockCleanup()
+
+	mock.SetOutput("info test-project-dev1", "")
+	mock.SetOutput("list test-project-dev1 -c4 -f csv", "10.0.0.5 (eth0)")
+	mock.SetOutput("exec test-project-dev1 -- sh -c", "LISTEN 0 4096 0.0.0.0:5432 0.0.0.0:*")
+
+	client, err := New(tmpDir)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	if err := client.WaitFor(context.Background(), "dev1", ConditionNetwork, PortListening(5432)); err != nil {
+		t.Fatalf("WaitFor() failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = client.WaitFor(ctx, "dev1", PortListening(8080))
+	if err == nil || !strings.Contains(err.Error(), "waiting for: port:8080") {
+		t.Errorf("expected timeout waiting for port:8080, got %v", err)
+	}
+}
+
+func TestClient_ReloadsChangedConfig(t *testing.T) {
+	tmpDir, cleanup := setupTestProject(t)
+	defer cleanup()
+
+	_, mockCleanup := setupMockExecutor(t)
+	defer mockCleanup()
+
+	client, err := New(tmpDir)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if client.HasContainer("dev3") {
+		t.Fatal("dev3 should not be in the config yet")
+	}
+
+	// Another process adds a container
+	configContent := `project: test-project
+containers:
+  dev1:
+    image: ubuntu:24.04
+  dev2:
+    image: ubuntu:24.04
+  dev3:
+    image: debian:12
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "containers.yaml"), []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	if !client.HasContainer("dev3") {
+		t.Error("Expected dev3 to be seen without Reload()")
+	}
+	if image, _ := client.GetContainerImage("dev3"); image != "debian:12" {
+		t.Errorf("GetContainerImage(dev3) = %q, want debian:12", image)
+	}
+	if ports := client.GetDefaultPorts(); len(ports) != 0 {
+		t.Errorf("Expected the removed default ports to be gone, got %v", ports)
+	}
+
+	if err := os.Remove(filepath.Join(tmpDir, "containers.yaml")); err != nil {
+		t.Fatalf("Failed to remove config: %v", err)
+	}
+	if _, err := client.List(); err != ErrProjectNotFound {
+		t.Errorf("List() after removing the config = %v, want ErrProjectNotFound", err)
+	}
+}
+
+func TestClient_WithoutAutoReload(t *testing.T) {
+	tmpDir, cleanup := setupTestProject(t)
+	defer cleanup()
+
+	_, mockCleanup := setupMockExecutor(t)
+	defer mockCleanup()
+
+	client, err := New(tmpDir, WithoutAutoReload())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	configContent := `project: test-project
+containers:
+  dev3:
+    image: debian:12
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "containers.yaml"), []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	if client.HasContainer("dev3") || !client.HasContainer("dev1") {
+		t.Error("Expected the config read by New() until Reload()")
+	}
+	if err := client.Reload(); err != nil {
+		t.Fatalf("Reload() failed: %v", err)
+	}
+	if !client.HasContainer("dev3") || client.HasContainer("dev1") {
+		t.Error("Expected Reload() to read the changed config")
+	}
+}
+
+func TestClient_ConcurrentUse(t *testing.T) {
+	tmpDir, cleanup := setupTestProject(t)
+	defer cleanup()
+
+	mock, mockCleanup := setupMockExecutor(t)
+	defer mockCleanup()
+	mock.SetOutput("list test-project-dev1 -cs", "RUNNING")
+
+	client, err := New(tmpDir)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			if err := client.SetDescription("dev2", fmt.Sprintf("note %d", i)); err != nil {
+				t.Errorf("SetDescription() failed: %v", err)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			if _, err := client.Status("dev1"); err != nil {
+				t.Errorf("Status() failed: %v", err)
+			}
+			if len(client.ListContainerNames()) != 2 {
+				t.Error("Expected 2 containers")
+			}
+		}()
+	}
+	wg.Wait()
+
+	cfg, err := config.Load(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if !strings.HasPrefix(cfg.Containers["dev2"].Description, "note ") {
+		t.Errorf("Expected a description to be saved, got %q", cfg.Containers["dev2"].Description)
+	}
+}
+
 func TestContainerError_Unwrap(t *testing.T) {
 	innerErr := ErrContainerNotFound
 	err := &ContainerError{
@@ -344,3 +535,77 @@ func TestMountError_Unwrap(t *testing.T) {
 		t.Error("Unwrap() did not return inner error")
 	}
 }
+
+func TestClient_SentinelErrors(t *testing.T) {
+	tmpDir, cleanup := setupTestProject(t)
+	defer cleanup()
+
+	mock, mockCleanup := setupMockExecutor(t)
+	defer mockCleanup()
+
+	mock.SetOutput("info test-project-dev1", "")
+	mock.SetOutput("info test-project-dev1/snap", "")
+	mock.SetError("info test-project-dev2", "not found")
+
+	client, err := New(tmpDir)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"not in config", client.Start("missing"), ErrContainerNotFound},
+		{"not in LXC", client.Start("dev2"), ErrContainerNotFound},
+		{"snapshot exists", client.CreateSnapshot("dev1", "snap", ""), ErrSnapshotExists},
+		{"initial-state", client.DeleteSnapshot("dev1", "initial-state"), ErrSnapshotProtected},
+		{"invalid container path", client.Mount("dev1", tmpDir, "relative/path"), ErrInvalidContainerPath},
+		{"validation", client.Mount("dev1", tmpDir, "relative/path"), ErrValidation},
+	}
+	for _, tt := range tests {
+		if !errors.Is(tt.err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, tt.err)
+		}
+	}
+	if errors.Is(client.Start("missing"), ErrSnapshotNotFound) {
+		t.Error("Expected a missing container not to match ErrSnapshotNotFound")
+	}
+}
+
+func TestClient_Validate(t *testing.T) {
+	tmpDir, cleanup := setupTestProject(t)
+	defer cleanup()
+
+	_, mockCleanup := setupMockExecutor(t)
+	defer mockCleanup()
+
+	client, err := New(tmpDir)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if err := client.Validate(); err != nil {
+		t.Fatalf("Validate() failed on a valid config: %v", err)
+	}
+
+	configContent := `project: test-project
+containers:
+  dev1:
+    image: ubuntu:24.04
+    sync:
+      - source: .env
+        dest: relative/.env
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "containers.yaml"), []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	err = client.Validate()
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("Validate() = %v, want ErrValidation", err)
+	}
+	if !strings.Contains(err.Error(), "container 'dev1': sync 1") {
+		t.Errorf("Unexpected message: %v", err)
+	}
+}
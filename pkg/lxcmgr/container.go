@@ -1,6 +1,7 @@
 package lxcmgr
 
 import (
+	"context"
 	"errors"
 	"time"
 
@@ -9,7 +10,8 @@ import (
 	"lxc-dev-manager/internal/operations"
 )
 
-// CreateContainer creates a new container in the project
+// CreateContainer creates a new container in the project. An empty image
+// uses the project's defaults.image.
 func (c *Client) CreateContainer(name, image string, opts ...CreateOption) error {
 	o := &createOpts{}
 	for _, opt := range opts {
@@ -29,22 +31,47 @@ func (c *Client) CreateContainer(name, image string, opts ...CreateOption) error
 		Ports:    o.ports,
 		User:     o.user,
 		Password: o.password,
+		IP:       o.ip,
+		Workdir:  o.workdir,
+
+		NoUser:     o.noUser,
+		NoSSH:      o.noSSH,
+		NoSnapshot: o.noSnapshot,
+		NoRollback: o.noRollback,
+		Progress:   stepProgress(o.progress),
 	}); err != nil {
 		return wrapContainerErr("create", name, err)
 	}
 
-	c.cfg = cfg
+	c.setConfig(cfg)
 	return nil
 }
 
 // Start starts a stopped container
 func (c *Client) Start(name string) error {
-	return wrapContainerErr("start", name, operations.Start(c.cfg, name))
+	cfg, err := c.config()
+	if err != nil {
+		return err
+	}
+	return wrapContainerErr("start", name, operations.Start(cfg, name))
 }
 
-// Stop stops a running container
-func (c *Client) Stop(name string) error {
-	return wrapContainerErr("stop", name, operations.Stop(c.cfg, name))
+// Stop stops a running container, waiting for a clean shutdown for its
+// stop_timeout (default: 5s)
+func (c *Client) Stop(name string, opts ...StopOption) error {
+	o := &stopOpts{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	cfg, err := c.config()
+	if err != nil {
+		return err
+	}
+	return wrapContainerErr("stop", name, operations.Stop(cfg, name, operations.StopOpts{
+		Timeout: o.timeout,
+		Force:   o.force,
+	}))
 }
 
 // Remove removes a container from the project
@@ -62,7 +89,7 @@ func (c *Client) Remove(name string, force bool) error {
 		return wrapContainerErr("remove", name, err)
 	}
 
-	c.cfg = cfg
+	c.setConfig(cfg)
 	return nil
 }
 
@@ -101,13 +128,36 @@ func (c *Client) Destroy(name string) error {
 		return wrapContainerErr("destroy", name, err)
 	}
 
-	c.cfg = cfg
+	c.setConfig(cfg)
 	return nil
 }
 
-// Reset resets a container to a snapshot state
-func (c *Client) Reset(name, snapshot string) error {
-	return wrapContainerErr("reset", name, operations.Reset(c.cfg, name, snapshot))
+// Reset resets a container to a snapshot state and brings its devices back
+// in line with containers.yaml. With WithSnapshotFirst, or
+// reset.auto_snapshot in containers.yaml, the current state is kept as a
+// pre-reset snapshot first.
+func (c *Client) Reset(name, snapshot string, opts ...ResetOption) error {
+	o := &resetOpts{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	cfg, lock, err := config.LoadWithLock(c.dir)
+	if err != nil {
+		if errors.Is(err, config.ErrNoProject) {
+			return ErrProjectNotFound
+		}
+		return wrapContainerErr("reset", name, err)
+	}
+	defer lock.Release()
+
+	_, err = operations.Reset(cfg, name, snapshot, operations.ResetOpts{
+		SnapshotFirst: o.snapshotFirst,
+		SyncFiles:     o.syncFiles,
+		PruneDevices:  o.pruneDevices,
+	})
+	c.setConfig(cfg)
+	return wrapContainerErr("reset", name, err)
 }
 
 // Clone clones a container to create a new one
@@ -128,17 +178,22 @@ func (c *Client) Clone(source, dest string, opts ...CloneOption) error {
 
 	if err := operations.Clone(cfg, source, dest, operations.CloneOpts{
 		FromSnapshot: o.fromSnapshot,
+		Progress:     stepProgress(o.progress),
 	}); err != nil {
 		return wrapContainerErr("clone", source, err)
 	}
 
-	c.cfg = cfg
+	c.setConfig(cfg)
 	return nil
 }
 
 // List returns all containers in the project
 func (c *Client) List() ([]ContainerInfo, error) {
-	containers, err := operations.List(c.cfg)
+	cfg, err := c.config()
+	if err != nil {
+		return nil, err
+	}
+	containers, err := operations.List(cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -146,11 +201,16 @@ func (c *Client) List() ([]ContainerInfo, error) {
 	var result []ContainerInfo
 	for _, info := range containers {
 		result = append(result, ContainerInfo{
-			Name:   info.Name,
-			Image:  info.Image,
-			Status: ContainerStatus(info.Status),
-			IP:     info.IP,
-			Ports:  info.Ports,
+			Name:        info.Name,
+			Image:       info.Image,
+			Description: info.Description,
+			Status:      ContainerStatus(info.Status),
+			IP:          info.IP,
+			IPv6:        info.IPv6,
+			Ports:       info.Ports,
+			Snapshots:   info.Snapshots,
+			Mounts:      info.Mounts,
+			DiskUsage:   info.DiskUsage,
 		})
 	}
 	return result, nil
@@ -158,24 +218,47 @@ func (c *Client) List() ([]ContainerInfo, error) {
 
 // Status returns the status of a container
 func (c *Client) Status(name string) (ContainerStatus, error) {
-	status, err := operations.Status(c.cfg, name)
+	cfg, err := c.config()
+	if err != nil {
+		return "", err
+	}
+	status, err := operations.Status(cfg, name)
 	return ContainerStatus(status), wrapContainerErr("status", name, err)
 }
 
 // IP returns the IP address of a container
 func (c *Client) IP(name string) (string, error) {
-	ip, err := operations.IP(c.cfg, name)
+	cfg, err := c.config()
+	if err != nil {
+		return "", err
+	}
+	ip, err := operations.IP(cfg, name)
+	return ip, wrapContainerErr("ip", name, err)
+}
+
+// IPv6 returns the global IPv6 address of a container
+func (c *Client) IPv6(name string) (string, error) {
+	cfg, err := c.config()
+	if err != nil {
+		return "", err
+	}
+	ip, err := operations.IPv6(cfg, name)
 	return ip, wrapContainerErr("ip", name, err)
 }
 
 // Exists checks if a container exists in the project (both config and LXC)
 func (c *Client) Exists(name string) bool {
-	return operations.Exists(c.cfg, name)
+	cfg, err := c.config()
+	if err != nil {
+		return false
+	}
+	return operations.Exists(cfg, name)
 }
 
 // HasContainer checks if a container exists in the project config (regardless of LXC state)
 func (c *Client) HasContainer(name string) bool {
-	return c.cfg.HasContainer(name)
+	cfg, _ := c.config()
+	return cfg.HasContainer(name)
 }
 
 // SetContainerImage updates the image for a container in the config
@@ -194,29 +277,149 @@ func (c *Client) SetContainerImage(name, image string) error {
 		return wrapContainerErr("set-image", name, err)
 	}
 
-	c.cfg = cfg
+	c.setConfig(cfg)
 	return nil
 }
 
 // ListContainerNames returns the names of all containers in the config
 func (c *Client) ListContainerNames() []string {
-	names := make([]string, 0, len(c.cfg.Containers))
-	for name := range c.cfg.Containers {
+	cfg, _ := c.config()
+	names := make([]string, 0, len(cfg.Containers))
+	for name := range cfg.Containers {
 		names = append(names, name)
 	}
 	return names
 }
 
+// SetDescription sets the notes shown for a container in list and info.
+// An empty description clears them.
+func (c *Client) SetDescription(name, description string) error {
+	cfg, lock, err := config.LoadWithLock(c.dir)
+	if err != nil {
+		if errors.Is(err, config.ErrNoProject) {
+			return ErrProjectNotFound
+		}
+		return wrapContainerErr("set-description", name, err)
+	}
+	defer lock.Release()
+
+	if err := operations.SetDescription(cfg, name, description); err != nil {
+		return wrapContainerErr("set-description", name, err)
+	}
+
+	c.setConfig(cfg)
+	return nil
+}
+
+// SetProperties changes several properties of a container at once: ports
+// (comma-separated), image, user.name, limits.cpu, limits.memory and
+// autostart. Values are checked together before anything changes, and an
+// empty value clears a property. Invalid values fail with ErrValidation,
+// as does a user.name that doesn't exist in the running container.
+func (c *Client) SetProperties(name string, properties map[string]string) error {
+	cfg, lock, err := config.LoadWithLock(c.dir)
+	if err != nil {
+		if errors.Is(err, config.ErrNoProject) {
+			return ErrProjectNotFound
+		}
+		return wrapContainerErr("set", name, err)
+	}
+	defer lock.Release()
+
+	if err := operations.SetProperties(cfg, name, properties); err != nil {
+		return wrapContainerErr("set", name, err)
+	}
+
+	c.setConfig(cfg)
+	return nil
+}
+
 // GetContainerImage returns the image for a container from the config
 func (c *Client) GetContainerImage(name string) (string, bool) {
-	container, ok := c.cfg.Containers[name]
+	cfg, _ := c.config()
+	container, ok := cfg.Containers[name]
 	if !ok {
 		return "", false
 	}
 	return container.Image, true
 }
 
-// WaitForReady waits for a container to be ready
+// WaitForReady waits for a container's ready conditions from
+// containers.yaml (default: cloud-init finished)
 func (c *Client) WaitForReady(name string, timeout time.Duration) error {
-	return wrapContainerErr("wait", name, operations.WaitForReady(c.cfg, name, timeout))
+	cfg, err := c.config()
+	if err != nil {
+		return err
+	}
+	return wrapContainerErr("wait", name, operations.WaitForReady(cfg, name, timeout))
+}
+
+// WaitFor waits until every condition holds inside a container, or ctx is
+// done. Without conditions, the container's ready conditions from
+// containers.yaml are used (default: cloud-init finished).
+func (c *Client) WaitFor(ctx context.Context, name string, conditions ...Condition) error {
+	conds := make([]string, len(conditions))
+	for i, cond := range conditions {
+		conds[i] = string(cond)
+	}
+	cfg, err := c.config()
+	if err != nil {
+		return err
+	}
+	return wrapContainerErr("wait", name, operations.WaitFor(ctx, cfg, name, conds...))
+}
+
+// ApplyDotfiles clones or updates the configured dotfiles repository for
+// the container user and runs its install script
+func (c *Client) ApplyDotfiles(name string) error {
+	cfg, err := c.config()
+	if err != nil {
+		return err
+	}
+	return operations.ApplyDotfiles(cfg, name)
+}
+
+// MoveToProject moves a container to the project managed by dest, renaming
+// it to that project's prefix
+func (c *Client) MoveToProject(name string, dest *Client) error {
+	if c.dir == dest.dir {
+		cfg, err := c.config()
+		if err != nil {
+			return err
+		}
+		return wrapContainerErr("move", name, operations.MoveToProject(cfg, cfg, name))
+	}
+
+	// Lock both projects in the same order whichever way the move goes,
+	// so two opposite moves can't deadlock
+	first, second := c, dest
+	if second.dir < first.dir {
+		first, second = second, first
+	}
+	firstCfg, firstLock, err := config.LoadWithLock(first.dir)
+	if err != nil {
+		if errors.Is(err, config.ErrNoProject) {
+			return ErrProjectNotFound
+		}
+		return wrapContainerErr("move", name, err)
+	}
+	defer firstLock.Release()
+	secondCfg, secondLock, err := config.LoadWithLock(second.dir)
+	if err != nil {
+		if errors.Is(err, config.ErrNoProject) {
+			return ErrProjectNotFound
+		}
+		return wrapContainerErr("move", name, err)
+	}
+	defer secondLock.Release()
+
+	src, dst := firstCfg, secondCfg
+	if first != c {
+		src, dst = secondCfg, firstCfg
+	}
+	err = operations.MoveToProject(src, dst, name)
+	// Both files may have been saved even when the move failed part way
+	c.setConfig(src)
+	dest.setConfig(dst)
+	return wrapContainerErr("move", name, err)
 }
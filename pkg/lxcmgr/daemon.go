@@ -0,0 +1,324 @@
+package lxcmgr
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"sync"
+
+	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/errcode"
+)
+
+// DaemonSocket returns where "lxc-dev-manager daemon" listens by default
+// for the project in dir
+func DaemonSocket(dir string) string {
+	return filepath.Join(dir, ".lxc-dev-manager", "daemon.sock")
+}
+
+// Request and response bodies of the daemon API, shared with RemoteClient
+
+type projectResponse struct {
+	Name string `json:"name"`
+	Dir  string `json:"dir"`
+}
+
+type createRequest struct {
+	Name     string `json:"name"`
+	Image    string `json:"image"`
+	Ports    []int  `json:"ports,omitempty"`
+	User     string `json:"user,omitempty"`
+	Password string `json:"password,omitempty"`
+	StaticIP string `json:"static_ip,omitempty"`
+	Workdir  string `json:"workdir,omitempty"`
+}
+
+type statusResponse struct {
+	Name   string          `json:"name"`
+	Status ContainerStatus `json:"status"`
+	IP     string          `json:"ip,omitempty"`
+}
+
+type resetRequest struct {
+	Snapshot      string `json:"snapshot,omitempty"`
+	SnapshotFirst bool   `json:"snapshot_first,omitempty"`
+	SyncFiles     bool   `json:"sync_files,omitempty"`
+	PruneDevices  bool   `json:"prune_devices,omitempty"`
+}
+
+type execRequest struct {
+	Command []string `json:"command"`
+}
+
+type execResponse struct {
+	Output []byte `json:"output"`
+}
+
+type snapshotRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+type mountRequest struct {
+	Source         string `json:"source"`
+	Path           string `json:"path"`
+	Name           string `json:"name,omitempty"`
+	ReadWrite      bool   `json:"read_write,omitempty"`
+	Shift          bool   `json:"shift,omitempty"`
+	IDMap          string `json:"idmap,omitempty"`
+	NoAutoShift    bool   `json:"no_auto_shift,omitempty"`
+	NoAutoSuffix   bool   `json:"no_auto_suffix,omitempty"`
+	Recursive      bool   `json:"recursive,omitempty"`
+	Propagation    string `json:"propagation,omitempty"`
+	AllowRiskyPath bool   `json:"allow_risky_path,omitempty"`
+}
+
+type errorResponse struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	Container string `json:"container"`
+}
+
+// daemon serves the Client API over HTTP. Requests run one at a time, each
+// against a freshly loaded config.
+type daemon struct {
+	mu     sync.Mutex
+	client *Client
+}
+
+// DaemonHandler returns an HTTP handler exposing c's project, container,
+// snapshot and mount operations as a JSON API, for "lxc-dev-manager
+// daemon". RemoteClient is its client.
+func DaemonHandler(c *Client) http.Handler {
+	d := &daemon{client: c}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/project", d.handle(d.project))
+	mux.HandleFunc("GET /v1/containers", d.handle(d.list))
+	mux.HandleFunc("POST /v1/containers", d.handle(d.create))
+	mux.HandleFunc("GET /v1/containers/{name}", d.handle(d.status))
+	mux.HandleFunc("DELETE /v1/containers/{name}", d.handle(d.remove))
+	mux.HandleFunc("POST /v1/containers/{name}/start", d.handle(d.start))
+	mux.HandleFunc("POST /v1/containers/{name}/stop", d.handle(d.stop))
+	mux.HandleFunc("POST /v1/containers/{name}/reset", d.handle(d.reset))
+	mux.HandleFunc("POST /v1/containers/{name}/exec", d.handle(d.exec))
+	mux.HandleFunc("GET /v1/containers/{name}/snapshots", d.handle(d.listSnapshots))
+	mux.HandleFunc("POST /v1/containers/{name}/snapshots", d.handle(d.createSnapshot))
+	mux.HandleFunc("DELETE /v1/containers/{name}/snapshots/{snapshot}", d.handle(d.deleteSnapshot))
+	mux.HandleFunc("GET /v1/containers/{name}/mounts", d.handle(d.listMounts))
+	mux.HandleFunc("POST /v1/containers/{name}/mounts", d.handle(d.mount))
+	mux.HandleFunc("DELETE /v1/containers/{name}/mounts/{mount}", d.handle(d.unmount))
+	return mux
+}
+
+// handle serializes requests, reloads the config so changes made by the
+// CLI are seen, audits mutations and writes fn's result (or error) as JSON
+func (d *daemon) handle(fn func(r *http.Request) (any, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		d.mu.Lock()
+		out, err := d.run(fn, r)
+		d.mu.Unlock()
+
+		// Requests that change something are recorded like CLI commands
+		if r.Method != http.MethodGet {
+			entry := config.AuditEntry{Command: "daemon", Args: []string{r.Method, r.URL.Path}}
+			if err != nil {
+				entry.Error = err.Error()
+			}
+			if err := config.AppendAudit(d.client.Dir(), entry); err != nil {
+				slog.Warn("failed to write audit log", "error", err)
+			}
+		}
+
+		if err == nil {
+			if out == nil {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			writeJSON(w, http.StatusOK, out)
+			return
+		}
+
+		code := errcode.Of(err)
+		writeJSON(w, code.HTTPStatus(), errorResponse{
+			Code:      string(code),
+			Message:   err.Error(),
+			Container: errcode.ContainerOf(err),
+		})
+	}
+}
+
+func (d *daemon) run(fn func(r *http.Request) (any, error), r *http.Request) (any, error) {
+	if err := d.client.Reload(); err != nil {
+		return nil, err
+	}
+	return fn(r)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// decode reads a JSON request body into v
+func decode(r *http.Request, v any) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return errcode.Errorf(errcode.Validation, "", "invalid request body: %w", err)
+	}
+	return nil
+}
+
+func (d *daemon) project(r *http.Request) (any, error) {
+	return projectResponse{Name: d.client.ProjectName(), Dir: d.client.Dir()}, nil
+}
+
+func (d *daemon) list(r *http.Request) (any, error) {
+	containers, err := d.client.List()
+	if containers == nil {
+		containers = []ContainerInfo{}
+	}
+	return containers, err
+}
+
+func (d *daemon) create(r *http.Request) (any, error) {
+	var req createRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	var opts []CreateOption
+	if len(req.Ports) > 0 {
+		opts = append(opts, WithPorts(req.Ports...))
+	}
+	if req.StaticIP != "" {
+		opts = append(opts, WithStaticIP(req.StaticIP))
+	}
+	if req.Workdir != "" {
+		opts = append(opts, WithWorkdir(req.Workdir))
+	}
+	if req.User != "" {
+		opts = append(opts, WithUser(req.User, req.Password))
+	}
+	return nil, d.client.CreateContainer(req.Name, req.Image, opts...)
+}
+
+func (d *daemon) status(r *http.Request) (any, error) {
+	name := r.PathValue("name")
+	status, err := d.client.Status(name)
+	if err != nil {
+		return nil, err
+	}
+	resp := statusResponse{Name: name, Status: status}
+	if status == StatusRunning {
+		resp.IP, _ = d.client.IP(name)
+	}
+	return resp, nil
+}
+
+func (d *daemon) remove(r *http.Request) (any, error) {
+	return nil, d.client.Remove(r.PathValue("name"), r.URL.Query().Get("force") == "true")
+}
+
+func (d *daemon) start(r *http.Request) (any, error) {
+	return nil, d.client.Start(r.PathValue("name"))
+}
+
+func (d *daemon) stop(r *http.Request) (any, error) {
+	return nil, d.client.Stop(r.PathValue("name"))
+}
+
+func (d *daemon) reset(r *http.Request) (any, error) {
+	var req resetRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	var opts []ResetOption
+	if req.SnapshotFirst {
+		opts = append(opts, WithSnapshotFirst())
+	}
+	if req.SyncFiles {
+		opts = append(opts, WithSyncFiles())
+	}
+	if req.PruneDevices {
+		opts = append(opts, WithPruneDevices())
+	}
+	return nil, d.client.Reset(r.PathValue("name"), req.Snapshot, opts...)
+}
+
+func (d *daemon) exec(r *http.Request) (any, error) {
+	var req execRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	if len(req.Command) == 0 {
+		return nil, errcode.New(errcode.Validation, "", errors.New("command required"))
+	}
+	output, err := d.client.Exec(r.PathValue("name"), req.Command)
+	if err != nil {
+		return nil, err
+	}
+	return execResponse{Output: output}, nil
+}
+
+func (d *daemon) listSnapshots(r *http.Request) (any, error) {
+	snapshots, err := d.client.ListSnapshots(r.PathValue("name"))
+	if snapshots == nil {
+		snapshots = []SnapshotInfo{}
+	}
+	return snapshots, err
+}
+
+func (d *daemon) createSnapshot(r *http.Request) (any, error) {
+	var req snapshotRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	return nil, d.client.CreateSnapshot(r.PathValue("name"), req.Name, req.Description)
+}
+
+func (d *daemon) deleteSnapshot(r *http.Request) (any, error) {
+	return nil, d.client.DeleteSnapshot(r.PathValue("name"), r.PathValue("snapshot"))
+}
+
+func (d *daemon) listMounts(r *http.Request) (any, error) {
+	mounts, err := d.client.ListMounts(r.PathValue("name"))
+	if mounts == nil {
+		mounts = []MountInfo{}
+	}
+	return mounts, err
+}
+
+func (d *daemon) mount(r *http.Request) (any, error) {
+	var req mountRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	opts := []MountOption{WithMountName(req.Name), WithPropagation(req.Propagation)}
+	if req.ReadWrite {
+		opts = append(opts, WithReadWrite())
+	}
+	if req.Shift {
+		opts = append(opts, WithShift())
+	}
+	if req.IDMap != "" {
+		opts = append(opts, WithIDMap(req.IDMap))
+	}
+	if req.NoAutoShift {
+		opts = append(opts, WithoutAutoShift())
+	}
+	if req.NoAutoSuffix {
+		opts = append(opts, WithoutAutoSuffix())
+	}
+	if req.Recursive {
+		opts = append(opts, WithRecursive())
+	}
+	if req.AllowRiskyPath {
+		opts = append(opts, AllowRiskyPaths())
+	}
+	return nil, d.client.Mount(r.PathValue("name"), req.Source, req.Path, opts...)
+}
+
+func (d *daemon) unmount(r *http.Request) (any, error) {
+	return nil, d.client.Unmount(r.PathValue("name"), r.PathValue("mount"))
+}
@@ -4,9 +4,14 @@ package lxcmgr
 import (
 	"errors"
 	"fmt"
+
+	"lxc-dev-manager/internal/errcode"
+	"lxc-dev-manager/internal/operations"
 )
 
-// Sentinel errors for programmatic handling
+// Sentinel errors for programmatic handling. Those shared with
+// internal/operations match the failures it reports, whichever method
+// returns them.
 var (
 	// Project errors
 	ErrProjectNotFound    = errors.New("project not found")
@@ -14,32 +19,32 @@ var (
 	ErrInvalidProjectName = errors.New("invalid project name")
 
 	// Container errors
-	ErrContainerNotFound    = errors.New("container not found")
-	ErrContainerExists      = errors.New("container already exists")
+	ErrContainerNotFound    = operations.ErrContainerNotFound
+	ErrContainerExists      = operations.ErrContainerExists
 	ErrContainerRunning     = errors.New("container is running")
-	ErrContainerStopped     = errors.New("container is stopped")
+	ErrContainerStopped     = operations.ErrContainerStopped
 	ErrInvalidContainerName = errors.New("invalid container name")
 
 	// Snapshot errors
-	ErrSnapshotNotFound   = errors.New("snapshot not found")
-	ErrSnapshotExists     = errors.New("snapshot already exists")
-	ErrSnapshotProtected  = errors.New("snapshot is protected") // initial-state
+	ErrSnapshotNotFound  = operations.ErrSnapshotNotFound
+	ErrSnapshotExists    = operations.ErrSnapshotExists
+	ErrSnapshotProtected = operations.ErrSnapshotProtected // initial-state
 
 	// Mount errors
-	ErrMountNotFound        = errors.New("mount not found")
-	ErrMountExists          = errors.New("mount already exists")
-	ErrMountPathConflict    = errors.New("mount path already in use")
-	ErrInvalidSourcePath    = errors.New("invalid source path")
-	ErrInvalidContainerPath = errors.New("invalid container path")
-	ErrBlockedPath          = errors.New("path is blocked for security")
-	ErrPrivilegedMount      = errors.New("operation not allowed on privileged container")
-	ErrRiskyPath            = errors.New("path is risky and requires explicit permission")
+	ErrMountNotFound        = operations.ErrMountNotFound
+	ErrMountExists          = operations.ErrMountExists
+	ErrMountPathConflict    = operations.ErrMountPathConflict
+	ErrInvalidSourcePath    = operations.ErrInvalidSourcePath
+	ErrInvalidContainerPath = operations.ErrInvalidContainerPath
+	ErrBlockedPath          = operations.ErrBlockedPath
+	ErrPrivilegedMount      = operations.ErrPrivilegedMount
+	ErrRiskyPath            = operations.ErrRiskyPath
 
 	// Image errors
-	ErrImageNotFound = errors.New("image not found")
-	ErrImageExists   = errors.New("image already exists")
+	ErrImageNotFound = operations.ErrImageNotFound
+	ErrImageExists   = operations.ErrImageExists
 
-	// Validation errors
+	// Validation errors, matched by any error failing validation
 	ErrValidation = errors.New("validation failed")
 )
 
@@ -58,6 +63,11 @@ func (e *ContainerError) Unwrap() error {
 	return e.Err
 }
 
+// Is lets errors.Is match ErrValidation against the failure type
+func (e *ContainerError) Is(target error) bool {
+	return isValidation(e.Err, target)
+}
+
 // ProjectError wraps errors with project context
 type ProjectError struct {
 	Project string
@@ -73,6 +83,11 @@ func (e *ProjectError) Unwrap() error {
 	return e.Err
 }
 
+// Is lets errors.Is match ErrValidation against the failure type
+func (e *ProjectError) Is(target error) bool {
+	return isValidation(e.Err, target)
+}
+
 // MountError wraps errors with mount context
 type MountError struct {
 	Container string
@@ -92,6 +107,11 @@ func (e *MountError) Unwrap() error {
 	return e.Err
 }
 
+// Is lets errors.Is match ErrValidation against the failure type
+func (e *MountError) Is(target error) bool {
+	return isValidation(e.Err, target)
+}
+
 // SnapshotError wraps errors with snapshot context
 type SnapshotError struct {
 	Container string
@@ -108,6 +128,17 @@ func (e *SnapshotError) Unwrap() error {
 	return e.Err
 }
 
+// Is lets errors.Is match ErrValidation against the failure type
+func (e *SnapshotError) Is(target error) bool {
+	return isValidation(e.Err, target)
+}
+
+// isValidation reports whether target is ErrValidation and err failed
+// validation
+func isValidation(err, target error) bool {
+	return target == ErrValidation && errcode.Of(err) == errcode.Validation
+}
+
 // wrapContainerErr wraps an error with container context
 func wrapContainerErr(op, container string, err error) error {
 	if err == nil {
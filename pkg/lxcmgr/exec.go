@@ -6,14 +6,22 @@ import (
 
 // Exec runs a command inside a container and returns the output
 func (c *Client) Exec(name string, cmd []string) ([]byte, error) {
-	output, err := operations.Exec(c.cfg, name, cmd)
+	cfg, err := c.config()
+	if err != nil {
+		return nil, err
+	}
+	output, err := operations.Exec(cfg, name, cmd)
 	return output, wrapContainerErr("exec", name, err)
 }
 
 // ExecInteractive runs an interactive command inside a container.
 // This replaces the current process with the container shell.
 func (c *Client) ExecInteractive(name string, cmd []string) error {
-	return wrapContainerErr("exec", name, operations.ExecInteractive(c.cfg, name, cmd))
+	cfg, err := c.config()
+	if err != nil {
+		return err
+	}
+	return wrapContainerErr("exec", name, operations.ExecInteractive(cfg, name, cmd))
 }
 
 // Shell opens an interactive shell in a container.
@@ -24,7 +32,11 @@ func (c *Client) Shell(name string, opts ...ShellOption) error {
 		opt(o)
 	}
 
-	return wrapContainerErr("shell", name, operations.Shell(c.cfg, name, operations.ShellOpts{
+	cfg, err := c.config()
+	if err != nil {
+		return err
+	}
+	return wrapContainerErr("shell", name, operations.Shell(cfg, name, operations.ShellOpts{
 		User: o.user,
 	}))
 }
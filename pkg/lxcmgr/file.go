@@ -11,14 +11,25 @@ func (c *Client) CopyToContainer(container, localPath, remotePath string, opts .
 		opt(o)
 	}
 
-	return operations.CopyToContainer(c.cfg, container, localPath, remotePath, operations.CopyOpts{
-		AutoCreateDir: o.autoCreateDir,
-	})
+	cfg, err := c.config()
+	if err != nil {
+		return err
+	}
+	return operations.CopyToContainer(cfg, container, localPath, remotePath, o.toOperations())
 }
 
 // CopyFromContainer copies a file or directory from container to host
 func (c *Client) CopyFromContainer(container, remotePath, localPath string, opts ...CopyOption) error {
-	return operations.CopyFromContainer(c.cfg, container, remotePath, localPath)
+	o := &copyOpts{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	cfg, err := c.config()
+	if err != nil {
+		return err
+	}
+	return operations.CopyFromContainer(cfg, container, remotePath, localPath, o.toOperations())
 }
 
 // CopyBetweenContainers copies a file or directory from one container to another
@@ -28,7 +39,30 @@ func (c *Client) CopyBetweenContainers(srcContainer, srcPath, destContainer, des
 		opt(o)
 	}
 
-	return operations.CopyBetweenContainers(c.cfg, srcContainer, srcPath, destContainer, destPath, operations.CopyOpts{
+	cfg, err := c.config()
+	if err != nil {
+		return err
+	}
+	return operations.CopyBetweenContainers(cfg, srcContainer, srcPath, destContainer, destPath, o.toOperations())
+}
+
+func (o *copyOpts) toOperations() operations.CopyOpts {
+	opts := operations.CopyOpts{
 		AutoCreateDir: o.autoCreateDir,
-	})
+		Exclude:       o.exclude,
+		Verify:        o.verify,
+	}
+	if o.progress != nil {
+		fn := o.progress
+		opts.Progress = func(p operations.CopyProgress) {
+			fn(CopyProgress{
+				Total:    p.Total,
+				Copied:   p.Copied,
+				Elapsed:  p.Elapsed,
+				ETA:      p.ETA(),
+				Finished: p.Finished,
+			})
+		}
+	}
+	return opts
 }
@@ -16,24 +16,52 @@ func ListImages(all bool) ([]ImageInfo, error) {
 	var result []ImageInfo
 	for _, img := range images {
 		result = append(result, ImageInfo{
-			Alias:       img.Alias,
-			Fingerprint: img.Fingerprint,
-			Size:        img.Size,
-			Description: img.Description,
+			Alias:           img.Alias,
+			Aliases:         img.Aliases,
+			Fingerprint:     img.Fingerprint,
+			Size:            img.Size,
+			SizeBytes:       img.SizeBytes,
+			Description:     img.Description,
+			Architecture:    img.Architecture,
+			CreatedAt:       img.CreatedAt,
+			SourceContainer: img.SourceContainer,
 		})
 	}
 
 	return result, nil
 }
 
-// CreateImage creates an image from a container
-func (c *Client) CreateImage(container, imageName string) error {
-	return c.CreateImageWithProgress(container, imageName, nil, nil)
+// CreateImage creates an image from a container. A running container
+// keeps running; the image is published from a snapshot.
+func (c *Client) CreateImage(container, imageName string, opts ...ImageOption) error {
+	return c.createImage(container, imageName, opts, nil, nil)
 }
 
 // CreateImageWithProgress creates an image from a container with progress output
-func (c *Client) CreateImageWithProgress(container, imageName string, stdout, stderr io.Writer) error {
-	return operations.CreateImage(c.cfg, container, imageName, stdout, stderr)
+func (c *Client) CreateImageWithProgress(container, imageName string, stdout, stderr io.Writer, opts ...ImageOption) error {
+	return c.createImage(container, imageName, opts, stdout, stderr)
+}
+
+func (c *Client) createImage(container, imageName string, opts []ImageOption, stdout, stderr io.Writer) error {
+	o := &imageOpts{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	cfg, err := c.config()
+	if err != nil {
+		return err
+	}
+
+	var progress func(step string)
+	if o.progress != nil {
+		progress = func(step string) {
+			o.progress(Step{Container: container, Name: step})
+		}
+	}
+	return operations.CreateImage(cfg, container, imageName, operations.CreateImageOpts{
+		Progress: progress,
+	}, stdout, stderr)
 }
 
 // DeleteImage deletes an image by alias
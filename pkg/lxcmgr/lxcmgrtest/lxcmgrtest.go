@@ -0,0 +1,439 @@
+// Package lxcmgrtest provides a fake lxc executor for unit testing code
+// that uses lxcmgr, without LXD.
+//
+//	client, fake := lxcmgrtest.NewClient(t, dir)
+//	fake.AddContainer("myproject-dev1", lxcmgr.StatusStopped)
+//	if err := client.Start("dev1"); err != nil { ... }
+//	if !fake.HasCall("start", "myproject-dev1") { ... }
+//
+// Containers are known to the fake by their LXC name: the project name,
+// a dash and the container name, or just the container name when the
+// project name is empty.
+package lxcmgrtest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"lxc-dev-manager/internal/lxc"
+	"lxc-dev-manager/pkg/lxcmgr"
+)
+
+// ErrNotFound is returned for commands on containers or snapshots the fake
+// doesn't know, like lxc's "Instance not found"
+var ErrNotFound = errors.New("Error: Instance not found")
+
+// Container is a container known to the fake
+type Container struct {
+	Status    lxcmgr.ContainerStatus
+	Image     string
+	IP        string // Reported while the container is running
+	Snapshots []string
+}
+
+// Executor is a fake lxcmgr.Executor. It keeps a table of containers that
+// launch, init, start, stop, restart, delete, copy, move, snapshot and
+// restore update and that info, list and snapshot queries report; exec
+// succeeds on running containers, with cloud-init reporting done. Any other
+// command succeeds with no output. Piped commands (copies between
+// containers) and streamed ones (monitor) go through the same table.
+//
+// SetOutput and SetError script a command's result instead, and every
+// command is recorded for Calls, HasCall and HasCallPrefix. It is safe for
+// concurrent use.
+type Executor struct {
+	mu         sync.Mutex
+	containers map[string]*Container
+	scripted   map[string]response
+	calls      [][]string
+	nextIP     int
+}
+
+type response struct {
+	output string
+	err    error
+}
+
+// NewExecutor returns a fake with no containers
+func NewExecutor() *Executor {
+	return &Executor{
+		containers: make(map[string]*Container),
+		scripted:   make(map[string]response),
+	}
+}
+
+// NewClient opens the project in dir with a new fake as its executor. The
+// real executor is restored when the test ends.
+func NewClient(t testing.TB, dir string) (*lxcmgr.Client, *Executor) {
+	t.Helper()
+	fake := NewExecutor()
+	client, err := lxcmgr.NewWithExecutor(dir, fake)
+	t.Cleanup(lxc.ResetExecutor)
+	if err != nil {
+		t.Fatalf("failed to open project %s: %v", dir, err)
+	}
+	return client, fake
+}
+
+// AddContainer adds a container in the given state, with an IP address
+// when running
+func (e *Executor) AddContainer(lxcName string, status lxcmgr.ContainerStatus) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.add(lxcName, "", status)
+}
+
+// Container returns the fake's state of a container, or nil if it doesn't
+// exist. The result is a copy.
+func (e *Executor) Container(lxcName string) *Container {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	c, ok := e.containers[lxcName]
+	if !ok {
+		return nil
+	}
+	copied := *c
+	copied.Snapshots = slices.Clone(c.Snapshots)
+	return &copied
+}
+
+// SetOutput makes commands starting with prefix (e.g. "exec dev1") succeed
+// with output, whatever the container table says. The longest matching
+// prefix wins.
+func (e *Executor) SetOutput(prefix, output string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.scripted[prefix] = response{output: output}
+}
+
+// SetError makes commands starting with prefix fail with msg
+func (e *Executor) SetError(prefix, msg string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.scripted[prefix] = response{err: errors.New(msg)}
+}
+
+// Calls returns the arguments of every command run, in order
+func (e *Executor) Calls() [][]string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	calls := make([][]string, len(e.calls))
+	for i, call := range e.calls {
+		calls[i] = slices.Clone(call)
+	}
+	return calls
+}
+
+// HasCall reports whether a command was run with exactly these arguments
+func (e *Executor) HasCall(args ...string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, call := range e.calls {
+		if slices.Equal(call, args) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasCallPrefix reports whether a command was run whose arguments start
+// with these
+func (e *Executor) HasCallPrefix(args ...string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, call := range e.calls {
+		if len(call) >= len(args) && slices.Equal(call[:len(args)], args) {
+			return true
+		}
+	}
+	return false
+}
+
+// Run implements lxcmgr.Executor
+func (e *Executor) Run(args ...string) ([]byte, error) {
+	return e.run(args)
+}
+
+// RunCombined implements lxcmgr.Executor
+func (e *Executor) RunCombined(args ...string) ([]byte, error) {
+	return e.run(args)
+}
+
+// RunPipe implements lxc.PipeExecutor, used to copy between containers.
+// Both commands are recorded; dest only runs if src succeeds.
+func (e *Executor) RunPipe(src, dest []string) ([]byte, error) {
+	if output, err := e.run(src); err != nil {
+		return output, err
+	}
+	return e.run(dest)
+}
+
+// RunStream implements lxc.StreamExecutor, used by monitor, writing the
+// command's output to w in one go
+func (e *Executor) RunStream(ctx context.Context, w io.Writer, args ...string) error {
+	output, err := e.run(args)
+	w.Write(output)
+	return err
+}
+
+func (e *Executor) run(args []string) ([]byte, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.calls = append(e.calls, slices.Clone(args))
+
+	if resp, ok := e.match(args); ok {
+		return []byte(resp.output), resp.err
+	}
+	if len(args) == 0 {
+		return nil, nil
+	}
+	output, err := e.simulate(args[0], args[1:])
+	return []byte(output), err
+}
+
+// match finds the scripted response with the longest prefix of args
+func (e *Executor) match(args []string) (response, bool) {
+	key := strings.Join(args, " ")
+	best, found := "", false
+	for prefix := range e.scripted {
+		if strings.HasPrefix(key, prefix) && (!found || len(prefix) > len(best)) {
+			best, found = prefix, true
+		}
+	}
+	return e.scripted[best], found
+}
+
+// simulate applies a command to the container table. Must be called with
+// e.mu held.
+func (e *Executor) simulate(verb string, args []string) (string, error) {
+	arg := func(i int) string {
+		if i < len(args) {
+			return args[i]
+		}
+		return ""
+	}
+
+	switch verb {
+	case "launch", "init":
+		if _, ok := e.containers[arg(1)]; ok {
+			return "", fmt.Errorf("Error: Instance %q already exists", arg(1))
+		}
+		status := lxcmgr.StatusRunning
+		if verb == "init" {
+			status = lxcmgr.StatusStopped
+		}
+		e.add(arg(1), arg(0), status)
+		return "", nil
+
+	case "start", "restart":
+		c, err := e.get(arg(0))
+		if err != nil {
+			return "", err
+		}
+		e.setStatus(c, lxcmgr.StatusRunning)
+		return "", nil
+
+	case "stop":
+		c, err := e.get(arg(0))
+		if err != nil {
+			return "", err
+		}
+		e.setStatus(c, lxcmgr.StatusStopped)
+		return "", nil
+
+	case "delete":
+		name, snapshot, isSnapshot := strings.Cut(arg(0), "/")
+		c, err := e.get(name)
+		if err != nil {
+			return "", err
+		}
+		if !isSnapshot {
+			delete(e.containers, name)
+			return "", nil
+		}
+		i := slices.Index(c.Snapshots, snapshot)
+		if i < 0 {
+			return "", ErrNotFound
+		}
+		c.Snapshots = slices.Delete(c.Snapshots, i, i+1)
+		return "", nil
+
+	case "snapshot":
+		c, err := e.get(arg(0))
+		if err != nil {
+			return "", err
+		}
+		c.Snapshots = append(c.Snapshots, arg(1))
+		return "", nil
+
+	case "restore":
+		c, err := e.get(arg(0))
+		if err != nil {
+			return "", err
+		}
+		if !slices.Contains(c.Snapshots, arg(1)) {
+			return "", ErrNotFound
+		}
+		return "", nil
+
+	case "copy":
+		name, snapshot, fromSnapshot := strings.Cut(arg(0), "/")
+		src, err := e.get(name)
+		if err != nil {
+			return "", err
+		}
+		if fromSnapshot && !slices.Contains(src.Snapshots, snapshot) {
+			return "", ErrNotFound
+		}
+		if _, ok := e.containers[arg(1)]; ok {
+			return "", fmt.Errorf("Error: Instance %q already exists", arg(1))
+		}
+		dst := e.add(arg(1), src.Image, lxcmgr.StatusStopped)
+		if !fromSnapshot {
+			dst.Snapshots = slices.Clone(src.Snapshots)
+		}
+		return "", nil
+
+	case "move":
+		c, err := e.get(arg(0))
+		if err != nil {
+			return "", err
+		}
+		delete(e.containers, arg(0))
+		e.containers[arg(1)] = c
+		return "", nil
+
+	case "info":
+		name, snapshot, isSnapshot := strings.Cut(arg(0), "/")
+		c, err := e.get(name)
+		if err != nil {
+			return "", err
+		}
+		if isSnapshot && !slices.Contains(c.Snapshots, snapshot) {
+			return "", ErrNotFound
+		}
+		return fmt.Sprintf("Name: %s\nStatus: %s\n", name, c.Status), nil
+
+	case "list":
+		if arg(0) == "--format" {
+			return e.listJSON()
+		}
+		// lxc list filters by name, so an unknown container lists nothing
+		c, ok := e.containers[arg(0)]
+		if !ok {
+			return "", nil
+		}
+		switch arg(1) {
+		case "-cs":
+			return string(c.Status), nil
+		case "-c4":
+			if c.Status == lxcmgr.StatusRunning && c.IP != "" {
+				return c.IP + " (eth0)", nil
+			}
+		}
+		return "", nil
+
+	case "query":
+		path := strings.TrimPrefix(arg(0), "/1.0/instances/")
+		if name, ok := strings.CutSuffix(path, "/snapshots"); ok {
+			c, err := e.get(name)
+			if err != nil {
+				return "", err
+			}
+			paths := make([]string, len(c.Snapshots))
+			for i, snapshot := range c.Snapshots {
+				paths[i] = "/1.0/instances/" + name + "/snapshots/" + snapshot
+			}
+			data, err := json.Marshal(paths)
+			return string(data), err
+		}
+		return "", nil
+
+	case "exec":
+		c, err := e.get(arg(0))
+		if err != nil {
+			return "", err
+		}
+		if c.Status != lxcmgr.StatusRunning {
+			return "", fmt.Errorf("Error: Instance is not running")
+		}
+		if i := slices.Index(args, "--"); i >= 0 && arg(i+1) == "cloud-init" {
+			return "status: done", nil
+		}
+		return "", nil
+	}
+	return "", nil
+}
+
+// add creates a container. Must be called with e.mu held.
+func (e *Executor) add(name, image string, status lxcmgr.ContainerStatus) *Container {
+	c := &Container{Image: image}
+	e.containers[name] = c
+	e.setStatus(c, status)
+	return c
+}
+
+// setStatus changes a container's status, giving it an address the first
+// time it runs. Must be called with e.mu held.
+func (e *Executor) setStatus(c *Container, status lxcmgr.ContainerStatus) {
+	c.Status = status
+	if status == lxcmgr.StatusRunning && c.IP == "" {
+		e.nextIP++
+		c.IP = fmt.Sprintf("10.0.3.%d", 100+e.nextIP%150)
+	}
+}
+
+// get returns a container or ErrNotFound. Must be called with e.mu held.
+func (e *Executor) get(name string) (*Container, error) {
+	c, ok := e.containers[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return c, nil
+}
+
+// listJSON renders the container table like "lxc list --format json".
+// Must be called with e.mu held.
+func (e *Executor) listJSON() (string, error) {
+	type address struct {
+		Family  string `json:"family"`
+		Address string `json:"address"`
+		Scope   string `json:"scope"`
+	}
+	names := make([]string, 0, len(e.containers))
+	for name := range e.containers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	instances := make([]map[string]any, 0, len(names))
+	for _, name := range names {
+		c := e.containers[name]
+		addrs := []address{}
+		if c.Status == lxcmgr.StatusRunning && c.IP != "" {
+			addrs = append(addrs, address{"inet", c.IP, "global"})
+		}
+		snapshots := make([]map[string]string, len(c.Snapshots))
+		for i, snapshot := range c.Snapshots {
+			snapshots[i] = map[string]string{"name": snapshot}
+		}
+		instances = append(instances, map[string]any{
+			"name":      name,
+			"status":    string(c.Status),
+			"snapshots": snapshots,
+			"state": map[string]any{
+				"network": map[string]any{"eth0": map[string]any{"addresses": addrs}},
+			},
+		})
+	}
+	data, err := json.Marshal(instances)
+	return string(data), err
+}
@@ -0,0 +1,238 @@
+package lxcmgrtest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"lxc-dev-manager/internal/lxc"
+	"lxc-dev-manager/pkg/lxcmgr"
+)
+
+func writeProject(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	config := `project: app
+containers:
+  dev1:
+    image: ubuntu:24.04
+  dev2:
+    image: ubuntu:24.04
+`
+	if err := os.WriteFile(filepath.Join(dir, "containers.yaml"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestFake_Lifecycle(t *testing.T) {
+	client, fake := NewClient(t, writeProject(t))
+	fake.AddContainer("app-dev1", lxcmgr.StatusStopped)
+
+	if err := client.Start("dev1"); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if !fake.HasCall("start", "app-dev1") {
+		t.Errorf("expected start to be run, got %v", fake.Calls())
+	}
+	status, err := client.Status("dev1")
+	if err != nil || status != lxcmgr.StatusRunning {
+		t.Errorf("Status = %q, %v; want RUNNING", status, err)
+	}
+	if ip, err := client.IP("dev1"); err != nil || ip == "" {
+		t.Errorf("expected a running container to have an IP, got %q, %v", ip, err)
+	}
+
+	if err := client.Stop("dev1"); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if c := fake.Container("app-dev1"); c == nil || c.Status != lxcmgr.StatusStopped {
+		t.Errorf("expected dev1 to be stopped, got %+v", c)
+	}
+}
+
+func TestFake_List(t *testing.T) {
+	client, fake := NewClient(t, writeProject(t))
+	fake.AddContainer("app-dev1", lxcmgr.StatusRunning)
+
+	containers, err := client.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	statuses := make(map[string]lxcmgr.ContainerStatus)
+	for _, c := range containers {
+		statuses[c.Name] = c.Status
+	}
+	if statuses["dev1"] != lxcmgr.StatusRunning || statuses["dev2"] != lxcmgr.StatusNotFound {
+		t.Errorf("unexpected statuses: %v", statuses)
+	}
+}
+
+func TestFake_SnapshotsAndClone(t *testing.T) {
+	client, fake := NewClient(t, writeProject(t))
+	fake.AddContainer("app-dev1", lxcmgr.StatusRunning)
+
+	if err := client.CreateSnapshot("dev1", "before", ""); err != nil {
+		t.Fatalf("CreateSnapshot: %v", err)
+	}
+	if err := client.Clone("dev1", "dev3"); err != nil {
+		t.Fatalf("Clone: %v", err)
+	}
+	c := fake.Container("app-dev3")
+	if c == nil || c.Status != lxcmgr.StatusRunning {
+		t.Fatalf("expected the clone to be started, got %+v", c)
+	}
+	if len(c.Snapshots) != 2 {
+		t.Errorf("expected the clone to keep the source's snapshot and get initial-state, got %v", c.Snapshots)
+	}
+}
+
+func TestFake_Scripted(t *testing.T) {
+	client, fake := NewClient(t, writeProject(t))
+	fake.AddContainer("app-dev1", lxcmgr.StatusStopped)
+	fake.SetError("start app-dev1", "Error: boom")
+
+	if err := client.Start("dev1"); err == nil {
+		t.Error("expected the scripted error")
+	}
+	if err := client.Start("dev2"); err == nil {
+		t.Error("expected an error for a container the fake doesn't know")
+	}
+}
+
+func TestNewProjectWithExecutor(t *testing.T) {
+	fake := NewExecutor()
+	t.Cleanup(lxc.ResetExecutor)
+
+	client, err := lxcmgr.NewProjectWithExecutor(t.TempDir(), fake, lxcmgr.WithProjectName("web"))
+	if err != nil {
+		t.Fatalf("NewProjectWithExecutor: %v", err)
+	}
+	if client.ProjectName() != "web" {
+		t.Errorf("ProjectName = %q, want web", client.ProjectName())
+	}
+	if err := client.CreateContainer("api", "ubuntu:24.04"); err != nil {
+		t.Fatalf("CreateContainer: %v", err)
+	}
+	c := fake.Container("web-api")
+	if c == nil || c.Status != lxcmgr.StatusRunning || c.Image != "ubuntu:24.04" {
+		t.Fatalf("expected the container to be launched through the fake, got %+v", c)
+	}
+	if len(c.Snapshots) != 1 || c.Snapshots[0] != "initial-state" {
+		t.Errorf("expected the initial-state snapshot, got %v", c.Snapshots)
+	}
+}
+
+func TestProgress(t *testing.T) {
+	client, fake := NewClient(t, writeProject(t))
+	fake.AddContainer("app-dev1", lxcmgr.StatusRunning)
+
+	var steps []lxcmgr.Step
+	record := func(step lxcmgr.Step) { steps = append(steps, step) }
+	has := func(container, name string) bool {
+		for _, step := range steps {
+			if step.Container == container && step.Name == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	if err := client.CreateContainer("dev3", "ubuntu:24.04", lxcmgr.WithProgress(record)); err != nil {
+		t.Fatalf("CreateContainer: %v", err)
+	}
+	if !has("dev3", "launching") || !has("dev3", "waiting for boot") || !has("dev3", "creating snapshot") {
+		t.Errorf("expected create steps, got %v", steps)
+	}
+
+	steps = nil
+	if err := client.Clone("dev1", "dev4", lxcmgr.WithCloneProgress(record)); err != nil {
+		t.Fatalf("Clone: %v", err)
+	}
+	if !has("dev4", "copying") || !has("dev4", "starting") {
+		t.Errorf("expected clone steps, got %v", steps)
+	}
+
+	// lxc publish streams its output, so it runs lxc directly rather than
+	// through the fake and fails here; the steps before it are still reported
+	steps = nil
+	client.CreateImage("dev1", "app-base", lxcmgr.WithImageProgress(record))
+	if !has("dev1", "creating snapshot") || !has("dev1", "publishing") {
+		t.Errorf("expected image steps, got %v", steps)
+	}
+}
+
+func TestFake_CreateWithPorts(t *testing.T) {
+	dir := writeProject(t)
+	client, _ := NewClient(t, dir)
+
+	err := client.CreateContainer("dev3", "ubuntu:24.04",
+		lxcmgr.WithPorts(3000, 5173), lxcmgr.WithoutUser(), lxcmgr.WithoutSSH(), lxcmgr.WithoutSnapshot())
+	if err != nil {
+		t.Fatalf("CreateContainer: %v", err)
+	}
+
+	containers, err := client.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	found := false
+	for _, c := range containers {
+		if c.Name != "dev3" {
+			continue
+		}
+		found = true
+		if len(c.Ports) != 2 || c.Ports[0] != 3000 || c.Ports[1] != 5173 {
+			t.Errorf("Ports = %v, want [3000 5173]", c.Ports)
+		}
+	}
+	if !found {
+		t.Fatalf("dev3 not listed: %+v", containers)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "containers.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "- 3000") {
+		t.Errorf("ports not saved in containers.yaml:\n%s", data)
+	}
+}
+
+func TestFake_CopyBetweenContainers(t *testing.T) {
+	client, fake := NewClient(t, writeProject(t))
+	fake.AddContainer("app-dev1", lxcmgr.StatusRunning)
+	fake.AddContainer("app-dev2", lxcmgr.StatusRunning)
+	fake.SetError("exec app-dev1 -- test -d", "exit status 1")
+
+	if err := client.CopyBetweenContainers("dev1", "/tmp/data.txt", "dev2", "/tmp/data.txt"); err != nil {
+		t.Fatalf("CopyBetweenContainers: %v", err)
+	}
+	if !fake.HasCall("exec", "app-dev1", "--", "cat", "--", "/tmp/data.txt") {
+		t.Errorf("expected the file to be streamed from dev1, got %v", fake.Calls())
+	}
+	if !fake.HasCallPrefix("exec", "app-dev2", "--", "sh", "-c") {
+		t.Errorf("expected the file to be streamed into dev2, got %v", fake.Calls())
+	}
+}
+
+func TestFake_CreateIgnoresDefaultPrivileged(t *testing.T) {
+	dir := t.TempDir()
+	config := `project: app
+defaults:
+  privileged: true
+containers: {}
+`
+	if err := os.WriteFile(filepath.Join(dir, "containers.yaml"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	client, fake := NewClient(t, dir)
+
+	if err := client.CreateContainer("dev3", "ubuntu:24.04"); err != nil {
+		t.Fatalf("CreateContainer: %v", err)
+	}
+	if fake.HasCallPrefix("config", "set", "app-dev3", "security.privileged") {
+		t.Error("defaults.privileged should need the confirming container create command")
+	}
+}
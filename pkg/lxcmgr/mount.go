@@ -27,12 +27,17 @@ func (c *Client) Mount(container, source, path string, opts ...MountOption) erro
 		Name:           o.name,
 		ReadWrite:      o.readWrite,
 		Shift:          o.shift,
+		IDMap:          o.idmap,
+		NoAutoShift:    o.noAutoShift,
+		NoAutoSuffix:   o.noAutoSuffix,
+		Recursive:      o.recursive,
+		Propagation:    o.propagation,
 		AllowRiskyPath: o.allowRiskyPath,
 	}); err != nil {
 		return wrapMountErr("mount", container, o.name, err)
 	}
 
-	c.cfg = cfg
+	c.setConfig(cfg)
 	return nil
 }
 
@@ -51,13 +56,17 @@ func (c *Client) Unmount(container, nameOrPath string) error {
 		return wrapMountErr("unmount", container, nameOrPath, err)
 	}
 
-	c.cfg = cfg
+	c.setConfig(cfg)
 	return nil
 }
 
 // ListMounts returns all mounts for a container
 func (c *Client) ListMounts(container string) ([]MountInfo, error) {
-	mounts, err := operations.ListMounts(c.cfg, container)
+	cfg, err := c.config()
+	if err != nil {
+		return nil, err
+	}
+	mounts, err := operations.ListMounts(cfg, container)
 	if err != nil {
 		return nil, wrapMountErr("list", container, "", err)
 	}
@@ -90,6 +99,35 @@ func (c *Client) SyncMounts(container string) error {
 		return wrapMountErr("sync", container, "", err)
 	}
 
-	c.cfg = cfg
+	c.setConfig(cfg)
 	return nil
 }
+
+// Mount adds a mount to a container of the project in projectDir. Like the
+// other package-level project functions, it reads containers.yaml for this
+// call only, so short-lived callers don't need to keep a Client.
+func Mount(projectDir, container, source, path string, opts ...MountOption) error {
+	c, err := New(projectDir)
+	if err != nil {
+		return err
+	}
+	return c.Mount(container, source, path, opts...)
+}
+
+// Unmount removes a mount from a container of the project in projectDir
+func Unmount(projectDir, container, nameOrPath string) error {
+	c, err := New(projectDir)
+	if err != nil {
+		return err
+	}
+	return c.Unmount(container, nameOrPath)
+}
+
+// ListMounts returns all mounts of a container of the project in projectDir
+func ListMounts(projectDir, container string) ([]MountInfo, error) {
+	c, err := New(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	return c.ListMounts(container)
+}
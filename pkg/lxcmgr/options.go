@@ -1,5 +1,28 @@
 package lxcmgr
 
+import (
+	"time"
+
+	"lxc-dev-manager/internal/operations"
+)
+
+// ClientOption configures a client opened with New
+type ClientOption func(*clientOpts)
+
+type clientOpts struct {
+	noAutoReload bool
+}
+
+// WithoutAutoReload keeps the config the client read when it was opened,
+// or on Reload, instead of reading containers.yaml again whenever another
+// process changes it. Changes made through the client are still seen.
+// Useful to get a consistent view of the project across several calls.
+func WithoutAutoReload() ClientOption {
+	return func(o *clientOpts) {
+		o.noAutoReload = true
+	}
+}
+
 // ProjectOption configures project creation
 type ProjectOption func(*projectOpts)
 
@@ -39,9 +62,18 @@ type createOpts struct {
 	ports    []int
 	user     string
 	password string
+	ip       string
+	workdir  string
+
+	noUser     bool
+	noSSH      bool
+	noSnapshot bool
+	noRollback bool
+	progress   func(Step)
 }
 
-// WithPorts sets the ports for the container
+// WithPorts sets the ports for the container. They are recorded in
+// containers.yaml and used by proxy and list instead of defaults.ports.
 func WithPorts(ports ...int) CreateOption {
 	return func(o *createOpts) {
 		o.ports = ports
@@ -56,11 +88,122 @@ func WithUser(name, password string) CreateOption {
 	}
 }
 
+// WithStaticIP pins the container to a fixed IPv4 address on the LXC bridge
+func WithStaticIP(ip string) CreateOption {
+	return func(o *createOpts) {
+		o.ip = ip
+	}
+}
+
+// WithWorkdir mounts the project directory read-write at path and exports
+// it as $WORKDIR inside the container
+func WithWorkdir(path string) CreateOption {
+	return func(o *createOpts) {
+		o.workdir = path
+	}
+}
+
+// WithoutUser skips creating the user, for throwaway containers; Exec
+// and Shell run as root
+func WithoutUser() CreateOption {
+	return func(o *createOpts) {
+		o.noUser = true
+	}
+}
+
+// WithoutSSH skips installing and enabling the SSH server
+func WithoutSSH() CreateOption {
+	return func(o *createOpts) {
+		o.noSSH = true
+	}
+}
+
+// WithoutSnapshot skips the initial-state snapshot, so Reset needs a named
+// snapshot
+func WithoutSnapshot() CreateOption {
+	return func(o *createOpts) {
+		o.noSnapshot = true
+	}
+}
+
+// WithoutRollback keeps a container whose setup failed, for inspection,
+// instead of deleting it
+func WithoutRollback() CreateOption {
+	return func(o *createOpts) {
+		o.noRollback = true
+	}
+}
+
+// WithProgress calls fn as each setup step starts
+func WithProgress(fn func(Step)) CreateOption {
+	return func(o *createOpts) {
+		o.progress = fn
+	}
+}
+
+// StopOption configures stopping a container
+type StopOption func(*stopOpts)
+
+type stopOpts struct {
+	timeout time.Duration
+	force   bool
+}
+
+// WithStopTimeout sets how long to wait for a clean shutdown, overriding
+// the container's stop_timeout. A negative timeout with ForceStop kills the
+// container without waiting.
+func WithStopTimeout(d time.Duration) StopOption {
+	return func(o *stopOpts) {
+		o.timeout = d
+	}
+}
+
+// ForceStop kills the container if it hasn't shut down cleanly in time
+func ForceStop() StopOption {
+	return func(o *stopOpts) {
+		o.force = true
+	}
+}
+
+// ResetOption configures resetting a container
+type ResetOption func(*resetOpts)
+
+type resetOpts struct {
+	snapshotFirst bool
+	syncFiles     bool
+	pruneDevices  bool
+}
+
+// WithSnapshotFirst keeps the container's current state as a timestamped
+// pre-reset snapshot before restoring
+func WithSnapshotFirst() ResetOption {
+	return func(o *resetOpts) {
+		o.snapshotFirst = true
+	}
+}
+
+// WithSyncFiles copies the container's sync entries again once it is back
+// up after the reset
+func WithSyncFiles() ResetOption {
+	return func(o *resetOpts) {
+		o.syncFiles = true
+	}
+}
+
+// WithPruneDevices detaches the disk, USB and character devices
+// containers.yaml doesn't list after the reset
+func WithPruneDevices() ResetOption {
+	return func(o *resetOpts) {
+		o.pruneDevices = true
+	}
+}
+
 // CloneOption configures container cloning
 type CloneOption func(*cloneOpts)
 
 type cloneOpts struct {
 	fromSnapshot string
+	progress     func(Step)
 }
 
 // FromSnapshot clones from a specific snapshot instead of current state
@@ -70,6 +213,27 @@ func FromSnapshot(name string) CloneOption {
 	}
 }
 
+// WithCloneProgress calls fn as each clone step starts
+func WithCloneProgress(fn func(Step)) CloneOption {
+	return func(o *cloneOpts) {
+		o.progress = fn
+	}
+}
+
+// ImageOption configures image creation
+type ImageOption func(*imageOpts)
+
+type imageOpts struct {
+	progress func(Step)
+}
+
+// WithImageProgress calls fn as each step of publishing the image starts
+func WithImageProgress(fn func(Step)) ImageOption {
+	return func(o *imageOpts) {
+		o.progress = fn
+	}
+}
+
 // MountOption configures mount operations
 type MountOption func(*mountOpts)
 
@@ -77,6 +241,11 @@ type mountOpts struct {
 	name           string
 	readWrite      bool
 	shift          bool
+	idmap          string
+	noAutoShift    bool
+	noAutoSuffix   bool
+	recursive      bool
+	propagation    string
 	allowRiskyPath bool
 }
 
@@ -101,6 +270,51 @@ func WithShift() MountOption {
 	}
 }
 
+// WithIDMap sets a raw.idmap entry such as "both 1000 1000" on the container.
+// The mapping takes effect on the next container start.
+func WithIDMap(entry string) MountOption {
+	return func(o *mountOpts) {
+		o.idmap = entry
+	}
+}
+
+// WithAutoIDMap maps the owner of the mount source to the container user
+func WithAutoIDMap() MountOption {
+	return func(o *mountOpts) {
+		o.idmap = operations.IDMapAuto
+	}
+}
+
+// WithoutAutoShift keeps rw mounts unshifted even when the source owner
+// doesn't match the container user's UID mapping
+func WithoutAutoShift() MountOption {
+	return func(o *mountOpts) {
+		o.noAutoShift = true
+	}
+}
+
+// WithoutAutoSuffix makes Mount fail when the auto-generated name is taken
+// instead of picking name-2, name-3, ...
+func WithoutAutoSuffix() MountOption {
+	return func(o *mountOpts) {
+		o.noAutoSuffix = true
+	}
+}
+
+// WithRecursive also binds mounts nested under the source
+func WithRecursive() MountOption {
+	return func(o *mountOpts) {
+		o.recursive = true
+	}
+}
+
+// WithPropagation sets the bind propagation mode (e.g. "shared", "rslave")
+func WithPropagation(mode string) MountOption {
+	return func(o *mountOpts) {
+		o.propagation = mode
+	}
+}
+
 // AllowRiskyPaths allows mounting paths that are flagged as risky
 func AllowRiskyPaths() MountOption {
 	return func(o *mountOpts) {
@@ -108,6 +322,40 @@ func AllowRiskyPaths() MountOption {
 	}
 }
 
+// ProxyOption configures port proxying
+type ProxyOption func(*proxyOpts)
+
+type proxyOpts struct {
+	preferIPv6 bool
+	autoPort   bool
+	tls        bool
+}
+
+// PreferIPv6 forwards to the container's IPv6 address when it has one
+func PreferIPv6() ProxyOption {
+	return func(o *proxyOpts) {
+		o.preferIPv6 = true
+	}
+}
+
+// WithAutoPort forwards a port that is already taken on the host (by
+// another project's proxy or another program) from the next free port
+// instead of failing. ProxyManager.LocalPorts tells which.
+func WithAutoPort() ProxyOption {
+	return func(o *proxyOpts) {
+		o.autoPort = true
+	}
+}
+
+// WithTLS terminates HTTPS on the local ports with certificates for
+// localhost and *.localhost from a local CA, forwarding plain HTTP to the
+// container. ProxyManager.CACert is the certificate to trust.
+func WithTLS() ProxyOption {
+	return func(o *proxyOpts) {
+		o.tls = true
+	}
+}
+
 // ShellOption configures shell access
 type ShellOption func(*shellOpts)
 
@@ -127,6 +375,20 @@ type CopyOption func(*copyOpts)
 
 type copyOpts struct {
 	autoCreateDir bool
+	exclude       []string
+	verify        bool
+	progress      func(CopyProgress)
+}
+
+// stepProgress adapts a Step callback to the operations package's, or
+// returns nil when fn is
+func stepProgress(fn func(Step)) func(name, step string) {
+	if fn == nil {
+		return nil
+	}
+	return func(name, step string) {
+		fn(Step{Container: name, Name: step})
+	}
 }
 
 // AutoCreateDir automatically creates the destination directory if it doesn't exist
@@ -135,3 +397,26 @@ func AutoCreateDir() CopyOption {
 		o.autoCreateDir = true
 	}
 }
+
+// WithExclude skips paths matching the patterns when copying a directory,
+// in addition to those in the directory's .lxcignore file
+func WithExclude(patterns ...string) CopyOption {
+	return func(o *copyOpts) {
+		o.exclude = append(o.exclude, patterns...)
+	}
+}
+
+// WithVerify compares sha256 checksums on both sides after copying
+func WithVerify() CopyOption {
+	return func(o *copyOpts) {
+		o.verify = true
+	}
+}
+
+// WithCopyProgress calls fn periodically while copying, and once with
+// Finished set when each transfer completes (twice for container to container)
+func WithCopyProgress(fn func(CopyProgress)) CopyOption {
+	return func(o *copyOpts) {
+		o.progress = fn
+	}
+}
@@ -4,10 +4,8 @@ import "lxc-dev-manager/internal/config"
 
 // GetDefaultPorts returns the default ports from containers.yaml.
 func (c *Client) GetDefaultPorts() []int {
-	if c.cfg == nil {
-		return nil
-	}
-	return c.cfg.Defaults.Ports
+	cfg, _ := c.config()
+	return cfg.Defaults.Ports
 }
 
 // SetDefaultPorts updates the default ports in containers.yaml.
@@ -22,6 +20,6 @@ func (c *Client) SetDefaultPorts(ports []int) error {
 	if err := cfg.Save(); err != nil {
 		return err
 	}
-	c.cfg = cfg
+	c.setConfig(cfg)
 	return nil
 }
@@ -1,28 +1,100 @@
 package lxcmgr
 
 import (
+	"path/filepath"
+
 	"lxc-dev-manager/internal/operations"
 	"lxc-dev-manager/internal/proxy"
 )
 
 // ProxyManager manages TCP proxies for port forwarding
 type ProxyManager struct {
-	manager *proxy.Manager
-	IP      string
-	Ports   []int
+	manager    *proxy.Manager
+	container  string
+	IP         string
+	Ports      []int  // Container ports
+	LocalPorts []int  // Where each of Ports is forwarded from; differs only WithAutoPort
+	CACert     string // Certificate of the local CA to trust, WithTLS
 }
 
 // StartProxy starts proxying ports for a container
-func (c *Client) StartProxy(name string) (*ProxyManager, error) {
-	manager, ip, ports, err := operations.StartProxy(c.cfg, name)
+func (c *Client) StartProxy(name string, opts ...ProxyOption) (*ProxyManager, error) {
+	o := &proxyOpts{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	cfg, err := c.config()
+	if err != nil {
+		return nil, err
+	}
+
+	manager, ip, forwards, err := operations.StartProxy(cfg, name, operations.ProxyOpts{
+		PreferIPv6: o.preferIPv6,
+		AutoPort:   o.autoPort,
+		TLS:        o.tls,
+	})
 	if err != nil {
 		return nil, wrapContainerErr("proxy", name, err)
 	}
-	return &ProxyManager{
-		manager: manager,
-		IP:      ip,
-		Ports:   ports,
-	}, nil
+	pm := &ProxyManager{manager: manager, container: name, IP: ip}
+	if o.tls {
+		if dir, err := proxy.DefaultCADir(); err == nil {
+			pm.CACert = filepath.Join(dir, proxy.CACertFile)
+		}
+	}
+	for _, f := range forwards {
+		pm.Ports = append(pm.Ports, f.Container)
+		pm.LocalPorts = append(pm.LocalPorts, f.Local)
+	}
+	return pm, nil
+}
+
+// Stats returns the connection counters of each forwarded port
+func (pm *ProxyManager) Stats() []ProxyStats {
+	if pm.manager == nil {
+		return nil
+	}
+	var stats []ProxyStats
+	for _, s := range pm.manager.Stats() {
+		stats = append(stats, toProxyStats(pm.container, s))
+	}
+	return stats
+}
+
+// ProxyStats returns the connection counters of ports forwarded by the
+// project's running proxies, including those of other processes (such as
+// the proxy command), for every container or only container's when given.
+// Counters of other processes are at most a second old.
+func (c *Client) ProxyStats(container string) ([]ProxyStats, error) {
+	cfg, err := c.config()
+	if err != nil {
+		return nil, err
+	}
+	forwards, err := operations.ProxyStats(cfg, container)
+	if err != nil {
+		return nil, wrapContainerErr("proxy stats", container, err)
+	}
+	var stats []ProxyStats
+	for _, f := range forwards {
+		stats = append(stats, toProxyStats(f.Owner.Container, f.Stats))
+	}
+	return stats, nil
+}
+
+func toProxyStats(container string, s proxy.Stats) ProxyStats {
+	return ProxyStats{
+		Container:      container,
+		LocalPort:      s.Port,
+		Remote:         s.Remote,
+		Active:         s.Active,
+		Total:          s.Total,
+		Rejected:       s.Rejected,
+		Failed:         s.Failed,
+		BytesIn:        s.BytesIn,
+		BytesOut:       s.BytesOut,
+		LastConnection: s.LastConnection,
+	}
 }
 
 // Stop stops all proxies
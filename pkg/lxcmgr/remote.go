@@ -0,0 +1,255 @@
+package lxcmgr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+)
+
+// RemoteClient drives a project through a running "lxc-dev-manager daemon"
+// instead of calling LXC directly. Its methods mirror Client's.
+type RemoteClient struct {
+	http *http.Client
+}
+
+// APIError is an error reported by the daemon
+type APIError struct {
+	Code      string // failure type, e.g. "not_found" or "lxc_failure"
+	Message   string
+	Container string
+}
+
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// Is lets errors.Is match the SDK's sentinel errors against the failure type
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrContainerNotFound:
+		return e.Code == "not_found" && e.Container != ""
+	case ErrContainerStopped:
+		return e.Code == "not_running"
+	case ErrValidation:
+		return e.Code == "validation"
+	}
+	return false
+}
+
+// Dial connects to the daemon listening on socketPath (see DaemonSocket)
+// and checks that it responds
+func Dial(socketPath string) (*RemoteClient, error) {
+	rc := &RemoteClient{
+		http: &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, "unix", socketPath)
+				},
+			},
+		},
+	}
+	if _, err := rc.ProjectName(); err != nil {
+		return nil, fmt.Errorf("failed to reach daemon at %s: %w", socketPath, err)
+	}
+	return rc, nil
+}
+
+// Close releases the client's idle connections to the daemon
+func (rc *RemoteClient) Close() {
+	rc.http.CloseIdleConnections()
+}
+
+// do sends a request with body encoded as JSON and decodes the response
+// into out, if non-nil
+func (rc *RemoteClient) do(method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	// The host is ignored; requests always go to the socket
+	req, err := http.NewRequest(method, "http://daemon"+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := rc.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		var e errorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&e); err != nil {
+			return fmt.Errorf("daemon returned %s", resp.Status)
+		}
+		return &APIError{Code: e.Code, Message: e.Message, Container: e.Container}
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func containerPath(name string, rest ...string) string {
+	path := "/v1/containers/" + url.PathEscape(name)
+	for _, part := range rest {
+		path += "/" + url.PathEscape(part)
+	}
+	return path
+}
+
+// ProjectName returns the project name
+func (rc *RemoteClient) ProjectName() (string, error) {
+	var p projectResponse
+	err := rc.do("GET", "/v1/project", nil, &p)
+	return p.Name, err
+}
+
+// List returns all containers in the project
+func (rc *RemoteClient) List() ([]ContainerInfo, error) {
+	var containers []ContainerInfo
+	err := rc.do("GET", "/v1/containers", nil, &containers)
+	return containers, err
+}
+
+// CreateContainer creates a new container in the project
+func (rc *RemoteClient) CreateContainer(name, image string, opts ...CreateOption) error {
+	o := &createOpts{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return rc.do("POST", "/v1/containers", createRequest{
+		Name:     name,
+		Image:    image,
+		Ports:    o.ports,
+		User:     o.user,
+		Password: o.password,
+		StaticIP: o.ip,
+		Workdir:  o.workdir,
+	}, nil)
+}
+
+// Start starts a stopped container
+func (rc *RemoteClient) Start(name string) error {
+	return rc.do("POST", containerPath(name, "start"), nil, nil)
+}
+
+// Stop stops a running container
+func (rc *RemoteClient) Stop(name string) error {
+	return rc.do("POST", containerPath(name, "stop"), nil, nil)
+}
+
+// Remove removes a container from the project
+func (rc *RemoteClient) Remove(name string, force bool) error {
+	path := containerPath(name)
+	if force {
+		path += "?force=true"
+	}
+	return rc.do("DELETE", path, nil, nil)
+}
+
+// Reset restores a container to a snapshot ("" for initial-state)
+func (rc *RemoteClient) Reset(name, snapshot string, opts ...ResetOption) error {
+	o := &resetOpts{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	req := resetRequest{Snapshot: snapshot, SnapshotFirst: o.snapshotFirst, SyncFiles: o.syncFiles, PruneDevices: o.pruneDevices}
+	return rc.do("POST", containerPath(name, "reset"), req, nil)
+}
+
+// Status returns the status of a container
+func (rc *RemoteClient) Status(name string) (ContainerStatus, error) {
+	var s statusResponse
+	err := rc.do("GET", containerPath(name), nil, &s)
+	return s.Status, err
+}
+
+// IP returns the IP address of a running container
+func (rc *RemoteClient) IP(name string) (string, error) {
+	var s statusResponse
+	if err := rc.do("GET", containerPath(name), nil, &s); err != nil {
+		return "", err
+	}
+	if s.Status != StatusRunning {
+		return "", fmt.Errorf("container '%s' is not running: %w", name, ErrContainerStopped)
+	}
+	return s.IP, nil
+}
+
+// Exec runs a command in a container and returns its combined output
+func (rc *RemoteClient) Exec(name string, cmd []string) ([]byte, error) {
+	if len(cmd) == 0 {
+		return nil, errors.New("command required")
+	}
+	var resp execResponse
+	err := rc.do("POST", containerPath(name, "exec"), execRequest{Command: cmd}, &resp)
+	return resp.Output, err
+}
+
+// CreateSnapshot creates a snapshot of a container
+func (rc *RemoteClient) CreateSnapshot(container, name, description string) error {
+	return rc.do("POST", containerPath(container, "snapshots"), snapshotRequest{Name: name, Description: description}, nil)
+}
+
+// ListSnapshots returns the snapshots of a container
+func (rc *RemoteClient) ListSnapshots(container string) ([]SnapshotInfo, error) {
+	var snapshots []SnapshotInfo
+	err := rc.do("GET", containerPath(container, "snapshots"), nil, &snapshots)
+	return snapshots, err
+}
+
+// DeleteSnapshot deletes a snapshot
+func (rc *RemoteClient) DeleteSnapshot(container, name string) error {
+	return rc.do("DELETE", containerPath(container, "snapshots", name), nil, nil)
+}
+
+// Mount mounts a host directory into a container. The source path is
+// resolved on the daemon's host.
+func (rc *RemoteClient) Mount(container, source, path string, opts ...MountOption) error {
+	o := &mountOpts{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return rc.do("POST", containerPath(container, "mounts"), mountRequest{
+		Source:         source,
+		Path:           path,
+		Name:           o.name,
+		ReadWrite:      o.readWrite,
+		Shift:          o.shift,
+		IDMap:          o.idmap,
+		NoAutoShift:    o.noAutoShift,
+		NoAutoSuffix:   o.noAutoSuffix,
+		Recursive:      o.recursive,
+		Propagation:    o.propagation,
+		AllowRiskyPath: o.allowRiskyPath,
+	}, nil)
+}
+
+// Unmount removes a mount from a container
+func (rc *RemoteClient) Unmount(container, nameOrPath string) error {
+	return rc.do("DELETE", containerPath(container, "mounts", nameOrPath), nil, nil)
+}
+
+// ListMounts returns the mounts of a container
+func (rc *RemoteClient) ListMounts(container string) ([]MountInfo, error) {
+	var mounts []MountInfo
+	err := rc.do("GET", containerPath(container, "mounts"), nil, &mounts)
+	return mounts, err
+}
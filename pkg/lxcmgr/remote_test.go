@@ -0,0 +1,141 @@
+package lxcmgr
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// startTestDaemon serves the test project on a unix socket and returns a
+// RemoteClient connected to it
+func startTestDaemon(t *testing.T) *RemoteClient {
+	t.Helper()
+
+	tmpDir, cleanup := setupTestProject(t)
+	t.Cleanup(cleanup)
+
+	client, err := New(tmpDir)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	// Socket paths are limited to ~108 bytes, so keep it short
+	sockDir, err := os.MkdirTemp("", "lxcmgr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(sockDir) })
+	socket := filepath.Join(sockDir, "d.sock")
+
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{Handler: DaemonHandler(client)}
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+
+	rc, err := Dial(socket)
+	if err != nil {
+		t.Fatalf("Dial() failed: %v", err)
+	}
+	t.Cleanup(rc.Close)
+	return rc
+}
+
+func TestRemoteClient_ProjectName(t *testing.T) {
+	_, mockCleanup := setupMockExecutor(t)
+	t.Cleanup(mockCleanup)
+	rc := startTestDaemon(t)
+
+	name, err := rc.ProjectName()
+	if err != nil {
+		t.Fatalf("ProjectName() failed: %v", err)
+	}
+	if name != "test-project" {
+		t.Errorf("Expected 'test-project', got '%s'", name)
+	}
+}
+
+func TestRemoteClient_Start(t *testing.T) {
+	mock, mockCleanup := setupMockExecutor(t)
+	t.Cleanup(mockCleanup)
+	rc := startTestDaemon(t)
+
+	mock.SetOutput("list test-project-dev1 -cs -f csv", "STOPPED")
+
+	if err := rc.Start("dev1"); err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+	if !mock.HasCallPrefix("start", "test-project-dev1") {
+		t.Error("Expected start command to be called")
+	}
+}
+
+func TestRemoteClient_Status(t *testing.T) {
+	mock, mockCleanup := setupMockExecutor(t)
+	t.Cleanup(mockCleanup)
+	rc := startTestDaemon(t)
+
+	mock.SetOutput("list test-project-dev1 -cs -f csv", "RUNNING")
+	mock.SetOutput("list test-project-dev1 -c4 -f csv", "10.0.0.5 (eth0)")
+
+	status, err := rc.Status("dev1")
+	if err != nil {
+		t.Fatalf("Status() failed: %v", err)
+	}
+	if status != StatusRunning {
+		t.Errorf("Expected status RUNNING, got %s", status)
+	}
+
+	ip, err := rc.IP("dev1")
+	if err != nil {
+		t.Fatalf("IP() failed: %v", err)
+	}
+	if ip != "10.0.0.5" {
+		t.Errorf("Expected IP '10.0.0.5', got '%s'", ip)
+	}
+}
+
+func TestRemoteClient_ListSnapshots(t *testing.T) {
+	mock, mockCleanup := setupMockExecutor(t)
+	t.Cleanup(mockCleanup)
+	rc := startTestDaemon(t)
+
+	mock.SetOutput("query /1.0/instances/test-project-dev1/snapshots", `["/1.0/instances/test-project-dev1/snapshots/initial-state"]`)
+
+	snapshots, err := rc.ListSnapshots("dev1")
+	if err != nil {
+		t.Fatalf("ListSnapshots() failed: %v", err)
+	}
+	if len(snapshots) != 1 || snapshots[0].Name != "initial-state" {
+		t.Errorf("Unexpected snapshots: %+v", snapshots)
+	}
+}
+
+func TestRemoteClient_NotFound(t *testing.T) {
+	mock, mockCleanup := setupMockExecutor(t)
+	t.Cleanup(mockCleanup)
+	rc := startTestDaemon(t)
+
+	err := rc.Start("missing")
+	if !errors.Is(err, ErrContainerNotFound) {
+		t.Fatalf("Expected ErrContainerNotFound, got %v", err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "not_found" || apiErr.Container != "missing" {
+		t.Errorf("Unexpected error: %+v", apiErr)
+	}
+	if mock.HasCallPrefix("start") {
+		t.Error("Expected no start command")
+	}
+}
+
+func TestRemoteClient_Dial_NoDaemon(t *testing.T) {
+	if _, err := Dial(filepath.Join(t.TempDir(), "missing.sock")); err == nil {
+		t.Error("Expected error dialing a missing socket")
+	}
+}
@@ -22,13 +22,34 @@ func (c *Client) CreateSnapshot(container, name, description string) error {
 		return wrapSnapshotErr("create", container, name, err)
 	}
 
-	c.cfg = cfg
+	c.setConfig(cfg)
 	return nil
 }
 
+// Rebase replaces the container's initial-state snapshot with its current
+// state, so Reset goes back to it from then on
+func (c *Client) Rebase(container string) error {
+	cfg, lock, err := config.LoadWithLock(c.dir)
+	if err != nil {
+		if errors.Is(err, config.ErrNoProject) {
+			return ErrProjectNotFound
+		}
+		return wrapSnapshotErr("rebase", container, "initial-state", err)
+	}
+	defer lock.Release()
+
+	err = operations.Rebase(cfg, container)
+	c.setConfig(cfg)
+	return wrapSnapshotErr("rebase", container, "initial-state", err)
+}
+
 // ListSnapshots returns all snapshots for a container
 func (c *Client) ListSnapshots(container string) ([]SnapshotInfo, error) {
-	snapshots, err := operations.ListSnapshots(c.cfg, container)
+	cfg, err := c.config()
+	if err != nil {
+		return nil, err
+	}
+	snapshots, err := operations.ListSnapshots(cfg, container)
 	if err != nil {
 		return nil, wrapSnapshotErr("list", container, "", err)
 	}
@@ -59,6 +80,36 @@ func (c *Client) DeleteSnapshot(container, name string) error {
 		return wrapSnapshotErr("delete", container, name, err)
 	}
 
-	c.cfg = cfg
+	c.setConfig(cfg)
 	return nil
 }
+
+// CreateSnapshot creates a snapshot of a container of the project in
+// projectDir, without keeping a Client (see Mount)
+func CreateSnapshot(projectDir, container, name, description string) error {
+	c, err := New(projectDir)
+	if err != nil {
+		return err
+	}
+	return c.CreateSnapshot(container, name, description)
+}
+
+// ListSnapshots returns all snapshots of a container of the project in
+// projectDir
+func ListSnapshots(projectDir, container string) ([]SnapshotInfo, error) {
+	c, err := New(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	return c.ListSnapshots(container)
+}
+
+// DeleteSnapshot deletes a snapshot from a container of the project in
+// projectDir
+func DeleteSnapshot(projectDir, container, name string) error {
+	c, err := New(projectDir)
+	if err != nil {
+		return err
+	}
+	return c.DeleteSnapshot(container, name)
+}
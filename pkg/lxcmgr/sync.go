@@ -1,19 +1,23 @@
 package lxcmgr
 
 import (
-	"fmt"
-
 	"lxc-dev-manager/internal/config"
+	"lxc-dev-manager/internal/errcode"
 	"lxc-dev-manager/internal/operations"
 )
 
 // SyncFiles copies all configured sync entries from host to container.
 func (c *Client) SyncFiles(container string) error {
-	return operations.SyncFiles(c.cfg, container, c.dir)
+	cfg, err := c.config()
+	if err != nil {
+		return err
+	}
+	return operations.SyncFiles(cfg, container, c.dir)
 }
 
 // AddSyncEntry adds a file sync entry to a container's configuration.
-// If an entry with the same source already exists, it is overwritten.
+// If an entry with the same source already exists, it is overwritten. An
+// invalid dest or source fails with ErrValidation.
 func (c *Client) AddSyncEntry(container, source, dest string) error {
 	cfg, lock, err := config.LoadWithLock(c.dir)
 	if err != nil {
@@ -21,14 +25,13 @@ func (c *Client) AddSyncEntry(container, source, dest string) error {
 	}
 	defer func() { _ = lock.Release() }()
 
-	cfg.AddSyncEntry(container, config.SyncEntry{
+	if err := operations.AddSyncEntry(cfg, container, config.SyncEntry{
 		Source: source,
 		Dest:   dest,
-	})
-	if err := cfg.Save(); err != nil {
-		return err
+	}); err != nil {
+		return wrapContainerErr("add sync", container, err)
 	}
-	c.cfg = cfg
+	c.setConfig(cfg)
 	return nil
 }
 
@@ -44,17 +47,18 @@ func (c *Client) RemoveSyncEntry(container, source string) error {
 	if err := cfg.Save(); err != nil {
 		return err
 	}
-	c.cfg = cfg
+	c.setConfig(cfg)
 	return nil
 }
 
 // ListSyncEntries returns all sync entries for a container.
 func (c *Client) ListSyncEntries(container string) ([]config.SyncEntry, error) {
-	if c.cfg == nil {
-		return nil, ErrProjectNotFound
+	cfg, err := c.config()
+	if err != nil {
+		return nil, err
 	}
-	if !c.cfg.HasContainer(container) {
-		return nil, fmt.Errorf("container '%s' not found in config", container)
+	if !cfg.HasContainer(container) {
+		return nil, errcode.Errorf(errcode.NotFound, container, "container '%s' not found in config", container)
 	}
-	return c.cfg.GetSyncEntries(container), nil
+	return cfg.GetSyncEntries(container), nil
 }
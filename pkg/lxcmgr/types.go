@@ -1,6 +1,7 @@
 package lxcmgr
 
 import (
+	"strconv"
 	"time"
 )
 
@@ -13,30 +14,49 @@ const (
 	StatusNotFound ContainerStatus = "NOT FOUND"
 )
 
+// Condition is something WaitFor waits to hold inside a container
+type Condition string
+
+const (
+	ConditionNetwork   Condition = "network"    // eth0 has an IPv4 address
+	ConditionSystemd   Condition = "systemd"    // systemctl is-system-running reports running or degraded
+	ConditionCloudInit Condition = "cloud-init" // cloud-init finished, or isn't installed
+)
+
+// PortListening is the condition that something listens on a TCP port
+func PortListening(port int) Condition {
+	return Condition("port:" + strconv.Itoa(port))
+}
+
 // ContainerInfo holds container information
 type ContainerInfo struct {
-	Name   string
-	Image  string
-	Status ContainerStatus
-	IP     string
-	Ports  []int
+	Name        string          `json:"name"`
+	Image       string          `json:"image"`
+	Description string          `json:"description,omitempty"`
+	Status      ContainerStatus `json:"status"`
+	IP          string          `json:"ip,omitempty"`
+	IPv6        string          `json:"ipv6,omitempty"`
+	Ports       []int           `json:"ports"`
+	Snapshots   int             `json:"snapshots"`  // Number of snapshots
+	Mounts      int             `json:"mounts"`     // Number of disk devices
+	DiskUsage   int64           `json:"disk_usage"` // Bytes used by the root disk, 0 if unknown
 }
 
 // SnapshotInfo holds snapshot information
 type SnapshotInfo struct {
-	Name        string
-	Description string
-	CreatedAt   time.Time
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
 }
 
 // MountInfo holds mount information
 type MountInfo struct {
-	Name     string
-	Source   string
-	Path     string
-	ReadOnly bool
-	Shift    bool
-	Status   MountStatus
+	Name     string      `json:"name"`
+	Source   string      `json:"source"`
+	Path     string      `json:"path"`
+	ReadOnly bool        `json:"read_only"`
+	Shift    bool        `json:"shift"`
+	Status   MountStatus `json:"status"`
 }
 
 // MountStatus represents the status of a mount
@@ -48,12 +68,47 @@ const (
 	MountMissing   MountStatus = "missing"
 )
 
+// CopyProgress reports how far a file copy has got
+type CopyProgress struct {
+	Total    int64 // Bytes to copy, 0 if unknown
+	Copied   int64
+	Elapsed  time.Duration
+	ETA      time.Duration // 0 if it can't be estimated
+	Finished bool
+}
+
+// Step reports that a long-running operation started a new step, such as
+// "launching", "waiting for boot" or "creating snapshot"
+type Step struct {
+	Container string // Container being created, cloned or published
+	Name      string
+}
+
 // ImageInfo holds image information
 type ImageInfo struct {
-	Alias       string
-	Fingerprint string
-	Size        string
-	Description string
+	Alias           string
+	Aliases         []string
+	Fingerprint     string
+	Size            string
+	SizeBytes       int64
+	Description     string
+	Architecture    string
+	CreatedAt       time.Time
+	SourceContainer string // Container the image was published from, if known
+}
+
+// ProxyStats holds the connection counters of a forwarded port
+type ProxyStats struct {
+	Container      string    `json:"container"`
+	LocalPort      int       `json:"local_port"`
+	Remote         string    `json:"remote"`    // Container address and port
+	Active         int64     `json:"active"`    // Open connections
+	Total          int64     `json:"total"`     // Connections accepted
+	Rejected       int64     `json:"rejected"`  // Refused at the connection limit
+	Failed         int64     `json:"failed"`    // The container couldn't be reached
+	BytesIn        int64     `json:"bytes_in"`  // From clients to the container
+	BytesOut       int64     `json:"bytes_out"` // From the container to clients
+	LastConnection time.Time `json:"last_connection,omitzero"`
 }
 
 // UserConfig holds user configuration